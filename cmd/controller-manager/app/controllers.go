@@ -22,9 +22,12 @@ import (
 	"k8s.io/klog"
 	iamv1alpha2 "kubesphere.io/kubesphere/pkg/apis/iam/v1alpha2"
 	authoptions "kubesphere.io/kubesphere/pkg/apiserver/authentication/options"
+	"kubesphere.io/kubesphere/pkg/controller/certexpiry"
 	"kubesphere.io/kubesphere/pkg/controller/certificatesigningrequest"
 	"kubesphere.io/kubesphere/pkg/controller/cluster"
 	"kubesphere.io/kubesphere/pkg/controller/clusterrolebinding"
+	"kubesphere.io/kubesphere/pkg/controller/dashboard"
+	"kubesphere.io/kubesphere/pkg/controller/dashboardsource"
 	"kubesphere.io/kubesphere/pkg/controller/destinationrule"
 	"kubesphere.io/kubesphere/pkg/controller/devopscredential"
 	"kubesphere.io/kubesphere/pkg/controller/devopsproject"
@@ -40,12 +43,14 @@ import (
 	"kubesphere.io/kubesphere/pkg/controller/notification"
 	"kubesphere.io/kubesphere/pkg/controller/pipeline"
 	"kubesphere.io/kubesphere/pkg/controller/s2ibinary"
+	"kubesphere.io/kubesphere/pkg/controller/scalingschedule"
 	"kubesphere.io/kubesphere/pkg/controller/s2irun"
 	"kubesphere.io/kubesphere/pkg/controller/storage/capability"
 	"kubesphere.io/kubesphere/pkg/controller/storage/expansion"
 	"kubesphere.io/kubesphere/pkg/controller/user"
 	"kubesphere.io/kubesphere/pkg/controller/virtualservice"
 	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/models/certs"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
 	ldapclient "kubesphere.io/kubesphere/pkg/simple/client/ldap"
@@ -68,6 +73,7 @@ func addControllers(
 	authenticationOptions *authoptions.AuthenticationOptions,
 	multiClusterOptions *multicluster.Options,
 	networkOptions *network.Options,
+	controllerGroup string,
 	serviceMeshEnabled bool,
 	kubectlImage string,
 	stopCh <-chan struct{}) error {
@@ -232,9 +238,18 @@ func addControllers(
 			client.Config(),
 			kubesphereInformer.Cluster().V1alpha1().Clusters(),
 			client.KubeSphere().ClusterV1alpha1().Clusters(),
-			multiClusterOptions.ClusterControllerResyncSecond)
+			multiClusterOptions)
 	}
 
+	certExpiryController := certexpiry.NewController(client.Kubernetes(),
+		certs.NewOperator(client.Kubernetes(), client.Config(), client.KubeSphere()))
+
+	builtinDashboardController := dashboard.NewController(mgr.GetClient())
+
+	scalingScheduleController := scalingschedule.NewController(mgr.GetClient(), client.Kubernetes())
+
+	dashboardSourceController := dashboardsource.NewController(mgr.GetClient(), client.Kubernetes())
+
 	var nsnpController manager.Runnable
 	if networkOptions.EnableNetworkPolicy {
 		nsnpProvider, err := provider.NewNsNetworkPolicyProvider(client.Kubernetes(), kubernetesInformer.Networking().V1().NetworkPolicies())
@@ -268,6 +283,10 @@ func addControllers(
 		"user-controller":               userController,
 		"loginrecord-controller":        loginRecordController,
 		"cluster-controller":            clusterController,
+		"certexpiry-controller":         certExpiryController,
+		"builtin-dashboard-controller":  builtinDashboardController,
+		"scalingschedule-controller":    scalingScheduleController,
+		"dashboardsource-controller":    dashboardSourceController,
 		"nsnp-controller":               nsnpController,
 		"csr-controller":                csrController,
 		"clusterrolebinding-controller": clusterRoleBindingController,
@@ -298,6 +317,11 @@ func addControllers(
 			continue
 		}
 
+		if !groupEnabled(controllerGroup, groupOfController(name)) {
+			klog.V(4).Infof("%s is not going to run, controller group %s is not selected.", name, groupOfController(name))
+			continue
+		}
+
 		if err := mgr.Add(ctrl); err != nil {
 			klog.Error(err, "add controller to manager failed", "name", name)
 			return err
@@ -0,0 +1,69 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+
+	"kubesphere.io/kubesphere/cmd/controller-manager/app/options"
+)
+
+// controllerGroups assigns the named runnables to controller groups, so
+// groups can run in separate deployments with independent leader election.
+// Controllers not listed here belong to the core group.
+var controllerGroups = map[string]string{
+	"user-controller":               options.ControllerGroupIAM,
+	"loginrecord-controller":        options.ControllerGroupIAM,
+	"csr-controller":                options.ControllerGroupIAM,
+	"clusterrolebinding-controller": options.ControllerGroupIAM,
+	"globalrole-controller":         options.ControllerGroupIAM,
+	"globalrolebinding-controller":  options.ControllerGroupIAM,
+	"group-controller":              options.ControllerGroupIAM,
+	"groupbinding-controller":       options.ControllerGroupIAM,
+
+	"s2ibinary-controller":        options.ControllerGroupDevOps,
+	"s2irun-controller":           options.ControllerGroupDevOps,
+	"pipeline-controller":         options.ControllerGroupDevOps,
+	"devopsprojects-controller":   options.ControllerGroupDevOps,
+	"devopscredential-controller": options.ControllerGroupDevOps,
+
+	"nsnp-controller":   options.ControllerGroupNetwork,
+	"ippool-controller": options.ControllerGroupNetwork,
+}
+
+// groupOfController returns the group the named controller belongs to.
+func groupOfController(name string) string {
+	if group, ok := controllerGroups[name]; ok {
+		return group
+	}
+	return options.ControllerGroupCore
+}
+
+// groupEnabled returns true when controllers of the given group should run
+// in the instance running the selected group.
+func groupEnabled(selected, group string) bool {
+	return len(selected) == 0 || selected == options.ControllerGroupAll || selected == group
+}
+
+// leaderElectionID gives each controller group its own leader election lock,
+// so groups running in separate deployments elect leaders independently.
+func leaderElectionID(group string) string {
+	if len(group) == 0 || group == options.ControllerGroupAll {
+		return "ks-controller-manager-leader-election"
+	}
+	return fmt.Sprintf("ks-controller-manager-%s-leader-election", group)
+}
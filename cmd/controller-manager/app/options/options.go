@@ -18,7 +18,9 @@ package options
 
 import (
 	"flag"
+	"fmt"
 	"k8s.io/apimachinery/pkg/labels"
+	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
 	"strings"
 	"time"
 
@@ -28,8 +30,11 @@ import (
 	"k8s.io/klog"
 	authoptions "kubesphere.io/kubesphere/pkg/apiserver/authentication/options"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops/jenkins"
+	"kubesphere.io/kubesphere/pkg/simple/client/harbor"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
 	ldapclient "kubesphere.io/kubesphere/pkg/simple/client/ldap"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring/prometheus"
 	"kubesphere.io/kubesphere/pkg/simple/client/multicluster"
 	"kubesphere.io/kubesphere/pkg/simple/client/network"
 	"kubesphere.io/kubesphere/pkg/simple/client/openpitrix"
@@ -37,6 +42,28 @@ import (
 	"kubesphere.io/kubesphere/pkg/simple/client/servicemesh"
 )
 
+// Controller groups ks-controller-manager can be split into. Each group can
+// run in its own deployment with independent leader election, so one
+// misbehaving controller group cannot take down all reconciliation.
+const (
+	ControllerGroupAll        = "all"
+	ControllerGroupCore       = "core"
+	ControllerGroupIAM        = "iam"
+	ControllerGroupDevOps     = "devops"
+	ControllerGroupNetwork    = "network"
+	ControllerGroupMonitoring = "monitoring"
+)
+
+// ControllerGroups lists all valid controller groups.
+var ControllerGroups = []string{
+	ControllerGroupAll,
+	ControllerGroupCore,
+	ControllerGroupIAM,
+	ControllerGroupDevOps,
+	ControllerGroupNetwork,
+	ControllerGroupMonitoring,
+}
+
 type KubeSphereControllerManagerOptions struct {
 	KubernetesOptions     *k8s.KubernetesOptions
 	DevopsOptions         *jenkins.Options
@@ -47,6 +74,9 @@ type KubeSphereControllerManagerOptions struct {
 	NetworkOptions        *network.Options
 	MultiClusterOptions   *multicluster.Options
 	ServiceMeshOptions    *servicemesh.Options
+	MonitoringOptions     *prometheus.Options
+	HarborOptions         *harbor.Options
+	LoggingOptions        *logging.Options
 	LeaderElect           bool
 	LeaderElection        *leaderelection.LeaderElectionConfig
 	WebhookCertDir        string
@@ -59,6 +89,17 @@ type KubeSphereControllerManagerOptions struct {
 	//      "kubesphere.io/creator=" means reconcile applications with this label key
 	//      "!kubesphere.io/creator" means exclude applications with this key
 	ApplicationSelector string
+
+	// ControllerGroup selects which controller group this instance runs,
+	// defaults to all. Running groups in separate deployments isolates them
+	// from each other and gives each group its own leader election.
+	ControllerGroup string
+
+	// NamespaceShards and NamespaceShardIndex optionally split namespaced
+	// reconciliation across several deployments of the same controller group
+	// by namespace hash. Sharding is disabled when NamespaceShards < 2.
+	NamespaceShards     int
+	NamespaceShardIndex int
 }
 
 func NewKubeSphereControllerManagerOptions() *KubeSphereControllerManagerOptions {
@@ -71,6 +112,9 @@ func NewKubeSphereControllerManagerOptions() *KubeSphereControllerManagerOptions
 		NetworkOptions:        network.NewNetworkOptions(),
 		MultiClusterOptions:   multicluster.NewOptions(),
 		ServiceMeshOptions:    servicemesh.NewServiceMeshOptions(),
+		MonitoringOptions:     prometheus.NewPrometheusOptions(),
+		HarborOptions:         harbor.NewHarborOptions(),
+		LoggingOptions:        logging.NewLoggingOptions(),
 		AuthenticationOptions: authoptions.NewAuthenticateOptions(),
 		LeaderElection: &leaderelection.LeaderElectionConfig{
 			LeaseDuration: 30 * time.Second,
@@ -80,6 +124,7 @@ func NewKubeSphereControllerManagerOptions() *KubeSphereControllerManagerOptions
 		LeaderElect:         false,
 		WebhookCertDir:      "",
 		ApplicationSelector: "",
+		ControllerGroup:     ControllerGroupAll,
 	}
 
 	return s
@@ -97,6 +142,9 @@ func (s *KubeSphereControllerManagerOptions) Flags() cliflag.NamedFlagSets {
 	s.NetworkOptions.AddFlags(fss.FlagSet("network"), s.NetworkOptions)
 	s.MultiClusterOptions.AddFlags(fss.FlagSet("multicluster"), s.MultiClusterOptions)
 	s.ServiceMeshOptions.AddFlags(fss.FlagSet("servicemesh"), s.ServiceMeshOptions)
+	s.MonitoringOptions.AddFlags(fss.FlagSet("monitoring"), s.MonitoringOptions)
+	s.HarborOptions.AddFlags(fss.FlagSet("harbor"), s.HarborOptions)
+	s.LoggingOptions.AddFlags(fss.FlagSet("logging"), s.LoggingOptions)
 
 	fs := fss.FlagSet("leaderelection")
 	s.bindLeaderElectionFlags(s.LeaderElection, fs)
@@ -115,6 +163,18 @@ func (s *KubeSphereControllerManagerOptions) Flags() cliflag.NamedFlagSets {
 		"Only reconcile application(sigs.k8s.io/application) objects match given selector, this could avoid conflicts with "+
 		"other projects built on top of sig-application. Default behavior is to reconcile all of application objects.")
 
+	gfs.StringVar(&s.ControllerGroup, "controller-group", s.ControllerGroup, ""+
+		"Controller group this instance runs, one of "+strings.Join(ControllerGroups, ", ")+". "+
+		"Groups can run in separate deployments with independent leader election, so one "+
+		"misbehaving controller cannot take down all reconciliation. Defaults to all.")
+
+	gfs.IntVar(&s.NamespaceShards, "namespace-shards", s.NamespaceShards, ""+
+		"Total number of deployments namespaced reconciliation is sharded across by namespace hash. "+
+		"Sharding is disabled when fewer than two shards are configured.")
+
+	gfs.IntVar(&s.NamespaceShardIndex, "namespace-shard-index", s.NamespaceShardIndex, ""+
+		"Shard index of this deployment, must be in [0, namespace-shards) when sharding is enabled.")
+
 	kfs := fss.FlagSet("klog")
 	local := flag.NewFlagSet("klog", flag.ExitOnError)
 	klog.InitFlags(local)
@@ -142,9 +202,25 @@ func (s *KubeSphereControllerManagerOptions) Validate() []error {
 		}
 	}
 
+	if len(s.ControllerGroup) != 0 && !sliceutil.HasString(ControllerGroups, s.ControllerGroup) {
+		errs = append(errs, fmt.Errorf("invalid controller group %s, must be one of %s",
+			s.ControllerGroup, strings.Join(ControllerGroups, ", ")))
+	}
+
+	if s.NamespaceShards > 1 && (s.NamespaceShardIndex < 0 || s.NamespaceShardIndex >= s.NamespaceShards) {
+		errs = append(errs, fmt.Errorf("namespace shard index %d out of range, must be in [0, %d)",
+			s.NamespaceShardIndex, s.NamespaceShards))
+	}
+
 	return errs
 }
 
+// GroupEnabled returns true when controllers of the given group should run
+// in this instance.
+func (s *KubeSphereControllerManagerOptions) GroupEnabled(group string) bool {
+	return len(s.ControllerGroup) == 0 || s.ControllerGroup == ControllerGroupAll || s.ControllerGroup == group
+}
+
 func (s *KubeSphereControllerManagerOptions) bindLeaderElectionFlags(l *leaderelection.LeaderElectionConfig, fs *pflag.FlagSet) {
 	fs.DurationVar(&l.LeaseDuration, "leader-elect-lease-duration", l.LeaseDuration, ""+
 		"The duration that non-leader candidates will wait after observing a leadership "+
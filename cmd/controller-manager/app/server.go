@@ -29,15 +29,35 @@ import (
 	"kubesphere.io/kubesphere/pkg/apis"
 	controllerconfig "kubesphere.io/kubesphere/pkg/apiserver/config"
 	"kubesphere.io/kubesphere/pkg/controller/application"
+	"kubesphere.io/kubesphere/pkg/controller/configuration"
+	"kubesphere.io/kubesphere/pkg/controller/defaulting"
+	harborcontroller "kubesphere.io/kubesphere/pkg/controller/harbor"
+	"kubesphere.io/kubesphere/pkg/controller/imagepolicy"
+	"kubesphere.io/kubesphere/pkg/controller/inspection"
+	installercontroller "kubesphere.io/kubesphere/pkg/controller/installer"
+	"kubesphere.io/kubesphere/pkg/controller/logging/indexstrategy"
+	"kubesphere.io/kubesphere/pkg/controller/logging/maskingpolicy"
+	"kubesphere.io/kubesphere/pkg/controller/logging/throttlepolicy"
+	"kubesphere.io/kubesphere/pkg/controller/metering/reportschedule"
 	"kubesphere.io/kubesphere/pkg/controller/namespace"
 	"kubesphere.io/kubesphere/pkg/controller/network/webhooks"
+	"kubesphere.io/kubesphere/pkg/controller/nodegroup"
+	"kubesphere.io/kubesphere/pkg/controller/notification/dispatch"
+	"kubesphere.io/kubesphere/pkg/controller/notification/eventrule"
 	"kubesphere.io/kubesphere/pkg/controller/openpitrix/helmapplication"
 	"kubesphere.io/kubesphere/pkg/controller/openpitrix/helmcategory"
 	"kubesphere.io/kubesphere/pkg/controller/openpitrix/helmrelease"
 	"kubesphere.io/kubesphere/pkg/controller/openpitrix/helmrepo"
+	"kubesphere.io/kubesphere/pkg/controller/projectrequest"
 	"kubesphere.io/kubesphere/pkg/controller/quota"
+	"kubesphere.io/kubesphere/pkg/controller/quota/forecast"
+	"kubesphere.io/kubesphere/pkg/controller/rollout"
 	"kubesphere.io/kubesphere/pkg/controller/serviceaccount"
+	"kubesphere.io/kubesphere/pkg/controller/storage/pvcautoresizer"
+	"kubesphere.io/kubesphere/pkg/controller/storage/snapshotpolicy"
 	"kubesphere.io/kubesphere/pkg/controller/user"
+	"kubesphere.io/kubesphere/pkg/controller/utils/sharding"
+	"kubesphere.io/kubesphere/pkg/controller/vault"
 	"kubesphere.io/kubesphere/pkg/controller/workspace"
 	"kubesphere.io/kubesphere/pkg/controller/workspacerole"
 	"kubesphere.io/kubesphere/pkg/controller/workspacerolebinding"
@@ -45,8 +65,10 @@ import (
 	"kubesphere.io/kubesphere/pkg/informers"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops/jenkins"
+	"kubesphere.io/kubesphere/pkg/simple/client/harbor"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
 	ldapclient "kubesphere.io/kubesphere/pkg/simple/client/ldap"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring/prometheus"
 	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 	"kubesphere.io/kubesphere/pkg/utils/metrics"
 	"kubesphere.io/kubesphere/pkg/utils/term"
@@ -72,6 +94,9 @@ func NewControllerManagerCommand() *cobra.Command {
 			NetworkOptions:        conf.NetworkOptions,
 			MultiClusterOptions:   conf.MultiClusterOptions,
 			ServiceMeshOptions:    conf.ServiceMeshOptions,
+			MonitoringOptions:     conf.MonitoringOptions,
+			HarborOptions:         conf.HarborOptions,
+			LoggingOptions:        conf.LoggingOptions,
 			LeaderElection:        s.LeaderElection,
 			LeaderElect:           s.LeaderElect,
 			WebhookCertDir:        s.WebhookCertDir,
@@ -152,6 +177,14 @@ func run(s *options.KubeSphereControllerManagerOptions, stopCh <-chan struct{})
 		}
 	}
 
+	var harborClient harbor.Interface
+	if s.HarborOptions != nil && len(s.HarborOptions.Host) != 0 {
+		harborClient, err = harbor.NewHarborClient(s.HarborOptions)
+		if err != nil {
+			return fmt.Errorf("failed to create harbor client, error: %v", err)
+		}
+	}
+
 	informerFactory := informers.NewInformerFactories(
 		kubernetesClient.Kubernetes(),
 		kubernetesClient.KubeSphere(),
@@ -171,7 +204,7 @@ func run(s *options.KubeSphereControllerManagerOptions, stopCh <-chan struct{})
 			Port:                    8443,
 			LeaderElection:          s.LeaderElect,
 			LeaderElectionNamespace: "kubesphere-system",
-			LeaderElectionID:        "ks-controller-manager-leader-election",
+			LeaderElectionID:        leaderElectionID(s.ControllerGroup),
 			LeaseDuration:           &s.LeaderElection.LeaseDuration,
 			RetryPeriod:             &s.LeaderElection.RetryPeriod,
 			RenewDeadline:           &s.LeaderElection.RenewDeadline,
@@ -193,88 +226,192 @@ func run(s *options.KubeSphereControllerManagerOptions, stopCh <-chan struct{})
 	// register common meta types into schemas.
 	metav1.AddToGroupVersion(mgr.GetScheme(), metav1.SchemeGroupVersion)
 
-	workspaceTemplateReconciler := &workspacetemplate.Reconciler{MultiClusterEnabled: s.MultiClusterOptions.Enable}
-	if err = workspaceTemplateReconciler.SetupWithManager(mgr); err != nil {
-		klog.Fatalf("Unable to create workspace template controller: %v", err)
-	}
+	// controllers of the core group, other groups can run in separate
+	// deployments with their own leader election
+	if s.GroupEnabled(options.ControllerGroupCore) {
+		workspaceTemplateReconciler := &workspacetemplate.Reconciler{MultiClusterEnabled: s.MultiClusterOptions.Enable}
+		if err = workspaceTemplateReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create workspace template controller: %v", err)
+		}
 
-	workspaceReconciler := &workspace.Reconciler{}
-	if err = workspaceReconciler.SetupWithManager(mgr); err != nil {
-		klog.Fatalf("Unable to create workspace controller: %v", err)
-	}
+		workspaceReconciler := &workspace.Reconciler{}
+		if err = workspaceReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create workspace controller: %v", err)
+		}
 
-	workspaceRoleReconciler := &workspacerole.Reconciler{MultiClusterEnabled: s.MultiClusterOptions.Enable}
-	if err = workspaceRoleReconciler.SetupWithManager(mgr); err != nil {
-		klog.Fatalf("Unable to create workspace role controller: %v", err)
-	}
+		if harborClient != nil {
+			harborReconciler := &harborcontroller.Reconciler{HarborClient: harborClient, RegistryHost: s.HarborOptions.Host}
+			if err = harborReconciler.SetupWithManager(mgr); err != nil {
+				klog.Fatalf("Unable to create harbor controller: %v", err)
+			}
+		}
 
-	workspaceRoleBindingReconciler := &workspacerolebinding.Reconciler{MultiClusterEnabled: s.MultiClusterOptions.Enable}
-	if err = workspaceRoleBindingReconciler.SetupWithManager(mgr); err != nil {
-		klog.Fatalf("Unable to create workspace role binding controller: %v", err)
-	}
+		secretBindingReconciler := &vault.Reconciler{}
+		if err = secretBindingReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create secretbinding controller: %v", err)
+		}
 
-	namespaceReconciler := &namespace.Reconciler{}
-	if err = namespaceReconciler.SetupWithManager(mgr); err != nil {
-		klog.Fatalf("Unable to create namespace controller: %v", err)
-	}
+		workspaceRoleReconciler := &workspacerole.Reconciler{MultiClusterEnabled: s.MultiClusterOptions.Enable}
+		if err = workspaceRoleReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create workspace role controller: %v", err)
+		}
 
-	err = helmrepo.Add(mgr)
-	if err != nil {
-		klog.Fatal("Unable to create helm repo controller")
-	}
+		workspaceRoleBindingReconciler := &workspacerolebinding.Reconciler{MultiClusterEnabled: s.MultiClusterOptions.Enable}
+		if err = workspaceRoleBindingReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create workspace role binding controller: %v", err)
+		}
 
-	err = helmcategory.Add(mgr)
-	if err != nil {
-		klog.Fatal("Unable to create helm category controller")
-	}
+		namespaceReconciler := &namespace.Reconciler{
+			SnapshotClient: kubernetesClient.Snapshot(),
+			S3Client:       s3Client,
+			Sharding:       sharding.New(s.NamespaceShards, s.NamespaceShardIndex),
+		}
+		if err = namespaceReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create namespace controller: %v", err)
+		}
 
-	var opS3Client s3.Interface
-	if !s.OpenPitrixOptions.AppStoreConfIsEmpty() {
-		opS3Client, err = s3.NewS3Client(s.OpenPitrixOptions.S3Options)
+		projectRequestReconciler := &projectrequest.Reconciler{}
+		if err = projectRequestReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create project request controller: %v", err)
+		}
+
+		eventRuleReconciler := &eventrule.Reconciler{}
+		if err = eventRuleReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create event rule controller: %v", err)
+		}
+
+		dispatchReconciler := &dispatch.Reconciler{}
+		if err = dispatchReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create notification dispatch controller: %v", err)
+		}
+
+		throttlePolicyReconciler := &throttlepolicy.Reconciler{}
+		if err = throttlePolicyReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create log throttle policy controller: %v", err)
+		}
+
+		indexStrategyReconciler := &indexstrategy.Reconciler{}
+		if s.LoggingOptions != nil {
+			indexStrategyReconciler.ElasticsearchHost = s.LoggingOptions.Host
+		}
+		if err = indexStrategyReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create log index strategy controller: %v", err)
+		}
+
+		maskingPolicyReconciler := &maskingpolicy.Reconciler{}
+		if err = maskingPolicyReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create log masking policy controller: %v", err)
+		}
+
+		configMapRevisionReconciler := &configuration.ConfigMapReconciler{}
+		if err = configMapRevisionReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create configmap revision controller: %v", err)
+		}
+
+		secretRevisionReconciler := &configuration.SecretReconciler{}
+		if err = secretRevisionReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create secret revision controller: %v", err)
+		}
+
+		clusterInspectionReconciler := &inspection.Reconciler{}
+		if err = clusterInspectionReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create cluster inspection controller: %v", err)
+		}
+
+		err = helmrepo.Add(mgr)
 		if err != nil {
-			klog.Fatalf("failed to connect to s3, please check openpitrix s3 service status, error: %v", err)
+			klog.Fatal("Unable to create helm repo controller")
 		}
-		err = (&helmapplication.ReconcileHelmApplication{}).SetupWithManager(mgr)
+
+		err = helmcategory.Add(mgr)
 		if err != nil {
-			klog.Fatalf("Unable to create helm application controller, error: %s", err)
+			klog.Fatal("Unable to create helm category controller")
+		}
+
+		var opS3Client s3.Interface
+		if !s.OpenPitrixOptions.AppStoreConfIsEmpty() {
+			opS3Client, err = s3.NewS3Client(s.OpenPitrixOptions.S3Options)
+			if err != nil {
+				klog.Fatalf("failed to connect to s3, please check openpitrix s3 service status, error: %v", err)
+			}
+			err = (&helmapplication.ReconcileHelmApplication{}).SetupWithManager(mgr)
+			if err != nil {
+				klog.Fatalf("Unable to create helm application controller, error: %s", err)
+			}
+
+			err = (&helmapplication.ReconcileHelmApplicationVersion{}).SetupWithManager(mgr)
+			if err != nil {
+				klog.Fatalf("Unable to create helm application version controller, error: %s ", err)
+			}
 		}
 
-		err = (&helmapplication.ReconcileHelmApplicationVersion{}).SetupWithManager(mgr)
+		err = (&helmrelease.ReconcileHelmRelease{
+			// nil interface is valid value.
+			StorageClient:      opS3Client,
+			KsFactory:          informerFactory.KubeSphereSharedInformerFactory(),
+			MultiClusterEnable: s.MultiClusterOptions.Enable,
+		}).SetupWithManager(mgr)
+
 		if err != nil {
-			klog.Fatalf("Unable to create helm application version controller, error: %s ", err)
+			klog.Fatalf("Unable to create helm release controller, error: %s", err)
+		}
+
+		selector, _ := labels.Parse(s.ApplicationSelector)
+		applicationReconciler := &application.ApplicationReconciler{
+			Scheme:              mgr.GetScheme(),
+			Client:              mgr.GetClient(),
+			Mapper:              mgr.GetRESTMapper(),
+			ApplicationSelector: selector,
+		}
+		if err = applicationReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create application controller: %v", err)
 		}
-	}
 
-	err = (&helmrelease.ReconcileHelmRelease{
-		// nil interface is valid value.
-		StorageClient:      opS3Client,
-		KsFactory:          informerFactory.KubeSphereSharedInformerFactory(),
-		MultiClusterEnable: s.MultiClusterOptions.Enable,
-	}).SetupWithManager(mgr)
+		saReconciler := &serviceaccount.Reconciler{}
+		if err = saReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create ServiceAccount controller: %v", err)
+		}
 
-	if err != nil {
-		klog.Fatalf("Unable to create helm release controller, error: %s", err)
+		snapshotPolicyReconciler := &snapshotpolicy.Reconciler{SnapshotClient: kubernetesClient.Snapshot()}
+		if err = snapshotPolicyReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create SnapshotPolicy controller: %v", err)
+		}
 	}
 
-	selector, _ := labels.Parse(s.ApplicationSelector)
-	applicationReconciler := &application.ApplicationReconciler{
-		Scheme:              mgr.GetScheme(),
-		Client:              mgr.GetClient(),
-		Mapper:              mgr.GetRESTMapper(),
-		ApplicationSelector: selector,
-	}
-	if err = applicationReconciler.SetupWithManager(mgr); err != nil {
-		klog.Fatalf("Unable to create application controller: %v", err)
-	}
+	if s.GroupEnabled(options.ControllerGroupMonitoring) && s.MonitoringOptions != nil && len(s.MonitoringOptions.Endpoint) != 0 {
+		monitoringClient, err := prometheus.NewPrometheus(s.MonitoringOptions)
+		if err != nil {
+			klog.Fatalf("Failed to connect to prometheus, please check prometheus status, error: %v", err)
+		}
+		pvcAutoResizer := &pvcautoresizer.Reconciler{MonitoringClient: monitoringClient}
+		if err = pvcAutoResizer.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create PVC autoresizer controller: %v", err)
+		}
 
-	saReconciler := &serviceaccount.Reconciler{}
-	if err = saReconciler.SetupWithManager(mgr); err != nil {
-		klog.Fatalf("Unable to create ServiceAccount controller: %v", err)
+		// nil S3 client is a valid value, s3 delivery is then rejected at run time
+		reportScheduleReconciler := &reportschedule.Reconciler{MonitoringClient: monitoringClient, S3Client: s3Client}
+		if err = reportScheduleReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create ReportSchedule controller: %v", err)
+		}
+
+		quotaForecastReconciler := &forecast.Reconciler{MonitoringClient: monitoringClient}
+		if err = quotaForecastReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create quota forecast controller: %v", err)
+		}
+
+		rolloutReconciler := &rollout.Reconciler{MonitoringClient: monitoringClient}
+		if err = rolloutReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatalf("Unable to create rollout controller: %v", err)
+		}
+	} else {
+		klog.Warning("ks-controller-manager starts without monitoring endpoint provided, PVC autoresizing, metering reports and rollout analytics are disabled")
 	}
 
-	resourceQuotaReconciler := quota.Reconciler{}
-	if err := resourceQuotaReconciler.SetupWithManager(mgr, quota.DefaultMaxConcurrentReconciles, quota.DefaultResyncPeriod, informerFactory.KubernetesSharedInformerFactory()); err != nil {
-		klog.Fatalf("Unable to create ResourceQuota controller: %v", err)
+	if s.GroupEnabled(options.ControllerGroupCore) {
+		resourceQuotaReconciler := quota.Reconciler{}
+		if err := resourceQuotaReconciler.SetupWithManager(mgr, quota.DefaultMaxConcurrentReconciles, quota.DefaultResyncPeriod, informerFactory.KubernetesSharedInformerFactory()); err != nil {
+			klog.Fatalf("Unable to create ResourceQuota controller: %v", err)
+		}
 	}
 
 	// TODO(jeff): refactor config with CRD
@@ -289,6 +426,7 @@ func run(s *options.KubeSphereControllerManagerOptions, stopCh <-chan struct{})
 		s.AuthenticationOptions,
 		s.MultiClusterOptions,
 		s.NetworkOptions,
+		s.ControllerGroup,
 		servicemeshEnabled,
 		s.AuthenticationOptions.KubectlImage, stopCh); err != nil {
 		klog.Fatalf("unable to register controllers to the manager: %v", err)
@@ -303,15 +441,41 @@ func run(s *options.KubeSphereControllerManagerOptions, stopCh <-chan struct{})
 	hookServer := mgr.GetWebhookServer()
 
 	klog.V(2).Info("registering webhooks to the webhook server")
-	hookServer.Register("/validate-email-iam-kubesphere-io-v1alpha2", &webhook.Admission{Handler: &user.EmailValidator{Client: mgr.GetClient()}})
-	hookServer.Register("/validate-network-kubesphere-io-v1alpha1", &webhook.Admission{Handler: &webhooks.ValidatingHandler{C: mgr.GetClient()}})
-	hookServer.Register("/mutate-network-kubesphere-io-v1alpha1", &webhook.Admission{Handler: &webhooks.MutatingHandler{C: mgr.GetClient()}})
+	if s.GroupEnabled(options.ControllerGroupCore) {
+		hookServer.Register("/validate-email-iam-kubesphere-io-v1alpha2", &webhook.Admission{Handler: &user.EmailValidator{Client: mgr.GetClient()}})
+		hookServer.Register("/validate-network-kubesphere-io-v1alpha1", &webhook.Admission{Handler: &webhooks.ValidatingHandler{C: mgr.GetClient()}})
+		hookServer.Register("/mutate-network-kubesphere-io-v1alpha1", &webhook.Admission{Handler: &webhooks.MutatingHandler{C: mgr.GetClient()}})
 
-	resourceQuotaAdmission, err := quota.NewResourceQuotaAdmission(mgr.GetClient(), mgr.GetScheme())
-	if err != nil {
-		klog.Fatalf("unable to create resource quota admission: %v", err)
+		resourceQuotaAdmission, err := quota.NewResourceQuotaAdmission(mgr.GetClient(), mgr.GetScheme())
+		if err != nil {
+			klog.Fatalf("unable to create resource quota admission: %v", err)
+		}
+		hookServer.Register("/validate-quota-kubesphere-io-v1alpha2", &webhook.Admission{Handler: resourceQuotaAdmission})
+
+		workloadDefaultingAdmission, err := defaulting.NewWorkloadDefaultingAdmission(mgr.GetClient(), mgr.GetScheme())
+		if err != nil {
+			klog.Fatalf("unable to create workload defaulting admission: %v", err)
+		}
+		hookServer.Register("/mutate-defaulting-kubesphere-io-v1alpha1", &webhook.Admission{Handler: workloadDefaultingAdmission})
+
+		imagePolicyAdmission, err := imagepolicy.NewImagePolicyAdmission(mgr.GetClient(), mgr.GetScheme())
+		if err != nil {
+			klog.Fatalf("unable to create image policy admission: %v", err)
+		}
+		hookServer.Register("/validate-imagepolicy-kubesphere-io-v1alpha1", &webhook.Admission{Handler: imagePolicyAdmission})
+
+		nodeGroupAdmission, err := nodegroup.NewNodeGroupAdmission(mgr.GetClient(), mgr.GetScheme())
+		if err != nil {
+			klog.Fatalf("unable to create node group admission: %v", err)
+		}
+		hookServer.Register("/mutate-nodegroup-kubesphere-io-v1alpha1", &webhook.Admission{Handler: nodeGroupAdmission})
+
+		clusterConfigurationAdmission, err := installercontroller.NewClusterConfigurationAdmission(mgr.GetScheme())
+		if err != nil {
+			klog.Fatalf("unable to create cluster configuration admission: %v", err)
+		}
+		hookServer.Register("/validate-installer-kubesphere-io-v1alpha1", &webhook.Admission{Handler: clusterConfigurationAdmission})
 	}
-	hookServer.Register("/validate-quota-kubesphere-io-v1alpha2", &webhook.Admission{Handler: resourceQuotaAdmission})
 
 	klog.V(2).Info("registering metrics to the webhook server")
 	// Add an extra metric endpoint, so we can use the the same metric definition with ks-apiserver
@@ -32,9 +32,12 @@ import (
 	auditingclient "kubesphere.io/kubesphere/pkg/simple/client/auditing/elasticsearch"
 	"kubesphere.io/kubesphere/pkg/simple/client/cache"
 	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"kubesphere.io/kubesphere/pkg/simple/client/devops/jenkins"
+	"kubesphere.io/kubesphere/pkg/simple/client/etcd"
 	eventsclient "kubesphere.io/kubesphere/pkg/simple/client/events/elasticsearch"
+	"kubesphere.io/kubesphere/pkg/simple/client/harbor"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
 	esclient "kubesphere.io/kubesphere/pkg/simple/client/logging/elasticsearch"
 	"kubesphere.io/kubesphere/pkg/simple/client/monitoring/metricsserver"
@@ -84,6 +87,8 @@ func (s *ServerRunOptions) Flags() (fss cliflag.NamedFlagSets) {
 	s.EventsOptions.AddFlags(fss.FlagSet("events"), s.EventsOptions)
 	s.AuditingOptions.AddFlags(fss.FlagSet("auditing"), s.AuditingOptions)
 	s.AlertingOptions.AddFlags(fss.FlagSet("alerting"), s.AlertingOptions)
+	s.HarborOptions.AddFlags(fss.FlagSet("harbor"), s.HarborOptions)
+	s.EtcdOptions.AddFlags(fss.FlagSet("etcd"), s.EtcdOptions)
 
 	fs = fss.FlagSet("klog")
 	local := flag.NewFlagSet("klog", flag.ExitOnError)
@@ -162,6 +167,22 @@ func (s *ServerRunOptions) NewAPIServer(stopCh <-chan struct{}) (*apiserver.APIS
 		apiServer.SonarClient = sonarqube.NewSonar(sonarClient.SonarQube())
 	}
 
+	if s.HarborOptions != nil && s.HarborOptions.Host != "" {
+		harborClient, err := harbor.NewHarborClient(s.HarborOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create harbor client, error: %v", err)
+		}
+		apiServer.HarborClient = harborClient
+	}
+
+	if s.EtcdOptions != nil && len(s.EtcdOptions.Endpoints) > 0 {
+		etcdClient, err := etcd.NewEtcdClient(s.EtcdOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create etcd client, error: %v", err)
+		}
+		apiServer.EtcdClient = etcdClient
+	}
+
 	var cacheClient cache.Interface
 	if s.RedisOptions != nil && len(s.RedisOptions.Host) != 0 {
 		if s.RedisOptions.Host == fakeInterface && s.DebugMode {
@@ -225,6 +246,11 @@ func (s *ServerRunOptions) NewAPIServer(stopCh <-chan struct{}) (*apiserver.APIS
 		klog.Fatalf("unable to create runtime cache: %v", err)
 	}
 
+	apiServer.RuntimeClient, err = runtimeclient.New(apiServer.KubernetesClient.Config(), runtimeclient.Options{Scheme: sch})
+	if err != nil {
+		klog.Fatalf("unable to create runtime client: %v", err)
+	}
+
 	apiServer.Server = server
 
 	return apiServer, nil
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/signals"
+
+	"kubesphere.io/kubesphere/pkg/apis"
+	"kubesphere.io/kubesphere/pkg/nodeprofile"
+)
+
+func main() {
+	klog.InitFlags(nil)
+	nodeName := flag.String("node-name", os.Getenv("NODE_NAME"), "name of the node the agent runs on, defaults to the NODE_NAME environment variable")
+	dropInFile := flag.String("kubelet-drop-in-file", nodeprofile.DefaultKubeletDropInFile, "path the kubelet settings of the profile are rendered to")
+	syncPeriod := flag.Duration("sync-period", nodeprofile.DefaultSyncPeriod, "how often the profile is re-applied")
+	flag.Parse()
+
+	cfg, err := config.GetConfig()
+	if err != nil {
+		klog.Fatalf("unable to load kubeconfig: %v", err)
+	}
+
+	if err := apis.AddToScheme(scheme.Scheme); err != nil {
+		klog.Fatalf("unable to add APIs to scheme: %v", err)
+	}
+
+	c, err := client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		klog.Fatalf("unable to create client: %v", err)
+	}
+
+	agent := &nodeprofile.Agent{
+		Client:            c,
+		NodeName:          *nodeName,
+		KubeletDropInFile: *dropInFile,
+		SyncPeriod:        *syncPeriod,
+	}
+	if err := agent.Run(signals.SetupSignalHandler()); err != nil {
+		klog.Fatal(err)
+	}
+}
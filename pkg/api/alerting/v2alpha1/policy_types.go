@@ -0,0 +1,141 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2alpha1
+
+import (
+	"github.com/pkg/errors"
+	prommodel "github.com/prometheus/common/model"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// GroupByAll is the special group by value collapsing nothing, every label
+// set forms its own group.
+const GroupByAll = "..."
+
+// GroupingConfig controls how alerts are batched into notifications, mapping
+// to the group_by, group_wait, group_interval and repeat_interval settings of
+// an alertmanager route.
+type GroupingConfig struct {
+	GroupBy        []string `json:"groupBy,omitempty" description:"labels alerts are grouped by, e.g. [alertname, namespace]; the special value ... disables grouping"`
+	GroupWait      string   `json:"groupWait,omitempty" description:"how long to wait before sending the first notification of a new group, which must match ^([0-9]+)(y|w|d|h|m|s|ms)$"`
+	GroupInterval  string   `json:"groupInterval,omitempty" description:"how long to wait before notifying about new alerts added to a group"`
+	RepeatInterval string   `json:"repeatInterval,omitempty" description:"how long to wait before re-sending a notification for a group"`
+}
+
+func (c *GroupingConfig) Validate() error {
+	errs := []error{}
+
+	for _, name := range c.GroupBy {
+		if name == GroupByAll {
+			if len(c.GroupBy) > 1 {
+				errs = append(errs, errors.Errorf("the special group by value %s can not be combined with labels", GroupByAll))
+			}
+			continue
+		}
+		if !prommodel.LabelName(name).IsValid() {
+			errs = append(errs, errors.Errorf("invalid group by label name: %s", name))
+		}
+	}
+
+	for field, value := range map[string]string{
+		"groupWait":      c.GroupWait,
+		"groupInterval":  c.GroupInterval,
+		"repeatInterval": c.RepeatInterval,
+	} {
+		if value == "" {
+			continue
+		}
+		if _, err := prommodel.ParseDuration(value); err != nil {
+			errs = append(errs, errors.Wrapf(err, "%s is invalid: %s", field, value))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// InhibitionRule mutes the alerts matching the target matchers while an alert
+// matching the source matchers is firing and both agree on the equal labels.
+// The typical use is muting the per-pod alerts of a node while the node down
+// alert fires.
+type InhibitionRule struct {
+	Name        string            `json:"name" description:"rule name, which must be unique within the policy"`
+	SourceMatch map[string]string `json:"sourceMatch" description:"label values the firing alert must match for the rule to take effect, e.g. alertname: NodeDown"`
+	TargetMatch map[string]string `json:"targetMatch" description:"label values of the alerts to mute, e.g. severity: warning"`
+	Equal       []string          `json:"equal,omitempty" description:"labels which must be equal between the source and the target alerts, e.g. [node]"`
+}
+
+func (r *InhibitionRule) Validate() error {
+	errs := []error{}
+
+	if r.Name == "" {
+		errs = append(errs, errors.New("name can not be empty"))
+	}
+	if len(r.SourceMatch) == 0 {
+		errs = append(errs, errors.New("sourceMatch can not be empty"))
+	}
+	if len(r.TargetMatch) == 0 {
+		errs = append(errs, errors.New("targetMatch can not be empty"))
+	}
+	for name := range r.SourceMatch {
+		if !prommodel.LabelName(name).IsValid() {
+			errs = append(errs, errors.Errorf("invalid sourceMatch label name: %s", name))
+		}
+	}
+	for name := range r.TargetMatch {
+		if !prommodel.LabelName(name).IsValid() {
+			errs = append(errs, errors.Errorf("invalid targetMatch label name: %s", name))
+		}
+	}
+	for _, name := range r.Equal {
+		if !prommodel.LabelName(name).IsValid() {
+			errs = append(errs, errors.Errorf("invalid equal label name: %s", name))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// AlertingPolicy is the grouping and inhibition configuration of a scope,
+// cluster wide or of one namespace.
+type AlertingPolicy struct {
+	Grouping    *GroupingConfig  `json:"grouping,omitempty"`
+	Inhibitions []InhibitionRule `json:"inhibitions,omitempty"`
+}
+
+func (p *AlertingPolicy) Validate() error {
+	errs := []error{}
+
+	if p.Grouping != nil {
+		if err := p.Grouping.Validate(); err != nil {
+			errs = append(errs, errors.Wrap(err, "grouping is invalid"))
+		}
+	}
+	names := make(map[string]bool, len(p.Inhibitions))
+	for i := range p.Inhibitions {
+		if err := p.Inhibitions[i].Validate(); err != nil {
+			errs = append(errs, errors.Wrapf(err, "inhibition[%d] is invalid", i))
+		}
+		if name := p.Inhibitions[i].Name; name != "" {
+			if names[name] {
+				errs = append(errs, errors.Errorf("duplicate inhibition name %s", name))
+			}
+			names[name] = true
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2alpha1
+
+import (
+	"github.com/pkg/errors"
+	prommodel "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+var (
+	ErrRuleResourceNotFound = errors.New("The prometheusrule resource was not found")
+	ErrRuleGroupNotFound    = errors.New("The rule group was not found")
+)
+
+// RuleGroupRule is one rule of a rule group, either a recording rule or an
+// alerting rule depending on which of record and alert is set.
+type RuleGroupRule struct {
+	Record      string            `json:"record,omitempty" description:"name of the time series the rule records to, mutually exclusive with alert"`
+	Alert       string            `json:"alert,omitempty" description:"name of the alert the rule fires, mutually exclusive with record"`
+	Expr        string            `json:"expr" description:"prometheus query expression to evaluate"`
+	For         string            `json:"for,omitempty" description:"duration an alert transitions from Pending to Firing state, which must match ^([0-9]+)(y|w|d|h|m|s|ms)$"`
+	Labels      map[string]string `json:"labels,omitempty" description:"labels to add or overwrite on the result"`
+	Annotations map[string]string `json:"annotations,omitempty" description:"annotations to add to the resulting alerts, only for alerting rules"`
+}
+
+func (r *RuleGroupRule) Validate() error {
+	errs := []error{}
+
+	if (r.Record == "") == (r.Alert == "") {
+		errs = append(errs, errors.New("exactly one of record and alert must be set"))
+	}
+	if r.Record != "" && len(r.Annotations) > 0 {
+		errs = append(errs, errors.New("annotations can only be set for alerting rules"))
+	}
+
+	if r.Expr == "" {
+		errs = append(errs, errors.New("expr can not be empty"))
+	} else if _, err := parser.ParseExpr(r.Expr); err != nil {
+		errs = append(errs, errors.Wrapf(err, "expr is invalid: %s", r.Expr))
+	}
+	if r.For != "" {
+		if _, err := prommodel.ParseDuration(r.For); err != nil {
+			errs = append(errs, errors.Wrapf(err, "for is invalid: %s", r.For))
+		}
+	}
+
+	for name := range r.Labels {
+		if !prommodel.LabelName(name).IsValid() {
+			errs = append(errs, errors.Errorf("invalid label name: %s", name))
+		}
+	}
+	for name := range r.Annotations {
+		if !prommodel.LabelName(name).IsValid() {
+			errs = append(errs, errors.Errorf("invalid annotation name: %s", name))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// PostableRuleGroup is a rule group as submitted by the client. It maps to one
+// group within a prometheusrule custom resource.
+type PostableRuleGroup struct {
+	Name     string          `json:"name" description:"group name, which must be unique within a prometheusrule resource"`
+	Interval string          `json:"interval,omitempty" description:"how often the rules in the group are evaluated, which must match ^([0-9]+)(y|w|d|h|m|s|ms)$"`
+	Rules    []RuleGroupRule `json:"rules" description:"rules of the group"`
+}
+
+func (g *PostableRuleGroup) Validate() error {
+	errs := []error{}
+
+	if g.Name == "" {
+		errs = append(errs, errors.New("name can not be empty"))
+	}
+	if g.Interval != "" {
+		if _, err := prommodel.ParseDuration(g.Interval); err != nil {
+			errs = append(errs, errors.Wrapf(err, "interval is invalid: %s", g.Interval))
+		}
+	}
+	if len(g.Rules) == 0 {
+		errs = append(errs, errors.New("rules can not be empty"))
+	}
+	for i := range g.Rules {
+		if err := g.Rules[i].Validate(); err != nil {
+			errs = append(errs, errors.Wrapf(err, "rule[%d] is invalid", i))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+type GettableRuleGroup struct {
+	PostableRuleGroup `json:",inline"`
+
+	ResourceName string `json:"resourceName" description:"name of the prometheusrule resource the group belongs to"`
+}
+
+type GettableRuleGroupList struct {
+	Items []*GettableRuleGroup `json:"items"`
+	Total int                  `json:"total"`
+}
+
+// TestSeries is one input series of a rule group test, in the notation of
+// promtool test files.
+type TestSeries struct {
+	Series string `json:"series" description:"series name with labels, e.g. node_cpu_seconds_total{mode=\"idle\",instance=\"node1\"}"`
+	Values string `json:"values" description:"expanding notation of the sample values, one sample per evaluation interval, e.g. 0+1x10 or 1 1 1 0 0"`
+}
+
+// ExpectedAlert describes an alert expected to be firing at an assertion time
+// of a rule group test, and also carries the actually firing alerts in the
+// test results.
+type ExpectedAlert struct {
+	Labels      map[string]string `json:"labels,omitempty" description:"labels of the alert, the alertname label may be omitted"`
+	Annotations map[string]string `json:"annotations,omitempty" description:"annotations of the alert"`
+}
+
+// AlertAssertion asserts which alerts of an alerting rule are firing at the
+// given time, an empty alert list asserts the rule fires nothing.
+type AlertAssertion struct {
+	EvalTime  string          `json:"evalTime" description:"time elapsed since the start of the test data at which to check the alerts, which must match ^([0-9]+)(y|w|d|h|m|s|ms)$"`
+	Alertname string          `json:"alertname" description:"name of the alerting rule to check"`
+	ExpAlerts []ExpectedAlert `json:"expAlerts" description:"alerts expected to be firing at the eval time, leave empty to expect none"`
+}
+
+// RuleGroupTest validates a rule group against supplied series, with the
+// semantics of promtool test rules.
+type RuleGroupTest struct {
+	Interval   string            `json:"interval,omitempty" description:"scrape and evaluation interval of the test, defaults to 1m"`
+	Series     []TestSeries      `json:"series" description:"input series the rules are evaluated against"`
+	RuleGroup  PostableRuleGroup `json:"ruleGroup" description:"rule group under test"`
+	Assertions []AlertAssertion  `json:"assertions" description:"alert assertions to check"`
+}
+
+func (t *RuleGroupTest) Validate() error {
+	errs := []error{}
+
+	if t.Interval != "" {
+		if _, err := prommodel.ParseDuration(t.Interval); err != nil {
+			errs = append(errs, errors.Wrapf(err, "interval is invalid: %s", t.Interval))
+		}
+	}
+	if len(t.Series) == 0 {
+		errs = append(errs, errors.New("series can not be empty"))
+	}
+	if err := t.RuleGroup.Validate(); err != nil {
+		errs = append(errs, errors.Wrap(err, "ruleGroup is invalid"))
+	}
+	if len(t.Assertions) == 0 {
+		errs = append(errs, errors.New("assertions can not be empty"))
+	}
+	for i, a := range t.Assertions {
+		if a.Alertname == "" {
+			errs = append(errs, errors.Errorf("assertion[%d] is invalid: alertname can not be empty", i))
+		}
+		if a.EvalTime == "" {
+			errs = append(errs, errors.Errorf("assertion[%d] is invalid: evalTime can not be empty", i))
+		} else if _, err := prommodel.ParseDuration(a.EvalTime); err != nil {
+			errs = append(errs, errors.Wrapf(err, "assertion[%d] is invalid: evalTime is invalid: %s", i, a.EvalTime))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// AssertionResult is the outcome of one alert assertion, carrying both the
+// expected and the actually firing alerts for failed assertions.
+type AssertionResult struct {
+	EvalTime  string          `json:"evalTime"`
+	Alertname string          `json:"alertname"`
+	Pass      bool            `json:"pass"`
+	Expected  []ExpectedAlert `json:"expected,omitempty" description:"alerts expected to be firing, only for failed assertions"`
+	Got       []ExpectedAlert `json:"got,omitempty" description:"alerts actually firing, only for failed assertions"`
+}
+
+type RuleGroupTestResult struct {
+	Pass    bool              `json:"pass" description:"whether all assertions passed"`
+	Results []AssertionResult `json:"results"`
+}
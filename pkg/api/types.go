@@ -24,6 +24,9 @@ import (
 type ListResult struct {
 	Items      []interface{} `json:"items"`
 	TotalItems int           `json:"totalItems"`
+
+	// opaque cursor of the next page, empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type ResourceQuota struct {
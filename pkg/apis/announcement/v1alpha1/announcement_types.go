@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindAnnouncement     = "Announcement"
+	ResourceSingularAnnouncement = "announcement"
+	ResourcePluralAnnouncement   = "announcements"
+)
+
+// AnnouncementSeverity rates how urgent an announcement is
+type AnnouncementSeverity string
+
+const (
+	SeverityInfo     AnnouncementSeverity = "info"
+	SeverityWarning  AnnouncementSeverity = "warning"
+	SeverityCritical AnnouncementSeverity = "critical"
+)
+
+// AnnouncementSpec defines the desired state of Announcement
+type AnnouncementSpec struct {
+	Title string `json:"title"`
+
+	// the notice shown to console users
+	Message string `json:"message"`
+
+	// info, warning or critical, defaults to info
+	Severity AnnouncementSeverity `json:"severity,omitempty"`
+
+	// when to start surfacing the announcement, immediately when unset
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// when to stop surfacing the announcement, never when unset
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+}
+
+// AnnouncementStatus defines the observed state of Announcement
+type AnnouncementStatus struct {
+	// users that acknowledged the announcement
+	AcknowledgedBy []string `json:"acknowledgedBy,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Announcement is a maintenance notice published by a platform admin,
+// surfaced to all console users while it is active
+// +k8s:openapi-gen=true
+type Announcement struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AnnouncementSpec   `json:"spec"`
+	Status AnnouncementStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AnnouncementList contains a list of Announcement
+type AnnouncementList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []Announcement `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&Announcement{},
+		&AnnouncementList{})
+}
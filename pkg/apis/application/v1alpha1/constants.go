@@ -25,8 +25,9 @@ const (
 	StateSubmitted = "submitted"
 	StatePassed    = "passed"
 	StateRejected  = "rejected"
-	StateSuspended = "suspended"
-	StateActive    = "active"
+	StateSuspended  = "suspended"
+	StateActive     = "active"
+	StateDeprecated = "deprecated"
 
 	// repo state
 	RepoStateSuccessful = "successful"
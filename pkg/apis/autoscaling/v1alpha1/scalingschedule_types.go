@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindScalingSchedule     = "ScalingSchedule"
+	ResourceSingularScalingSchedule = "scalingschedule"
+	ResourcePluralScalingSchedule   = "scalingschedules"
+)
+
+// ScalingTargetRef points at the workload to scale, in the schedule's
+// namespace.
+type ScalingTargetRef struct {
+	// Deployment or StatefulSet
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// ScalingRule scales the target to the given replica count whenever the
+// cron expression fires.
+type ScalingRule struct {
+	// standard five-field cron expression, evaluated in the schedule's
+	// time zone, e.g. "0 20 * * 1-5"
+	Schedule string `json:"schedule"`
+
+	Replicas int32 `json:"replicas"`
+
+	// free-form note shown in the console, e.g. "scale down for the night"
+	Description string `json:"description,omitempty"`
+}
+
+// ScalingScheduleSpec scales a workload on cron schedules. When a
+// HorizontalPodAutoscaler targets the same workload the rules move its
+// replica bounds instead of the workload's replica count, so the two do not
+// fight; scaling to zero always sets the workload's replicas directly.
+type ScalingScheduleSpec struct {
+	TargetRef ScalingTargetRef `json:"targetRef"`
+
+	// IANA time zone the cron expressions are evaluated in, defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+
+	Rules []ScalingRule `json:"rules"`
+}
+
+// ScalingScheduleStatus records the last rule that fired.
+type ScalingScheduleStatus struct {
+	// LastScaleTime is when a rule last scaled the target
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+
+	// LastSchedule is the cron expression of the rule that last fired
+	LastSchedule string `json:"lastSchedule,omitempty"`
+
+	// LastReplicas is the replica count the target was last scaled to
+	LastReplicas int32 `json:"lastReplicas,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ScalingSchedule is the Schema for the scalingschedule API
+// +k8s:openapi-gen=true
+type ScalingSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScalingScheduleSpec   `json:"spec"`
+	Status ScalingScheduleStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ScalingScheduleList contains a list of ScalingSchedule
+type ScalingScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ScalingSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&ScalingSchedule{},
+		&ScalingScheduleList{})
+}
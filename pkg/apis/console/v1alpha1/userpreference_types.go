@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindUserPreference     = "UserPreference"
+	ResourceSingularUserPreference = "userpreference"
+	ResourcePluralUserPreference   = "userpreferences"
+)
+
+// UserPreferenceSpec holds the console settings of one user. The object is
+// named after the user it belongs to.
+type UserPreferenceSpec struct {
+	// cluster the console opens by default
+	DefaultCluster string `json:"defaultCluster,omitempty"`
+
+	// workspace the console opens by default
+	DefaultWorkspace string `json:"defaultWorkspace,omitempty"`
+
+	// dashboards pinned to the top of the dashboard list
+	FavoriteDashboards []string `json:"favoriteDashboards,omitempty"`
+
+	// projects pinned to the top of the project list
+	PinnedProjects []string `json:"pinnedProjects,omitempty"`
+
+	// visible columns per console table, keyed by table id
+	TableColumns map[string][]string `json:"tableColumns,omitempty"`
+
+	// other console settings as key value pairs
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// UserPreference stores the console settings of a user so they follow the
+// user across browsers instead of living in localStorage
+// +k8s:openapi-gen=true
+type UserPreference struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec UserPreferenceSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// UserPreferenceList contains a list of UserPreference
+type UserPreferenceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []UserPreference `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&UserPreference{},
+		&UserPreferenceList{})
+}
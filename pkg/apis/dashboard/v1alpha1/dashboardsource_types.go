@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindDashboardSource     = "DashboardSource"
+	ResourceSingularDashboardSource = "dashboardsource"
+	ResourcePluralDashboardSource   = "dashboardsources"
+)
+
+const (
+	// SourceStateSynced means the last sync applied the repository cleanly
+	SourceStateSynced = "Synced"
+	// SourceStateFailed means the last sync could not be completed
+	SourceStateFailed = "Failed"
+)
+
+// DashboardSourceSpec points at a Git repository holding Dashboard and
+// ClusterDashboard manifests. The controller clones it on an interval and
+// applies what it finds, giving teams GitOps for dashboards without
+// external tooling.
+type DashboardSourceSpec struct {
+	// Repo is the clone URL of the Git repository
+	Repo string `json:"repo"`
+
+	// Branch to sync from, defaults to the remote's default branch
+	Branch string `json:"branch,omitempty"`
+
+	// Path is the directory inside the repository holding the manifests,
+	// defaults to the repository root
+	Path string `json:"path,omitempty"`
+
+	// Interval between syncs, e.g. 5m, defaults to 5m
+	Interval string `json:"interval,omitempty"`
+
+	// SecretRef names a basic-auth secret for private repositories
+	SecretRef *corev1.SecretReference `json:"secretRef,omitempty"`
+
+	// Prune deletes dashboards this source applied earlier but which are
+	// no longer in the repository
+	Prune bool `json:"prune,omitempty"`
+}
+
+// DashboardSourceStatus reports the outcome of the last sync.
+type DashboardSourceStatus struct {
+	// LastSyncTime is when the source was last synced
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Revision is the commit the last successful sync applied
+	Revision string `json:"revision,omitempty"`
+
+	// Dashboards is the number of dashboards the last sync applied
+	Dashboards int `json:"dashboards,omitempty"`
+
+	// State is Synced or Failed
+	State string `json:"state,omitempty"`
+
+	// Message explains a failed sync
+	Message string `json:"message,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DashboardSource is the Schema for the dashboardsource API
+// +k8s:openapi-gen=true
+type DashboardSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DashboardSourceSpec   `json:"spec"`
+	Status DashboardSourceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DashboardSourceList contains a list of DashboardSource
+type DashboardSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []DashboardSource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&DashboardSource{},
+		&DashboardSourceList{})
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindDefaultingPolicy     = "DefaultingPolicy"
+	ResourceSingularDefaultingPolicy = "defaultingpolicy"
+	ResourcePluralDefaultingPolicy   = "defaultingpolicies"
+
+	// NamespaceOptInLabel set to "true" on a namespace opts its workloads
+	// in to defaulting policies
+	NamespaceOptInLabel = "defaulting.kubesphere.io/enabled"
+)
+
+// DefaultingPolicySpec declares defaults injected into workloads created in
+// opted-in namespaces.
+type DefaultingPolicySpec struct {
+	// namespaces the policy applies to, matched against namespace labels,
+	// an empty selector selects every opted-in namespace
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// resource requests injected into containers that declare none
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+
+	// resource limits injected into containers that declare none
+	Limits corev1.ResourceList `json:"limits,omitempty"`
+
+	// labels merged into created workloads, existing labels win
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// annotations merged into created workloads, existing annotations win
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DefaultingPolicy is the Schema for the defaultingpolicy API
+// +k8s:openapi-gen=true
+type DefaultingPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DefaultingPolicySpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DefaultingPolicyList contains a list of DefaultingPolicy
+type DefaultingPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []DefaultingPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&DefaultingPolicy{},
+		&DefaultingPolicyList{})
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindAPIService     = "APIService"
+	ResourceSingularAPIService = "apiservice"
+	ResourcePluralAPIService   = "apiservices"
+
+	// DefaultHealthCheckPath is probed when spec.healthCheckPath is empty
+	DefaultHealthCheckPath = "/healthz"
+)
+
+// ServiceReference refers to a kubernetes service backing an API group.
+type ServiceReference struct {
+	// namespace of the service
+	Namespace string `json:"namespace"`
+
+	// name of the service
+	Name string `json:"name"`
+
+	// port the service listens on, defaults to 443
+	Port *int32 `json:"port,omitempty"`
+}
+
+// APIServiceSpec defines a kapis group served by an external service.
+// Requests under /kapis/{group}/{version} are authenticated and authorized
+// by ks-apiserver, then proxied to the backend with the verified user
+// identity passed along in headers.
+type APIServiceSpec struct {
+	// api group the backend serves, e.g. alerting.thirdparty.io
+	Group string `json:"group"`
+
+	// api version the backend serves, e.g. v1alpha1
+	Version string `json:"version"`
+
+	// url of the backend, takes precedence over field service,
+	// e.g. https://alerting.example.com:8443
+	URL string `json:"url,omitempty"`
+
+	// service inside the cluster serving the group
+	Service *ServiceReference `json:"service,omitempty"`
+
+	// base64 encoded CA bundle used to verify the backend's serving certificate
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// disable certificate verification for the backend, for development only
+	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty"`
+
+	// path probed to decide whether the backend is available,
+	// defaults to /healthz
+	HealthCheckPath string `json:"healthCheckPath,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// APIService is the Schema for the apiservice API
+// +k8s:openapi-gen=true
+type APIService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec APIServiceSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// APIServiceList contains a list of APIService
+type APIServiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []APIService `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&APIService{},
+		&APIServiceList{})
+}
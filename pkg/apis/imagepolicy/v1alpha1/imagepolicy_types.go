@@ -0,0 +1,74 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindImagePolicy     = "ImagePolicy"
+	ResourceSingularImagePolicy = "imagepolicy"
+	ResourcePluralImagePolicy   = "imagepolicies"
+)
+
+// ImagePolicySpec restricts the images pods may run in the selected
+// workspace.
+type ImagePolicySpec struct {
+	// workspace the policy applies to, an empty workspace selects every
+	// namespace
+	Workspace string `json:"workspace,omitempty"`
+
+	// registries images may be pulled from, e.g. harbor.example.com or
+	// docker.io/library, an empty list allows every registry
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+
+	// reject images whose signature cannot be verified
+	RequireSignature bool `json:"requireSignature,omitempty"`
+
+	// endpoint of the signature verification service (cosign/notation
+	// compatible), called with the image reference during admission
+	SignatureVerifierURL string `json:"signatureVerifierURL,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ImagePolicy is the Schema for the imagepolicy API
+// +k8s:openapi-gen=true
+type ImagePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ImagePolicySpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ImagePolicyList contains a list of ImagePolicy
+type ImagePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ImagePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&ImagePolicy{},
+		&ImagePolicyList{})
+}
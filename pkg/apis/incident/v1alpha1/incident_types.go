@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindIncident     = "Incident"
+	ResourceSingularIncident = "incident"
+	ResourcePluralIncident   = "incidents"
+)
+
+// IncidentState tracks whether the incident is still being worked on
+type IncidentState string
+
+const (
+	IncidentOpen     IncidentState = "open"
+	IncidentResolved IncidentState = "resolved"
+)
+
+// TimelineEntryType says what kind of happening a timeline entry records
+type TimelineEntryType string
+
+const (
+	// EntryAlert is a firing alert correlated into the incident
+	EntryAlert TimelineEntryType = "alert"
+	// EntryEvent is a Kubernetes event observed during the incident
+	EntryEvent TimelineEntryType = "event"
+	// EntryDeploy is a workload rollout around the incident window
+	EntryDeploy TimelineEntryType = "deploy"
+	// EntryAction is a note or state change recorded by an operator
+	EntryAction TimelineEntryType = "action"
+)
+
+// TimelineEntry is one happening on the incident's timeline
+type TimelineEntry struct {
+	Time metav1.Time       `json:"time"`
+	Type TimelineEntryType `json:"type"`
+
+	// what produced the entry: the alert name, the event reason, the
+	// workload rolled out, or the acting user
+	Source string `json:"source,omitempty"`
+
+	Message string `json:"message,omitempty"`
+}
+
+// IncidentSpec defines the desired state of Incident
+type IncidentSpec struct {
+	Title string `json:"title"`
+
+	// the namespace the alert storm is scoped to, empty for cluster wide
+	// incidents
+	Namespace string `json:"namespace,omitempty"`
+
+	// the alert that opened the incident
+	Alertname string `json:"alertname,omitempty"`
+}
+
+// IncidentStatus defines the observed state of Incident
+type IncidentStatus struct {
+	// open or resolved
+	State IncidentState `json:"state,omitempty"`
+
+	StartTime    *metav1.Time `json:"startTime,omitempty"`
+	ResolvedTime *metav1.Time `json:"resolvedTime,omitempty"`
+
+	// how many alerts were correlated into the incident
+	AlertCount int `json:"alertCount,omitempty"`
+
+	// when the last alert was correlated, bounding the correlation window
+	LastAlertTime *metav1.Time `json:"lastAlertTime,omitempty"`
+
+	// the recorded alerts and operator actions; events and rollouts are
+	// aggregated at read time
+	Timeline []TimelineEntry `json:"timeline,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Incident is opened automatically from a storm of critical alerts and
+// collects the related alerts, events, rollouts and operator actions into one
+// timeline for the postmortem
+// +k8s:openapi-gen=true
+type Incident struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IncidentSpec   `json:"spec"`
+	Status IncidentStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// IncidentList contains a list of Incident
+type IncidentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []Incident `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&Incident{},
+		&IncidentList{})
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindClusterInspection     = "ClusterInspection"
+	ResourceSingularClusterInspection = "clusterinspection"
+	ResourcePluralClusterInspection   = "clusterinspections"
+
+	// ClusterInspectionLabel marks inspection reports created from a
+	// cluster inspection
+	ClusterInspectionLabel = "inspection.kubesphere.io/clusterinspection"
+)
+
+// ClusterInspectionSpec defines the desired state of ClusterInspection
+type ClusterInspectionSpec struct {
+	// inspection schedule in cron format, e.g. "0 2 * * *"
+	Schedule string `json:"schedule"`
+
+	// number of inspection reports to keep, older reports are garbage
+	// collected. Zero means keep all reports.
+	HistoryLimit int `json:"historyLimit,omitempty"`
+
+	// suspended inspections do not run
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// ClusterInspectionStatus defines the observed state of ClusterInspection
+type ClusterInspectionStatus struct {
+	// time the inspection last ran
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+
+	// name of the report produced by the last run
+	LastReportName string `json:"lastReportName,omitempty"`
+
+	// number of failed checks in the last run
+	LastFailureCount int `json:"lastFailureCount,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterInspection runs CIS benchmark and best-practice checks against the
+// cluster on a cron schedule, each run produces an InspectionReport
+// +k8s:openapi-gen=true
+type ClusterInspection struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterInspectionSpec   `json:"spec"`
+	Status ClusterInspectionStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterInspectionList contains a list of ClusterInspection
+type ClusterInspectionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ClusterInspection `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&ClusterInspection{},
+		&ClusterInspectionList{})
+}
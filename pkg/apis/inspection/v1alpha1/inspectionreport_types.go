@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindInspectionReport     = "InspectionReport"
+	ResourceSingularInspectionReport = "inspectionreport"
+	ResourcePluralInspectionReport   = "inspectionreports"
+)
+
+// CheckStatus is the outcome of a single check
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "Pass"
+	CheckWarn CheckStatus = "Warn"
+	CheckFail CheckStatus = "Fail"
+)
+
+// targets of a check
+const (
+	TargetControlPlane = "control-plane"
+	TargetNode         = "node"
+	TargetWorkload     = "workload"
+)
+
+// CheckResult is the outcome of one check of an inspection run. IDs are
+// stable across runs so reports can be diffed.
+type CheckResult struct {
+	// stable id of the check, CIS benchmark section for benchmark checks
+	ID string `json:"id"`
+
+	Description string `json:"description"`
+
+	// what the check inspected, control-plane, node or workload
+	Target string `json:"target"`
+
+	Status CheckStatus `json:"status"`
+
+	// details about the findings, e.g. the offending pods or nodes
+	Message string `json:"message,omitempty"`
+
+	// how to fix a failed check
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// InspectionSummary counts check outcomes of a run
+type InspectionSummary struct {
+	Pass int `json:"pass"`
+	Warn int `json:"warn"`
+	Fail int `json:"fail"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// InspectionReport holds the results of one inspection run, it is created
+// by the inspection controller and never updated
+// +k8s:openapi-gen=true
+type InspectionReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// time the run started
+	StartTime metav1.Time `json:"startTime"`
+
+	Summary InspectionSummary `json:"summary"`
+
+	Results []CheckResult `json:"results,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// InspectionReportList contains a list of InspectionReport
+type InspectionReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []InspectionReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&InspectionReport{},
+		&InspectionReportList{})
+}
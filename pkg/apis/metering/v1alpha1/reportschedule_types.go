@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindReportSchedule     = "ReportSchedule"
+	ResourceSingularReportSchedule = "reportschedule"
+	ResourcePluralReportSchedule   = "reportschedules"
+
+	// ReportFormatCSV is the only report format currently supported
+	ReportFormatCSV = "csv"
+)
+
+// ReportDelivery defines where generated reports are delivered to.
+type ReportDelivery struct {
+	// upload reports to the configured S3 storage
+	S3 bool `json:"s3,omitempty"`
+
+	// email addresses the report location is announced to through the
+	// notification pipeline
+	EmailRecipients []string `json:"emailRecipients,omitempty"`
+}
+
+// ReportScheduleSpec defines the desired state of ReportSchedule
+type ReportScheduleSpec struct {
+	// report schedule in cron format, e.g. "0 0 1 * *" for monthly reports
+	Schedule string `json:"schedule"`
+
+	// workspace the report covers
+	Workspace string `json:"workspace"`
+
+	// report format, defaults to csv
+	Format string `json:"format,omitempty"`
+
+	// where reports are delivered to
+	Delivery ReportDelivery `json:"delivery,omitempty"`
+
+	// paused schedules do not generate new reports
+	Paused bool `json:"paused,omitempty"`
+}
+
+// ReportScheduleStatus defines the observed state of ReportSchedule
+type ReportScheduleStatus struct {
+	// time the last report was generated
+	LastReportTime *metav1.Time `json:"lastReportTime,omitempty"`
+
+	// storage location of the last generated report
+	LastReportLocation string `json:"lastReportLocation,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ReportSchedule is the Schema for the reportschedule API
+// +k8s:openapi-gen=true
+type ReportSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ReportScheduleSpec   `json:"spec"`
+	Status ReportScheduleStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ReportScheduleList contains a list of ReportSchedule
+type ReportScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ReportSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&ReportSchedule{},
+		&ReportScheduleList{})
+}
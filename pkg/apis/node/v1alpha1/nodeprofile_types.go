@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindNodeProfile     = "NodeProfile"
+	ResourceSingularNodeProfile = "nodeprofile"
+	ResourcePluralNodeProfile   = "nodeprofiles"
+
+	// AppliedProfileAnnotation records which profile revision the node
+	// agent applied to a node, in the form <name>/<generation>
+	AppliedProfileAnnotation = "node.kubesphere.io/applied-profile"
+
+	// AppliedStatusAnnotation records the outcome of the last apply,
+	// either StatusApplied or an error message
+	AppliedStatusAnnotation = "node.kubesphere.io/profile-status"
+
+	StatusApplied = "applied"
+)
+
+// NodeProfileSpec is the tuning applied to every node of the selected node
+// group. Sysctls take effect immediately, the kubelet settings are rendered
+// into a drop-in configuration that the kubelet picks up on its next
+// restart.
+type NodeProfileSpec struct {
+	// node group the profile applies to
+	NodeGroup string `json:"nodeGroup"`
+
+	// kernel parameters, e.g. vm.max_map_count: "262144"
+	Sysctls map[string]string `json:"sysctls,omitempty"`
+
+	// resources reserved for kubernetes system daemons
+	KubeReserved map[string]string `json:"kubeReserved,omitempty"`
+
+	// resources reserved for OS system daemons
+	SystemReserved map[string]string `json:"systemReserved,omitempty"`
+
+	// huge page allocations by page size, e.g. 2Mi: "1Gi"
+	HugePages map[string]string `json:"hugePages,omitempty"`
+
+	// kubelet CPU manager policy, none or static
+	CPUManagerPolicy string `json:"cpuManagerPolicy,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeProfile is the Schema for the nodeprofile API
+// +k8s:openapi-gen=true
+type NodeProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NodeProfileSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeProfileList contains a list of NodeProfile
+type NodeProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []NodeProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&NodeProfile{},
+		&NodeProfileList{})
+}
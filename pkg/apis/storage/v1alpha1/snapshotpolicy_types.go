@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindSnapshotPolicy     = "SnapshotPolicy"
+	ResourceSingularSnapshotPolicy = "snapshotpolicy"
+	ResourcePluralSnapshotPolicy   = "snapshotpolicies"
+
+	// SnapshotPolicyLabel marks volume snapshots created from a snapshot policy
+	SnapshotPolicyLabel = "storage.kubesphere.io/snapshotpolicy"
+)
+
+// SnapshotPolicySpec defines the desired state of SnapshotPolicy
+type SnapshotPolicySpec struct {
+	// name of the PVC to snapshot, in the same namespace as the policy
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+
+	// optional, name of the VolumeSnapshotClass used to create snapshots
+	SnapshotClassName string `json:"snapshotClassName,omitempty"`
+
+	// snapshot schedule in cron format, e.g. "0 2 * * *"
+	Schedule string `json:"schedule"`
+
+	// number of snapshots to keep, older snapshots are garbage collected.
+	// Zero means keep all snapshots.
+	Retention int `json:"retention,omitempty"`
+
+	// paused policies do not create new snapshots
+	Paused bool `json:"paused,omitempty"`
+}
+
+// SnapshotPolicyStatus defines the observed state of SnapshotPolicy
+type SnapshotPolicyStatus struct {
+	// time the last snapshot was created from this policy
+	LastSnapshotTime *metav1.Time `json:"lastSnapshotTime,omitempty"`
+
+	// number of snapshots currently kept by this policy
+	SnapshotCount int `json:"snapshotCount,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SnapshotPolicy is the Schema for the snapshotpolicy API
+// +k8s:openapi-gen=true
+type SnapshotPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SnapshotPolicySpec   `json:"spec"`
+	Status SnapshotPolicyStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SnapshotPolicyList contains a list of SnapshotPolicy
+type SnapshotPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []SnapshotPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&SnapshotPolicy{},
+		&SnapshotPolicyList{})
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindProjectRequest     = "ProjectRequest"
+	ResourceSingularProjectRequest = "projectrequest"
+	ResourcePluralProjectRequest   = "projectrequests"
+
+	// ProjectRequestPending waits for a workspace admin's decision
+	ProjectRequestPending = "Pending"
+	// ProjectRequestApproved makes the controller provision the namespace
+	ProjectRequestApproved = "Approved"
+	ProjectRequestRejected = "Rejected"
+)
+
+// ProjectRequestSpec asks for a new namespace in the workspace with the
+// given resource quota.
+type ProjectRequestSpec struct {
+	Workspace string `json:"workspace"`
+	// Project is the name of the requested namespace
+	Project   string `json:"project"`
+	Requester string `json:"requester,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	// Hard is the requested resource quota of the namespace
+	Hard corev1.ResourceList `json:"hard,omitempty"`
+}
+
+// ProjectRequestStatus records the admin's decision and the provisioning
+// outcome.
+type ProjectRequestStatus struct {
+	State    string `json:"state,omitempty"`
+	Approver string `json:"approver,omitempty"`
+	Message  string `json:"message,omitempty"`
+	// CompletionTime is when the namespace was provisioned or the request
+	// was rejected
+	CompletionTime metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +genclient:nonNamespaced
+
+// ProjectRequest is a self-service request for a new namespace, approved
+// or rejected by a workspace admin
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:categories="tenant",scope="Cluster"
+type ProjectRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ProjectRequestSpec   `json:"spec,omitempty"`
+	Status            ProjectRequestStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +genclient:nonNamespaced
+
+// ProjectRequestList contains a list of ProjectRequest
+type ProjectRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProjectRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ProjectRequest{}, &ProjectRequestList{})
+}
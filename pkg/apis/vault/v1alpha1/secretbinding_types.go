@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The KubeSphere authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindSecretBinding     = "SecretBinding"
+	ResourceSingularSecretBinding = "secretbinding"
+	ResourcePluralSecretBinding   = "secretbindings"
+
+	// InjectionModeCSI mounts the bound secrets through the secrets store
+	// CSI driver, the controller maintains a SecretProviderClass
+	InjectionModeCSI = "csi"
+
+	// InjectionModeAgent injects the bound secrets through the vault agent
+	// sidecar, the controller annotates the consuming workloads
+	InjectionModeAgent = "agent"
+
+	// BindingLabel marks a workload as a consumer of a SecretBinding in
+	// the same namespace, the value is the binding's name
+	BindingLabel = "vault.kubesphere.io/binding"
+)
+
+// VaultSecret is a single secret read from Vault.
+type VaultSecret struct {
+	// path of the secret inside Vault, e.g. secret/data/myapp/config
+	Path string `json:"path"`
+
+	// key inside the secret, an empty key selects the whole secret
+	Key string `json:"key,omitempty"`
+
+	// name the secret is exposed under, as the mounted file name in csi
+	// mode or the rendered template name in agent mode, defaults to the
+	// last element of the path
+	ObjectName string `json:"objectName,omitempty"`
+}
+
+// SecretBindingSpec binds the namespace to a set of Vault paths.
+type SecretBindingSpec struct {
+	// address of the Vault server
+	Address string `json:"address"`
+
+	// Vault role the consuming workloads authenticate as, via the
+	// kubernetes auth method
+	Role string `json:"role"`
+
+	// injection mode, csi or agent, defaults to csi
+	Mode string `json:"mode,omitempty"`
+
+	// secrets read from Vault
+	Secrets []VaultSecret `json:"secrets"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SecretBinding is the Schema for the secretbinding API
+// +k8s:openapi-gen=true
+type SecretBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SecretBindingSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SecretBindingList contains a list of SecretBinding
+type SecretBindingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []SecretBinding `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(
+		&SecretBinding{},
+		&SecretBindingList{})
+}
@@ -35,6 +35,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	unionauth "k8s.io/apiserver/pkg/authentication/request/union"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/klog"
 	clusterv1alpha1 "kubesphere.io/kubesphere/pkg/apis/cluster/v1alpha1"
 	iamv1alpha2 "kubesphere.io/kubesphere/pkg/apis/iam/v1alpha2"
@@ -59,26 +61,48 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/request"
 	"kubesphere.io/kubesphere/pkg/informers"
 	alertingv1 "kubesphere.io/kubesphere/pkg/kapis/alerting/v1"
+	announcementv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/announcement/v1alpha1"
 	alertingv2alpha1 "kubesphere.io/kubesphere/pkg/kapis/alerting/v2alpha1"
+	autoscalingv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/autoscaling/v1alpha1"
+	backupv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/backup/v1alpha1"
+	certsv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/certs/v1alpha1"
 	clusterkapisv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/cluster/v1alpha1"
 	configv1alpha2 "kubesphere.io/kubesphere/pkg/kapis/config/v1alpha2"
+	consolev1alpha1 "kubesphere.io/kubesphere/pkg/kapis/console/v1alpha1"
+	dashboardsv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/dashboards/v1alpha1"
+	debugv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/debug/v1alpha1"
 	devopsv1alpha2 "kubesphere.io/kubesphere/pkg/kapis/devops/v1alpha2"
 	devopsv1alpha3 "kubesphere.io/kubesphere/pkg/kapis/devops/v1alpha3"
+	etcdv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/etcd/v1alpha1"
+	graphqlv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/graphql/v1alpha1"
+	harborv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/harbor/v1alpha1"
 	iamapi "kubesphere.io/kubesphere/pkg/kapis/iam/v1alpha2"
+	incidentv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/incident/v1alpha1"
+	inspectionv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/inspection/v1alpha1"
+	installerv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/installer/v1alpha1"
 	kubeedgev1alpha1 "kubesphere.io/kubesphere/pkg/kapis/kubeedge/v1alpha1"
+	licensev1alpha1 "kubesphere.io/kubesphere/pkg/kapis/license/v1alpha1"
 	meteringv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/metering/v1alpha1"
 	monitoringv1alpha3 "kubesphere.io/kubesphere/pkg/kapis/monitoring/v1alpha3"
+	monitoringv1alpha4 "kubesphere.io/kubesphere/pkg/kapis/monitoring/v1alpha4"
 	networkv1alpha2 "kubesphere.io/kubesphere/pkg/kapis/network/v1alpha2"
 	notificationv1 "kubesphere.io/kubesphere/pkg/kapis/notification/v1"
+	nodegroupv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/nodegroup/v1alpha1"
 	notificationkapisv2beta1 "kubesphere.io/kubesphere/pkg/kapis/notification/v2beta1"
 	"kubesphere.io/kubesphere/pkg/kapis/oauth"
 	openpitrixv1 "kubesphere.io/kubesphere/pkg/kapis/openpitrix/v1"
 	operationsv1alpha2 "kubesphere.io/kubesphere/pkg/kapis/operations/v1alpha2"
+	recommendationv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/recommendation/v1alpha1"
 	resourcesv1alpha2 "kubesphere.io/kubesphere/pkg/kapis/resources/v1alpha2"
 	resourcev1alpha3 "kubesphere.io/kubesphere/pkg/kapis/resources/v1alpha3"
+	searchv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/search/v1alpha1"
 	servicemeshv1alpha2 "kubesphere.io/kubesphere/pkg/kapis/servicemesh/metrics/v1alpha2"
+	storagev1alpha1 "kubesphere.io/kubesphere/pkg/kapis/storage/v1alpha1"
+	securityv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/security/v1alpha1"
 	tenantv1alpha2 "kubesphere.io/kubesphere/pkg/kapis/tenant/v1alpha2"
 	terminalv1alpha2 "kubesphere.io/kubesphere/pkg/kapis/terminal/v1alpha2"
+	topologyv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/topology/v1alpha1"
+	vaultv1alpha1 "kubesphere.io/kubesphere/pkg/kapis/vault/v1alpha1"
 	"kubesphere.io/kubesphere/pkg/kapis/version"
 	"kubesphere.io/kubesphere/pkg/models/auth"
 	"kubesphere.io/kubesphere/pkg/models/iam/am"
@@ -90,15 +114,21 @@ import (
 	"kubesphere.io/kubesphere/pkg/simple/client/auditing"
 	"kubesphere.io/kubesphere/pkg/simple/client/cache"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops"
+	"kubesphere.io/kubesphere/pkg/simple/client/etcd"
 	"kubesphere.io/kubesphere/pkg/simple/client/events"
+	"kubesphere.io/kubesphere/pkg/simple/client/harbor"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
 	"kubesphere.io/kubesphere/pkg/simple/client/logging"
 	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
 	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 	"kubesphere.io/kubesphere/pkg/simple/client/sonarqube"
+	"kubesphere.io/kubesphere/pkg/utils/clusterclient"
+	"kubesphere.io/kubesphere/pkg/utils/hashring"
 	"kubesphere.io/kubesphere/pkg/utils/metrics"
 	utilnet "kubesphere.io/kubesphere/pkg/utils/net"
+	"kubesphere.io/kubesphere/pkg/utils/tracing"
 	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -151,6 +181,10 @@ type APIServer struct {
 
 	SonarClient sonarqube.SonarInterface
 
+	HarborClient harbor.Interface
+
+	EtcdClient etcd.Interface
+
 	EventsClient events.Client
 
 	AuditingClient auditing.Client
@@ -159,6 +193,9 @@ type APIServer struct {
 
 	// controller-runtime cache
 	RuntimeCache runtimecache.Cache
+
+	// controller-runtime client, for writes that go through the API server
+	RuntimeClient runtimeclient.Client
 }
 
 func (s *APIServer) PrepareRun(stopCh <-chan struct{}) error {
@@ -185,13 +222,35 @@ func (s *APIServer) PrepareRun(stopCh <-chan struct{}) error {
 	return nil
 }
 
+// requests slower than this are logged together with the backend calls
+// made while serving them
+const slowRequestThreshold = 4 * time.Second
+
 func monitorRequest(r *restful.Request, response *restful.Response, chain *restful.FilterChain) {
 	start := time.Now()
+
+	var username string
+	if user, exists := apirequest.UserFrom(r.Request.Context()); exists {
+		username = user.GetName()
+	}
+	trace, ctx := tracing.StartRequest(r.Request.Context(), r.Request.Method, r.Request.URL.Path, username)
+	defer tracing.FinishRequest(trace)
+	r.Request = r.Request.WithContext(ctx)
+
 	chain.ProcessFilter(r, response)
+
+	elapsed := time.Since(start)
+	if handler := r.SelectedRoutePath(); handler != "" {
+		HandlerLatencies.WithLabelValues(r.Request.Method, handler).Observe(elapsed.Seconds())
+	}
+	if elapsed > slowRequestThreshold {
+		klog.Warningf("slow request: %s %s by %s took %v: %s", r.Request.Method, r.Request.URL.Path, username, elapsed, trace.SpanSummary())
+	}
+
 	reqInfo, exists := request.RequestInfoFrom(r.Request.Context())
 	if exists && reqInfo.APIGroup != "" {
 		RequestCounter.WithLabelValues(reqInfo.Verb, reqInfo.APIGroup, reqInfo.APIVersion, reqInfo.Resource, strconv.Itoa(response.StatusCode())).Inc()
-		elapsedSeconds := time.Now().Sub(start).Seconds()
+		elapsedSeconds := elapsed.Seconds()
 		RequestLatencies.WithLabelValues(reqInfo.Verb, reqInfo.APIGroup, reqInfo.APIVersion, reqInfo.Resource).Observe(elapsedSeconds)
 	}
 }
@@ -218,21 +277,33 @@ func (s *APIServer) installKubeSphereAPIs() {
 	urlruntime.Must(configv1alpha2.AddToContainer(s.container, s.Config))
 	urlruntime.Must(resourcev1alpha3.AddToContainer(s.container, s.InformerFactory, s.RuntimeCache))
 	urlruntime.Must(monitoringv1alpha3.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.MonitoringClient, s.MetricsClient, s.InformerFactory))
+	urlruntime.Must(monitoringv1alpha4.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.MonitoringClient, s.MetricsClient, s.InformerFactory, s.Config.MonitoringOptions))
 	urlruntime.Must(meteringv1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.MonitoringClient, s.InformerFactory, s.RuntimeCache))
 	urlruntime.Must(openpitrixv1.AddToContainer(s.container, s.InformerFactory, s.KubernetesClient.KubeSphere(), s.Config.OpenPitrixOptions))
 	urlruntime.Must(openpitrixv2alpha1.AddToContainer(s.container, s.InformerFactory, s.KubernetesClient.KubeSphere(), s.Config.OpenPitrixOptions))
-	urlruntime.Must(operationsv1alpha2.AddToContainer(s.container, s.KubernetesClient.Kubernetes()))
+	urlruntime.Must(operationsv1alpha2.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.KubernetesClient.Config(), s.InformerFactory.KubeSphereSharedInformerFactory(),
+		rbacAuthorizer))
 	urlruntime.Must(resourcesv1alpha2.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.InformerFactory,
 		s.KubernetesClient.Master()))
 	urlruntime.Must(tenantv1alpha2.AddToContainer(s.container, s.InformerFactory, s.KubernetesClient.Kubernetes(),
-		s.KubernetesClient.KubeSphere(), s.EventsClient, s.LoggingClient, s.AuditingClient, amOperator, rbacAuthorizer, s.MonitoringClient, s.RuntimeCache))
-	urlruntime.Must(terminalv1alpha2.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.KubernetesClient.Config()))
+		s.KubernetesClient.KubeSphere(), s.EventsClient, s.LoggingClient, s.AuditingClient, amOperator, rbacAuthorizer, s.MonitoringClient, s.RuntimeCache, s.RuntimeClient,
+		s.Config.AuthenticationOptions, s.Config.LoggingOptions))
+	urlruntime.Must(securityv1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes(),
+		dynamic.NewForConfigOrDie(s.KubernetesClient.Config()), s.InformerFactory.KubernetesSharedInformerFactory()))
+	urlruntime.Must(terminalv1alpha2.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.KubernetesClient.Config(), s.S3Client))
+	urlruntime.Must(backupv1alpha1.AddToContainer(s.container, dynamic.NewForConfigOrDie(s.KubernetesClient.Config()), rbacAuthorizer))
+	urlruntime.Must(storagev1alpha1.AddToContainer(s.container, s.InformerFactory.KubernetesSharedInformerFactory(), s.MonitoringClient))
+	urlruntime.Must(recommendationv1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.InformerFactory.KubernetesSharedInformerFactory(), s.MonitoringClient))
+	urlruntime.Must(graphqlv1alpha1.AddToContainer(s.container, s.InformerFactory.KubernetesSharedInformerFactory(), s.MonitoringClient))
+	urlruntime.Must(debugv1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes()))
 	urlruntime.Must(clusterkapisv1alpha1.AddToContainer(s.container,
 		s.InformerFactory.KubernetesSharedInformerFactory(),
 		s.InformerFactory.KubeSphereSharedInformerFactory(),
 		s.Config.MultiClusterOptions.ProxyPublishService,
 		s.Config.MultiClusterOptions.ProxyPublishAddress,
 		s.Config.MultiClusterOptions.AgentImage))
+	urlruntime.Must(searchv1alpha1.AddToContainer(s.container, s.InformerFactory.KubernetesSharedInformerFactory(),
+		rbacAuthorizer))
 	urlruntime.Must(iamapi.AddToContainer(s.container, imOperator, amOperator,
 		group.New(s.InformerFactory, s.KubernetesClient.KubeSphere(), s.KubernetesClient.Kubernetes()),
 		rbacAuthorizer))
@@ -252,7 +323,7 @@ func (s *APIServer) installKubeSphereAPIs() {
 			s.InformerFactory.KubeSphereSharedInformerFactory().Iam().V1alpha2().Users().Lister()),
 		s.Config.AuthenticationOptions))
 	urlruntime.Must(servicemeshv1alpha2.AddToContainer(s.container))
-	urlruntime.Must(networkv1alpha2.AddToContainer(s.container, s.Config.NetworkOptions.WeaveScopeHost))
+	urlruntime.Must(networkv1alpha2.AddToContainer(s.container, s.Config.NetworkOptions.WeaveScopeHost, s.Config.NetworkOptions.FlowSourceHost))
 	urlruntime.Must(devopsv1alpha2.AddToContainer(s.container,
 		s.InformerFactory.KubeSphereSharedInformerFactory(),
 		s.DevopsClient,
@@ -270,11 +341,43 @@ func (s *APIServer) installKubeSphereAPIs() {
 	urlruntime.Must(notificationv1.AddToContainer(s.container, s.Config.NotificationOptions.Endpoint))
 	urlruntime.Must(alertingv1.AddToContainer(s.container, s.Config.AlertingOptions.Endpoint))
 	urlruntime.Must(alertingv2alpha1.AddToContainer(s.container, s.InformerFactory,
-		s.KubernetesClient.Prometheus(), s.AlertingClient, s.Config.AlertingOptions))
+		s.KubernetesClient.Kubernetes(), s.KubernetesClient.Prometheus(), s.AlertingClient, s.Config.AlertingOptions,
+		rbacAuthorizer))
 	urlruntime.Must(version.AddToContainer(s.container, s.KubernetesClient.Discovery()))
 	urlruntime.Must(kubeedgev1alpha1.AddToContainer(s.container, s.Config.KubeEdgeOptions.Endpoint))
 	urlruntime.Must(notificationkapisv2beta1.AddToContainer(s.container, s.InformerFactory, s.KubernetesClient.Kubernetes(),
 		s.KubernetesClient.KubeSphere()))
+
+	if s.HarborClient != nil {
+		urlruntime.Must(harborv1alpha1.AddToContainer(s.container, s.HarborClient))
+	}
+
+	urlruntime.Must(vaultv1alpha1.AddToContainer(s.container, s.InformerFactory.KubernetesSharedInformerFactory()))
+	urlruntime.Must(nodegroupv1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes(),
+		s.KubernetesClient.KubeSphere(), s.InformerFactory.KubernetesSharedInformerFactory(), s.RuntimeCache))
+	urlruntime.Must(certsv1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes(),
+		s.KubernetesClient.Config(), s.KubernetesClient.KubeSphere()))
+
+	if s.EtcdClient != nil {
+		urlruntime.Must(etcdv1alpha1.AddToContainer(s.container, s.EtcdClient))
+	}
+
+	urlruntime.Must(autoscalingv1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.KubernetesClient.Config()))
+
+	kialiQueryHost := ""
+	if s.Config.ServiceMeshOptions != nil {
+		kialiQueryHost = s.Config.ServiceMeshOptions.KialiQueryHost
+	}
+	urlruntime.Must(topologyv1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), kialiQueryHost))
+	urlruntime.Must(installerv1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.KubernetesClient.Config()))
+	urlruntime.Must(inspectionv1alpha1.AddToContainer(s.container, dynamic.NewForConfigOrDie(s.KubernetesClient.Config())))
+	urlruntime.Must(licensev1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes()))
+	urlruntime.Must(announcementv1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes(),
+		dynamic.NewForConfigOrDie(s.KubernetesClient.Config())))
+	urlruntime.Must(incidentv1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes(),
+		dynamic.NewForConfigOrDie(s.KubernetesClient.Config())))
+	urlruntime.Must(consolev1alpha1.AddToContainer(s.container, dynamic.NewForConfigOrDie(s.KubernetesClient.Config())))
+	urlruntime.Must(dashboardsv1alpha1.AddToContainer(s.container, s.RuntimeCache, s.RuntimeClient, s.MonitoringClient))
 }
 
 func (s *APIServer) Run(stopCh <-chan struct{}) (err error) {
@@ -322,6 +425,9 @@ func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
 
 	handler := s.Server.Handler
 	handler = filters.WithKubeAPIServer(handler, s.KubernetesClient.Config(), &errorResponder{})
+	// kapis groups served by third party extensions, see APIService in
+	// extensions.kubesphere.io
+	handler = filters.WithAPIService(handler, s.RuntimeCache, &errorResponder{})
 
 	if s.Config.AuditingOptions.Enable {
 		handler = filters.WithAuditing(handler,
@@ -346,7 +452,24 @@ func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
 
 	handler = filters.WithAuthorization(handler, authorizers)
 	if s.Config.MultiClusterOptions.Enable {
-		clusterDispatcher := dispatch.NewClusterDispatch(s.InformerFactory.KubeSphereSharedInformerFactory().Cluster().V1alpha1().Clusters())
+		// when sharding is enabled each replica only keeps transports for the
+		// member clusters it owns, requests for the rest are forwarded to the
+		// owning replica
+		shards := s.Config.MultiClusterOptions.APIServerShards
+		localShard := s.Config.MultiClusterOptions.APIServerShard
+		if len(shards) > 1 {
+			shardRing := hashring.New(0)
+			shardRing.Add(shards...)
+			clusterclient.SetClusterOwnershipFilter(func(clusterName string) bool {
+				return shardRing.Get(clusterName) == localShard
+			})
+		}
+
+		var clusterDispatcher dispatch.Dispatcher = dispatch.NewClusterDispatch(s.InformerFactory.KubeSphereSharedInformerFactory().Cluster().V1alpha1().Clusters())
+		clusterDispatcher, err := dispatch.NewShardingDispatch(clusterDispatcher, localShard, shards)
+		if err != nil {
+			klog.Fatalf("Failed to enable apiserver sharding: %v", err)
+		}
 		handler = filters.WithMultipleClusterDispatcher(handler, clusterDispatcher)
 	}
 
@@ -46,6 +46,7 @@ type Backend struct {
 	getSenderTimeout   time.Duration
 	eventBatchSize     int
 	eventBatchInterval time.Duration
+	sinks              []*eventSink
 	stopCh             <-chan struct{}
 }
 
@@ -79,6 +80,14 @@ func NewBackend(opts *options.Options, cache chan *v1alpha1.Event, stopCh <-chan
 	}
 	b.senderCh = make(chan interface{}, sendersNum)
 
+	sinks, err := newEventSinks(opts.Sinks)
+	if err != nil {
+		// a misconfigured sink must not take auditing down
+		klog.Errorf("audit sinks disabled, %s", err)
+	} else {
+		b.sinks = sinks
+	}
+
 	b.client = http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{
@@ -106,6 +115,23 @@ func (b *Backend) worker() {
 		}
 
 		go b.sendEvents(events)
+		if len(b.sinks) > 0 {
+			go b.sendToSinks(events)
+		}
+	}
+}
+
+// sendToSinks streams the batch to the configured external sinks; each
+// sink only receives the events its rule selects.
+func (b *Backend) sendToSinks(events *v1alpha1.EventList) {
+	for _, sink := range b.sinks {
+		filtered := sink.filter(events)
+		if len(filtered.Items) == 0 {
+			continue
+		}
+		if err := sink.send(filtered); err != nil {
+			klog.Errorf("send %d audit events to sink %s error, %s", len(filtered.Items), sink.options.Name, err)
+		}
 	}
 }
 
@@ -0,0 +1,196 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+const (
+	SinkTypeWebhook = "webhook"
+	SinkTypeSplunk  = "splunk"
+	SinkTypeKafka   = "kafka"
+)
+
+// eventSink streams the audit events matched by its rule to one external
+// system, so security teams receive them in near-real-time instead of
+// exporting from elasticsearch periodically.
+type eventSink struct {
+	options options.SinkOptions
+	client  *http.Client
+}
+
+func newEventSink(opts options.SinkOptions) (*eventSink, error) {
+	switch opts.Type {
+	case SinkTypeWebhook, SinkTypeKafka:
+	case SinkTypeSplunk:
+		if opts.Token == "" {
+			return nil, fmt.Errorf("audit sink %s: splunk sinks require a token", opts.Name)
+		}
+	default:
+		return nil, fmt.Errorf("audit sink %s: unknown sink type %s", opts.Name, opts.Type)
+	}
+	if opts.Url == "" {
+		return nil, fmt.Errorf("audit sink %s: url must not be empty", opts.Name)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("audit sink %s: load client certificate failed, %v", opts.Name, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if opts.CAFile != "" {
+		ca, err := ioutil.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("audit sink %s: read CA failed, %v", opts.Name, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("audit sink %s: no certificate found in %s", opts.Name, opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &eventSink{
+		options: opts,
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+			Timeout:   SendTimeout,
+		},
+	}, nil
+}
+
+func newEventSinks(opts []options.SinkOptions) ([]*eventSink, error) {
+	sinks := make([]*eventSink, 0, len(opts))
+	for _, opt := range opts {
+		sink, err := newEventSink(opt)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// match applies the sink's rule to one event.
+func (s *eventSink) match(event *v1alpha1.Event) bool {
+	if !matchList(s.options.Verbs, event.Verb) {
+		return false
+	}
+	if !matchList(s.options.Workspaces, event.Workspace) {
+		return false
+	}
+	resource := ""
+	if event.ObjectRef != nil {
+		resource = event.ObjectRef.Resource
+	}
+	return matchList(s.options.Resources, resource)
+}
+
+func matchList(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the events of the batch selected by the sink's rule.
+func (s *eventSink) filter(events *v1alpha1.EventList) *v1alpha1.EventList {
+	filtered := &v1alpha1.EventList{}
+	for i := range events.Items {
+		if s.match(&events.Items[i]) {
+			filtered.Items = append(filtered.Items, events.Items[i])
+		}
+	}
+	return filtered
+}
+
+func (s *eventSink) send(events *v1alpha1.EventList) error {
+	body, contentType, err := s.payload(events)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, s.options.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", contentType)
+	if s.options.Type == SinkTypeSplunk {
+		request.Header.Set("Authorization", "Splunk "+s.options.Token)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("sink returned %s", response.Status)
+	}
+	return nil
+}
+
+// payload serializes the batch in the format the sink type expects.
+func (s *eventSink) payload(events *v1alpha1.EventList) ([]byte, string, error) {
+	switch s.options.Type {
+	case SinkTypeSplunk:
+		// one HEC envelope per line, batched in a single request
+		var buf bytes.Buffer
+		for i := range events.Items {
+			envelope := map[string]interface{}{
+				"event":      &events.Items[i],
+				"sourcetype": "kubesphere:auditing",
+			}
+			bs, err := json.Marshal(envelope)
+			if err != nil {
+				return nil, "", err
+			}
+			buf.Write(bs)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), "application/json", nil
+	case SinkTypeKafka:
+		// Kafka REST proxy produce format
+		records := make([]map[string]interface{}, 0, len(events.Items))
+		for i := range events.Items {
+			records = append(records, map[string]interface{}{"value": &events.Items[i]})
+		}
+		bs, err := json.Marshal(map[string]interface{}{"records": records})
+		return bs, "application/vnd.kafka.json.v2+json", err
+	default:
+		bs, err := json.Marshal(events)
+		return bs, "application/json", err
+	}
+}
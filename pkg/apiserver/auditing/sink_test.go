@@ -0,0 +1,112 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apiserver/pkg/apis/audit"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+func sinkEvents() *v1alpha1.EventList {
+	return &v1alpha1.EventList{
+		Items: []v1alpha1.Event{
+			{
+				Workspace: "system-workspace",
+				Event: audit.Event{
+					Verb:      "delete",
+					ObjectRef: &audit.ObjectReference{Resource: "namespaces"},
+				},
+			},
+			{
+				Workspace: "demo-workspace",
+				Event: audit.Event{
+					Verb:      "get",
+					ObjectRef: &audit.ObjectReference{Resource: "pods"},
+				},
+			},
+		},
+	}
+}
+
+func TestEventSinkFilter(t *testing.T) {
+	sink, err := newEventSink(options.SinkOptions{
+		Name:  "siem",
+		Type:  SinkTypeWebhook,
+		Url:   "https://siem.example.com/audit",
+		Verbs: []string{"create", "delete"},
+	})
+	assert.Nil(t, err)
+
+	filtered := sink.filter(sinkEvents())
+	assert.Equal(t, 1, len(filtered.Items))
+	assert.Equal(t, "delete", filtered.Items[0].Verb)
+
+	sink.options.Verbs = nil
+	sink.options.Workspaces = []string{"demo-workspace"}
+	filtered = sink.filter(sinkEvents())
+	assert.Equal(t, 1, len(filtered.Items))
+	assert.Equal(t, "demo-workspace", filtered.Items[0].Workspace)
+
+	sink.options.Workspaces = nil
+	sink.options.Resources = []string{"pods"}
+	filtered = sink.filter(sinkEvents())
+	assert.Equal(t, 1, len(filtered.Items))
+	assert.Equal(t, "pods", filtered.Items[0].ObjectRef.Resource)
+}
+
+func TestEventSinkSend(t *testing.T) {
+	var contentType, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhook, err := newEventSink(options.SinkOptions{Name: "hook", Type: SinkTypeWebhook, Url: server.URL})
+	assert.Nil(t, err)
+	assert.Nil(t, webhook.send(sinkEvents()))
+	assert.Equal(t, "application/json", contentType)
+
+	splunk, err := newEventSink(options.SinkOptions{Name: "hec", Type: SinkTypeSplunk, Url: server.URL, Token: "secret"})
+	assert.Nil(t, err)
+	assert.Nil(t, splunk.send(sinkEvents()))
+	assert.Equal(t, "Splunk secret", authorization)
+
+	kafka, err := newEventSink(options.SinkOptions{Name: "bus", Type: SinkTypeKafka, Url: server.URL})
+	assert.Nil(t, err)
+	assert.Nil(t, kafka.send(sinkEvents()))
+	assert.Equal(t, "application/vnd.kafka.json.v2+json", contentType)
+}
+
+func TestNewEventSinkValidation(t *testing.T) {
+	_, err := newEventSink(options.SinkOptions{Name: "bad", Type: "syslog", Url: "http://example.com"})
+	assert.NotNil(t, err)
+
+	_, err = newEventSink(options.SinkOptions{Name: "bad", Type: SinkTypeWebhook})
+	assert.NotNil(t, err)
+
+	_, err = newEventSink(options.SinkOptions{Name: "bad", Type: SinkTypeSplunk, Url: "http://example.com"})
+	assert.NotNil(t, err)
+}
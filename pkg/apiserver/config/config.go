@@ -26,7 +26,9 @@ import (
 	"kubesphere.io/kubesphere/pkg/simple/client/auditing"
 	"kubesphere.io/kubesphere/pkg/simple/client/cache"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops/jenkins"
+	"kubesphere.io/kubesphere/pkg/simple/client/etcd"
 	"kubesphere.io/kubesphere/pkg/simple/client/events"
+	"kubesphere.io/kubesphere/pkg/simple/client/harbor"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
 	"kubesphere.io/kubesphere/pkg/simple/client/kubeedge"
 	"kubesphere.io/kubesphere/pkg/simple/client/ldap"
@@ -99,6 +101,8 @@ type Config struct {
 	AlertingOptions       *alerting.Options                          `json:"alerting,omitempty" yaml:"alerting,omitempty" mapstructure:"alerting"`
 	NotificationOptions   *notification.Options                      `json:"notification,omitempty" yaml:"notification,omitempty" mapstructure:"notification"`
 	KubeEdgeOptions       *kubeedge.Options                          `json:"kubeedge,omitempty" yaml:"kubeedge,omitempty" mapstructure:"kubeedge"`
+	HarborOptions         *harbor.Options                            `json:"harbor,omitempty" yaml:"harbor,omitempty" mapstructure:"harbor"`
+	EtcdOptions           *etcd.Options                              `json:"etcd,omitempty" yaml:"etcd,omitempty" mapstructure:"etcd"`
 }
 
 // newConfig creates a default non-empty Config
@@ -123,6 +127,8 @@ func New() *Config {
 		EventsOptions:         events.NewEventsOptions(),
 		AuditingOptions:       auditing.NewAuditingOptions(),
 		KubeEdgeOptions:       kubeedge.NewKubeEdgeOptions(),
+		HarborOptions:         harbor.NewHarborOptions(),
+		EtcdOptions:           etcd.NewEtcdOptions(),
 	}
 }
 
@@ -285,4 +291,12 @@ func (conf *Config) stripEmptyOptions() {
 	if conf.KubeEdgeOptions != nil && conf.KubeEdgeOptions.Endpoint == "" {
 		conf.KubeEdgeOptions = nil
 	}
+
+	if conf.HarborOptions != nil && conf.HarborOptions.Host == "" {
+		conf.HarborOptions = nil
+	}
+
+	if conf.EtcdOptions != nil && len(conf.EtcdOptions.Endpoints) == 0 {
+		conf.EtcdOptions = nil
+	}
 }
@@ -29,7 +29,9 @@ import (
 	"kubesphere.io/kubesphere/pkg/simple/client/auditing"
 	"kubesphere.io/kubesphere/pkg/simple/client/cache"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops/jenkins"
+	"kubesphere.io/kubesphere/pkg/simple/client/etcd"
 	"kubesphere.io/kubesphere/pkg/simple/client/events"
+	"kubesphere.io/kubesphere/pkg/simple/client/harbor"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
 	"kubesphere.io/kubesphere/pkg/simple/client/kubeedge"
 	"kubesphere.io/kubesphere/pkg/simple/client/ldap"
@@ -153,7 +155,8 @@ func newTestConfig() (*Config, error) {
 			},
 		},
 		MultiClusterOptions: &multicluster.Options{
-			Enable: false,
+			Enable:          false,
+			APIServerShards: []string{},
 		},
 		EventsOptions: &events.Options{
 			Host:        "http://elasticsearch-logging-data.kubesphere-logging-system.svc:9200",
@@ -168,6 +171,17 @@ func newTestConfig() (*Config, error) {
 		KubeEdgeOptions: &kubeedge.Options{
 			Endpoint: "http://edge-watcher.kubeedge.svc/api/",
 		},
+		HarborOptions: &harbor.Options{
+			Host:     "https://harbor.kubesphere-system.svc",
+			Username: "admin",
+			Password: "Harbor12345",
+		},
+		EtcdOptions: &etcd.Options{
+			Endpoints: []string{"https://127.0.0.1:2379"},
+			CertFile:  "/etc/kubernetes/pki/etcd/healthcheck-client.crt",
+			KeyFile:   "/etc/kubernetes/pki/etcd/healthcheck-client.key",
+			CAFile:    "/etc/kubernetes/pki/etcd/ca.crt",
+		},
 	}
 	return conf, nil
 }
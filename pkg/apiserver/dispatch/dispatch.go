@@ -44,10 +44,14 @@ type Dispatcher interface {
 
 type clusterDispatch struct {
 	clusterclient.ClusterClients
+	transports *clusterTransports
 }
 
 func NewClusterDispatch(clusterInformer clusterinformer.ClusterInformer) Dispatcher {
-	return &clusterDispatch{clusterclient.NewClusterClient(clusterInformer)}
+	return &clusterDispatch{
+		ClusterClients: clusterclient.NewClusterClient(clusterInformer),
+		transports:     newClusterTransports(),
+	}
 }
 
 // Dispatch dispatch requests to designated cluster
@@ -78,17 +82,21 @@ func (c *clusterDispatch) Dispatch(w http.ResponseWriter, req *http.Request, han
 	}
 
 	if !c.IsClusterReady(cluster) {
+		// the agent is reconnecting, drop pooled connections so recovery
+		// dials a fresh tunnel instead of reusing a dead one
+		c.transports.forget(cluster.Name)
 		http.Error(w, fmt.Sprintf("cluster %s is not ready", cluster.Name), http.StatusBadRequest)
 		return
 	}
 
 	innCluster := c.GetInnerCluster(cluster.Name)
 	if innCluster == nil {
+		c.transports.forget(cluster.Name)
 		http.Error(w, fmt.Sprintf("cluster %s is not ready", cluster.Name), http.StatusBadRequest)
 		return
 	}
 
-	transport := http.DefaultTransport
+	var transport http.RoundTripper = http.DefaultTransport
 
 	// change request host to actually cluster hosts
 	u := *req.URL
@@ -125,21 +133,37 @@ func (c *clusterDispatch) Dispatch(w http.ResponseWriter, req *http.Request, han
 		if len(u.Query()["dryRun"]) != 0 {
 			req.URL.RawQuery = strings.Replace(req.URL.RawQuery, "dryRun", "dryrun", 1)
 		}
-
-		// kube-apiserver lost query string when proxy websocket requests, there are several issues opened
-		// tracking this, like https://github.com/kubernetes/kubernetes/issues/89360. Also there is a promising
-		// PR aim to fix this, but it's unlikely it will get merged soon. So here we are again. Put raw query
-		// string in Header and extract it on member cluster.
-		if httpstream.IsUpgradeRequest(req) && len(req.URL.RawQuery) != 0 {
-			req.Header.Set("X-KubeSphere-Rawquery", req.URL.RawQuery)
-		}
 	} else {
 		// everything else goes to ks-apiserver, since our ks-apiserver has the ability to proxy kube-apiserver requests
 
 		u.Host = innCluster.KubesphereURL.Host
 		u.Scheme = innCluster.KubesphereURL.Scheme
+
+		// reuse pooled connections per cluster so a console session sticks to
+		// the warm path through the agent tunnel across requests
+		transport = c.transports.get(cluster.Name)
+
+		// pass the user token along in the dedicated header as well, so the
+		// member cluster can authenticate the session even if an intermediate
+		// proxy strips the Authorization header
+		if authorization := req.Header.Get("Authorization"); len(authorization) != 0 {
+			req.Header.Set("X-KubeSphere-Authorization", authorization)
+		}
+	}
+
+	// kube-apiserver lost query string when proxy websocket requests, there are several issues opened
+	// tracking this, like https://github.com/kubernetes/kubernetes/issues/89360. Also there is a promising
+	// PR aim to fix this, but it's unlikely it will get merged soon. So here we are again. Put raw query
+	// string in Header and extract it on member cluster. The same applies to streams tunneled through
+	// tower, so the header is set for both connection types.
+	if httpstream.IsUpgradeRequest(req) && len(req.URL.RawQuery) != 0 {
+		req.Header.Set("X-KubeSphere-Rawquery", req.URL.RawQuery)
 	}
 
+	// retry across brief agent reconnects so long-lived streams being
+	// established (logs, exec) and console requests survive the hiccup
+	transport = newRetryRoundTripper(transport)
+
 	httpProxy := proxy.NewUpgradeAwareHandler(&u, transport, false, false, c)
 	httpProxy.UpgradeTransport = proxy.NewUpgradeRequestRoundTripper(transport, transport)
 	httpProxy.ServeHTTP(w, req)
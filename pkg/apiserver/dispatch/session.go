@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatch
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+)
+
+const (
+	// a member cluster agent reconnect is usually over within a second or
+	// two; retry replayable requests across that window instead of failing
+	// console sessions and stream setups outright
+	dialRetries      = 3
+	dialRetryBackoff = 500 * time.Millisecond
+)
+
+// clusterTransports keeps one keep-alive transport per member cluster, so
+// console sessions stick to the warm connections of the agent tunnel
+// instead of re-dialing through tower on every request.
+type clusterTransports struct {
+	mutex      sync.Mutex
+	transports map[string]http.RoundTripper
+}
+
+func newClusterTransports() *clusterTransports {
+	return &clusterTransports{transports: make(map[string]http.RoundTripper)}
+}
+
+func (c *clusterTransports) get(cluster string) http.RoundTripper {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if transport, ok := c.transports[cluster]; ok {
+		return transport
+	}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        32,
+		MaxIdleConnsPerHost: 32,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	c.transports[cluster] = transport
+	return transport
+}
+
+// forget drops the cached transport, closing its idle connections; used
+// when a cluster turns unready so a fresh agent connection is dialed on
+// recovery.
+func (c *clusterTransports) forget(cluster string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if transport, ok := c.transports[cluster]; ok {
+		if t, ok := transport.(*http.Transport); ok {
+			t.CloseIdleConnections()
+		}
+		delete(c.transports, cluster)
+	}
+}
+
+// retryRoundTripper retries requests that failed before any response was
+// received, smoothing over the brief window where the member cluster
+// agent reconnects. Responses already in flight are never replayed.
+type retryRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+func newRetryRoundTripper(delegate http.RoundTripper) http.RoundTripper {
+	return &retryRoundTripper{delegate: delegate}
+}
+
+func (r *retryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= dialRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * dialRetryBackoff)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, lastErr
+				}
+				req.Body = body
+			}
+			klog.V(4).Infof("retry request %s %s after connection error: %v", req.Method, req.URL.Path, lastErr)
+		}
+		response, err := r.delegate.RoundTrip(req)
+		if err == nil {
+			return response, nil
+		}
+		lastErr = err
+		if !replayable(req) || !isConnectionError(err) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// replayable reports whether the request can be safely re-sent: either it
+// has no body, or the body can be rewound.
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// isConnectionError reports whether the error happened while connecting,
+// before the member cluster saw the request.
+func isConnectionError(err error) bool {
+	if err == io.EOF {
+		return true
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return true
+	}
+	message := err.Error()
+	return strings.Contains(message, "connection refused") ||
+		strings.Contains(message, "connection reset by peer") ||
+		strings.Contains(message, "no such host") ||
+		strings.Contains(message, "EOF")
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatch
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type flakyRoundTripper struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, errors.New("dial tcp 10.0.0.1:443: connect: connection refused")
+	}
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestRetryRoundTripperRecovers(t *testing.T) {
+	delegate := &flakyRoundTripper{failures: 2}
+	rt := newRetryRoundTripper(delegate)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://member-cluster/api/v1/pods", nil)
+	response, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("expected retries to recover, got %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", response.StatusCode)
+	}
+	if delegate.calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", delegate.calls)
+	}
+}
+
+func TestRetryRoundTripperSkipsUnreplayableBody(t *testing.T) {
+	delegate := &flakyRoundTripper{failures: 1}
+	rt := newRetryRoundTripper(delegate)
+
+	req, _ := http.NewRequest(http.MethodPost, "http://member-cluster/api/v1/pods", bytes.NewBufferString("{}"))
+	// drop GetBody to simulate a streamed body that cannot be rewound
+	req.GetBody = nil
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Fatal("expected error for unreplayable request")
+	}
+	if delegate.calls != 1 {
+		t.Fatalf("expected a single attempt, got %d", delegate.calls)
+	}
+}
+
+func TestClusterTransportsSticky(t *testing.T) {
+	transports := newClusterTransports()
+
+	first := transports.get("member")
+	if first != transports.get("member") {
+		t.Fatal("expected the same transport for the same cluster")
+	}
+	if first == transports.get("other") {
+		t.Fatal("expected distinct transports per cluster")
+	}
+
+	transports.forget("member")
+	if first == transports.get("member") {
+		t.Fatal("expected a fresh transport after forget")
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dispatch
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"k8s.io/apimachinery/pkg/util/proxy"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/klog"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+	"kubesphere.io/kubesphere/pkg/utils/hashring"
+)
+
+// shardingDispatch assigns each member cluster to one ks-apiserver replica
+// on a consistent hash ring and forwards requests for clusters owned by
+// another replica to it, so every replica only keeps transports and caches
+// warm for its share of the clusters instead of all of them.
+type shardingDispatch struct {
+	inner      Dispatcher
+	ring       *hashring.Ring
+	localShard string
+	shardURLs  map[string]*url.URL
+}
+
+// NewShardingDispatch wraps the cluster dispatcher with consistent hash
+// routing across the given ks-apiserver shard addresses. localShard must be
+// one of shards; with fewer than two shards the inner dispatcher is
+// returned unchanged.
+func NewShardingDispatch(inner Dispatcher, localShard string, shards []string) (Dispatcher, error) {
+	if len(shards) < 2 {
+		return inner, nil
+	}
+
+	shardURLs := make(map[string]*url.URL, len(shards))
+	for _, shard := range shards {
+		shardURL, err := url.Parse(shard)
+		if err != nil || shardURL.Host == "" {
+			return nil, fmt.Errorf("invalid apiserver shard address %s", shard)
+		}
+		shardURLs[shard] = shardURL
+	}
+	if _, ok := shardURLs[localShard]; !ok {
+		return nil, fmt.Errorf("apiserver shard name %s is not one of the shard addresses", localShard)
+	}
+
+	ring := hashring.New(0)
+	ring.Add(shards...)
+
+	return &shardingDispatch{
+		inner:      inner,
+		ring:       ring,
+		localShard: localShard,
+		shardURLs:  shardURLs,
+	}, nil
+}
+
+func (s *shardingDispatch) Dispatch(w http.ResponseWriter, req *http.Request, handler http.Handler) {
+	info, _ := request.RequestInfoFrom(req.Context())
+
+	owner := s.ring.Get(info.Cluster)
+	if owner == "" || owner == s.localShard {
+		s.inner.Dispatch(w, req, handler)
+		return
+	}
+
+	klog.V(4).Infof("forward request for cluster %s to shard %s", info.Cluster, owner)
+	u := *s.shardURLs[owner]
+	u.Path = req.URL.Path
+	u.RawQuery = req.URL.RawQuery
+
+	transport := http.DefaultTransport
+	httpProxy := proxy.NewUpgradeAwareHandler(&u, transport, false, false, s)
+	httpProxy.UpgradeTransport = proxy.NewUpgradeRequestRoundTripper(transport, transport)
+	httpProxy.ServeHTTP(w, req)
+}
+
+func (s *shardingDispatch) Error(w http.ResponseWriter, req *http.Request, err error) {
+	responsewriters.InternalError(w, req, err)
+}
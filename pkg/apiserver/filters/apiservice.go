@@ -0,0 +1,242 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/proxy"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+
+	extensionsv1alpha1 "kubesphere.io/kubesphere/pkg/apis/extensions/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+)
+
+const (
+	defaultAPIServicePort = 443
+
+	// backend availability is cached between probes to keep the hot path cheap
+	healthCheckInterval = 30 * time.Second
+	healthCheckTimeout  = 5 * time.Second
+)
+
+// user identity verified by ks-apiserver is passed to the backend in
+// headers, the same convention the kubernetes aggregation layer uses
+const (
+	headerRemoteUser  = "X-Remote-User"
+	headerRemoteGroup = "X-Remote-Group"
+)
+
+type apiServiceBackend struct {
+	url       *url.URL
+	transport http.RoundTripper
+
+	healthCheckURL string
+
+	// last probe result, refreshed at most every healthCheckInterval
+	lastProbeTime time.Time
+	available     bool
+}
+
+type apiServiceDispatcher struct {
+	next   http.Handler
+	cache  runtimecache.Cache
+	failed proxy.ErrorResponder
+
+	mutex    sync.Mutex
+	backends map[string]*apiServiceBackend
+}
+
+// WithAPIService proxies requests for kapis groups registered by third party
+// extensions through APIService objects. Authentication and authorization
+// already happened in the outer filters, the verified user identity is
+// passed to the backend in headers. Backends failing their health check get
+// 503 instead of a proxied request.
+func WithAPIService(handler http.Handler, cache runtimecache.Cache, failed proxy.ErrorResponder) http.Handler {
+	dispatcher := &apiServiceDispatcher{
+		next:     handler,
+		cache:    cache,
+		failed:   failed,
+		backends: make(map[string]*apiServiceBackend),
+	}
+	return dispatcher
+}
+
+func (d *apiServiceDispatcher) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	info, ok := request.RequestInfoFrom(req.Context())
+	if !ok || info.IsKubernetesRequest || info.APIGroup == "" {
+		d.next.ServeHTTP(w, req)
+		return
+	}
+
+	apiServices := &extensionsv1alpha1.APIServiceList{}
+	if err := d.cache.List(req.Context(), apiServices); err != nil {
+		// the APIService CRD may not be installed at all
+		klog.V(4).Infof("list apiservices: %v", err)
+		d.next.ServeHTTP(w, req)
+		return
+	}
+
+	for i := range apiServices.Items {
+		apiService := &apiServices.Items[i]
+		if apiService.Spec.Group != info.APIGroup || apiService.Spec.Version != info.APIVersion {
+			continue
+		}
+
+		backend, err := d.backendFor(apiService)
+		if err != nil {
+			klog.Errorf("apiservice %s is misconfigured: %v", apiService.Name, err)
+			http.Error(w, fmt.Sprintf("apiservice %s is misconfigured", apiService.Name), http.StatusServiceUnavailable)
+			return
+		}
+		if !d.isAvailable(backend) {
+			http.Error(w, fmt.Sprintf("apiservice %s is unavailable", apiService.Name), http.StatusServiceUnavailable)
+			return
+		}
+
+		u := *backend.url
+		u.Path = req.URL.Path
+		u.RawQuery = req.URL.RawQuery
+
+		// replace the client's credentials with the identity ks-apiserver verified
+		req.Header.Del("Authorization")
+		req.Header.Del(headerRemoteUser)
+		req.Header.Del(headerRemoteGroup)
+		if user, ok := apirequest.UserFrom(req.Context()); ok {
+			req.Header.Set(headerRemoteUser, user.GetName())
+			for _, group := range user.GetGroups() {
+				req.Header.Add(headerRemoteGroup, group)
+			}
+		}
+
+		httpProxy := proxy.NewUpgradeAwareHandler(&u, backend.transport, false, false, d.failed)
+		httpProxy.UpgradeTransport = proxy.NewUpgradeRequestRoundTripper(backend.transport, backend.transport)
+		httpProxy.ServeHTTP(w, req)
+		return
+	}
+
+	d.next.ServeHTTP(w, req)
+}
+
+// backendFor resolves the endpoint and transport of an APIService, reusing
+// previously built transports as long as the spec does not change.
+func (d *apiServiceDispatcher) backendFor(apiService *extensionsv1alpha1.APIService) (*apiServiceBackend, error) {
+	key := fmt.Sprintf("%s/%d", apiService.Name, apiService.Generation)
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if backend, ok := d.backends[key]; ok {
+		return backend, nil
+	}
+
+	endpoint, err := endpointFor(&apiService.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := transportFor(&apiService.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	healthCheckPath := apiService.Spec.HealthCheckPath
+	if healthCheckPath == "" {
+		healthCheckPath = extensionsv1alpha1.DefaultHealthCheckPath
+	}
+	healthCheckURL := *endpoint
+	healthCheckURL.Path = healthCheckPath
+
+	backend := &apiServiceBackend{
+		url:            endpoint,
+		transport:      transport,
+		healthCheckURL: healthCheckURL.String(),
+	}
+	// drop backends built from older generations of the same object
+	for k := range d.backends {
+		if k != key && d.backends[k].url.Host == backend.url.Host {
+			delete(d.backends, k)
+		}
+	}
+	d.backends[key] = backend
+	return backend, nil
+}
+
+func endpointFor(spec *extensionsv1alpha1.APIServiceSpec) (*url.URL, error) {
+	if spec.URL != "" {
+		endpoint, err := url.Parse(spec.URL)
+		if err != nil || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid url %s", spec.URL)
+		}
+		return endpoint, nil
+	}
+	if spec.Service == nil {
+		return nil, fmt.Errorf("neither url nor service is specified")
+	}
+	port := int32(defaultAPIServicePort)
+	if spec.Service.Port != nil {
+		port = *spec.Service.Port
+	}
+	return &url.URL{
+		Scheme: "https",
+		Host:   fmt.Sprintf("%s.%s.svc:%d", spec.Service.Name, spec.Service.Namespace, port),
+	}, nil
+}
+
+func transportFor(spec *extensionsv1alpha1.APIServiceSpec) (http.RoundTripper, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: spec.InsecureSkipTLSVerify,
+	}
+	if len(spec.CABundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(spec.CABundle) {
+			return nil, fmt.Errorf("invalid caBundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return &http.Transport{TLSClientConfig: tlsConfig}, nil
+}
+
+func (d *apiServiceDispatcher) isAvailable(backend *apiServiceBackend) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if time.Since(backend.lastProbeTime) < healthCheckInterval {
+		return backend.available
+	}
+
+	client := &http.Client{Transport: backend.transport, Timeout: healthCheckTimeout}
+	resp, err := client.Get(backend.healthCheckURL)
+	if err != nil {
+		klog.Warningf("apiservice backend %s is unreachable: %v", backend.healthCheckURL, err)
+		backend.available = false
+	} else {
+		resp.Body.Close()
+		backend.available = resp.StatusCode == http.StatusOK
+		if !backend.available {
+			klog.Warningf("apiservice backend %s health check returned %s", backend.healthCheckURL, resp.Status)
+		}
+	}
+	backend.lastProbeTime = time.Now()
+	return backend.available
+}
@@ -27,6 +27,7 @@ import (
 
 	"kubesphere.io/kubesphere/pkg/apiserver/request"
 	"kubesphere.io/kubesphere/pkg/server/errors"
+	"kubesphere.io/kubesphere/pkg/utils/tracing"
 )
 
 // WithKubeAPIServer proxy request to kubernetes service if requests path starts with /api
@@ -53,7 +54,8 @@ func WithKubeAPIServer(handler http.Handler, config *rest.Config, failed proxy.E
 
 			// make sure we don't override kubernetes's authorization
 			req.Header.Del("Authorization")
-			httpProxy := proxy.NewUpgradeAwareHandler(&s, defaultTransport, true, false, failed)
+			// trace proxied calls so slow requests can be attributed to the kubernetes API
+			httpProxy := proxy.NewUpgradeAwareHandler(&s, tracing.InstrumentRoundTripper("kubernetes", defaultTransport), true, false, failed)
 			httpProxy.UpgradeTransport = proxy.NewUpgradeRequestRoundTripper(defaultTransport, defaultTransport)
 			httpProxy.ServeHTTP(w, req)
 			return
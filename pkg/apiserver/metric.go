@@ -29,9 +29,20 @@ var (
 		[]string{"verb", "group", "version", "resource"},
 	)
 
+	HandlerLatencies = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Name:           "ks_server_handler_duration_seconds",
+			Help:           "Response latency distribution in seconds for each verb and handler route, to locate the exact slow handler.",
+			Buckets:        []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"verb", "handler"},
+	)
+
 	metricsList = []compbasemetrics.Registerable{
 		RequestCounter,
 		RequestLatencies,
+		HandlerLatencies,
 	}
 )
 
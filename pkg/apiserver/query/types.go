@@ -17,10 +17,13 @@ limitations under the License.
 package query
 
 import (
+	"encoding/base64"
+	"fmt"
 	"github.com/emicklei/go-restful"
 	"k8s.io/apimachinery/pkg/labels"
 	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -31,6 +34,8 @@ const (
 	ParameterLimit         = "limit"
 	ParameterOrderBy       = "sortBy"
 	ParameterAscending     = "ascending"
+	ParameterFields        = "fields"
+	ParameterCursor        = "cursor"
 )
 
 // Query represents api search terms
@@ -47,6 +52,9 @@ type Query struct {
 	Filters map[Field]Value
 
 	LabelSelector string
+
+	// project result items to these fields only, e.g. fields=metadata.name,status.phase
+	Fields []string
 }
 
 type Pagination struct {
@@ -125,7 +133,14 @@ func ParseQueryParameter(request *restful.Request) *Query {
 		page = 1
 	}
 
-	query.Pagination = newPagination(limit, (page-1)*limit)
+	offset := (page - 1) * limit
+	// a cursor returned by a previous page supersedes page-based offsets
+	if cursor := request.QueryParameter(ParameterCursor); cursor != "" {
+		if decoded, err := DecodeCursor(cursor); err == nil {
+			offset = decoded
+		}
+	}
+	query.Pagination = newPagination(limit, offset)
 
 	query.SortBy = Field(defaultString(request.QueryParameter(ParameterOrderBy), FieldCreationTimeStamp))
 
@@ -138,8 +153,12 @@ func ParseQueryParameter(request *restful.Request) *Query {
 
 	query.LabelSelector = request.QueryParameter(ParameterLabelSelector)
 
+	if fields := request.QueryParameter(ParameterFields); fields != "" {
+		query.Fields = strings.Split(fields, ",")
+	}
+
 	for key, values := range request.Request.URL.Query() {
-		if !sliceutil.HasString([]string{ParameterPage, ParameterLimit, ParameterOrderBy, ParameterAscending, ParameterLabelSelector}, key) {
+		if !sliceutil.HasString([]string{ParameterPage, ParameterLimit, ParameterOrderBy, ParameterAscending, ParameterLabelSelector, ParameterFields, ParameterCursor}, key) {
 			// support multiple query condition
 			for _, value := range values {
 				query.Filters[Field(key)] = Value(value)
@@ -156,3 +175,21 @@ func defaultString(value, defaultValue string) string {
 	}
 	return value
 }
+
+// EncodeCursor encodes the offset of the next page into an opaque cursor
+// clients pass back as-is.
+func EncodeCursor(offset int) string {
+	return base64.URLEncoding.EncodeToString([]byte(fmt.Sprintf("offset:%d", offset)))
+}
+
+func DecodeCursor(cursor string) (int, error) {
+	decoded, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(decoded), "offset:"))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return offset, nil
+}
@@ -42,6 +42,9 @@ const (
 	ChartApplicationVersionIdLabelKey = "application.kubesphere.io/app-version-id"
 	CategoryIdLabelKey                = "application.kubesphere.io/app-category-id"
 	CreatorAnnotationKey              = "kubesphere.io/creator"
+	ArchiveAnnotationKey              = "namespace.kubesphere.io/archive"
+	ArchivedAtAnnotationKey           = "namespace.kubesphere.io/archived-at"
+	DeleteAfterAnnotationKey          = "namespace.kubesphere.io/delete-after"
 	UsernameLabelKey                  = "kubesphere.io/username"
 	DevOpsProjectLabelKey             = "kubesphere.io/devopsproject"
 	KubefedManagedLabel               = "kubefed.io/managed"
@@ -78,11 +81,31 @@ const (
 	DevOpsScmTag         = "DevOps Scm"
 	DevOpsJenkinsTag     = "Jenkins"
 
-	ToolboxTag      = "Toolbox"
-	RegistryTag     = "Docker Registry"
-	GitTag          = "Git"
-	TerminalTag     = "Terminal"
-	MultiClusterTag = "Multi-cluster"
+	ToolboxTag          = "Toolbox"
+	RegistryTag         = "Docker Registry"
+	GitTag              = "Git"
+	TerminalTag         = "Terminal"
+	MultiClusterTag     = "Multi-cluster"
+	BackupTag           = "Backup"
+	StorageAnalyticsTag = "Storage Analytics"
+	RecommendationTag   = "Recommendation"
+	PodSecurityTag      = "Pod Security"
+	RuntimeSecurityTag  = "Runtime Security"
+	HarborTag           = "Harbor"
+	VaultTag            = "Vault"
+	NodeGroupTag        = "Node Group"
+	CertificateTag      = "Certificate"
+	EtcdTag             = "Etcd"
+	AutoscalingTag      = "Autoscaling"
+	TopologyTag         = "Topology"
+	InstallerTag        = "Installer"
+	DashboardTag        = "Dashboard"
+	InspectionTag       = "Cluster Inspection"
+	LicenseTag          = "License"
+	AnnouncementTag     = "Announcement"
+	IncidentTag         = "Incident"
+	ConsoleTag          = "Console"
+	SearchTag           = "Search"
 
 	WorkspaceTag     = "Workspace"
 	NamespaceTag     = "Namespace"
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package certexpiry periodically inventories cluster certificates and
+// raises warning events for certificates close to expiry, which the
+// notification pipeline forwards to the configured receivers.
+package certexpiry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/certs"
+)
+
+const (
+	// defaultCheckInterval is how often the inventory runs, one event per
+	// certificate is raised per interval while it stays close to expiry
+	defaultCheckInterval = 24 * time.Hour
+
+	reasonExpiringSoon = "CertificateExpiringSoon"
+	reasonExpired      = "CertificateExpired"
+)
+
+// Controller scans cluster certificates and raises expiry warnings.
+type Controller struct {
+	client        kubernetes.Interface
+	operator      certs.Operator
+	checkInterval time.Duration
+}
+
+func NewController(client kubernetes.Interface, operator certs.Operator) *Controller {
+	return &Controller{
+		client:        client,
+		operator:      operator,
+		checkInterval: defaultCheckInterval,
+	}
+}
+
+func (c *Controller) Start(stopCh <-chan struct{}) error {
+	wait.Until(c.check, c.checkInterval, stopCh)
+	return nil
+}
+
+func (c *Controller) check() {
+	certificates, err := c.operator.ListCertificates()
+	if err != nil {
+		klog.Errorf("failed to inventory cluster certificates: %v", err)
+		return
+	}
+	for _, certificate := range certificates {
+		if !certificate.Expired && !certificate.ExpiringSoon {
+			continue
+		}
+		if err := c.warn(certificate); err != nil {
+			klog.Errorf("failed to record certificate expiry warning: %v", err)
+		}
+	}
+}
+
+func (c *Controller) warn(certificate certs.Certificate) error {
+	reason := reasonExpiringSoon
+	message := fmt.Sprintf("certificate %s of cluster %s expires in %d days, at %s",
+		certificate.Component, certificate.Cluster, certificate.DaysRemaining, certificate.NotAfter.Format(time.RFC3339))
+	if certificate.Expired {
+		reason = reasonExpired
+		message = fmt.Sprintf("certificate %s of cluster %s expired at %s",
+			certificate.Component, certificate.Cluster, certificate.NotAfter.Format(time.RFC3339))
+	}
+	if certificate.Node != "" {
+		message = fmt.Sprintf("%s, node %s", message, certificate.Node)
+	}
+
+	now := metav1.Now()
+	name := fmt.Sprintf("cert-expiry.%s.%s", certificate.Cluster, certificate.Component)
+	if certificate.Node != "" {
+		name = fmt.Sprintf("%s.%s", name, certificate.Node)
+	}
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			// one event per certificate and day, repeated creates within
+			// the same day are dropped as already existing
+			Name:      fmt.Sprintf("%s.%s", name, now.Format("20060102")),
+			Namespace: constants.KubeSphereNamespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      "Cluster",
+			Name:      certificate.Cluster,
+			Namespace: constants.KubeSphereNamespace,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           corev1.EventTypeWarning,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Source: corev1.EventSource{
+			Component: "certexpiry-controller",
+		},
+	}
+	_, err := c.client.CoreV1().Events(constants.KubeSphereNamespace).Create(context.Background(), event, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
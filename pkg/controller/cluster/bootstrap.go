@@ -0,0 +1,303 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+
+	clusterv1alpha1 "kubesphere.io/kubesphere/pkg/apis/cluster/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+// Agent bootstrap automates joining a proxy connection cluster once a
+// kubeconfig is provided: prerequisites are checked, the agent deployment is
+// applied to the member cluster, and progress is reported through the
+// cluster conditions, replacing the copy-paste YAML bootstrap.
+
+const (
+	agentDeploymentName = "cluster-agent"
+	agentServiceAccount = "kubesphere"
+
+	// the oldest kubernetes minor version the agent supports
+	minimumKubernetesMinor = 15
+
+	// installerGroupVersion is the CRD group of ks-installer, the cheapest
+	// signal that KubeSphere has been installed on the member cluster
+	installerGroupVersion = "installer.kubesphere.io/v1alpha1"
+
+	// condition reasons reported while bootstrapping the agent
+	reasonPrerequisitesNotMet = "PrerequisitesNotMet"
+	reasonWaitingForToken     = "WaitingForToken"
+	reasonAgentDeployFailed   = "AgentDeployFailed"
+	reasonAgentDeployed       = "AgentDeployed"
+)
+
+// bootstrapAgent deploys the cluster agent into the member cluster through
+// the provided kubeconfig. Errors are reflected in the Initialized condition
+// of the cluster before they are returned, so users can follow the progress
+// on the Cluster resource.
+func (c *clusterController) bootstrapAgent(cluster *clusterv1alpha1.Cluster, clusterDt *clusterData) error {
+	if err := checkPrerequisites(clusterDt); err != nil {
+		c.updateClusterCondition(cluster, clusterv1alpha1.ClusterCondition{
+			Type:               clusterv1alpha1.ClusterInitialized,
+			Status:             v1.ConditionFalse,
+			LastUpdateTime:     metav1.Now(),
+			LastTransitionTime: metav1.Now(),
+			Reason:             reasonPrerequisitesNotMet,
+			Message:            err.Error(),
+		})
+		return err
+	}
+
+	// the token is issued by ks-apiserver when the cluster is created, a
+	// missing token means the cluster object has not been admitted yet
+	if len(cluster.Spec.Connection.Token) == 0 {
+		c.updateClusterCondition(cluster, clusterv1alpha1.ClusterCondition{
+			Type:               clusterv1alpha1.ClusterInitialized,
+			Status:             v1.ConditionFalse,
+			LastUpdateTime:     metav1.Now(),
+			LastTransitionTime: metav1.Now(),
+			Reason:             reasonWaitingForToken,
+			Message:            "Agent token has not been issued yet",
+		})
+		return fmt.Errorf("agent token of cluster %s has not been issued yet", cluster.Name)
+	}
+
+	proxyAddress, err := c.resolveProxyAddress()
+	if err == nil {
+		err = c.deployAgent(cluster, clusterDt, proxyAddress)
+	}
+	if err != nil {
+		c.updateClusterCondition(cluster, clusterv1alpha1.ClusterCondition{
+			Type:               clusterv1alpha1.ClusterInitialized,
+			Status:             v1.ConditionFalse,
+			LastUpdateTime:     metav1.Now(),
+			LastTransitionTime: metav1.Now(),
+			Reason:             reasonAgentDeployFailed,
+			Message:            err.Error(),
+		})
+		return err
+	}
+
+	c.updateClusterCondition(cluster, clusterv1alpha1.ClusterCondition{
+		Type:               clusterv1alpha1.ClusterInitialized,
+		Status:             v1.ConditionTrue,
+		LastUpdateTime:     metav1.Now(),
+		LastTransitionTime: metav1.Now(),
+		Reason:             reasonAgentDeployed,
+		Message:            "Agent has been deployed to the member cluster",
+	})
+
+	c.updateAgentAvailableCondition(cluster, clusterDt)
+	return nil
+}
+
+// checkPrerequisites verifies the member cluster is reachable, runs a
+// supported kubernetes version and has KubeSphere installed before the agent
+// is deployed.
+func checkPrerequisites(clusterDt *clusterData) error {
+	version, err := clusterDt.client.Discovery().ServerVersion()
+	if err != nil {
+		return fmt.Errorf("member cluster is not reachable, %v", err)
+	}
+
+	// some vendors suffix the minor version, e.g. 15+
+	minor, err := strconv.Atoi(strings.TrimSuffix(version.Minor, "+"))
+	if err == nil && minor < minimumKubernetesMinor {
+		return fmt.Errorf("kubernetes version %s is not supported, at least 1.%d is required",
+			version.GitVersion, minimumKubernetesMinor)
+	}
+
+	if _, err = clusterDt.client.Discovery().ServerResourcesForGroupVersion(installerGroupVersion); err != nil {
+		return fmt.Errorf("CRD group %s not found, please install KubeSphere on the member cluster first", installerGroupVersion)
+	}
+
+	// the agent runs with the kubesphere service account
+	if _, err = clusterDt.client.CoreV1().ServiceAccounts(constants.KubeSphereNamespace).
+		Get(context.TODO(), agentServiceAccount, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("service account %s/%s not found on the member cluster, %v",
+			constants.KubeSphereNamespace, agentServiceAccount, err)
+	}
+
+	return nil
+}
+
+// resolveProxyAddress returns the public address of tower, using the ingress
+// address of the proxy service when no address is configured explicitly.
+func (c *clusterController) resolveProxyAddress() (string, error) {
+	if len(c.proxyAddress) != 0 {
+		return c.proxyAddress, nil
+	}
+
+	if len(c.proxyService) == 0 {
+		return "", fmt.Errorf("neither proxy address nor proxy service provided")
+	}
+
+	namespace := constants.KubeSphereNamespace
+	parts := strings.Split(c.proxyService, ".")
+	if len(parts) > 1 && len(parts[1]) != 0 {
+		namespace = parts[1]
+	}
+
+	service, err := c.client.CoreV1().Services(namespace).Get(context.TODO(), parts[0], metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("proxy service %s not found in namespace %s", parts[0], namespace)
+	}
+
+	if len(service.Spec.Ports) == 0 {
+		return "", fmt.Errorf("there are no ports in proxy service %s spec", c.proxyService)
+	}
+
+	port := service.Spec.Ports[0].Port
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if len(ingress.Hostname) != 0 {
+			return fmt.Sprintf("http://%s:%d", ingress.Hostname, port), nil
+		}
+		if len(ingress.IP) != 0 {
+			return fmt.Sprintf("http://%s:%d", ingress.IP, port), nil
+		}
+	}
+
+	return "", fmt.Errorf("proxy service %s has no public address, please check its status "+
+		"or set proxy publish address manually in ClusterConfiguration", c.proxyService)
+}
+
+// deployAgent creates or updates the agent deployment on the member cluster.
+func (c *clusterController) deployAgent(cluster *clusterv1alpha1.Cluster, clusterDt *clusterData, proxyAddress string) error {
+	agent := newAgentDeployment(cluster, proxyAddress, c.agentImage)
+
+	existing, err := clusterDt.client.AppsV1().Deployments(constants.KubeSphereNamespace).
+		Get(context.TODO(), agentDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = clusterDt.client.AppsV1().Deployments(constants.KubeSphereNamespace).
+			Create(context.TODO(), agent, metav1.CreateOptions{})
+		if err == nil {
+			klog.V(2).Infof("deployed agent to cluster %s", cluster.Name)
+		}
+		return err
+	}
+
+	if equality.Semantic.DeepDerivative(agent.Spec, existing.Spec) {
+		return nil
+	}
+
+	existing.Spec = agent.Spec
+	_, err = clusterDt.client.AppsV1().Deployments(constants.KubeSphereNamespace).
+		Update(context.TODO(), existing, metav1.UpdateOptions{})
+	if err == nil {
+		klog.V(2).Infof("updated agent of cluster %s", cluster.Name)
+	}
+	return err
+}
+
+// updateAgentAvailableCondition reports the agent rollout progress so users
+// can follow the remaining bootstrap steps on the Cluster resource.
+func (c *clusterController) updateAgentAvailableCondition(cluster *clusterv1alpha1.Cluster, clusterDt *clusterData) {
+	deployment, err := clusterDt.client.AppsV1().Deployments(constants.KubeSphereNamespace).
+		Get(context.TODO(), agentDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return
+	}
+
+	condition := clusterv1alpha1.ClusterCondition{
+		Type:               clusterv1alpha1.ClusterAgentAvailable,
+		Status:             v1.ConditionFalse,
+		LastUpdateTime:     metav1.Now(),
+		LastTransitionTime: metav1.Now(),
+		Reason:             "AgentNotReady",
+		Message:            "Agent has been deployed, waiting for the rollout to complete",
+	}
+	if deployment.Status.ReadyReplicas > 0 {
+		condition.Status = v1.ConditionTrue
+		condition.Reason = "AgentReady"
+		condition.Message = "Agent is connected to the proxy"
+	}
+	c.updateClusterCondition(cluster, condition)
+}
+
+// newAgentDeployment keeps the deployment consistent with the yaml generated
+// by the cluster kapis for manual bootstrap.
+func newAgentDeployment(cluster *clusterv1alpha1.Cluster, proxyAddress, agentImage string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      agentDeploymentName,
+			Namespace: constants.KubeSphereNamespace,
+			Labels: map[string]string{
+				kubesphereManaged: "true",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app":                       "agent",
+					"app.kubernetes.io/part-of": "tower",
+				},
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app":                       "agent",
+						"app.kubernetes.io/part-of": "tower",
+					},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name: "agent",
+							Command: []string{
+								"/agent",
+								fmt.Sprintf("--name=%s", cluster.Name),
+								fmt.Sprintf("--token=%s", cluster.Spec.Connection.Token),
+								fmt.Sprintf("--proxy-server=%s", proxyAddress),
+								"--keepalive=10s",
+								"--kubesphere-service=ks-apiserver.kubesphere-system.svc:80",
+								"--kubernetes-service=kubernetes.default.svc:443",
+								"--v=0",
+							},
+							Image: agentImage,
+							Resources: v1.ResourceRequirements{
+								Limits: v1.ResourceList{
+									v1.ResourceCPU:    resource.MustParse("1"),
+									v1.ResourceMemory: resource.MustParse("200M"),
+								},
+								Requests: v1.ResourceList{
+									v1.ResourceCPU:    resource.MustParse("100m"),
+									v1.ResourceMemory: resource.MustParse("100M"),
+								},
+							},
+						},
+					},
+					ServiceAccountName: agentServiceAccount,
+				},
+			},
+		},
+	}
+}
@@ -51,6 +51,7 @@ import (
 	clusterclient "kubesphere.io/kubesphere/pkg/client/clientset/versioned/typed/cluster/v1alpha1"
 	clusterinformer "kubesphere.io/kubesphere/pkg/client/informers/externalversions/cluster/v1alpha1"
 	clusterlister "kubesphere.io/kubesphere/pkg/client/listers/cluster/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/simple/client/multicluster"
 )
 
 // Cluster controller only runs under multicluster mode. Cluster controller is following below steps,
@@ -152,7 +153,15 @@ type clusterController struct {
 
 	clusterMap map[string]*clusterData
 
+	// guards cluster syncs with per-cluster rate limits and backpressure
+	guard *syncGuard
+
 	resyncPeriod time.Duration
+
+	// used to bootstrap agents for proxy connection clusters
+	proxyService string
+	proxyAddress string
+	agentImage   string
 }
 
 func NewClusterController(
@@ -160,8 +169,9 @@ func NewClusterController(
 	config *rest.Config,
 	clusterInformer clusterinformer.ClusterInformer,
 	clusterClient clusterclient.ClusterInterface,
-	resyncPeriod time.Duration,
+	options *multicluster.Options,
 ) *clusterController {
+	registerMetrics()
 
 	broadcaster := record.NewBroadcaster()
 	broadcaster.StartLogging(func(format string, args ...interface{}) {
@@ -179,7 +189,11 @@ func NewClusterController(
 		queue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "cluster"),
 		workerLoopPeriod: time.Second,
 		clusterMap:       make(map[string]*clusterData),
-		resyncPeriod:     resyncPeriod,
+		guard:            newSyncGuard(),
+		resyncPeriod:     options.ClusterControllerResyncSecond,
+		proxyService:     options.ProxyPublishService,
+		proxyAddress:     options.ProxyPublishAddress,
+		agentImage:       options.AgentImage,
 	}
 	c.clusterLister = clusterInformer.Lister()
 	c.clusterHasSynced = clusterInformer.Informer().HasSynced
@@ -190,7 +204,7 @@ func NewClusterController(
 			c.addCluster(newObj)
 		},
 		DeleteFunc: c.addCluster,
-	}, resyncPeriod)
+	}, options.ClusterControllerResyncSecond)
 
 	return c
 }
@@ -243,8 +257,36 @@ func (c *clusterController) processNextItem() bool {
 
 	defer c.queue.Done(key)
 
-	err := c.syncCluster(key.(string))
-	c.handleErr(err, key)
+	name := key.(string)
+	switch c.guard.admit(name) {
+	case admitThrottled:
+		// the cluster synced too often recently, delay further syncs so a
+		// flapping cluster cannot monopolize the controller
+		clusterSyncThrottled.WithLabelValues(name).Inc()
+		c.queue.Forget(key)
+		c.queue.AddAfter(key, throttleRequeueDelay)
+		return true
+	case admitCoalesced:
+		// already being synced, the running sync requeues the cluster when
+		// it finishes
+		c.queue.Forget(key)
+		return true
+	case admitBlocked:
+		// all sync slots taken, apply backpressure
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	// run the sync in its own goroutine so one slow cluster cannot occupy
+	// a shared queue worker, the guard serializes syncs per cluster
+	go func() {
+		defer c.guard.release(name, func() { c.queue.Add(name) })
+
+		start := time.Now()
+		err := c.syncCluster(name)
+		clusterSyncDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+		c.handleErr(err, name)
+	}()
 	return true
 }
 
@@ -442,6 +484,7 @@ func (c *clusterController) syncCluster(key string) error {
 		// cluster not found, possibly been deleted
 		// need to do the cleanup
 		if errors.IsNotFound(err) {
+			c.guard.forget(name)
 			return nil
 		}
 
@@ -510,6 +553,20 @@ func (c *clusterController) syncCluster(key string) error {
 	}
 	c.mu.Unlock()
 
+	// a proxy connection cluster imported with a kubeconfig gets its agent
+	// deployed automatically, no more copy-paste YAML bootstrap
+	if cluster.Spec.Connection.Type == clusterv1alpha1.ConnectionTypeProxy {
+		if err = c.bootstrapAgent(cluster, clusterDt); err != nil {
+			klog.Errorf("Failed to bootstrap agent for cluster %s, error %v", cluster.Name, err)
+			c.eventRecorder.Event(cluster, v1.EventTypeWarning, "AgentBootstrap", err.Error())
+
+			if _, updateErr := c.clusterClient.Update(context.TODO(), cluster, metav1.UpdateOptions{}); updateErr != nil {
+				klog.Errorf("Failed to update cluster status, %#v", updateErr)
+			}
+			return err
+		}
+	}
+
 	if !cluster.Spec.JoinFederation { // trying to unJoin federation
 		err = c.unJoinFederation(clusterDt.config, cluster.Name)
 		if err != nil {
@@ -669,6 +726,7 @@ func (c *clusterController) addCluster(obj interface{}) {
 		return
 	}
 
+	c.guard.markEnqueued(key)
 	c.queue.Add(key)
 }
 
@@ -0,0 +1,149 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// Guardrails for cluster syncs. Syncing a member cluster involves several
+// network round trips with multi-second timeouts, so without bounds one
+// flapping cluster with a long backlog can occupy all shared workers and
+// starve reconciliation of the others. The guard serializes syncs per
+// cluster, rate limits how often a single cluster may sync, and bounds the
+// total number of concurrent syncs as backpressure.
+
+const (
+	// maxConcurrentSyncs bounds how many clusters may sync at the same time
+	maxConcurrentSyncs = 8
+
+	// each cluster may sync at most clusterSyncBurst times back to back,
+	// then is limited to clusterSyncQPS on average
+	clusterSyncQPS   = 0.2
+	clusterSyncBurst = 3
+
+	// delay before a throttled cluster is queued again
+	throttleRequeueDelay = 15 * time.Second
+)
+
+type admission int
+
+const (
+	// the sync may start now
+	admitOK admission = iota
+	// the cluster synced too often recently
+	admitThrottled
+	// a sync of this cluster is already running, another run follows when it finishes
+	admitCoalesced
+	// all sync slots are taken
+	admitBlocked
+)
+
+type syncGuard struct {
+	mu        sync.Mutex
+	limiters  map[string]flowcontrol.RateLimiter
+	inflight  map[string]bool
+	pending   map[string]bool
+	enqueued  map[string]time.Time
+	semaphore chan struct{}
+}
+
+func newSyncGuard() *syncGuard {
+	return &syncGuard{
+		limiters:  make(map[string]flowcontrol.RateLimiter),
+		inflight:  make(map[string]bool),
+		pending:   make(map[string]bool),
+		enqueued:  make(map[string]time.Time),
+		semaphore: make(chan struct{}, maxConcurrentSyncs),
+	}
+}
+
+// markEnqueued records when the cluster became due for a sync, the lag
+// metric is derived from it once the sync starts.
+func (g *syncGuard) markEnqueued(cluster string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.enqueued[cluster]; !ok {
+		g.enqueued[cluster] = time.Now()
+	}
+}
+
+// admit decides whether a sync of the cluster may start now.
+func (g *syncGuard) admit(cluster string) admission {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.inflight[cluster] {
+		g.pending[cluster] = true
+		return admitCoalesced
+	}
+
+	limiter, ok := g.limiters[cluster]
+	if !ok {
+		limiter = flowcontrol.NewTokenBucketRateLimiter(clusterSyncQPS, clusterSyncBurst)
+		g.limiters[cluster] = limiter
+	}
+	if !limiter.TryAccept() {
+		return admitThrottled
+	}
+
+	select {
+	case g.semaphore <- struct{}{}:
+	default:
+		return admitBlocked
+	}
+
+	g.inflight[cluster] = true
+	if enqueued, ok := g.enqueued[cluster]; ok {
+		clusterSyncLag.WithLabelValues(cluster).Set(time.Since(enqueued).Seconds())
+		delete(g.enqueued, cluster)
+	}
+	return admitOK
+}
+
+// release frees the sync slot of the cluster, requeue is called when syncs
+// were coalesced while this one was running.
+func (g *syncGuard) release(cluster string, requeue func()) {
+	g.mu.Lock()
+	delete(g.inflight, cluster)
+	pending := g.pending[cluster]
+	delete(g.pending, cluster)
+	g.mu.Unlock()
+
+	<-g.semaphore
+
+	if pending {
+		requeue()
+	}
+}
+
+// forget drops all per-cluster state, called when the cluster is deleted.
+func (g *syncGuard) forget(cluster string) {
+	g.mu.Lock()
+	delete(g.limiters, cluster)
+	delete(g.pending, cluster)
+	delete(g.enqueued, cluster)
+	g.mu.Unlock()
+
+	labels := map[string]string{"cluster": cluster}
+	clusterSyncLag.Delete(labels)
+	clusterSyncDuration.Delete(labels)
+	clusterSyncThrottled.Delete(labels)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+)
+
+func TestSyncGuardCoalescesRunningSyncs(t *testing.T) {
+	guard := newSyncGuard()
+
+	if got := guard.admit("member"); got != admitOK {
+		t.Fatalf("expected first sync to be admitted, got %v", got)
+	}
+	if got := guard.admit("member"); got != admitCoalesced {
+		t.Fatalf("expected concurrent sync to be coalesced, got %v", got)
+	}
+
+	requeued := false
+	guard.release("member", func() { requeued = true })
+	if !requeued {
+		t.Fatal("expected coalesced sync to be requeued on release")
+	}
+}
+
+func TestSyncGuardThrottlesFlappingCluster(t *testing.T) {
+	guard := newSyncGuard()
+
+	for i := 0; i < clusterSyncBurst; i++ {
+		if got := guard.admit("member"); got != admitOK {
+			t.Fatalf("expected sync %d within burst to be admitted, got %v", i, got)
+		}
+		guard.release("member", func() {})
+	}
+
+	if got := guard.admit("member"); got != admitThrottled {
+		t.Fatalf("expected sync beyond burst to be throttled, got %v", got)
+	}
+
+	// other clusters are not affected by the throttled one
+	if got := guard.admit("other"); got != admitOK {
+		t.Fatalf("expected another cluster to be admitted, got %v", got)
+	}
+}
+
+func TestSyncGuardAppliesBackpressure(t *testing.T) {
+	guard := newSyncGuard()
+	guard.semaphore = make(chan struct{}, 1)
+
+	if got := guard.admit("first"); got != admitOK {
+		t.Fatalf("expected first sync to be admitted, got %v", got)
+	}
+	if got := guard.admit("second"); got != admitBlocked {
+		t.Fatalf("expected second sync to be blocked, got %v", got)
+	}
+
+	guard.release("first", func() {})
+	if got := guard.admit("second"); got != admitOK {
+		t.Fatalf("expected second sync to be admitted after release, got %v", got)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	compbasemetrics "k8s.io/component-base/metrics"
+	"kubesphere.io/kubesphere/pkg/utils/metrics"
+)
+
+var (
+	clusterSyncLag = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Subsystem:      "ks_cm",
+			Name:           "cluster_sync_lag_seconds",
+			Help:           "Seconds the last sync of a member cluster waited in queue before it started",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"cluster"},
+	)
+
+	clusterSyncDuration = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Subsystem:      "ks_cm",
+			Name:           "cluster_sync_duration_seconds",
+			Help:           "Duration of member cluster syncs",
+			Buckets:        []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"cluster"},
+	)
+
+	clusterSyncThrottled = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      "ks_cm",
+			Name:           "cluster_sync_throttled_total",
+			Help:           "Counter of member cluster syncs delayed by the per-cluster rate limit",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"cluster"},
+	)
+
+	metricsList = []compbasemetrics.Registerable{
+		clusterSyncLag,
+		clusterSyncDuration,
+		clusterSyncThrottled,
+	}
+)
+
+func registerMetrics() {
+	for _, m := range metricsList {
+		metrics.MustRegister(m)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	configmodel "kubesphere.io/kubesphere/pkg/models/configuration"
+)
+
+const configMapControllerName = "configmap-revision-controller"
+
+// ConfigMapReconciler tracks config map changes through a hash annotation,
+// bumps a revision counter on every change and rolls restarts to consuming
+// workloads that opted in, so config edits stop causing silently stale pods.
+type ConfigMapReconciler struct {
+	client.Client
+
+	logger logr.Logger
+}
+
+func (r *ConfigMapReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.logger == nil {
+		r.logger = ctrl.Log.WithName("controllers").WithName(configMapControllerName)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(configMapControllerName).
+		For(&corev1.ConfigMap{}).
+		Complete(r)
+}
+
+func (r *ConfigMapReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	logger := r.logger.WithValues("configmap", req.NamespacedName)
+	ctx := context.Background()
+
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(ctx, req.NamespacedName, cm); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !cm.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	hash := configmodel.ConfigMapHash(cm)
+	changed, firstObservation := observeRevision(&cm.ObjectMeta, hash)
+	if !changed {
+		return ctrl.Result{}, nil
+	}
+	if err := r.Update(ctx, cm); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// the first observation only records the baseline, an unchanged config
+	// must not restart anything
+	if firstObservation {
+		return ctrl.Result{}, nil
+	}
+
+	logger.V(4).Info("config map changed, restarting opted-in consumers", "revision", cm.Annotations[configmodel.RevisionAnnotation])
+	return ctrl.Result{}, r.restartConsumers(ctx, cm)
+}
+
+func (r *ConfigMapReconciler) restartConsumers(ctx context.Context, cm *corev1.ConfigMap) error {
+	return restartConsumers(ctx, r.Client, r.logger, cm.Namespace, configmodel.KindConfigMap, cm.Name,
+		func(spec *corev1.PodSpec) bool {
+			configMaps, _ := configmodel.References(spec)
+			return configMaps[cm.Name]
+		})
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	configmodel "kubesphere.io/kubesphere/pkg/models/configuration"
+)
+
+// observeRevision updates the hash, revision and last-change annotations for
+// the observed data hash. It reports whether the object needs an update and
+// whether this is the first time the object is seen, which records the
+// baseline without counting as a change.
+func observeRevision(meta *metav1.ObjectMeta, hash string) (changed, firstObservation bool) {
+	annotations := meta.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	previous := annotations[configmodel.HashAnnotation]
+	if previous == hash {
+		return false, false
+	}
+
+	revision := 1
+	if previous != "" {
+		if current, err := strconv.Atoi(annotations[configmodel.RevisionAnnotation]); err == nil {
+			revision = current + 1
+		}
+	}
+	annotations[configmodel.HashAnnotation] = hash
+	annotations[configmodel.RevisionAnnotation] = strconv.Itoa(revision)
+	annotations[configmodel.LastChangeAnnotation] = time.Now().Format(time.RFC3339)
+	meta.SetAnnotations(annotations)
+	return true, previous == ""
+}
+
+// restartConsumers rolls a restart to every workload of the namespace that
+// consumes the changed config object and carries the restart-on-change
+// annotation, by stamping the restarted-at annotation into the pod template.
+func restartConsumers(ctx context.Context, c client.Client, logger logr.Logger, namespace, kind, name string, consumes func(*corev1.PodSpec) bool) error {
+	restartedFor := fmt.Sprintf("%s/%s", kind, name)
+	restartedAt := time.Now().Format(time.RFC3339)
+
+	deployments := &appsv1.DeploymentList{}
+	if err := c.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		if !configmodel.RestartOnChange(deployment.Annotations) || !consumes(&deployment.Spec.Template.Spec) {
+			continue
+		}
+		stampRestart(&deployment.Spec.Template, restartedAt, restartedFor)
+		if err := c.Update(ctx, deployment); err != nil {
+			return err
+		}
+		logger.Info("restarted deployment for config change", "deployment", deployment.Name, "config", restartedFor)
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := c.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		statefulSet := &statefulSets.Items[i]
+		if !configmodel.RestartOnChange(statefulSet.Annotations) || !consumes(&statefulSet.Spec.Template.Spec) {
+			continue
+		}
+		stampRestart(&statefulSet.Spec.Template, restartedAt, restartedFor)
+		if err := c.Update(ctx, statefulSet); err != nil {
+			return err
+		}
+		logger.Info("restarted statefulset for config change", "statefulset", statefulSet.Name, "config", restartedFor)
+	}
+
+	daemonSets := &appsv1.DaemonSetList{}
+	if err := c.List(ctx, daemonSets, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range daemonSets.Items {
+		daemonSet := &daemonSets.Items[i]
+		if !configmodel.RestartOnChange(daemonSet.Annotations) || !consumes(&daemonSet.Spec.Template.Spec) {
+			continue
+		}
+		stampRestart(&daemonSet.Spec.Template, restartedAt, restartedFor)
+		if err := c.Update(ctx, daemonSet); err != nil {
+			return err
+		}
+		logger.Info("restarted daemonset for config change", "daemonset", daemonSet.Name, "config", restartedFor)
+	}
+
+	return nil
+}
+
+func stampRestart(template *corev1.PodTemplateSpec, restartedAt, restartedFor string) {
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[configmodel.RestartedAtAnnotation] = restartedAt
+	template.Annotations[configmodel.RestartedForAnnotation] = restartedFor
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	configmodel "kubesphere.io/kubesphere/pkg/models/configuration"
+)
+
+const secretControllerName = "secret-revision-controller"
+
+// SecretReconciler is the secret counterpart of ConfigMapReconciler. Only
+// plain Opaque secrets are tracked, typed secrets like service account
+// tokens churn constantly without any workload-visible change.
+type SecretReconciler struct {
+	client.Client
+
+	logger logr.Logger
+}
+
+func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.logger == nil {
+		r.logger = ctrl.Log.WithName("controllers").WithName(secretControllerName)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(secretControllerName).
+		For(&corev1.Secret{}).
+		Complete(r)
+}
+
+func (r *SecretReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	logger := r.logger.WithValues("secret", req.NamespacedName)
+	ctx := context.Background()
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, req.NamespacedName, secret); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !secret.ObjectMeta.DeletionTimestamp.IsZero() || secret.Type != corev1.SecretTypeOpaque {
+		return ctrl.Result{}, nil
+	}
+
+	hash := configmodel.SecretHash(secret)
+	changed, firstObservation := observeRevision(&secret.ObjectMeta, hash)
+	if !changed {
+		return ctrl.Result{}, nil
+	}
+	if err := r.Update(ctx, secret); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if firstObservation {
+		return ctrl.Result{}, nil
+	}
+
+	logger.V(4).Info("secret changed, restarting opted-in consumers", "revision", secret.Annotations[configmodel.RevisionAnnotation])
+	return ctrl.Result{}, r.restartConsumers(ctx, secret)
+}
+
+func (r *SecretReconciler) restartConsumers(ctx context.Context, secret *corev1.Secret) error {
+	return restartConsumers(ctx, r.Client, r.logger, secret.Namespace, configmodel.KindSecret, secret.Name,
+		func(spec *corev1.PodSpec) bool {
+			_, secrets := configmodel.References(spec)
+			return secrets[secret.Name]
+		})
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dashboard installs the built-in monitoring dashboards. Built-in
+// dashboards are created once if absent, customizations by admins are left
+// untouched.
+package dashboard
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	monitoringdashboardv1alpha1 "kubesphere.io/monitoring-dashboard/api/v1alpha1"
+	"kubesphere.io/monitoring-dashboard/api/v1alpha1/panels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const etcdDashboardName = "etcd"
+
+// Controller creates the built-in cluster dashboards on startup.
+type Controller struct {
+	Client client.Client
+}
+
+func NewController(client client.Client) *Controller {
+	return &Controller{Client: client}
+}
+
+func (c *Controller) Start(stopCh <-chan struct{}) error {
+	if err := c.ensure(etcdDashboard()); err != nil {
+		// the dashboard CRD may not be installed, don't block the other
+		// controllers on it
+		klog.Errorf("failed to create built-in dashboard %s: %v", etcdDashboardName, err)
+	}
+	<-stopCh
+	return nil
+}
+
+func (c *Controller) ensure(dashboard *monitoringdashboardv1alpha1.ClusterDashboard) error {
+	err := c.Client.Create(context.Background(), dashboard)
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// etcdDashboard charts etcd DB size, fragmentation and the largest resource
+// types by object count, complementing the etcd.kubesphere.io analytics APIs.
+func etcdDashboard() *monitoringdashboardv1alpha1.ClusterDashboard {
+	return &monitoringdashboardv1alpha1.ClusterDashboard{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: etcdDashboardName,
+		},
+		Spec: monitoringdashboardv1alpha1.DashboardSpec{
+			Title:       "etcd",
+			Description: "etcd storage health: DB size, fragmentation and object counts per resource type",
+			DataSource:  "prometheus",
+			Time: monitoringdashboardv1alpha1.Time{
+				From: "now-3h",
+				To:   "now",
+			},
+			Panels: []monitoringdashboardv1alpha1.Panel{
+				{SingleStat: &panels.SingleStat{
+					Title:  "DB Size",
+					Type:   "singlestat",
+					Id:     1,
+					Format: "Byte",
+					Targets: []panels.Target{
+						{Expression: `sum(etcd_mvcc_db_total_size_in_bytes)`, RefID: 1},
+					},
+				}},
+				{SingleStat: &panels.SingleStat{
+					Title:    "Fragmentation Ratio",
+					Type:     "singlestat",
+					Id:       2,
+					Format:   "percent (0.0-1.0)",
+					Decimals: 2,
+					Targets: []panels.Target{
+						{Expression: `1 - sum(etcd_mvcc_db_total_size_in_use_in_bytes) / sum(etcd_mvcc_db_total_size_in_bytes)`, RefID: 1},
+					},
+				}},
+				{Graph: &panels.Graph{
+					Title: "DB Size",
+					Type:  "graph",
+					Id:    3,
+					Lines: true,
+					Targets: []panels.Target{
+						{Expression: `etcd_mvcc_db_total_size_in_bytes`, LegendFormat: "{{instance}} total", RefID: 1},
+						{Expression: `etcd_mvcc_db_total_size_in_use_in_bytes`, LegendFormat: "{{instance}} in use", RefID: 2},
+					},
+				}},
+				{Graph: &panels.Graph{
+					Title:       "Largest Resource Types",
+					Type:        "graph",
+					Id:          4,
+					Lines:       true,
+					Description: "object count of the ten largest resource types, growth here usually means CRD bloat",
+					Targets: []panels.Target{
+						{Expression: `topk(10, etcd_object_counts)`, LegendFormat: "{{resource}}", RefID: 1},
+					},
+				}},
+				{Graph: &panels.Graph{
+					Title: "Leader Changes",
+					Type:  "graph",
+					Id:    5,
+					Lines: true,
+					Targets: []panels.Target{
+						{Expression: `changes(etcd_server_leader_changes_seen_total[1h])`, LegendFormat: "{{instance}}", RefID: 1},
+					},
+				}},
+			},
+		},
+	}
+}
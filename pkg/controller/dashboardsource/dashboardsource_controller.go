@@ -0,0 +1,334 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboardsource
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	githttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	dashboardv1alpha1 "kubesphere.io/kubesphere/pkg/apis/dashboard/v1alpha1"
+	monitoringdashboardv1alpha1 "kubesphere.io/monitoring-dashboard/api/v1alpha1"
+)
+
+const (
+	tickPeriod      = 30 * time.Second
+	defaultInterval = 5 * time.Minute
+
+	// SourceLabel marks dashboards applied by a DashboardSource, used to
+	// prune dashboards that left the repository
+	SourceLabel = "monitoring.kubesphere.io/source"
+)
+
+// Controller syncs DashboardSources: it clones each source's Git repository
+// on the configured interval, validates the Dashboard and ClusterDashboard
+// manifests it finds, applies them and optionally prunes dashboards the
+// repository no longer contains.
+type Controller struct {
+	client runtimeclient.Client
+	k8s    kubernetes.Interface
+}
+
+func NewController(client runtimeclient.Client, k8s kubernetes.Interface) *Controller {
+	return &Controller{client: client, k8s: k8s}
+}
+
+func (c *Controller) Start(stopCh <-chan struct{}) error {
+	klog.Info("starting dashboard source controller")
+	defer klog.Info("shutting down dashboard source controller")
+
+	wait.Until(func() {
+		c.tick(time.Now())
+	}, tickPeriod, stopCh)
+	return nil
+}
+
+func (c *Controller) tick(now time.Time) {
+	sources := &dashboardv1alpha1.DashboardSourceList{}
+	if err := c.client.List(context.Background(), sources); err != nil {
+		klog.Errorf("failed to list dashboard sources: %v", err)
+		return
+	}
+
+	for i := range sources.Items {
+		source := &sources.Items[i]
+
+		interval := defaultInterval
+		if source.Spec.Interval != "" {
+			var err error
+			if interval, err = time.ParseDuration(source.Spec.Interval); err != nil {
+				klog.Errorf("dashboard source %s has invalid interval %q: %v",
+					source.Name, source.Spec.Interval, err)
+				continue
+			}
+		}
+		if source.Status.LastSyncTime != nil && now.Sub(source.Status.LastSyncTime.Time) < interval {
+			continue
+		}
+
+		revision, applied, err := c.sync(source)
+		source.Status.LastSyncTime = &metav1.Time{Time: now}
+		if err != nil {
+			klog.Errorf("failed to sync dashboard source %s: %v", source.Name, err)
+			source.Status.State = dashboardv1alpha1.SourceStateFailed
+			source.Status.Message = err.Error()
+		} else {
+			source.Status.State = dashboardv1alpha1.SourceStateSynced
+			source.Status.Message = ""
+			source.Status.Revision = revision
+			source.Status.Dashboards = applied
+		}
+		if err := c.client.Status().Update(context.Background(), source); err != nil {
+			klog.Errorf("failed to update status of dashboard source %s: %v", source.Name, err)
+		}
+	}
+}
+
+// sync clones the repository and applies its manifests, returning the
+// synced revision and the number of dashboards applied.
+func (c *Controller) sync(source *dashboardv1alpha1.DashboardSource) (string, int, error) {
+	auth, err := c.auth(source)
+	if err != nil {
+		return "", 0, err
+	}
+
+	dir, err := ioutil.TempDir("", "dashboardsource")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	options := &git.CloneOptions{
+		URL:          source.Spec.Repo,
+		Depth:        1,
+		SingleBranch: true,
+		Auth:         auth,
+	}
+	if source.Spec.Branch != "" {
+		options.ReferenceName = plumbing.NewBranchReferenceName(source.Spec.Branch)
+	}
+	repository, err := git.PlainClone(dir, false, options)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to clone %s: %v", source.Spec.Repo, err)
+	}
+	head, err := repository.Head()
+	if err != nil {
+		return "", 0, err
+	}
+
+	dashboards, clusterDashboards, err := loadManifests(filepath.Join(dir, source.Spec.Path))
+	if err != nil {
+		return "", 0, err
+	}
+
+	applied, err := c.apply(source, dashboards, clusterDashboards)
+	if err != nil {
+		return "", 0, err
+	}
+	if source.Spec.Prune {
+		if err := c.prune(source, dashboards, clusterDashboards); err != nil {
+			return "", 0, err
+		}
+	}
+	return head.Hash().String(), applied, nil
+}
+
+func (c *Controller) auth(source *dashboardv1alpha1.DashboardSource) (transport.AuthMethod, error) {
+	if source.Spec.SecretRef == nil {
+		return nil, nil
+	}
+	secret, err := c.k8s.CoreV1().Secrets(source.Spec.SecretRef.Namespace).
+		Get(context.Background(), source.Spec.SecretRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth secret %s/%s: %v",
+			source.Spec.SecretRef.Namespace, source.Spec.SecretRef.Name, err)
+	}
+	return &githttp.BasicAuth{
+		Username: string(secret.Data[corev1.BasicAuthUsernameKey]),
+		Password: string(secret.Data[corev1.BasicAuthPasswordKey]),
+	}, nil
+}
+
+// loadManifests reads and validates the dashboard manifests under dir.
+func loadManifests(dir string) ([]*monitoringdashboardv1alpha1.Dashboard, []*monitoringdashboardv1alpha1.ClusterDashboard, error) {
+	var dashboards []*monitoringdashboardv1alpha1.Dashboard
+	var clusterDashboards []*monitoringdashboardv1alpha1.ClusterDashboard
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, document := range strings.Split(string(data), "\n---") {
+			if strings.TrimSpace(document) == "" {
+				continue
+			}
+			var meta struct {
+				metav1.TypeMeta   `json:",inline"`
+				metav1.ObjectMeta `json:"metadata,omitempty"`
+			}
+			if err := yaml.Unmarshal([]byte(document), &meta); err != nil {
+				return fmt.Errorf("invalid manifest in %s: %v", filepath.Base(path), err)
+			}
+			switch meta.Kind {
+			case "Dashboard":
+				dashboard := &monitoringdashboardv1alpha1.Dashboard{}
+				if err := yaml.Unmarshal([]byte(document), dashboard); err != nil {
+					return fmt.Errorf("invalid dashboard in %s: %v", filepath.Base(path), err)
+				}
+				if dashboard.Name == "" || dashboard.Namespace == "" {
+					return fmt.Errorf("dashboard in %s needs a name and a namespace", filepath.Base(path))
+				}
+				dashboards = append(dashboards, dashboard)
+			case "ClusterDashboard":
+				dashboard := &monitoringdashboardv1alpha1.ClusterDashboard{}
+				if err := yaml.Unmarshal([]byte(document), dashboard); err != nil {
+					return fmt.Errorf("invalid cluster dashboard in %s: %v", filepath.Base(path), err)
+				}
+				if dashboard.Name == "" {
+					return fmt.Errorf("cluster dashboard in %s needs a name", filepath.Base(path))
+				}
+				clusterDashboards = append(clusterDashboards, dashboard)
+			}
+		}
+		return nil
+	})
+	return dashboards, clusterDashboards, err
+}
+
+func (c *Controller) apply(source *dashboardv1alpha1.DashboardSource,
+	dashboards []*monitoringdashboardv1alpha1.Dashboard,
+	clusterDashboards []*monitoringdashboardv1alpha1.ClusterDashboard) (int, error) {
+
+	applied := 0
+	for _, dashboard := range dashboards {
+		existing := &monitoringdashboardv1alpha1.Dashboard{}
+		key := runtimeclient.ObjectKey{Namespace: dashboard.Namespace, Name: dashboard.Name}
+		err := c.client.Get(context.Background(), key, existing)
+		if apierrors.IsNotFound(err) {
+			markSource(&dashboard.ObjectMeta, source.Name)
+			err = c.client.Create(context.Background(), dashboard)
+		} else if err == nil {
+			existing.Spec = dashboard.Spec
+			markSource(&existing.ObjectMeta, source.Name)
+			err = c.client.Update(context.Background(), existing)
+		}
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply dashboard %s/%s: %v", dashboard.Namespace, dashboard.Name, err)
+		}
+		applied++
+	}
+	for _, dashboard := range clusterDashboards {
+		existing := &monitoringdashboardv1alpha1.ClusterDashboard{}
+		err := c.client.Get(context.Background(), runtimeclient.ObjectKey{Name: dashboard.Name}, existing)
+		if apierrors.IsNotFound(err) {
+			markSource(&dashboard.ObjectMeta, source.Name)
+			err = c.client.Create(context.Background(), dashboard)
+		} else if err == nil {
+			existing.Spec = dashboard.Spec
+			markSource(&existing.ObjectMeta, source.Name)
+			err = c.client.Update(context.Background(), existing)
+		}
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply cluster dashboard %s: %v", dashboard.Name, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// prune deletes dashboards this source applied earlier but which the
+// repository no longer contains.
+func (c *Controller) prune(source *dashboardv1alpha1.DashboardSource,
+	dashboards []*monitoringdashboardv1alpha1.Dashboard,
+	clusterDashboards []*monitoringdashboardv1alpha1.ClusterDashboard) error {
+
+	keep := make(map[string]bool)
+	for _, dashboard := range dashboards {
+		keep[dashboard.Namespace+"/"+dashboard.Name] = true
+	}
+	for _, dashboard := range clusterDashboards {
+		keep["/"+dashboard.Name] = true
+	}
+
+	owned := &monitoringdashboardv1alpha1.DashboardList{}
+	if err := c.client.List(context.Background(), owned, runtimeclient.MatchingLabels{SourceLabel: source.Name}); err != nil {
+		return err
+	}
+	for i := range owned.Items {
+		dashboard := &owned.Items[i]
+		if keep[dashboard.Namespace+"/"+dashboard.Name] {
+			continue
+		}
+		if err := c.client.Delete(context.Background(), dashboard); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	ownedCluster := &monitoringdashboardv1alpha1.ClusterDashboardList{}
+	if err := c.client.List(context.Background(), ownedCluster, runtimeclient.MatchingLabels{SourceLabel: source.Name}); err != nil {
+		return err
+	}
+	for i := range ownedCluster.Items {
+		dashboard := &ownedCluster.Items[i]
+		if keep["/"+dashboard.Name] {
+			continue
+		}
+		if err := c.client.Delete(context.Background(), dashboard); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func markSource(meta *metav1.ObjectMeta, source string) {
+	if meta.Labels == nil {
+		meta.Labels = make(map[string]string)
+	}
+	meta.Labels[SourceLabel] = source
+}
@@ -0,0 +1,191 @@
+/*
+
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+
+*/
+
+package defaulting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/api/admission/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	defaultingv1alpha1 "kubesphere.io/kubesphere/pkg/apis/defaulting/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+// WorkloadDefaultingAdmission injects default resource requests/limits and
+// the standard owner and workspace metadata into workloads created in
+// namespaces that opted in through the defaulting.kubesphere.io/enabled
+// label, following the cluster's DefaultingPolicy objects.
+type WorkloadDefaultingAdmission struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+func NewWorkloadDefaultingAdmission(client client.Client, scheme *runtime.Scheme) (webhook.AdmissionHandler, error) {
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		return nil, err
+	}
+	return &WorkloadDefaultingAdmission{client: client, decoder: decoder}, nil
+}
+
+func (w *WorkloadDefaultingAdmission) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != v1beta1.Create || len(req.RequestSubResource) != 0 || len(req.Namespace) == 0 {
+		return admission.Allowed("")
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := w.client.Get(ctx, client.ObjectKey{Name: req.Namespace}, namespace); err != nil {
+		klog.Error(err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if namespace.Labels[defaultingv1alpha1.NamespaceOptInLabel] != "true" {
+		return admission.Allowed("")
+	}
+
+	workload, meta, podTemplate, err := w.decodeWorkload(req)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if workload == nil {
+		return admission.Allowed("")
+	}
+
+	policies := &defaultingv1alpha1.DefaultingPolicyList{}
+	if err := w.client.List(ctx, policies); err != nil {
+		klog.Error(err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		matches, err := policyMatches(policy, namespace)
+		if err != nil {
+			klog.Errorf("invalid namespace selector of defaulting policy %s: %v", policy.Name, err)
+			continue
+		}
+		if matches {
+			applyPolicy(policy, meta, podTemplate)
+		}
+	}
+
+	// standard ownership metadata, injected regardless of policies
+	setIfAbsent(&meta.Annotations, constants.CreatorAnnotationKey, req.UserInfo.Username)
+	if workspace := namespace.Labels[constants.WorkspaceLabelKey]; workspace != "" {
+		setIfAbsent(&meta.Labels, constants.WorkspaceLabelKey, workspace)
+	}
+
+	marshalled, err := json.Marshal(workload)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshalled)
+}
+
+// decodeWorkload returns the decoded workload together with pointers into
+// its metadata and pod template, workloads of unhandled kinds return nil
+func (w *WorkloadDefaultingAdmission) decodeWorkload(req admission.Request) (runtime.Object, *metav1.ObjectMeta, *corev1.PodTemplateSpec, error) {
+	switch req.Kind.Kind {
+	case "Deployment":
+		deployment := &appsv1.Deployment{}
+		if err := w.decoder.Decode(req, deployment); err != nil {
+			return nil, nil, nil, err
+		}
+		return deployment, &deployment.ObjectMeta, &deployment.Spec.Template, nil
+	case "StatefulSet":
+		statefulSet := &appsv1.StatefulSet{}
+		if err := w.decoder.Decode(req, statefulSet); err != nil {
+			return nil, nil, nil, err
+		}
+		return statefulSet, &statefulSet.ObjectMeta, &statefulSet.Spec.Template, nil
+	case "DaemonSet":
+		daemonSet := &appsv1.DaemonSet{}
+		if err := w.decoder.Decode(req, daemonSet); err != nil {
+			return nil, nil, nil, err
+		}
+		return daemonSet, &daemonSet.ObjectMeta, &daemonSet.Spec.Template, nil
+	}
+	return nil, nil, nil, nil
+}
+
+func policyMatches(policy *defaultingv1alpha1.DefaultingPolicy, namespace *corev1.Namespace) (bool, error) {
+	if policy.Spec.NamespaceSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(namespace.Labels)), nil
+}
+
+func applyPolicy(policy *defaultingv1alpha1.DefaultingPolicy, meta *metav1.ObjectMeta, podTemplate *corev1.PodTemplateSpec) {
+	for i := range podTemplate.Spec.Containers {
+		applyResourceDefaults(&podTemplate.Spec.Containers[i], policy.Spec.Requests, policy.Spec.Limits)
+	}
+	for key, value := range policy.Spec.Labels {
+		setIfAbsent(&meta.Labels, key, value)
+	}
+	for key, value := range policy.Spec.Annotations {
+		setIfAbsent(&meta.Annotations, key, value)
+	}
+}
+
+// applyResourceDefaults fills in requests and limits the container does not
+// declare itself, explicit values always win
+func applyResourceDefaults(container *corev1.Container, requests, limits corev1.ResourceList) {
+	if len(requests) > 0 && container.Resources.Requests == nil {
+		container.Resources.Requests = corev1.ResourceList{}
+	}
+	for name, quantity := range requests {
+		if _, ok := container.Resources.Requests[name]; !ok {
+			container.Resources.Requests[name] = quantity
+		}
+	}
+	if len(limits) > 0 && container.Resources.Limits == nil {
+		container.Resources.Limits = corev1.ResourceList{}
+	}
+	for name, quantity := range limits {
+		if _, ok := container.Resources.Limits[name]; !ok {
+			container.Resources.Limits[name] = quantity
+		}
+	}
+}
+
+func setIfAbsent(values *map[string]string, key, value string) {
+	if value == "" {
+		return
+	}
+	if *values == nil {
+		*values = map[string]string{}
+	}
+	if _, ok := (*values)[key]; !ok {
+		(*values)[key] = value
+	}
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harbor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	iamv1alpha2 "kubesphere.io/kubesphere/pkg/apis/iam/v1alpha2"
+	tenantv1alpha1 "kubesphere.io/kubesphere/pkg/apis/tenant/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/harbor"
+	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
+)
+
+const (
+	controllerName = "harbor-controller"
+
+	// PullSecretName is the name of the image pull secret holding a Harbor
+	// robot account, written into every namespace of the workspace
+	PullSecretName = "harbor-pull-secret"
+
+	finalizer = "finalizers.harbor.kubesphere.io"
+)
+
+// Reconciler keeps Harbor in sync with workspaces: every workspace gets a
+// Harbor project of the same name, workspace members are mapped to project
+// roles and each namespace of the workspace gets a robot account as an
+// image pull secret.
+type Reconciler struct {
+	client.Client
+	Logger                  logr.Logger
+	Recorder                record.EventRecorder
+	HarborClient            harbor.Interface
+	RegistryHost            string
+	MaxConcurrentReconciles int
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	if r.MaxConcurrentReconciles <= 0 {
+		r.MaxConcurrentReconciles = 1
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+		}).
+		For(&tenantv1alpha1.Workspace{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.WithValues("workspace", req.NamespacedName)
+	rootCtx := context.Background()
+	workspace := &tenantv1alpha1.Workspace{}
+	if err := r.Get(rootCtx, req.NamespacedName, workspace); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if workspace.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !sliceutil.HasString(workspace.ObjectMeta.Finalizers, finalizer) {
+			workspace.ObjectMeta.Finalizers = append(workspace.ObjectMeta.Finalizers, finalizer)
+			if err := r.Update(rootCtx, workspace); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if sliceutil.HasString(workspace.ObjectMeta.Finalizers, finalizer) {
+			if err := r.HarborClient.DeleteProject(workspace.Name); err != nil {
+				logger.Error(err, "delete harbor project failed")
+				return ctrl.Result{}, err
+			}
+			workspace.ObjectMeta.Finalizers = sliceutil.RemoveString(workspace.ObjectMeta.Finalizers, func(item string) bool {
+				return item == finalizer
+			})
+			if err := r.Update(rootCtx, workspace); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.HarborClient.EnsureProject(workspace.Name); err != nil {
+		logger.Error(err, "ensure harbor project failed")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.syncMembers(rootCtx, workspace); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.syncPullSecrets(rootCtx, workspace); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// syncMembers maps workspace role bindings to Harbor project roles, users
+// unknown to Harbor are skipped until they log in through the shared
+// identity provider
+func (r *Reconciler) syncMembers(ctx context.Context, workspace *tenantv1alpha1.Workspace) error {
+	logger := r.Logger.WithValues("workspace", workspace.Name)
+	roleBindings := &iamv1alpha2.WorkspaceRoleBindingList{}
+	if err := r.List(ctx, roleBindings, client.MatchingLabels{constants.WorkspaceLabelKey: workspace.Name}); err != nil {
+		return err
+	}
+	for _, roleBinding := range roleBindings.Items {
+		roleID := harborRole(workspace.Name, roleBinding.RoleRef.Name)
+		for _, subject := range roleBinding.Subjects {
+			if subject.Kind != rbacv1.UserKind {
+				continue
+			}
+			if err := r.HarborClient.SetProjectMember(workspace.Name, subject.Name, roleID); err != nil {
+				logger.V(4).Info("set harbor project member failed", "user", subject.Name, "error", err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// syncPullSecrets makes sure every namespace of the workspace holds a
+// dockerconfigjson secret backed by a project robot account, the robot
+// credentials are only available at creation so existing secrets are left
+// untouched
+func (r *Reconciler) syncPullSecrets(ctx context.Context, workspace *tenantv1alpha1.Workspace) error {
+	namespaces := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaces, client.MatchingLabels{constants.WorkspaceLabelKey: workspace.Name}); err != nil {
+		return err
+	}
+	for i := range namespaces.Items {
+		namespace := &namespaces.Items[i]
+		if !namespace.ObjectMeta.DeletionTimestamp.IsZero() {
+			continue
+		}
+		secret := &corev1.Secret{}
+		err := r.Get(ctx, client.ObjectKey{Namespace: namespace.Name, Name: PullSecretName}, secret)
+		if err == nil {
+			continue
+		}
+		if !isNotFound(err) {
+			return err
+		}
+		robot, err := r.HarborClient.CreateRobotAccount(workspace.Name, fmt.Sprintf("ks-%s", namespace.Name))
+		if err != nil {
+			r.Logger.Error(err, "create harbor robot account failed", "namespace", namespace.Name)
+			return err
+		}
+		if err := r.Create(ctx, r.pullSecret(namespace.Name, robot)); err != nil {
+			return err
+		}
+		r.Recorder.Event(workspace, corev1.EventTypeNormal, "RobotAccountCreated",
+			fmt.Sprintf("created Harbor robot account for namespace %s", namespace.Name))
+	}
+	return nil
+}
+
+func (r *Reconciler) pullSecret(namespace string, robot *harbor.RobotAccount) *corev1.Secret {
+	registry := strings.TrimPrefix(strings.TrimPrefix(r.RegistryHost, "https://"), "http://")
+	dockerConfig, _ := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{
+			registry: map[string]string{
+				"username": robot.Name,
+				"password": robot.Secret,
+				"auth":     base64.StdEncoding.EncodeToString([]byte(robot.Name + ":" + robot.Secret)),
+			},
+		},
+	})
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PullSecretName,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: dockerConfig,
+		},
+	}
+}
+
+// harborRole maps the workspace role of a binding to the closest Harbor
+// project role
+func harborRole(workspace, roleName string) int {
+	switch strings.TrimPrefix(roleName, workspace+"-") {
+	case "admin":
+		return harbor.RoleProjectAdmin
+	case "viewer":
+		return harbor.RoleGuest
+	default:
+		return harbor.RoleDeveloper
+	}
+}
+
+func isNotFound(err error) bool {
+	return client.IgnoreNotFound(err) == nil
+}
@@ -0,0 +1,199 @@
+/*
+
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+
+*/
+
+package imagepolicy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	imagepolicyv1alpha1 "kubesphere.io/kubesphere/pkg/apis/imagepolicy/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// verifierTimeout bounds a single call to the signature verification
+	// service, pod creation blocks on it
+	verifierTimeout = 10 * time.Second
+
+	rejectedEventReason = "ImagePolicyViolation"
+)
+
+// ImagePolicyAdmission rejects pods that pull images from registries outside
+// the workspace's allowlist or whose signature cannot be verified, following
+// the cluster's ImagePolicy objects. Rejections are explained both in the
+// admission response and through an event in the pod's namespace.
+type ImagePolicyAdmission struct {
+	client     client.Client
+	decoder    *admission.Decoder
+	httpClient *http.Client
+}
+
+func NewImagePolicyAdmission(client client.Client, scheme *runtime.Scheme) (webhook.AdmissionHandler, error) {
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		return nil, err
+	}
+	return &ImagePolicyAdmission{
+		client:     client,
+		decoder:    decoder,
+		httpClient: &http.Client{Timeout: verifierTimeout},
+	}, nil
+}
+
+func (a *ImagePolicyAdmission) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != v1beta1.Create || len(req.RequestSubResource) != 0 || len(req.Namespace) == 0 {
+		return admission.Allowed("")
+	}
+
+	pod := &corev1.Pod{}
+	if err := a.decoder.Decode(req, pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := a.client.Get(ctx, client.ObjectKey{Name: req.Namespace}, namespace); err != nil {
+		klog.Error(err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	policies := &imagepolicyv1alpha1.ImagePolicyList{}
+	if err := a.client.List(ctx, policies); err != nil {
+		klog.Error(err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	workspace := namespace.Labels[constants.WorkspaceLabelKey]
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		if policy.Spec.Workspace != "" && policy.Spec.Workspace != workspace {
+			continue
+		}
+		if reason := a.checkPolicy(policy, pod); reason != "" {
+			message := fmt.Sprintf("image policy %s: %s", policy.Name, reason)
+			a.recordRejection(ctx, req, pod, message)
+			return admission.Denied(message)
+		}
+	}
+
+	return admission.Allowed("")
+}
+
+// checkPolicy returns an empty string when every image of the pod satisfies
+// the policy, otherwise the reason the pod must be rejected
+func (a *ImagePolicyAdmission) checkPolicy(policy *imagepolicyv1alpha1.ImagePolicy, pod *corev1.Pod) string {
+	for _, image := range podImages(pod) {
+		if !registryAllowed(policy.Spec.AllowedRegistries, image) {
+			return fmt.Sprintf("image %s is not from an allowed registry, allowed registries: %s",
+				image, strings.Join(policy.Spec.AllowedRegistries, ", "))
+		}
+		if policy.Spec.RequireSignature {
+			if err := a.verifySignature(policy.Spec.SignatureVerifierURL, image); err != nil {
+				return fmt.Sprintf("signature verification failed for image %s: %v", image, err)
+			}
+		}
+	}
+	return ""
+}
+
+// verifySignature asks the configured verification service whether the image
+// carries a valid signature, any status other than 200 counts as unsigned
+func (a *ImagePolicyAdmission) verifySignature(verifierURL, image string) error {
+	if verifierURL == "" {
+		return fmt.Errorf("no signature verifier configured")
+	}
+	resp, err := a.httpClient.PostForm(verifierURL, url.Values{"image": {image}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("verifier returned status %s", resp.Status)
+	}
+	return nil
+}
+
+func (a *ImagePolicyAdmission) recordRejection(ctx context.Context, req admission.Request, pod *corev1.Pod, message string) {
+	podName := pod.Name
+	if podName == "" {
+		podName = pod.GenerateName
+	}
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "image-policy-",
+			Namespace:    req.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:       "Pod",
+			Namespace:  req.Namespace,
+			Name:       podName,
+			APIVersion: "v1",
+		},
+		Reason:         rejectedEventReason,
+		Message:        message,
+		Type:           corev1.EventTypeWarning,
+		FirstTimestamp: metav1.Now(),
+		LastTimestamp:  metav1.Now(),
+		Count:          1,
+		Source:         corev1.EventSource{Component: "imagepolicy-webhook"},
+	}
+	if err := a.client.Create(ctx, event); err != nil {
+		klog.Errorf("failed to record image policy rejection of pod %s/%s: %v", req.Namespace, podName, err)
+	}
+}
+
+func podImages(pod *corev1.Pod) []string {
+	var images []string
+	for _, container := range pod.Spec.InitContainers {
+		images = append(images, container.Image)
+	}
+	for _, container := range pod.Spec.Containers {
+		images = append(images, container.Image)
+	}
+	return images
+}
+
+// registryAllowed reports whether the image matches one of the allowed
+// registry prefixes, an empty allowlist allows every registry. Matching is
+// done on path components so allowing docker.io does not allow
+// docker.io.example.com.
+func registryAllowed(allowedRegistries []string, image string) bool {
+	if len(allowedRegistries) == 0 {
+		return true
+	}
+	for _, registry := range allowedRegistries {
+		prefix := strings.TrimSuffix(registry, "/")
+		if image == prefix || strings.HasPrefix(image, prefix+"/") || strings.HasPrefix(image, prefix+":") || strings.HasPrefix(image, prefix+"@") {
+			return true
+		}
+	}
+	return false
+}
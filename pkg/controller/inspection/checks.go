@@ -0,0 +1,319 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspection
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	inspectionv1alpha1 "kubesphere.io/kubesphere/pkg/apis/inspection/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checks are run against the API only, they cover the CIS benchmark
+// sections that are visible there (control-plane command line flags) plus
+// node and workload best practices. Host level checks like file permissions
+// need kube-bench on the nodes and are out of scope.
+
+// systemNamespaces are exempt from the workload best-practice checks,
+// infrastructure components legitimately run privileged there
+var systemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// flagRule is a CIS benchmark check of a command line flag on a
+// control-plane component
+type flagRule struct {
+	id          string
+	component   string
+	flag        string
+	description string
+	remediation string
+	// ok reports whether the flag satisfies the rule, set is false when
+	// the flag is absent from the command line
+	ok func(value string, set bool) bool
+}
+
+var flagRules = []flagRule{
+	{
+		id:          "CIS 1.2.1",
+		component:   "kube-apiserver",
+		flag:        "anonymous-auth",
+		description: "Ensure that the --anonymous-auth argument is set to false",
+		remediation: "Set --anonymous-auth=false on the kube-apiserver",
+		ok:          func(value string, set bool) bool { return set && value == "false" },
+	},
+	{
+		id:          "CIS 1.2.7",
+		component:   "kube-apiserver",
+		flag:        "authorization-mode",
+		description: "Ensure that the --authorization-mode argument is not set to AlwaysAllow",
+		remediation: "Set --authorization-mode on the kube-apiserver to a value that does not include AlwaysAllow, e.g. Node,RBAC",
+		ok:          func(value string, set bool) bool { return set && !strings.Contains(value, "AlwaysAllow") },
+	},
+	{
+		id:          "CIS 1.2.21",
+		component:   "kube-apiserver",
+		flag:        "profiling",
+		description: "Ensure that the --profiling argument is set to false",
+		remediation: "Set --profiling=false on the kube-apiserver",
+		ok:          func(value string, set bool) bool { return set && value == "false" },
+	},
+	{
+		id:          "CIS 1.3.2",
+		component:   "kube-controller-manager",
+		flag:        "profiling",
+		description: "Ensure that the --profiling argument is set to false",
+		remediation: "Set --profiling=false on the kube-controller-manager",
+		ok:          func(value string, set bool) bool { return set && value == "false" },
+	},
+	{
+		id:          "CIS 1.4.1",
+		component:   "kube-scheduler",
+		flag:        "profiling",
+		description: "Ensure that the --profiling argument is set to false",
+		remediation: "Set --profiling=false on the kube-scheduler",
+		ok:          func(value string, set bool) bool { return set && value == "false" },
+	},
+	{
+		id:          "CIS 2.2",
+		component:   "etcd",
+		flag:        "client-cert-auth",
+		description: "Ensure that the --client-cert-auth argument is set to true",
+		remediation: "Set --client-cert-auth=true on etcd",
+		ok:          func(value string, set bool) bool { return set && value == "true" },
+	},
+}
+
+// runChecks runs every check and returns the results ordered by check id
+func runChecks(ctx context.Context, c client.Client) ([]inspectionv1alpha1.CheckResult, error) {
+	var results []inspectionv1alpha1.CheckResult
+
+	controlPlane, err := checkControlPlane(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, controlPlane...)
+
+	nodes, err := checkNodes(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, nodes...)
+
+	workloads, err := checkWorkloads(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, workloads...)
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
+
+// checkControlPlane evaluates the flag rules against the control-plane pods
+// in kube-system. On managed clusters the control-plane is not visible from
+// the API and the rules degrade to warnings.
+func checkControlPlane(ctx context.Context, c client.Client) ([]inspectionv1alpha1.CheckResult, error) {
+	var results []inspectionv1alpha1.CheckResult
+	for _, rule := range flagRules {
+		pods := &corev1.PodList{}
+		err := c.List(ctx, pods, client.InNamespace("kube-system"), client.MatchingLabels{"component": rule.component})
+		if err != nil {
+			return nil, err
+		}
+
+		result := inspectionv1alpha1.CheckResult{
+			ID:          rule.id,
+			Description: rule.description,
+			Target:      inspectionv1alpha1.TargetControlPlane,
+			Status:      inspectionv1alpha1.CheckPass,
+			Remediation: rule.remediation,
+		}
+
+		if len(pods.Items) == 0 {
+			result.Status = inspectionv1alpha1.CheckWarn
+			result.Message = fmt.Sprintf("no %s pods are visible from the API, run kube-bench on the control-plane hosts to cover this check", rule.component)
+			results = append(results, result)
+			continue
+		}
+
+		var failed []string
+		for _, pod := range pods.Items {
+			value, set := findFlag(&pod, rule.flag)
+			if !rule.ok(value, set) {
+				failed = append(failed, pod.Name)
+			}
+		}
+		if len(failed) > 0 {
+			result.Status = inspectionv1alpha1.CheckFail
+			result.Message = fmt.Sprintf("--%s is not set correctly on %s", rule.flag, strings.Join(failed, ", "))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// findFlag looks up a command line flag in the pod's containers, both the
+// --flag=value and the --flag value forms
+func findFlag(pod *corev1.Pod, flag string) (string, bool) {
+	prefix := fmt.Sprintf("--%s=", flag)
+	bare := fmt.Sprintf("--%s", flag)
+	for _, container := range pod.Spec.Containers {
+		args := append(append([]string{}, container.Command...), container.Args...)
+		for i, arg := range args {
+			if strings.HasPrefix(arg, prefix) {
+				return strings.TrimPrefix(arg, prefix), true
+			}
+			if arg == bare && i+1 < len(args) {
+				return args[i+1], true
+			}
+		}
+	}
+	return "", false
+}
+
+func checkNodes(ctx context.Context, c client.Client) ([]inspectionv1alpha1.CheckResult, error) {
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		return nil, err
+	}
+
+	var notReady, underPressure []string
+	versions := map[string]bool{}
+	for _, node := range nodes.Items {
+		versions[node.Status.NodeInfo.KubeletVersion] = true
+		for _, condition := range node.Status.Conditions {
+			switch condition.Type {
+			case corev1.NodeReady:
+				if condition.Status != corev1.ConditionTrue {
+					notReady = append(notReady, node.Name)
+				}
+			case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure:
+				if condition.Status == corev1.ConditionTrue {
+					underPressure = append(underPressure, fmt.Sprintf("%s (%s)", node.Name, condition.Type))
+				}
+			}
+		}
+	}
+
+	ready := inspectionv1alpha1.CheckResult{
+		ID:          "KS-NODE-1",
+		Description: "Ensure that all nodes are ready",
+		Target:      inspectionv1alpha1.TargetNode,
+		Status:      inspectionv1alpha1.CheckPass,
+		Remediation: "Inspect the kubelet and container runtime on the node",
+	}
+	if len(notReady) > 0 {
+		ready.Status = inspectionv1alpha1.CheckFail
+		ready.Message = fmt.Sprintf("nodes not ready: %s", strings.Join(notReady, ", "))
+	}
+
+	pressure := inspectionv1alpha1.CheckResult{
+		ID:          "KS-NODE-2",
+		Description: "Ensure that no node is under memory, disk or PID pressure",
+		Target:      inspectionv1alpha1.TargetNode,
+		Status:      inspectionv1alpha1.CheckPass,
+		Remediation: "Free up resources on the node or reschedule workloads",
+	}
+	if len(underPressure) > 0 {
+		pressure.Status = inspectionv1alpha1.CheckWarn
+		pressure.Message = fmt.Sprintf("nodes under pressure: %s", strings.Join(underPressure, ", "))
+	}
+
+	skew := inspectionv1alpha1.CheckResult{
+		ID:          "KS-NODE-3",
+		Description: "Ensure that all nodes run the same kubelet version",
+		Target:      inspectionv1alpha1.TargetNode,
+		Status:      inspectionv1alpha1.CheckPass,
+		Remediation: "Finish the rolling upgrade so all kubelets are on the same version",
+	}
+	if len(versions) > 1 {
+		var list []string
+		for version := range versions {
+			list = append(list, version)
+		}
+		sort.Strings(list)
+		skew.Status = inspectionv1alpha1.CheckWarn
+		skew.Message = fmt.Sprintf("kubelet versions differ across nodes: %s", strings.Join(list, ", "))
+	}
+
+	return []inspectionv1alpha1.CheckResult{ready, pressure, skew}, nil
+}
+
+func isSystemNamespace(namespace string) bool {
+	for _, system := range systemNamespaces {
+		if namespace == system {
+			return true
+		}
+	}
+	return false
+}
+
+func checkWorkloads(ctx context.Context, c client.Client) ([]inspectionv1alpha1.CheckResult, error) {
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods); err != nil {
+		return nil, err
+	}
+
+	var privileged, hostAccess []string
+	for _, pod := range pods.Items {
+		if isSystemNamespace(pod.Namespace) {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			if container.SecurityContext != nil &&
+				container.SecurityContext.Privileged != nil && *container.SecurityContext.Privileged {
+				privileged = append(privileged, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+				break
+			}
+		}
+		if pod.Spec.HostNetwork || pod.Spec.HostPID || pod.Spec.HostIPC {
+			hostAccess = append(hostAccess, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+		}
+	}
+
+	privilegedResult := inspectionv1alpha1.CheckResult{
+		ID:          "KS-POD-1",
+		Description: "Ensure that no privileged containers run outside the system namespaces",
+		Target:      inspectionv1alpha1.TargetWorkload,
+		Status:      inspectionv1alpha1.CheckPass,
+		Remediation: "Remove privileged: true from the container security context or move the workload to a system namespace",
+	}
+	if len(privileged) > 0 {
+		privilegedResult.Status = inspectionv1alpha1.CheckFail
+		privilegedResult.Message = fmt.Sprintf("privileged pods: %s", strings.Join(privileged, ", "))
+	}
+
+	hostResult := inspectionv1alpha1.CheckResult{
+		ID:          "KS-POD-2",
+		Description: "Ensure that no pods share the host network, PID or IPC namespace outside the system namespaces",
+		Target:      inspectionv1alpha1.TargetWorkload,
+		Status:      inspectionv1alpha1.CheckPass,
+		Remediation: "Remove hostNetwork, hostPID and hostIPC from the pod spec",
+	}
+	if len(hostAccess) > 0 {
+		hostResult.Status = inspectionv1alpha1.CheckWarn
+		hostResult.Message = fmt.Sprintf("pods with host access: %s", strings.Join(hostAccess, ", "))
+	}
+
+	return []inspectionv1alpha1.CheckResult{privilegedResult, hostResult}, nil
+}
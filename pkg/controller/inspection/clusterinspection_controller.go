@@ -0,0 +1,240 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspection
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	inspectionv1alpha1 "kubesphere.io/kubesphere/pkg/apis/inspection/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/utils/cronutil"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	controllerName = "clusterinspection-controller"
+
+	// inspections are scheduled with minute granularity, check each one
+	// once per interval
+	checkInterval = time.Minute
+)
+
+// Reconciler runs the checks of ClusterInspection objects on their cron
+// schedule, stores each run as an InspectionReport and raises an event when
+// checks fail that passed in the previous run.
+type Reconciler struct {
+	client.Client
+
+	logger   logr.Logger
+	recorder record.EventRecorder
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.logger == nil {
+		r.logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.recorder == nil {
+		r.recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		For(&inspectionv1alpha1.ClusterInspection{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	logger := r.logger.WithValues("clusterinspection", req.Name)
+	ctx := context.Background()
+
+	inspection := &inspectionv1alpha1.ClusterInspection{}
+	if err := r.Get(ctx, req.NamespacedName, inspection); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !inspection.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	if inspection.Spec.Suspend {
+		return ctrl.Result{RequeueAfter: checkInterval}, nil
+	}
+
+	now := time.Now()
+	due, err := cronutil.Matches(inspection.Spec.Schedule, now)
+	if err != nil {
+		logger.Error(err, "invalid schedule")
+		r.recorder.Event(inspection, corev1.EventTypeWarning, "InvalidSchedule", err.Error())
+		// do not requeue until the spec is fixed
+		return ctrl.Result{}, nil
+	}
+
+	if due && !r.ranThisMinute(inspection, now) {
+		if err := r.runInspection(ctx, inspection, now); err != nil {
+			logger.Error(err, "inspection run failed")
+			r.recorder.Event(inspection, corev1.EventTypeWarning, "InspectionFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.expireReports(ctx, inspection); err != nil {
+		logger.Error(err, "expire reports failed")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: checkInterval}, nil
+}
+
+// ranThisMinute reports whether the inspection already ran in the minute the
+// schedule fired for, to keep the periodic checks idempotent.
+func (r *Reconciler) ranThisMinute(inspection *inspectionv1alpha1.ClusterInspection, now time.Time) bool {
+	last := inspection.Status.LastRunTime
+	return last != nil && !last.Time.Before(now.Truncate(time.Minute))
+}
+
+func (r *Reconciler) runInspection(ctx context.Context, inspection *inspectionv1alpha1.ClusterInspection, now time.Time) error {
+	results, err := runChecks(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+
+	summary := inspectionv1alpha1.InspectionSummary{}
+	for _, result := range results {
+		switch result.Status {
+		case inspectionv1alpha1.CheckPass:
+			summary.Pass++
+		case inspectionv1alpha1.CheckWarn:
+			summary.Warn++
+		case inspectionv1alpha1.CheckFail:
+			summary.Fail++
+		}
+	}
+
+	report := &inspectionv1alpha1.InspectionReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("%s-%s", inspection.Name, now.UTC().Format("20060102-1504")),
+			Labels: map[string]string{
+				inspectionv1alpha1.ClusterInspectionLabel: inspection.Name,
+			},
+		},
+		StartTime: metav1.Time{Time: now},
+		Summary:   summary,
+		Results:   results,
+	}
+
+	// the previous run is needed before the new report makes it the second newest
+	previous, err := r.latestReport(ctx, inspection)
+	if err != nil {
+		return err
+	}
+
+	if err := r.Create(ctx, report); err != nil {
+		return err
+	}
+
+	r.recorder.Event(inspection, corev1.EventTypeNormal, "InspectionCompleted",
+		fmt.Sprintf("Inspection run stored as report %s: %d passed, %d warnings, %d failed",
+			report.Name, summary.Pass, summary.Warn, summary.Fail))
+	r.notifyNewFailures(inspection, previous, report)
+
+	inspection.Status.LastRunTime = &metav1.Time{Time: now}
+	inspection.Status.LastReportName = report.Name
+	inspection.Status.LastFailureCount = summary.Fail
+	return r.Status().Update(ctx, inspection)
+}
+
+// notifyNewFailures raises a warning event for checks that fail in the new
+// report but did not fail in the previous one, so subscribers are only
+// notified about regressions instead of every run.
+func (r *Reconciler) notifyNewFailures(inspection *inspectionv1alpha1.ClusterInspection, previous, report *inspectionv1alpha1.InspectionReport) {
+	previousFailures := map[string]bool{}
+	if previous != nil {
+		for _, result := range previous.Results {
+			if result.Status == inspectionv1alpha1.CheckFail {
+				previousFailures[result.ID] = true
+			}
+		}
+	}
+
+	var newFailures []string
+	for _, result := range report.Results {
+		if result.Status == inspectionv1alpha1.CheckFail && !previousFailures[result.ID] {
+			newFailures = append(newFailures, result.ID)
+		}
+	}
+	if len(newFailures) > 0 {
+		r.recorder.Event(inspection, corev1.EventTypeWarning, "NewFailures",
+			fmt.Sprintf("Checks failing that passed in the previous run: %s", strings.Join(newFailures, ", ")))
+	}
+}
+
+// latestReport returns the newest report of the inspection, nil when there
+// is none yet.
+func (r *Reconciler) latestReport(ctx context.Context, inspection *inspectionv1alpha1.ClusterInspection) (*inspectionv1alpha1.InspectionReport, error) {
+	reports, err := r.listReports(ctx, inspection)
+	if err != nil || len(reports) == 0 {
+		return nil, err
+	}
+	return &reports[len(reports)-1], nil
+}
+
+// listReports returns the reports of the inspection ordered oldest first
+func (r *Reconciler) listReports(ctx context.Context, inspection *inspectionv1alpha1.ClusterInspection) ([]inspectionv1alpha1.InspectionReport, error) {
+	list := &inspectionv1alpha1.InspectionReportList{}
+	err := r.List(ctx, list, client.MatchingLabels{inspectionv1alpha1.ClusterInspectionLabel: inspection.Name})
+	if err != nil {
+		return nil, err
+	}
+	reports := list.Items
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].StartTime.Before(&reports[j].StartTime)
+	})
+	return reports, nil
+}
+
+// expireReports deletes the oldest reports of the inspection when there are
+// more than the history limit.
+func (r *Reconciler) expireReports(ctx context.Context, inspection *inspectionv1alpha1.ClusterInspection) error {
+	limit := inspection.Spec.HistoryLimit
+	if limit <= 0 {
+		return nil
+	}
+
+	reports, err := r.listReports(ctx, inspection)
+	if err != nil {
+		return err
+	}
+	for len(reports) > limit {
+		report := reports[0]
+		if err := r.Delete(ctx, &report); err != nil {
+			return err
+		}
+		reports = reports[1:]
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+
+*/
+
+package installer
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	installermodels "kubesphere.io/kubesphere/pkg/models/installer"
+)
+
+// ClusterConfigurationAdmission rejects ClusterConfiguration edits with
+// broken field combinations before ks-installer consumes them, e.g. logging
+// enabled without any elasticsearch configured. The rejection message lists
+// every broken combination so the user can fix them in one pass.
+type ClusterConfigurationAdmission struct {
+	decoder *admission.Decoder
+}
+
+func NewClusterConfigurationAdmission(scheme *runtime.Scheme) (webhook.AdmissionHandler, error) {
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		return nil, err
+	}
+	return &ClusterConfigurationAdmission{decoder: decoder}, nil
+}
+
+func (a *ClusterConfigurationAdmission) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation == v1beta1.Delete {
+		return admission.Allowed("")
+	}
+
+	cc := &unstructured.Unstructured{}
+	if err := a.decoder.Decode(req, cc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if errs := installermodels.ValidateClusterConfiguration(cc); len(errs) > 0 {
+		messages := make([]string, 0, len(errs))
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		return admission.Denied(strings.Join(messages, "; "))
+	}
+
+	return admission.Allowed("")
+}
@@ -47,6 +47,9 @@ const (
 	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
 	maxRetries             = 15
 	revisionsAnnotationKey = "revisions"
+	// failureNotifiedAnnotationKey marks jobs whose failure event has already
+	// been emitted, so retries of the sync do not emit it again
+	failureNotifiedAnnotationKey = "kubesphere.io/failure-notified"
 )
 
 type JobController struct {
@@ -167,9 +170,67 @@ func (v *JobController) syncJob(key string) error {
 		return err
 	}
 
+	err = v.notifyFailure(job)
+
+	if err != nil {
+		log.Error(err, "notify job failure failed", "namespace", namespace, "name", name)
+		return err
+	}
+
 	return nil
 }
 
+// notifyFailure emits a warning event once a job reaches the failed state, so
+// the failure shows up in the events pipeline and can be picked up by
+// notifications. The job is annotated so the event is emitted only once.
+func (v *JobController) notifyFailure(job *batchv1.Job) error {
+	if job.Annotations[failureNotifiedAnnotationKey] == "true" {
+		return nil
+	}
+
+	var failed *batchv1.JobCondition
+	for i, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == v1.ConditionTrue {
+			failed = &job.Status.Conditions[i]
+			break
+		}
+	}
+	if failed == nil {
+		return nil
+	}
+
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", job.Name, time.Now().UnixNano()),
+			Namespace: job.Namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:      "Job",
+			Namespace: job.Namespace,
+			Name:      job.Name,
+			UID:       job.UID,
+		},
+		Type:           v1.EventTypeWarning,
+		Reason:         "JobFailed",
+		Message:        fmt.Sprintf("Job %s failed: %s", job.Name, failed.Message),
+		Source:         v1.EventSource{Component: "job-controller"},
+		FirstTimestamp: metav1.Now(),
+		LastTimestamp:  metav1.Now(),
+		Count:          1,
+	}
+	if _, err := v.client.CoreV1().Events(job.Namespace).Create(context.Background(), event, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	job = job.DeepCopy()
+	if job.Annotations == nil {
+		job.Annotations = make(map[string]string)
+	}
+	job.Annotations[failureNotifiedAnnotationKey] = "true"
+	_, err := v.client.BatchV1().Jobs(job.Namespace).Update(context.Background(), job, metav1.UpdateOptions{})
+	return err
+}
+
 // When a job is added, figure out which service it will be used
 // and enqueue it. obj must have *batchv1.Job type
 func (v *JobController) addJob(obj interface{}) {
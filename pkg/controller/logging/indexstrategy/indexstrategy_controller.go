@@ -0,0 +1,198 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package indexstrategy rolls the per-workspace log index strategies into
+// the configuration of the log agent and drives the index rollovers their
+// quotas ask for. It watches the strategy ConfigMap, keeps the rendered
+// fluent-bit output sections in the agent ConfigMap in sync, and
+// periodically asks Elasticsearch to roll indices over once a workspace's
+// document or size quota is reached.
+package indexstrategy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/logging"
+)
+
+const (
+	controllerName = "log-index-strategy-controller"
+
+	// resyncInterval is how often the rollover conditions are re-checked
+	// and workspace membership changes are picked up
+	resyncInterval = 10 * time.Minute
+)
+
+// Reconciler renders the log index strategies into the agent ConfigMap and
+// drives the quota rollovers against Elasticsearch.
+type Reconciler struct {
+	client.Client
+	Logger logr.Logger
+
+	// ElasticsearchHost is the logging backend the rollovers run against;
+	// empty skips them and only the agent config is reconciled
+	ElasticsearchHost string
+
+	httpClient *http.Client
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.httpClient == nil {
+		r.httpClient = &http.Client{Timeout: 15 * time.Second}
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		For(&corev1.ConfigMap{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	// only the strategy ConfigMap and the rendered agent ConfigMap matter
+	if req.Namespace != constants.KubeSphereLoggingNamespace ||
+		(req.Name != logging.IndexStrategyConfigMapName && req.Name != logging.AgentOutputConfigMapName) {
+		return ctrl.Result{}, nil
+	}
+
+	ctx := context.Background()
+
+	strategies, err := r.currentStrategies(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	namespaces, err := r.workspaceNamespaces(ctx, strategies)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	desired := logging.RenderAgentOutputConfig(strategies, namespaces)
+	if err := r.syncAgentConfig(ctx, desired, len(strategies)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	r.rollover(strategies)
+
+	// requeue to re-check the rollover conditions and pick up namespaces
+	// joining or leaving a workspace
+	return ctrl.Result{RequeueAfter: resyncInterval}, nil
+}
+
+func (r *Reconciler) currentStrategies(ctx context.Context) ([]logging.WorkspaceIndexStrategy, error) {
+	cm := &corev1.ConfigMap{}
+	name := types.NamespacedName{Namespace: constants.KubeSphereLoggingNamespace, Name: logging.IndexStrategyConfigMapName}
+	if err := r.Get(ctx, name, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	strategies, err := logging.UnmarshalIndexStrategies(cm)
+	if err != nil {
+		// a broken ConfigMap must not wedge the reconciliation, render
+		// without strategies until it is fixed
+		r.Logger.Error(err, "skip invalid index strategies")
+		return nil, nil
+	}
+	return strategies, nil
+}
+
+// workspaceNamespaces resolves the namespaces of the workspaces holding a
+// strategy, the agent routes logs by namespace
+func (r *Reconciler) workspaceNamespaces(ctx context.Context, strategies []logging.WorkspaceIndexStrategy) (map[string][]string, error) {
+	namespaces := map[string][]string{}
+	for _, strategy := range strategies {
+		list := &corev1.NamespaceList{}
+		if err := r.List(ctx, list, client.MatchingLabels{constants.WorkspaceLabelKey: strategy.Workspace}); err != nil {
+			return nil, err
+		}
+		for _, ns := range list.Items {
+			namespaces[strategy.Workspace] = append(namespaces[strategy.Workspace], ns.Name)
+		}
+	}
+	return namespaces, nil
+}
+
+func (r *Reconciler) syncAgentConfig(ctx context.Context, desired map[string]string, strategies int) error {
+	agent := &corev1.ConfigMap{}
+	name := types.NamespacedName{Namespace: constants.KubeSphereLoggingNamespace, Name: logging.AgentOutputConfigMapName}
+	if err := r.Get(ctx, name, agent); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		agent = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: name.Namespace,
+				Name:      name.Name,
+			},
+			Data: desired,
+		}
+		r.Logger.V(4).Info("creating agent output config", "strategies", strategies)
+		return r.Create(ctx, agent)
+	}
+
+	if reflect.DeepEqual(agent.Data, desired) {
+		return nil
+	}
+	agent.Data = desired
+	r.Logger.V(4).Info("updating agent output config", "strategies", strategies)
+	return r.Update(ctx, agent)
+}
+
+// rollover asks Elasticsearch to roll each quota-bearing strategy's write
+// index over; Elasticsearch only acts once a condition is met. Failures are
+// logged and retried on the next resync, a single unreachable backend must
+// not block the reconciliation.
+func (r *Reconciler) rollover(strategies []logging.WorkspaceIndexStrategy) {
+	if r.ElasticsearchHost == "" {
+		return
+	}
+	for _, strategy := range strategies {
+		body, ok := logging.RolloverConditions(strategy)
+		if !ok {
+			continue
+		}
+		url := fmt.Sprintf("%s/%s/_rollover", strings.TrimSuffix(r.ElasticsearchHost, "/"), strategy.IndexPrefix)
+		resp, err := r.httpClient.Post(url, "application/json", strings.NewReader(body))
+		if err != nil {
+			r.Logger.Error(err, "rollover request failed", "workspace", strategy.Workspace)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			r.Logger.Info("rollover request rejected", "workspace", strategy.Workspace,
+				"index", strategy.IndexPrefix, "status", resp.StatusCode)
+		}
+	}
+}
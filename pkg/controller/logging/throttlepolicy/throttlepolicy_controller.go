@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package throttlepolicy rolls the per-namespace log throttling and
+// sampling policies into the configuration of the log agent. It watches the
+// policy ConfigMap and keeps the rendered fluent-bit sections in the agent
+// ConfigMap in sync, also restoring them when the agent ConfigMap is edited
+// or deleted out of band.
+package throttlepolicy
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/logging"
+)
+
+const controllerName = "log-throttle-policy-controller"
+
+// Reconciler renders the log throttle policies into the agent ConfigMap.
+type Reconciler struct {
+	client.Client
+	Logger logr.Logger
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		For(&corev1.ConfigMap{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	// only the policy ConfigMap and the rendered agent ConfigMap matter
+	if req.Namespace != constants.KubeSphereLoggingNamespace ||
+		(req.Name != logging.ThrottlePolicyConfigMapName && req.Name != logging.AgentThrottleConfigMapName) {
+		return ctrl.Result{}, nil
+	}
+
+	ctx := context.Background()
+
+	policies, err := r.currentPolicies(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	desired := logging.RenderAgentThrottleConfig(policies)
+
+	agent := &corev1.ConfigMap{}
+	name := types.NamespacedName{Namespace: constants.KubeSphereLoggingNamespace, Name: logging.AgentThrottleConfigMapName}
+	if err := r.Get(ctx, name, agent); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		agent = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: name.Namespace,
+				Name:      name.Name,
+			},
+			Data: desired,
+		}
+		r.Logger.V(4).Info("creating agent throttle config", "policies", len(policies))
+		return ctrl.Result{}, r.Create(ctx, agent)
+	}
+
+	if reflect.DeepEqual(agent.Data, desired) {
+		return ctrl.Result{}, nil
+	}
+	agent.Data = desired
+	r.Logger.V(4).Info("updating agent throttle config", "policies", len(policies))
+	return ctrl.Result{}, r.Update(ctx, agent)
+}
+
+func (r *Reconciler) currentPolicies(ctx context.Context) ([]logging.ThrottlePolicy, error) {
+	cm := &corev1.ConfigMap{}
+	name := types.NamespacedName{Namespace: constants.KubeSphereLoggingNamespace, Name: logging.ThrottlePolicyConfigMapName}
+	if err := r.Get(ctx, name, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	policies, err := logging.UnmarshalThrottlePolicies(cm)
+	if err != nil {
+		// a broken ConfigMap must not wedge the reconciliation, render
+		// without policies until it is fixed
+		r.Logger.Error(err, "skip invalid throttle policies")
+		return nil, nil
+	}
+	return policies, nil
+}
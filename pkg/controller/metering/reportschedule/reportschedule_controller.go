@@ -0,0 +1,199 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reportschedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	meteringv1alpha1 "kubesphere.io/kubesphere/pkg/apis/metering/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+	"kubesphere.io/kubesphere/pkg/simple/client/s3"
+	"kubesphere.io/kubesphere/pkg/utils/cronutil"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	controllerName = "reportschedule-controller"
+
+	// report schedules fire with minute granularity, check each schedule
+	// once per interval
+	checkInterval = time.Minute
+)
+
+// meters included in workspace reports
+var workspaceMeters = []string{
+	"meter_workspace_cpu_usage",
+	"meter_workspace_memory_usage",
+	"meter_workspace_net_bytes_transmitted",
+	"meter_workspace_net_bytes_received",
+	"meter_workspace_pvc_bytes_total",
+}
+
+// Reconciler renders metering reports from ReportSchedule objects on their
+// cron schedule and delivers them to S3 or the notification pipeline.
+type Reconciler struct {
+	client.Client
+	MonitoringClient monitoring.Interface
+	S3Client         s3.Interface
+
+	logger   logr.Logger
+	recorder record.EventRecorder
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.logger == nil {
+		r.logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.recorder == nil {
+		r.recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		For(&meteringv1alpha1.ReportSchedule{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	logger := r.logger.WithValues("reportschedule", req.Name)
+	ctx := context.Background()
+
+	schedule := &meteringv1alpha1.ReportSchedule{}
+	if err := r.Get(ctx, req.NamespacedName, schedule); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !schedule.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	if schedule.Spec.Paused {
+		return ctrl.Result{RequeueAfter: checkInterval}, nil
+	}
+
+	now := time.Now()
+	due, err := cronutil.Matches(schedule.Spec.Schedule, now)
+	if err != nil {
+		logger.Error(err, "invalid schedule")
+		r.recorder.Event(schedule, corev1.EventTypeWarning, "InvalidSchedule", err.Error())
+		// do not requeue until the spec is fixed
+		return ctrl.Result{}, nil
+	}
+
+	if due && !r.firedThisMinute(schedule, now) {
+		if err := r.generateReport(ctx, schedule, now); err != nil {
+			logger.Error(err, "generate report failed")
+			r.recorder.Event(schedule, corev1.EventTypeWarning, "ReportFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: checkInterval}, nil
+}
+
+// firedThisMinute reports whether a report was already generated in the
+// minute the schedule fired for, to keep the periodic checks idempotent.
+func (r *Reconciler) firedThisMinute(schedule *meteringv1alpha1.ReportSchedule, now time.Time) bool {
+	last := schedule.Status.LastReportTime
+	return last != nil && !last.Time.Before(now.Truncate(time.Minute))
+}
+
+func (r *Reconciler) generateReport(ctx context.Context, schedule *meteringv1alpha1.ReportSchedule, now time.Time) error {
+	// the report covers the previous calendar month
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	start := end.AddDate(0, -1, 0)
+
+	report, err := r.renderCSV(schedule.Spec.Workspace, start, end)
+	if err != nil {
+		return err
+	}
+
+	location := fmt.Sprintf("metering/reports/%s-%s.csv", schedule.Spec.Workspace, start.Format("200601"))
+	if schedule.Spec.Delivery.S3 {
+		if r.S3Client == nil {
+			return fmt.Errorf("s3 delivery requested but no s3 storage is configured")
+		}
+		if err := r.S3Client.Upload(location, fmt.Sprintf("%s-%s.csv", schedule.Spec.Workspace, start.Format("200601")), bytes.NewReader(report)); err != nil {
+			return err
+		}
+		r.recorder.Event(schedule, corev1.EventTypeNormal, "ReportUploaded",
+			fmt.Sprintf("Uploaded metering report %s", location))
+	}
+
+	if len(schedule.Spec.Delivery.EmailRecipients) > 0 {
+		// the notification pipeline picks the report location up from the event
+		r.recorder.Event(schedule, corev1.EventTypeNormal, "ReportGenerated",
+			fmt.Sprintf("Metering report %s for workspace %s is ready, recipients: %s",
+				location, schedule.Spec.Workspace, strings.Join(schedule.Spec.Delivery.EmailRecipients, ",")))
+	}
+
+	schedule.Status.LastReportTime = &metav1.Time{Time: now}
+	schedule.Status.LastReportLocation = location
+	return r.Status().Update(ctx, schedule)
+}
+
+// renderCSV queries the workspace meters over the report period and renders
+// one row per meter and sample.
+func (r *Reconciler) renderCSV(workspace string, start, end time.Time) ([]byte, error) {
+	metrics := r.MonitoringClient.GetNamedMetersOverTime(workspaceMeters, start, end, time.Hour,
+		[]monitoring.QueryOption{monitoring.WorkspaceOption{WorkspaceName: workspace}})
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"meter", "workspace", "time", "value"}); err != nil {
+		return nil, err
+	}
+
+	for _, metric := range metrics {
+		if metric.Error != "" {
+			return nil, fmt.Errorf("query %s failed: %s", metric.MetricName, metric.Error)
+		}
+		for _, value := range metric.MetricValues {
+			for _, point := range value.Series {
+				row := []string{
+					metric.MetricName,
+					workspace,
+					time.Unix(int64(point[0]), 0).UTC().Format(time.RFC3339),
+					strconv.FormatFloat(point[1], 'f', -1, 64),
+				}
+				if err := w.Write(row); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	snapshotv1beta1 "github.com/kubernetes-csi/external-snapshotter/client/v3/apis/volumesnapshot/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// DefaultDeletionGracePeriod is how long an archived namespace is kept
+	// before it is deleted, unless the archive request says otherwise
+	DefaultDeletionGracePeriod = 30 * 24 * time.Hour
+
+	// archivedReplicasAnnotation records a workload's replicas before the
+	// namespace was archived, so cancelling restores them
+	archivedReplicasAnnotation = "namespace.kubesphere.io/archived-replicas"
+
+	archiveSnapshotPrefix  = "archive-"
+	archiveObjectKeyFormat = "namespace-archives/%s.json"
+)
+
+// reconcileArchival drives a namespace through the archive lifecycle:
+// scale workloads to zero, snapshot PVCs, export manifests to object
+// storage, then delete the namespace once the grace period has passed.
+// Removing the archive annotation before that cancels the deletion and
+// restores the workloads.
+func (r *Reconciler) reconcileArchival(ctx context.Context, logger logr.Logger, namespace *corev1.Namespace) (ctrl.Result, error) {
+	_, requested := namespace.Annotations[constants.ArchiveAnnotationKey]
+	_, archived := namespace.Annotations[constants.ArchivedAtAnnotationKey]
+
+	if !requested {
+		if archived {
+			return ctrl.Result{}, r.cancelArchival(ctx, logger, namespace)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !archived {
+		if err := r.archive(ctx, logger, namespace); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	deleteAfter, err := time.Parse(time.RFC3339, namespace.Annotations[constants.DeleteAfterAnnotationKey])
+	if err != nil {
+		// the annotations were tampered with, re-archive on the next request
+		logger.Error(err, "invalid deletion schedule, cancelling archival")
+		return ctrl.Result{}, r.cancelArchival(ctx, logger, namespace)
+	}
+	if remaining := time.Until(deleteAfter); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	logger.Info("grace period expired, deleting archived namespace")
+	r.Recorder.Event(namespace, corev1.EventTypeNormal, "ArchiveExpired", "deleting archived namespace, the grace period has passed")
+	return ctrl.Result{}, r.Delete(ctx, namespace)
+}
+
+func (r *Reconciler) archive(ctx context.Context, logger logr.Logger, namespace *corev1.Namespace) error {
+	gracePeriod := DefaultDeletionGracePeriod
+	if value := namespace.Annotations[constants.ArchiveAnnotationKey]; value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			gracePeriod = parsed
+		}
+	}
+
+	if err := r.scaleWorkloadsToZero(ctx, logger, namespace.Name); err != nil {
+		return err
+	}
+	if err := r.snapshotVolumes(ctx, logger, namespace.Name); err != nil {
+		return err
+	}
+	if err := r.exportManifests(ctx, logger, namespace.Name); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	deleteAfter := now.Add(gracePeriod)
+	namespace.Annotations[constants.ArchivedAtAnnotationKey] = now.Format(time.RFC3339)
+	namespace.Annotations[constants.DeleteAfterAnnotationKey] = deleteAfter.Format(time.RFC3339)
+	if err := r.Update(ctx, namespace); err != nil {
+		return err
+	}
+	r.Recorder.Eventf(namespace, corev1.EventTypeNormal, "Archived",
+		"namespace archived, deletion scheduled at %s", deleteAfter.Format(time.RFC3339))
+	return nil
+}
+
+func (r *Reconciler) cancelArchival(ctx context.Context, logger logr.Logger, namespace *corev1.Namespace) error {
+	if err := r.restoreWorkloads(ctx, logger, namespace.Name); err != nil {
+		return err
+	}
+	delete(namespace.Annotations, constants.ArchivedAtAnnotationKey)
+	delete(namespace.Annotations, constants.DeleteAfterAnnotationKey)
+	if err := r.Update(ctx, namespace); err != nil {
+		return err
+	}
+	r.Recorder.Event(namespace, corev1.EventTypeNormal, "ArchiveCancelled", "namespace archival cancelled, workloads restored")
+	return nil
+}
+
+func (r *Reconciler) scaleWorkloadsToZero(ctx context.Context, logger logr.Logger, namespace string) error {
+	var zero int32
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas == 0 {
+			continue
+		}
+		if deployment.Annotations == nil {
+			deployment.Annotations = make(map[string]string)
+		}
+		deployment.Annotations[archivedReplicasAnnotation] = strconv.Itoa(int(*deployment.Spec.Replicas))
+		deployment.Spec.Replicas = &zero
+		logger.V(4).Info("scale down archived workload", "deployment", deployment.Name)
+		if err := r.Update(ctx, deployment); err != nil {
+			return err
+		}
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		statefulSet := &statefulSets.Items[i]
+		if statefulSet.Spec.Replicas == nil || *statefulSet.Spec.Replicas == 0 {
+			continue
+		}
+		if statefulSet.Annotations == nil {
+			statefulSet.Annotations = make(map[string]string)
+		}
+		statefulSet.Annotations[archivedReplicasAnnotation] = strconv.Itoa(int(*statefulSet.Spec.Replicas))
+		statefulSet.Spec.Replicas = &zero
+		logger.V(4).Info("scale down archived workload", "statefulset", statefulSet.Name)
+		if err := r.Update(ctx, statefulSet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) restoreWorkloads(ctx context.Context, logger logr.Logger, namespace string) error {
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		replicas, err := archivedReplicas(deployment.Annotations)
+		if err != nil {
+			continue
+		}
+		deployment.Spec.Replicas = &replicas
+		delete(deployment.Annotations, archivedReplicasAnnotation)
+		logger.V(4).Info("restore archived workload", "deployment", deployment.Name, "replicas", replicas)
+		if err := r.Update(ctx, deployment); err != nil {
+			return err
+		}
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		statefulSet := &statefulSets.Items[i]
+		replicas, err := archivedReplicas(statefulSet.Annotations)
+		if err != nil {
+			continue
+		}
+		statefulSet.Spec.Replicas = &replicas
+		delete(statefulSet.Annotations, archivedReplicasAnnotation)
+		logger.V(4).Info("restore archived workload", "statefulset", statefulSet.Name, "replicas", replicas)
+		if err := r.Update(ctx, statefulSet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func archivedReplicas(annotations map[string]string) (int32, error) {
+	value, ok := annotations[archivedReplicasAnnotation]
+	if !ok {
+		return 0, fmt.Errorf("not archived")
+	}
+	replicas, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	return int32(replicas), nil
+}
+
+// snapshotVolumes takes a CSI snapshot of every PVC in the namespace, so
+// the data survives the scheduled deletion.
+func (r *Reconciler) snapshotVolumes(ctx context.Context, logger logr.Logger, namespace string) error {
+	if r.SnapshotClient == nil {
+		logger.V(4).Info("snapshot client not configured, skip volume snapshots")
+		return nil
+	}
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := r.List(ctx, pvcs, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		snapshot := &snapshotv1beta1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      archiveSnapshotPrefix + pvc.Name,
+				Namespace: namespace,
+			},
+			Spec: snapshotv1beta1.VolumeSnapshotSpec{
+				Source: snapshotv1beta1.VolumeSnapshotSource{
+					PersistentVolumeClaimName: &pvc.Name,
+				},
+			},
+		}
+		logger.V(4).Info("snapshot archived volume", "pvc", pvc.Name)
+		if _, err := r.SnapshotClient.SnapshotV1beta1().VolumeSnapshots(namespace).Create(ctx, snapshot, metav1.CreateOptions{}); err != nil {
+			if errors.IsAlreadyExists(err) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// exportManifests uploads the namespace's workload and configuration
+// manifests to object storage before the namespace is deleted.
+func (r *Reconciler) exportManifests(ctx context.Context, logger logr.Logger, namespace string) error {
+	if r.S3Client == nil {
+		logger.V(4).Info("object storage not configured, skip manifest export")
+		return nil
+	}
+
+	manifests := make(map[string]interface{})
+	for kind, list := range map[string]runtime.Object{
+		"deployments":            &appsv1.DeploymentList{},
+		"statefulsets":           &appsv1.StatefulSetList{},
+		"services":               &corev1.ServiceList{},
+		"configmaps":             &corev1.ConfigMapList{},
+		"persistentvolumeclaims": &corev1.PersistentVolumeClaimList{},
+	} {
+		if err := r.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return err
+		}
+		manifests[kind] = list
+	}
+
+	body, err := json.Marshal(manifests)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf(archiveObjectKeyFormat, namespace)
+	logger.V(4).Info("export archived manifests", "key", key)
+	return r.S3Client.Upload(key, namespace+".json", bytes.NewReader(body))
+}
@@ -23,6 +23,7 @@ import (
 	"reflect"
 
 	"github.com/go-logr/logr"
+	snapshotclient "github.com/kubernetes-csi/external-snapshotter/client/v3/clientset/versioned"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -37,6 +38,8 @@ import (
 	tenantv1alpha1 "kubesphere.io/kubesphere/pkg/apis/tenant/v1alpha1"
 	"kubesphere.io/kubesphere/pkg/constants"
 	controllerutils "kubesphere.io/kubesphere/pkg/controller/utils/controller"
+	"kubesphere.io/kubesphere/pkg/controller/utils/sharding"
+	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 	"kubesphere.io/kubesphere/pkg/utils/k8sutil"
 	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -55,6 +58,15 @@ type Reconciler struct {
 	Logger                  logr.Logger
 	Recorder                record.EventRecorder
 	MaxConcurrentReconciles int
+
+	// SnapshotClient and S3Client back namespace archival; when nil the
+	// snapshot and manifest export steps are skipped
+	SnapshotClient snapshotclient.Interface
+	S3Client       s3.Interface
+
+	// Sharding optionally limits reconciliation to the namespaces owned by
+	// this shard, nil reconciles everything
+	Sharding *sharding.Sharding
 }
 
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -70,13 +82,16 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if r.MaxConcurrentReconciles <= 0 {
 		r.MaxConcurrentReconciles = 1
 	}
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		Named(controllerName).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
 		}).
-		For(&corev1.Namespace{}).
-		Complete(r)
+		For(&corev1.Namespace{})
+	if r.Sharding.Enabled() {
+		builder = builder.WithEventFilter(r.Sharding.Predicate())
+	}
+	return builder.Complete(r)
 }
 
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
@@ -130,12 +145,20 @@ func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, nil
 	}
 
+	// Archived namespaces are scheduled for deletion after a grace period
+	if result, err := r.reconcileArchival(rootCtx, logger, namespace); err != nil || result.RequeueAfter > 0 {
+		return result, err
+	}
+
 	// Bind to workspace if the namespace created by kubesphere
 	_, hasWorkspaceLabel := namespace.Labels[tenantv1alpha1.WorkspaceLabel]
 	if hasWorkspaceLabel {
 		if err := r.bindWorkspace(rootCtx, logger, namespace); err != nil {
 			return ctrl.Result{}, err
 		}
+		if err := r.applyWorkspaceTemplates(rootCtx, logger, namespace); err != nil {
+			return ctrl.Result{}, err
+		}
 	} else {
 		if err := r.unbindWorkspace(rootCtx, logger, namespace); err != nil {
 			return ctrl.Result{}, err
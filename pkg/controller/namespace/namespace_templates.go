@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// templateConfigMapFormat names the ConfigMap in the kubesphere-system
+	// namespace holding the default resources of a workspace; each data
+	// entry is one YAML manifest (ResourceQuota, LimitRange or NetworkPolicy)
+	// stamped onto every namespace of the workspace.
+	templateConfigMapFormat = "namespace-templates-%s"
+
+	// TemplateVersionAnnotation on the ConfigMap versions the templates.
+	// The applied version is recorded on the namespace under the same key,
+	// so bumping it makes the controller re-apply the templates on the
+	// next reconcile.
+	TemplateVersionAnnotation = "tenant.kubesphere.io/template-version"
+)
+
+// applyWorkspaceTemplates stamps the workspace's default ResourceQuota,
+// LimitRange and NetworkPolicy manifests onto the namespace, skipping
+// namespaces already at the current template version.
+func (r *Reconciler) applyWorkspaceTemplates(ctx context.Context, logger logr.Logger, namespace *corev1.Namespace) error {
+	workspace := namespace.Labels[constants.WorkspaceLabelKey]
+	if workspace == "" {
+		return nil
+	}
+
+	templates := &corev1.ConfigMap{}
+	name := types.NamespacedName{Namespace: constants.KubeSphereNamespace, Name: fmt.Sprintf(templateConfigMapFormat, workspace)}
+	if err := r.Get(ctx, name, templates); err != nil {
+		// workspaces without templates are the common case
+		return client.IgnoreNotFound(err)
+	}
+
+	version := templates.Annotations[TemplateVersionAnnotation]
+	if version != "" && namespace.Annotations[TemplateVersionAnnotation] == version {
+		return nil
+	}
+
+	for entry, manifest := range templates.Data {
+		if err := r.applyTemplate(ctx, logger, namespace.Name, manifest); err != nil {
+			logger.Error(err, "apply workspace template failed", "workspace", workspace, "template", entry)
+			return err
+		}
+	}
+
+	if version != "" {
+		namespace := namespace.DeepCopy()
+		if namespace.Annotations == nil {
+			namespace.Annotations = make(map[string]string)
+		}
+		namespace.Annotations[TemplateVersionAnnotation] = version
+		logger.V(4).Info("record applied template version", "workspace", workspace, "version", version)
+		if err := r.Update(ctx, namespace); err != nil {
+			logger.Error(err, "update namespace failed")
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) applyTemplate(ctx context.Context, logger logr.Logger, namespace, manifest string) error {
+	var typeMeta metav1.TypeMeta
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(manifest), 1024).Decode(&typeMeta); err != nil {
+		return err
+	}
+
+	decode := func(into interface{}) error {
+		return yaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(manifest), 1024).Decode(into)
+	}
+	switch typeMeta.Kind {
+	case "ResourceQuota":
+		var quota corev1.ResourceQuota
+		if err := decode(&quota); err != nil {
+			return err
+		}
+		quota.Namespace = namespace
+		var old corev1.ResourceQuota
+		return r.createOrUpdate(ctx, logger, typeMeta.Kind, &quota, &old, func() bool {
+			if reflect.DeepEqual(quota.Spec, old.Spec) {
+				return false
+			}
+			old.Spec = quota.Spec
+			return true
+		})
+	case "LimitRange":
+		var limits corev1.LimitRange
+		if err := decode(&limits); err != nil {
+			return err
+		}
+		limits.Namespace = namespace
+		var old corev1.LimitRange
+		return r.createOrUpdate(ctx, logger, typeMeta.Kind, &limits, &old, func() bool {
+			if reflect.DeepEqual(limits.Spec, old.Spec) {
+				return false
+			}
+			old.Spec = limits.Spec
+			return true
+		})
+	case "NetworkPolicy":
+		var policy networkingv1.NetworkPolicy
+		if err := decode(&policy); err != nil {
+			return err
+		}
+		policy.Namespace = namespace
+		var old networkingv1.NetworkPolicy
+		return r.createOrUpdate(ctx, logger, typeMeta.Kind, &policy, &old, func() bool {
+			if reflect.DeepEqual(policy.Spec, old.Spec) {
+				return false
+			}
+			old.Spec = policy.Spec
+			return true
+		})
+	}
+	return fmt.Errorf("unsupported template kind %s", typeMeta.Kind)
+}
+
+// createOrUpdate creates the templated object in the namespace, or syncs
+// the existing one through the merge callback when the template changed.
+// The desired object must already carry its target namespace.
+func (r *Reconciler) createOrUpdate(ctx context.Context, logger logr.Logger, kind string, desired, old runtime.Object, merge func() bool) error {
+	name, err := client.ObjectKeyFromObject(desired)
+	if err != nil {
+		return err
+	}
+	if err := r.Get(ctx, name, old); err != nil {
+		if errors.IsNotFound(err) {
+			logger.V(4).Info("create templated resource", "kind", kind, "name", name.Name)
+			return r.Create(ctx, desired)
+		}
+		return err
+	}
+	if merge() {
+		logger.V(4).Info("update templated resource", "kind", kind, "name", name.Name)
+		return r.Update(ctx, old)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+
+*/
+
+package nodegroup
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/api/admission/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	tenantv1alpha1 "kubesphere.io/kubesphere/pkg/apis/tenant/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/nodegroup"
+)
+
+// NodeGroupAdmission pins workloads of workspaces bound to a dedicated node
+// group onto that group's nodes by injecting the matching nodeSelector and
+// toleration.
+type NodeGroupAdmission struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+func NewNodeGroupAdmission(client client.Client, scheme *runtime.Scheme) (webhook.AdmissionHandler, error) {
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeGroupAdmission{client: client, decoder: decoder}, nil
+}
+
+func (w *NodeGroupAdmission) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != v1beta1.Create || len(req.RequestSubResource) != 0 || len(req.Namespace) == 0 {
+		return admission.Allowed("")
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := w.client.Get(ctx, client.ObjectKey{Name: req.Namespace}, namespace); err != nil {
+		klog.Error(err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	workspaceName := namespace.Labels[constants.WorkspaceLabelKey]
+	if workspaceName == "" {
+		return admission.Allowed("")
+	}
+
+	workspace := &tenantv1alpha1.Workspace{}
+	if err := w.client.Get(ctx, client.ObjectKey{Name: workspaceName}, workspace); err != nil {
+		// namespaces may reference workspaces that no longer exist
+		return admission.Allowed("")
+	}
+	group := workspace.Labels[nodegroup.GroupLabel]
+	if group == "" {
+		return admission.Allowed("")
+	}
+
+	workload, podTemplate, err := w.decodeWorkload(req)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if workload == nil {
+		return admission.Allowed("")
+	}
+
+	pinToGroup(podTemplate, group)
+
+	marshalled, err := json.Marshal(workload)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshalled)
+}
+
+// decodeWorkload returns the decoded workload together with a pointer into
+// its pod template, workloads of unhandled kinds return nil
+func (w *NodeGroupAdmission) decodeWorkload(req admission.Request) (runtime.Object, *corev1.PodTemplateSpec, error) {
+	switch req.Kind.Kind {
+	case "Deployment":
+		deployment := &appsv1.Deployment{}
+		if err := w.decoder.Decode(req, deployment); err != nil {
+			return nil, nil, err
+		}
+		return deployment, &deployment.Spec.Template, nil
+	case "StatefulSet":
+		statefulSet := &appsv1.StatefulSet{}
+		if err := w.decoder.Decode(req, statefulSet); err != nil {
+			return nil, nil, err
+		}
+		return statefulSet, &statefulSet.Spec.Template, nil
+	case "DaemonSet":
+		daemonSet := &appsv1.DaemonSet{}
+		if err := w.decoder.Decode(req, daemonSet); err != nil {
+			return nil, nil, err
+		}
+		return daemonSet, &daemonSet.Spec.Template, nil
+	}
+	return nil, nil, nil
+}
+
+func pinToGroup(podTemplate *corev1.PodTemplateSpec, group string) {
+	if podTemplate.Spec.NodeSelector == nil {
+		podTemplate.Spec.NodeSelector = map[string]string{}
+	}
+	if _, ok := podTemplate.Spec.NodeSelector[nodegroup.GroupLabel]; !ok {
+		podTemplate.Spec.NodeSelector[nodegroup.GroupLabel] = group
+	}
+	for _, toleration := range podTemplate.Spec.Tolerations {
+		if toleration.Key == nodegroup.TaintKey {
+			return
+		}
+	}
+	podTemplate.Spec.Tolerations = append(podTemplate.Spec.Tolerations, corev1.Toleration{
+		Key:      nodegroup.TaintKey,
+		Operator: corev1.TolerationOpEqual,
+		Value:    group,
+		Effect:   corev1.TaintEffectNoSchedule,
+	})
+}
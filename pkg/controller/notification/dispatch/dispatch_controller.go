@@ -0,0 +1,174 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dispatch retries the queued notification deliveries. It watches
+// the delivery queue ConfigMap, posts every due pending delivery again with
+// exponential backoff, and moves deliveries that exhausted their attempts
+// into the dead-letter ConfigMap for inspection and manual retry.
+package dispatch
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/notification"
+)
+
+const (
+	controllerName = "notification-dispatch-controller"
+
+	// resyncInterval bounds how long a due retry waits when no ConfigMap
+	// event triggers the reconciliation earlier
+	resyncInterval = 30 * time.Second
+)
+
+// Reconciler retries the queued notification deliveries.
+type Reconciler struct {
+	client.Client
+	Logger logr.Logger
+
+	httpClient *http.Client
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.httpClient == nil {
+		r.httpClient = &http.Client{Timeout: 15 * time.Second}
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		For(&corev1.ConfigMap{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	// only the delivery queue matters
+	if req.Namespace != constants.KubeSphereNamespace || req.Name != notification.DeliveryQueueConfigMapName {
+		return ctrl.Result{}, nil
+	}
+
+	ctx := context.Background()
+
+	cm := &corev1.ConfigMap{}
+	name := types.NamespacedName{Namespace: constants.KubeSphereNamespace, Name: notification.DeliveryQueueConfigMapName}
+	if err := r.Get(ctx, name, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	deliveries, err := notification.UnmarshalDeliveries(cm)
+	if err != nil {
+		// a broken ConfigMap must not wedge the reconciliation, leave it
+		// alone until it is fixed
+		r.Logger.Error(err, "skip invalid delivery queue")
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+	var dead []notification.Delivery
+	kept := make([]notification.Delivery, 0, len(deliveries))
+	attempted := 0
+	for _, delivery := range deliveries {
+		if delivery.State == notification.DeliveryPending && !delivery.NextAttempt.After(now) {
+			notification.AttemptDelivery(r.httpClient, &delivery)
+			attempted++
+		}
+		if delivery.State == notification.DeliveryDead {
+			r.Logger.Info("delivery exhausted its attempts", "delivery", delivery.ID,
+				"endpoint", delivery.Endpoint, "error", delivery.LastError)
+			dead = append(dead, delivery)
+			continue
+		}
+		kept = append(kept, delivery)
+	}
+	kept = notification.PruneDeliveries(kept)
+
+	if attempted > 0 || len(kept) != len(deliveries) {
+		data, err := notification.MarshalDeliveries(kept)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		cm.Data = data
+		if err := r.Update(ctx, cm); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if len(dead) > 0 {
+		if err := r.appendDeadLetters(ctx, dead); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// come back for the retries still pending
+	for _, delivery := range kept {
+		if delivery.State == notification.DeliveryPending {
+			return ctrl.Result{RequeueAfter: resyncInterval}, nil
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *Reconciler) appendDeadLetters(ctx context.Context, dead []notification.Delivery) error {
+	cm := &corev1.ConfigMap{}
+	name := types.NamespacedName{Namespace: constants.KubeSphereNamespace, Name: notification.DeadLetterConfigMapName}
+	if err := r.Get(ctx, name, cm); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		data, err := notification.MarshalDeliveries(notification.CapDeadLetters(dead))
+		if err != nil {
+			return err
+		}
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: name.Namespace,
+				Name:      name.Name,
+			},
+			Data: data,
+		}
+		return r.Create(ctx, cm)
+	}
+
+	existing, err := notification.UnmarshalDeliveries(cm)
+	if err != nil {
+		r.Logger.Error(err, "replacing invalid dead letters")
+		existing = nil
+	}
+	data, err := notification.MarshalDeliveries(notification.CapDeadLetters(append(existing, dead...)))
+	if err != nil {
+		return err
+	}
+	cm.Data = data
+	return r.Update(ctx, cm)
+}
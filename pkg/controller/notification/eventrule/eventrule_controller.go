@@ -0,0 +1,227 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventrule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/notification"
+)
+
+const (
+	controllerName = "event-rule-controller"
+
+	// defaultEndpoint is where matched events are posted as alerts;
+	// notification-manager routes them to the workspace's channels
+	defaultEndpoint = "http://notification-manager-svc.kubesphere-monitoring-system.svc:19093/api/v2/alerts"
+)
+
+// Reconciler converts Kubernetes events matched by a workspace's event
+// notification rules into notifications, deduplicated per rule window.
+type Reconciler struct {
+	client.Client
+	Logger logr.Logger
+
+	// Endpoint overrides where the alerts are posted; empty uses the
+	// notification-manager service
+	Endpoint string
+
+	httpClient *http.Client
+
+	mutex    sync.Mutex
+	lastSent map[string]time.Time
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.Endpoint == "" {
+		r.Endpoint = defaultEndpoint
+	}
+	if r.httpClient == nil {
+		r.httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	r.lastSent = make(map[string]time.Time)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		For(&corev1.Event{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	event := &corev1.Event{}
+	if err := r.Get(ctx, req.NamespacedName, event); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if event.Namespace == "" {
+		return ctrl.Result{}, nil
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: event.Namespace}, namespace); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	workspace := namespace.Labels[constants.WorkspaceLabelKey]
+	if workspace == "" {
+		return ctrl.Result{}, nil
+	}
+
+	rulesConfigMap := &corev1.ConfigMap{}
+	name := types.NamespacedName{Namespace: constants.KubeSphereNamespace, Name: notification.EventRuleConfigMapName(workspace)}
+	if err := r.Get(ctx, name, rulesConfigMap); err != nil {
+		// most workspaces have no rules
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	rules, err := notification.UnmarshalEventRules(rulesConfigMap)
+	if err != nil {
+		// broken rules must not wedge the event queue
+		r.Logger.Error(err, "skip invalid event rules", "workspace", workspace)
+		return ctrl.Result{}, nil
+	}
+
+	for _, rule := range rules {
+		if !rule.Matches(event) {
+			continue
+		}
+		if !r.shouldNotify(rule, event) {
+			continue
+		}
+		if err := r.notify(workspace, rule, event); err != nil {
+			r.Logger.Error(err, "send notification failed", "workspace", workspace, "rule", rule.Name)
+			return ctrl.Result{}, err
+		}
+		r.Logger.V(4).Info("event notification sent", "workspace", workspace, "rule", rule.Name, "reason", event.Reason)
+	}
+	return ctrl.Result{}, nil
+}
+
+// shouldNotify applies the rule's dedup window to the event source and
+// records the send time when the notification goes through.
+func (r *Reconciler) shouldNotify(rule notification.EventRule, event *corev1.Event) bool {
+	key := fmt.Sprintf("%s/%s/%s/%s/%s", rule.Name, event.Namespace, event.Reason, event.InvolvedObject.Kind, event.InvolvedObject.Name)
+	now := time.Now()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if last, ok := r.lastSent[key]; ok && now.Sub(last) < rule.Window() {
+		return false
+	}
+	// drop expired entries so the map does not grow with event churn
+	if len(r.lastSent) > 1024 {
+		for k, v := range r.lastSent {
+			if now.Sub(v) > notification.DefaultDedupWindow {
+				delete(r.lastSent, k)
+			}
+		}
+	}
+	r.lastSent[key] = now
+	return true
+}
+
+// currentOnCall resolves who the workspace's on-call schedule pages right
+// now. Resolution failures only drop the routing label, the notification
+// still goes out through the default routing.
+func (r *Reconciler) currentOnCall(workspace, schedule string) []string {
+	cm := &corev1.ConfigMap{}
+	name := types.NamespacedName{Namespace: constants.KubeSphereNamespace, Name: notification.OnCallConfigMapName(workspace)}
+	if err := r.Get(context.Background(), name, cm); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			r.Logger.Error(err, "read on-call schedules failed", "workspace", workspace)
+		}
+		return nil
+	}
+	schedules, err := notification.UnmarshalOnCallSchedules(cm)
+	if err != nil {
+		r.Logger.Error(err, "skip invalid on-call schedules", "workspace", workspace)
+		return nil
+	}
+	for _, s := range schedules {
+		if s.Name != schedule {
+			continue
+		}
+		users, err := s.OnCallAt(time.Now())
+		if err != nil {
+			r.Logger.Error(err, "resolve on-call users failed", "workspace", workspace, "schedule", schedule)
+			return nil
+		}
+		return users
+	}
+	return nil
+}
+
+type alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+}
+
+func (r *Reconciler) notify(workspace string, rule notification.EventRule, event *corev1.Event) error {
+	labels := map[string]string{
+		"alertname":            rule.Name,
+		"alerttype":            "event",
+		"workspace":            workspace,
+		"namespace":            event.Namespace,
+		"reason":               event.Reason,
+		"involved_object_kind": event.InvolvedObject.Kind,
+		"involved_object_name": event.InvolvedObject.Name,
+	}
+	if rule.Receiver != "" {
+		labels["receiver"] = rule.Receiver
+	}
+	if rule.OnCallSchedule != "" {
+		if users := r.currentOnCall(workspace, rule.OnCallSchedule); len(users) > 0 {
+			labels["user"] = strings.Join(users, ",")
+		}
+	}
+	body, err := json.Marshal([]alert{{
+		Labels:      labels,
+		Annotations: map[string]string{"message": event.Message},
+		StartsAt:    time.Now(),
+	}})
+	if err != nil {
+		return err
+	}
+
+	response, err := r.httpClient.Post(r.Endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("notification endpoint returned %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package projectrequest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenantv1alpha2 "kubesphere.io/kubesphere/pkg/apis/tenant/v1alpha2"
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const controllerName = "projectrequest-controller"
+
+// Reconciler provisions the namespace of an approved ProjectRequest with
+// the approved resource quota, and surfaces pending requests to workspace
+// admins through events.
+type Reconciler struct {
+	client.Client
+	Logger   logr.Logger
+	Recorder record.EventRecorder
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		For(&tenantv1alpha2.ProjectRequest{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	logger := r.Logger.WithValues("projectrequest", req.Name)
+
+	projectRequest := &tenantv1alpha2.ProjectRequest{}
+	if err := r.Get(ctx, req.NamespacedName, projectRequest); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	switch projectRequest.Status.State {
+	case "":
+		// notify workspace admins that a decision is needed
+		projectRequest.Status.State = tenantv1alpha2.ProjectRequestPending
+		if err := r.Update(ctx, projectRequest); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(projectRequest, corev1.EventTypeNormal, "ProjectRequested",
+			"%s requested project %s in workspace %s", projectRequest.Spec.Requester, projectRequest.Spec.Project, projectRequest.Spec.Workspace)
+	case tenantv1alpha2.ProjectRequestApproved:
+		if !projectRequest.Status.CompletionTime.IsZero() {
+			return ctrl.Result{}, nil
+		}
+		if err := r.provision(ctx, logger, projectRequest); err != nil {
+			return ctrl.Result{}, err
+		}
+	case tenantv1alpha2.ProjectRequestRejected:
+		if projectRequest.Status.CompletionTime.IsZero() {
+			projectRequest.Status.CompletionTime = metav1.Now()
+			if err := r.Update(ctx, projectRequest); err != nil {
+				return ctrl.Result{}, err
+			}
+			r.Recorder.Eventf(projectRequest, corev1.EventTypeNormal, "ProjectRejected",
+				"request for project %s was rejected by %s", projectRequest.Spec.Project, projectRequest.Status.Approver)
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *Reconciler) provision(ctx context.Context, logger logr.Logger, projectRequest *tenantv1alpha2.ProjectRequest) error {
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: projectRequest.Spec.Project,
+			Labels: map[string]string{
+				constants.WorkspaceLabelKey: projectRequest.Spec.Workspace,
+			},
+			Annotations: map[string]string{
+				constants.CreatorAnnotationKey: projectRequest.Spec.Requester,
+			},
+		},
+	}
+	if err := r.Create(ctx, namespace); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		// the name was taken after approval, the request cannot be fulfilled
+		projectRequest.Status.State = tenantv1alpha2.ProjectRequestRejected
+		projectRequest.Status.Message = fmt.Sprintf("project %s already exists", projectRequest.Spec.Project)
+		projectRequest.Status.CompletionTime = metav1.Now()
+		if err := r.Update(ctx, projectRequest); err != nil {
+			return err
+		}
+		r.Recorder.Event(projectRequest, corev1.EventTypeWarning, "ProvisionFailed", projectRequest.Status.Message)
+		return nil
+	}
+	logger.Info("provisioned project", "namespace", namespace.Name, "workspace", projectRequest.Spec.Workspace)
+
+	if len(projectRequest.Spec.Hard) > 0 {
+		quota := &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "default",
+				Namespace: namespace.Name,
+			},
+			Spec: corev1.ResourceQuotaSpec{
+				Hard: projectRequest.Spec.Hard,
+			},
+		}
+		if err := r.Create(ctx, quota); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	projectRequest.Status.Message = fmt.Sprintf("project %s provisioned", namespace.Name)
+	projectRequest.Status.CompletionTime = metav1.Now()
+	if err := r.Update(ctx, projectRequest); err != nil {
+		return err
+	}
+	r.Recorder.Eventf(projectRequest, corev1.EventTypeNormal, "ProjectProvisioned",
+		"project %s provisioned in workspace %s, approved by %s", namespace.Name, projectRequest.Spec.Workspace, projectRequest.Status.Approver)
+	return nil
+}
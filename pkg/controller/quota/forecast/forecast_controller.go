@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package forecast
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	quotav1alpha2 "kubesphere.io/kubesphere/pkg/apis/quota/v1alpha2"
+	"kubesphere.io/kubesphere/pkg/models/quotas"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const (
+	controllerName = "quotaforecast-controller"
+
+	// HorizonAnnotation overrides the forecast horizon of a quota, in days
+	HorizonAnnotation = "quota.kubesphere.io/forecast-horizon-days"
+
+	// usage trends move slowly, re-evaluating more often is pointless
+	resyncInterval = time.Hour
+)
+
+// Reconciler warns when a workspace is projected to hit its resource quota
+// within the forecast horizon, so the first signal is a notification
+// instead of a failing deploy.
+type Reconciler struct {
+	client.Client
+	MonitoringClient monitoring.Interface
+
+	forecaster quotas.QuotaForecaster
+	logger     logr.Logger
+	recorder   record.EventRecorder
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.forecaster == nil {
+		// the per-quota forecast does not need the kubesphere clientset
+		r.forecaster = quotas.NewQuotaForecaster(nil, r.MonitoringClient)
+	}
+	if r.logger == nil {
+		r.logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.recorder == nil {
+		r.recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		For(&quotav1alpha2.ResourceQuota{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	quota := &quotav1alpha2.ResourceQuota{}
+	if err := r.Get(context.Background(), req.NamespacedName, quota); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	horizon := quotas.DefaultForecastHorizon
+	if value := quota.Annotations[HorizonAnnotation]; value != "" {
+		if days, err := strconv.Atoi(value); err == nil && days > 0 {
+			horizon = time.Duration(days) * 24 * time.Hour
+		}
+	}
+
+	for _, hotspot := range r.forecaster.ForecastQuota(quota, horizon) {
+		r.logger.Info("workspace is projected to hit its quota",
+			"workspace", hotspot.Workspace, "resource", hotspot.Resource, "daysLeft", hotspot.DaysLeft)
+		r.recorder.Eventf(quota, corev1.EventTypeWarning, "QuotaExhaustionForecast",
+			"workspace %s is projected to exhaust its %s quota (%s of %s used) in %.1f days, around %s",
+			hotspot.Workspace, hotspot.Resource, hotspot.Used, hotspot.Hard, hotspot.DaysLeft, hotspot.ExhaustionTime)
+	}
+	return ctrl.Result{RequeueAfter: resyncInterval}, nil
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rolloutmodel "kubesphere.io/kubesphere/pkg/models/rollout"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const (
+	controllerName = "rollout-controller"
+
+	// revisionAnnotation is maintained by the deployment controller
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+
+	// soakPeriod is how long after rollout completion the post-deploy error
+	// rate is sampled, long enough for the monitoring system to observe the
+	// new pods
+	soakPeriod = 2 * time.Minute
+
+	// progressPollInterval paces the checks of an ongoing rollout
+	progressPollInterval = 15 * time.Second
+
+	// defaultThreshold is the error rate increase that triggers an automatic
+	// rollback when the deployment does not configure one
+	defaultThreshold = 0.05
+)
+
+// Reconciler records rollout analytics on deployments: duration, container
+// restarts and the monitored error rate before and after each rollout. When
+// a deployment opts into auto-rollback and the post-deploy error rate
+// regresses beyond the threshold, the previous revision is restored.
+type Reconciler struct {
+	client.Client
+	// MonitoringClient is optional, without it error rates are not sampled
+	// and auto-rollback never fires
+	MonitoringClient monitoring.Interface
+
+	logger   logr.Logger
+	recorder record.EventRecorder
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.logger == nil {
+		r.logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.recorder == nil {
+		r.recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		For(&appsv1.Deployment{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	logger := r.logger.WithValues("deployment", req.NamespacedName)
+	ctx := context.Background()
+
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, req.NamespacedName, deployment); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !deployment.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	revision := deployment.Annotations[revisionAnnotation]
+	if revision == "" {
+		return ctrl.Result{}, nil
+	}
+
+	history := rolloutmodel.History(deployment.Annotations)
+
+	// a revision without a record means a rollout just started
+	if len(history) == 0 || history[len(history)-1].Revision != revision {
+		entry := rolloutmodel.Record{
+			Revision:        revision,
+			StartTime:       metav1.Now(),
+			ErrorRateBefore: r.errorRate(deployment),
+			Result:          rolloutmodel.ResultProgressing,
+		}
+		history = append(history, entry)
+		if err := r.saveHistory(ctx, deployment, history); err != nil {
+			return ctrl.Result{}, err
+		}
+		logger.V(4).Info("rollout started", "revision", revision)
+		return ctrl.Result{RequeueAfter: progressPollInterval}, nil
+	}
+
+	current := &history[len(history)-1]
+	switch {
+	case current.Result == rolloutmodel.ResultProgressing:
+		if !rolloutComplete(deployment) {
+			return ctrl.Result{RequeueAfter: progressPollInterval}, nil
+		}
+		now := metav1.Now()
+		current.EndTime = &now
+		current.DurationSeconds = int64(now.Sub(current.StartTime.Time).Seconds())
+		restarts, err := r.podRestarts(ctx, deployment)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		current.Restarts = restarts
+		current.Result = rolloutmodel.ResultSucceeded
+		if err := r.saveHistory(ctx, deployment, history); err != nil {
+			return ctrl.Result{}, err
+		}
+		logger.V(4).Info("rollout complete", "revision", revision, "duration", current.DurationSeconds)
+		return ctrl.Result{RequeueAfter: soakPeriod}, nil
+
+	case current.Result == rolloutmodel.ResultSucceeded && current.ErrorRateAfter == nil && r.MonitoringClient != nil:
+		if remaining := soakPeriod - time.Since(current.EndTime.Time); remaining > 0 {
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+		current.ErrorRateAfter = r.errorRate(deployment)
+		if r.shouldRollback(deployment, current) {
+			if err := r.rollback(ctx, deployment, current); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, r.saveHistory(ctx, deployment, history)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// rolloutComplete mirrors the check kubectl rollout status uses.
+func rolloutComplete(deployment *appsv1.Deployment) bool {
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas == desired &&
+		deployment.Status.Replicas == desired &&
+		deployment.Status.AvailableReplicas == desired
+}
+
+func (r *Reconciler) saveHistory(ctx context.Context, deployment *appsv1.Deployment, history []rolloutmodel.Record) error {
+	if deployment.Annotations == nil {
+		deployment.Annotations = make(map[string]string)
+	}
+	deployment.Annotations[rolloutmodel.HistoryAnnotation] = rolloutmodel.EncodeHistory(history)
+	return r.Update(ctx, deployment)
+}
+
+// errorRate samples the deployment's unavailable replica ratio from the
+// monitoring system, nil when monitoring is not configured or has no data.
+func (r *Reconciler) errorRate(deployment *appsv1.Deployment) *float64 {
+	if r.MonitoringClient == nil {
+		return nil
+	}
+	expr := fmt.Sprintf(`namespace:deployment_unavailable_replicas:ratio{namespace=%q, deployment=%q}`,
+		deployment.Namespace, deployment.Name)
+	metric := r.MonitoringClient.GetMetric(expr, time.Now())
+	if len(metric.MetricValues) == 0 || metric.MetricValues[0].Sample == nil {
+		return nil
+	}
+	value := metric.MetricValues[0].Sample.Value()
+	return &value
+}
+
+func (r *Reconciler) podRestarts(ctx context.Context, deployment *appsv1.Deployment) (int32, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return 0, err
+	}
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(deployment.Namespace),
+		client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return 0, err
+	}
+	var restarts int32
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts += status.RestartCount
+		}
+	}
+	return restarts, nil
+}
+
+// shouldRollback applies the auto-rollback policy of the deployment to the
+// sampled error rates.
+func (r *Reconciler) shouldRollback(deployment *appsv1.Deployment, record *rolloutmodel.Record) bool {
+	if deployment.Annotations[rolloutmodel.AutoRollbackAnnotation] != "true" {
+		return false
+	}
+	if record.ErrorRateBefore == nil || record.ErrorRateAfter == nil {
+		return false
+	}
+	threshold := defaultThreshold
+	if configured, err := strconv.ParseFloat(deployment.Annotations[rolloutmodel.ThresholdAnnotation], 64); err == nil && configured > 0 {
+		threshold = configured
+	}
+	return *record.ErrorRateAfter-*record.ErrorRateBefore > threshold
+}
+
+// rollback restores the pod template of the previous revision's replica set,
+// the same way kubectl rollout undo does.
+func (r *Reconciler) rollback(ctx context.Context, deployment *appsv1.Deployment, record *rolloutmodel.Record) error {
+	previous, err := r.previousReplicaSet(ctx, deployment, record.Revision)
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		record.Message = "error rate regressed but no previous revision to roll back to"
+		return nil
+	}
+
+	deployment.Spec.Template = previous.Spec.Template
+	record.Result = rolloutmodel.ResultRolledBack
+	record.Message = fmt.Sprintf("error rate rose from %.3f to %.3f, rolled back to revision %s",
+		*record.ErrorRateBefore, *record.ErrorRateAfter, previous.Annotations[revisionAnnotation])
+	r.recorder.Event(deployment, corev1.EventTypeWarning, "RolloutRolledBack", record.Message)
+	r.logger.Info("rolled back deployment", "deployment", deployment.Namespace+"/"+deployment.Name, "message", record.Message)
+	return nil
+}
+
+// previousReplicaSet finds the replica set with the highest revision below
+// the given one.
+func (r *Reconciler) previousReplicaSet(ctx context.Context, deployment *appsv1.Deployment, revision string) (*appsv1.ReplicaSet, error) {
+	current, err := strconv.ParseInt(revision, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	replicaSets := &appsv1.ReplicaSetList{}
+	if err := r.List(ctx, replicaSets, client.InNamespace(deployment.Namespace),
+		client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	var previous *appsv1.ReplicaSet
+	var previousRevision int64
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		if !metav1.IsControlledBy(rs, deployment) {
+			continue
+		}
+		rsRevision, err := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+		if err != nil || rsRevision >= current {
+			continue
+		}
+		if previous == nil || rsRevision > previousRevision {
+			previous = rs
+			previousRevision = rsRevision
+		}
+	}
+	return previous, nil
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scalingschedule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	autoscalingv1alpha1 "kubesphere.io/kubesphere/pkg/apis/autoscaling/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/utils/cronutil"
+)
+
+const tickPeriod = time.Minute
+
+// Controller evaluates ScalingSchedules once a minute and scales the target
+// workloads whose cron rules fire. When a HorizontalPodAutoscaler targets
+// the same workload the rule moves the HPA's replica bounds instead of the
+// workload's replica count, so schedule and autoscaler do not fight each
+// other; scaling to zero sets the workload's replicas directly, which also
+// puts the HPA on standby.
+type Controller struct {
+	client runtimeclient.Client
+	k8s    kubernetes.Interface
+}
+
+func NewController(client runtimeclient.Client, k8s kubernetes.Interface) *Controller {
+	return &Controller{client: client, k8s: k8s}
+}
+
+func (c *Controller) Start(stopCh <-chan struct{}) error {
+	klog.Info("starting scaling schedule controller")
+	defer klog.Info("shutting down scaling schedule controller")
+
+	wait.Until(func() {
+		c.tick(time.Now())
+	}, tickPeriod, stopCh)
+	return nil
+}
+
+func (c *Controller) tick(now time.Time) {
+	schedules := &autoscalingv1alpha1.ScalingScheduleList{}
+	if err := c.client.List(context.Background(), schedules); err != nil {
+		klog.Errorf("failed to list scaling schedules: %v", err)
+		return
+	}
+
+	for i := range schedules.Items {
+		schedule := &schedules.Items[i]
+		location := time.UTC
+		if schedule.Spec.TimeZone != "" {
+			var err error
+			if location, err = time.LoadLocation(schedule.Spec.TimeZone); err != nil {
+				klog.Errorf("scaling schedule %s/%s has invalid time zone %q: %v",
+					schedule.Namespace, schedule.Name, schedule.Spec.TimeZone, err)
+				continue
+			}
+		}
+		local := now.In(location)
+
+		for _, rule := range schedule.Spec.Rules {
+			due, err := cronutil.Matches(rule.Schedule, local)
+			if err != nil {
+				klog.Errorf("scaling schedule %s/%s: %v", schedule.Namespace, schedule.Name, err)
+				continue
+			}
+			if !due {
+				continue
+			}
+			if alreadyFired(schedule, rule, now) {
+				continue
+			}
+			if err := c.apply(schedule, rule); err != nil {
+				klog.Errorf("scaling schedule %s/%s failed to scale %s %s to %d: %v",
+					schedule.Namespace, schedule.Name, schedule.Spec.TargetRef.Kind,
+					schedule.Spec.TargetRef.Name, rule.Replicas, err)
+				continue
+			}
+
+			schedule.Status.LastScaleTime = &metav1.Time{Time: now}
+			schedule.Status.LastSchedule = rule.Schedule
+			schedule.Status.LastReplicas = rule.Replicas
+			if err := c.client.Status().Update(context.Background(), schedule); err != nil {
+				klog.Errorf("failed to update status of scaling schedule %s/%s: %v",
+					schedule.Namespace, schedule.Name, err)
+			}
+		}
+	}
+}
+
+// alreadyFired guards against a rule firing twice within its minute when
+// ticks are not exactly aligned.
+func alreadyFired(schedule *autoscalingv1alpha1.ScalingSchedule, rule autoscalingv1alpha1.ScalingRule, now time.Time) bool {
+	last := schedule.Status.LastScaleTime
+	return last != nil && schedule.Status.LastSchedule == rule.Schedule && now.Sub(last.Time) < tickPeriod
+}
+
+func (c *Controller) apply(schedule *autoscalingv1alpha1.ScalingSchedule, rule autoscalingv1alpha1.ScalingRule) error {
+	namespace := schedule.Namespace
+	target := schedule.Spec.TargetRef
+
+	if rule.Replicas > 0 {
+		adjusted, err := c.adjustHPA(namespace, target, rule.Replicas)
+		if err != nil {
+			return err
+		}
+		if adjusted {
+			return nil
+		}
+	}
+	return c.scaleWorkload(namespace, target, rule.Replicas)
+}
+
+// adjustHPA moves the bounds of an HPA targeting the workload and reports
+// whether one was found.
+func (c *Controller) adjustHPA(namespace string, target autoscalingv1alpha1.ScalingTargetRef, replicas int32) (bool, error) {
+	hpas, err := c.k8s.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return false, err
+	}
+	for i := range hpas.Items {
+		hpa := &hpas.Items[i]
+		if hpa.Spec.ScaleTargetRef.Kind != target.Kind || hpa.Spec.ScaleTargetRef.Name != target.Name {
+			continue
+		}
+		hpa.Spec.MinReplicas = &replicas
+		if hpa.Spec.MaxReplicas < replicas {
+			hpa.Spec.MaxReplicas = replicas
+		}
+		_, err = c.k8s.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).Update(context.Background(), hpa, metav1.UpdateOptions{})
+		return true, err
+	}
+	return false, nil
+}
+
+func (c *Controller) scaleWorkload(namespace string, target autoscalingv1alpha1.ScalingTargetRef, replicas int32) error {
+	switch target.Kind {
+	case "Deployment":
+		deployment, err := c.k8s.AppsV1().Deployments(namespace).Get(context.Background(), target.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		deployment.Spec.Replicas = &replicas
+		_, err = c.k8s.AppsV1().Deployments(namespace).Update(context.Background(), deployment, metav1.UpdateOptions{})
+		return err
+	case "StatefulSet":
+		statefulSet, err := c.k8s.AppsV1().StatefulSets(namespace).Get(context.Background(), target.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		statefulSet.Spec.Replicas = &replicas
+		_, err = c.k8s.AppsV1().StatefulSets(namespace).Update(context.Background(), statefulSet, metav1.UpdateOptions{})
+		return err
+	default:
+		return fmt.Errorf("unsupported target kind %s", target.Kind)
+	}
+}
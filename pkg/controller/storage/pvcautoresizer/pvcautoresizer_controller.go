@@ -0,0 +1,222 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pvcautoresizer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	controllerName = "pvcautoresizer-controller"
+
+	// namespace annotations configuring the auto resize policy for all PVCs
+	// in the namespace
+	thresholdAnnotation = "storage.kubesphere.io/auto-resize-threshold"
+	maxSizeAnnotation   = "storage.kubesphere.io/auto-resize-max-size"
+	stepAnnotation      = "storage.kubesphere.io/auto-resize-step"
+
+	// usage metrics are scraped periodically, polling more often is pointless
+	resyncInterval = 5 * time.Minute
+)
+
+// Reconciler expands bound PVCs whose usage exceeds the threshold configured
+// on their namespace, when the storage class allows volume expansion.
+type Reconciler struct {
+	client.Client
+	MonitoringClient monitoring.Interface
+
+	logger   logr.Logger
+	recorder record.EventRecorder
+}
+
+type resizePolicy struct {
+	// expand when used bytes exceed this percentage of the capacity
+	threshold float64
+	// never expand the volume beyond this size
+	maxSize resource.Quantity
+	// size increment of a single expansion
+	step resource.Quantity
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.logger == nil {
+		r.logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.recorder == nil {
+		r.recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		For(&corev1.PersistentVolumeClaim{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	logger := r.logger.WithValues("persistentvolumeclaim", req.NamespacedName)
+	ctx := context.Background()
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, req.NamespacedName, pvc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !pvc.ObjectMeta.DeletionTimestamp.IsZero() || pvc.Status.Phase != corev1.ClaimBound {
+		return ctrl.Result{}, nil
+	}
+
+	policy, err := r.policyFor(ctx, pvc.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if policy == nil {
+		// namespace has no auto resize policy
+		return ctrl.Result{}, nil
+	}
+
+	expandable, err := r.classAllowsExpansion(ctx, pvc)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !expandable {
+		return ctrl.Result{}, nil
+	}
+
+	used, capacity := r.volumeStats(pvc)
+	if capacity <= 0 {
+		// no usage metrics for this volume yet
+		return ctrl.Result{RequeueAfter: resyncInterval}, nil
+	}
+
+	utilisation := used / capacity * 100
+	if utilisation < policy.threshold {
+		return ctrl.Result{RequeueAfter: resyncInterval}, nil
+	}
+
+	current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if current.Cmp(policy.maxSize) >= 0 {
+		r.recorder.Eventf(pvc, corev1.EventTypeWarning, "MaxSizeReached",
+			"Volume usage is %.0f%% but the maximum size %s of the namespace policy is reached", utilisation, policy.maxSize.String())
+		return ctrl.Result{RequeueAfter: resyncInterval}, nil
+	}
+
+	newSize := current.DeepCopy()
+	newSize.Add(policy.step)
+	if newSize.Cmp(policy.maxSize) > 0 {
+		newSize = policy.maxSize
+	}
+
+	patched := pvc.DeepCopy()
+	patched.Spec.Resources.Requests[corev1.ResourceStorage] = newSize
+	if err := r.Patch(ctx, patched, client.MergeFrom(pvc)); err != nil {
+		logger.Error(err, "expand volume failed")
+		r.recorder.Event(pvc, corev1.EventTypeWarning, "ResizeFailed", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	r.recorder.Eventf(pvc, corev1.EventTypeNormal, "Resized",
+		"Expanded volume from %s to %s, usage was %.0f%%", current.String(), newSize.String(), utilisation)
+	logger.V(4).Info("expanded volume", "from", current.String(), "to", newSize.String())
+
+	return ctrl.Result{RequeueAfter: resyncInterval}, nil
+}
+
+// policyFor reads the auto resize policy from the namespace annotations,
+// returning nil if the namespace does not define one.
+func (r *Reconciler) policyFor(ctx context.Context, namespace string) (*resizePolicy, error) {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return nil, err
+	}
+
+	thresholdValue, ok := ns.Annotations[thresholdAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	threshold, err := strconv.ParseFloat(thresholdValue, 64)
+	if err != nil || threshold <= 0 || threshold > 100 {
+		return nil, fmt.Errorf("invalid value %q of annotation %s on namespace %s", thresholdValue, thresholdAnnotation, namespace)
+	}
+
+	maxSize, err := resource.ParseQuantity(ns.Annotations[maxSizeAnnotation])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q of annotation %s on namespace %s", ns.Annotations[maxSizeAnnotation], maxSizeAnnotation, namespace)
+	}
+
+	step, err := resource.ParseQuantity(ns.Annotations[stepAnnotation])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q of annotation %s on namespace %s", ns.Annotations[stepAnnotation], stepAnnotation, namespace)
+	}
+
+	return &resizePolicy{threshold: threshold, maxSize: maxSize, step: step}, nil
+}
+
+func (r *Reconciler) classAllowsExpansion(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	className := ""
+	if pvc.Spec.StorageClassName != nil {
+		className = *pvc.Spec.StorageClassName
+	}
+	if className == "" {
+		return false, nil
+	}
+
+	class := &storagev1.StorageClass{}
+	if err := r.Get(ctx, types.NamespacedName{Name: className}, class); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return class.AllowVolumeExpansion != nil && *class.AllowVolumeExpansion, nil
+}
+
+// volumeStats returns the used and total bytes of the volume reported by the
+// monitoring system, zero when no metrics are available.
+func (r *Reconciler) volumeStats(pvc *corev1.PersistentVolumeClaim) (used, capacity float64) {
+	metrics := r.MonitoringClient.GetNamedMetrics([]string{"pvc_bytes_used", "pvc_bytes_total"}, time.Now(), monitoring.PVCOption{
+		NamespaceName:             pvc.Namespace,
+		PersistentVolumeClaimName: pvc.Name,
+	})
+
+	for _, metric := range metrics {
+		if len(metric.MetricValues) == 0 || metric.MetricValues[0].Sample == nil {
+			continue
+		}
+		value := metric.MetricValues[0].Sample[1]
+		switch metric.MetricName {
+		case "pvc_bytes_used":
+			used = value
+		case "pvc_bytes_total":
+			capacity = value
+		}
+	}
+	return
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshotpolicy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	snapshotv1beta1 "github.com/kubernetes-csi/external-snapshotter/client/v3/apis/volumesnapshot/v1beta1"
+	snapshotclient "github.com/kubernetes-csi/external-snapshotter/client/v3/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	storagev1alpha1 "kubesphere.io/kubesphere/pkg/apis/storage/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/utils/cronutil"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	controllerName = "snapshotpolicy-controller"
+
+	// snapshots are scheduled with minute granularity, check each policy
+	// once per interval
+	checkInterval = time.Minute
+)
+
+// Reconciler creates VolumeSnapshots from SnapshotPolicy objects on their
+// cron schedule and garbage collects snapshots beyond the retention count.
+type Reconciler struct {
+	client.Client
+	SnapshotClient snapshotclient.Interface
+
+	logger   logr.Logger
+	recorder record.EventRecorder
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.logger == nil {
+		r.logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.recorder == nil {
+		r.recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		For(&storagev1alpha1.SnapshotPolicy{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	logger := r.logger.WithValues("snapshotpolicy", req.NamespacedName)
+	ctx := context.Background()
+
+	policy := &storagev1alpha1.SnapshotPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, policy); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !policy.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	if policy.Spec.Paused {
+		return ctrl.Result{RequeueAfter: checkInterval}, nil
+	}
+
+	now := time.Now()
+	due, err := cronutil.Matches(policy.Spec.Schedule, now)
+	if err != nil {
+		logger.Error(err, "invalid schedule")
+		r.recorder.Event(policy, corev1.EventTypeWarning, "InvalidSchedule", err.Error())
+		// do not requeue until the spec is fixed
+		return ctrl.Result{}, nil
+	}
+
+	if due && !r.firedThisMinute(policy, now) {
+		if err := r.createSnapshot(ctx, policy, now); err != nil {
+			logger.Error(err, "create snapshot failed")
+			r.recorder.Event(policy, corev1.EventTypeWarning, "SnapshotFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.expireSnapshots(ctx, policy); err != nil {
+		logger.Error(err, "expire snapshots failed")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: checkInterval}, nil
+}
+
+// firedThisMinute reports whether a snapshot was already created in the
+// minute the schedule fired for, to keep the periodic checks idempotent.
+func (r *Reconciler) firedThisMinute(policy *storagev1alpha1.SnapshotPolicy, now time.Time) bool {
+	last := policy.Status.LastSnapshotTime
+	return last != nil && !last.Time.Before(now.Truncate(time.Minute))
+}
+
+func (r *Reconciler) createSnapshot(ctx context.Context, policy *storagev1alpha1.SnapshotPolicy, now time.Time) error {
+	pvcName := policy.Spec.PersistentVolumeClaimName
+	snapshot := &snapshotv1beta1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", policy.Name, now.UTC().Format("20060102-1504")),
+			Namespace: policy.Namespace,
+			Labels: map[string]string{
+				storagev1alpha1.SnapshotPolicyLabel: policy.Name,
+			},
+		},
+		Spec: snapshotv1beta1.VolumeSnapshotSpec{
+			Source: snapshotv1beta1.VolumeSnapshotSource{
+				PersistentVolumeClaimName: &pvcName,
+			},
+		},
+	}
+	if policy.Spec.SnapshotClassName != "" {
+		snapshotClassName := policy.Spec.SnapshotClassName
+		snapshot.Spec.VolumeSnapshotClassName = &snapshotClassName
+	}
+
+	_, err := r.SnapshotClient.SnapshotV1beta1().VolumeSnapshots(policy.Namespace).Create(ctx, snapshot, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+
+	r.recorder.Event(policy, corev1.EventTypeNormal, "SnapshotCreated",
+		fmt.Sprintf("Created snapshot %s of PVC %s", snapshot.Name, pvcName))
+
+	policy.Status.LastSnapshotTime = &metav1.Time{Time: now}
+	return r.Status().Update(ctx, policy)
+}
+
+// expireSnapshots deletes the oldest snapshots created from the policy when
+// there are more than the retention count.
+func (r *Reconciler) expireSnapshots(ctx context.Context, policy *storagev1alpha1.SnapshotPolicy) error {
+	list, err := r.SnapshotClient.SnapshotV1beta1().VolumeSnapshots(policy.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", storagev1alpha1.SnapshotPolicyLabel, policy.Name),
+	})
+	if err != nil {
+		return err
+	}
+
+	snapshots := list.Items
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreationTimestamp.Before(&snapshots[j].CreationTimestamp)
+	})
+
+	retention := policy.Spec.Retention
+	if retention > 0 {
+		for len(snapshots) > retention {
+			snapshot := snapshots[0]
+			err := r.SnapshotClient.SnapshotV1beta1().VolumeSnapshots(policy.Namespace).Delete(ctx, snapshot.Name, metav1.DeleteOptions{})
+			if err != nil {
+				return err
+			}
+			r.recorder.Event(policy, corev1.EventTypeNormal, "SnapshotExpired",
+				fmt.Sprintf("Deleted expired snapshot %s", snapshot.Name))
+			snapshots = snapshots[1:]
+		}
+	}
+
+	if policy.Status.SnapshotCount != len(snapshots) {
+		policy.Status.SnapshotCount = len(snapshots)
+		return r.Status().Update(ctx, policy)
+	}
+	return nil
+}
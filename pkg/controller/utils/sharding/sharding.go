@@ -0,0 +1,73 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sharding splits reconciliation work across several
+// ks-controller-manager deployments by hashing the namespace of the
+// reconciled object. Each deployment is configured with the total number of
+// shards and its own shard index, and only reconciles objects owned by its
+// shard. Sharding is optional, a nil *Sharding owns everything.
+package sharding
+
+import (
+	"hash/fnv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+type Sharding struct {
+	shards int
+	index  int
+}
+
+// New returns the sharding for this deployment, nil when fewer than two
+// shards are configured so callers can treat sharding as disabled.
+func New(shards, index int) *Sharding {
+	if shards < 2 {
+		return nil
+	}
+	return &Sharding{shards: shards, index: index}
+}
+
+// Enabled reports whether work is actually split across shards.
+func (s *Sharding) Enabled() bool {
+	return s != nil
+}
+
+// Owns reports whether the given key hashes onto this shard. Objects are
+// keyed by namespace so all objects of one namespace land on the same shard,
+// cluster-scoped objects are keyed by name.
+func (s *Sharding) Owns(key string) bool {
+	if s == nil {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32())%s.shards == s.index
+}
+
+// Predicate filters the events of a controller down to the objects owned by
+// this shard.
+func (s *Sharding) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(meta metav1.Object, object runtime.Object) bool {
+		key := meta.GetNamespace()
+		if len(key) == 0 {
+			key = meta.GetName()
+		}
+		return s.Owns(key)
+	})
+}
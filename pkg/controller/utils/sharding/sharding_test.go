@@ -0,0 +1,59 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewDisablesSingleShard(t *testing.T) {
+	if New(0, 0).Enabled() || New(1, 0).Enabled() {
+		t.Fatal("expected sharding to be disabled with fewer than two shards")
+	}
+	if !New(2, 0).Enabled() {
+		t.Fatal("expected sharding to be enabled with two shards")
+	}
+}
+
+func TestNilShardingOwnsEverything(t *testing.T) {
+	var s *Sharding
+	if !s.Owns("any-namespace") {
+		t.Fatal("expected nil sharding to own everything")
+	}
+}
+
+func TestShardsPartitionKeys(t *testing.T) {
+	const shards = 3
+	all := make([]*Sharding, shards)
+	for i := range all {
+		all[i] = New(shards, i)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("namespace-%d", i)
+		owners := 0
+		for _, s := range all {
+			if s.Owns(key) {
+				owners++
+			}
+		}
+		if owners != 1 {
+			t.Fatalf("expected exactly one owner for %s, got %d", key, owners)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	vaultv1alpha1 "kubesphere.io/kubesphere/pkg/apis/vault/v1alpha1"
+)
+
+const (
+	controllerName = "secretbinding-controller"
+
+	agentInjectAnnotation       = "vault.hashicorp.com/agent-inject"
+	agentRoleAnnotation         = "vault.hashicorp.com/role"
+	agentSecretAnnotationPrefix = "vault.hashicorp.com/agent-inject-secret-"
+)
+
+// secretProviderClassGVK identifies the secrets store CSI driver's
+// SecretProviderClass, managed as an unstructured object because its CRD is
+// installed together with the driver, not by KubeSphere
+var secretProviderClassGVK = schema.GroupVersionKind{
+	Group:   "secrets-store.csi.x-k8s.io",
+	Version: "v1alpha1",
+	Kind:    "SecretProviderClass",
+}
+
+// Reconciler materializes SecretBindings: in csi mode it maintains a vault
+// SecretProviderClass of the same name in the namespace, in agent mode it
+// stamps the vault agent-injector annotations onto workloads labeled as
+// consumers of the binding.
+type Reconciler struct {
+	client.Client
+	Logger                  logr.Logger
+	Recorder                record.EventRecorder
+	Scheme                  *runtime.Scheme
+	MaxConcurrentReconciles int
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	if r.Scheme == nil {
+		r.Scheme = mgr.GetScheme()
+	}
+	if r.MaxConcurrentReconciles <= 0 {
+		r.MaxConcurrentReconciles = 1
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+		}).
+		For(&vaultv1alpha1.SecretBinding{}).
+		Complete(r)
+}
+
+func (r *Reconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.WithValues("secretbinding", req.NamespacedName)
+	rootCtx := context.Background()
+	binding := &vaultv1alpha1.SecretBinding{}
+	if err := r.Get(rootCtx, req.NamespacedName, binding); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !binding.ObjectMeta.DeletionTimestamp.IsZero() {
+		// the SecretProviderClass is owned by the binding and garbage
+		// collected with it, agent annotations stay on the workloads until
+		// their next rollout
+		return ctrl.Result{}, nil
+	}
+
+	switch binding.Spec.Mode {
+	case vaultv1alpha1.InjectionModeAgent:
+		if err := r.syncAgentAnnotations(rootCtx, binding); err != nil {
+			return ctrl.Result{}, err
+		}
+	default:
+		if err := r.syncSecretProviderClass(rootCtx, binding); err != nil {
+			logger.Error(err, "sync SecretProviderClass failed")
+			r.Recorder.Event(binding, corev1.EventTypeWarning, "SyncFailed", err.Error())
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *Reconciler) syncSecretProviderClass(ctx context.Context, binding *vaultv1alpha1.SecretBinding) error {
+	providerClass := &unstructured.Unstructured{}
+	providerClass.SetGroupVersionKind(secretProviderClassGVK)
+	err := r.Get(ctx, client.ObjectKey{Namespace: binding.Namespace, Name: binding.Name}, providerClass)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	notFound := apierrors.IsNotFound(err)
+
+	spec := map[string]interface{}{
+		"provider": "vault",
+		"parameters": map[string]interface{}{
+			"vaultAddress": binding.Spec.Address,
+			"roleName":     binding.Spec.Role,
+			"objects":      renderObjects(binding.Spec.Secrets),
+		},
+	}
+
+	if notFound {
+		providerClass.SetNamespace(binding.Namespace)
+		providerClass.SetName(binding.Name)
+		if err := controllerutil.SetControllerReference(binding, providerClass, r.Scheme); err != nil {
+			return err
+		}
+		if err := unstructured.SetNestedMap(providerClass.Object, spec, "spec"); err != nil {
+			return err
+		}
+		return r.Create(ctx, providerClass)
+	}
+
+	if err := unstructured.SetNestedMap(providerClass.Object, spec, "spec"); err != nil {
+		return err
+	}
+	return r.Update(ctx, providerClass)
+}
+
+// syncAgentAnnotations stamps the agent-injector annotations onto the pod
+// templates of deployments and statefulsets labeled as consumers of the
+// binding, the injector sidecar picks them up on the next rollout
+func (r *Reconciler) syncAgentAnnotations(ctx context.Context, binding *vaultv1alpha1.SecretBinding) error {
+	consumerLabels := client.MatchingLabels{vaultv1alpha1.BindingLabel: binding.Name}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(binding.Namespace), consumerLabels); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		if applyAgentAnnotations(&deployment.Spec.Template, binding) {
+			if err := r.Update(ctx, deployment); err != nil {
+				return err
+			}
+		}
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, statefulSets, client.InNamespace(binding.Namespace), consumerLabels); err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		statefulSet := &statefulSets.Items[i]
+		if applyAgentAnnotations(&statefulSet.Spec.Template, binding) {
+			if err := r.Update(ctx, statefulSet); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func applyAgentAnnotations(podTemplate *corev1.PodTemplateSpec, binding *vaultv1alpha1.SecretBinding) bool {
+	annotations := map[string]string{
+		agentInjectAnnotation: "true",
+		agentRoleAnnotation:   binding.Spec.Role,
+	}
+	for _, secret := range binding.Spec.Secrets {
+		annotations[agentSecretAnnotationPrefix+objectName(secret)] = secret.Path
+	}
+
+	changed := false
+	for key, value := range annotations {
+		if podTemplate.Annotations[key] != value {
+			if podTemplate.Annotations == nil {
+				podTemplate.Annotations = map[string]string{}
+			}
+			podTemplate.Annotations[key] = value
+			changed = true
+		}
+	}
+	return changed
+}
+
+// renderObjects renders the objects parameter of a vault
+// SecretProviderClass, a yaml list of the secrets to mount
+func renderObjects(secrets []vaultv1alpha1.VaultSecret) string {
+	var builder strings.Builder
+	for _, secret := range secrets {
+		builder.WriteString(fmt.Sprintf("- objectName: %q\n  secretPath: %q\n", objectName(secret), secret.Path))
+		if secret.Key != "" {
+			builder.WriteString(fmt.Sprintf("  secretKey: %q\n", secret.Key))
+		}
+	}
+	return builder.String()
+}
+
+func objectName(secret vaultv1alpha1.VaultSecret) string {
+	if secret.ObjectName != "" {
+		return secret.ObjectName
+	}
+	return path.Base(secret.Path)
+}
@@ -19,27 +19,96 @@ package v2alpha1
 import (
 	"github.com/emicklei/go-restful"
 	promresourcesclient "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
 	ksapi "kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/api/alerting/v2alpha1"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
 	"kubesphere.io/kubesphere/pkg/informers"
 	alertingmodels "kubesphere.io/kubesphere/pkg/models/alerting"
 	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
 )
 
 type handler struct {
-	operator alertingmodels.Operator
+	operator          alertingmodels.Operator
+	ruleGroupOperator alertingmodels.RuleGroupOperator
+	policyOperator    alertingmodels.PolicyOperator
+	authorizer        authorizer.Authorizer
 }
 
-func newHandler(informers informers.InformerFactory,
+func newHandler(informers informers.InformerFactory, k8sClient kubernetes.Interface,
 	promResourceClient promresourcesclient.Interface, ruleClient alerting.RuleClient,
-	option *alerting.Options) *handler {
+	option *alerting.Options, authorizer authorizer.Authorizer) *handler {
 	return &handler{
 		operator: alertingmodels.NewOperator(
 			informers, promResourceClient, ruleClient, option),
+		ruleGroupOperator: alertingmodels.NewRuleGroupOperator(informers, promResourceClient),
+		policyOperator:    alertingmodels.NewPolicyOperator(k8sClient),
+		authorizer:        authorizer,
 	}
 }
 
+// filterAlerts drops the alerts of namespaces the requesting user may not
+// see. Users allowed to list alerts at cluster scope keep everything,
+// everyone else keeps only the alerts whose namespace label points into a
+// namespace they can list alerts in; alerts without a namespace label stay
+// visible at cluster scope only.
+func (h *handler) filterAlerts(req *restful.Request, list *v2alpha1.AlertList) *v2alpha1.AlertList {
+	if list == nil {
+		return nil
+	}
+	requestUser, ok := request.UserFrom(req.Request.Context())
+	if !ok {
+		return &v2alpha1.AlertList{Items: []*v2alpha1.Alert{}}
+	}
+	if h.allowedToListAlerts(requestUser, "") {
+		return list
+	}
+
+	decisions := make(map[string]bool)
+	items := make([]*v2alpha1.Alert, 0, len(list.Items))
+	for _, alert := range list.Items {
+		namespace := alert.Labels["namespace"]
+		if namespace == "" {
+			continue
+		}
+		decision, ok := decisions[namespace]
+		if !ok {
+			decision = h.allowedToListAlerts(requestUser, namespace)
+			decisions[namespace] = decision
+		}
+		if decision {
+			items = append(items, alert)
+		}
+	}
+	// the page was filtered after the fact, shrink the total by what was
+	// dropped from it
+	return &v2alpha1.AlertList{Items: items, Total: list.Total - (len(list.Items) - len(items))}
+}
+
+func (h *handler) allowedToListAlerts(requestUser user.Info, namespace string) bool {
+	attributes := authorizer.AttributesRecord{
+		User:            requestUser,
+		Verb:            "list",
+		APIGroup:        groupName,
+		Resource:        "alerts",
+		Namespace:       namespace,
+		ResourceRequest: true,
+		ResourceScope:   request.NamespaceScope,
+	}
+	if namespace == "" {
+		attributes.ResourceScope = request.ClusterScope
+	}
+	decision, _, err := h.authorizer.Authorize(attributes)
+	if err != nil {
+		klog.Errorf("authorize alert visibility for %s failed: %v", requestUser.GetName(), err)
+		return false
+	}
+	return decision == authorizer.DecisionAllow
+}
+
 func (h *handler) handleListCustomAlertingRules(req *restful.Request, resp *restful.Response) {
 	namespace := req.PathParameter("namespace")
 	query, err := v2alpha1.ParseAlertingRuleQueryParams(req)
@@ -83,6 +152,11 @@ func (h *handler) handleListCustomRulesAlerts(req *restful.Request, resp *restfu
 		}
 		return
 	}
+	// the cluster-level path serves alerts of every namespace, scope them
+	// to what the user may see; the namespaced path is already scoped
+	if namespace == "" {
+		alerts = h.filterAlerts(req, alerts)
+	}
 	resp.WriteEntity(alerts)
 }
 
@@ -127,6 +201,9 @@ func (h *handler) handleListCustomRuleAlerts(req *restful.Request, resp *restful
 		}
 		return
 	}
+	if namespace == "" {
+		alerts = h.filterAlerts(req, alerts)
+	}
 	resp.WriteEntity(alerts)
 }
 
@@ -241,7 +318,7 @@ func (h *handler) handleListBuiltinRulesAlerts(req *restful.Request, resp *restf
 		ksapi.HandleInternalError(resp, nil, err)
 		return
 	}
-	resp.WriteEntity(alerts)
+	resp.WriteEntity(h.filterAlerts(req, alerts))
 }
 
 func (h *handler) handleGetBuiltinAlertingRule(req *restful.Request, resp *restful.Response) {
@@ -281,7 +358,7 @@ func (h *handler) handleListBuiltinRuleAlerts(req *restful.Request, resp *restfu
 		return
 	}
 
-	resp.WriteEntity(alerts)
+	resp.WriteEntity(h.filterAlerts(req, alerts))
 }
 
 func (h *handler) handleCreateOrUpdateCustomAlertingRules(req *restful.Request, resp *restful.Response) {
@@ -308,6 +385,136 @@ func (h *handler) handleCreateOrUpdateCustomAlertingRules(req *restful.Request,
 	resp.WriteEntity(bulkResp)
 }
 
+func (h *handler) handleListRuleGroups(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	groups, err := h.ruleGroupOperator.ListRuleGroups(req.Request.Context(), namespace)
+	if err != nil {
+		klog.Error(err)
+		ksapi.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteEntity(groups)
+}
+
+func (h *handler) handleGetRuleGroup(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	resource := req.PathParameter("resource")
+	groupName := req.PathParameter("group")
+
+	group, err := h.ruleGroupOperator.GetRuleGroup(req.Request.Context(), namespace, resource, groupName)
+	if err != nil {
+		klog.Error(err)
+		switch {
+		case err == v2alpha1.ErrRuleResourceNotFound || err == v2alpha1.ErrRuleGroupNotFound:
+			ksapi.HandleNotFound(resp, nil, err)
+		default:
+			ksapi.HandleInternalError(resp, nil, err)
+		}
+		return
+	}
+	resp.WriteEntity(group)
+}
+
+func (h *handler) handleCreateOrUpdateRuleGroup(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	resource := req.PathParameter("resource")
+
+	var group v2alpha1.PostableRuleGroup
+	if err := req.ReadEntity(&group); err != nil {
+		klog.Error(err)
+		ksapi.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if err := group.Validate(); err != nil {
+		klog.Error(err)
+		ksapi.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	err := h.ruleGroupOperator.CreateOrUpdateRuleGroup(req.Request.Context(), namespace, resource, &group)
+	if err != nil {
+		klog.Error(err)
+		ksapi.HandleInternalError(resp, nil, err)
+		return
+	}
+}
+
+func (h *handler) handleDeleteRuleGroup(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	resource := req.PathParameter("resource")
+	groupName := req.PathParameter("group")
+
+	err := h.ruleGroupOperator.DeleteRuleGroup(req.Request.Context(), namespace, resource, groupName)
+	if err != nil {
+		klog.Error(err)
+		switch {
+		case err == v2alpha1.ErrRuleResourceNotFound || err == v2alpha1.ErrRuleGroupNotFound:
+			ksapi.HandleNotFound(resp, nil, err)
+		default:
+			ksapi.HandleInternalError(resp, nil, err)
+		}
+		return
+	}
+}
+
+func (h *handler) handleGetAlertingPolicy(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	policy, err := h.policyOperator.GetPolicy(req.Request.Context(), namespace)
+	if err != nil {
+		klog.Error(err)
+		ksapi.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteEntity(policy)
+}
+
+func (h *handler) handleSaveAlertingPolicy(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	var policy v2alpha1.AlertingPolicy
+	if err := req.ReadEntity(&policy); err != nil {
+		klog.Error(err)
+		ksapi.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		klog.Error(err)
+		ksapi.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	if err := h.policyOperator.SavePolicy(req.Request.Context(), namespace, &policy); err != nil {
+		klog.Error(err)
+		ksapi.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteEntity(policy)
+}
+
+func (h *handler) handleTestRuleGroup(req *restful.Request, resp *restful.Response) {
+	var test v2alpha1.RuleGroupTest
+	if err := req.ReadEntity(&test); err != nil {
+		klog.Error(err)
+		ksapi.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if err := test.Validate(); err != nil {
+		klog.Error(err)
+		ksapi.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	result, err := h.ruleGroupOperator.TestRuleGroup(&test)
+	if err != nil {
+		klog.Error(err)
+		ksapi.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
 func (h *handler) handleDeleteCustomAlertingRules(req *restful.Request, resp *restful.Response) {
 	namespace := req.PathParameter("namespace")
 	names := req.QueryParameters("name")
@@ -23,8 +23,10 @@ import (
 	restfulspec "github.com/emicklei/go-restful-openapi"
 	promresourcesclient "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
 	ksapi "kubesphere.io/kubesphere/pkg/api"
 	alertingv2alpha1 "kubesphere.io/kubesphere/pkg/api/alerting/v2alpha1"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/informers"
@@ -38,12 +40,13 @@ const (
 var GroupVersion = schema.GroupVersion{Group: groupName, Version: "v2alpha1"}
 
 func AddToContainer(container *restful.Container, informers informers.InformerFactory,
-	promResourceClient promresourcesclient.Interface, ruleClient alerting.RuleClient,
-	option *alerting.Options) error {
+	k8sClient kubernetes.Interface, promResourceClient promresourcesclient.Interface,
+	ruleClient alerting.RuleClient, option *alerting.Options,
+	authorizer authorizer.Authorizer) error {
 
 	ws := runtime.NewWebService(GroupVersion)
 
-	if informers == nil || promResourceClient == nil || ruleClient == nil || option == nil {
+	if informers == nil || k8sClient == nil || promResourceClient == nil || ruleClient == nil || option == nil {
 		h := func(req *restful.Request, resp *restful.Response) {
 			ksapi.HandleBadRequest(resp, nil, alertingv2alpha1.ErrAlertingAPIV2NotEnabled)
 			return
@@ -57,7 +60,7 @@ func AddToContainer(container *restful.Container, informers informers.InformerFa
 		return nil
 	}
 
-	handler := newHandler(informers, promResourceClient, ruleClient, option)
+	handler := newHandler(informers, k8sClient, promResourceClient, ruleClient, option, authorizer)
 
 	ws.Route(ws.GET("/rules").
 		To(handler.handleListCustomAlertingRules).
@@ -199,6 +202,64 @@ func AddToContainer(container *restful.Container, informers informers.InformerFa
 		Returns(http.StatusOK, ksapi.StatusOK, nil).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
 
+	ws.Route(ws.GET("/namespaces/{namespace}/rulegroups").
+		To(handler.handleListRuleGroups).
+		Doc("list the rule groups of all the prometheusrule resources in the specified namespace").
+		Returns(http.StatusOK, ksapi.StatusOK, alertingv2alpha1.GettableRuleGroupList{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/rulegroups/{resource}/{group}").
+		To(handler.handleGetRuleGroup).
+		Doc("get the rule group with the specified name within the specified prometheusrule resource").
+		Returns(http.StatusOK, ksapi.StatusOK, alertingv2alpha1.GettableRuleGroup{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/rulegroups/{resource}").
+		To(handler.handleCreateOrUpdateRuleGroup).
+		Doc("create or update a rule group within the specified prometheusrule resource, creating the resource if it does not exist").
+		Reads(alertingv2alpha1.PostableRuleGroup{}).
+		Returns(http.StatusOK, ksapi.StatusOK, nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.DELETE("/namespaces/{namespace}/rulegroups/{resource}/{group}").
+		To(handler.handleDeleteRuleGroup).
+		Doc("delete the rule group with the specified name from the specified prometheusrule resource").
+		Returns(http.StatusOK, ksapi.StatusOK, nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.GET("/policy").
+		To(handler.handleGetAlertingPolicy).
+		Doc("get the cluster-level alert grouping and inhibition policy").
+		Returns(http.StatusOK, ksapi.StatusOK, alertingv2alpha1.AlertingPolicy{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.PUT("/policy").
+		To(handler.handleSaveAlertingPolicy).
+		Doc("replace the cluster-level alert grouping and inhibition policy").
+		Reads(alertingv2alpha1.AlertingPolicy{}).
+		Returns(http.StatusOK, ksapi.StatusOK, alertingv2alpha1.AlertingPolicy{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/policy").
+		To(handler.handleGetAlertingPolicy).
+		Doc("get the alert grouping and inhibition policy of the specified namespace").
+		Returns(http.StatusOK, ksapi.StatusOK, alertingv2alpha1.AlertingPolicy{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.PUT("/namespaces/{namespace}/policy").
+		To(handler.handleSaveAlertingPolicy).
+		Doc("replace the alert grouping and inhibition policy of the specified namespace").
+		Reads(alertingv2alpha1.AlertingPolicy{}).
+		Returns(http.StatusOK, ksapi.StatusOK, alertingv2alpha1.AlertingPolicy{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/ruletests").
+		To(handler.handleTestRuleGroup).
+		Doc("test a rule group against supplied series with the semantics of promtool test rules, without persisting anything").
+		Reads(alertingv2alpha1.RuleGroupTest{}).
+		Returns(http.StatusOK, ksapi.StatusOK, alertingv2alpha1.RuleGroupTestResult{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AlertingTag}))
+
 	ws.Route(ws.GET("/builtin/rules").
 		To(handler.handleListBuiltinAlertingRules).
 		Doc("list the builtin(non-custom) alerting rules").
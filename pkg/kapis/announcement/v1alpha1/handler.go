@@ -0,0 +1,92 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	announcementv1alpha1 "kubesphere.io/kubesphere/pkg/apis/announcement/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/models/announcement"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+type handler struct {
+	operator announcement.Operator
+}
+
+func newHandler(client kubernetes.Interface, dynamicClient dynamic.Interface) *handler {
+	return &handler{operator: announcement.NewOperator(client, dynamicClient)}
+}
+
+func username(req *restful.Request) string {
+	if user, ok := request.UserFrom(req.Request.Context()); ok {
+		return user.GetName()
+	}
+	return ""
+}
+
+func (h *handler) handleListAnnouncements(req *restful.Request, response *restful.Response) {
+	activeOnly := req.QueryParameter("active") == "true"
+
+	views, err := h.operator.List(username(req), activeOnly)
+	if err != nil {
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(views)
+}
+
+func (h *handler) handlePublishAnnouncement(req *restful.Request, response *restful.Response) {
+	var spec announcementv1alpha1.AnnouncementSpec
+	if err := req.ReadEntity(&spec); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	published, err := h.operator.Publish(&spec)
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(published)
+}
+
+func (h *handler) handleDeleteAnnouncement(req *restful.Request, response *restful.Response) {
+	if err := h.operator.Delete(req.PathParameter("announcement")); err != nil {
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleAcknowledgeAnnouncement(req *restful.Request, response *restful.Response) {
+	name := req.PathParameter("announcement")
+
+	if err := h.operator.Acknowledge(name, username(req)); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(errors.None)
+}
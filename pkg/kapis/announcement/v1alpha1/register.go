@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	announcementv1alpha1 "kubesphere.io/kubesphere/pkg/apis/announcement/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/announcement"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+const (
+	GroupName = "announcement.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client kubernetes.Interface, dynamicClient dynamic.Interface) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(client, dynamicClient)
+
+	webservice.Route(webservice.GET("/announcements").
+		To(handler.handleListAnnouncements).
+		Param(webservice.QueryParameter("active", "when true only announcements currently within their active window are returned")).
+		Doc("list announcements newest first, each with the acknowledgement state of the requesting user").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AnnouncementTag}).
+		Returns(http.StatusOK, api.StatusOK, []announcement.View{}))
+
+	webservice.Route(webservice.POST("/announcements").
+		To(handler.handlePublishAnnouncement).
+		Reads(announcementv1alpha1.AnnouncementSpec{}).
+		Doc("publish a maintenance notice, warning and critical notices also reach the notification channels").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AnnouncementTag}).
+		Returns(http.StatusOK, api.StatusOK, announcement.View{}))
+
+	webservice.Route(webservice.DELETE("/announcements/{announcement}").
+		To(handler.handleDeleteAnnouncement).
+		Param(webservice.PathParameter("announcement", "name of the announcement")).
+		Doc("retract an announcement").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AnnouncementTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.POST("/announcements/{announcement}/acknowledgement").
+		To(handler.handleAcknowledgeAnnouncement).
+		Param(webservice.PathParameter("announcement", "name of the announcement")).
+		Doc("acknowledge an announcement, the console stops showing it to the user").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AnnouncementTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	c.Add(webservice)
+
+	return nil
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/emicklei/go-restful"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/models/autoscaling"
+	"kubesphere.io/kubesphere/pkg/models/keda"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+type handler struct {
+	operator     autoscaling.Operator
+	kedaOperator keda.Operator
+}
+
+func newHandler(client kubernetes.Interface, config *rest.Config) (*handler, error) {
+	kedaOperator, err := keda.NewOperator(config)
+	if err != nil {
+		return nil, err
+	}
+	return &handler{
+		operator:     autoscaling.NewOperator(client),
+		kedaOperator: kedaOperator,
+	}, nil
+}
+
+func (h *handler) handleListHPAs(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	hpas, err := h.operator.List(namespace)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(hpas)
+}
+
+func (h *handler) handleGetHPA(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	name := req.PathParameter("horizontalpodautoscaler")
+
+	hpa, err := h.operator.Get(namespace, name)
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(hpa)
+}
+
+func (h *handler) handleCreateHPA(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	var hpa autoscalingv2beta2.HorizontalPodAutoscaler
+	if err := req.ReadEntity(&hpa); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	created, err := h.operator.Create(namespace, &hpa)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(created)
+}
+
+func (h *handler) handleUpdateHPA(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	name := req.PathParameter("horizontalpodautoscaler")
+
+	var hpa autoscalingv2beta2.HorizontalPodAutoscaler
+	if err := req.ReadEntity(&hpa); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if hpa.Name != name {
+		api.HandleBadRequest(resp, nil, fmt.Errorf("the name of the object (%s) does not match the name on the URL (%s)", hpa.Name, name))
+		return
+	}
+
+	updated, err := h.operator.Update(namespace, &hpa)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(updated)
+}
+
+func (h *handler) handleDeleteHPA(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	name := req.PathParameter("horizontalpodautoscaler")
+
+	if err := h.operator.Delete(namespace, name); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleListCustomMetrics(req *restful.Request, resp *restful.Response) {
+	metrics, err := h.operator.ListCustomMetrics()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(metrics)
+}
+
+func (h *handler) handleListExternalMetrics(req *restful.Request, resp *restful.Response) {
+	metrics, err := h.operator.ListExternalMetrics()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(metrics)
+}
+
+func (h *handler) handleListScaledObjects(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	scaledObjects, err := h.kedaOperator.List(namespace)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(scaledObjects)
+}
+
+func (h *handler) handleGetScaledObject(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	name := req.PathParameter("scaledobject")
+
+	scaledObject, err := h.kedaOperator.Get(namespace, name)
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(scaledObject)
+}
+
+func (h *handler) handleCreateScaledObject(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	var scaledObject keda.ScaledObject
+	if err := req.ReadEntity(&scaledObject); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	created, err := h.kedaOperator.Create(namespace, &scaledObject)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(created)
+}
+
+func (h *handler) handleUpdateScaledObject(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	name := req.PathParameter("scaledobject")
+
+	var scaledObject keda.ScaledObject
+	if err := req.ReadEntity(&scaledObject); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if scaledObject.Name != name {
+		api.HandleBadRequest(resp, nil, fmt.Errorf("the name of the object (%s) does not match the name on the URL (%s)", scaledObject.Name, name))
+		return
+	}
+
+	updated, err := h.kedaOperator.Update(namespace, &scaledObject)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(updated)
+}
+
+func (h *handler) handleDeleteScaledObject(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	name := req.PathParameter("scaledobject")
+
+	if err := h.kedaOperator.Delete(namespace, name); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleListTriggerTypes(req *restful.Request, resp *restful.Response) {
+	resp.WriteAsJson(h.kedaOperator.ListTriggerTypes())
+}
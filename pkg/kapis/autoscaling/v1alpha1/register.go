@@ -0,0 +1,152 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/autoscaling"
+	"kubesphere.io/kubesphere/pkg/models/keda"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+const (
+	GroupName = "autoscaling.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client kubernetes.Interface, config *rest.Config) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler, err := newHandler(client, config)
+	if err != nil {
+		return err
+	}
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/horizontalpodautoscalers").
+		To(handler.handleListHPAs).
+		Doc("list the namespace's horizontal pod autoscalers with their current scaling decisions").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, []autoscaling.Detail{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/horizontalpodautoscalers/{horizontalpodautoscaler}").
+		To(handler.handleGetHPA).
+		Doc("retrieve the horizontal pod autoscaler with its current metric values").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("horizontalpodautoscaler", "name of the horizontal pod autoscaler")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, autoscaling.Detail{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/horizontalpodautoscalers").
+		To(handler.handleCreateHPA).
+		Doc("create a horizontal pod autoscaler, referenced custom and external metrics are validated against the metrics adapters").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Reads(autoscalingv2beta2.HorizontalPodAutoscaler{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, autoscalingv2beta2.HorizontalPodAutoscaler{}))
+
+	webservice.Route(webservice.PUT("/namespaces/{namespace}/horizontalpodautoscalers/{horizontalpodautoscaler}").
+		To(handler.handleUpdateHPA).
+		Doc("update the horizontal pod autoscaler").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("horizontalpodautoscaler", "name of the horizontal pod autoscaler")).
+		Reads(autoscalingv2beta2.HorizontalPodAutoscaler{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, autoscalingv2beta2.HorizontalPodAutoscaler{}))
+
+	webservice.Route(webservice.DELETE("/namespaces/{namespace}/horizontalpodautoscalers/{horizontalpodautoscaler}").
+		To(handler.handleDeleteHPA).
+		Doc("delete the horizontal pod autoscaler").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("horizontalpodautoscaler", "name of the horizontal pod autoscaler")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/custommetrics").
+		To(handler.handleListCustomMetrics).
+		Doc("list the metric names served by the custom metrics adapter").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, []string{}))
+
+	webservice.Route(webservice.GET("/externalmetrics").
+		To(handler.handleListExternalMetrics).
+		Doc("list the metric names served by the external metrics adapter").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, []string{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/scaledobjects").
+		To(handler.handleListScaledObjects).
+		Doc("list the namespace's KEDA scaled objects with scaler health").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, []keda.ScaledObject{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/scaledobjects/{scaledobject}").
+		To(handler.handleGetScaledObject).
+		Doc("retrieve the KEDA scaled object").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("scaledobject", "name of the scaled object")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, keda.ScaledObject{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/scaledobjects").
+		To(handler.handleCreateScaledObject).
+		Doc("create a KEDA scaled object, trigger metadata is validated against the trigger catalog").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Reads(keda.ScaledObject{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, keda.ScaledObject{}))
+
+	webservice.Route(webservice.PUT("/namespaces/{namespace}/scaledobjects/{scaledobject}").
+		To(handler.handleUpdateScaledObject).
+		Doc("update the KEDA scaled object").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("scaledobject", "name of the scaled object")).
+		Reads(keda.ScaledObject{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, keda.ScaledObject{}))
+
+	webservice.Route(webservice.DELETE("/namespaces/{namespace}/scaledobjects/{scaledobject}").
+		To(handler.handleDeleteScaledObject).
+		Doc("delete the KEDA scaled object").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("scaledobject", "name of the scaled object")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/triggertypes").
+		To(handler.handleListTriggerTypes).
+		Doc("list the supported KEDA trigger types with their required metadata").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AutoscalingTag}).
+		Returns(http.StatusOK, api.StatusOK, []keda.TriggerType{}))
+
+	c.Add(webservice)
+
+	return nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+	"kubesphere.io/kubesphere/pkg/models/backup"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+type handler struct {
+	operator   backup.Interface
+	authorizer authorizer.Authorizer
+}
+
+func newHandler(client dynamic.Interface, authorizer authorizer.Authorizer) *handler {
+	return &handler{operator: backup.NewBackupOperator(client), authorizer: authorizer}
+}
+
+// authorizeRestoreTarget requires the requesting user to be allowed to
+// create restores in the target namespace; the RBAC filter on the route
+// only covered the source namespace carried in the URL
+func (h *handler) authorizeRestoreTarget(req *restful.Request, targetNamespace string) error {
+	requestUser, ok := request.UserFrom(req.Request.Context())
+	if h.authorizer == nil || !ok {
+		return fmt.Errorf("unable to identify the requesting user")
+	}
+	attributes := authorizer.AttributesRecord{
+		User:            requestUser,
+		Verb:            "create",
+		Namespace:       targetNamespace,
+		APIGroup:        groupName,
+		Resource:        "restores",
+		ResourceRequest: true,
+		ResourceScope:   request.NamespaceScope,
+	}
+	decision, reason, err := h.authorizer.Authorize(attributes)
+	if err != nil {
+		return err
+	}
+	if decision != authorizer.DecisionAllow {
+		return fmt.Errorf("not allowed to restore into namespace %s: %s", targetNamespace, reason)
+	}
+	return nil
+}
+
+func (h *handler) CreateBackupPlan(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	var plan backup.BackupPlan
+	err := req.ReadEntity(&plan)
+	if err != nil {
+		klog.V(4).Infoln(err)
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	created, err := h.operator.CreateBackupPlan(namespace, &plan)
+	if err != nil {
+		klog.Errorln(err)
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteEntity(created)
+}
+
+func (h *handler) ListBackupPlans(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	plans, err := h.operator.ListBackupPlans(namespace)
+	if err != nil {
+		klog.Errorln(err)
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteEntity(plans)
+}
+
+func (h *handler) DeleteBackupPlan(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	plan := req.PathParameter("backupplan")
+
+	err := h.operator.DeleteBackupPlan(namespace, plan)
+	if err != nil {
+		klog.Errorln(err)
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteEntity(errors.None)
+}
+
+func (h *handler) ListBackups(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	backups, err := h.operator.ListBackups(namespace)
+	if err != nil {
+		klog.Errorln(err)
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteEntity(backups)
+}
+
+func (h *handler) CreateRestore(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	var restoreRequest backup.RestoreRequest
+	err := req.ReadEntity(&restoreRequest)
+	if err != nil {
+		klog.V(4).Infoln(err)
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	if restoreRequest.TargetNamespace != "" && restoreRequest.TargetNamespace != namespace {
+		if err := h.authorizeRestoreTarget(req, restoreRequest.TargetNamespace); err != nil {
+			api.HandleForbidden(resp, nil, err)
+			return
+		}
+	}
+
+	restore, err := h.operator.CreateRestore(namespace, &restoreRequest)
+	if err != nil {
+		klog.Errorln(err)
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteEntity(restore)
+}
+
+func (h *handler) ListRestores(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	restores, err := h.operator.ListRestores(namespace)
+	if err != nil {
+		klog.Errorln(err)
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteEntity(restores)
+}
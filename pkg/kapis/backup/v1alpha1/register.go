@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/backup"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+const groupName = "backup.kubesphere.io"
+
+var GroupVersion = schema.GroupVersion{Group: groupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client dynamic.Interface, authorizer authorizer.Authorizer) error {
+	ws := runtime.NewWebService(GroupVersion)
+	h := newHandler(client, authorizer)
+
+	ws.Route(ws.POST("/namespaces/{namespace}/backupplans").
+		To(h.CreateBackupPlan).
+		Doc("Create a scheduled backup plan for the namespace").
+		Param(ws.PathParameter("namespace", "the name of the namespace")).
+		Reads(backup.BackupPlan{}).
+		Returns(http.StatusOK, api.StatusOK, backup.BackupPlan{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.BackupTag}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/backupplans").
+		To(h.ListBackupPlans).
+		Doc("List backup plans of the namespace").
+		Param(ws.PathParameter("namespace", "the name of the namespace")).
+		Returns(http.StatusOK, api.StatusOK, []backup.BackupPlan{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.BackupTag}))
+
+	ws.Route(ws.DELETE("/namespaces/{namespace}/backupplans/{backupplan}").
+		To(h.DeleteBackupPlan).
+		Doc("Delete the backup plan, backups already taken are kept until they expire").
+		Param(ws.PathParameter("namespace", "the name of the namespace")).
+		Param(ws.PathParameter("backupplan", "the name of the backup plan")).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.BackupTag}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/backups").
+		To(h.ListBackups).
+		Doc("List backups of the namespace").
+		Param(ws.PathParameter("namespace", "the name of the namespace")).
+		Returns(http.StatusOK, api.StatusOK, []backup.Backup{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.BackupTag}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/restores").
+		To(h.CreateRestore).
+		Doc("Restore a backup of the namespace, optionally into a different namespace").
+		Param(ws.PathParameter("namespace", "the name of the namespace")).
+		Reads(backup.RestoreRequest{}).
+		Returns(http.StatusOK, api.StatusOK, backup.Restore{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.BackupTag}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/restores").
+		To(h.ListRestores).
+		Doc("List restores of the namespace").
+		Param(ws.PathParameter("namespace", "the name of the namespace")).
+		Returns(http.StatusOK, api.StatusOK, []backup.Restore{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.BackupTag}))
+
+	c.Add(ws)
+	return nil
+}
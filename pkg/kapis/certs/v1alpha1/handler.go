@@ -0,0 +1,67 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	kubesphereclient "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+	"kubesphere.io/kubesphere/pkg/models/certs"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+type handler struct {
+	operator certs.Operator
+}
+
+func newHandler(client kubernetes.Interface, config *rest.Config, ksClient kubesphereclient.Interface) *handler {
+	return &handler{operator: certs.NewOperator(client, config, ksClient)}
+}
+
+func (h *handler) handleListCertificates(req *restful.Request, resp *restful.Response) {
+	certificates, err := h.operator.ListCertificates()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(certificates)
+}
+
+func (h *handler) handleListClusterCertificates(req *restful.Request, resp *restful.Response) {
+	cluster := req.PathParameter("cluster")
+
+	certificates, err := h.operator.ListClusterCertificates(cluster)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(certificates)
+}
+
+func (h *handler) handleRotateKubeletCertificate(req *restful.Request, resp *restful.Response) {
+	cluster := req.PathParameter("cluster")
+	node := req.PathParameter("node")
+
+	if err := h.operator.RotateKubeletCertificate(cluster, node); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	kubesphereclient "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/certs"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+const (
+	GroupName = "certs.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client kubernetes.Interface, config *rest.Config, ksClient kubesphereclient.Interface) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(client, config, ksClient)
+
+	webservice.Route(webservice.GET("/certificates").
+		To(handler.handleListCertificates).
+		Doc("inventory the control-plane and kubelet certificates of every cluster, sorted by expiry").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.CertificateTag}).
+		Returns(http.StatusOK, api.StatusOK, []certs.Certificate{}))
+
+	webservice.Route(webservice.GET("/clusters/{cluster}/certificates").
+		To(handler.handleListClusterCertificates).
+		Doc("inventory the control-plane and kubelet certificates of the cluster").
+		Param(webservice.PathParameter("cluster", "name of the cluster")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.CertificateTag}).
+		Returns(http.StatusOK, api.StatusOK, []certs.Certificate{}))
+
+	webservice.Route(webservice.POST("/clusters/{cluster}/nodes/{node}/certificates/rotation").
+		To(handler.handleRotateKubeletCertificate).
+		Doc("approve the node's pending serving certificate requests to unblock kubelet certificate rotation").
+		Param(webservice.PathParameter("cluster", "name of the cluster")).
+		Param(webservice.PathParameter("node", "name of the node")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.CertificateTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	c.Add(webservice)
+
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/dynamic"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	consolev1alpha1 "kubesphere.io/kubesphere/pkg/apis/console/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/models/preferences"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+type handler struct {
+	operator preferences.Operator
+}
+
+func newHandler(client dynamic.Interface) *handler {
+	return &handler{operator: preferences.NewOperator(client)}
+}
+
+func username(req *restful.Request) string {
+	if user, ok := request.UserFrom(req.Request.Context()); ok {
+		return user.GetName()
+	}
+	return ""
+}
+
+func (h *handler) handleGetPreferences(req *restful.Request, response *restful.Response) {
+	spec, err := h.operator.Get(username(req))
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(spec)
+}
+
+func (h *handler) handleUpdatePreferences(req *restful.Request, response *restful.Response) {
+	var spec consolev1alpha1.UserPreferenceSpec
+	if err := req.ReadEntity(&spec); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	if err := h.operator.Update(username(req), &spec); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(spec)
+}
+
+func (h *handler) handleDeletePreferences(req *restful.Request, response *restful.Response) {
+	if err := h.operator.Delete(username(req)); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(errors.None)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	consolev1alpha1 "kubesphere.io/kubesphere/pkg/apis/console/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+const (
+	GroupName = "console.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client dynamic.Interface) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(client)
+
+	webservice.Route(webservice.GET("/preferences").
+		To(handler.handleGetPreferences).
+		Doc("get the console preferences of the authenticated user, empty defaults when none are saved").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ConsoleTag}).
+		Returns(http.StatusOK, api.StatusOK, consolev1alpha1.UserPreferenceSpec{}))
+
+	webservice.Route(webservice.PUT("/preferences").
+		To(handler.handleUpdatePreferences).
+		Reads(consolev1alpha1.UserPreferenceSpec{}).
+		Doc("replace the console preferences of the authenticated user").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ConsoleTag}).
+		Returns(http.StatusOK, api.StatusOK, consolev1alpha1.UserPreferenceSpec{}))
+
+	webservice.Route(webservice.DELETE("/preferences").
+		To(handler.handleDeletePreferences).
+		Doc("reset the console preferences of the authenticated user to the defaults").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ConsoleTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	c.Add(webservice)
+
+	return nil
+}
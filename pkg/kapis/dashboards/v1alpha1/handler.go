@@ -0,0 +1,445 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/models/dashboards"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+	monitoringclient "kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+type handler struct {
+	operator dashboards.Operator
+}
+
+func newHandler(cache runtimecache.Cache, client runtimeclient.Client, monitoring monitoringclient.Interface) *handler {
+	return &handler{
+		operator: dashboards.NewOperator(cache, client, monitoring),
+	}
+}
+
+func (h *handler) handleSearchDashboards(req *restful.Request, resp *restful.Response) {
+	query := dashboards.Query{
+		Namespace:  req.QueryParameter("namespace"),
+		Folder:     req.QueryParameter("folder"),
+		Tag:        req.QueryParameter("tag"),
+		Title:      req.QueryParameter("title"),
+		Datasource: req.QueryParameter("datasource"),
+	}
+
+	entries, err := h.operator.Search(query)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(entries)
+}
+
+func (h *handler) handleListFolders(req *restful.Request, resp *restful.Response) {
+	folders, err := h.operator.ListFolders()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(folders)
+}
+
+func (h *handler) handleSetDashboardMetadata(req *restful.Request, resp *restful.Response) {
+	h.setMetadata(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleSetClusterDashboardMetadata(req *restful.Request, resp *restful.Response) {
+	h.setMetadata(req, resp, "")
+}
+
+func (h *handler) handleListQuickRanges(req *restful.Request, resp *restful.Response) {
+	resp.WriteAsJson(dashboards.ListQuickRanges())
+}
+
+func (h *handler) handleGetPanelTimes(req *restful.Request, resp *restful.Response) {
+	h.getPanelTimes(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleGetClusterPanelTimes(req *restful.Request, resp *restful.Response) {
+	h.getPanelTimes(req, resp, "")
+}
+
+func (h *handler) getPanelTimes(req *restful.Request, resp *restful.Response, namespace string) {
+	overrides, err := h.operator.GetPanelTimeOverrides(namespace, req.PathParameter("dashboard"))
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(overrides)
+}
+
+func (h *handler) handleSetPanelTimes(req *restful.Request, resp *restful.Response) {
+	h.setPanelTimes(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleSetClusterPanelTimes(req *restful.Request, resp *restful.Response) {
+	h.setPanelTimes(req, resp, "")
+}
+
+func (h *handler) setPanelTimes(req *restful.Request, resp *restful.Response, namespace string) {
+	var overrides []dashboards.PanelTimeOverride
+	if err := req.ReadEntity(&overrides); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if err := h.operator.SetPanelTimeOverrides(namespace, req.PathParameter("dashboard"), overrides); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleGetGraphDisplays(req *restful.Request, resp *restful.Response) {
+	h.getGraphDisplays(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleGetClusterGraphDisplays(req *restful.Request, resp *restful.Response) {
+	h.getGraphDisplays(req, resp, "")
+}
+
+func (h *handler) getGraphDisplays(req *restful.Request, resp *restful.Response, namespace string) {
+	displays, err := h.operator.GetGraphDisplays(namespace, req.PathParameter("dashboard"))
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(displays)
+}
+
+func (h *handler) handleSetGraphDisplays(req *restful.Request, resp *restful.Response) {
+	h.setGraphDisplays(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleSetClusterGraphDisplays(req *restful.Request, resp *restful.Response) {
+	h.setGraphDisplays(req, resp, "")
+}
+
+func (h *handler) setGraphDisplays(req *restful.Request, resp *restful.Response, namespace string) {
+	var displays []dashboards.GraphDisplay
+	if err := req.ReadEntity(&displays); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if err := h.operator.SetGraphDisplays(namespace, req.PathParameter("dashboard"), displays); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleGetSingleStatDisplays(req *restful.Request, resp *restful.Response) {
+	h.getSingleStatDisplays(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleGetClusterSingleStatDisplays(req *restful.Request, resp *restful.Response) {
+	h.getSingleStatDisplays(req, resp, "")
+}
+
+func (h *handler) getSingleStatDisplays(req *restful.Request, resp *restful.Response, namespace string) {
+	displays, err := h.operator.GetSingleStatDisplays(namespace, req.PathParameter("dashboard"))
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(displays)
+}
+
+func (h *handler) handleSetSingleStatDisplays(req *restful.Request, resp *restful.Response) {
+	h.setSingleStatDisplays(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleSetClusterSingleStatDisplays(req *restful.Request, resp *restful.Response) {
+	h.setSingleStatDisplays(req, resp, "")
+}
+
+func (h *handler) setSingleStatDisplays(req *restful.Request, resp *restful.Response, namespace string) {
+	var displays []dashboards.SingleStatDisplay
+	if err := req.ReadEntity(&displays); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if err := h.operator.SetSingleStatDisplays(namespace, req.PathParameter("dashboard"), displays); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleGetPanelRepeats(req *restful.Request, resp *restful.Response) {
+	h.getPanelRepeats(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleGetClusterPanelRepeats(req *restful.Request, resp *restful.Response) {
+	h.getPanelRepeats(req, resp, "")
+}
+
+func (h *handler) getPanelRepeats(req *restful.Request, resp *restful.Response, namespace string) {
+	repeats, err := h.operator.GetPanelRepeats(namespace, req.PathParameter("dashboard"))
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(repeats)
+}
+
+func (h *handler) handleSetPanelRepeats(req *restful.Request, resp *restful.Response) {
+	h.setPanelRepeats(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleSetClusterPanelRepeats(req *restful.Request, resp *restful.Response) {
+	h.setPanelRepeats(req, resp, "")
+}
+
+func (h *handler) setPanelRepeats(req *restful.Request, resp *restful.Response, namespace string) {
+	var repeats []dashboards.PanelRepeat
+	if err := req.ReadEntity(&repeats); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if err := h.operator.SetPanelRepeats(namespace, req.PathParameter("dashboard"), repeats); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleRenderPanels(req *restful.Request, resp *restful.Response) {
+	h.renderPanels(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleRenderClusterPanels(req *restful.Request, resp *restful.Response) {
+	h.renderPanels(req, resp, "")
+}
+
+func (h *handler) renderPanels(req *restful.Request, resp *restful.Response, namespace string) {
+	values := make(map[string][]string)
+	for key, vals := range req.Request.URL.Query() {
+		if !strings.HasPrefix(key, "var.") {
+			continue
+		}
+		name := strings.TrimPrefix(key, "var.")
+		for _, val := range vals {
+			values[name] = append(values[name], strings.Split(val, ",")...)
+		}
+	}
+	panels, err := h.operator.ExpandRepeats(namespace, req.PathParameter("dashboard"), values)
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(panels)
+}
+
+func (h *handler) handleGetLocalizations(req *restful.Request, resp *restful.Response) {
+	h.getLocalizations(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleGetClusterLocalizations(req *restful.Request, resp *restful.Response) {
+	h.getLocalizations(req, resp, "")
+}
+
+func (h *handler) getLocalizations(req *restful.Request, resp *restful.Response, namespace string) {
+	localizations, err := h.operator.GetLocalizations(namespace, req.PathParameter("dashboard"))
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(localizations)
+}
+
+func (h *handler) handleSetLocalizations(req *restful.Request, resp *restful.Response) {
+	h.setLocalizations(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleSetClusterLocalizations(req *restful.Request, resp *restful.Response) {
+	h.setLocalizations(req, resp, "")
+}
+
+func (h *handler) setLocalizations(req *restful.Request, resp *restful.Response, namespace string) {
+	var localizations map[string]dashboards.Localization
+	if err := req.ReadEntity(&localizations); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if err := h.operator.SetLocalizations(namespace, req.PathParameter("dashboard"), localizations); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleLocalize(req *restful.Request, resp *restful.Response) {
+	h.localize(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleLocalizeCluster(req *restful.Request, resp *restful.Response) {
+	h.localize(req, resp, "")
+}
+
+func (h *handler) localize(req *restful.Request, resp *restful.Response, namespace string) {
+	localized, err := h.operator.Localize(namespace, req.PathParameter("dashboard"), req.HeaderParameter("Accept-Language"))
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(localized)
+}
+
+func (h *handler) handleGetPanelThresholds(req *restful.Request, resp *restful.Response) {
+	h.getPanelThresholds(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleGetClusterPanelThresholds(req *restful.Request, resp *restful.Response) {
+	h.getPanelThresholds(req, resp, "")
+}
+
+func (h *handler) getPanelThresholds(req *restful.Request, resp *restful.Response, namespace string) {
+	thresholds, err := h.operator.GetPanelThresholds(namespace, req.PathParameter("dashboard"))
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(thresholds)
+}
+
+func (h *handler) handleSetPanelThresholds(req *restful.Request, resp *restful.Response) {
+	h.setPanelThresholds(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleSetClusterPanelThresholds(req *restful.Request, resp *restful.Response) {
+	h.setPanelThresholds(req, resp, "")
+}
+
+func (h *handler) setPanelThresholds(req *restful.Request, resp *restful.Response, namespace string) {
+	var thresholds []dashboards.PanelThreshold
+	if err := req.ReadEntity(&thresholds); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if err := h.operator.SetPanelThresholds(namespace, req.PathParameter("dashboard"), thresholds); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleEvaluatePanelStates(req *restful.Request, resp *restful.Response) {
+	h.evaluatePanelStates(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleEvaluateClusterPanelStates(req *restful.Request, resp *restful.Response) {
+	h.evaluatePanelStates(req, resp, "")
+}
+
+func (h *handler) evaluatePanelStates(req *restful.Request, resp *restful.Response, namespace string) {
+	states, err := h.operator.EvaluatePanelStates(namespace, req.PathParameter("dashboard"))
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(states)
+}
+
+func (h *handler) handleRecordView(req *restful.Request, resp *restful.Response) {
+	h.recordView(req, resp, req.PathParameter("namespace"))
+}
+
+func (h *handler) handleRecordClusterView(req *restful.Request, resp *restful.Response) {
+	h.recordView(req, resp, "")
+}
+
+func (h *handler) recordView(req *restful.Request, resp *restful.Response, namespace string) {
+	if err := h.operator.RecordView(namespace, req.PathParameter("dashboard")); err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleUsageReport(req *restful.Request, resp *restful.Response) {
+	report, err := h.operator.UsageReport()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(report)
+}
+
+// copyRequest names the Dashboard a cluster dashboard is copied to.
+type copyRequest struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// promoteRequest names the ClusterDashboard a dashboard is promoted to;
+// promotion must be explicitly approved.
+type promoteRequest struct {
+	Name     string `json:"name"`
+	Approved bool   `json:"approved"`
+}
+
+func (h *handler) handleCopyClusterDashboard(req *restful.Request, resp *restful.Response) {
+	var copy copyRequest
+	if err := req.ReadEntity(&copy); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if err := h.operator.CopyClusterDashboard(req.PathParameter("dashboard"), copy.Namespace, copy.Name); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handlePromoteDashboard(req *restful.Request, resp *restful.Response) {
+	var promote promoteRequest
+	if err := req.ReadEntity(&promote); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	err := h.operator.PromoteDashboard(req.PathParameter("namespace"), req.PathParameter("dashboard"), promote.Name, promote.Approved)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) setMetadata(req *restful.Request, resp *restful.Response, namespace string) {
+	var metadata dashboards.Metadata
+	if err := req.ReadEntity(&metadata); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if err := h.operator.SetMetadata(namespace, req.PathParameter("dashboard"), metadata); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
@@ -0,0 +1,365 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/dashboards"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+	monitoringclient "kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const (
+	GroupName = "dashboards.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, cache runtimecache.Cache, client runtimeclient.Client, monitoring monitoringclient.Interface) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(cache, client, monitoring)
+
+	webservice.Route(webservice.GET("/dashboards").
+		To(handler.handleSearchDashboards).
+		Doc("search dashboards and cluster dashboards by folder, tag, title and datasource").
+		Param(webservice.QueryParameter("namespace", "limit the search to the namespace's dashboards")).
+		Param(webservice.QueryParameter("folder", "only dashboards in this folder")).
+		Param(webservice.QueryParameter("tag", "only dashboards carrying this tag")).
+		Param(webservice.QueryParameter("title", "case-insensitive substring of the dashboard title")).
+		Param(webservice.QueryParameter("datasource", "only dashboards using this datasource")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.Entry{}))
+
+	webservice.Route(webservice.GET("/folders").
+		To(handler.handleListFolders).
+		Doc("list the dashboard folders in use with their dashboard counts").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.Folder{}))
+
+	webservice.Route(webservice.PUT("/namespaces/{namespace}/dashboards/{dashboard}/metadata").
+		To(handler.handleSetDashboardMetadata).
+		Doc("set the folder and tags of the dashboard").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Reads(dashboards.Metadata{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.PUT("/clusterdashboards/{dashboard}/metadata").
+		To(handler.handleSetClusterDashboardMetadata).
+		Doc("set the folder and tags of the cluster dashboard").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Reads(dashboards.Metadata{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/timeranges").
+		To(handler.handleListQuickRanges).
+		Doc("list the named time range presets the console offers next to the free-form picker").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.QuickRange{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/dashboards/{dashboard}/paneltimes").
+		To(handler.handleGetPanelTimes).
+		Doc("get the dashboard's per-panel time overrides").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.PanelTimeOverride{}))
+
+	webservice.Route(webservice.PUT("/namespaces/{namespace}/dashboards/{dashboard}/paneltimes").
+		To(handler.handleSetPanelTimes).
+		Doc("replace the dashboard's per-panel time overrides: pin a panel to its own range or timeshift it, e.g. -7d for week-over-week comparisons").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Reads([]dashboards.PanelTimeOverride{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/clusterdashboards/{dashboard}/paneltimes").
+		To(handler.handleGetClusterPanelTimes).
+		Doc("get the cluster dashboard's per-panel time overrides").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.PanelTimeOverride{}))
+
+	webservice.Route(webservice.PUT("/clusterdashboards/{dashboard}/paneltimes").
+		To(handler.handleSetClusterPanelTimes).
+		Doc("replace the cluster dashboard's per-panel time overrides").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Reads([]dashboards.PanelTimeOverride{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/dashboards/{dashboard}/graphdisplay").
+		To(handler.handleGetGraphDisplays).
+		Doc("get the dashboard's graph display settings").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.GraphDisplay{}))
+
+	webservice.Route(webservice.PUT("/namespaces/{namespace}/dashboards/{dashboard}/graphdisplay").
+		To(handler.handleSetGraphDisplays).
+		Doc("replace the dashboard's graph display settings: stacking, per-series overrides and a right Y-axis with its own unit").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Reads([]dashboards.GraphDisplay{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/clusterdashboards/{dashboard}/graphdisplay").
+		To(handler.handleGetClusterGraphDisplays).
+		Doc("get the cluster dashboard's graph display settings").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.GraphDisplay{}))
+
+	webservice.Route(webservice.PUT("/clusterdashboards/{dashboard}/graphdisplay").
+		To(handler.handleSetClusterGraphDisplays).
+		Doc("replace the cluster dashboard's graph display settings").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Reads([]dashboards.GraphDisplay{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/dashboards/{dashboard}/statdisplay").
+		To(handler.handleGetSingleStatDisplays).
+		Doc("get the dashboard's singlestat display settings").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.SingleStatDisplay{}))
+
+	webservice.Route(webservice.PUT("/namespaces/{namespace}/dashboards/{dashboard}/statdisplay").
+		To(handler.handleSetSingleStatDisplays).
+		Doc("replace the dashboard's singlestat display settings: sparkline, value-to-text mappings and drill-down links").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Reads([]dashboards.SingleStatDisplay{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/clusterdashboards/{dashboard}/statdisplay").
+		To(handler.handleGetClusterSingleStatDisplays).
+		Doc("get the cluster dashboard's singlestat display settings").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.SingleStatDisplay{}))
+
+	webservice.Route(webservice.PUT("/clusterdashboards/{dashboard}/statdisplay").
+		To(handler.handleSetClusterSingleStatDisplays).
+		Doc("replace the cluster dashboard's singlestat display settings").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Reads([]dashboards.SingleStatDisplay{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/dashboards/{dashboard}/panelrepeats").
+		To(handler.handleGetPanelRepeats).
+		Doc("get the dashboard's repeat-by-variable assignments").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.PanelRepeat{}))
+
+	webservice.Route(webservice.PUT("/namespaces/{namespace}/dashboards/{dashboard}/panelrepeats").
+		To(handler.handleSetPanelRepeats).
+		Doc("replace the dashboard's repeat-by-variable assignments: a repeated row or graph expands once per value of a templating variable").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Reads([]dashboards.PanelRepeat{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/dashboards/{dashboard}/rendered").
+		To(handler.handleRenderPanels).
+		Doc("render the dashboard's panels with repeats expanded, for exports; pass variable values as var.<name>=v1,v2").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, nil))
+
+	webservice.Route(webservice.GET("/clusterdashboards/{dashboard}/panelrepeats").
+		To(handler.handleGetClusterPanelRepeats).
+		Doc("get the cluster dashboard's repeat-by-variable assignments").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.PanelRepeat{}))
+
+	webservice.Route(webservice.PUT("/clusterdashboards/{dashboard}/panelrepeats").
+		To(handler.handleSetClusterPanelRepeats).
+		Doc("replace the cluster dashboard's repeat-by-variable assignments").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Reads([]dashboards.PanelRepeat{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/clusterdashboards/{dashboard}/rendered").
+		To(handler.handleRenderClusterPanels).
+		Doc("render the cluster dashboard's panels with repeats expanded").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, nil))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/dashboards/{dashboard}/i18n").
+		To(handler.handleGetLocalizations).
+		Doc("get the dashboard's translations keyed by locale").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, map[string]dashboards.Localization{}))
+
+	webservice.Route(webservice.PUT("/namespaces/{namespace}/dashboards/{dashboard}/i18n").
+		To(handler.handleSetLocalizations).
+		Doc("replace the dashboard's translations: per-locale title, description and panel titles").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Reads(map[string]dashboards.Localization{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/dashboards/{dashboard}/localized").
+		To(handler.handleLocalize).
+		Doc("get the dashboard's display strings in the language negotiated from the Accept-Language header").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, dashboards.LocalizedDashboard{}))
+
+	webservice.Route(webservice.GET("/clusterdashboards/{dashboard}/i18n").
+		To(handler.handleGetClusterLocalizations).
+		Doc("get the cluster dashboard's translations keyed by locale").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, map[string]dashboards.Localization{}))
+
+	webservice.Route(webservice.PUT("/clusterdashboards/{dashboard}/i18n").
+		To(handler.handleSetClusterLocalizations).
+		Doc("replace the cluster dashboard's translations").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Reads(map[string]dashboards.Localization{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/clusterdashboards/{dashboard}/localized").
+		To(handler.handleLocalizeCluster).
+		Doc("get the cluster dashboard's display strings in the language negotiated from the Accept-Language header").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, dashboards.LocalizedDashboard{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/dashboards/{dashboard}/thresholds").
+		To(handler.handleGetPanelThresholds).
+		Doc("get the dashboard's panel thresholds").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.PanelThreshold{}))
+
+	webservice.Route(webservice.PUT("/namespaces/{namespace}/dashboards/{dashboard}/thresholds").
+		To(handler.handleSetPanelThresholds).
+		Doc("replace the dashboard's panel thresholds driving background color and alert badge").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Reads([]dashboards.PanelThreshold{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/dashboards/{dashboard}/panelstates").
+		To(handler.handleEvaluatePanelStates).
+		Doc("evaluate the dashboard's panel thresholds at the current values: ok, warning or critical per panel").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.PanelState{}))
+
+	webservice.Route(webservice.GET("/clusterdashboards/{dashboard}/thresholds").
+		To(handler.handleGetClusterPanelThresholds).
+		Doc("get the cluster dashboard's panel thresholds").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.PanelThreshold{}))
+
+	webservice.Route(webservice.PUT("/clusterdashboards/{dashboard}/thresholds").
+		To(handler.handleSetClusterPanelThresholds).
+		Doc("replace the cluster dashboard's panel thresholds").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Reads([]dashboards.PanelThreshold{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/clusterdashboards/{dashboard}/panelstates").
+		To(handler.handleEvaluateClusterPanelStates).
+		Doc("evaluate the cluster dashboard's panel thresholds at the current values").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.PanelState{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/dashboards/{dashboard}/views").
+		To(handler.handleRecordView).
+		Doc("record that the dashboard was opened").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.POST("/clusterdashboards/{dashboard}/views").
+		To(handler.handleRecordClusterView).
+		Doc("record that the cluster dashboard was opened").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/usage").
+		To(handler.handleUsageReport).
+		Doc("list every dashboard's view statistics, least viewed first, to find dashboards worth pruning").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, []dashboards.UsageEntry{}))
+
+	webservice.Route(webservice.POST("/clusterdashboards/{dashboard}/copy").
+		To(handler.handleCopyClusterDashboard).
+		Doc("copy the cluster dashboard into a namespace as a dashboard the project can tweak; the $namespace variable is pinned to the target namespace").
+		Param(webservice.PathParameter("dashboard", "name of the cluster dashboard")).
+		Reads(copyRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/dashboards/{dashboard}/promotion").
+		To(handler.handlePromoteDashboard).
+		Doc("promote the dashboard to a cluster dashboard; requires approval since it becomes visible to every tenant").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("dashboard", "name of the dashboard")).
+		Reads(promoteRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DashboardTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	c.Add(webservice)
+
+	return nil
+}
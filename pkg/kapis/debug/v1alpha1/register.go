@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/diagnosis"
+	"kubesphere.io/kubesphere/pkg/utils/tracing"
+)
+
+const groupName = "debug.kubesphere.io"
+
+var GroupVersion = schema.GroupVersion{Group: groupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client kubernetes.Interface) error {
+	ws := runtime.NewWebService(GroupVersion)
+
+	diagnosisOperator := diagnosis.NewOperator(client)
+
+	ws.Route(ws.GET("/inflightrequests").
+		To(handleInFlightRequests).
+		Doc("List the API requests being served right now with the backend calls they made so far, longest running first.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ToolboxTag}).
+		Returns(http.StatusOK, api.StatusOK, []tracing.InFlightRequest{}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/pods/{pod}/diagnosis").
+		To(handleDiagnosePod(diagnosisOperator)).
+		Doc("Inspect a failing pod's status and events and return a root-cause hypothesis with remediation suggestions, covering scheduling, image pulls, crashes, OOM kills, probes and volumes.").
+		Param(ws.PathParameter("namespace", "name of the namespace")).
+		Param(ws.PathParameter("pod", "name of the pod")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ToolboxTag}).
+		Returns(http.StatusOK, api.StatusOK, diagnosis.Diagnosis{}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/networkcheck").
+		To(handleNetworkCheck(diagnosisOperator)).
+		Doc("Run connectivity probes (DNS resolution, pod to service, pod to external, cross-node) from ephemeral probe pods in the namespace and return a structured report.").
+		Param(ws.PathParameter("namespace", "name of the namespace")).
+		Reads(diagnosis.NetworkCheckSpec{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ToolboxTag}).
+		Returns(http.StatusOK, api.StatusOK, diagnosis.NetworkCheckReport{}))
+
+	c.Add(ws)
+	return nil
+}
+
+func handleInFlightRequests(req *restful.Request, resp *restful.Response) {
+	resp.WriteAsJson(tracing.InFlightRequests())
+}
+
+func handleNetworkCheck(operator diagnosis.Operator) restful.RouteFunction {
+	return func(req *restful.Request, resp *restful.Response) {
+		var spec diagnosis.NetworkCheckSpec
+		if err := req.ReadEntity(&spec); err != nil && err.Error() != "EOF" {
+			api.HandleBadRequest(resp, nil, err)
+			return
+		}
+		result, err := operator.CheckNetwork(req.PathParameter("namespace"), spec)
+		if err != nil {
+			api.HandleInternalError(resp, nil, err)
+			return
+		}
+		resp.WriteAsJson(result)
+	}
+}
+
+func handleDiagnosePod(operator diagnosis.Operator) restful.RouteFunction {
+	return func(req *restful.Request, resp *restful.Response) {
+		result, err := operator.Diagnose(req.PathParameter("namespace"), req.PathParameter("pod"))
+		if err != nil {
+			api.HandleNotFound(resp, nil, err)
+			return
+		}
+		resp.WriteAsJson(result)
+	}
+}
@@ -35,7 +35,8 @@ import (
 )
 
 type devopsHandler struct {
-	devops devops.DevopsOperator
+	devops     devops.DevopsOperator
+	agentPools devops.AgentPoolOperator
 }
 
 func newDevOpsHandler(devopsClient devopsClient.Interface, k8sclient kubernetes.Interface, ksclient kubesphere.Interface,
@@ -43,7 +44,8 @@ func newDevOpsHandler(devopsClient devopsClient.Interface, k8sclient kubernetes.
 	k8sInformers informers.SharedInformerFactory) *devopsHandler {
 
 	return &devopsHandler{
-		devops: devops.NewDevopsOperator(devopsClient, k8sclient, ksclient, ksInformers, k8sInformers),
+		devops:     devops.NewDevopsOperator(devopsClient, k8sclient, ksclient, ksInformers, k8sInformers),
+		agentPools: devops.NewAgentPoolOperator(k8sclient),
 	}
 }
 
@@ -160,6 +162,50 @@ func (h *devopsHandler) DeleteDevOpsProject(request *restful.Request, response *
 	response.WriteEntity(servererr.None)
 }
 
+// agent pool handler about get/put
+func (h *devopsHandler) GetAgentPool(request *restful.Request, response *restful.Response) {
+	devops := request.PathParameter("devops")
+
+	config, err := h.agentPools.GetAgentPoolConfig(devops)
+
+	if err != nil {
+		klog.Error(err)
+		if errors.IsNotFound(err) {
+			api.HandleNotFound(response, request, err)
+			return
+		}
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	response.WriteEntity(config)
+}
+
+func (h *devopsHandler) UpdateAgentPool(request *restful.Request, response *restful.Response) {
+	var config devops.AgentPoolConfig
+	err := request.ReadEntity(&config)
+
+	if err != nil {
+		klog.Error(err)
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	saved, err := h.agentPools.SaveAgentPoolConfig(request.PathParameter("devops"), &config)
+
+	if err != nil {
+		klog.Error(err)
+		if errors.IsNotFound(err) {
+			api.HandleNotFound(response, request, err)
+			return
+		}
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	response.WriteEntity(saved)
+}
+
 // pipeline handler about get/list/post/put/delete
 func (h *devopsHandler) GetPipeline(request *restful.Request, response *restful.Response) {
 	devops := request.PathParameter("devops")
@@ -271,7 +317,7 @@ func (h *devopsHandler) DeletePipeline(request *restful.Request, response *restf
 	response.WriteEntity(servererr.None)
 }
 
-//credential handler about get/list/post/put/delete
+// credential handler about get/list/post/put/delete
 func (h *devopsHandler) GetCredential(request *restful.Request, response *restful.Response) {
 	devops := request.PathParameter("devops")
 	credential := request.PathParameter("credential")
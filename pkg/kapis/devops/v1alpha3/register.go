@@ -32,6 +32,7 @@ import (
 	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
 	"kubesphere.io/kubesphere/pkg/client/informers/externalversions"
 	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/devops"
 	"kubesphere.io/kubesphere/pkg/server/params"
 	devopsClient "kubesphere.io/kubesphere/pkg/simple/client/devops"
 	"net/http"
@@ -96,6 +97,21 @@ func AddToContainer(container *restful.Container, devopsClient devopsClient.Inte
 			Returns(http.StatusOK, api.StatusOK, []v1.Secret{}).
 			Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsPipelineTag}))
 
+		// agent pool
+		ws.Route(ws.GET("/devops/{devops}/agentpool").
+			To(handler.GetAgentPool).
+			Param(ws.PathParameter("devops", "devops name")).
+			Doc("get the build agent pool configuration of the specified devops").
+			Returns(http.StatusOK, api.StatusOK, devops.AgentPoolConfig{}).
+			Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag}))
+
+		ws.Route(ws.PUT("/devops/{devops}/agentpool").
+			To(handler.UpdateAgentPool).
+			Param(ws.PathParameter("devops", "devops name")).
+			Doc("put the build agent pool configuration of the specified devops, node selector, agent sizes, concurrency caps and executor quota included").
+			Returns(http.StatusOK, api.StatusOK, devops.AgentPoolConfig{}).
+			Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag}))
+
 		// pipeline
 		ws.Route(ws.GET("/devops/{devops}/pipelines").
 			To(handler.ListPipeline).
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+	"kubesphere.io/kubesphere/pkg/simple/client/etcd"
+)
+
+type handler struct {
+	client etcd.Interface
+}
+
+func newHandler(client etcd.Interface) *handler {
+	return &handler{client: client}
+}
+
+func (h *handler) handleStatus(req *restful.Request, resp *restful.Response) {
+	status, err := h.client.Status()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(status)
+}
+
+func (h *handler) handleObjectCounts(req *restful.Request, resp *restful.Response) {
+	objects, err := h.client.ObjectCounts()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(objects)
+}
+
+func (h *handler) handleDefragment(req *restful.Request, resp *restful.Response) {
+	if err := h.client.Defragment(); err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
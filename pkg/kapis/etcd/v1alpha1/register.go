@@ -0,0 +1,65 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+	"kubesphere.io/kubesphere/pkg/simple/client/etcd"
+)
+
+const (
+	GroupName = "etcd.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client etcd.Interface) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(client)
+
+	webservice.Route(webservice.GET("/members").
+		To(handler.handleStatus).
+		Doc("report per member etcd DB size, fragmentation and leadership").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.EtcdTag}).
+		Returns(http.StatusOK, api.StatusOK, []etcd.MemberStatus{}))
+
+	webservice.Route(webservice.GET("/objects").
+		To(handler.handleObjectCounts).
+		Doc("aggregate object count and storage bytes per resource type, largest first, to spot CRD bloat").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.EtcdTag}).
+		Returns(http.StatusOK, api.StatusOK, []etcd.ResourceUsage{}))
+
+	webservice.Route(webservice.POST("/defragmentation").
+		To(handler.handleDefragment).
+		Doc("defragment every etcd member, one at a time, to reclaim fragmented space").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.EtcdTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	c.Add(webservice)
+
+	return nil
+}
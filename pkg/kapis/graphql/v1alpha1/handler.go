@@ -0,0 +1,361 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	coreinformers "k8s.io/client-go/informers"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+type handler struct {
+	informers        coreinformers.SharedInformerFactory
+	monitoringClient monitoring.Interface
+}
+
+func newHandler(informers coreinformers.SharedInformerFactory, monitoringClient monitoring.Interface) *handler {
+	return &handler{informers: informers, monitoringClient: monitoringClient}
+}
+
+type queryRequest struct {
+	Query string `json:"query" description:"GraphQL query document"`
+}
+
+type queryError struct {
+	Message string `json:"message" description:"what went wrong"`
+}
+
+type queryResponse struct {
+	Data   map[string]interface{} `json:"data,omitempty" description:"resolved query result"`
+	Errors []queryError           `json:"errors,omitempty" description:"resolution failures"`
+}
+
+// workload is the resolver state of one workload: the common fields of the
+// supported kinds plus what the nested resolvers need.
+type workload struct {
+	name          string
+	namespace     string
+	kind          string
+	replicas      int32
+	readyReplicas int32
+	labels        map[string]string
+	selector      labels.Selector
+}
+
+func (h *handler) handleQuery(req *restful.Request, resp *restful.Response) {
+	var request queryRequest
+	if err := req.ReadEntity(&request); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	selections, err := parseQuery(request.Query)
+	if err != nil {
+		resp.WriteAsJson(queryResponse{Errors: []queryError{{Message: err.Error()}}})
+		return
+	}
+
+	response := queryResponse{Data: make(map[string]interface{})}
+	for _, field := range selections {
+		switch field.Name {
+		case "workloads":
+			result, err := h.resolveWorkloads(field)
+			if err != nil {
+				response.Errors = append(response.Errors, queryError{Message: err.Error()})
+				continue
+			}
+			response.Data[field.Name] = result
+		default:
+			response.Errors = append(response.Errors, queryError{Message: fmt.Sprintf("unknown field %s", field.Name)})
+		}
+	}
+	resp.WriteAsJson(response)
+}
+
+func (h *handler) resolveWorkloads(field selection) ([]map[string]interface{}, error) {
+	namespace := field.Arguments["namespace"]
+	if namespace == "" {
+		return nil, fmt.Errorf("workloads requires a namespace argument")
+	}
+	kind := field.Arguments["kind"]
+	if kind == "" {
+		kind = "deployments"
+	}
+
+	workloads, err := h.listWorkloads(namespace, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(workloads))
+	for _, w := range workloads {
+		resolved, err := h.resolveWorkload(w, field.Fields)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, resolved)
+	}
+	return result, nil
+}
+
+func (h *handler) resolveWorkload(w workload, fields []selection) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		switch field.Name {
+		case "name":
+			result[field.Name] = w.name
+		case "namespace":
+			result[field.Name] = w.namespace
+		case "kind":
+			result[field.Name] = w.kind
+		case "replicas":
+			result[field.Name] = w.replicas
+		case "readyReplicas":
+			result[field.Name] = w.readyReplicas
+		case "ownerApp":
+			result[field.Name] = ownerApp(w.labels)
+		case "pods":
+			pods, err := h.resolvePods(w, field.Fields)
+			if err != nil {
+				return nil, err
+			}
+			result[field.Name] = pods
+		case "events":
+			events, err := h.resolveEvents(w, field.Fields)
+			if err != nil {
+				return nil, err
+			}
+			result[field.Name] = events
+		case "metrics":
+			result[field.Name] = h.resolveMetrics(w, field.Fields)
+		default:
+			return nil, fmt.Errorf("unknown workload field %s", field.Name)
+		}
+	}
+	return result, nil
+}
+
+func (h *handler) resolvePods(w workload, fields []selection) ([]map[string]interface{}, error) {
+	pods, err := h.informers.Core().V1().Pods().Lister().Pods(w.namespace).List(w.selector)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(pods))
+	for _, pod := range pods {
+		resolved := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			switch field.Name {
+			case "name":
+				resolved[field.Name] = pod.Name
+			case "status":
+				resolved[field.Name] = string(pod.Status.Phase)
+			case "node":
+				resolved[field.Name] = pod.Spec.NodeName
+			case "restarts":
+				resolved[field.Name] = podRestarts(pod)
+			default:
+				return nil, fmt.Errorf("unknown pod field %s", field.Name)
+			}
+		}
+		result = append(result, resolved)
+	}
+	return result, nil
+}
+
+func (h *handler) resolveEvents(w workload, fields []selection) ([]map[string]interface{}, error) {
+	events, err := h.informers.Core().V1().Events().Lister().Events(w.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0)
+	for _, event := range events {
+		if event.InvolvedObject.Name != w.name || event.InvolvedObject.Kind != eventKind(w.kind) {
+			continue
+		}
+		resolved := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			switch field.Name {
+			case "type":
+				resolved[field.Name] = event.Type
+			case "reason":
+				resolved[field.Name] = event.Reason
+			case "message":
+				resolved[field.Name] = event.Message
+			case "lastTimestamp":
+				resolved[field.Name] = event.LastTimestamp.Format(time.RFC3339)
+			default:
+				return nil, fmt.Errorf("unknown event field %s", field.Name)
+			}
+		}
+		result = append(result, resolved)
+	}
+	return result, nil
+}
+
+func (h *handler) resolveMetrics(w workload, fields []selection) map[string]interface{} {
+	metrics := h.monitoringClient.GetNamedMetrics([]string{"workload_cpu_usage", "workload_memory_usage_wo_cache"},
+		time.Now(), monitoring.WorkloadOption{
+			NamespaceName:  w.namespace,
+			WorkloadKind:   metricKind(w.kind),
+			ResourceFilter: fmt.Sprintf("^%s$", w.name),
+		})
+
+	values := make(map[string]float64, len(metrics))
+	for _, metric := range metrics {
+		for _, value := range metric.MetricValues {
+			if value.Sample != nil {
+				values[metric.MetricName] = value.Sample.Value()
+			}
+		}
+	}
+
+	result := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		switch field.Name {
+		case "cpuUsage":
+			result[field.Name] = values["workload_cpu_usage"]
+		case "memoryUsage":
+			result[field.Name] = values["workload_memory_usage_wo_cache"]
+		}
+	}
+	return result
+}
+
+func (h *handler) listWorkloads(namespace, kind string) ([]workload, error) {
+	var workloads []workload
+	switch kind {
+	case "deployments":
+		deployments, err := h.informers.Apps().V1().Deployments().Lister().Deployments(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, deployment := range deployments {
+			selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+			if err != nil {
+				continue
+			}
+			workloads = append(workloads, workload{
+				name:          deployment.Name,
+				namespace:     namespace,
+				kind:          kind,
+				replicas:      replicasOf(deployment.Spec.Replicas),
+				readyReplicas: deployment.Status.ReadyReplicas,
+				labels:        deployment.Labels,
+				selector:      selector,
+			})
+		}
+	case "statefulsets":
+		statefulSets, err := h.informers.Apps().V1().StatefulSets().Lister().StatefulSets(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, statefulSet := range statefulSets {
+			selector, err := metav1.LabelSelectorAsSelector(statefulSet.Spec.Selector)
+			if err != nil {
+				continue
+			}
+			workloads = append(workloads, workload{
+				name:          statefulSet.Name,
+				namespace:     namespace,
+				kind:          kind,
+				replicas:      replicasOf(statefulSet.Spec.Replicas),
+				readyReplicas: statefulSet.Status.ReadyReplicas,
+				labels:        statefulSet.Labels,
+				selector:      selector,
+			})
+		}
+	case "daemonsets":
+		daemonSets, err := h.informers.Apps().V1().DaemonSets().Lister().DaemonSets(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, daemonSet := range daemonSets {
+			selector, err := metav1.LabelSelectorAsSelector(daemonSet.Spec.Selector)
+			if err != nil {
+				continue
+			}
+			workloads = append(workloads, workload{
+				name:          daemonSet.Name,
+				namespace:     namespace,
+				kind:          kind,
+				replicas:      daemonSet.Status.DesiredNumberScheduled,
+				readyReplicas: daemonSet.Status.NumberReady,
+				labels:        daemonSet.Labels,
+				selector:      selector,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unknown workload kind %s", kind)
+	}
+	return workloads, nil
+}
+
+func ownerApp(workloadLabels map[string]string) string {
+	if app := workloadLabels["app.kubernetes.io/name"]; app != "" {
+		return app
+	}
+	return workloadLabels["app"]
+}
+
+func podRestarts(pod *corev1.Pod) int32 {
+	var restarts int32
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+	return restarts
+}
+
+func replicasOf(replicas *int32) int32 {
+	if replicas == nil {
+		return 0
+	}
+	return *replicas
+}
+
+func eventKind(kind string) string {
+	switch kind {
+	case "deployments":
+		return "Deployment"
+	case "statefulsets":
+		return "StatefulSet"
+	case "daemonsets":
+		return "DaemonSet"
+	}
+	return ""
+}
+
+func metricKind(kind string) string {
+	switch kind {
+	case "deployments":
+		return "deployment"
+	case "statefulsets":
+		return "statefulset"
+	case "daemonsets":
+		return "daemonset"
+	}
+	return ""
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// selection is one requested field with its arguments and sub-selections,
+// e.g. workloads(namespace: "default") { name pods { name } }.
+type selection struct {
+	Name      string
+	Arguments map[string]string
+	Fields    []selection
+}
+
+// parseQuery parses the GraphQL subset the gateway supports: a single
+// anonymous operation of nested selection sets with string arguments.
+// Variables, fragments, aliases and directives are not supported.
+func parseQuery(query string) ([]selection, error) {
+	p := &parser{input: query}
+	p.skipSpace()
+	// an optional leading "query" keyword
+	if p.peekName() == "query" {
+		p.readName()
+		p.skipSpace()
+	}
+	if !p.consume('{') {
+		return nil, fmt.Errorf("expected { at position %d", p.pos)
+	}
+	selections, err := p.parseSelections()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.input) {
+		return nil, fmt.Errorf("unexpected input at position %d", p.pos)
+	}
+	return selections, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+// parseSelections reads fields until the closing brace, the opening brace
+// has already been consumed.
+func (p *parser) parseSelections() ([]selection, error) {
+	var selections []selection
+	for {
+		p.skipSpace()
+		if p.consume('}') {
+			if len(selections) == 0 {
+				return nil, fmt.Errorf("empty selection set at position %d", p.pos)
+			}
+			return selections, nil
+		}
+
+		name := p.readName()
+		if name == "" {
+			return nil, fmt.Errorf("expected field name at position %d", p.pos)
+		}
+		field := selection{Name: name}
+
+		p.skipSpace()
+		if p.consume('(') {
+			arguments, err := p.parseArguments()
+			if err != nil {
+				return nil, err
+			}
+			field.Arguments = arguments
+			p.skipSpace()
+		}
+
+		if p.consume('{') {
+			fields, err := p.parseSelections()
+			if err != nil {
+				return nil, err
+			}
+			field.Fields = fields
+		}
+		selections = append(selections, field)
+	}
+}
+
+func (p *parser) parseArguments() (map[string]string, error) {
+	arguments := make(map[string]string)
+	for {
+		p.skipSpace()
+		if p.consume(')') {
+			return arguments, nil
+		}
+
+		name := p.readName()
+		if name == "" {
+			return nil, fmt.Errorf("expected argument name at position %d", p.pos)
+		}
+		p.skipSpace()
+		if !p.consume(':') {
+			return nil, fmt.Errorf("expected : at position %d", p.pos)
+		}
+		p.skipSpace()
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		arguments[name] = value
+	}
+}
+
+// readValue reads a string, number, boolean or enum argument value, all
+// returned in their string form.
+func (p *parser) readValue() (string, error) {
+	if p.consume('"') {
+		start := p.pos
+		for p.pos < len(p.input) && p.input[p.pos] != '"' {
+			p.pos++
+		}
+		if p.pos == len(p.input) {
+			return "", fmt.Errorf("unterminated string at position %d", start)
+		}
+		value := p.input[start:p.pos]
+		p.pos++
+		return value, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && !unicode.IsSpace(rune(p.input[p.pos])) && !strings.ContainsRune("(),{}:", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", fmt.Errorf("expected value at position %d", start)
+	}
+	return p.input[start:p.pos], nil
+}
+
+func (p *parser) readName() string {
+	start := p.pos
+	for p.pos < len(p.input) && (isNameRune(rune(p.input[p.pos]))) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *parser) peekName() string {
+	saved := p.pos
+	name := p.readName()
+	p.pos = saved
+	return name
+}
+
+func (p *parser) consume(expected byte) bool {
+	if p.pos < len(p.input) && p.input[p.pos] == expected {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) {
+		r := rune(p.input[p.pos])
+		if unicode.IsSpace(r) || r == ',' {
+			p.pos++
+			continue
+		}
+		// a comment runs to the end of the line
+		if r == '#' {
+			for p.pos < len(p.input) && p.input[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
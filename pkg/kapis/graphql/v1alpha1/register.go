@@ -0,0 +1,55 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1alpha1 exposes the aggregated resource model over a GraphQL
+// style query endpoint, so a console page can fetch workloads together with
+// their pods, events and metrics in one round trip. Only a subset of GraphQL
+// is supported: one anonymous operation of nested selections with string
+// arguments, no variables, fragments or aliases.
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	coreinformers "k8s.io/client-go/informers"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const groupName = "graphql.kubesphere.io"
+
+var GroupVersion = schema.GroupVersion{Group: groupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, informers coreinformers.SharedInformerFactory, monitoringClient monitoring.Interface) error {
+	ws := runtime.NewWebService(GroupVersion)
+	h := newHandler(informers, monitoringClient)
+
+	ws.Route(ws.POST("/query").
+		To(h.handleQuery).
+		Doc("Resolve a GraphQL query against the aggregated resource model, e.g. { workloads(namespace: \"default\") { name pods { name status } metrics { cpuUsage } } }.").
+		Reads(queryRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceResourcesTag}).
+		Returns(http.StatusOK, api.StatusOK, queryResponse{}))
+
+	c.Add(ws)
+	return nil
+}
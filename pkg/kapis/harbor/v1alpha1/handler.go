@@ -0,0 +1,55 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/simple/client/harbor"
+)
+
+type handler struct {
+	client harbor.Interface
+}
+
+func newHandler(client harbor.Interface) *handler {
+	return &handler{client: client}
+}
+
+func (h *handler) handleListRepositories(req *restful.Request, resp *restful.Response) {
+	workspace := req.PathParameter("workspace")
+
+	repositories, err := h.client.ListRepositories(workspace)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(repositories)
+}
+
+func (h *handler) handleListArtifacts(req *restful.Request, resp *restful.Response) {
+	workspace := req.PathParameter("workspace")
+	repository := req.PathParameter("repository")
+
+	artifacts, err := h.client.ListArtifacts(workspace, repository)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(artifacts)
+}
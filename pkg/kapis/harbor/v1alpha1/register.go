@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/harbor"
+)
+
+const (
+	GroupName = "harbor.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client harbor.Interface) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(client)
+
+	webservice.Route(webservice.GET("/workspaces/{workspace}/repositories").
+		To(handler.handleListRepositories).
+		Doc("list image repositories of the Harbor project backing the workspace").
+		Param(webservice.PathParameter("workspace", "name of the workspace")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.HarborTag}).
+		Returns(http.StatusOK, api.StatusOK, []harbor.Repository{}))
+
+	webservice.Route(webservice.GET("/workspaces/{workspace}/repositories/{repository}/artifacts").
+		To(handler.handleListArtifacts).
+		Doc("list artifacts of a repository together with tags and vulnerability scan results").
+		Param(webservice.PathParameter("workspace", "name of the workspace")).
+		Param(webservice.PathParameter("repository", "name of the repository inside the workspace's project")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.HarborTag}).
+		Returns(http.StatusOK, api.StatusOK, []harbor.Artifact{}))
+
+	c.Add(webservice)
+
+	return nil
+}
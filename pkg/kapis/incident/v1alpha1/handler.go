@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	incidentv1alpha1 "kubesphere.io/kubesphere/pkg/apis/incident/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/models/incident"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+type handler struct {
+	operator incident.Operator
+}
+
+func newHandler(client kubernetes.Interface, dynamicClient dynamic.Interface) *handler {
+	return &handler{operator: incident.NewOperator(client, dynamicClient)}
+}
+
+func username(req *restful.Request) string {
+	if user, ok := request.UserFrom(req.Request.Context()); ok {
+		return user.GetName()
+	}
+	return ""
+}
+
+func (h *handler) handleReportAlerts(req *restful.Request, response *restful.Response) {
+	var webhook incident.AlertWebhook
+	if err := req.ReadEntity(&webhook); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	result, err := h.operator.ReportAlerts(webhook.Alerts)
+	if err != nil {
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(result)
+}
+
+func (h *handler) handleListIncidents(req *restful.Request, response *restful.Response) {
+	namespace := req.QueryParameter("namespace")
+	state := incidentv1alpha1.IncidentState(req.QueryParameter("state"))
+
+	incidents, err := h.operator.List(namespace, state)
+	if err != nil {
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(incidents)
+}
+
+func (h *handler) handleGetIncident(req *restful.Request, response *restful.Response) {
+	result, err := h.operator.Get(req.PathParameter("incident"))
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			api.HandleNotFound(response, nil, err)
+			return
+		}
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(result)
+}
+
+func (h *handler) handleAddAction(req *restful.Request, response *restful.Response) {
+	var entry incidentv1alpha1.TimelineEntry
+	if err := req.ReadEntity(&entry); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	if err := h.operator.AddAction(req.PathParameter("incident"), username(req), entry.Message); err != nil {
+		if apierrors.IsNotFound(err) {
+			api.HandleNotFound(response, nil, err)
+			return
+		}
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleResolveIncident(req *restful.Request, response *restful.Response) {
+	if err := h.operator.Resolve(req.PathParameter("incident"), username(req)); err != nil {
+		if apierrors.IsNotFound(err) {
+			api.HandleNotFound(response, nil, err)
+			return
+		}
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(errors.None)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	incidentv1alpha1 "kubesphere.io/kubesphere/pkg/apis/incident/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/incident"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+const (
+	GroupName = "incident.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client kubernetes.Interface, dynamicClient dynamic.Interface) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(client, dynamicClient)
+
+	webservice.Route(webservice.POST("/alerts").
+		To(handler.handleReportAlerts).
+		Reads(incident.AlertWebhook{}).
+		Doc("report firing alerts, storms of critical alerts open incidents and later alerts attach to open ones; point an alertmanager webhook receiver here").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.IncidentTag}).
+		Returns(http.StatusOK, api.StatusOK, incident.ReportResult{}))
+
+	webservice.Route(webservice.GET("/incidents").
+		To(handler.handleListIncidents).
+		Param(webservice.QueryParameter("namespace", "only incidents scoped to the namespace")).
+		Param(webservice.QueryParameter("state", "only incidents in the state, open or resolved")).
+		Doc("list incidents newest first").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.IncidentTag}).
+		Returns(http.StatusOK, api.StatusOK, []incidentv1alpha1.Incident{}))
+
+	webservice.Route(webservice.GET("/incidents/{incident}").
+		To(handler.handleGetIncident).
+		Param(webservice.PathParameter("incident", "name of the incident")).
+		Doc("get an incident with the events and rollouts of its window merged into the timeline").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.IncidentTag}).
+		Returns(http.StatusOK, api.StatusOK, incidentv1alpha1.Incident{}))
+
+	webservice.Route(webservice.POST("/incidents/{incident}/actions").
+		To(handler.handleAddAction).
+		Param(webservice.PathParameter("incident", "name of the incident")).
+		Reads(incidentv1alpha1.TimelineEntry{}).
+		Doc("record an operator action on the incident's timeline, only the message of the entry is taken").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.IncidentTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.POST("/incidents/{incident}/resolve").
+		To(handler.handleResolveIncident).
+		Param(webservice.PathParameter("incident", "name of the incident")).
+		Doc("resolve the incident, closing its timeline").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.IncidentTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	c.Add(webservice)
+
+	return nil
+}
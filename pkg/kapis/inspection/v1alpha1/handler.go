@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+	"k8s.io/client-go/dynamic"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/models/inspection"
+)
+
+type handler struct {
+	operator inspection.Operator
+}
+
+func newHandler(client dynamic.Interface) *handler {
+	return &handler{operator: inspection.NewOperator(client)}
+}
+
+func (h *handler) handleListReports(req *restful.Request, response *restful.Response) {
+	clusterInspection := req.PathParameter("clusterinspection")
+
+	reports, err := h.operator.ListReports(clusterInspection)
+	if err != nil {
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(reports)
+}
+
+func (h *handler) handleGetReport(req *restful.Request, response *restful.Response) {
+	report, err := h.operator.GetReport(req.PathParameter("report"))
+	if err != nil {
+		api.HandleNotFound(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(report)
+}
+
+func (h *handler) handleDiffReport(req *restful.Request, response *restful.Response) {
+	report := req.PathParameter("report")
+	against := req.QueryParameter("against")
+
+	diff, err := h.operator.Diff(report, against)
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(diff)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	inspectionv1alpha1 "kubesphere.io/kubesphere/pkg/apis/inspection/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/inspection"
+)
+
+const (
+	GroupName = "inspection.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client dynamic.Interface) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(client)
+
+	webservice.Route(webservice.GET("/clusterinspections/{clusterinspection}/inspectionreports").
+		To(handler.handleListReports).
+		Param(webservice.PathParameter("clusterinspection", "name of the cluster inspection")).
+		Doc("list the reports produced by the scheduled runs of the inspection, newest first").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.InspectionTag}).
+		Returns(http.StatusOK, api.StatusOK, []inspectionv1alpha1.InspectionReport{}))
+
+	webservice.Route(webservice.GET("/inspectionreports/{report}").
+		To(handler.handleGetReport).
+		Param(webservice.PathParameter("report", "name of the inspection report")).
+		Doc("get a single inspection report with the full check results").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.InspectionTag}).
+		Returns(http.StatusOK, api.StatusOK, inspectionv1alpha1.InspectionReport{}))
+
+	webservice.Route(webservice.GET("/inspectionreports/{report}/diff").
+		To(handler.handleDiffReport).
+		Param(webservice.PathParameter("report", "name of the inspection report")).
+		Param(webservice.QueryParameter("against", "older report to compare against, defaults to the run right before the report")).
+		Doc("diff an inspection report against an older run: new failures, resolved and still failing checks").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.InspectionTag}).
+		Returns(http.StatusOK, api.StatusOK, inspection.ReportDiff{}))
+
+	c.Add(webservice)
+
+	return nil
+}
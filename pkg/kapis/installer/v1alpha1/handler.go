@@ -0,0 +1,122 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/models/installer"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+type handler struct {
+	operator installer.Operator
+}
+
+func newHandler(client kubernetes.Interface, config *rest.Config) (*handler, error) {
+	operator, err := installer.NewOperator(client, config)
+	if err != nil {
+		return nil, err
+	}
+	return &handler{operator: operator}, nil
+}
+
+func (h *handler) handleListDrift(req *restful.Request, resp *restful.Response) {
+	drifts, err := h.operator.ListDrift()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(drifts)
+}
+
+func (h *handler) handleTriggerReconcile(req *restful.Request, resp *restful.Response) {
+	if err := h.operator.TriggerReconcile(); err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleGetClusterConfiguration(req *restful.Request, resp *restful.Response) {
+	cc, err := h.operator.GetClusterConfiguration()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(cc)
+}
+
+func (h *handler) handleUpdateClusterConfiguration(req *restful.Request, resp *restful.Response) {
+	cc := &unstructured.Unstructured{}
+	if err := req.ReadEntity(&cc.Object); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	dryRun := req.QueryParameter("dryRun") == "true"
+	if err := h.operator.UpdateClusterConfiguration(cc, dryRun); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleListComponents(req *restful.Request, resp *restful.Response) {
+	statuses, err := h.operator.ListComponents()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(statuses)
+}
+
+func (h *handler) handleEnableComponent(req *restful.Request, resp *restful.Response) {
+	if err := h.operator.SetComponentEnabled(req.PathParameter("component"), true); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleDisableComponent(req *restful.Request, resp *restful.Response) {
+	if err := h.operator.SetComponentEnabled(req.PathParameter("component"), false); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleComponentProgress(req *restful.Request, resp *restful.Response) {
+	progress, err := h.operator.ComponentProgress(req.PathParameter("component"))
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(progress)
+}
+
+func (h *handler) handleRollbackComponent(req *restful.Request, resp *restful.Response) {
+	if err := h.operator.RollbackComponent(req.PathParameter("component")); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
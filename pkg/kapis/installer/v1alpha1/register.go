@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/installer"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+const (
+	GroupName = "installer.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client kubernetes.Interface, config *rest.Config) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler, err := newHandler(client, config)
+	if err != nil {
+		return err
+	}
+
+	webservice.Route(webservice.GET("/clusterconfiguration/drift").
+		To(handler.handleListDrift).
+		Doc("compare the declared ClusterConfiguration with the deployed KubeSphere components and list every difference: missing or unexpected components, replica mismatches and image version drift").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.InstallerTag}).
+		Returns(http.StatusOK, api.StatusOK, []installer.Drift{}))
+
+	webservice.Route(webservice.POST("/clusterconfiguration/reconciliation").
+		To(handler.handleTriggerReconcile).
+		Doc("ask ks-installer to reconcile the components back to the declared ClusterConfiguration").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.InstallerTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/clusterconfiguration").
+		To(handler.handleGetClusterConfiguration).
+		Doc("get the declared ClusterConfiguration").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.InstallerTag}).
+		Returns(http.StatusOK, api.StatusOK, nil))
+
+	webservice.Route(webservice.PUT("/clusterconfiguration").
+		To(handler.handleUpdateClusterConfiguration).
+		Doc("update the ClusterConfiguration. Field combinations are validated first and broken ones rejected with actionable errors, with dryRun=true the change is validated only and never persisted").
+		Param(webservice.QueryParameter("dryRun", "validate the change without persisting it").DataType("boolean").DefaultValue("false")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.InstallerTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/components").
+		To(handler.handleListComponents).
+		Doc("list the pluggable components with their declared and observed state").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.InstallerTag}).
+		Returns(http.StatusOK, api.StatusOK, []installer.ComponentStatus{}))
+
+	webservice.Route(webservice.POST("/components/{component}/enable").
+		To(handler.handleEnableComponent).
+		Doc("enable the pluggable component. With its chart available the change runs through helm in the background; otherwise ks-installer reconciles just that component. Poll the progress endpoint.").
+		Param(webservice.PathParameter("component", "name of the component, e.g. devops")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.InstallerTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.POST("/components/{component}/disable").
+		To(handler.handleDisableComponent).
+		Doc("disable the pluggable component").
+		Param(webservice.PathParameter("component", "name of the component, e.g. devops")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.InstallerTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/components/{component}/progress").
+		To(handler.handleComponentProgress).
+		Doc("get the progress of the component's latest enable, disable or rollback").
+		Param(webservice.PathParameter("component", "name of the component, e.g. devops")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.InstallerTag}).
+		Returns(http.StatusOK, api.StatusOK, installer.ChangeProgress{}))
+
+	webservice.Route(webservice.POST("/components/{component}/rollback").
+		To(handler.handleRollbackComponent).
+		Doc("restore the component to its state before the last change").
+		Param(webservice.PathParameter("component", "name of the component, e.g. devops")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.InstallerTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	c.Add(webservice)
+
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"io/ioutil"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/models/license"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+type handler struct {
+	operator license.Operator
+}
+
+func newHandler(client kubernetes.Interface) *handler {
+	return &handler{operator: license.NewOperator(client)}
+}
+
+func (h *handler) handleGetStatus(req *restful.Request, response *restful.Response) {
+	status, err := h.operator.GetStatus()
+	if err != nil {
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(status)
+}
+
+func (h *handler) handleUploadLicense(req *restful.Request, response *restful.Response) {
+	raw, err := ioutil.ReadAll(req.Request.Body)
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	uploaded, err := h.operator.UploadLicense(raw)
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(uploaded)
+}
+
+func (h *handler) handleDeleteLicense(req *restful.Request, response *restful.Response) {
+	if err := h.operator.DeleteLicense(); err != nil {
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleCheckFeature(req *restful.Request, response *restful.Response) {
+	feature := req.PathParameter("feature")
+
+	entitled, err := h.operator.HasFeature(feature)
+	if err != nil {
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(map[string]bool{"entitled": entitled})
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/license"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+const (
+	GroupName = "license.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client kubernetes.Interface) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(client)
+
+	webservice.Route(webservice.GET("/license").
+		To(handler.handleGetStatus).
+		Doc("get the license with current usage and soft warnings as the node count or expiry date approach the licensed limits").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LicenseTag}).
+		Returns(http.StatusOK, api.StatusOK, license.Status{}))
+
+	webservice.Route(webservice.POST("/license").
+		To(handler.handleUploadLicense).
+		Doc("upload a license document, the body is the license JSON as issued").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LicenseTag}).
+		Returns(http.StatusOK, api.StatusOK, license.License{}))
+
+	webservice.Route(webservice.DELETE("/license").
+		To(handler.handleDeleteLicense).
+		Doc("remove the uploaded license").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LicenseTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/license/features/{feature}").
+		To(handler.handleCheckFeature).
+		Param(webservice.PathParameter("feature", "name of the feature, e.g. multicluster")).
+		Doc("check whether the license entitles a feature, unlicensed clusters are entitled to everything").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LicenseTag}))
+
+	c.Add(webservice)
+
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/informers"
+	model "kubesphere.io/kubesphere/pkg/models/monitoring"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+type chargebackHandler struct {
+	operator model.ChargebackOperator
+}
+
+func newChargebackHandler(k kubernetes.Interface, m monitoring.Interface, f informers.InformerFactory) *chargebackHandler {
+	return &chargebackHandler{
+		operator: model.NewChargebackOperator(k, f.KubernetesSharedInformerFactory(), m),
+	}
+}
+
+func (h *chargebackHandler) HandleCostDimensionsQuery(req *restful.Request, resp *restful.Response) {
+	dimensions, err := h.operator.GetCostDimensions()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteEntity(dimensions)
+}
+
+func (h *chargebackHandler) HandleCostDimensionsUpdate(req *restful.Request, resp *restful.Response) {
+	var dimensions []string
+	if err := req.ReadEntity(&dimensions); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	if err := h.operator.SetCostDimensions(dimensions); err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteEntity(dimensions)
+}
+
+func (h *chargebackHandler) HandleChargebackQuery(req *restful.Request, resp *restful.Response) {
+	dimension := req.QueryParameter("dimension")
+	if dimension == "" {
+		api.HandleBadRequest(resp, nil, fmt.Errorf("parameter dimension is required"))
+		return
+	}
+
+	start, err := parseUnixTime(req.QueryParameter("start"))
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	end, err := parseUnixTime(req.QueryParameter("end"))
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	groups, err := h.operator.Chargeback(dimension, start, end)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteEntity(groups)
+}
+
+func parseUnixTime(value string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+	}
+	return time.Unix(seconds, 0), nil
+}
@@ -351,6 +351,34 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, meteri
 		Returns(http.StatusOK, respOK, model.Metrics{})).
 		Produces(restful.MIME_JSON)
 
+	ch := newChargebackHandler(k8sClient, meteringClient, factory)
+
+	ws.Route(ws.GET("/dimensions").
+		To(ch.HandleCostDimensionsQuery).
+		Doc("List workload labels configured as cost dimensions.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ClusterMetersTag}).
+		Returns(http.StatusOK, respOK, []string{})).
+		Produces(restful.MIME_JSON)
+
+	ws.Route(ws.PUT("/dimensions").
+		To(ch.HandleCostDimensionsUpdate).
+		Doc("Configure which workload labels are cost dimensions.").
+		Reads([]string{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ClusterMetersTag}).
+		Returns(http.StatusOK, respOK, []string{})).
+		Produces(restful.MIME_JSON)
+
+	ws.Route(ws.GET("/chargeback").
+		To(ch.HandleChargebackQuery).
+		Doc("Get pod costs over a time span grouped by the values of a cost dimension label.").
+		Param(ws.QueryParameter("dimension", "The label key costs are grouped by. Must be configured as a cost dimension.").DataType("string").Required(true)).
+		Param(ws.QueryParameter("start", "Start time of query. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(true)).
+		Param(ws.QueryParameter("end", "End time of query. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(true)).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ClusterMetersTag}).
+		Writes([]model.CostGroup{}).
+		Returns(http.StatusOK, respOK, []model.CostGroup{})).
+		Produces(restful.MIME_JSON)
+
 	c.Add(ws)
 	return nil
 }
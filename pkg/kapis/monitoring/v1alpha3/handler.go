@@ -21,13 +21,16 @@ package v1alpha3
 import (
 	"errors"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/emicklei/go-restful"
 	"k8s.io/client-go/kubernetes"
 	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/informers"
 	model "kubesphere.io/kubesphere/pkg/models/monitoring"
+	"kubesphere.io/kubesphere/pkg/models/monitoring/expressions"
 	resourcev1alpha3 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/resource"
 	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
 )
@@ -35,10 +38,24 @@ import (
 type handler struct {
 	k  kubernetes.Interface
 	mo model.MonitoringOperator
+	nn model.NoisyNeighborOperator
 }
 
 func NewHandler(k kubernetes.Interface, monitoringClient monitoring.Interface, metricsClient monitoring.Interface, f informers.InformerFactory, resourceGetter *resourcev1alpha3.ResourceGetter) *handler {
-	return &handler{k, model.NewMonitoringOperator(monitoringClient, metricsClient, k, f, resourceGetter)}
+	return &handler{
+		k:  k,
+		mo: model.NewMonitoringOperator(monitoringClient, metricsClient, k, f, resourceGetter),
+		nn: model.NewNoisyNeighborOperator(f.KubernetesSharedInformerFactory(), monitoringClient),
+	}
+}
+
+func (h handler) handleNoisyNeighborsQuery(req *restful.Request, resp *restful.Response) {
+	result, err := h.nn.GetNoisyNeighbors()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(result)
 }
 
 func (h handler) handleKubeSphereMetricsQuery(req *restful.Request, resp *restful.Response) {
@@ -172,6 +189,50 @@ func (h handler) handleComponentMetricsQuery(req *restful.Request, resp *restful
 	h.handleNamedMetricsQuery(resp, opt)
 }
 
+func (h handler) handleTopConsumersQuery(req *restful.Request, resp *restful.Response) {
+	metric := req.QueryParameter("metric")
+	if metric == "" {
+		metric = "pod_cpu_usage"
+	}
+
+	page := DefaultPage
+	if raw := req.QueryParameter("page"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			api.HandleBadRequest(resp, nil, errors.New(ErrInvalidPage))
+			return
+		}
+		page = parsed
+	}
+
+	limit := DefaultTopConsumersLimit
+	if raw := req.QueryParameter("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			api.HandleBadRequest(resp, nil, errors.New(ErrInvalidLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	queryTime := time.Now()
+	if raw := req.QueryParameter("time"); raw != "" {
+		timeInt, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			api.HandleBadRequest(resp, nil, err)
+			return
+		}
+		queryTime = time.Unix(timeInt, 0)
+	}
+
+	res, err := h.mo.GetTopConsumers(metric, req.PathParameter("workspace"), req.PathParameter("namespace"), queryTime, page, limit)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(res)
+}
+
 func handleNoHit(namedMetrics []string) model.Metrics {
 	var res model.Metrics
 	for _, metic := range namedMetrics {
@@ -203,6 +264,10 @@ func (h handler) handleNamedMetricsQuery(resp *restful.Response, q queryOptions)
 	}
 
 	if q.isRangeQuery() {
+		if q.offset > 0 {
+			resp.WriteAsJson(h.mo.GetNamedMetricsOverTimeComparison(metrics, q.start, q.end, q.step, q.offset, q.option))
+			return
+		}
 		res = h.mo.GetNamedMetricsOverTime(metrics, q.start, q.end, q.step, q.option)
 	} else {
 		res = h.mo.GetNamedMetrics(metrics, q.time, q.option)
@@ -257,7 +322,23 @@ func (h handler) handleAdhocQuery(req *restful.Request, resp *restful.Response)
 		return
 	}
 
-	if opt.isRangeQuery() {
+	// The scope tokens $cluster, $workload and $pod narrow the query further
+	// within the namespace; when any of them is given the whole scope is
+	// substituted into the expression and enforced on its selectors.
+	scope := expressions.Scope{
+		Cluster:   req.QueryParameter("cluster"),
+		Namespace: params.namespaceName,
+		Workload:  req.QueryParameter("workload"),
+		Pod:       req.QueryParameter("pod"),
+	}
+
+	if scope.Cluster != "" || scope.Workload != "" || scope.Pod != "" {
+		if opt.isRangeQuery() {
+			res, err = h.mo.GetScopedMetricOverTime(params.expression, scope, opt.start, opt.end, opt.step)
+		} else {
+			res, err = h.mo.GetScopedMetric(params.expression, scope, opt.time)
+		}
+	} else if opt.isRangeQuery() {
 		res, err = h.mo.GetMetricOverTime(params.expression, params.namespaceName, opt.start, opt.end, opt.step)
 	} else {
 		res, err = h.mo.GetMetric(params.expression, params.namespaceName, opt.time)
@@ -269,3 +350,56 @@ func (h handler) handleAdhocQuery(req *restful.Request, resp *restful.Response)
 		resp.WriteAsJson(res)
 	}
 }
+
+// pipelineQuery evaluates several expressions and runs a transformation
+// pipeline over their results.
+type pipelineQuery struct {
+	Queries         []string               `json:"queries"`
+	Transformations []model.Transformation `json:"transformations,omitempty"`
+}
+
+func (h handler) handlePipelineQuery(req *restful.Request, resp *restful.Response) {
+	params := parseRequestParams(req)
+	opt, err := h.makeQueryOptions(params, 0)
+	if err != nil {
+		if err.Error() == ErrNoHit {
+			resp.WriteAsJson([]monitoring.Metric{})
+			return
+		}
+
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	var query pipelineQuery
+	if err := req.ReadEntity(&query); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	if len(query.Queries) == 0 {
+		api.HandleBadRequest(resp, nil, errors.New("at least one query is required"))
+		return
+	}
+
+	metrics := make([]monitoring.Metric, 0, len(query.Queries))
+	for _, expr := range query.Queries {
+		var res monitoring.Metric
+		if opt.isRangeQuery() {
+			res, err = h.mo.GetMetricOverTime(expr, params.namespaceName, opt.start, opt.end, opt.step)
+		} else {
+			res, err = h.mo.GetMetric(expr, params.namespaceName, opt.time)
+		}
+		if err != nil {
+			api.HandleBadRequest(resp, nil, err)
+			return
+		}
+		metrics = append(metrics, res)
+	}
+
+	metrics, err = model.ApplyTransformations(metrics, query.Transformations)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(metrics)
+}
@@ -41,6 +41,8 @@ const (
 	DefaultPage   = 1
 	DefaultLimit  = 5
 
+	DefaultTopConsumersLimit = 10
+
 	OperationQuery  = "query"
 	OperationExport = "export"
 
@@ -53,6 +55,8 @@ const (
 	ErrInvalidStartEnd   = "'start' must be before 'end'."
 	ErrInvalidPage       = "Invalid parameter 'page'."
 	ErrInvalidLimit      = "Invalid parameter 'limit'."
+	ErrInvalidCompare    = "Invalid parameter 'compare', expect a positive offset like 1d, 7d or 12h."
+	ErrCompareRangeOnly  = "'compare' is only valid for range queries."
 	ErrParameterNotfound = "Parmameter [%s] not found"
 )
 
@@ -83,6 +87,7 @@ type reqParams struct {
 	applications     string
 	services         string
 	pvcFilter        string
+	compare          string
 }
 
 type queryOptions struct {
@@ -102,6 +107,10 @@ type queryOptions struct {
 	page       int
 	limit      int
 
+	// offset of the comparison window for range queries, 0 means no
+	// comparison
+	offset time.Duration
+
 	option monitoring.QueryOption
 }
 
@@ -154,10 +163,30 @@ func parseRequestParams(req *restful.Request) reqParams {
 	r.applications = req.QueryParameter("applications")
 	r.services = req.QueryParameter("services")
 	r.pvcFilter = req.QueryParameter("pvc_filter")
+	r.compare = req.QueryParameter("compare")
 
 	return r
 }
 
+// parseCompareOffset understands day shorthands like 1d and 7d besides the
+// durations time.ParseDuration accepts, a leading minus is tolerated since
+// the offset always reaches into the past
+func parseCompareOffset(compare string) (time.Duration, error) {
+	compare = strings.TrimPrefix(compare, "-")
+	if strings.HasSuffix(compare, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(compare, "d"))
+		if err != nil || days <= 0 {
+			return 0, errors.New(ErrInvalidCompare)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	offset, err := time.ParseDuration(compare)
+	if err != nil || offset <= 0 {
+		return 0, errors.New(ErrInvalidCompare)
+	}
+	return offset, nil
+}
+
 func (h handler) makeQueryOptions(r reqParams, lvl monitoring.Level) (q queryOptions, err error) {
 	if r.resourceFilter == "" {
 		r.resourceFilter = DefaultFilter
@@ -323,6 +352,16 @@ func (h handler) makeQueryOptions(r reqParams, lvl monitoring.Level) (q queryOpt
 		return q, errors.Errorf(ErrParamConflict)
 	}
 
+	if r.compare != "" {
+		if !q.isRangeQuery() {
+			return q, errors.New(ErrCompareRangeOnly)
+		}
+		q.offset, err = parseCompareOffset(r.compare)
+		if err != nil {
+			return q, err
+		}
+	}
+
 	// Ensure query start time to be after the namespace creation time
 	if r.namespaceName != "" {
 		ns, err := h.k.CoreV1().Namespaces().Get(context.Background(), r.namespaceName, corev1.GetOptions{})
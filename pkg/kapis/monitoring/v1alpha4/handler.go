@@ -0,0 +1,194 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/informers"
+	model "kubesphere.io/kubesphere/pkg/models/monitoring"
+	"kubesphere.io/kubesphere/pkg/models/monitoring/dsl"
+	"kubesphere.io/kubesphere/pkg/models/monitoring/remotewrite"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+// defaultStep is the query resolution when a range query gives no step.
+const defaultStep = 10 * time.Minute
+
+type handler struct {
+	mo model.MonitoringOperator
+	rw remotewrite.Operator
+}
+
+func NewHandler(k kubernetes.Interface, monitoringClient monitoring.Interface, metricsClient monitoring.Interface, f informers.InformerFactory, writeEndpoint string) *handler {
+	return &handler{
+		mo: model.NewMonitoringOperator(monitoringClient, metricsClient, k, f, nil),
+		rw: remotewrite.NewOperator(k, writeEndpoint),
+	}
+}
+
+// queryWindow is the time range parsed from the request, either a range
+// query or an instant query.
+type queryWindow struct {
+	start, end time.Time
+	step       time.Duration
+	time       time.Time
+}
+
+func (w queryWindow) isRangeQuery() bool {
+	return w.time.IsZero()
+}
+
+func parseQueryWindow(req *restful.Request) (queryWindow, error) {
+	var window queryWindow
+
+	start := req.QueryParameter("start")
+	end := req.QueryParameter("end")
+	if start != "" && end != "" {
+		startInt, err := strconv.ParseInt(start, 10, 64)
+		if err != nil {
+			return window, err
+		}
+		window.start = time.Unix(startInt, 0)
+
+		endInt, err := strconv.ParseInt(end, 10, 64)
+		if err != nil {
+			return window, err
+		}
+		window.end = time.Unix(endInt, 0)
+
+		if window.start.After(window.end) {
+			return window, fmt.Errorf("the start time must be before the end time")
+		}
+
+		window.step = defaultStep
+		if step := req.QueryParameter("step"); step != "" {
+			if window.step, err = time.ParseDuration(step); err != nil {
+				return window, err
+			}
+		}
+		return window, nil
+	}
+
+	window.time = time.Now()
+	if ts := req.QueryParameter("time"); ts != "" {
+		timeInt, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return window, err
+		}
+		window.time = time.Unix(timeInt, 0)
+	}
+	return window, nil
+}
+
+// compiledQuery is a structured query together with the expression it
+// compiles to; the expression can be stored as a dashboard target.
+type compiledQuery struct {
+	Query dsl.Query `json:"query"`
+	Expr  string    `json:"expr"`
+}
+
+func (h handler) compile(req *restful.Request) (compiledQuery, error) {
+	var result compiledQuery
+	if err := req.ReadEntity(&result.Query); err != nil {
+		return result, err
+	}
+
+	compile, ok := dsl.CompileFns["prometheus"]
+	if !ok {
+		return result, fmt.Errorf("the monitoring backend has no query compiler")
+	}
+
+	expr, err := compile(result.Query)
+	if err != nil {
+		return result, err
+	}
+	result.Expr = expr
+	return result, nil
+}
+
+func (h handler) handleCompileQuery(req *restful.Request, resp *restful.Response) {
+	result, err := h.compile(req)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(result)
+}
+
+// handleStructuredQuery compiles and evaluates a structured query. The
+// namespace path parameter, when present, is enforced on the compiled
+// expression the same way it is on raw expression queries.
+func (h handler) handleStructuredQuery(req *restful.Request, resp *restful.Response) {
+	result, err := h.compile(req)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	window, err := parseQueryWindow(req)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	namespace := req.PathParameter("namespace")
+
+	var res monitoring.Metric
+	if window.isRangeQuery() {
+		res, err = h.mo.GetMetricOverTime(result.Expr, namespace, window.start, window.end, window.step)
+	} else {
+		res, err = h.mo.GetMetric(result.Expr, namespace, window.time)
+	}
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(res)
+}
+
+// handleRemoteWrite receives a Prometheus remote-write request from an
+// external source. The bearer token identifies the tenant.
+func (h handler) handleRemoteWrite(req *restful.Request, resp *restful.Response) {
+	token := strings.TrimPrefix(req.HeaderParameter("Authorization"), "Bearer ")
+
+	compressed, err := ioutil.ReadAll(req.Request.Body)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	switch err := h.rw.Receive(token, compressed); err {
+	case nil:
+		resp.WriteHeader(http.StatusNoContent)
+	case remotewrite.ErrUnauthorized:
+		api.HandleUnauthorized(resp, nil, err)
+	case remotewrite.ErrNotConfigured:
+		resp.WriteError(http.StatusServiceUnavailable, err)
+	default:
+		api.HandleBadRequest(resp, nil, err)
+	}
+}
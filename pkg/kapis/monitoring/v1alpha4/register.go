@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	"github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/models/monitoring/dsl"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring/prometheus"
+)
+
+const (
+	groupName = "monitoring.kubesphere.io"
+	respOK    = "ok"
+)
+
+var GroupVersion = schema.GroupVersion{Group: groupName, Version: "v1alpha4"}
+
+func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, monitoringClient monitoring.Interface, metricsClient monitoring.Interface, factory informers.InformerFactory, options *prometheus.Options) error {
+	ws := runtime.NewWebService(GroupVersion)
+
+	writeEndpoint := ""
+	if options != nil {
+		writeEndpoint = options.RemoteWriteEndpoint
+	}
+	h := NewHandler(k8sClient, monitoringClient, metricsClient, factory, writeEndpoint)
+
+	ws.Route(ws.POST("/query").
+		To(h.handleStructuredQuery).
+		Doc("Evaluate a structured metric query at the cluster level. The query is compiled to the backend query language server-side.").
+		Reads(dsl.Query{}).
+		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
+		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
+		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.CustomMetricsTag}).
+		Writes(monitoring.Metric{}).
+		Returns(http.StatusOK, respOK, monitoring.Metric{})).
+		Produces(restful.MIME_JSON)
+
+	ws.Route(ws.POST("/namespaces/{namespace}/query").
+		To(h.handleStructuredQuery).
+		Doc("Evaluate a structured metric query in the specific namespace. The query is compiled to the backend query language server-side and scoped to the namespace.").
+		Reads(dsl.Query{}).
+		Param(ws.PathParameter("namespace", "The name of the namespace.").DataType("string").Required(true)).
+		Param(ws.QueryParameter("start", "Start time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1559347200. ").DataType("string").Required(false)).
+		Param(ws.QueryParameter("end", "End time of query. Use **start** and **end** to retrieve metric data over a time span. It is a string with Unix time format, eg. 1561939200. ").DataType("string").Required(false)).
+		Param(ws.QueryParameter("step", "Time interval. Retrieve metric data at a fixed interval within the time range of start and end. It requires both **start** and **end** are provided. The format is [0-9]+[smhdwy]. Defaults to 10m (i.e. 10 min).").DataType("string").DefaultValue("10m").Required(false)).
+		Param(ws.QueryParameter("time", "A timestamp in Unix time format. Retrieve metric data at a single point in time. Defaults to now. Time and the combination of start, end, step are mutually exclusive.").DataType("string").Required(false)).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.CustomMetricsTag}).
+		Writes(monitoring.Metric{}).
+		Returns(http.StatusOK, respOK, monitoring.Metric{})).
+		Produces(restful.MIME_JSON)
+
+	ws.Route(ws.POST("/query/compilation").
+		To(h.handleCompileQuery).
+		Doc("Compile a structured metric query to the backend query language without evaluating it, e.g. to store the expression as a dashboard target.").
+		Reads(dsl.Query{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.CustomMetricsTag}).
+		Returns(http.StatusOK, respOK, compiledQuery{})).
+		Produces(restful.MIME_JSON)
+
+	ws.Route(ws.POST("/remotewrite").
+		To(h.handleRemoteWrite).
+		Doc("Receive a Prometheus remote-write request from a source outside the cluster. The sender authenticates with its tenant's token in the Authorization header; every series is relabeled and scoped to the tenant's namespace before it is forwarded to the monitoring stack.").
+		Consumes("application/x-protobuf").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.CustomMetricsTag}).
+		Returns(http.StatusNoContent, respOK, nil))
+
+	c.Add(ws)
+	return nil
+}
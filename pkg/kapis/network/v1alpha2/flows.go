@@ -0,0 +1,266 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/klog"
+
+	"kubesphere.io/kubesphere/pkg/api"
+)
+
+// FlowQueryUrl is the flow source API, e.g. a Cilium Hubble JSON gateway
+const FlowQueryUrl = "http://%s/api/v1/flows"
+
+// flowEndpoint is one side of an observed flow, Hubble schema.
+type flowEndpoint struct {
+	Namespace string `json:"namespace"`
+	PodName   string `json:"pod_name"`
+}
+
+// flow is the subset of a Hubble flow the aggregations need.
+type flow struct {
+	Verdict        string       `json:"verdict"`
+	DropReasonDesc string       `json:"drop_reason_desc,omitempty"`
+	Time           string       `json:"time"`
+	Source         flowEndpoint `json:"source"`
+	Destination    flowEndpoint `json:"destination"`
+	L4             struct {
+		TCP *struct {
+			DestinationPort int `json:"destination_port"`
+		} `json:"TCP,omitempty"`
+		UDP *struct {
+			DestinationPort int `json:"destination_port"`
+		} `json:"UDP,omitempty"`
+	} `json:"l4"`
+	L7 *struct {
+		DNS *struct {
+			Query string `json:"query"`
+			Rcode int    `json:"rcode"`
+		} `json:"dns,omitempty"`
+	} `json:"l7,omitempty"`
+}
+
+// FlowConnection is one edge of the namespace connection map: an observed
+// source/destination pair with its flow counts.
+type FlowConnection struct {
+	SourceNamespace      string `json:"sourceNamespace,omitempty"`
+	SourcePod            string `json:"sourcePod,omitempty"`
+	DestinationNamespace string `json:"destinationNamespace,omitempty"`
+	DestinationPod       string `json:"destinationPod,omitempty"`
+	Protocol             string `json:"protocol,omitempty"`
+	Port                 int    `json:"port,omitempty"`
+	Flows                int    `json:"flows"`
+	Denied               int    `json:"denied"`
+}
+
+// DeniedFlow is a flow dropped by a NetworkPolicy.
+type DeniedFlow struct {
+	FlowConnection
+	Reason   string `json:"reason,omitempty"`
+	LastSeen string `json:"lastSeen,omitempty"`
+}
+
+// DNSStat is the query count of one DNS name seen in the namespace.
+type DNSStat struct {
+	Query  string `json:"query"`
+	Count  int    `json:"count"`
+	Errors int    `json:"errors"`
+}
+
+// fetchFlows reads the namespace's flows from the flow source.
+func (h *handler) fetchFlows(namespace string) ([]flow, error) {
+	if h.flowSourceHost == "" {
+		return nil, fmt.Errorf("no network flow source is configured")
+	}
+
+	query := url.Values{"namespace": []string{namespace}}
+	u := fmt.Sprintf(FlowQueryUrl+"?%s", h.flowSourceHost, query.Encode())
+
+	resp, err := http.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the flow source returned %s", resp.Status)
+	}
+
+	flows := make([]flow, 0)
+	if err := json.Unmarshal(body, &flows); err != nil {
+		return nil, err
+	}
+	return flows, nil
+}
+
+func (f flow) connection() FlowConnection {
+	connection := FlowConnection{
+		SourceNamespace:      f.Source.Namespace,
+		SourcePod:            f.Source.PodName,
+		DestinationNamespace: f.Destination.Namespace,
+		DestinationPod:       f.Destination.PodName,
+	}
+	switch {
+	case f.L4.TCP != nil:
+		connection.Protocol = "TCP"
+		connection.Port = f.L4.TCP.DestinationPort
+	case f.L4.UDP != nil:
+		connection.Protocol = "UDP"
+		connection.Port = f.L4.UDP.DestinationPort
+	}
+	return connection
+}
+
+func (f flow) denied() bool {
+	return f.Verdict == "DROPPED"
+}
+
+// aggregateConnections folds the flows into the namespace connection map.
+func aggregateConnections(flows []flow) []FlowConnection {
+	counts := make(map[FlowConnection]*FlowConnection)
+	for _, f := range flows {
+		key := f.connection()
+		edge, ok := counts[key]
+		if !ok {
+			edge = &FlowConnection{}
+			*edge = key
+			counts[key] = edge
+		}
+		edge.Flows++
+		if f.denied() {
+			edge.Denied++
+		}
+	}
+	connections := make([]FlowConnection, 0, len(counts))
+	for _, edge := range counts {
+		connections = append(connections, *edge)
+	}
+	sort.Slice(connections, func(i, j int) bool {
+		if connections[i].Flows != connections[j].Flows {
+			return connections[i].Flows > connections[j].Flows
+		}
+		return connections[i].SourcePod < connections[j].SourcePod
+	})
+	return connections
+}
+
+// aggregateDenied folds the dropped flows by connection and drop reason.
+func aggregateDenied(flows []flow) []DeniedFlow {
+	type deniedKey struct {
+		connection FlowConnection
+		reason     string
+	}
+	counts := make(map[deniedKey]*DeniedFlow)
+	for _, f := range flows {
+		if !f.denied() {
+			continue
+		}
+		key := deniedKey{connection: f.connection(), reason: f.DropReasonDesc}
+		denied, ok := counts[key]
+		if !ok {
+			denied = &DeniedFlow{FlowConnection: key.connection, Reason: key.reason}
+			counts[key] = denied
+		}
+		denied.Flows++
+		denied.Denied++
+		if f.Time > denied.LastSeen {
+			denied.LastSeen = f.Time
+		}
+	}
+	deniedFlows := make([]DeniedFlow, 0, len(counts))
+	for _, denied := range counts {
+		deniedFlows = append(deniedFlows, *denied)
+	}
+	sort.Slice(deniedFlows, func(i, j int) bool {
+		if deniedFlows[i].Denied != deniedFlows[j].Denied {
+			return deniedFlows[i].Denied > deniedFlows[j].Denied
+		}
+		return deniedFlows[i].SourcePod < deniedFlows[j].SourcePod
+	})
+	return deniedFlows
+}
+
+// aggregateDNS folds the DNS flows into per-query stats.
+func aggregateDNS(flows []flow) []DNSStat {
+	counts := make(map[string]*DNSStat)
+	for _, f := range flows {
+		if f.L7 == nil || f.L7.DNS == nil || f.L7.DNS.Query == "" {
+			continue
+		}
+		stat, ok := counts[f.L7.DNS.Query]
+		if !ok {
+			stat = &DNSStat{Query: f.L7.DNS.Query}
+			counts[f.L7.DNS.Query] = stat
+		}
+		stat.Count++
+		if f.L7.DNS.Rcode != 0 {
+			stat.Errors++
+		}
+	}
+	stats := make([]DNSStat, 0, len(counts))
+	for _, stat := range counts {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Query < stats[j].Query
+	})
+	return stats
+}
+
+func (h *handler) getNamespaceConnections(request *restful.Request, response *restful.Response) {
+	flows, err := h.fetchFlows(request.PathParameter("namespace"))
+	if err != nil {
+		klog.Errorf("query flow source failed with err %v", err)
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+	response.WriteAsJson(aggregateConnections(flows))
+}
+
+func (h *handler) getNamespaceDeniedFlows(request *restful.Request, response *restful.Response) {
+	flows, err := h.fetchFlows(request.PathParameter("namespace"))
+	if err != nil {
+		klog.Errorf("query flow source failed with err %v", err)
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+	response.WriteAsJson(aggregateDenied(flows))
+}
+
+func (h *handler) getNamespaceDNSStats(request *restful.Request, response *restful.Response) {
+	flows, err := h.fetchFlows(request.PathParameter("namespace"))
+	if err != nil {
+		klog.Errorf("query flow source failed with err %v", err)
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+	response.WriteAsJson(aggregateDNS(flows))
+}
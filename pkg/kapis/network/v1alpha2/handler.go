@@ -33,6 +33,9 @@ const ScopeQueryUrl = "http://%s/api/topology/services"
 type handler struct {
 	// if weave scope installed in the cluster, it is maybe `weave-scope-app.weave`
 	weaveScopeHost string
+	// flow source serving observed network flows, e.g. a Cilium Hubble
+	// JSON gateway like `hubble-gateway.kube-system`
+	flowSourceHost string
 }
 
 func (h *handler) getScopeUrl() string {
@@ -30,9 +30,9 @@ const GroupName = "network.kubesphere.io"
 
 var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha2"}
 
-func AddToContainer(c *restful.Container, weaveScopeHost string) error {
+func AddToContainer(c *restful.Container, weaveScopeHost string, flowSourceHost string) error {
 	webservice := runtime.NewWebService(GroupVersion)
-	h := handler{weaveScopeHost: weaveScopeHost}
+	h := handler{weaveScopeHost: weaveScopeHost, flowSourceHost: flowSourceHost}
 
 	webservice.Route(webservice.GET("/namespaces/{namespace}/topology").
 		To(h.getNamespaceTopology).
@@ -53,6 +53,33 @@ func AddToContainer(c *restful.Container, weaveScopeHost string) error {
 		Writes(NodeResponse{})).
 		Produces(restful.MIME_JSON)
 
+	webservice.Route(webservice.GET("/namespaces/{namespace}/flows/connections").
+		To(h.getNamespaceConnections).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NetworkTopologyTag}).
+		Doc("Get the observed connection map of a namespace, aggregated from the network flow source").
+		Param(webservice.PathParameter("namespace", "name of the namespace").Required(true)).
+		Returns(http.StatusOK, "ok", []FlowConnection{}).
+		Writes([]FlowConnection{})).
+		Produces(restful.MIME_JSON)
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/flows/denied").
+		To(h.getNamespaceDeniedFlows).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NetworkTopologyTag}).
+		Doc("Get the flows of a namespace dropped by network policies, grouped by connection and drop reason").
+		Param(webservice.PathParameter("namespace", "name of the namespace").Required(true)).
+		Returns(http.StatusOK, "ok", []DeniedFlow{}).
+		Writes([]DeniedFlow{})).
+		Produces(restful.MIME_JSON)
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/flows/dns").
+		To(h.getNamespaceDNSStats).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NetworkTopologyTag}).
+		Doc("Get the DNS query stats of a namespace, aggregated from the network flow source").
+		Param(webservice.PathParameter("namespace", "name of the namespace").Required(true)).
+		Returns(http.StatusOK, "ok", []DNSStat{}).
+		Writes([]DNSStat{})).
+		Produces(restful.MIME_JSON)
+
 	c.Add(webservice)
 
 	return nil
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	kubesphereclient "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+	"kubesphere.io/kubesphere/pkg/models/nodegroup"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+type handler struct {
+	operator nodegroup.Operator
+}
+
+func newHandler(client kubernetes.Interface, ksClient kubesphereclient.Interface, informers informers.SharedInformerFactory, cache runtimecache.Cache) *handler {
+	return &handler{operator: nodegroup.NewOperator(client, ksClient, informers, cache)}
+}
+
+func (h *handler) handleListNodeGroups(req *restful.Request, resp *restful.Response) {
+	groups, err := h.operator.ListNodeGroups()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(groups)
+}
+
+func (h *handler) handleAddNode(req *restful.Request, resp *restful.Response) {
+	group := req.PathParameter("nodegroup")
+	node := req.PathParameter("node")
+
+	if err := h.operator.AddNode(group, node); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleRemoveNode(req *restful.Request, resp *restful.Response) {
+	group := req.PathParameter("nodegroup")
+	node := req.PathParameter("node")
+
+	if err := h.operator.RemoveNode(group, node); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleListNodeCompliance(req *restful.Request, resp *restful.Response) {
+	compliance, err := h.operator.ListNodeCompliance()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(compliance)
+}
+
+func (h *handler) handleBindWorkspace(req *restful.Request, resp *restful.Response) {
+	group := req.PathParameter("nodegroup")
+	workspace := req.PathParameter("workspace")
+
+	if err := h.operator.BindWorkspace(group, workspace); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleUnbindWorkspace(req *restful.Request, resp *restful.Response) {
+	group := req.PathParameter("nodegroup")
+	workspace := req.PathParameter("workspace")
+
+	if err := h.operator.UnbindWorkspace(group, workspace); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	kubesphereclient "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/nodegroup"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+const (
+	GroupName = "nodegroup.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client kubernetes.Interface, ksClient kubesphereclient.Interface, k8sInformers informers.SharedInformerFactory, cache runtimecache.Cache) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(client, ksClient, k8sInformers, cache)
+
+	webservice.Route(webservice.GET("/nodegroups").
+		To(handler.handleListNodeGroups).
+		Doc("list dedicated node groups with their nodes, bound workspaces and capacity").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NodeGroupTag}).
+		Returns(http.StatusOK, api.StatusOK, []nodegroup.NodeGroup{}))
+
+	webservice.Route(webservice.POST("/nodegroups/{nodegroup}/nodes/{node}").
+		To(handler.handleAddNode).
+		Doc("add the node to the node group, labeling and tainting it for dedicated use").
+		Param(webservice.PathParameter("nodegroup", "name of the node group")).
+		Param(webservice.PathParameter("node", "name of the node")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NodeGroupTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.DELETE("/nodegroups/{nodegroup}/nodes/{node}").
+		To(handler.handleRemoveNode).
+		Doc("remove the node from the node group").
+		Param(webservice.PathParameter("nodegroup", "name of the node group")).
+		Param(webservice.PathParameter("node", "name of the node")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NodeGroupTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/nodeprofilecompliance").
+		To(handler.handleListNodeCompliance).
+		Doc("report per node whether the current node profile of its group has been applied").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NodeGroupTag}).
+		Returns(http.StatusOK, api.StatusOK, []nodegroup.NodeCompliance{}))
+
+	webservice.Route(webservice.POST("/nodegroups/{nodegroup}/workspaces/{workspace}").
+		To(handler.handleBindWorkspace).
+		Doc("bind the workspace to the node group, its workloads are scheduled onto the group's nodes").
+		Param(webservice.PathParameter("nodegroup", "name of the node group")).
+		Param(webservice.PathParameter("workspace", "name of the workspace")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NodeGroupTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.DELETE("/nodegroups/{nodegroup}/workspaces/{workspace}").
+		To(handler.handleUnbindWorkspace).
+		Doc("unbind the workspace from the node group").
+		Param(webservice.PathParameter("nodegroup", "name of the node group")).
+		Param(webservice.PathParameter("workspace", "name of the workspace")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NodeGroupTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	c.Add(webservice)
+
+	return nil
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package v2beta1
 
 import (
+	"io/ioutil"
+	"time"
+
 	"github.com/emicklei/go-restful"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes"
@@ -30,7 +33,12 @@ import (
 )
 
 type handler struct {
-	operator notification.Operator
+	operator          notification.Operator
+	eventRuleOperator notification.EventRuleOperator
+	templateOperator  notification.TemplateOperator
+	onCallOperator    notification.OnCallOperator
+	webhookOperator   notification.WebhookOperator
+	dispatchOperator  notification.DispatchOperator
 }
 
 func newNotificationHandler(
@@ -39,10 +47,165 @@ func newNotificationHandler(
 	ksClient kubesphere.Interface) *handler {
 
 	return &handler{
-		operator: notification.NewOperator(informers, k8sClient, ksClient),
+		operator:          notification.NewOperator(informers, k8sClient, ksClient),
+		eventRuleOperator: notification.NewEventRuleOperator(k8sClient),
+		templateOperator:  notification.NewTemplateOperator(k8sClient),
+		onCallOperator:    notification.NewOnCallOperator(k8sClient),
+		webhookOperator:   notification.NewWebhookOperator(k8sClient),
+		dispatchOperator:  notification.NewDispatchOperator(k8sClient),
 	}
 }
 
+func (h *handler) ListDeliveries(req *restful.Request, resp *restful.Response) {
+
+	deliveries, err := h.dispatchOperator.ListDeliveries(req.QueryParameter("state"))
+	handleResponse(req, resp, deliveries, err)
+}
+
+func (h *handler) GetDelivery(req *restful.Request, resp *restful.Response) {
+
+	delivery, err := h.dispatchOperator.GetDelivery(req.PathParameter("delivery"))
+	handleResponse(req, resp, delivery, err)
+}
+
+func (h *handler) RetryDelivery(req *restful.Request, resp *restful.Response) {
+
+	delivery, err := h.dispatchOperator.RetryDelivery(req.PathParameter("delivery"))
+	handleResponse(req, resp, delivery, err)
+}
+
+func (h *handler) ListWebhookMappings(req *restful.Request, resp *restful.Response) {
+
+	rules, err := h.webhookOperator.ListMappingRules()
+	handleResponse(req, resp, rules, err)
+}
+
+func (h *handler) SaveWebhookMappings(req *restful.Request, resp *restful.Response) {
+
+	var rules []notification.MappingRule
+	if err := req.ReadEntity(&rules); err != nil {
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	saved, err := h.webhookOperator.SaveMappingRules(rules)
+	handleResponse(req, resp, saved, err)
+}
+
+func (h *handler) IngestWebhookAlerts(req *restful.Request, resp *restful.Response) {
+
+	source := req.PathParameter("source")
+
+	payload, err := ioutil.ReadAll(req.Request.Body)
+	if err != nil {
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	result, err := h.webhookOperator.Ingest(source, payload)
+	handleResponse(req, resp, result, err)
+}
+
+func (h *handler) ListOnCallSchedules(req *restful.Request, resp *restful.Response) {
+
+	workspace := req.PathParameter("workspace")
+
+	schedules, err := h.onCallOperator.ListSchedules(workspace)
+	handleResponse(req, resp, schedules, err)
+}
+
+func (h *handler) SaveOnCallSchedules(req *restful.Request, resp *restful.Response) {
+
+	workspace := req.PathParameter("workspace")
+
+	var schedules []notification.OnCallSchedule
+	if err := req.ReadEntity(&schedules); err != nil {
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	saved, err := h.onCallOperator.SaveSchedules(workspace, schedules)
+	handleResponse(req, resp, saved, err)
+}
+
+func (h *handler) GetOnCall(req *restful.Request, resp *restful.Response) {
+
+	workspace := req.PathParameter("workspace")
+	schedule := req.PathParameter("schedule")
+
+	at := time.Now()
+	if value := req.QueryParameter("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			api.HandleBadRequest(resp, req, servererr.New("invalid at time %s, expected the RFC3339 form", value))
+			return
+		}
+		at = parsed
+	}
+
+	status, err := h.onCallOperator.OnCall(workspace, schedule, at)
+	handleResponse(req, resp, status, err)
+}
+
+func (h *handler) ListEventRules(req *restful.Request, resp *restful.Response) {
+
+	workspace := req.PathParameter("workspace")
+
+	rules, err := h.eventRuleOperator.ListEventRules(workspace)
+	handleResponse(req, resp, rules, err)
+}
+
+func (h *handler) SaveEventRules(req *restful.Request, resp *restful.Response) {
+
+	workspace := req.PathParameter("workspace")
+
+	var rules []notification.EventRule
+	if err := req.ReadEntity(&rules); err != nil {
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	saved, err := h.eventRuleOperator.SaveEventRules(workspace, rules)
+	handleResponse(req, resp, saved, err)
+}
+
+func (h *handler) ListTemplates(req *restful.Request, resp *restful.Response) {
+
+	receiver := req.PathParameter("receiver")
+
+	templates, err := h.templateOperator.ListTemplates(receiver)
+	handleResponse(req, resp, templates, err)
+}
+
+func (h *handler) SaveTemplates(req *restful.Request, resp *restful.Response) {
+
+	receiver := req.PathParameter("receiver")
+
+	var templates []notification.MessageTemplate
+	if err := req.ReadEntity(&templates); err != nil {
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	saved, err := h.templateOperator.SaveTemplates(receiver, templates)
+	handleResponse(req, resp, saved, err)
+}
+
+func (h *handler) RenderTemplate(req *restful.Request, resp *restful.Response) {
+
+	receiver := req.PathParameter("receiver")
+	language := req.QueryParameter("language")
+
+	var data notification.TemplateData
+	if err := req.ReadEntity(&data); err != nil {
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	rendered, err := h.templateOperator.RenderTemplate(receiver, language, &data)
+	handleResponse(req, resp, rendered, err)
+}
+
 func (h *handler) ListResource(req *restful.Request, resp *restful.Response) {
 
 	user := req.PathParameter("user")
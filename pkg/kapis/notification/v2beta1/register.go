@@ -29,6 +29,7 @@ import (
 	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
 	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/informers"
+	"kubesphere.io/kubesphere/pkg/models/notification"
 	"kubesphere.io/kubesphere/pkg/server/errors"
 	"net/http"
 )
@@ -148,6 +149,115 @@ func AddToContainer(
 		Param(ws.PathParameter(query.ParameterName, "the name of the resource")).
 		Returns(http.StatusOK, api.StatusOK, errors.None))
 
+	// apis for per-workspace event notification rules
+	ws.Route(ws.GET("/workspaces/{workspace}/eventrules").
+		To(h.ListEventRules).
+		Doc("list the workspace's rules converting Kubernetes events into notifications").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Returns(http.StatusOK, api.StatusOK, []notification.EventRule{}))
+
+	ws.Route(ws.PUT("/workspaces/{workspace}/eventrules").
+		To(h.SaveEventRules).
+		Doc("replace the workspace's rules converting Kubernetes events into notifications").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Reads([]notification.EventRule{}).
+		Returns(http.StatusOK, api.StatusOK, []notification.EventRule{}))
+
+	// apis for per-workspace on-call schedules
+	ws.Route(ws.GET("/workspaces/{workspace}/oncallschedules").
+		To(h.ListOnCallSchedules).
+		Doc("list the workspace's on-call schedules").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Returns(http.StatusOK, api.StatusOK, []notification.OnCallSchedule{}))
+
+	ws.Route(ws.PUT("/workspaces/{workspace}/oncallschedules").
+		To(h.SaveOnCallSchedules).
+		Doc("replace the workspace's on-call schedules").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Reads([]notification.OnCallSchedule{}).
+		Returns(http.StatusOK, api.StatusOK, []notification.OnCallSchedule{}))
+
+	ws.Route(ws.GET("/workspaces/{workspace}/oncallschedules/{schedule}/oncall").
+		To(h.GetOnCall).
+		Doc("resolve who is on call for the schedule").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("schedule", "schedule name")).
+		Param(ws.QueryParameter("at", "resolve at this RFC3339 time instead of now").Required(false)).
+		Returns(http.StatusOK, api.StatusOK, notification.OnCallStatus{}))
+
+	// apis for per-receiver message templates
+	ws.Route(ws.GET("/receivers/{receiver}/templates").
+		To(h.ListTemplates).
+		Doc("list the receiver's message templates").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.PathParameter("receiver", "receiver name")).
+		Returns(http.StatusOK, api.StatusOK, []notification.MessageTemplate{}))
+
+	ws.Route(ws.PUT("/receivers/{receiver}/templates").
+		To(h.SaveTemplates).
+		Doc("replace the receiver's message templates").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.PathParameter("receiver", "receiver name")).
+		Reads([]notification.MessageTemplate{}).
+		Returns(http.StatusOK, api.StatusOK, []notification.MessageTemplate{}))
+
+	ws.Route(ws.POST("/receivers/{receiver}/templates/render").
+		To(h.RenderTemplate).
+		Doc("render the receiver's message template against the supplied alert data for preview, without sending anything").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.PathParameter("receiver", "receiver name")).
+		Param(ws.QueryParameter("language", "language of the template to render, defaults to en").Required(false)).
+		Reads(notification.TemplateData{}).
+		Returns(http.StatusOK, api.StatusOK, notification.RenderedMessage{}))
+
+	// apis for alerts received from external monitoring systems
+	ws.Route(ws.GET("/webhooks/mappings").
+		To(h.ListWebhookMappings).
+		Doc("list the mapping rules applied to alerts received from external monitoring systems").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Returns(http.StatusOK, api.StatusOK, []notification.MappingRule{}))
+
+	ws.Route(ws.PUT("/webhooks/mappings").
+		To(h.SaveWebhookMappings).
+		Doc("replace the mapping rules applied to alerts received from external monitoring systems").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Reads([]notification.MappingRule{}).
+		Returns(http.StatusOK, api.StatusOK, []notification.MappingRule{}))
+
+	ws.Route(ws.POST("/webhooks/{source}").
+		To(h.IngestWebhookAlerts).
+		Doc("receive alerts from an external monitoring system and forward them into the notification pipeline").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.PathParameter("source", "alert source, known values include generic, zabbix, nagios, cloudwatch")).
+		Returns(http.StatusOK, api.StatusOK, notification.IngestResult{}))
+
+	// apis for the delivery status of dispatched notifications
+	ws.Route(ws.GET("/deliveries").
+		To(h.ListDeliveries).
+		Doc("list the queued notification deliveries and dead letters, newest first").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.QueryParameter("state", "narrow the list to one delivery state, one of pending, delivered, dead").Required(false)).
+		Returns(http.StatusOK, api.StatusOK, []notification.Delivery{}))
+
+	ws.Route(ws.GET("/deliveries/{delivery}").
+		To(h.GetDelivery).
+		Doc("describe one notification delivery").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.PathParameter("delivery", "delivery id")).
+		Returns(http.StatusOK, api.StatusOK, notification.Delivery{}))
+
+	ws.Route(ws.POST("/deliveries/{delivery}/retry").
+		To(h.RetryDelivery).
+		Doc("put a dead letter back into the delivery queue with a fresh attempt budget").
+		Metadata(KeyOpenAPITags, []string{constants.NotificationTag}).
+		Param(ws.PathParameter("delivery", "delivery id")).
+		Returns(http.StatusOK, api.StatusOK, notification.Delivery{}))
+
 	container.Add(ws)
 	return nil
 }
@@ -585,6 +585,21 @@ func (h *openpitrixHandler) GetAppVersionPackage(req *restful.Request, resp *res
 	resp.WriteEntity(result)
 }
 
+func (h *openpitrixHandler) GetAppVersionValuesSchema(req *restful.Request, resp *restful.Response) {
+	appId := req.PathParameter("app")
+	versionId := req.PathParameter("version")
+
+	result, err := h.openpitrix.GetAppVersionValuesSchema(appId, versionId)
+
+	if err != nil {
+		klog.Errorln(err)
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteEntity(result)
+}
+
 func (h *openpitrixHandler) GetAppVersionFiles(req *restful.Request, resp *restful.Response) {
 	versionId := req.PathParameter("version")
 	getAppVersionFilesRequest := &openpitrix.GetAppVersionFilesRequest{}
@@ -918,6 +933,10 @@ func (h *openpitrixHandler) ListReviews(req *restful.Request, resp *restful.Resp
 		return
 	}
 
+	if req.PathParameter("workspace") != "" {
+		conditions.Match[openpitrix.WorkspaceLabel] = req.PathParameter("workspace")
+	}
+
 	result, err := h.openpitrix.ListAppVersionReviews(conditions, orderBy, reverse, limit, offset)
 
 	if err != nil {
@@ -355,6 +355,14 @@ func AddToContainer(c *restful.Container, ksInfomrers informers.InformerFactory,
 		Param(webservice.PathParameter("version", "app template version id")).
 		Param(webservice.PathParameter("app", "app template id")))
 
+	webservice.Route(webservice.GET("/apps/{app}/versions/{version}/schema").
+		To(handler.GetAppVersionValuesSchema).
+		Doc("Get the values.schema.json of version-specific app").
+		Returns(http.StatusOK, api.StatusOK, openpitrix.GetAppVersionValuesSchemaResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.OpenpitrixAppTemplateTag}).
+		Param(webservice.PathParameter("version", "app template version id")).
+		Param(webservice.PathParameter("app", "app template id")))
+
 	webservice.Route(webservice.PATCH("/apps/{app}/versions/{version}").
 		Deprecate().
 		Consumes(mimePatch...).
@@ -676,6 +684,19 @@ func AddToContainer(c *restful.Container, ksInfomrers informers.InformerFactory,
 			DefaultValue("limit=10,page=1")).
 		Returns(http.StatusOK, api.StatusOK, openpitrix.AppVersionReview{}))
 
+	webservice.Route(webservice.GET("/workspaces/{workspace}/reviews").
+		To(handler.ListReviews).
+		Doc("Get reviews of version-specific app in the specified workspace").
+		Param(webservice.PathParameter("workspace", "the name of the workspace")).
+		Param(webservice.QueryParameter(params.ConditionsParam, "query conditions,connect multiple conditions with commas, equal symbol for exact query, wave symbol for fuzzy query e.g. name~a").
+			Required(false).
+			DataFormat("key=%s,key~%s")).
+		Param(webservice.QueryParameter(params.PagingParam, "paging query, e.g. limit=100,page=1").
+			Required(false).
+			DataFormat("limit=%d,page=%d").
+			DefaultValue("limit=10,page=1")).
+		Returns(http.StatusOK, api.StatusOK, openpitrix.AppVersionReview{}))
+
 	webservice.Route(webservice.GET("/attachments/{attachment}").
 		To(handler.DescribeAttachment).
 		Doc("Get attachment by attachment id").
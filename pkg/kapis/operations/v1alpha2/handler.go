@@ -19,21 +19,134 @@ package v1alpha2
 import (
 	"fmt"
 	"github.com/emicklei/go-restful"
+	"io/ioutil"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+	"kubesphere.io/kubesphere/pkg/client/informers/externalversions"
+	"kubesphere.io/kubesphere/pkg/models/apply"
+	"kubesphere.io/kubesphere/pkg/models/cleanup"
+	"kubesphere.io/kubesphere/pkg/models/clone"
+	"kubesphere.io/kubesphere/pkg/models/nodes"
+	"kubesphere.io/kubesphere/pkg/models/release"
 	"kubesphere.io/kubesphere/pkg/models/workloads"
 	"kubesphere.io/kubesphere/pkg/server/errors"
 	"net/http"
+	"strconv"
 )
 
 type operationHandler struct {
-	jobRunner workloads.JobRunner
+	jobRunner           workloads.JobRunner
+	batchOperator       workloads.BatchOperator
+	nodeOperator        nodes.Operator
+	cronJobOperator     workloads.CronJobOperator
+	statefulSetOperator workloads.StatefulSetOperator
+	cleanupOperator     cleanup.Operator
+	releaseOperator     release.Operator
+	formOperator        workloads.FormOperator
+	applyOperator       apply.Operator
+	cloneOperator       clone.Operator
+	authorizer          authorizer.Authorizer
 }
 
-func newOperationHandler(client kubernetes.Interface) *operationHandler {
+func newOperationHandler(client kubernetes.Interface, config *rest.Config, ksFactory externalversions.SharedInformerFactory,
+	authorizer authorizer.Authorizer) (*operationHandler, error) {
+	applyOperator, err := apply.NewOperator(config, authorizer)
+	if err != nil {
+		return nil, err
+	}
 	return &operationHandler{
-		jobRunner: workloads.NewJobRunner(client),
+		jobRunner:           workloads.NewJobRunner(client),
+		batchOperator:       workloads.NewBatchOperator(client),
+		nodeOperator:        nodes.NewOperator(client),
+		cronJobOperator:     workloads.NewCronJobOperator(client),
+		statefulSetOperator: workloads.NewStatefulSetOperator(client),
+		cleanupOperator:     cleanup.NewOperator(client),
+		releaseOperator:     release.NewOperator(client),
+		formOperator:        workloads.NewFormOperator(client),
+		applyOperator:       applyOperator,
+		cloneOperator:       clone.NewOperator(client, ksFactory),
+		authorizer:          authorizer,
+	}, nil
+}
+
+// authorizeClone requires the requesting user to be allowed to create the
+// cloned workload kind and every dependency kind in the target namespace;
+// the RBAC filter on the route only covered the source namespace
+func (r *operationHandler) authorizeClone(req *restful.Request, kind string, cloneRequest clone.Request) error {
+	requestUser, ok := request.UserFrom(req.Request.Context())
+	if !ok {
+		return fmt.Errorf("unable to identify the requesting user")
+	}
+	for _, resource := range []string{kind, "configmaps", "secrets", "services"} {
+		apiGroup := ""
+		if resource == clone.KindDeployment || resource == clone.KindStatefulSet || resource == clone.KindDaemonSet {
+			apiGroup = "apps"
+		}
+		attributes := authorizer.AttributesRecord{
+			User:            requestUser,
+			Verb:            "create",
+			Cluster:         cloneRequest.TargetCluster,
+			Namespace:       cloneRequest.TargetNamespace,
+			APIGroup:        apiGroup,
+			Resource:        resource,
+			ResourceRequest: true,
+			ResourceScope:   request.NamespaceScope,
+		}
+		decision, reason, err := r.authorizer.Authorize(attributes)
+		if err != nil {
+			return err
+		}
+		if decision != authorizer.DecisionAllow {
+			return fmt.Errorf("not allowed to create %s in namespace %s: %s", resource, cloneRequest.TargetNamespace, reason)
+		}
+	}
+	return nil
+}
+
+func (r *operationHandler) handleWorkloadClone(req *restful.Request, response *restful.Response) {
+	var cloneRequest clone.Request
+	if err := req.ReadEntity(&cloneRequest); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	kind := req.PathParameter("kind")
+	if err := r.authorizeClone(req, kind, cloneRequest); err != nil {
+		api.HandleForbidden(response, nil, err)
+		return
+	}
+
+	result, err := r.cloneOperator.Clone(
+		req.PathParameter("namespace"),
+		kind,
+		req.PathParameter("workload"),
+		cloneRequest)
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(result)
+}
+
+func (r *operationHandler) handleWorkloadBatch(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+
+	var batchRequest workloads.BatchRequest
+	if err := request.ReadEntity(&batchRequest); err != nil {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, errors.Wrap(err))
+		return
+	}
+
+	result, err := r.batchOperator.Batch(namespace, batchRequest)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, errors.Wrap(err))
+		return
 	}
+	response.WriteAsJson(result)
 }
 
 func (r *operationHandler) handleJobReRun(request *restful.Request, response *restful.Response) {
@@ -62,3 +175,338 @@ func (r *operationHandler) handleJobReRun(request *restful.Request, response *re
 
 	response.WriteAsJson(errors.None)
 }
+
+func (r *operationHandler) handleCronJobTrigger(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	cronJob := request.PathParameter("cronjob")
+
+	job, err := r.cronJobOperator.TriggerNow(namespace, cronJob)
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(job)
+}
+
+func (r *operationHandler) handleCronJobPause(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	cronJob := request.PathParameter("cronjob")
+
+	if err := r.cronJobOperator.Pause(namespace, cronJob); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleCronJobResume(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	cronJob := request.PathParameter("cronjob")
+
+	if err := r.cronJobOperator.Resume(namespace, cronJob); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleCronJobHistory(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	cronJob := request.PathParameter("cronjob")
+
+	runs, err := r.cronJobOperator.History(namespace, cronJob)
+	if err != nil {
+		api.HandleNotFound(response, nil, err)
+		return
+	}
+	response.WriteAsJson(runs)
+}
+
+func (r *operationHandler) handleJobLogs(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	job := request.PathParameter("job")
+
+	logs, err := r.cronJobOperator.RunLogs(namespace, job)
+	if err != nil {
+		api.HandleNotFound(response, nil, err)
+		return
+	}
+	response.Write([]byte(logs))
+}
+
+func (r *operationHandler) handleStatefulSetPartition(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	statefulSet := request.PathParameter("statefulset")
+
+	var partition workloads.PartitionRequest
+	if err := request.ReadEntity(&partition); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	if err := r.statefulSetOperator.SetPartition(namespace, statefulSet, partition.Partition); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleStatefulSetRestartOrdinal(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	statefulSet := request.PathParameter("statefulset")
+
+	ordinal, err := strconv.ParseInt(request.PathParameter("ordinal"), 10, 32)
+	if err != nil {
+		api.HandleBadRequest(response, nil, fmt.Errorf("invalid ordinal: %v", err))
+		return
+	}
+	if err := r.statefulSetOperator.RestartOrdinal(namespace, statefulSet, int32(ordinal)); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleStatefulSetScale(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	statefulSet := request.PathParameter("statefulset")
+
+	var scale workloads.ScaleRequest
+	if err := request.ReadEntity(&scale); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	if err := r.statefulSetOperator.Scale(namespace, statefulSet, scale); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleStatefulSetProgress(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	statefulSet := request.PathParameter("statefulset")
+
+	progress, err := r.statefulSetOperator.Progress(namespace, statefulSet)
+	if err != nil {
+		api.HandleNotFound(response, nil, err)
+		return
+	}
+	response.WriteAsJson(progress)
+}
+
+func (r *operationHandler) handleNodeCordon(request *restful.Request, response *restful.Response) {
+	node := request.PathParameter("node")
+
+	if err := r.nodeOperator.Cordon(node); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleNodeUncordon(request *restful.Request, response *restful.Response) {
+	node := request.PathParameter("node")
+
+	if err := r.nodeOperator.Uncordon(node); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleNodeDrain(request *restful.Request, response *restful.Response) {
+	node := request.PathParameter("node")
+
+	var options nodes.DrainOptions
+	if request.Request.ContentLength != 0 {
+		if err := request.ReadEntity(&options); err != nil {
+			api.HandleBadRequest(response, nil, err)
+			return
+		}
+	}
+	if err := r.nodeOperator.Drain(node, options); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleNodeDrainStatus(request *restful.Request, response *restful.Response) {
+	node := request.PathParameter("node")
+
+	status, err := r.nodeOperator.DrainStatus(node)
+	if err != nil {
+		api.HandleNotFound(response, nil, err)
+		return
+	}
+	response.WriteAsJson(status)
+}
+
+func (r *operationHandler) handleScheduleNodeDrain(request *restful.Request, response *restful.Response) {
+	node := request.PathParameter("node")
+
+	var window nodes.ScheduledDrain
+	if err := request.ReadEntity(&window); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	if err := r.nodeOperator.ScheduleDrain(node, window.StartTime.Time, window.Options); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleCancelScheduledNodeDrain(request *restful.Request, response *restful.Response) {
+	node := request.PathParameter("node")
+
+	if err := r.nodeOperator.CancelScheduledDrain(node); err != nil {
+		api.HandleNotFound(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleListScheduledNodeDrains(request *restful.Request, response *restful.Response) {
+	response.WriteAsJson(r.nodeOperator.ListScheduledDrains())
+}
+
+func (r *operationHandler) handleOrphanReport(request *restful.Request, response *restful.Response) {
+	report, err := r.cleanupOperator.Report(request.QueryParameter("namespace"))
+	if err != nil {
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+	response.WriteAsJson(report)
+}
+
+func (r *operationHandler) handleOrphanScan(request *restful.Request, response *restful.Response) {
+	report, err := r.cleanupOperator.Scan()
+	if err != nil {
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+	response.WriteAsJson(report)
+}
+
+func (r *operationHandler) handleOrphanCleanup(request *restful.Request, response *restful.Response) {
+	if err := r.cleanupOperator.Cleanup(
+		request.PathParameter("namespace"),
+		request.PathParameter("kind"),
+		request.PathParameter("name")); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleOrphanVolumeCleanup(request *restful.Request, response *restful.Response) {
+	if err := r.cleanupOperator.Cleanup("", cleanup.KindPersistentVolume,
+		request.PathParameter("persistentvolume")); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleApplyWorkloadForm(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	dryRun := request.QueryParameter("dryRun") == "true"
+
+	var form workloads.WorkloadForm
+	if err := request.ReadEntity(&form); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	workload, err := r.formOperator.Apply(namespace, form, dryRun)
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(workload)
+}
+
+func (r *operationHandler) handleYAMLPreview(req *restful.Request, response *restful.Response) {
+	manifest, err := ioutil.ReadAll(req.Request.Body)
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	requestUser, _ := request.UserFrom(req.Request.Context())
+
+	preview, err := r.applyOperator.Preview(requestUser, manifest)
+	if err != nil {
+		if k8serr.IsForbidden(err) {
+			api.HandleForbidden(response, nil, err)
+			return
+		}
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(preview)
+}
+
+func (r *operationHandler) handleYAMLApply(req *restful.Request, response *restful.Response) {
+	manifest, err := ioutil.ReadAll(req.Request.Body)
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	requestUser, _ := request.UserFrom(req.Request.Context())
+
+	result, err := r.applyOperator.Apply(requestUser, manifest, req.QueryParameter("force") == "true")
+	if err != nil {
+		if k8serr.IsForbidden(err) {
+			api.HandleForbidden(response, nil, err)
+			return
+		}
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(result)
+}
+
+func (r *operationHandler) handleStartBlueGreen(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	service := request.PathParameter("service")
+
+	var blueGreenRequest release.BlueGreenRequest
+	if err := request.ReadEntity(&blueGreenRequest); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	status, err := r.releaseOperator.StartBlueGreen(namespace, service, blueGreenRequest)
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(status)
+}
+
+func (r *operationHandler) handleBlueGreenPromote(request *restful.Request, response *restful.Response) {
+	status, err := r.releaseOperator.Promote(request.PathParameter("namespace"), request.PathParameter("service"))
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(status)
+}
+
+func (r *operationHandler) handleBlueGreenAbort(request *restful.Request, response *restful.Response) {
+	if err := r.releaseOperator.Abort(request.PathParameter("namespace"), request.PathParameter("service")); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	response.WriteAsJson(errors.None)
+}
+
+func (r *operationHandler) handleBlueGreenStatus(request *restful.Request, response *restful.Response) {
+	status, err := r.releaseOperator.Status(request.PathParameter("namespace"), request.PathParameter("service"))
+	if err != nil {
+		api.HandleNotFound(response, nil, err)
+		return
+	}
+	response.WriteAsJson(status)
+}
@@ -18,10 +18,20 @@ package v1alpha2
 
 import (
 	"github.com/emicklei/go-restful"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/client/informers/externalversions"
+	"kubesphere.io/kubesphere/pkg/models/apply"
+	"kubesphere.io/kubesphere/pkg/models/cleanup"
+	"kubesphere.io/kubesphere/pkg/models/clone"
+	"kubesphere.io/kubesphere/pkg/models/nodes"
+	"kubesphere.io/kubesphere/pkg/models/release"
+	"kubesphere.io/kubesphere/pkg/models/workloads"
 	"kubesphere.io/kubesphere/pkg/server/errors"
 	"net/http"
 )
@@ -32,11 +42,15 @@ const (
 
 var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha2"}
 
-func AddToContainer(c *restful.Container, client kubernetes.Interface) error {
+func AddToContainer(c *restful.Container, client kubernetes.Interface, config *rest.Config, ksFactory externalversions.SharedInformerFactory,
+	authorizer authorizer.Authorizer) error {
 
 	webservice := runtime.NewWebService(GroupVersion)
 
-	handler := newOperationHandler(client)
+	handler, err := newOperationHandler(client, config, ksFactory, authorizer)
+	if err != nil {
+		return err
+	}
 
 	webservice.Route(webservice.POST("/namespaces/{namespace}/jobs/{job}").
 		To(handler.handleJobReRun).
@@ -48,6 +62,206 @@ func AddToContainer(c *restful.Container, client kubernetes.Interface) error {
 		Param(webservice.QueryParameter("resourceVersion", "version of job, rerun when the version matches").Required(true)).
 		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
 
+	webservice.Route(webservice.POST("/namespaces/{namespace}/workloads").
+		To(handler.handleWorkloadBatch).
+		Doc("Apply one action to many workloads of the namespace in a single request, with per-item results. Supports restart, scale, delete and label, and server-side dry-run.").
+		Param(webservice.PathParameter("namespace", "the name of the namespace the workloads run in")).
+		Reads(workloads.BatchRequest{}).
+		Returns(http.StatusOK, api.StatusOK, workloads.BatchResult{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/cronjobs/{cronjob}/trigger").
+		To(handler.handleCronJobTrigger).
+		Doc("Run the cron job immediately, outside its schedule. Returns the created job.").
+		Param(webservice.PathParameter("cronjob", "cron job name")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the cron job runs in")).
+		Returns(http.StatusOK, api.StatusOK, batchv1.Job{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/cronjobs/{cronjob}/pause").
+		To(handler.handleCronJobPause).
+		Doc("Suspend the cron job's schedule, jobs already running are not interrupted").
+		Param(webservice.PathParameter("cronjob", "cron job name")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the cron job runs in")).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/cronjobs/{cronjob}/resume").
+		To(handler.handleCronJobResume).
+		Doc("Resume the suspended cron job").
+		Param(webservice.PathParameter("cronjob", "cron job name")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the cron job runs in")).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/cronjobs/{cronjob}/history").
+		To(handler.handleCronJobHistory).
+		Doc("List the runs of the cron job with durations and results, newest first").
+		Param(webservice.PathParameter("cronjob", "cron job name")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the cron job runs in")).
+		Returns(http.StatusOK, api.StatusOK, []workloads.Run{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/jobs/{job}/logs").
+		To(handler.handleJobLogs).
+		Doc("Get the pod logs of one run of a job or cron job").
+		Param(webservice.PathParameter("job", "job name")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the job runs in")).
+		Returns(http.StatusOK, api.StatusOK, ""))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/statefulsets/{statefulset}/partition").
+		To(handler.handleStatefulSetPartition).
+		Doc("Move the rolling update partition of the stateful set. Ordinals greater than or equal to the partition roll to the updated revision, the rest stay put, enabling canary updates.").
+		Param(webservice.PathParameter("statefulset", "stateful set name")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the stateful set runs in")).
+		Reads(workloads.PartitionRequest{}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/statefulsets/{statefulset}/pods/{ordinal}/restart").
+		To(handler.handleStatefulSetRestartOrdinal).
+		Doc("Restart a single ordinal of the stateful set by deleting its pod, the controller recreates it in place").
+		Param(webservice.PathParameter("statefulset", "stateful set name")).
+		Param(webservice.PathParameter("ordinal", "ordinal of the pod to restart")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the stateful set runs in")).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/statefulsets/{statefulset}/scale").
+		To(handler.handleStatefulSetScale).
+		Doc("Scale the stateful set, optionally deleting the persistent volume claims of removed ordinals on scale-down").
+		Param(webservice.PathParameter("statefulset", "stateful set name")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the stateful set runs in")).
+		Reads(workloads.ScaleRequest{}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/statefulsets/{statefulset}/progress").
+		To(handler.handleStatefulSetProgress).
+		Doc("Get the per-ordinal rollout state of the stateful set, poll it during updates and restarts").
+		Param(webservice.PathParameter("statefulset", "stateful set name")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the stateful set runs in")).
+		Returns(http.StatusOK, api.StatusOK, workloads.UpdateProgress{}))
+
+	webservice.Route(webservice.POST("/nodes/{node}/cordon").
+		To(handler.handleNodeCordon).
+		Doc("Mark the node unschedulable").
+		Param(webservice.PathParameter("node", "node name")).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.POST("/nodes/{node}/uncordon").
+		To(handler.handleNodeUncordon).
+		Doc("Mark the node schedulable again").
+		Param(webservice.PathParameter("node", "node name")).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.POST("/nodes/{node}/drain").
+		To(handler.handleNodeDrain).
+		Doc("Cordon the node and evict its pods through the eviction API, respecting pod disruption budgets. The drain runs asynchronously, poll its progress with GET.").
+		Param(webservice.PathParameter("node", "node name")).
+		Reads(nodes.DrainOptions{}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/nodes/{node}/drain").
+		To(handler.handleNodeDrainStatus).
+		Doc("Get the progress of the latest drain of the node").
+		Param(webservice.PathParameter("node", "node name")).
+		Returns(http.StatusOK, api.StatusOK, nodes.DrainStatus{}))
+
+	webservice.Route(webservice.POST("/nodes/{node}/drain/schedule").
+		To(handler.handleScheduleNodeDrain).
+		Doc("Schedule a drain of the node to start at the given time, e.g. a maintenance window").
+		Param(webservice.PathParameter("node", "node name")).
+		Reads(nodes.ScheduledDrain{}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.DELETE("/nodes/{node}/drain/schedule").
+		To(handler.handleCancelScheduledNodeDrain).
+		Doc("Cancel a scheduled drain of the node").
+		Param(webservice.PathParameter("node", "node name")).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/scheduleddrains").
+		To(handler.handleListScheduledNodeDrains).
+		Doc("List drains waiting for their window").
+		Returns(http.StatusOK, api.StatusOK, []nodes.ScheduledDrain{}))
+
+	webservice.Route(webservice.GET("/orphanedresources").
+		To(handler.handleOrphanReport).
+		Doc("Report resources nothing references anymore: persistent volumes without claims, config maps and secrets unused by any pod, services without endpoints and node images no pod runs. The report is refreshed at most every few minutes, POST to scan immediately.").
+		Param(webservice.QueryParameter("namespace", "limit the report to one namespace")).
+		Returns(http.StatusOK, api.StatusOK, cleanup.Report{}))
+
+	webservice.Route(webservice.POST("/orphanedresources/scan").
+		To(handler.handleOrphanScan).
+		Doc("Run a fresh orphan scan and return the report").
+		Returns(http.StatusOK, api.StatusOK, cleanup.Report{}))
+
+	webservice.Route(webservice.DELETE("/namespaces/{namespace}/orphanedresources/{kind}/{name}").
+		To(handler.handleOrphanCleanup).
+		Doc("Delete an orphaned resource of the namespace. Guarded: the resource must appear in the latest report and is re-checked against the live cluster right before deletion.").
+		Param(webservice.PathParameter("namespace", "the name of the namespace the resource belongs to")).
+		Param(webservice.PathParameter("kind", "one of configmaps, secrets, services")).
+		Param(webservice.PathParameter("name", "name of the resource")).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.DELETE("/orphanedresources/persistentvolumes/{persistentvolume}").
+		To(handler.handleOrphanVolumeCleanup).
+		Doc("Delete an orphaned persistent volume, with the same guards as the namespaced cleanup").
+		Param(webservice.PathParameter("persistentvolume", "name of the persistent volume")).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/workloadforms").
+		To(handler.handleApplyWorkloadForm).
+		Doc("Create or update a workload from a form that fully models init containers, sidecar containers, lifecycle hooks and startup probes. The form is validated with actionable errors, with dryRun=true the rendered workload is returned without applying it.").
+		Param(webservice.PathParameter("namespace", "the name of the namespace the workload runs in")).
+		Param(webservice.QueryParameter("dryRun", "validate and render the form without applying it").DataType("boolean").DefaultValue("false")).
+		Reads(workloads.WorkloadForm{}).
+		Returns(http.StatusOK, api.StatusOK, nil))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/workloads/{kind}/{workload}/clone").
+		To(handler.handleWorkloadClone).
+		Doc("Copy the workload plus the config maps, secrets and services it depends on into another namespace or cluster. Names get the requested prefix and references inside the pod template are rewritten, useful for spinning up test copies of production services.").
+		Param(webservice.PathParameter("namespace", "the name of the namespace the workload runs in")).
+		Param(webservice.PathParameter("kind", "one of deployments, statefulsets, daemonsets")).
+		Param(webservice.PathParameter("workload", "workload name")).
+		Reads(clone.Request{}).
+		Returns(http.StatusOK, api.StatusOK, clone.Result{}))
+
+	webservice.Route(webservice.POST("/yaml/preview").
+		To(handler.handleYAMLPreview).
+		Consumes("application/yaml", "text/plain", restful.MIME_JSON).
+		Doc("Dry-run a server-side apply of the posted manifest and return the fields it would change, plus any conflicts with fields owned by other managers").
+		Returns(http.StatusOK, api.StatusOK, apply.Preview{}))
+
+	webservice.Route(webservice.POST("/yaml/apply").
+		To(handler.handleYAMLApply).
+		Consumes("application/yaml", "text/plain", restful.MIME_JSON).
+		Doc("Server-side apply the posted manifest under a per-user field manager, so edits never silently stomp controller-managed fields. On conflicts nothing is applied and the conflicting fields are returned, retry with force=true to take them over.").
+		Param(webservice.QueryParameter("force", "take over fields owned by other managers").DataType("boolean").DefaultValue("false")).
+		Returns(http.StatusOK, api.StatusOK, apply.Result{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/services/{service}/bluegreen").
+		To(handler.handleStartBlueGreen).
+		Doc("Start a blue/green release for the service without a service mesh: a preview service exposes the green deployment while live traffic stays on the current pods, then promote or abort").
+		Param(webservice.PathParameter("service", "the name of the stable service")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the service belongs to")).
+		Reads(release.BlueGreenRequest{}).
+		Returns(http.StatusOK, api.StatusOK, release.BlueGreenStatus{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/services/{service}/bluegreen").
+		To(handler.handleBlueGreenStatus).
+		Doc("Get the state of the blue/green release on the service, including the preview URL").
+		Param(webservice.PathParameter("service", "the name of the stable service")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the service belongs to")).
+		Returns(http.StatusOK, api.StatusOK, release.BlueGreenStatus{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/services/{service}/bluegreen/promote").
+		To(handler.handleBlueGreenPromote).
+		Doc("Promote the release: switch the stable service's selector to the green pods in one step and remove the preview").
+		Param(webservice.PathParameter("service", "the name of the stable service")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the service belongs to")).
+		Returns(http.StatusOK, api.StatusOK, release.BlueGreenStatus{}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/services/{service}/bluegreen/abort").
+		To(handler.handleBlueGreenAbort).
+		Doc("Abort the release: remove the preview service, live traffic is never touched").
+		Param(webservice.PathParameter("service", "the name of the stable service")).
+		Param(webservice.PathParameter("namespace", "the name of the namespace the service belongs to")).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
 	c.Add(webservice)
 
 	return nil
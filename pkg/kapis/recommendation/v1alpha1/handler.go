@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/models/recommendation"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+type handler struct {
+	operator recommendation.Operator
+}
+
+func newHandler(k8s kubernetes.Interface, factory informers.SharedInformerFactory, monitoringClient monitoring.Interface) *handler {
+	return &handler{
+		operator: recommendation.NewOperator(k8s, factory, monitoringClient),
+	}
+}
+
+func (h *handler) GetWorkloadRecommendation(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	kind := req.PathParameter("kind")
+	workload := req.PathParameter("workload")
+
+	result, err := h.operator.GetWorkloadRecommendation(namespace, kind, workload)
+	if err != nil {
+		h.handleError(resp, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) ApplyWorkloadRecommendation(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	kind := req.PathParameter("kind")
+	workload := req.PathParameter("workload")
+
+	result, err := h.operator.ApplyWorkloadRecommendation(namespace, kind, workload)
+	if err != nil {
+		h.handleError(resp, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) ListIdleDeployments(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	var window time.Duration
+	if value := req.QueryParameter("window"); value != "" {
+		var err error
+		if window, err = time.ParseDuration(value); err != nil {
+			api.HandleBadRequest(resp, nil, fmt.Errorf("invalid window %q", value))
+			return
+		}
+	}
+
+	result, err := h.operator.ListIdleDeployments(namespace, window)
+	if err != nil {
+		h.handleError(resp, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) ScaleToZero(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	deployment := req.PathParameter("deployment")
+
+	if err := h.operator.ScaleToZero(namespace, deployment); err != nil {
+		h.handleError(resp, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) Wake(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	deployment := req.PathParameter("deployment")
+
+	if err := h.operator.Wake(namespace, deployment); err != nil {
+		h.handleError(resp, err)
+		return
+	}
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleError(resp *restful.Response, err error) {
+	if k8serr.IsNotFound(err) {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	api.HandleInternalError(resp, nil, err)
+}
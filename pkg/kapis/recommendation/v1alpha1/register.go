@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/recommendation"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const groupName = "recommendation.kubesphere.io"
+
+var GroupVersion = schema.GroupVersion{Group: groupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, k8s kubernetes.Interface, factory informers.SharedInformerFactory, monitoringClient monitoring.Interface) error {
+	ws := runtime.NewWebService(GroupVersion)
+	h := newHandler(k8s, factory, monitoringClient)
+
+	ws.Route(ws.GET("/namespaces/{namespace}/{kind}/{workload}/recommendation").
+		To(h.GetWorkloadRecommendation).
+		Doc("Get suggested requests and limits for a workload, computed from its historical usage.").
+		Param(ws.PathParameter("namespace", "the name of the namespace")).
+		Param(ws.PathParameter("kind", "workload kind, one of deployments, statefulsets")).
+		Param(ws.PathParameter("workload", "the name of the workload")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.RecommendationTag}).
+		Returns(http.StatusOK, api.StatusOK, recommendation.WorkloadRecommendation{}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/{kind}/{workload}/recommendation/apply").
+		To(h.ApplyWorkloadRecommendation).
+		Doc("Apply the suggested requests and limits by patching the workload.").
+		Param(ws.PathParameter("namespace", "the name of the namespace")).
+		Param(ws.PathParameter("kind", "workload kind, one of deployments, statefulsets")).
+		Param(ws.PathParameter("workload", "the name of the workload")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.RecommendationTag}).
+		Returns(http.StatusOK, api.StatusOK, recommendation.WorkloadRecommendation{}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/idleworkloads").
+		To(h.ListIdleDeployments).
+		Doc("List deployments with near-zero cpu and network usage over the window, with estimated savings.").
+		Param(ws.PathParameter("namespace", "the name of the namespace")).
+		Param(ws.QueryParameter("window", "usage window to analyze, eg. 24h or 30m, defaults to 24h")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.RecommendationTag}).
+		Returns(http.StatusOK, api.StatusOK, []recommendation.IdleWorkload{}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/deployments/{deployment}/scaletozero").
+		To(h.ScaleToZero).
+		Doc("Scale the deployment down to zero replicas, keeping the previous replica count for wake.").
+		Param(ws.PathParameter("namespace", "the name of the namespace")).
+		Param(ws.PathParameter("deployment", "the name of the deployment")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.RecommendationTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/deployments/{deployment}/wake").
+		To(h.Wake).
+		Doc("Restore the replica count the deployment had before it was scaled to zero.").
+		Param(ws.PathParameter("namespace", "the name of the namespace")).
+		Param(ws.PathParameter("deployment", "the name of the deployment")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.RecommendationTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	c.Add(ws)
+	return nil
+}
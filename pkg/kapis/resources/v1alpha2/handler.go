@@ -30,6 +30,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/informers"
 	"kubesphere.io/kubesphere/pkg/models/components"
+	"kubesphere.io/kubesphere/pkg/models/configuration"
 	"kubesphere.io/kubesphere/pkg/models/git"
 	"kubesphere.io/kubesphere/pkg/models/kubeconfig"
 	"kubesphere.io/kubesphere/pkg/models/kubectl"
@@ -38,6 +39,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha2"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha2/resource"
 	"kubesphere.io/kubesphere/pkg/models/revisions"
+	"kubesphere.io/kubesphere/pkg/models/rollout"
 	"kubesphere.io/kubesphere/pkg/models/routers"
 	"kubesphere.io/kubesphere/pkg/server/errors"
 	"kubesphere.io/kubesphere/pkg/server/params"
@@ -53,6 +55,8 @@ type resourceHandler struct {
 	registryGetter      registries.RegistryGetter
 	kubeconfigOperator  kubeconfig.Interface
 	kubectlOperator     kubectl.Interface
+	configOperator      configuration.Operator
+	rolloutOperator     rollout.Operator
 }
 
 func newResourceHandler(k8sClient kubernetes.Interface, factory informers.InformerFactory, masterURL string) *resourceHandler {
@@ -65,10 +69,12 @@ func newResourceHandler(k8sClient kubernetes.Interface, factory informers.Inform
 		routerOperator:      routers.NewRouterOperator(k8sClient, factory.KubernetesSharedInformerFactory()),
 		gitVerifier:         git.NewGitVerifier(factory.KubernetesSharedInformerFactory()),
 		registryGetter:      registries.NewRegistryGetter(factory.KubernetesSharedInformerFactory()),
-		kubeconfigOperator:  kubeconfig.NewReadOnlyOperator(factory.KubernetesSharedInformerFactory().Core().V1().ConfigMaps(), masterURL),
+		kubeconfigOperator:  kubeconfig.NewOperator(k8sClient, factory.KubernetesSharedInformerFactory().Core().V1().ConfigMaps(), nil, masterURL),
 		kubectlOperator: kubectl.NewOperator(nil, factory.KubernetesSharedInformerFactory().Apps().V1().Deployments(),
 			factory.KubernetesSharedInformerFactory().Core().V1().Pods(),
 			factory.KubeSphereSharedInformerFactory().Iam().V1alpha2().Users(), ""),
+		configOperator:  configuration.NewOperator(k8sClient),
+		rolloutOperator: rollout.NewOperator(k8sClient),
 	}
 }
 
@@ -177,6 +183,60 @@ func (r *resourceHandler) handleGetDaemonSetRevision(request *restful.Request, r
 	response.WriteAsJson(result)
 }
 
+func (r *resourceHandler) handleGetDeploymentRollouts(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	deployment := request.PathParameter("deployment")
+
+	result, err := r.rolloutOperator.History(namespace, deployment)
+
+	if err != nil {
+		if k8serr.IsNotFound(err) {
+			response.WriteHeaderAndEntity(http.StatusNotFound, errors.Wrap(err))
+			return
+		}
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, errors.Wrap(err))
+		return
+	}
+
+	response.WriteAsJson(result)
+}
+
+func (r *resourceHandler) handleGetConfigMapConsumers(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	configmap := request.PathParameter("configmap")
+
+	result, err := r.configOperator.ConfigMapConsumers(namespace, configmap)
+
+	if err != nil {
+		if k8serr.IsNotFound(err) {
+			response.WriteHeaderAndEntity(http.StatusNotFound, errors.Wrap(err))
+			return
+		}
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, errors.Wrap(err))
+		return
+	}
+
+	response.WriteAsJson(result)
+}
+
+func (r *resourceHandler) handleGetSecretConsumers(request *restful.Request, response *restful.Response) {
+	namespace := request.PathParameter("namespace")
+	secret := request.PathParameter("secret")
+
+	result, err := r.configOperator.SecretConsumers(namespace, secret)
+
+	if err != nil {
+		if k8serr.IsNotFound(err) {
+			response.WriteHeaderAndEntity(http.StatusNotFound, errors.Wrap(err))
+			return
+		}
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, errors.Wrap(err))
+		return
+	}
+
+	response.WriteAsJson(result)
+}
+
 func (r *resourceHandler) handleGetDeploymentRevision(request *restful.Request, response *restful.Response) {
 	deploy := request.PathParameter("deployment")
 	namespace := request.PathParameter("namespace")
@@ -401,3 +461,22 @@ func (r *resourceHandler) GetKubeconfig(request *restful.Request, response *rest
 
 	response.Write([]byte(kubectlConfig))
 }
+
+// RefreshKubeconfig rotates the user's client certificate, the csr-controller
+// swaps the fresh key and certificate into the kubeconfig once it has been
+// signed, clients re-download the kubeconfig afterwards
+func (r *resourceHandler) RefreshKubeconfig(request *restful.Request, response *restful.Response) {
+	user := request.PathParameter("user")
+
+	if err := r.kubeconfigOperator.RotateKubeConfig(user); err != nil {
+		klog.Error(err)
+		if k8serr.IsNotFound(err) {
+			response.WriteHeaderAndJson(http.StatusNotFound, errors.Wrap(err), restful.MIME_JSON)
+		} else {
+			response.WriteHeaderAndJson(http.StatusInternalServerError, errors.Wrap(err), restful.MIME_JSON)
+		}
+		return
+	}
+
+	response.WriteAsJson(errors.None)
+}
@@ -31,8 +31,10 @@ import (
 	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/informers"
 	"kubesphere.io/kubesphere/pkg/models"
+	configurationmodel "kubesphere.io/kubesphere/pkg/models/configuration"
 	gitmodel "kubesphere.io/kubesphere/pkg/models/git"
 	registriesmodel "kubesphere.io/kubesphere/pkg/models/registries"
+	rolloutmodel "kubesphere.io/kubesphere/pkg/models/rollout"
 	"kubesphere.io/kubesphere/pkg/server/errors"
 	"kubesphere.io/kubesphere/pkg/server/params"
 )
@@ -98,6 +100,13 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, factor
 		Returns(http.StatusOK, api.StatusOK, "").
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ToolboxTag}))
 
+	webservice.Route(webservice.POST("/users/{user}/kubeconfig/refresh").
+		To(handler.RefreshKubeconfig).
+		Doc("rotate users' client certificate, the kubeconfig carries the new certificate once it has been signed").
+		Param(webservice.PathParameter("user", "username")).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ToolboxTag}))
+
 	webservice.Route(webservice.GET("/components").
 		To(handler.handleGetComponents).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ComponentStatusTag}).
@@ -186,6 +195,27 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, factor
 		Param(webservice.PathParameter("namespace", "the namespace of the statefulset")).
 		Param(webservice.PathParameter("revision", "the revision of the statefulset")).
 		Returns(http.StatusOK, api.StatusOK, appsv1.StatefulSet{}))
+	webservice.Route(webservice.GET("/namespaces/{namespace}/deployments/{deployment}/rollouts").
+		To(handler.handleGetDeploymentRollouts).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceResourcesTag}).
+		Doc("List the recorded rollouts of the deployment with durations, restart counts and error rate deltas, oldest first").
+		Param(webservice.PathParameter("deployment", "the name of deployment")).
+		Param(webservice.PathParameter("namespace", "the namespace of the deployment")).
+		Returns(http.StatusOK, api.StatusOK, []rolloutmodel.Record{}))
+	webservice.Route(webservice.GET("/namespaces/{namespace}/configmaps/{configmap}/consumers").
+		To(handler.handleGetConfigMapConsumers).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceResourcesTag}).
+		Doc("List the workloads consuming the configmap, with its tracked revision and which consumers restart automatically on changes").
+		Param(webservice.PathParameter("configmap", "the name of the configmap")).
+		Param(webservice.PathParameter("namespace", "the namespace of the configmap")).
+		Returns(http.StatusOK, api.StatusOK, configurationmodel.ConsumerReport{}))
+	webservice.Route(webservice.GET("/namespaces/{namespace}/secrets/{secret}/consumers").
+		To(handler.handleGetSecretConsumers).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceResourcesTag}).
+		Doc("List the workloads consuming the secret, with its tracked revision and which consumers restart automatically on changes").
+		Param(webservice.PathParameter("secret", "the name of the secret")).
+		Param(webservice.PathParameter("namespace", "the namespace of the secret")).
+		Returns(http.StatusOK, api.StatusOK, configurationmodel.ConsumerReport{}))
 
 	webservice.Route(webservice.GET("/namespaces/{namespace}/router").
 		To(handler.handleGetRouter).
@@ -24,8 +24,10 @@ import (
 	"kubesphere.io/kubesphere/pkg/models/components"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha2"
 	resourcev1alpha2 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha2/resource"
+	modelsv1alpha3 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3"
 	resourcev1alpha3 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/resource"
 	"kubesphere.io/kubesphere/pkg/server/params"
+	"strconv"
 	"strings"
 )
 
@@ -79,12 +81,18 @@ func (h *Handler) handleGetResources(request *restful.Request, response *restful
 
 // handleListResources retrieves resources
 func (h *Handler) handleListResources(request *restful.Request, response *restful.Response) {
+	if watch, _ := strconv.ParseBool(request.QueryParameter("watch")); watch {
+		h.handleWatchResources(request, response)
+		return
+	}
+
 	query := query.ParseQueryParameter(request)
 	resourceType := request.PathParameter("resources")
 	namespace := request.PathParameter("namespace")
 
 	result, err := h.resourceGetterV1alpha3.List(resourceType, namespace, query)
 	if err == nil {
+		modelsv1alpha3.ProjectFields(result, query.Fields)
 		response.WriteEntity(result)
 		return
 	}
@@ -106,6 +114,7 @@ func (h *Handler) handleListResources(request *restful.Request, response *restfu
 		api.HandleError(response, request, err)
 		return
 	}
+	modelsv1alpha3.ProjectFields(result, query.Fields)
 	response.WriteEntity(result)
 }
 
@@ -62,8 +62,12 @@ func AddToContainer(c *restful.Container, informerFactory informers.InformerFact
 		Param(webservice.QueryParameter(query.ParameterName, "name used to do filtering").Required(false)).
 		Param(webservice.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
 		Param(webservice.QueryParameter(query.ParameterLimit, "limit").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterCursor, "cursor of the page to return, taken from the nextCursor field of a previous response, supersedes page").Required(false)).
 		Param(webservice.QueryParameter(query.ParameterAscending, "sort parameters, e.g. reverse=true").Required(false).DefaultValue("ascending=false")).
-		Param(webservice.QueryParameter(query.ParameterOrderBy, "sort parameters, e.g. orderBy=createTime")).
+		Param(webservice.QueryParameter(query.ParameterOrderBy, "sort parameters, e.g. orderBy=createTime, dot paths like orderBy=status.phase sort on any column")).
+		Param(webservice.QueryParameter(query.ParameterFields, "comma separated dot paths to project result items to, e.g. fields=metadata.name,status.phase").Required(false)).
+		Param(webservice.QueryParameter("watch", "watch=true streams incremental updates of the list as server-sent events instead of returning a snapshot").Required(false)).
+		Param(webservice.QueryParameter("resourceVersion", "resume a watch from this resourceVersion, only items changed since it are replayed").Required(false)).
 		Returns(http.StatusOK, ok, api.ListResult{}))
 
 	webservice.Route(webservice.GET("/{resources}/{name}").
@@ -83,8 +87,12 @@ func AddToContainer(c *restful.Container, informerFactory informers.InformerFact
 		Param(webservice.QueryParameter(query.ParameterName, "name used to do filtering").Required(false)).
 		Param(webservice.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
 		Param(webservice.QueryParameter(query.ParameterLimit, "limit").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterCursor, "cursor of the page to return, taken from the nextCursor field of a previous response, supersedes page").Required(false)).
 		Param(webservice.QueryParameter(query.ParameterAscending, "sort parameters, e.g. reverse=true").Required(false).DefaultValue("ascending=false")).
-		Param(webservice.QueryParameter(query.ParameterOrderBy, "sort parameters, e.g. orderBy=createTime")).
+		Param(webservice.QueryParameter(query.ParameterOrderBy, "sort parameters, e.g. orderBy=createTime, dot paths like orderBy=status.phase sort on any column")).
+		Param(webservice.QueryParameter(query.ParameterFields, "comma separated dot paths to project result items to, e.g. fields=metadata.name,status.phase").Required(false)).
+		Param(webservice.QueryParameter("watch", "watch=true streams incremental updates of the list as server-sent events instead of returning a snapshot").Required(false)).
+		Param(webservice.QueryParameter("resourceVersion", "resume a watch from this resourceVersion, only items changed since it are replayed").Required(false)).
 		Returns(http.StatusOK, ok, api.ListResult{}))
 
 	webservice.Route(webservice.GET("/namespaces/{namespace}/{resources}/{name}").
@@ -0,0 +1,145 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/klog"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	resourcev1alpha3 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/resource"
+)
+
+// interval the informer cache is re-read at while a watch is open, cache
+// reads are cheap so this is much tighter than console polling used to be
+const watchInterval = 2 * time.Second
+
+const (
+	eventAdded    = "ADDED"
+	eventModified = "MODIFIED"
+	eventDeleted  = "DELETED"
+)
+
+type watchEvent struct {
+	Type   string      `json:"type"`
+	Object interface{} `json:"object"`
+}
+
+// handleWatchResources streams incremental updates of the requested resource
+// list as server-sent events. An initial sync of ADDED events is sent first,
+// unless the client resumes with the resourceVersion parameter, in which case
+// only items changed since that version are sent. Deletions that happened
+// while the client was disconnected cannot be replayed.
+func (h *Handler) handleWatchResources(request *restful.Request, response *restful.Response) {
+	q := query.ParseQueryParameter(request)
+	q.Pagination = query.NoPagination
+	resourceType := request.PathParameter("resources")
+	namespace := request.PathParameter("namespace")
+	sinceVersion, _ := strconv.ParseUint(request.QueryParameter("resourceVersion"), 10, 64)
+
+	flusher, ok := response.ResponseWriter.(http.Flusher)
+	if !ok {
+		api.HandleInternalError(response, request, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	// fail before switching to the event stream if the resource type is unknown
+	if _, err := h.resourceGetterV1alpha3.List(resourceType, namespace, q); err != nil {
+		if err == resourcev1alpha3.ErrResourceNotSupported {
+			api.HandleNotFound(response, request, err)
+			return
+		}
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	response.Header().Set("Content-Type", "text/event-stream")
+	response.Header().Set("Cache-Control", "no-cache")
+	response.Header().Set("Connection", "keep-alive")
+	response.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := request.Request.Context()
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	known := make(map[string]uint64)
+	first := true
+	for {
+		result, err := h.resourceGetterV1alpha3.List(resourceType, namespace, q)
+		if err != nil {
+			klog.Error(err)
+			return
+		}
+
+		seen := make(map[string]uint64, len(result.Items))
+		for _, item := range result.Items {
+			accessor, err := meta.Accessor(item)
+			if err != nil {
+				continue
+			}
+			key := accessor.GetNamespace() + "/" + accessor.GetName()
+			version, _ := strconv.ParseUint(accessor.GetResourceVersion(), 10, 64)
+			seen[key] = version
+
+			lastVersion, existed := known[key]
+			switch {
+			case first && sinceVersion > 0:
+				if version > sinceVersion {
+					writeEvent(response, flusher, watchEvent{Type: eventModified, Object: item})
+				}
+			case !existed:
+				writeEvent(response, flusher, watchEvent{Type: eventAdded, Object: item})
+			case version != lastVersion:
+				writeEvent(response, flusher, watchEvent{Type: eventModified, Object: item})
+			}
+		}
+
+		for key := range known {
+			if _, ok := seen[key]; !ok {
+				writeEvent(response, flusher, watchEvent{Type: eventDeleted, Object: key})
+			}
+		}
+
+		known = seen
+		first = false
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func writeEvent(response *restful.Response, flusher http.Flusher, event watchEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		klog.Error(err)
+		return
+	}
+	fmt.Fprintf(response, "data: %s\n\n", data)
+	flusher.Flush()
+}
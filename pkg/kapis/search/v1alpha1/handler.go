@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/models/search"
+)
+
+const defaultLimit = 50
+
+type handler struct {
+	search search.Interface
+}
+
+func newHandler(search search.Interface) *handler {
+	return &handler{search: search}
+}
+
+func (h *handler) handleSearch(req *restful.Request, response *restful.Response) {
+	query := req.QueryParameter("q")
+	if query == "" {
+		api.HandleBadRequest(response, nil, fmt.Errorf("the q parameter is required"))
+		return
+	}
+
+	limit := defaultLimit
+	if raw := req.QueryParameter("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			api.HandleBadRequest(response, nil, fmt.Errorf("invalid limit %s", raw))
+			return
+		}
+		limit = parsed
+	}
+
+	user, ok := request.UserFrom(req.Request.Context())
+	if !ok {
+		api.HandleBadRequest(response, nil, fmt.Errorf("search needs an authenticated user"))
+		return
+	}
+
+	results, err := h.search.Search(user, query, limit)
+	if err != nil {
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(results)
+}
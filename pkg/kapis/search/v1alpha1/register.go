@@ -0,0 +1,56 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/search"
+)
+
+const (
+	GroupName = "search.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, factory informers.SharedInformerFactory, authorizer authorizer.Authorizer) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(search.NewSearch(factory, authorizer))
+
+	webservice.Route(webservice.GET("/search").
+		To(handler.handleSearch).
+		Param(webservice.QueryParameter("q", "the search query, e.g. a resource name, label or annotation fragment")).
+		Param(webservice.QueryParameter("limit", "maximum number of results to return, defaults to 50")).
+		Doc("full-text search over resource names, labels and annotations, results are restricted to namespaces the user can list").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SearchTag}).
+		Returns(http.StatusOK, api.StatusOK, search.Results{}))
+
+	c.Add(webservice)
+
+	return nil
+}
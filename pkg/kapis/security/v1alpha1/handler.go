@@ -0,0 +1,205 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/models/falco"
+	"kubesphere.io/kubesphere/pkg/models/gatekeeper"
+	"kubesphere.io/kubesphere/pkg/models/podsecurity"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+type handler struct {
+	podSecurity podsecurity.Operator
+	gatekeeper  gatekeeper.Operator
+	falco       falco.Operator
+}
+
+func newHandler(client kubernetes.Interface, dynamicClient dynamic.Interface, informers informers.SharedInformerFactory) *handler {
+	return &handler{
+		podSecurity: podsecurity.NewOperator(client, informers),
+		gatekeeper:  gatekeeper.NewOperator(dynamicClient),
+		falco:       falco.NewOperator(client),
+	}
+}
+
+func (h *handler) handleIngestFalcoAlert(req *restful.Request, resp *restful.Response) {
+	var alert falco.Alert
+	if err := req.ReadEntity(&alert); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	if err := h.falco.Ingest(&alert); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleListSecurityEvents(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	minPriority := req.QueryParameter("priority")
+
+	events, err := h.falco.ListSecurityEvents(namespace, minPriority)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteAsJson(events)
+}
+
+func (h *handler) handleListConstraintTemplates(req *restful.Request, resp *restful.Response) {
+	templates, err := h.gatekeeper.ListTemplates()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteAsJson(templates)
+}
+
+func (h *handler) handleListConstraints(req *restful.Request, resp *restful.Response) {
+	constraints, err := h.gatekeeper.ListConstraints()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteAsJson(constraints)
+}
+
+func (h *handler) handleEnableConstraint(req *restful.Request, resp *restful.Response) {
+	var constraint gatekeeper.Constraint
+	if err := req.ReadEntity(&constraint); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	if err := h.gatekeeper.EnableConstraint(&constraint); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleDisableConstraint(req *restful.Request, resp *restful.Response) {
+	kind := req.PathParameter("kind")
+	name := req.PathParameter("constraint")
+
+	if err := h.gatekeeper.DisableConstraint(kind, name); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleListViolations(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	violations, err := h.gatekeeper.ListViolations(namespace)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteAsJson(violations)
+}
+
+func (h *handler) handleGetPodSecurity(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	levels, err := h.podSecurity.GetLevels(namespace)
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+
+	resp.WriteAsJson(levels)
+}
+
+func (h *handler) handleSetPodSecurity(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	var levels podsecurity.Levels
+	if err := req.ReadEntity(&levels); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	if err := h.podSecurity.SetLevels(namespace, &levels); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handleListWorkspacePodSecurity(req *restful.Request, resp *restful.Response) {
+	workspace := req.PathParameter("workspace")
+
+	levels, err := h.podSecurity.ListWorkspaceLevels(workspace)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+
+	resp.WriteAsJson(levels)
+}
+
+func (h *handler) handleSetWorkspacePodSecurity(req *restful.Request, resp *restful.Response) {
+	workspace := req.PathParameter("workspace")
+
+	var levels podsecurity.Levels
+	if err := req.ReadEntity(&levels); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	if err := h.podSecurity.SetWorkspaceLevels(workspace, &levels); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	resp.WriteAsJson(errors.None)
+}
+
+func (h *handler) handlePodSecurityDryRun(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	level := req.QueryParameter("level")
+	if level == "" {
+		level = podsecurity.LevelBaseline
+	}
+
+	report, err := h.podSecurity.DryRun(namespace, level)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	resp.WriteAsJson(report)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/falco"
+	"kubesphere.io/kubesphere/pkg/models/gatekeeper"
+	"kubesphere.io/kubesphere/pkg/models/podsecurity"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+)
+
+const (
+	GroupName = "security.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client kubernetes.Interface, dynamicClient dynamic.Interface, informers informers.SharedInformerFactory) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(client, dynamicClient, informers)
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/podsecurity").
+		To(handler.handleGetPodSecurity).
+		Doc("get the pod security standards levels of the namespace").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, podsecurity.Levels{}))
+
+	webservice.Route(webservice.PUT("/namespaces/{namespace}/podsecurity").
+		To(handler.handleSetPodSecurity).
+		Doc("set the pod security standards levels of the namespace, an empty level removes the mode").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Reads(podsecurity.Levels{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/workspaces/{workspace}/podsecurity").
+		To(handler.handleListWorkspacePodSecurity).
+		Doc("list the pod security standards levels of every namespace in the workspace").
+		Param(webservice.PathParameter("workspace", "name of the workspace")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, []podsecurity.NamespaceLevels{}))
+
+	webservice.Route(webservice.PUT("/workspaces/{workspace}/podsecurity").
+		To(handler.handleSetWorkspacePodSecurity).
+		Doc("set the pod security standards levels of every namespace in the workspace").
+		Param(webservice.PathParameter("workspace", "name of the workspace")).
+		Reads(podsecurity.Levels{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/podsecurity/review").
+		To(handler.handlePodSecurityDryRun).
+		Doc("dry run, list workloads that would be rejected if the level were enforced").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.QueryParameter("level", "pod security level to evaluate, baseline or restricted, defaults to baseline")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, podsecurity.Report{}))
+
+	webservice.Route(webservice.GET("/constrainttemplates").
+		To(handler.handleListConstraintTemplates).
+		Doc("list gatekeeper constraint templates installed in the cluster").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, []gatekeeper.Template{}))
+
+	webservice.Route(webservice.GET("/constraints").
+		To(handler.handleListConstraints).
+		Doc("list constraints enabled from gatekeeper constraint templates").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, []gatekeeper.Constraint{}))
+
+	webservice.Route(webservice.POST("/constraints").
+		To(handler.handleEnableConstraint).
+		Doc("enable a constraint from a gatekeeper constraint template").
+		Reads(gatekeeper.Constraint{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.DELETE("/constraints/{kind}/{constraint}").
+		To(handler.handleDisableConstraint).
+		Doc("disable a constraint").
+		Param(webservice.PathParameter("kind", "kind of the constraint, defined by its template")).
+		Param(webservice.PathParameter("constraint", "name of the constraint")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/violations").
+		To(handler.handleListViolations).
+		Doc("list violations found by the gatekeeper audit across all namespaces").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, []gatekeeper.Violation{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/violations").
+		To(handler.handleListViolations).
+		Doc("list violations found by the gatekeeper audit in the namespace").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.PodSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, []gatekeeper.Violation{}))
+
+	webservice.Route(webservice.POST("/falco/alerts").
+		To(handler.handleIngestFalcoAlert).
+		Doc("ingestion endpoint for the Falco http_output, stores the alert as an event in the namespace of the offending container").
+		Reads(falco.Alert{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.RuntimeSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, errors.Error{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/securityevents").
+		To(handler.handleListSecurityEvents).
+		Doc("list the runtime security alerts of the namespace, newest first").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.QueryParameter("priority", "only return alerts at or above this Falco priority, e.g. warning")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.RuntimeSecurityTag}).
+		Returns(http.StatusOK, api.StatusOK, []falco.SecurityEvent{}))
+
+	c.Add(webservice)
+
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/informers"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/models/storage"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+type handler struct {
+	analytics storage.AnalyticsInterface
+}
+
+func newHandler(factory informers.SharedInformerFactory, monitoringClient monitoring.Interface) *handler {
+	return &handler{
+		analytics: storage.NewAnalyticsOperator(factory, monitoringClient),
+	}
+}
+
+func (h *handler) ListStorageClassCapacities(req *restful.Request, resp *restful.Response) {
+	result, err := h.analytics.ListStorageClassCapacities()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) ListOrphanedPersistentVolumes(req *restful.Request, resp *restful.Response) {
+	result, err := h.analytics.ListOrphanedPersistentVolumes()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
+
+func (h *handler) GetPersistentVolumeClaimPerformance(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	pvc := req.PathParameter("persistentvolumeclaim")
+
+	result, err := h.analytics.GetPersistentVolumeClaimPerformance(namespace, pvc)
+	if err != nil {
+		if k8serr.IsNotFound(err) {
+			api.HandleNotFound(resp, nil, err)
+			return
+		}
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteEntity(result)
+}
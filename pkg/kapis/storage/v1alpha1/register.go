@@ -0,0 +1,64 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/storage"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const groupName = "storage.kubesphere.io"
+
+var GroupVersion = schema.GroupVersion{Group: groupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, factory informers.SharedInformerFactory, monitoringClient monitoring.Interface) error {
+	ws := runtime.NewWebService(GroupVersion)
+	h := newHandler(factory, monitoringClient)
+
+	ws.Route(ws.GET("/storageclasscapacities").
+		To(h.ListStorageClassCapacities).
+		Doc("List provisioned and used capacity aggregated by storage class.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.StorageAnalyticsTag}).
+		Returns(http.StatusOK, api.StatusOK, []storage.StorageClassCapacity{}))
+
+	ws.Route(ws.GET("/persistentvolumes/orphaned").
+		To(h.ListOrphanedPersistentVolumes).
+		Doc("List persistent volumes no longer bound to an existing claim.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.StorageAnalyticsTag}).
+		Returns(http.StatusOK, api.StatusOK, []storage.OrphanedPersistentVolume{}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/persistentvolumeclaims/{persistentvolumeclaim}/performance").
+		To(h.GetPersistentVolumeClaimPerformance).
+		Doc("Get the latest IOPS, throughput and latency of a volume from csi metrics.").
+		Param(ws.PathParameter("namespace", "the name of the namespace")).
+		Param(ws.PathParameter("persistentvolumeclaim", "the name of the PVC")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.StorageAnalyticsTag}).
+		Returns(http.StatusOK, api.StatusOK, storage.PersistentVolumeClaimPerformance{}))
+
+	c.Add(ws)
+	return nil
+}
@@ -19,10 +19,13 @@ package v1alpha2
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/emicklei/go-restful"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
@@ -32,12 +35,17 @@ import (
 	loggingv1alpha2 "kubesphere.io/kubesphere/pkg/api/logging/v1alpha2"
 	quotav1alpha2 "kubesphere.io/kubesphere/pkg/apis/quota/v1alpha2"
 	tenantv1alpha2 "kubesphere.io/kubesphere/pkg/apis/tenant/v1alpha2"
+	authoptions "kubesphere.io/kubesphere/pkg/apiserver/authentication/options"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
 	"kubesphere.io/kubesphere/pkg/apiserver/request"
 	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/informers"
 	"kubesphere.io/kubesphere/pkg/models/iam/am"
+	loggingmodels "kubesphere.io/kubesphere/pkg/models/logging"
+	"kubesphere.io/kubesphere/pkg/models/quotas"
 	resourcev1alpha3 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/resource"
 	"kubesphere.io/kubesphere/pkg/models/tenant"
 	servererr "kubesphere.io/kubesphere/pkg/server/errors"
@@ -45,21 +53,237 @@ import (
 	"kubesphere.io/kubesphere/pkg/simple/client/events"
 	"kubesphere.io/kubesphere/pkg/simple/client/logging"
 	monitoringclient "kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type tenantHandler struct {
-	tenant tenant.Interface
+	tenant           tenant.Interface
+	quotaForecaster  quotas.QuotaForecaster
+	throttlePolicies loggingmodels.ThrottlePolicyOperator
+	logSidecar       loggingmodels.LogSidecarOperator
+	maskingPolicies  loggingmodels.MaskingPolicyOperator
+	indexStrategies  loggingmodels.IndexStrategyOperator
+	dashboards       loggingmodels.DashboardsOperator
+	client           runtimeclient.Client
 }
 
 func newTenantHandler(factory informers.InformerFactory, k8sclient kubernetes.Interface, ksclient kubesphere.Interface,
 	evtsClient events.Client, loggingClient logging.Client, auditingclient auditing.Client,
 	am am.AccessManagementInterface, authorizer authorizer.Authorizer,
 	monitoringclient monitoringclient.Interface,
-	resourceGetter *resourcev1alpha3.ResourceGetter) *tenantHandler {
+	resourceGetter *resourcev1alpha3.ResourceGetter,
+	client runtimeclient.Client,
+	authOptions *authoptions.AuthenticationOptions, loggingOptions *logging.Options) *tenantHandler {
+
+	var dashboards loggingmodels.DashboardsOperator
+	if authOptions != nil {
+		issuer := token.NewTokenIssuer(authOptions.JwtSecret, authOptions.MaximumClockSkew)
+		dashboards = loggingmodels.NewDashboardsOperator(k8sclient, issuer, authorizer, loggingOptions)
+	}
 
 	return &tenantHandler{
-		tenant: tenant.New(factory, k8sclient, ksclient, evtsClient, loggingClient, auditingclient, am, authorizer, monitoringclient, resourceGetter),
+		tenant:           tenant.New(factory, k8sclient, ksclient, evtsClient, loggingClient, auditingclient, am, authorizer, monitoringclient, resourceGetter),
+		quotaForecaster:  quotas.NewQuotaForecaster(ksclient, monitoringclient),
+		throttlePolicies: loggingmodels.NewThrottlePolicyOperator(k8sclient),
+		logSidecar:       loggingmodels.NewLogSidecarOperator(k8sclient),
+		maskingPolicies:  loggingmodels.NewMaskingPolicyOperator(k8sclient),
+		indexStrategies:  loggingmodels.NewIndexStrategyOperator(k8sclient),
+		dashboards:       dashboards,
+		client:           client,
+	}
+}
+
+// GetLogSidecarConfig returns the namespace's logsidecar-injector
+// configuration.
+func (h *tenantHandler) GetLogSidecarConfig(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	config, err := h.logSidecar.GetLogSidecarConfig(namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			api.HandleNotFound(resp, nil, err)
+			return
+		}
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(config)
+}
+
+// SaveLogSidecarConfig replaces the namespace's logsidecar-injector
+// configuration, validating the path groups and multiline parsers.
+func (h *tenantHandler) SaveLogSidecarConfig(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	var config loggingmodels.LogSidecarConfig
+	if err := req.ReadEntity(&config); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	saved, err := h.logSidecar.SaveLogSidecarConfig(namespace, &config)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			api.HandleNotFound(resp, nil, err)
+			return
+		}
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(saved)
+}
+
+// ListLogThrottlePolicies lists the per-namespace log throttling and
+// sampling policies.
+func (h *tenantHandler) ListLogThrottlePolicies(req *restful.Request, resp *restful.Response) {
+	policies, err := h.throttlePolicies.ListThrottlePolicies()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(policies)
+}
+
+// SaveLogThrottlePolicies replaces the per-namespace log throttling and
+// sampling policies, the controller rolls them into the agent config.
+func (h *tenantHandler) SaveLogThrottlePolicies(req *restful.Request, resp *restful.Response) {
+	var policies []loggingmodels.ThrottlePolicy
+	if err := req.ReadEntity(&policies); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	saved, err := h.throttlePolicies.SaveThrottlePolicies(policies)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(saved)
+}
+
+// ListLogMaskingPolicies lists the per-namespace log masking policies.
+func (h *tenantHandler) ListLogMaskingPolicies(req *restful.Request, resp *restful.Response) {
+	policies, err := h.maskingPolicies.ListMaskingPolicies()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(policies)
+}
+
+// SaveLogMaskingPolicies replaces the per-namespace log masking policies,
+// recording who changed them; the controller rolls the agent-stage rules
+// into the agent config, query-stage rules apply to query results.
+func (h *tenantHandler) SaveLogMaskingPolicies(req *restful.Request, resp *restful.Response) {
+	user, ok := request.UserFrom(req.Request.Context())
+	if !ok {
+		err := fmt.Errorf("cannot obtain user info")
+		klog.Errorln(err)
+		api.HandleForbidden(resp, req, err)
+		return
+	}
+
+	var policies []loggingmodels.MaskingPolicy
+	if err := req.ReadEntity(&policies); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	saved, err := h.maskingPolicies.SaveMaskingPolicies(policies, user.GetName())
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(saved)
+}
+
+// ListLogIndexStrategies lists the per-workspace log index strategies.
+func (h *tenantHandler) ListLogIndexStrategies(req *restful.Request, resp *restful.Response) {
+	strategies, err := h.indexStrategies.ListIndexStrategies()
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
 	}
+	resp.WriteAsJson(strategies)
+}
+
+// SaveLogIndexStrategies replaces the per-workspace log index strategies,
+// the controller rolls them into the agent config and drives the quota
+// rollovers.
+func (h *tenantHandler) SaveLogIndexStrategies(req *restful.Request, resp *restful.Response) {
+	var strategies []loggingmodels.WorkspaceIndexStrategy
+	if err := req.ReadEntity(&strategies); err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+
+	saved, err := h.indexStrategies.SaveIndexStrategies(strategies)
+	if err != nil {
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(saved)
+}
+
+// CreateLogDashboardLink issues a deep link into Kibana or OpenSearch
+// Dashboards under the user's own identity, scoped to the indices of the
+// namespaces the user may view logs in.
+func (h *tenantHandler) CreateLogDashboardLink(req *restful.Request, resp *restful.Response) {
+	user, ok := request.UserFrom(req.Request.Context())
+	if !ok {
+		err := fmt.Errorf("cannot obtain user info")
+		klog.Errorln(err)
+		api.HandleForbidden(resp, req, err)
+		return
+	}
+
+	if h.dashboards == nil {
+		api.HandleInternalError(resp, nil, fmt.Errorf("log dashboard deep links are not configured"))
+		return
+	}
+
+	link, err := h.dashboards.CreateDeepLink(user, req.QueryParameter("namespace"))
+	if err != nil {
+		if errors.IsForbidden(err) {
+			api.HandleForbidden(resp, req, err)
+			return
+		}
+		api.HandleBadRequest(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(link)
+}
+
+// logMasker compiles the query-stage masking rules; without policies the
+// logs pass through untouched.
+func (h *tenantHandler) logMasker() *loggingmodels.Masker {
+	policies, err := h.maskingPolicies.ListMaskingPolicies()
+	if err != nil {
+		klog.Errorf("Failed to load the log masking policies: %v", err)
+		policies = nil
+	}
+	return loggingmodels.NewMasker(policies)
+}
+
+// ListQuotaHotspots lists the workspaces projected to hit a resource quota
+// within the horizon, soonest first.
+func (h *tenantHandler) ListQuotaHotspots(req *restful.Request, resp *restful.Response) {
+	horizon := quotas.DefaultForecastHorizon
+	if value := req.QueryParameter("horizon_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days <= 0 {
+			api.HandleBadRequest(resp, nil, fmt.Errorf("invalid horizon_days value %s", value))
+			return
+		}
+		horizon = time.Duration(days) * 24 * time.Hour
+	}
+
+	result, err := h.quotaForecaster.ListHotspots(horizon)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(result)
 }
 
 func (h *tenantHandler) ListWorkspaces(req *restful.Request, resp *restful.Response) {
@@ -349,15 +573,24 @@ func (h *tenantHandler) QueryLogs(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	masker := h.logMasker()
 	if queryParam.Operation == loggingv1alpha2.OperationExport {
 		resp.Header().Set(restful.HEADER_ContentType, "text/plain")
 		resp.Header().Set("Content-Disposition", "attachment")
-		err := h.tenant.ExportLogs(user, queryParam, resp)
+		// exported lines carry no namespace, mask them under every
+		// namespace's query-stage rules
+		writer := masker.Writer(resp)
+		err := h.tenant.ExportLogs(user, queryParam, writer)
 		if err != nil {
 			klog.Errorln(err)
 			api.HandleInternalError(resp, req, err)
 			return
 		}
+		if err := writer.Flush(); err != nil {
+			klog.Errorln(err)
+			api.HandleInternalError(resp, req, err)
+			return
+		}
 	} else {
 		result, err := h.tenant.QueryLogs(user, queryParam)
 		if err != nil {
@@ -365,6 +598,7 @@ func (h *tenantHandler) QueryLogs(req *restful.Request, resp *restful.Response)
 			api.HandleInternalError(resp, req, err)
 			return
 		}
+		masker.MaskLogs(result.Logs)
 		resp.WriteAsJson(result)
 	}
 }
@@ -430,6 +664,167 @@ func (h *tenantHandler) DeleteNamespace(request *restful.Request, response *rest
 	response.WriteEntity(servererr.None)
 }
 
+func (h *tenantHandler) ArchiveNamespace(request *restful.Request, response *restful.Response) {
+	workspaceName := request.PathParameter("workspace")
+	namespaceName := request.PathParameter("namespace")
+
+	gracePeriodParam := request.QueryParameter("grace_period")
+	if gracePeriodParam == "" {
+		gracePeriodParam = "720h"
+	}
+	gracePeriod, err := time.ParseDuration(gracePeriodParam)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	if err := h.tenant.ArchiveNamespace(workspaceName, namespaceName, gracePeriod); err != nil {
+		klog.Error(err)
+		if errors.IsNotFound(err) {
+			api.HandleNotFound(response, request, err)
+			return
+		}
+		if errors.IsBadRequest(err) || errors.IsConflict(err) {
+			api.HandleBadRequest(response, request, err)
+			return
+		}
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+func (h *tenantHandler) CancelNamespaceArchival(request *restful.Request, response *restful.Response) {
+	workspaceName := request.PathParameter("workspace")
+	namespaceName := request.PathParameter("namespace")
+
+	if err := h.tenant.CancelNamespaceArchival(workspaceName, namespaceName); err != nil {
+		klog.Error(err)
+		if errors.IsNotFound(err) {
+			api.HandleNotFound(response, request, err)
+			return
+		}
+		if errors.IsBadRequest(err) {
+			api.HandleBadRequest(response, request, err)
+			return
+		}
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+func (h *tenantHandler) CreateProjectRequest(req *restful.Request, resp *restful.Response) {
+	workspaceName := req.PathParameter("workspace")
+
+	var projectRequest tenantv1alpha2.ProjectRequest
+	if err := req.ReadEntity(&projectRequest); err != nil {
+		klog.Error(err)
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+	if projectRequest.Spec.Project == "" {
+		api.HandleBadRequest(resp, req, fmt.Errorf("spec.project is required"))
+		return
+	}
+
+	requester, ok := request.UserFrom(req.Request.Context())
+	if !ok {
+		api.HandleInternalError(resp, req, fmt.Errorf("cannot obtain user info"))
+		return
+	}
+
+	projectRequest.Spec.Workspace = workspaceName
+	projectRequest.Spec.Requester = requester.GetName()
+	if projectRequest.Name == "" {
+		projectRequest.Name = fmt.Sprintf("%s-%s", workspaceName, projectRequest.Spec.Project)
+	}
+	if projectRequest.Labels == nil {
+		projectRequest.Labels = make(map[string]string)
+	}
+	projectRequest.Labels[constants.WorkspaceLabelKey] = workspaceName
+
+	if err := h.client.Create(req.Request.Context(), &projectRequest); err != nil {
+		klog.Error(err)
+		if errors.IsAlreadyExists(err) {
+			api.HandleConflict(resp, req, err)
+			return
+		}
+		api.HandleInternalError(resp, req, err)
+		return
+	}
+
+	resp.WriteEntity(&projectRequest)
+}
+
+func (h *tenantHandler) ListProjectRequests(req *restful.Request, resp *restful.Response) {
+	workspaceName := req.PathParameter("workspace")
+
+	projectRequests := &tenantv1alpha2.ProjectRequestList{}
+	if err := h.client.List(req.Request.Context(), projectRequests,
+		runtimeclient.MatchingLabels{constants.WorkspaceLabelKey: workspaceName}); err != nil {
+		klog.Error(err)
+		api.HandleInternalError(resp, req, err)
+		return
+	}
+
+	resp.WriteEntity(projectRequests)
+}
+
+// decideProjectRequest records the admin's decision; the controller
+// provisions the namespace of an approved request.
+func (h *tenantHandler) decideProjectRequest(req *restful.Request, resp *restful.Response, state string) {
+	workspaceName := req.PathParameter("workspace")
+	projectRequestName := req.PathParameter("projectrequest")
+
+	approver, ok := request.UserFrom(req.Request.Context())
+	if !ok {
+		api.HandleInternalError(resp, req, fmt.Errorf("cannot obtain user info"))
+		return
+	}
+
+	ctx := req.Request.Context()
+	projectRequest := &tenantv1alpha2.ProjectRequest{}
+	if err := h.client.Get(ctx, types.NamespacedName{Name: projectRequestName}, projectRequest); err != nil {
+		klog.Error(err)
+		if errors.IsNotFound(err) {
+			api.HandleNotFound(resp, req, err)
+			return
+		}
+		api.HandleInternalError(resp, req, err)
+		return
+	}
+	if projectRequest.Spec.Workspace != workspaceName {
+		api.HandleNotFound(resp, req, errors.NewNotFound(tenantv1alpha2.Resource(tenantv1alpha2.ResourceSingularProjectRequest), projectRequestName))
+		return
+	}
+	if projectRequest.Status.State != tenantv1alpha2.ProjectRequestPending {
+		api.HandleBadRequest(resp, req, fmt.Errorf("project request %s is already %s", projectRequestName, projectRequest.Status.State))
+		return
+	}
+
+	projectRequest.Status.State = state
+	projectRequest.Status.Approver = approver.GetName()
+	projectRequest.Status.Message = req.QueryParameter("message")
+	if err := h.client.Update(ctx, projectRequest); err != nil {
+		klog.Error(err)
+		api.HandleInternalError(resp, req, err)
+		return
+	}
+
+	resp.WriteEntity(projectRequest)
+}
+
+func (h *tenantHandler) ApproveProjectRequest(req *restful.Request, resp *restful.Response) {
+	h.decideProjectRequest(req, resp, tenantv1alpha2.ProjectRequestApproved)
+}
+
+func (h *tenantHandler) RejectProjectRequest(req *restful.Request, resp *restful.Response) {
+	h.decideProjectRequest(req, resp, tenantv1alpha2.ProjectRequestRejected)
+}
+
 func (h *tenantHandler) UpdateNamespace(request *restful.Request, response *restful.Response) {
 	workspaceName := request.PathParameter("workspace")
 	namespaceName := request.PathParameter("namespace")
@@ -73,13 +73,14 @@ func (h *tenantHandler) HandlePriceInfoQuery(req *restful.Request, resp *restful
 		return
 	}
 
-	priceInfo := meterConfig.GetPriceInfo()
+	priceInfo := meterConfig.GetClusterPriceInfo(req.QueryParameter("cluster"))
 	priceInfoResponse.Currency = priceInfo.CurrencyUnit
 	priceInfoResponse.CpuPerCorePerHour = priceInfo.CpuPerCorePerHour
 	priceInfoResponse.MemPerGigabytesPerHour = priceInfo.MemPerGigabytesPerHour
 	priceInfoResponse.IngressNetworkTrafficPerGiagabytesPerHour = priceInfo.IngressNetworkTrafficPerGiagabytesPerHour
 	priceInfoResponse.EgressNetworkTrafficPerGiagabytesPerHour = priceInfo.EgressNetworkTrafficPerGigabytesPerHour
 	priceInfoResponse.PvcPerGigabytesPerHour = priceInfo.PvcPerGigabytesPerHour
+	priceInfoResponse.PvcPerGigabytesPerHourByStorageClass = priceInfo.PvcPerGigabytesPerHourByStorageClass
 
 	resp.WriteAsJson(priceInfoResponse)
 
@@ -21,6 +21,7 @@ import (
 
 	"kubesphere.io/kubesphere/pkg/models/metering"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/emicklei/go-restful"
 	"github.com/emicklei/go-restful-openapi"
@@ -33,6 +34,7 @@ import (
 	loggingv1alpha2 "kubesphere.io/kubesphere/pkg/api/logging/v1alpha2"
 	quotav1alpha2 "kubesphere.io/kubesphere/pkg/apis/quota/v1alpha2"
 	tenantv1alpha2 "kubesphere.io/kubesphere/pkg/apis/tenant/v1alpha2"
+	authoptions "kubesphere.io/kubesphere/pkg/apiserver/authentication/options"
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
@@ -41,7 +43,9 @@ import (
 	monitoringv1alpha3 "kubesphere.io/kubesphere/pkg/kapis/monitoring/v1alpha3"
 	"kubesphere.io/kubesphere/pkg/models"
 	"kubesphere.io/kubesphere/pkg/models/iam/am"
+	loggingmodels "kubesphere.io/kubesphere/pkg/models/logging"
 	"kubesphere.io/kubesphere/pkg/models/monitoring"
+	"kubesphere.io/kubesphere/pkg/models/quotas"
 	resourcev1alpha3 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/resource"
 	"kubesphere.io/kubesphere/pkg/server/errors"
 	"kubesphere.io/kubesphere/pkg/simple/client/auditing"
@@ -63,11 +67,12 @@ func Resource(resource string) schema.GroupResource {
 func AddToContainer(c *restful.Container, factory informers.InformerFactory, k8sclient kubernetes.Interface,
 	ksclient kubesphere.Interface, evtsClient events.Client, loggingClient logging.Client,
 	auditingclient auditing.Client, am am.AccessManagementInterface, authorizer authorizer.Authorizer,
-	monitoringclient monitoringclient.Interface, cache cache.Cache) error {
+	monitoringclient monitoringclient.Interface, cache cache.Cache, client runtimeclient.Client,
+	authOptions *authoptions.AuthenticationOptions, loggingOptions *logging.Options) error {
 	mimePatch := []string{restful.MIME_JSON, runtime.MimeMergePatchJson, runtime.MimeJsonPatchJson}
 
 	ws := runtime.NewWebService(GroupVersion)
-	handler := newTenantHandler(factory, k8sclient, ksclient, evtsClient, loggingClient, auditingclient, am, authorizer, monitoringclient, resourcev1alpha3.NewResourceGetter(factory, cache))
+	handler := newTenantHandler(factory, k8sclient, ksclient, evtsClient, loggingClient, auditingclient, am, authorizer, monitoringclient, resourcev1alpha3.NewResourceGetter(factory, cache), client, authOptions, loggingOptions)
 
 	ws.Route(ws.GET("/clusters").
 		To(handler.ListClusters).
@@ -184,6 +189,56 @@ func AddToContainer(c *restful.Container, factory informers.InformerFactory, k8s
 		Returns(http.StatusOK, api.StatusOK, errors.None).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceTag}))
 
+	ws.Route(ws.POST("/workspaces/{workspace}/namespaces/{namespace}/archive").
+		To(handler.ArchiveNamespace).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("namespace", "project name")).
+		Param(ws.QueryParameter("grace_period", "How long the archived namespace is kept before deletion, as a Go duration. Defaults to 720h (30 days).").DefaultValue("720h").Required(false)).
+		Doc("Archive namespace: scale workloads to zero, snapshot volumes, export manifests to object storage and schedule deletion after the grace period.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceTag}))
+
+	ws.Route(ws.DELETE("/workspaces/{workspace}/namespaces/{namespace}/archive").
+		To(handler.CancelNamespaceArchival).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("namespace", "project name")).
+		Doc("Cancel namespace archival: restore workloads and unschedule the deletion.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceTag}))
+
+	ws.Route(ws.POST("/workspaces/{workspace}/projectrequests").
+		To(handler.CreateProjectRequest).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Doc("Request a new project in the workspace; a workspace admin approves or rejects the request.").
+		Reads(tenantv1alpha2.ProjectRequest{}).
+		Returns(http.StatusOK, api.StatusOK, tenantv1alpha2.ProjectRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceTag}))
+
+	ws.Route(ws.GET("/workspaces/{workspace}/projectrequests").
+		To(handler.ListProjectRequests).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Doc("List the project requests of the workspace.").
+		Returns(http.StatusOK, api.StatusOK, tenantv1alpha2.ProjectRequestList{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceTag}))
+
+	ws.Route(ws.POST("/workspaces/{workspace}/projectrequests/{projectrequest}/approve").
+		To(handler.ApproveProjectRequest).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("projectrequest", "project request name")).
+		Param(ws.QueryParameter("message", "optional note recorded with the decision").Required(false)).
+		Doc("Approve a project request; the namespace is provisioned with the approved resource quota.").
+		Returns(http.StatusOK, api.StatusOK, tenantv1alpha2.ProjectRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceTag}))
+
+	ws.Route(ws.POST("/workspaces/{workspace}/projectrequests/{projectrequest}/reject").
+		To(handler.RejectProjectRequest).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("projectrequest", "project request name")).
+		Param(ws.QueryParameter("message", "optional note recorded with the decision").Required(false)).
+		Doc("Reject a project request.").
+		Returns(http.StatusOK, api.StatusOK, tenantv1alpha2.ProjectRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.NamespaceTag}))
+
 	ws.Route(ws.POST("/workspaces/{workspace}/namespaces").
 		To(handler.CreateNamespace).
 		Param(ws.PathParameter("workspace", "workspace name")).
@@ -268,6 +323,67 @@ func AddToContainer(c *restful.Container, factory informers.InformerFactory, k8s
 		Consumes(restful.MIME_JSON, restful.MIME_XML).
 		Produces(restful.MIME_JSON, "text/plain")
 
+	ws.Route(ws.GET("/namespaces/{namespace}/logsidecarconfig").
+		To(handler.GetLogSidecarConfig).
+		Doc("Get the namespace's logsidecar-injector configuration.").
+		Param(ws.PathParameter("namespace", "namespace name")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LogQueryTag}).
+		Returns(http.StatusOK, api.StatusOK, loggingmodels.LogSidecarConfig{}))
+
+	ws.Route(ws.PUT("/namespaces/{namespace}/logsidecarconfig").
+		To(handler.SaveLogSidecarConfig).
+		Doc("Replace the namespace's logsidecar-injector configuration, validating the file path groups and multiline parsers.").
+		Param(ws.PathParameter("namespace", "namespace name")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LogQueryTag}).
+		Reads(loggingmodels.LogSidecarConfig{}).
+		Returns(http.StatusOK, api.StatusOK, loggingmodels.LogSidecarConfig{}))
+
+	ws.Route(ws.GET("/logthrottlepolicies").
+		To(handler.ListLogThrottlePolicies).
+		Doc("List the per-namespace log throttling and sampling policies.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LogQueryTag}).
+		Returns(http.StatusOK, api.StatusOK, []loggingmodels.ThrottlePolicy{}))
+
+	ws.Route(ws.PUT("/logthrottlepolicies").
+		To(handler.SaveLogThrottlePolicies).
+		Doc("Replace the per-namespace log throttling and sampling policies, they are rolled into the log agent configuration.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LogQueryTag}).
+		Reads([]loggingmodels.ThrottlePolicy{}).
+		Returns(http.StatusOK, api.StatusOK, []loggingmodels.ThrottlePolicy{}))
+
+	ws.Route(ws.GET("/logmaskingpolicies").
+		To(handler.ListLogMaskingPolicies).
+		Doc("List the per-namespace log masking policies.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LogQueryTag}).
+		Returns(http.StatusOK, api.StatusOK, []loggingmodels.MaskingPolicy{}))
+
+	ws.Route(ws.PUT("/logmaskingpolicies").
+		To(handler.SaveLogMaskingPolicies).
+		Doc("Replace the per-namespace log masking policies, agent-stage rules are rolled into the log agent configuration and query-stage rules are applied to query results; the change is audited.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LogQueryTag}).
+		Reads([]loggingmodels.MaskingPolicy{}).
+		Returns(http.StatusOK, api.StatusOK, []loggingmodels.MaskingPolicy{}))
+
+	ws.Route(ws.GET("/logindexstrategies").
+		To(handler.ListLogIndexStrategies).
+		Doc("List the per-workspace log index strategies.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LogQueryTag}).
+		Returns(http.StatusOK, api.StatusOK, []loggingmodels.WorkspaceIndexStrategy{}))
+
+	ws.Route(ws.PUT("/logindexstrategies").
+		To(handler.SaveLogIndexStrategies).
+		Doc("Replace the per-workspace log index strategies, routing each workspace's logs into dedicated indices with document and size quotas driving automatic rollover.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LogQueryTag}).
+		Reads([]loggingmodels.WorkspaceIndexStrategy{}).
+		Returns(http.StatusOK, api.StatusOK, []loggingmodels.WorkspaceIndexStrategy{}))
+
+	ws.Route(ws.GET("/logdashboard").
+		To(handler.CreateLogDashboardLink).
+		Doc("Issue a deep link into Kibana or OpenSearch Dashboards under the user's own identity, scoped to the indices of the namespaces the user may view logs in.").
+		Param(ws.QueryParameter("namespace", "Scope the dashboard session to this namespace only. Defaults to every namespace the user may view logs in.")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LogQueryTag}).
+		Returns(http.StatusOK, api.StatusOK, loggingmodels.DashboardsDeepLink{}))
+
 	ws.Route(ws.GET("/auditing/events").
 		To(handler.Auditing).
 		Doc("Query auditing events against the cluster").
@@ -339,6 +455,7 @@ func AddToContainer(c *restful.Container, factory informers.InformerFactory, k8s
 	ws.Route(ws.GET("/metering/price").
 		To(handler.HandlePriceInfoQuery).
 		Doc("Get resoure price.").
+		Param(ws.QueryParameter("cluster", "Cluster name. Return the price sheet of the cluster if one is defined, the default price sheet otherwise.").DataType("string").Required(false)).
 		Writes(metering.PriceInfo{}).
 		Returns(http.StatusOK, api.StatusOK, metering.PriceInfo{}))
 	ws.Route(ws.POST("/workspaces/{workspace}/resourcequotas").
@@ -373,6 +490,13 @@ func AddToContainer(c *restful.Container, factory informers.InformerFactory, k8s
 		Doc("Describe resource quota.").
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceTag}))
 
+	ws.Route(ws.GET("/quotahotspots").
+		To(handler.ListQuotaHotspots).
+		Param(ws.QueryParameter("horizon_days", "Flag workspaces projected to hit their quota within this many days. Defaults to 7.").DataType("integer").DefaultValue("7").Required(false)).
+		Returns(http.StatusOK, api.StatusOK, []quotas.QuotaForecast{}).
+		Doc("List workspaces projected to hit a resource quota soon, based on their recent usage trend, soonest first.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceTag}))
+
 	c.Add(ws)
 	return nil
 }
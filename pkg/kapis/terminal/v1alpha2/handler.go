@@ -17,13 +17,20 @@ limitations under the License.
 package v1alpha2
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/emicklei/go-restful"
 	"github.com/gorilla/websocket"
+	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog"
+	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/models/terminal"
+	"kubesphere.io/kubesphere/pkg/server/errors"
+	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 	"net/http"
+	"path"
 )
 
 var upgrader = websocket.Upgrader{
@@ -37,23 +44,165 @@ type terminalHandler struct {
 	terminaler terminal.Interface
 }
 
-func newTerminalHandler(client kubernetes.Interface, config *rest.Config) *terminalHandler {
+func newTerminalHandler(client kubernetes.Interface, config *rest.Config, store s3.Interface) *terminalHandler {
 	return &terminalHandler{
-		terminaler: terminal.NewTerminaler(client, config),
+		terminaler: terminal.NewTerminaler(client, config, store),
 	}
 }
 
-func (t *terminalHandler) handleTerminalSession(request *restful.Request, response *restful.Response) {
-	namespace := request.PathParameter("namespace")
-	podName := request.PathParameter("pod")
-	containerName := request.QueryParameter("container")
-	shell := request.QueryParameter("shell")
+func (t *terminalHandler) handleTerminalSession(req *restful.Request, response *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	podName := req.PathParameter("pod")
+	containerName := req.QueryParameter("container")
+	shell := req.QueryParameter("shell")
 
-	conn, err := upgrader.Upgrade(response.ResponseWriter, request.Request, nil)
+	username := ""
+	if user, ok := request.UserFrom(req.Request.Context()); ok {
+		username = user.GetName()
+	}
+
+	conn, err := upgrader.Upgrade(response.ResponseWriter, req.Request, nil)
+	if err != nil {
+		klog.Warning(err)
+		return
+	}
+
+	t.terminaler.HandleSession(username, shell, namespace, podName, containerName, conn)
+}
+
+func (t *terminalHandler) handleLaunchDebugContainer(req *restful.Request, response *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	podName := req.PathParameter("pod")
+
+	username := ""
+	if user, ok := request.UserFrom(req.Request.Context()); ok {
+		username = user.GetName()
+	}
+
+	var debugRequest terminal.DebugContainerRequest
+	if req.Request.ContentLength != 0 {
+		if err := req.ReadEntity(&debugRequest); err != nil {
+			api.HandleBadRequest(response, nil, err)
+			return
+		}
+	}
+
+	debug, err := t.terminaler.LaunchDebugContainer(username, namespace, podName, debugRequest)
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(debug)
+}
+
+func (t *terminalHandler) handleDebugSession(req *restful.Request, response *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	podName := req.PathParameter("pod")
+	containerName := req.PathParameter("container")
+
+	username := ""
+	if user, ok := request.UserFrom(req.Request.Context()); ok {
+		username = user.GetName()
+	}
+
+	conn, err := upgrader.Upgrade(response.ResponseWriter, req.Request, nil)
 	if err != nil {
 		klog.Warning(err)
 		return
 	}
 
-	t.terminaler.HandleSession(shell, namespace, podName, containerName, conn)
+	t.terminaler.HandleDebugSession(username, namespace, podName, containerName, conn)
+}
+
+func (t *terminalHandler) handleListFiles(req *restful.Request, response *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	podName := req.PathParameter("pod")
+	containerName := req.QueryParameter("container")
+	dirPath := req.QueryParameter("path")
+
+	files, err := t.terminaler.ListFiles(namespace, podName, containerName, dirPath)
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(files)
+}
+
+func (t *terminalHandler) handleDownloadFile(req *restful.Request, response *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	podName := req.PathParameter("pod")
+	containerName := req.QueryParameter("container")
+	filePath := req.QueryParameter("path")
+
+	username := ""
+	if user, ok := request.UserFrom(req.Request.Context()); ok {
+		username = user.GetName()
+	}
+
+	// buffer the archive so transfer errors can still be reported cleanly,
+	// size is bounded by terminal.MaxTransferSize
+	var buf bytes.Buffer
+	if err := t.terminaler.DownloadFile(username, namespace, podName, containerName, filePath, &buf); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/x-tar")
+	response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(filePath)+".tar"))
+	response.Write(buf.Bytes())
+}
+
+func (t *terminalHandler) handleUploadFile(req *restful.Request, response *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	podName := req.PathParameter("pod")
+	containerName := req.QueryParameter("container")
+	destDir := req.QueryParameter("path")
+
+	username := ""
+	if user, ok := request.UserFrom(req.Request.Context()); ok {
+		username = user.GetName()
+	}
+
+	file, header, err := req.Request.FormFile("file")
+	if err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+	defer file.Close()
+
+	if err := t.terminaler.UploadFile(username, namespace, podName, containerName, destDir, header.Filename, header.Size, file); err != nil {
+		api.HandleBadRequest(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(errors.None)
+}
+
+func (t *terminalHandler) handleListRecordings(req *restful.Request, response *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	recordings, err := t.terminaler.ListRecordings(namespace)
+	if err != nil {
+		api.HandleInternalError(response, nil, err)
+		return
+	}
+
+	response.WriteAsJson(recordings)
+}
+
+func (t *terminalHandler) handleGetRecording(req *restful.Request, response *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	recording := req.PathParameter("recording")
+
+	content, err := t.terminaler.GetRecording(namespace, recording)
+	if err != nil {
+		api.HandleNotFound(response, nil, err)
+		return
+	}
+
+	// asciicast v2, playable with `asciinema play`
+	response.Header().Set("Content-Type", "application/x-asciicast")
+	response.Write(content)
 }
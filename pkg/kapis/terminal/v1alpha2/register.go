@@ -25,6 +25,8 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/models"
+	"kubesphere.io/kubesphere/pkg/models/terminal"
+	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 )
 
 const (
@@ -33,11 +35,11 @@ const (
 
 var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha2"}
 
-func AddToContainer(c *restful.Container, client kubernetes.Interface, config *rest.Config) error {
+func AddToContainer(c *restful.Container, client kubernetes.Interface, config *rest.Config, store s3.Interface) error {
 
 	webservice := runtime.NewWebService(GroupVersion)
 
-	handler := newTerminalHandler(client, config)
+	handler := newTerminalHandler(client, config, store)
 
 	webservice.Route(webservice.GET("/namespaces/{namespace}/pods/{pod}/exec").
 		To(handler.handleTerminalSession).
@@ -47,6 +49,67 @@ func AddToContainer(c *restful.Container, client kubernetes.Interface, config *r
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}).
 		Writes(models.PodInfo{}))
 
+	webservice.Route(webservice.POST("/namespaces/{namespace}/pods/{pod}/debug").
+		To(handler.handleLaunchDebugContainer).
+		Param(webservice.PathParameter("namespace", "namespace of which the pod located in")).
+		Param(webservice.PathParameter("pod", "name of the pod")).
+		Reads(terminal.DebugContainerRequest{}).
+		Doc("attach an ephemeral debug container to the running pod. The image is user-selectable, capabilities are policy-restricted and the launch is audited through an event on the pod. Open a terminal to it with the debug attach endpoint.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}).
+		Writes(terminal.DebugContainer{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/pods/{pod}/debug/{container}/attach").
+		To(handler.handleDebugSession).
+		Param(webservice.PathParameter("namespace", "namespace of which the pod located in")).
+		Param(webservice.PathParameter("pod", "name of the pod")).
+		Param(webservice.PathParameter("container", "name of the debug container")).
+		Doc("open a terminal attached to the debug container, the session is recorded like a regular terminal session").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/pods/{pod}/files").
+		To(handler.handleListFiles).
+		Param(webservice.PathParameter("namespace", "namespace of which the pod located in")).
+		Param(webservice.PathParameter("pod", "name of the pod")).
+		Param(webservice.QueryParameter("container", "name of the container")).
+		Param(webservice.QueryParameter("path", "absolute path of the directory to list")).
+		Doc("list files of a directory inside the container").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}).
+		Writes([]terminal.FileInfo{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/pods/{pod}/files/download").
+		To(handler.handleDownloadFile).
+		Param(webservice.PathParameter("namespace", "namespace of which the pod located in")).
+		Param(webservice.PathParameter("pod", "name of the pod")).
+		Param(webservice.QueryParameter("container", "name of the container")).
+		Param(webservice.QueryParameter("path", "absolute path of the file to download")).
+		Doc("download a file from the container as a tar archive").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}))
+
+	webservice.Route(webservice.POST("/namespaces/{namespace}/pods/{pod}/files").
+		To(handler.handleUploadFile).
+		Consumes("multipart/form-data").
+		Param(webservice.PathParameter("namespace", "namespace of which the pod located in")).
+		Param(webservice.PathParameter("pod", "name of the pod")).
+		Param(webservice.QueryParameter("container", "name of the container")).
+		Param(webservice.QueryParameter("path", "absolute path of the directory the file is uploaded to")).
+		Param(webservice.FormParameter("file", "content of the uploaded file")).
+		Doc("upload a file into the container").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/recordings").
+		To(handler.handleListRecordings).
+		Param(webservice.PathParameter("namespace", "namespace of which the recorded sessions located in")).
+		Doc("list recorded terminal sessions").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}).
+		Writes([]terminal.SessionMetadata{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/recordings/{recording}").
+		To(handler.handleGetRecording).
+		Param(webservice.PathParameter("namespace", "namespace of which the recorded session located in")).
+		Param(webservice.PathParameter("recording", "id of the recorded session")).
+		Doc("play back a recorded terminal session, the response is an asciicast v2 file").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}))
+
 	c.Add(webservice)
 
 	return nil
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/models/topology"
+)
+
+type handler struct {
+	operator topology.Operator
+}
+
+func newHandler(client kubernetes.Interface, kialiQueryHost string) *handler {
+	return &handler{
+		operator: topology.NewOperator(client, kialiQueryHost),
+	}
+}
+
+func (h *handler) handleGetTopology(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	graph, err := h.operator.Graph(namespace)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(graph)
+}
+
+func (h *handler) handleGetImpact(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+	kind := req.PathParameter("kind")
+	name := req.PathParameter("name")
+
+	impact, err := h.operator.Impact(namespace, kind, name)
+	if err != nil {
+		api.HandleNotFound(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(impact)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/topology"
+)
+
+const (
+	GroupName = "topology.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, client kubernetes.Interface, kialiQueryHost string) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(client, kialiQueryHost)
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/topology").
+		To(handler.handleGetTopology).
+		Doc("compute the dependency graph of the namespace: services, workloads and the configmaps, secrets and volume claims they use, plus mesh traffic edges when the service mesh is enabled").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TopologyTag}).
+		Returns(http.StatusOK, api.StatusOK, topology.Graph{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/topology/{kind}/{name}/impact").
+		To(handler.handleGetImpact).
+		Doc("list the resources that directly depend on the given one, what would be affected by deleting it").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Param(webservice.PathParameter("kind", "kind of the resource, e.g. Deployment or ConfigMap")).
+		Param(webservice.PathParameter("name", "name of the resource")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TopologyTag}).
+		Returns(http.StatusOK, api.StatusOK, topology.Impact{}))
+
+	c.Add(webservice)
+
+	return nil
+}
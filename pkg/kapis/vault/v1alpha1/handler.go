@@ -0,0 +1,44 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/emicklei/go-restful"
+	"k8s.io/client-go/informers"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/models/vault"
+)
+
+type handler struct {
+	operator vault.Operator
+}
+
+func newHandler(informers informers.SharedInformerFactory) *handler {
+	return &handler{operator: vault.NewOperator(informers)}
+}
+
+func (h *handler) handleListConsumers(req *restful.Request, resp *restful.Response) {
+	namespace := req.PathParameter("namespace")
+
+	consumers, err := h.operator.ListConsumers(namespace)
+	if err != nil {
+		api.HandleInternalError(resp, nil, err)
+		return
+	}
+	resp.WriteAsJson(consumers)
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/vault"
+)
+
+const (
+	GroupName = "vault.kubesphere.io"
+)
+
+var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+func AddToContainer(c *restful.Container, k8sInformers informers.SharedInformerFactory) error {
+	webservice := runtime.NewWebService(GroupVersion)
+
+	handler := newHandler(k8sInformers)
+
+	webservice.Route(webservice.GET("/secretconsumers").
+		To(handler.handleListConsumers).
+		Doc("list workloads consuming external secrets across all namespaces").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.VaultTag}).
+		Returns(http.StatusOK, api.StatusOK, []vault.Consumer{}))
+
+	webservice.Route(webservice.GET("/namespaces/{namespace}/secretconsumers").
+		To(handler.handleListConsumers).
+		Doc("list workloads of the namespace consuming external secrets").
+		Param(webservice.PathParameter("namespace", "name of the namespace")).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.VaultTag}).
+		Returns(http.StatusOK, api.StatusOK, []vault.Consumer{}))
+
+	c.Add(webservice)
+
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"kubesphere.io/kubesphere/pkg/api/alerting/v2alpha1"
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// clusterPolicyConfigMapName names the ConfigMap holding the cluster
+	// level alerting policy, the per-namespace policies append the
+	// namespace name
+	clusterPolicyConfigMapName     = "alerting-policy"
+	namespacePolicyConfigMapFormat = "alerting-policy-%s"
+	policyDataKey                  = "policy"
+)
+
+// PolicyOperator manages the alert grouping and inhibition configuration.
+// The policies are persisted as ConfigMaps in the monitoring namespace, one
+// for the cluster level and one per namespace, from which the alertmanager
+// configuration is assembled.
+type PolicyOperator interface {
+	// GetPolicy returns the policy of the namespace, or the cluster level
+	// policy when namespace is empty. A scope without a policy yields an
+	// empty policy.
+	GetPolicy(ctx context.Context, namespace string) (*v2alpha1.AlertingPolicy, error)
+	// SavePolicy replaces the policy of the scope.
+	SavePolicy(ctx context.Context, namespace string, policy *v2alpha1.AlertingPolicy) error
+}
+
+type policyOperator struct {
+	k8sClient kubernetes.Interface
+}
+
+func NewPolicyOperator(k8sClient kubernetes.Interface) PolicyOperator {
+	return &policyOperator{k8sClient: k8sClient}
+}
+
+func policyConfigMapName(namespace string) string {
+	if namespace == "" {
+		return clusterPolicyConfigMapName
+	}
+	return fmt.Sprintf(namespacePolicyConfigMapFormat, namespace)
+}
+
+func (o *policyOperator) GetPolicy(ctx context.Context, namespace string) (*v2alpha1.AlertingPolicy, error) {
+	cm, err := o.k8sClient.CoreV1().ConfigMaps(constants.KubeSphereMonitoringNamespace).
+		Get(ctx, policyConfigMapName(namespace), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return &v2alpha1.AlertingPolicy{}, nil
+		}
+		return nil, err
+	}
+
+	data, ok := cm.Data[policyDataKey]
+	if !ok || data == "" {
+		return &v2alpha1.AlertingPolicy{}, nil
+	}
+	var policy v2alpha1.AlertingPolicy
+	if err := json.Unmarshal([]byte(data), &policy); err != nil {
+		return nil, fmt.Errorf("invalid alerting policy in %s/%s: %v", cm.Namespace, cm.Name, err)
+	}
+	return &policy, nil
+}
+
+func (o *policyOperator) SavePolicy(ctx context.Context, namespace string, policy *v2alpha1.AlertingPolicy) error {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      policyConfigMapName(namespace),
+			Namespace: constants.KubeSphereMonitoringNamespace,
+		},
+		Data: map[string]string{policyDataKey: string(data)},
+	}
+	if namespace != "" {
+		cm.Labels = map[string]string{constants.NamespaceLabelKey: namespace}
+	}
+
+	client := o.k8sClient.CoreV1().ConfigMaps(constants.KubeSphereMonitoringNamespace)
+	old, err := client.Get(ctx, cm.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		_, err = client.Create(ctx, cm, metav1.CreateOptions{})
+		return err
+	}
+	old.Labels = cm.Labels
+	old.Data = cm.Data
+	_, err = client.Update(ctx, old, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"sort"
+
+	promresourcesv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	prominformersv1 "github.com/prometheus-operator/prometheus-operator/pkg/client/informers/externalversions/monitoring/v1"
+	promresourcesclient "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"kubesphere.io/kubesphere/pkg/api/alerting/v2alpha1"
+	"kubesphere.io/kubesphere/pkg/informers"
+)
+
+// RuleGroupOperator contains the operations to the rule groups within the
+// prometheusrule custom resources of a namespace. Unlike Operator, which views
+// the rules one by one from the perspective of alerting, it manages whole
+// groups as they are persisted, including recording rules, and can validate a
+// group against supplied series before it is deployed.
+type RuleGroupOperator interface {
+	// ListRuleGroups lists the rule groups of all the prometheusrule resources in the namespace.
+	ListRuleGroups(ctx context.Context, namespace string) (*v2alpha1.GettableRuleGroupList, error)
+	// GetRuleGroup gets the rule group with the given name within the given prometheusrule resource.
+	GetRuleGroup(ctx context.Context, namespace, resource, group string) (*v2alpha1.GettableRuleGroup, error)
+	// CreateOrUpdateRuleGroup writes the rule group into the given prometheusrule resource,
+	// creating the resource if it does not exist.
+	CreateOrUpdateRuleGroup(ctx context.Context, namespace, resource string, group *v2alpha1.PostableRuleGroup) error
+	// DeleteRuleGroup deletes the rule group with the given name from the given prometheusrule
+	// resource, and deletes the resource itself when its last group is removed.
+	DeleteRuleGroup(ctx context.Context, namespace, resource, group string) error
+
+	// TestRuleGroup evaluates the rule group against the supplied series with the semantics
+	// of promtool test rules, without touching any resource.
+	TestRuleGroup(test *v2alpha1.RuleGroupTest) (*v2alpha1.RuleGroupTestResult, error)
+}
+
+func NewRuleGroupOperator(informers informers.InformerFactory,
+	promResourceClient promresourcesclient.Interface) RuleGroupOperator {
+	return &ruleGroupOperator{
+		promResourceClient:   promResourceClient,
+		ruleResourceInformer: informers.PrometheusSharedInformerFactory().Monitoring().V1().PrometheusRules(),
+	}
+}
+
+type ruleGroupOperator struct {
+	promResourceClient promresourcesclient.Interface
+
+	ruleResourceInformer prominformersv1.PrometheusRuleInformer
+}
+
+func (o *ruleGroupOperator) ListRuleGroups(ctx context.Context, namespace string) (
+	*v2alpha1.GettableRuleGroupList, error) {
+	resources, err := o.ruleResourceInformer.Lister().PrometheusRules(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	list := &v2alpha1.GettableRuleGroupList{Items: []*v2alpha1.GettableRuleGroup{}}
+	for _, resource := range resources {
+		for i := range resource.Spec.Groups {
+			list.Items = append(list.Items, gettableRuleGroup(resource.Name, &resource.Spec.Groups[i]))
+		}
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		if list.Items[i].ResourceName != list.Items[j].ResourceName {
+			return list.Items[i].ResourceName < list.Items[j].ResourceName
+		}
+		return list.Items[i].Name < list.Items[j].Name
+	})
+	list.Total = len(list.Items)
+	return list, nil
+}
+
+func (o *ruleGroupOperator) GetRuleGroup(ctx context.Context, namespace, resource, group string) (
+	*v2alpha1.GettableRuleGroup, error) {
+	ruleResource, err := o.ruleResourceInformer.Lister().PrometheusRules(namespace).Get(resource)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, v2alpha1.ErrRuleResourceNotFound
+		}
+		return nil, err
+	}
+	for i := range ruleResource.Spec.Groups {
+		if ruleResource.Spec.Groups[i].Name == group {
+			return gettableRuleGroup(ruleResource.Name, &ruleResource.Spec.Groups[i]), nil
+		}
+	}
+	return nil, v2alpha1.ErrRuleGroupNotFound
+}
+
+func (o *ruleGroupOperator) CreateOrUpdateRuleGroup(ctx context.Context, namespace, resource string,
+	group *v2alpha1.PostableRuleGroup) error {
+	newGroup := promRuleGroup(group)
+
+	client := o.promResourceClient.MonitoringV1().PrometheusRules(namespace)
+	ruleResource, err := client.Get(ctx, resource, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		ruleResource = &promresourcesv1.PrometheusRule{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      resource,
+				Namespace: namespace,
+			},
+			Spec: promresourcesv1.PrometheusRuleSpec{
+				Groups: []promresourcesv1.RuleGroup{newGroup},
+			},
+		}
+		_, err = client.Create(ctx, ruleResource, metav1.CreateOptions{})
+		return err
+	}
+
+	updated := false
+	for i := range ruleResource.Spec.Groups {
+		if ruleResource.Spec.Groups[i].Name == newGroup.Name {
+			ruleResource.Spec.Groups[i] = newGroup
+			updated = true
+			break
+		}
+	}
+	if !updated {
+		ruleResource.Spec.Groups = append(ruleResource.Spec.Groups, newGroup)
+	}
+	_, err = client.Update(ctx, ruleResource, metav1.UpdateOptions{})
+	return err
+}
+
+func (o *ruleGroupOperator) DeleteRuleGroup(ctx context.Context, namespace, resource, group string) error {
+	client := o.promResourceClient.MonitoringV1().PrometheusRules(namespace)
+	ruleResource, err := client.Get(ctx, resource, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return v2alpha1.ErrRuleResourceNotFound
+		}
+		return err
+	}
+
+	var groups []promresourcesv1.RuleGroup
+	for i := range ruleResource.Spec.Groups {
+		if ruleResource.Spec.Groups[i].Name != group {
+			groups = append(groups, ruleResource.Spec.Groups[i])
+		}
+	}
+	if len(groups) == len(ruleResource.Spec.Groups) {
+		return v2alpha1.ErrRuleGroupNotFound
+	}
+	if len(groups) == 0 {
+		// a prometheusrule resource without groups serves nothing
+		return client.Delete(ctx, resource, metav1.DeleteOptions{})
+	}
+
+	ruleResource.Spec.Groups = groups
+	_, err = client.Update(ctx, ruleResource, metav1.UpdateOptions{})
+	return err
+}
+
+func gettableRuleGroup(resourceName string, group *promresourcesv1.RuleGroup) *v2alpha1.GettableRuleGroup {
+	g := &v2alpha1.GettableRuleGroup{
+		PostableRuleGroup: v2alpha1.PostableRuleGroup{
+			Name:     group.Name,
+			Interval: group.Interval,
+		},
+		ResourceName: resourceName,
+	}
+	for _, rule := range group.Rules {
+		g.Rules = append(g.Rules, v2alpha1.RuleGroupRule{
+			Record:      rule.Record,
+			Alert:       rule.Alert,
+			Expr:        rule.Expr.String(),
+			For:         rule.For,
+			Labels:      rule.Labels,
+			Annotations: rule.Annotations,
+		})
+	}
+	return g
+}
+
+func promRuleGroup(group *v2alpha1.PostableRuleGroup) promresourcesv1.RuleGroup {
+	g := promresourcesv1.RuleGroup{
+		Name:     group.Name,
+		Interval: group.Interval,
+	}
+	for _, rule := range group.Rules {
+		g.Rules = append(g.Rules, promresourcesv1.Rule{
+			Record:      rule.Record,
+			Alert:       rule.Alert,
+			Expr:        intstr.FromString(rule.Expr),
+			For:         rule.For,
+			Labels:      rule.Labels,
+			Annotations: rule.Annotations,
+		})
+	}
+	return g
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	prommodel "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	promrules "github.com/prometheus/prometheus/rules"
+	"kubesphere.io/kubesphere/pkg/api/alerting/v2alpha1"
+)
+
+const defaultTestInterval = time.Minute
+
+// testFataler satisfies testutil.T for the promql test suite, turning fatal
+// calls into panics which TestRuleGroup recovers into regular errors.
+type testFataler struct{}
+
+func (testFataler) Fatal(args ...interface{}) { panic(fmt.Sprint(args...)) }
+
+func (testFataler) Fatalf(format string, args ...interface{}) { panic(fmt.Sprintf(format, args...)) }
+
+// TestRuleGroup loads the supplied series into a throwaway storage, then
+// evaluates the rule group against it step by step from the start of the test
+// data, the same way promtool test rules does. Hold durations therefore take
+// effect: an alert only counts as firing once its condition held for its
+// whole for duration.
+func (o *ruleGroupOperator) TestRuleGroup(test *v2alpha1.RuleGroupTest) (
+	result *v2alpha1.RuleGroupTestResult, err error) {
+	interval := defaultTestInterval
+	if test.Interval != "" {
+		d, err := prommodel.ParseDuration(test.Interval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "interval is invalid: %s", test.Interval)
+		}
+		interval = time.Duration(d)
+	}
+
+	evalTimes := make([]time.Duration, len(test.Assertions))
+	var maxEvalTime time.Duration
+	for i, assertion := range test.Assertions {
+		d, err := prommodel.ParseDuration(assertion.EvalTime)
+		if err != nil {
+			return nil, errors.Wrapf(err, "evalTime is invalid: %s", assertion.EvalTime)
+		}
+		evalTimes[i] = time.Duration(d)
+		if evalTimes[i] > maxEvalTime {
+			maxEvalTime = evalTimes[i]
+		}
+	}
+
+	var input strings.Builder
+	fmt.Fprintf(&input, "load %v\n", prommodel.Duration(interval))
+	for _, series := range test.Series {
+		fmt.Fprintf(&input, "  %s %s\n", series.Series, series.Values)
+	}
+
+	// the suite aborts through testutil.T on storage errors
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, errors.Errorf("rule group test aborted: %v", r)
+		}
+	}()
+
+	suite, err := promql.NewTest(testFataler{}, input.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "series are invalid")
+	}
+	defer suite.Close()
+	if err := suite.Run(); err != nil {
+		return nil, errors.Wrap(err, "loading series failed")
+	}
+
+	group, err := testGroup(&test.RuleGroup, interval, suite)
+	if err != nil {
+		return nil, err
+	}
+
+	result = &v2alpha1.RuleGroupTestResult{Pass: true}
+	start := time.Unix(0, 0).UTC()
+	for elapsed := time.Duration(0); elapsed <= maxEvalTime; elapsed += interval {
+		group.Eval(suite.Context(), start.Add(elapsed))
+
+		for i := range test.Assertions {
+			if evalTimes[i] < elapsed || evalTimes[i] >= elapsed+interval {
+				continue
+			}
+			assertionResult := checkAlertAssertion(group, &test.Assertions[i])
+			if !assertionResult.Pass {
+				result.Pass = false
+			}
+			result.Results = append(result.Results, assertionResult)
+		}
+	}
+	return result, nil
+}
+
+// testGroup assembles the posted rule group into an evaluatable group backed
+// by the storage and engine of the test suite.
+func testGroup(g *v2alpha1.PostableRuleGroup, interval time.Duration, suite *promql.Test) (
+	*promrules.Group, error) {
+	var rs []promrules.Rule
+	for _, rule := range g.Rules {
+		expr, err := parser.ParseExpr(rule.Expr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "expr is invalid: %s", rule.Expr)
+		}
+		if rule.Record != "" {
+			rs = append(rs, promrules.NewRecordingRule(rule.Record, expr, labels.FromMap(rule.Labels)))
+			continue
+		}
+		var hold time.Duration
+		if rule.For != "" {
+			d, err := prommodel.ParseDuration(rule.For)
+			if err != nil {
+				return nil, errors.Wrapf(err, "for is invalid: %s", rule.For)
+			}
+			hold = time.Duration(d)
+		}
+		rs = append(rs, promrules.NewAlertingRule(rule.Alert, expr, hold,
+			labels.FromMap(rule.Labels), labels.FromMap(rule.Annotations), nil, true, log.NewNopLogger()))
+	}
+
+	return promrules.NewGroup(promrules.GroupOptions{
+		Name:     g.Name,
+		Interval: interval,
+		Rules:    rs,
+		Opts: &promrules.ManagerOptions{
+			QueryFunc:  promrules.EngineQueryFunc(suite.QueryEngine(), suite.Storage()),
+			NotifyFunc: func(ctx context.Context, expr string, alerts ...*promrules.Alert) {},
+			Appendable: suite.Storage(),
+			TSDB:       suite.Storage(),
+			Context:    context.Background(),
+			Logger:     log.NewNopLogger(),
+		},
+	}), nil
+}
+
+// checkAlertAssertion compares the alerts firing for the asserted rule with
+// the expected ones, ignoring order.
+func checkAlertAssertion(g *promrules.Group, assertion *v2alpha1.AlertAssertion) v2alpha1.AssertionResult {
+	result := v2alpha1.AssertionResult{
+		EvalTime:  assertion.EvalTime,
+		Alertname: assertion.Alertname,
+	}
+
+	got := []v2alpha1.ExpectedAlert{}
+	for _, rule := range g.Rules() {
+		alertingRule, ok := rule.(*promrules.AlertingRule)
+		if !ok || alertingRule.Name() != assertion.Alertname {
+			continue
+		}
+		for _, alert := range alertingRule.ActiveAlerts() {
+			if alert.State != promrules.StateFiring {
+				continue
+			}
+			got = append(got, v2alpha1.ExpectedAlert{
+				Labels:      alert.Labels.Map(),
+				Annotations: alert.Annotations.Map(),
+			})
+		}
+	}
+
+	expected := []v2alpha1.ExpectedAlert{}
+	for _, exp := range assertion.ExpAlerts {
+		lbls := labels.NewBuilder(labels.FromMap(exp.Labels)).
+			Set(prommodel.AlertNameLabel, assertion.Alertname).Labels()
+		expected = append(expected, v2alpha1.ExpectedAlert{
+			Labels:      lbls.Map(),
+			Annotations: exp.Annotations,
+		})
+	}
+
+	result.Pass = alertsEqual(expected, got)
+	if !result.Pass {
+		result.Expected = expected
+		result.Got = got
+	}
+	return result
+}
+
+func alertKey(alert v2alpha1.ExpectedAlert) string {
+	return labels.FromMap(alert.Labels).String() + "/" + labels.FromMap(alert.Annotations).String()
+}
+
+func alertsEqual(expected, got []v2alpha1.ExpectedAlert) bool {
+	if len(expected) != len(got) {
+		return false
+	}
+	sort.Slice(expected, func(i, j int) bool { return alertKey(expected[i]) < alertKey(expected[j]) })
+	sort.Slice(got, func(i, j int) bool { return alertKey(got[i]) < alertKey(got[j]) })
+	for i := range expected {
+		if alertKey(expected[i]) != alertKey(got[i]) {
+			return false
+		}
+	}
+	return true
+}
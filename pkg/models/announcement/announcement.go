@@ -0,0 +1,278 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package announcement lets platform admins publish maintenance notices.
+// Announcements are cluster scoped custom resources with an optional active
+// window, the console polls the list endpoint and shows every active
+// announcement the user has not acknowledged yet. Publishing also leaves an
+// event in the KubeSphere namespace so warning and critical notices reach
+// the notification channels.
+package announcement
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+
+	announcementv1alpha1 "kubesphere.io/kubesphere/pkg/apis/announcement/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const publishedEventReason = "AnnouncementPublished"
+
+var announcementGVR = schema.GroupVersionResource{
+	Group:    announcementv1alpha1.SchemeGroupVersion.Group,
+	Version:  announcementv1alpha1.SchemeGroupVersion.Version,
+	Resource: announcementv1alpha1.ResourcePluralAnnouncement,
+}
+
+// View is an announcement as shown to a console user
+type View struct {
+	Name     string                                    `json:"name"`
+	Title    string                                    `json:"title"`
+	Message  string                                    `json:"message"`
+	Severity announcementv1alpha1.AnnouncementSeverity `json:"severity"`
+
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	EndTime   *metav1.Time `json:"endTime,omitempty"`
+
+	// whether the announcement is currently within its active window
+	Active bool `json:"active"`
+
+	// whether the requesting user acknowledged the announcement
+	Acknowledged bool `json:"acknowledged"`
+
+	// how many users acknowledged the announcement
+	Acknowledgements int `json:"acknowledgements"`
+}
+
+type Operator interface {
+	// List returns the announcements as seen by the user, newest first,
+	// optionally only the currently active ones
+	List(username string, activeOnly bool) ([]View, error)
+
+	// Publish creates an announcement and surfaces it to the notification
+	// channels
+	Publish(spec *announcementv1alpha1.AnnouncementSpec) (*View, error)
+
+	Delete(name string) error
+
+	// Acknowledge records that the user has seen the announcement
+	Acknowledge(name, username string) error
+}
+
+type operator struct {
+	client        kubernetes.Interface
+	dynamicClient dynamic.Interface
+}
+
+func NewOperator(client kubernetes.Interface, dynamicClient dynamic.Interface) Operator {
+	return &operator{client: client, dynamicClient: dynamicClient}
+}
+
+// active reports whether the announcement is within its window
+func active(spec *announcementv1alpha1.AnnouncementSpec, now time.Time) bool {
+	if spec.StartTime != nil && now.Before(spec.StartTime.Time) {
+		return false
+	}
+	if spec.EndTime != nil && !now.Before(spec.EndTime.Time) {
+		return false
+	}
+	return true
+}
+
+func acknowledged(status *announcementv1alpha1.AnnouncementStatus, username string) bool {
+	for _, user := range status.AcknowledgedBy {
+		if user == username {
+			return true
+		}
+	}
+	return false
+}
+
+func view(announcement *announcementv1alpha1.Announcement, username string) View {
+	return View{
+		Name:             announcement.Name,
+		Title:            announcement.Spec.Title,
+		Message:          announcement.Spec.Message,
+		Severity:         announcement.Spec.Severity,
+		StartTime:        announcement.Spec.StartTime,
+		EndTime:          announcement.Spec.EndTime,
+		Active:           active(&announcement.Spec, time.Now()),
+		Acknowledged:     acknowledged(&announcement.Status, username),
+		Acknowledgements: len(announcement.Status.AcknowledgedBy),
+	}
+}
+
+func (o *operator) List(username string, activeOnly bool) ([]View, error) {
+	list, err := o.dynamicClient.Resource(announcementGVR).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	announcements := make([]announcementv1alpha1.Announcement, 0, len(list.Items))
+	for i := range list.Items {
+		announcement, err := convert(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, *announcement)
+	}
+	sort.Slice(announcements, func(i, j int) bool {
+		return announcements[j].CreationTimestamp.Before(&announcements[i].CreationTimestamp)
+	})
+
+	views := make([]View, 0, len(announcements))
+	for i := range announcements {
+		if activeOnly && !active(&announcements[i].Spec, time.Now()) {
+			continue
+		}
+		views = append(views, view(&announcements[i], username))
+	}
+	return views, nil
+}
+
+func validSeverity(severity announcementv1alpha1.AnnouncementSeverity) bool {
+	switch severity {
+	case announcementv1alpha1.SeverityInfo, announcementv1alpha1.SeverityWarning, announcementv1alpha1.SeverityCritical:
+		return true
+	}
+	return false
+}
+
+func (o *operator) Publish(spec *announcementv1alpha1.AnnouncementSpec) (*View, error) {
+	if spec.Title == "" || spec.Message == "" {
+		return nil, fmt.Errorf("an announcement needs a title and a message")
+	}
+	if spec.Severity == "" {
+		spec.Severity = announcementv1alpha1.SeverityInfo
+	}
+	if !validSeverity(spec.Severity) {
+		return nil, fmt.Errorf("severity must be info, warning or critical")
+	}
+	if spec.StartTime != nil && spec.EndTime != nil && !spec.StartTime.Before(spec.EndTime) {
+		return nil, fmt.Errorf("the announcement would never be active, startTime is not before endTime")
+	}
+
+	announcement := &announcementv1alpha1.Announcement{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: announcementv1alpha1.SchemeGroupVersion.String(),
+			Kind:       announcementv1alpha1.ResourceKindAnnouncement,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "announcement-",
+		},
+		Spec: *spec,
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(announcement)
+	if err != nil {
+		return nil, err
+	}
+	created, err := o.dynamicClient.Resource(announcementGVR).Create(context.Background(), &unstructured.Unstructured{Object: content}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	o.notify(announcement.Spec, created.GetName())
+
+	result, err := convert(created)
+	if err != nil {
+		return nil, err
+	}
+	published := view(result, "")
+	return &published, nil
+}
+
+// notify leaves an event in the KubeSphere namespace so the announcement
+// reaches the notification channels, warning and critical notices as
+// warning events. Failures only log, the announcement is already published.
+func (o *operator) notify(spec announcementv1alpha1.AnnouncementSpec, name string) {
+	eventType := corev1.EventTypeNormal
+	if spec.Severity != announcementv1alpha1.SeverityInfo {
+		eventType = corev1.EventTypeWarning
+	}
+
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "announcement-",
+			Namespace:    constants.KubeSphereNamespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:       announcementv1alpha1.ResourceKindAnnouncement,
+			Name:       name,
+			APIVersion: announcementv1alpha1.SchemeGroupVersion.String(),
+		},
+		Reason:         publishedEventReason,
+		Message:        fmt.Sprintf("%s: %s", spec.Title, spec.Message),
+		Type:           eventType,
+		FirstTimestamp: metav1.Now(),
+		LastTimestamp:  metav1.Now(),
+		Count:          1,
+		Source:         corev1.EventSource{Component: "ks-apiserver"},
+	}
+	if _, err := o.client.CoreV1().Events(constants.KubeSphereNamespace).Create(context.Background(), event, metav1.CreateOptions{}); err != nil {
+		klog.Errorf("Failed to create event for announcement %s: %v", name, err)
+	}
+}
+
+func (o *operator) Delete(name string) error {
+	return o.dynamicClient.Resource(announcementGVR).Delete(context.Background(), name, metav1.DeleteOptions{})
+}
+
+func (o *operator) Acknowledge(name, username string) error {
+	if username == "" {
+		return fmt.Errorf("acknowledgement needs an authenticated user")
+	}
+
+	obj, err := o.dynamicClient.Resource(announcementGVR).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	announcement, err := convert(obj)
+	if err != nil {
+		return err
+	}
+
+	if acknowledged(&announcement.Status, username) {
+		return nil
+	}
+	users := append(announcement.Status.AcknowledgedBy, username)
+
+	if err := unstructured.SetNestedStringSlice(obj.Object, users, "status", "acknowledgedBy"); err != nil {
+		return err
+	}
+	_, err = o.dynamicClient.Resource(announcementGVR).UpdateStatus(context.Background(), obj, metav1.UpdateOptions{})
+	return err
+}
+
+func convert(obj *unstructured.Unstructured) (*announcementv1alpha1.Announcement, error) {
+	announcement := &announcementv1alpha1.Announcement{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, announcement); err != nil {
+		return nil, err
+	}
+	return announcement, nil
+}
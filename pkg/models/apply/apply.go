@@ -0,0 +1,348 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apply lets the console editor submit YAML through server-side
+// apply instead of blind replace updates. A preview runs the apply as a
+// dry-run and reports the resulting field changes, conflicts with fields
+// owned by controllers come back structured so the console can ask the user
+// before forcing. Every user applies under an own field manager.
+package apply
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/yaml"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+)
+
+// fields that always differ between live and applied objects and carry no
+// information for the user
+var ignoredDiffPaths = []string{
+	"metadata.managedFields",
+	"metadata.resourceVersion",
+	"metadata.generation",
+	"metadata.uid",
+	"metadata.creationTimestamp",
+	"metadata.selfLink",
+	"status",
+}
+
+// Conflict is a field another manager owns that the apply would take over
+type Conflict struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldChange is one field the apply would change
+type FieldChange struct {
+	Path    string      `json:"path"`
+	Live    interface{} `json:"live,omitempty"`
+	Applied interface{} `json:"applied,omitempty"`
+}
+
+// Preview is the dry-run result of applying an edited manifest
+type Preview struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	// whether the object already exists
+	Exists bool `json:"exists"`
+
+	// conflicts with other field managers, when set the apply needs force
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+
+	// fields the apply would change
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// Result is the outcome of an apply
+type Result struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	Applied bool `json:"applied"`
+
+	// set when the apply was rejected because of conflicts and force was
+	// not requested
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+}
+
+type Operator interface {
+	// Preview dry-runs a server-side apply of the manifest and reports
+	// conflicts and field changes
+	Preview(user user.Info, manifest []byte) (*Preview, error)
+
+	// Apply server-side applies the manifest under the user's field
+	// manager, force takes over conflicting fields
+	Apply(user user.Info, manifest []byte, force bool) (*Result, error)
+}
+
+type operator struct {
+	dynamicClient dynamic.Interface
+	discovery     discovery.DiscoveryInterface
+	authorizer    authorizer.Authorizer
+}
+
+func NewOperator(config *rest.Config, authorizer authorizer.Authorizer) (Operator, error) {
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &operator{dynamicClient: dynamicClient, discovery: discoveryClient, authorizer: authorizer}, nil
+}
+
+// fieldManager gives every user an own manager so ownership in
+// managedFields tracks who edited what
+func fieldManager(username string) string {
+	if username == "" {
+		return "kubesphere"
+	}
+	return fmt.Sprintf("kubesphere-%s", username)
+}
+
+// parse decodes a single YAML document into an unstructured object
+func parse(manifest []byte) (*unstructured.Unstructured, error) {
+	content := map[string]interface{}{}
+	if err := yaml.Unmarshal(manifest, &content); err != nil {
+		return nil, fmt.Errorf("manifest is not valid YAML: %v", err)
+	}
+
+	obj := &unstructured.Unstructured{Object: content}
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+		return nil, fmt.Errorf("manifest needs apiVersion and kind")
+	}
+	if obj.GetName() == "" {
+		return nil, fmt.Errorf("manifest needs metadata.name")
+	}
+	return obj, nil
+}
+
+// resource resolves the manifest to the dynamic client of its resource
+func (o *operator) resource(obj *unstructured.Unstructured) (dynamic.ResourceInterface, *meta.RESTMapping, error) {
+	groupResources, err := restmapper.GetAPIGroupResources(o.discovery)
+	if err != nil {
+		return nil, nil, err
+	}
+	mapper := restmapper.NewDiscoveryRESTMapper(groupResources)
+
+	gvk := obj.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unknown resource %s: %v", gvk, err)
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		namespace := obj.GetNamespace()
+		if namespace == "" {
+			return nil, nil, fmt.Errorf("manifest of namespaced kind %s needs metadata.namespace", gvk.Kind)
+		}
+		return o.dynamicClient.Resource(mapping.Resource).Namespace(namespace), mapping, nil
+	}
+	return o.dynamicClient.Resource(mapping.Resource), mapping, nil
+}
+
+// authorize requires the requesting user to be allowed to patch the object
+// the manifest targets. The apply runs with the apiserver's own privileged
+// client and the route carries no namespace, so the decision has to be made
+// here, against what the manifest declares.
+func (o *operator) authorize(requestUser user.Info, obj *unstructured.Unstructured, mapping *meta.RESTMapping) error {
+	resource := mapping.Resource.GroupResource()
+	if o.authorizer == nil || requestUser == nil {
+		return apierrors.NewForbidden(resource, obj.GetName(), fmt.Errorf("unable to authorize the request"))
+	}
+
+	attributes := authorizer.AttributesRecord{
+		User:            requestUser,
+		Verb:            "patch",
+		Namespace:       obj.GetNamespace(),
+		APIGroup:        mapping.Resource.Group,
+		APIVersion:      mapping.Resource.Version,
+		Resource:        mapping.Resource.Resource,
+		Name:            obj.GetName(),
+		ResourceRequest: true,
+		ResourceScope:   request.ClusterScope,
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		attributes.ResourceScope = request.NamespaceScope
+	}
+	decision, reason, err := o.authorizer.Authorize(attributes)
+	if err != nil {
+		return err
+	}
+	if decision != authorizer.DecisionAllow {
+		return apierrors.NewForbidden(resource, obj.GetName(), fmt.Errorf(reason))
+	}
+	return nil
+}
+
+// conflictsFrom extracts the field manager conflicts of a failed apply,
+// nil when the error is something else
+func conflictsFrom(err error) []Conflict {
+	statusError, ok := err.(*apierrors.StatusError)
+	if !ok || statusError.ErrStatus.Details == nil {
+		return nil
+	}
+	var conflicts []Conflict
+	for _, cause := range statusError.ErrStatus.Details.Causes {
+		if cause.Type == metav1.CauseTypeFieldManagerConflict {
+			conflicts = append(conflicts, Conflict{Field: cause.Field, Message: cause.Message})
+		}
+	}
+	return conflicts
+}
+
+func ignored(path string) bool {
+	for _, prefix := range ignoredDiffPaths {
+		if path == prefix || strings.HasPrefix(path, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// diff collects the leaf fields that differ between the live and the
+// applied object
+func diff(path string, live, applied interface{}, changes *[]FieldChange) {
+	if ignored(path) {
+		return
+	}
+
+	liveMap, liveOK := live.(map[string]interface{})
+	appliedMap, appliedOK := applied.(map[string]interface{})
+	if liveOK && appliedOK {
+		keys := map[string]bool{}
+		for key := range liveMap {
+			keys[key] = true
+		}
+		for key := range appliedMap {
+			keys[key] = true
+		}
+		for key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			diff(childPath, liveMap[key], appliedMap[key], changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(live, applied) {
+		*changes = append(*changes, FieldChange{Path: path, Live: live, Applied: applied})
+	}
+}
+
+func (o *operator) Preview(requestUser user.Info, manifest []byte) (*Preview, error) {
+	obj, err := parse(manifest)
+	if err != nil {
+		return nil, err
+	}
+	resource, mapping, err := o.resource(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.authorize(requestUser, obj, mapping); err != nil {
+		return nil, err
+	}
+
+	preview := &Preview{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	ctx := context.Background()
+	live, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	preview.Exists = live != nil && err == nil
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := resource.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: fieldManager(requestUser.GetName()),
+		DryRun:       []string{metav1.DryRunAll},
+	})
+	if err != nil {
+		if conflicts := conflictsFrom(err); conflicts != nil {
+			preview.Conflicts = conflicts
+			return preview, nil
+		}
+		return nil, err
+	}
+
+	var liveContent map[string]interface{}
+	if preview.Exists {
+		liveContent = live.Object
+	}
+	diff("", liveContent, applied.Object, &preview.Changes)
+	return preview, nil
+}
+
+func (o *operator) Apply(requestUser user.Info, manifest []byte, force bool) (*Result, error) {
+	obj, err := parse(manifest)
+	if err != nil {
+		return nil, err
+	}
+	resource, mapping, err := o.resource(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.authorize(requestUser, obj, mapping); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	options := metav1.PatchOptions{FieldManager: fieldManager(requestUser.GetName())}
+	if force {
+		options.Force = &force
+	}
+	if _, err := resource.Patch(context.Background(), obj.GetName(), types.ApplyPatchType, data, options); err != nil {
+		if conflicts := conflictsFrom(err); conflicts != nil {
+			result.Conflicts = conflicts
+			return result, nil
+		}
+		return nil, err
+	}
+
+	result.Applied = true
+	return result, nil
+}
@@ -0,0 +1,326 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package autoscaling manages HorizontalPodAutoscaler v2 objects with
+// custom and external metrics. Metric references are validated against the
+// custom.metrics.k8s.io and external.metrics.k8s.io discovery APIs before an
+// HPA is stored, so a typo surfaces as a request error instead of an HPA
+// stuck at FailedGetPodsMetric.
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	customMetricsGroupVersion   = "custom.metrics.k8s.io/v1beta1"
+	externalMetricsGroupVersion = "external.metrics.k8s.io/v1beta1"
+)
+
+// MetricValue is one metric of an HPA with its target and, when the HPA is
+// active, its currently observed value.
+type MetricValue struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Target  string `json:"target"`
+	Current string `json:"current,omitempty"`
+}
+
+// Detail is an HPA with its scaling decision rendered for display.
+type Detail struct {
+	autoscalingv2beta2.HorizontalPodAutoscaler `json:",inline"`
+
+	Metrics []MetricValue `json:"metrics,omitempty"`
+}
+
+type Operator interface {
+	List(namespace string) ([]Detail, error)
+	Get(namespace, name string) (*Detail, error)
+	Create(namespace string, hpa *autoscalingv2beta2.HorizontalPodAutoscaler) (*autoscalingv2beta2.HorizontalPodAutoscaler, error)
+	Update(namespace string, hpa *autoscalingv2beta2.HorizontalPodAutoscaler) (*autoscalingv2beta2.HorizontalPodAutoscaler, error)
+	Delete(namespace, name string) error
+
+	// ListCustomMetrics lists the metric names the custom metrics
+	// adapter currently serves
+	ListCustomMetrics() ([]string, error)
+
+	// ListExternalMetrics lists the metric names the external metrics
+	// adapter currently serves
+	ListExternalMetrics() ([]string, error)
+}
+
+type operator struct {
+	client kubernetes.Interface
+}
+
+func NewOperator(client kubernetes.Interface) Operator {
+	return &operator{client: client}
+}
+
+func (o *operator) List(namespace string) ([]Detail, error) {
+	hpas, err := o.client.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Detail, 0, len(hpas.Items))
+	for i := range hpas.Items {
+		result = append(result, renderDetail(&hpas.Items[i]))
+	}
+	return result, nil
+}
+
+func (o *operator) Get(namespace, name string) (*Detail, error) {
+	hpa, err := o.client.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	detail := renderDetail(hpa)
+	return &detail, nil
+}
+
+func (o *operator) Create(namespace string, hpa *autoscalingv2beta2.HorizontalPodAutoscaler) (*autoscalingv2beta2.HorizontalPodAutoscaler, error) {
+	if err := o.validateMetrics(hpa); err != nil {
+		return nil, err
+	}
+	return o.client.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).Create(context.Background(), hpa, metav1.CreateOptions{})
+}
+
+func (o *operator) Update(namespace string, hpa *autoscalingv2beta2.HorizontalPodAutoscaler) (*autoscalingv2beta2.HorizontalPodAutoscaler, error) {
+	if err := o.validateMetrics(hpa); err != nil {
+		return nil, err
+	}
+	current, err := o.client.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).Get(context.Background(), hpa.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	current.Labels = hpa.Labels
+	current.Annotations = hpa.Annotations
+	current.Spec = hpa.Spec
+	return o.client.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).Update(context.Background(), current, metav1.UpdateOptions{})
+}
+
+func (o *operator) Delete(namespace, name string) error {
+	return o.client.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+}
+
+func (o *operator) ListCustomMetrics() ([]string, error) {
+	return o.listAdapterMetrics(customMetricsGroupVersion, true)
+}
+
+func (o *operator) ListExternalMetrics() ([]string, error) {
+	return o.listAdapterMetrics(externalMetricsGroupVersion, false)
+}
+
+// listAdapterMetrics lists the metric names served by a metrics adapter.
+// Custom metrics resources are named <resource>/<metric>, external metrics
+// resources carry the metric name directly.
+func (o *operator) listAdapterMetrics(groupVersion string, stripResource bool) ([]string, error) {
+	resources, err := o.client.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, resource := range resources.APIResources {
+		name := resource.Name
+		if stripResource {
+			if index := strings.Index(name, "/"); index >= 0 {
+				name = name[index+1:]
+			}
+		}
+		names[name] = true
+	}
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// validateMetrics checks that every custom and external metric the HPA
+// references is actually served by the corresponding metrics adapter.
+func (o *operator) validateMetrics(hpa *autoscalingv2beta2.HorizontalPodAutoscaler) error {
+	var custom, external []string
+	for _, metric := range hpa.Spec.Metrics {
+		switch metric.Type {
+		case autoscalingv2beta2.ResourceMetricSourceType:
+			if metric.Resource == nil {
+				return fmt.Errorf("resource metric source must not be empty")
+			}
+			if metric.Resource.Name != corev1.ResourceCPU && metric.Resource.Name != corev1.ResourceMemory {
+				return fmt.Errorf("unsupported resource metric %s, only cpu and memory are supported", metric.Resource.Name)
+			}
+		case autoscalingv2beta2.PodsMetricSourceType:
+			if metric.Pods == nil {
+				return fmt.Errorf("pods metric source must not be empty")
+			}
+			custom = append(custom, metric.Pods.Metric.Name)
+		case autoscalingv2beta2.ObjectMetricSourceType:
+			if metric.Object == nil {
+				return fmt.Errorf("object metric source must not be empty")
+			}
+			custom = append(custom, metric.Object.Metric.Name)
+		case autoscalingv2beta2.ExternalMetricSourceType:
+			if metric.External == nil {
+				return fmt.Errorf("external metric source must not be empty")
+			}
+			external = append(external, metric.External.Metric.Name)
+		}
+	}
+
+	if len(custom) > 0 {
+		if err := o.checkMetricsExist(customMetricsGroupVersion, "custom", custom); err != nil {
+			return err
+		}
+	}
+	if len(external) > 0 {
+		if err := o.checkMetricsExist(externalMetricsGroupVersion, "external", external); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *operator) checkMetricsExist(groupVersion, kind string, metrics []string) error {
+	var available []string
+	var err error
+	if kind == "custom" {
+		available, err = o.ListCustomMetrics()
+	} else {
+		available, err = o.ListExternalMetrics()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list %s metrics: %v", kind, err)
+	}
+	if len(available) == 0 {
+		return fmt.Errorf("the %s metrics API %s is not available, is the metrics adapter installed", kind, groupVersion)
+	}
+	served := map[string]bool{}
+	for _, name := range available {
+		served[name] = true
+	}
+	for _, name := range metrics {
+		if !served[name] {
+			return fmt.Errorf("%s metric %s is not served by the metrics adapter", kind, name)
+		}
+	}
+	return nil
+}
+
+func renderDetail(hpa *autoscalingv2beta2.HorizontalPodAutoscaler) Detail {
+	detail := Detail{HorizontalPodAutoscaler: *hpa}
+
+	current := map[string]string{}
+	for _, metric := range hpa.Status.CurrentMetrics {
+		name, value := renderMetricStatus(&metric)
+		if name != "" {
+			current[name] = value
+		}
+	}
+	for _, metric := range hpa.Spec.Metrics {
+		name, target := renderMetricSpec(&metric)
+		if name == "" {
+			continue
+		}
+		detail.Metrics = append(detail.Metrics, MetricValue{
+			Type:    string(metric.Type),
+			Name:    name,
+			Target:  target,
+			Current: current[name],
+		})
+	}
+	return detail
+}
+
+func renderMetricSpec(metric *autoscalingv2beta2.MetricSpec) (string, string) {
+	switch metric.Type {
+	case autoscalingv2beta2.ResourceMetricSourceType:
+		if metric.Resource != nil {
+			return string(metric.Resource.Name), renderTarget(&metric.Resource.Target)
+		}
+	case autoscalingv2beta2.PodsMetricSourceType:
+		if metric.Pods != nil {
+			return metric.Pods.Metric.Name, renderTarget(&metric.Pods.Target)
+		}
+	case autoscalingv2beta2.ObjectMetricSourceType:
+		if metric.Object != nil {
+			return metric.Object.Metric.Name, renderTarget(&metric.Object.Target)
+		}
+	case autoscalingv2beta2.ExternalMetricSourceType:
+		if metric.External != nil {
+			return metric.External.Metric.Name, renderTarget(&metric.External.Target)
+		}
+	}
+	return "", ""
+}
+
+func renderMetricStatus(metric *autoscalingv2beta2.MetricStatus) (string, string) {
+	switch metric.Type {
+	case autoscalingv2beta2.ResourceMetricSourceType:
+		if metric.Resource != nil {
+			return string(metric.Resource.Name), renderValue(&metric.Resource.Current)
+		}
+	case autoscalingv2beta2.PodsMetricSourceType:
+		if metric.Pods != nil {
+			return metric.Pods.Metric.Name, renderValue(&metric.Pods.Current)
+		}
+	case autoscalingv2beta2.ObjectMetricSourceType:
+		if metric.Object != nil {
+			return metric.Object.Metric.Name, renderValue(&metric.Object.Current)
+		}
+	case autoscalingv2beta2.ExternalMetricSourceType:
+		if metric.External != nil {
+			return metric.External.Metric.Name, renderValue(&metric.External.Current)
+		}
+	}
+	return "", ""
+}
+
+func renderTarget(target *autoscalingv2beta2.MetricTarget) string {
+	switch {
+	case target.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *target.AverageUtilization)
+	case target.AverageValue != nil:
+		return target.AverageValue.String()
+	case target.Value != nil:
+		return target.Value.String()
+	}
+	return ""
+}
+
+func renderValue(value *autoscalingv2beta2.MetricValueStatus) string {
+	switch {
+	case value.AverageUtilization != nil:
+		return fmt.Sprintf("%d%%", *value.AverageUtilization)
+	case value.AverageValue != nil:
+		return value.AverageValue.String()
+	case value.Value != nil:
+		return value.Value.String()
+	}
+	return ""
+}
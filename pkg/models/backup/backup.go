@@ -0,0 +1,242 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/utils/idutils"
+)
+
+const (
+	// VeleroNamespace is the namespace velero is installed in
+	VeleroNamespace = "velero"
+
+	scheduleNameLabel = "velero.io/schedule-name"
+)
+
+var (
+	scheduleGVR = schema.GroupVersionResource{Group: "velero.io", Version: "v1", Resource: "schedules"}
+	backupGVR   = schema.GroupVersionResource{Group: "velero.io", Version: "v1", Resource: "backups"}
+	restoreGVR  = schema.GroupVersionResource{Group: "velero.io", Version: "v1", Resource: "restores"}
+)
+
+type Interface interface {
+	CreateBackupPlan(namespace string, plan *BackupPlan) (*BackupPlan, error)
+	ListBackupPlans(namespace string) ([]*BackupPlan, error)
+	DeleteBackupPlan(namespace, plan string) error
+	ListBackups(namespace string) ([]*Backup, error)
+	CreateRestore(namespace string, request *RestoreRequest) (*Restore, error)
+	ListRestores(namespace string) ([]*Restore, error)
+}
+
+type backupOperator struct {
+	client dynamic.Interface
+}
+
+func NewBackupOperator(client dynamic.Interface) Interface {
+	return &backupOperator{client: client}
+}
+
+// CreateBackupPlan creates a velero Schedule which backs up the given
+// namespace at the requested cron schedule, keeping backups for the
+// retention period.
+func (o *backupOperator) CreateBackupPlan(namespace string, plan *BackupPlan) (*BackupPlan, error) {
+	template := map[string]interface{}{
+		"includedNamespaces": []interface{}{namespace},
+	}
+	if plan.Retention != "" {
+		template["ttl"] = plan.Retention
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "velero.io/v1",
+		"kind":       "Schedule",
+		"metadata": map[string]interface{}{
+			"name":      fmt.Sprintf("%s-%s", namespace, plan.Name),
+			"namespace": VeleroNamespace,
+			"labels": map[string]interface{}{
+				constants.NamespaceLabelKey: namespace,
+			},
+			"annotations": map[string]interface{}{
+				constants.DisplayNameAnnotationKey: plan.Name,
+			},
+		},
+		"spec": map[string]interface{}{
+			"schedule": plan.Schedule,
+			"paused":   plan.Paused,
+			"template": template,
+		},
+	}}
+
+	created, err := o.client.Resource(scheduleGVR).Namespace(VeleroNamespace).Create(context.TODO(), obj, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("create backup plan %s/%s failed, error: %s", namespace, plan.Name, err)
+		return nil, err
+	}
+
+	return convertBackupPlan(created), nil
+}
+
+func (o *backupOperator) ListBackupPlans(namespace string) ([]*BackupPlan, error) {
+	list, err := o.client.Resource(scheduleGVR).Namespace(VeleroNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", constants.NamespaceLabelKey, namespace),
+	})
+	if err != nil {
+		klog.Errorf("list backup plans of namespace %s failed, error: %s", namespace, err)
+		return nil, err
+	}
+
+	plans := make([]*BackupPlan, 0, len(list.Items))
+	for i := range list.Items {
+		plans = append(plans, convertBackupPlan(&list.Items[i]))
+	}
+	return plans, nil
+}
+
+func (o *backupOperator) DeleteBackupPlan(namespace, plan string) error {
+	err := o.client.Resource(scheduleGVR).Namespace(VeleroNamespace).Delete(context.TODO(),
+		fmt.Sprintf("%s-%s", namespace, plan), metav1.DeleteOptions{})
+	if err != nil {
+		klog.Errorf("delete backup plan %s/%s failed, error: %s", namespace, plan, err)
+	}
+	return err
+}
+
+func (o *backupOperator) ListBackups(namespace string) ([]*Backup, error) {
+	list, err := o.client.Resource(backupGVR).Namespace(VeleroNamespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("list backups of namespace %s failed, error: %s", namespace, err)
+		return nil, err
+	}
+
+	backups := make([]*Backup, 0)
+	for i := range list.Items {
+		item := &list.Items[i]
+		namespaces, _, _ := unstructured.NestedStringSlice(item.Object, "spec", "includedNamespaces")
+		for _, ns := range namespaces {
+			if ns == namespace {
+				backups = append(backups, convertBackup(item, namespace))
+				break
+			}
+		}
+	}
+	return backups, nil
+}
+
+// CreateRestore restores a backup, mapping the backed up namespace to the
+// target namespace if one is given.
+func (o *backupOperator) CreateRestore(namespace string, request *RestoreRequest) (*Restore, error) {
+	spec := map[string]interface{}{
+		"backupName":         request.BackupName,
+		"includedNamespaces": []interface{}{namespace},
+	}
+	if request.TargetNamespace != "" && request.TargetNamespace != namespace {
+		spec["namespaceMapping"] = map[string]interface{}{
+			namespace: request.TargetNamespace,
+		}
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "velero.io/v1",
+		"kind":       "Restore",
+		"metadata": map[string]interface{}{
+			"name":      idutils.GetUuid36(fmt.Sprintf("%s-restore-", request.BackupName)),
+			"namespace": VeleroNamespace,
+			"labels": map[string]interface{}{
+				constants.NamespaceLabelKey: namespace,
+			},
+		},
+		"spec": spec,
+	}}
+
+	created, err := o.client.Resource(restoreGVR).Namespace(VeleroNamespace).Create(context.TODO(), obj, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("create restore from backup %s failed, error: %s", request.BackupName, err)
+		return nil, err
+	}
+
+	return convertRestore(created), nil
+}
+
+func (o *backupOperator) ListRestores(namespace string) ([]*Restore, error) {
+	list, err := o.client.Resource(restoreGVR).Namespace(VeleroNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", constants.NamespaceLabelKey, namespace),
+	})
+	if err != nil {
+		klog.Errorf("list restores of namespace %s failed, error: %s", namespace, err)
+		return nil, err
+	}
+
+	restores := make([]*Restore, 0, len(list.Items))
+	for i := range list.Items {
+		restores = append(restores, convertRestore(&list.Items[i]))
+	}
+	return restores, nil
+}
+
+func convertBackupPlan(obj *unstructured.Unstructured) *BackupPlan {
+	plan := &BackupPlan{
+		Name:      obj.GetName(),
+		Namespace: obj.GetLabels()[constants.NamespaceLabelKey],
+	}
+	if displayName := obj.GetAnnotations()[constants.DisplayNameAnnotationKey]; displayName != "" {
+		plan.Name = displayName
+	}
+	plan.Schedule, _, _ = unstructured.NestedString(obj.Object, "spec", "schedule")
+	plan.Retention, _, _ = unstructured.NestedString(obj.Object, "spec", "template", "ttl")
+	plan.Paused, _, _ = unstructured.NestedBool(obj.Object, "spec", "paused")
+	plan.Status, _, _ = unstructured.NestedString(obj.Object, "status", "phase")
+	plan.LastBackup, _, _ = unstructured.NestedString(obj.Object, "status", "lastBackup")
+	return plan
+}
+
+func convertBackup(obj *unstructured.Unstructured, namespace string) *Backup {
+	backup := &Backup{
+		Name:       obj.GetName(),
+		BackupPlan: obj.GetLabels()[scheduleNameLabel],
+		Namespace:  namespace,
+	}
+	backup.Status, _, _ = unstructured.NestedString(obj.Object, "status", "phase")
+	backup.StartTime, _, _ = unstructured.NestedString(obj.Object, "status", "startTimestamp")
+	backup.Expiration, _, _ = unstructured.NestedString(obj.Object, "status", "expiration")
+	return backup
+}
+
+func convertRestore(obj *unstructured.Unstructured) *Restore {
+	restore := &Restore{
+		Name:      obj.GetName(),
+		Namespace: obj.GetLabels()[constants.NamespaceLabelKey],
+	}
+	restore.BackupName, _, _ = unstructured.NestedString(obj.Object, "spec", "backupName")
+	mapping, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "namespaceMapping")
+	if target, ok := mapping[restore.Namespace]; ok {
+		restore.TargetNamespace = target
+	} else {
+		restore.TargetNamespace = restore.Namespace
+	}
+	restore.Status, _, _ = unstructured.NestedString(obj.Object, "status", "phase")
+	return restore
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+// BackupPlan describes a scheduled backup of a namespace, backed by a velero
+// Schedule object in the velero install namespace.
+type BackupPlan struct {
+	Name string `json:"name" description:"backup plan name"`
+
+	// namespace whose resources and volumes are backed up
+	Namespace string `json:"namespace" description:"namespace to back up"`
+
+	// cron expression, e.g. 0 2 * * *
+	Schedule string `json:"schedule" description:"backup schedule in cron format"`
+
+	// how long the backups created from this plan are kept, e.g. 720h
+	Retention string `json:"retention,omitempty" description:"retention period of backups, duration format, e.g. 720h"`
+
+	// whether the schedule is paused
+	Paused bool `json:"paused,omitempty" description:"whether the backup plan is paused"`
+
+	// phase of the velero schedule
+	Status string `json:"status,omitempty" description:"status of the backup plan"`
+
+	// last time a backup was created from this plan
+	LastBackup string `json:"lastBackup,omitempty" description:"time of the last backup"`
+}
+
+// Backup is a single point-in-time backup created from a plan.
+type Backup struct {
+	Name string `json:"name" description:"backup name"`
+
+	// plan this backup was created from, empty for manual backups
+	BackupPlan string `json:"backupPlan,omitempty" description:"backup plan the backup was created from"`
+
+	Namespace string `json:"namespace,omitempty" description:"namespace included in the backup"`
+
+	Status string `json:"status,omitempty" description:"phase of the backup"`
+
+	StartTime string `json:"startTime,omitempty" description:"time the backup started"`
+
+	// time the backup will be garbage collected
+	Expiration string `json:"expiration,omitempty" description:"time the backup expires"`
+}
+
+// RestoreRequest restores a backup, optionally into a different namespace.
+type RestoreRequest struct {
+	// backup to restore from
+	BackupName string `json:"backupName" description:"name of the backup to restore from"`
+
+	// optional, restore into this namespace instead of the original one
+	TargetNamespace string `json:"targetNamespace,omitempty" description:"restore into this namespace instead of the original one"`
+}
+
+// Restore is the status of a restore operation.
+type Restore struct {
+	Name string `json:"name" description:"restore name"`
+
+	BackupName string `json:"backupName" description:"backup the restore was created from"`
+
+	Namespace string `json:"namespace,omitempty" description:"namespace the backup was taken from"`
+
+	TargetNamespace string `json:"targetNamespace,omitempty" description:"namespace the backup is restored into"`
+
+	Status string `json:"status,omitempty" description:"phase of the restore"`
+}
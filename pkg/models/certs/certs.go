@@ -0,0 +1,290 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package certs inventories the control-plane and kubelet serving
+// certificates of the clusters KubeSphere manages, so expiring certificates
+// surface before they brick a cluster. Certificates are read off the wire
+// with a TLS handshake, no access to the node filesystem is needed.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+
+	clusterv1alpha1 "kubesphere.io/kubesphere/pkg/apis/cluster/v1alpha1"
+	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+)
+
+const (
+	ComponentAPIServer = "kube-apiserver"
+	ComponentKubelet   = "kubelet"
+	ComponentClusterCA = "cluster-ca"
+
+	// ExpiryWarningThreshold is how long before NotAfter a certificate is
+	// reported as expiring soon
+	ExpiryWarningThreshold = 30 * 24 * time.Hour
+
+	// HostClusterName names the cluster running this process when no
+	// Cluster objects exist, i.e. multicluster is disabled
+	HostClusterName = "host"
+
+	kubeletPort = "10250"
+	dialTimeout = 3 * time.Second
+)
+
+// Certificate is one serving certificate of a cluster component.
+type Certificate struct {
+	Cluster       string      `json:"cluster"`
+	Component     string      `json:"component"`
+	Node          string      `json:"node,omitempty"`
+	Subject       string      `json:"subject"`
+	Issuer        string      `json:"issuer"`
+	NotBefore     metav1.Time `json:"notBefore"`
+	NotAfter      metav1.Time `json:"notAfter"`
+	DaysRemaining int         `json:"daysRemaining"`
+	Expired       bool        `json:"expired"`
+	ExpiringSoon  bool        `json:"expiringSoon"`
+}
+
+type Operator interface {
+	// ListCertificates inventories the certificates of every ready cluster
+	ListCertificates() ([]Certificate, error)
+
+	// ListClusterCertificates inventories the certificates of one cluster
+	ListClusterCertificates(cluster string) ([]Certificate, error)
+
+	// RotateKubeletCertificate approves the node's pending serving
+	// certificate requests, unblocking the kubelet's own rotation
+	RotateKubeletCertificate(cluster, node string) error
+}
+
+type operator struct {
+	client   kubernetes.Interface
+	config   *rest.Config
+	ksClient kubesphere.Interface
+}
+
+func NewOperator(client kubernetes.Interface, config *rest.Config, ksClient kubesphere.Interface) Operator {
+	return &operator{client: client, config: config, ksClient: ksClient}
+}
+
+func (o *operator) ListCertificates() ([]Certificate, error) {
+	clusters, err := o.ksClient.ClusterV1alpha1().Clusters().List(context.Background(), metav1.ListOptions{})
+	if err != nil || len(clusters.Items) == 0 {
+		// multicluster disabled, fall back to the cluster we run in
+		return o.scanCluster(HostClusterName, o.client, o.config)
+	}
+
+	var result []Certificate
+	for _, cluster := range clusters.Items {
+		certificates, err := o.ListClusterCertificates(cluster.Name)
+		if err != nil {
+			klog.Errorf("failed to inventory certificates of cluster %s: %v", cluster.Name, err)
+			continue
+		}
+		result = append(result, certificates...)
+	}
+	return result, nil
+}
+
+func (o *operator) ListClusterCertificates(cluster string) ([]Certificate, error) {
+	client, config, err := o.clusterAccess(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return o.scanCluster(cluster, client, config)
+}
+
+func (o *operator) RotateKubeletCertificate(cluster, node string) error {
+	client, _, err := o.clusterAccess(cluster)
+	if err != nil {
+		return err
+	}
+
+	csrs, err := client.CertificatesV1beta1().CertificateSigningRequests().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	username := fmt.Sprintf("system:node:%s", node)
+	approved := 0
+	for i := range csrs.Items {
+		csr := &csrs.Items[i]
+		if csr.Spec.Username != username || len(csr.Status.Certificate) > 0 || len(csr.Status.Conditions) > 0 {
+			continue
+		}
+		csr.Status.Conditions = []certificatesv1beta1.CertificateSigningRequestCondition{{
+			Type:           certificatesv1beta1.CertificateApproved,
+			Reason:         "KubeSphereRotation",
+			Message:        "Approved by the KubeSphere certificate rotation API",
+			LastUpdateTime: metav1.Now(),
+		}}
+		if _, err = client.CertificatesV1beta1().CertificateSigningRequests().UpdateApproval(context.Background(), csr, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		approved++
+	}
+	if approved == 0 {
+		return fmt.Errorf("no pending certificate signing request for node %s, restart the kubelet to request a new serving certificate", node)
+	}
+	return nil
+}
+
+// clusterAccess returns a client and rest config for the named cluster, the
+// local ones for the host cluster and ones built from the stored kubeconfig
+// for member clusters.
+func (o *operator) clusterAccess(name string) (kubernetes.Interface, *rest.Config, error) {
+	if name == HostClusterName {
+		return o.client, o.config, nil
+	}
+	cluster, err := o.ksClient.ClusterV1alpha1().Clusters().Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := cluster.Labels[clusterv1alpha1.HostCluster]; ok || len(cluster.Spec.Connection.KubeConfig) == 0 {
+		return o.client, o.config, nil
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(cluster.Spec.Connection.KubeConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, config, nil
+}
+
+func (o *operator) scanCluster(name string, client kubernetes.Interface, config *rest.Config) ([]Certificate, error) {
+	var result []Certificate
+
+	if cert, err := serverCertificate(apiServerAddress(config)); err != nil {
+		klog.Errorf("failed to read apiserver certificate of cluster %s: %v", name, err)
+	} else {
+		result = append(result, render(name, ComponentAPIServer, "", cert))
+	}
+
+	if len(config.TLSClientConfig.CAData) > 0 {
+		if ca, err := parseCertificate(config.TLSClientConfig.CAData); err == nil {
+			result = append(result, render(name, ComponentClusterCA, "", ca))
+		}
+	}
+
+	nodes, err := client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return result, err
+	}
+	for _, node := range nodes.Items {
+		address := nodeAddress(&node)
+		if address == "" {
+			continue
+		}
+		cert, err := serverCertificate(net.JoinHostPort(address, kubeletPort))
+		if err != nil {
+			klog.V(4).Infof("failed to read kubelet certificate of node %s/%s: %v", name, node.Name, err)
+			continue
+		}
+		result = append(result, render(name, ComponentKubelet, node.Name, cert))
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].NotAfter.Equal(&result[j].NotAfter) {
+			return result[i].Node < result[j].Node
+		}
+		return result[i].NotAfter.Before(&result[j].NotAfter)
+	})
+	return result, nil
+}
+
+// serverCertificate performs a TLS handshake against the address and returns
+// the leaf certificate presented by the server
+func serverCertificate(address string) (*x509.Certificate, error) {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", address, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	certificates := conn.ConnectionState().PeerCertificates
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("no certificate presented by %s", address)
+	}
+	return certificates[0], nil
+}
+
+func parseCertificate(pemData []byte) (*x509.Certificate, error) {
+	for len(pemData) > 0 {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+	return nil, fmt.Errorf("no certificate found in PEM data")
+}
+
+func apiServerAddress(config *rest.Config) string {
+	address := config.Host
+	if u, err := url.Parse(config.Host); err == nil && u.Host != "" {
+		address = u.Host
+	}
+	if !strings.Contains(address, ":") {
+		address = net.JoinHostPort(address, "443")
+	}
+	return address
+}
+
+func nodeAddress(node *corev1.Node) string {
+	for _, address := range node.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP {
+			return address.Address
+		}
+	}
+	return ""
+}
+
+func render(cluster, component, node string, cert *x509.Certificate) Certificate {
+	remaining := time.Until(cert.NotAfter)
+	return Certificate{
+		Cluster:       cluster,
+		Component:     component,
+		Node:          node,
+		Subject:       cert.Subject.String(),
+		Issuer:        cert.Issuer.String(),
+		NotBefore:     metav1.NewTime(cert.NotBefore),
+		NotAfter:      metav1.NewTime(cert.NotAfter),
+		DaysRemaining: int(remaining.Hours() / 24),
+		Expired:       remaining <= 0,
+		ExpiringSoon:  remaining > 0 && remaining <= ExpiryWarningThreshold,
+	}
+}
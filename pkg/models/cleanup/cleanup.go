@@ -0,0 +1,437 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
+)
+
+const (
+	// scanInterval bounds how often a full scan runs, Report serves the
+	// cached result in between
+	scanInterval = 10 * time.Minute
+
+	KindPersistentVolume = "persistentvolumes"
+	KindConfigMap        = "configmaps"
+	KindSecret           = "secrets"
+	KindService          = "services"
+	KindImage            = "images"
+)
+
+// systemNamespaces are never scanned, their unreferenced objects are usually
+// held for the control plane rather than leaked.
+var systemNamespaces = []string{metav1.NamespaceSystem, metav1.NamespacePublic, corev1.NamespaceNodeLease}
+
+// OrphanedResource is one resource no longer referenced by anything the
+// scanner knows about.
+type OrphanedResource struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Reason explains why the resource counts as orphaned
+	Reason string `json:"reason"`
+	// Cleanable marks resources the cleanup endpoint can delete, images for
+	// example are only reported
+	Cleanable bool `json:"cleanable"`
+}
+
+// Report is the result of one scan.
+type Report struct {
+	ScannedAt metav1.Time        `json:"scannedAt"`
+	Orphans   []OrphanedResource `json:"orphans"`
+}
+
+type Operator interface {
+	// Report returns the latest orphan report, rescanning first when the
+	// cached one is older than the scan interval. A non-empty namespace
+	// filters the report to that namespace.
+	Report(namespace string) (*Report, error)
+
+	// Scan runs a fresh scan regardless of the cache
+	Scan() (*Report, error)
+
+	// Cleanup deletes the orphaned resource. It refuses resources that are
+	// not in the latest report and re-verifies the resource is still
+	// orphaned right before deleting, so a resource that gained a consumer
+	// since the scan is never removed.
+	Cleanup(namespace, kind, name string) error
+}
+
+type operator struct {
+	client kubernetes.Interface
+
+	mutex  sync.Mutex
+	report *Report
+}
+
+func NewOperator(client kubernetes.Interface) Operator {
+	return &operator{client: client}
+}
+
+func (o *operator) Report(namespace string) (*Report, error) {
+	o.mutex.Lock()
+	report := o.report
+	o.mutex.Unlock()
+
+	if report == nil || time.Since(report.ScannedAt.Time) > scanInterval {
+		var err error
+		if report, err = o.Scan(); err != nil {
+			return nil, err
+		}
+	}
+	if namespace == "" {
+		return report, nil
+	}
+
+	filtered := &Report{ScannedAt: report.ScannedAt, Orphans: []OrphanedResource{}}
+	for _, orphan := range report.Orphans {
+		if orphan.Namespace == namespace {
+			filtered.Orphans = append(filtered.Orphans, orphan)
+		}
+	}
+	return filtered, nil
+}
+
+func (o *operator) Scan() (*Report, error) {
+	ctx := context.Background()
+
+	pods, err := o.client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	refs := collectPodReferences(pods.Items)
+
+	orphans := []OrphanedResource{}
+
+	volumes, err := o.client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, pv := range volumes.Items {
+		if reason := persistentVolumeOrphaned(&pv); reason != "" {
+			orphans = append(orphans, OrphanedResource{
+				Kind:      KindPersistentVolume,
+				Name:      pv.Name,
+				Reason:    reason,
+				Cleanable: true,
+			})
+		}
+	}
+
+	configMaps, err := o.client.CoreV1().ConfigMaps("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, cm := range configMaps.Items {
+		if skipNamespace(cm.Namespace) || !configMapOrphanable(&cm) {
+			continue
+		}
+		if !refs.configMaps[cm.Namespace+"/"+cm.Name] {
+			orphans = append(orphans, OrphanedResource{
+				Kind:      KindConfigMap,
+				Namespace: cm.Namespace,
+				Name:      cm.Name,
+				Reason:    "not referenced by any pod",
+				Cleanable: true,
+			})
+		}
+	}
+
+	secrets, err := o.client.CoreV1().Secrets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets.Items {
+		if skipNamespace(secret.Namespace) || !secretOrphanable(&secret) {
+			continue
+		}
+		if !refs.secrets[secret.Namespace+"/"+secret.Name] {
+			orphans = append(orphans, OrphanedResource{
+				Kind:      KindSecret,
+				Namespace: secret.Namespace,
+				Name:      secret.Name,
+				Reason:    "not referenced by any pod",
+				Cleanable: true,
+			})
+		}
+	}
+
+	serviceOrphans, err := o.serviceOrphans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orphans = append(orphans, serviceOrphans...)
+
+	imageOrphans, err := o.imageOrphans(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	orphans = append(orphans, imageOrphans...)
+
+	report := &Report{ScannedAt: metav1.Now(), Orphans: orphans}
+	o.mutex.Lock()
+	o.report = report
+	o.mutex.Unlock()
+	return report, nil
+}
+
+func (o *operator) serviceOrphans(ctx context.Context) ([]OrphanedResource, error) {
+	services, err := o.client.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var orphans []OrphanedResource
+	for i := range services.Items {
+		service := &services.Items[i]
+		if skipNamespace(service.Namespace) || service.Spec.Type == corev1.ServiceTypeExternalName {
+			continue
+		}
+		orphaned, err := o.serviceOrphaned(ctx, service)
+		if err != nil {
+			return nil, err
+		}
+		if !orphaned {
+			continue
+		}
+		orphans = append(orphans, OrphanedResource{
+			Kind:      KindService,
+			Namespace: service.Namespace,
+			Name:      service.Name,
+			Reason:    "selector does not match any pod",
+			Cleanable: true,
+		})
+	}
+	return orphans, nil
+}
+
+// serviceOrphaned reports whether the service's selector matches no pod at
+// all. Endpoint readiness is deliberately not considered, a scaled-to-zero
+// or briefly unready workload must not make its service deletable. Services
+// without a selector keep their endpoints maintained by hand and are skipped.
+func (o *operator) serviceOrphaned(ctx context.Context, service *corev1.Service) (bool, error) {
+	if len(service.Spec.Selector) == 0 {
+		return false, nil
+	}
+	selector := labels.SelectorFromSet(service.Spec.Selector).String()
+	pods, err := o.client.CoreV1().Pods(service.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return false, err
+	}
+	return len(pods.Items) == 0, nil
+}
+
+// imageOrphans reports node images no running pod uses. They cannot be
+// removed through the API, the kubelet garbage collects them on disk
+// pressure, so they are report-only.
+func (o *operator) imageOrphans(ctx context.Context, refs podReferences) ([]OrphanedResource, error) {
+	nodes, err := o.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	reported := map[string]bool{}
+	var orphans []OrphanedResource
+	for _, node := range nodes.Items {
+		for _, image := range node.Status.Images {
+			for _, name := range image.Names {
+				if refs.images[name] || reported[name] {
+					continue
+				}
+				reported[name] = true
+				orphans = append(orphans, OrphanedResource{
+					Kind:      KindImage,
+					Name:      name,
+					Reason:    "not used by any pod",
+					Cleanable: false,
+				})
+			}
+		}
+	}
+	return orphans, nil
+}
+
+func (o *operator) Cleanup(namespace, kind, name string) error {
+	o.mutex.Lock()
+	report := o.report
+	o.mutex.Unlock()
+	if report == nil || !inReport(report, namespace, kind, name) {
+		return fmt.Errorf("%s %s/%s is not in the latest orphan report, scan first", kind, namespace, name)
+	}
+
+	orphaned, err := o.stillOrphaned(namespace, kind, name)
+	if err != nil {
+		return err
+	}
+	if !orphaned {
+		return fmt.Errorf("%s %s/%s is no longer orphaned, rescan to refresh the report", kind, namespace, name)
+	}
+
+	ctx := context.Background()
+	switch kind {
+	case KindPersistentVolume:
+		return o.client.CoreV1().PersistentVolumes().Delete(ctx, name, metav1.DeleteOptions{})
+	case KindConfigMap:
+		return o.client.CoreV1().ConfigMaps(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	case KindSecret:
+		return o.client.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	case KindService:
+		return o.client.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	default:
+		return fmt.Errorf("cleanup does not support kind %s", kind)
+	}
+}
+
+// stillOrphaned re-runs the orphan check for a single resource against the
+// live cluster state.
+func (o *operator) stillOrphaned(namespace, kind, name string) (bool, error) {
+	ctx := context.Background()
+	switch kind {
+	case KindPersistentVolume:
+		pv, err := o.client.CoreV1().PersistentVolumes().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return persistentVolumeOrphaned(pv) != "", nil
+	case KindConfigMap, KindSecret:
+		pods, err := o.client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		refs := collectPodReferences(pods.Items)
+		if kind == KindConfigMap {
+			return !refs.configMaps[namespace+"/"+name], nil
+		}
+		return !refs.secrets[namespace+"/"+name], nil
+	case KindService:
+		service, err := o.client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return o.serviceOrphaned(ctx, service)
+	default:
+		return false, fmt.Errorf("cleanup does not support kind %s", kind)
+	}
+}
+
+func persistentVolumeOrphaned(pv *corev1.PersistentVolume) string {
+	switch pv.Status.Phase {
+	case corev1.VolumeAvailable:
+		return "not bound to any claim"
+	case corev1.VolumeReleased:
+		return "claim was deleted"
+	default:
+		return ""
+	}
+}
+
+// configMapOrphanable excludes config maps the cluster itself maintains.
+func configMapOrphanable(cm *corev1.ConfigMap) bool {
+	return cm.Name != "kube-root-ca.crt" && len(cm.OwnerReferences) == 0
+}
+
+// secretOrphanable limits the check to plain Opaque secrets, typed secrets
+// like service account tokens, helm releases or TLS certificates are consumed
+// outside pod specs.
+func secretOrphanable(secret *corev1.Secret) bool {
+	return secret.Type == corev1.SecretTypeOpaque && len(secret.OwnerReferences) == 0
+}
+
+func skipNamespace(namespace string) bool {
+	return sliceutil.HasString(systemNamespaces, namespace)
+}
+
+func inReport(report *Report, namespace, kind, name string) bool {
+	for _, orphan := range report.Orphans {
+		if orphan.Kind == kind && orphan.Namespace == namespace && orphan.Name == name {
+			return orphan.Cleanable
+		}
+	}
+	return false
+}
+
+// podReferences indexes every config map, secret and image the pods of the
+// cluster reference, keyed by namespace/name for the namespaced kinds.
+type podReferences struct {
+	configMaps map[string]bool
+	secrets    map[string]bool
+	images     map[string]bool
+}
+
+func collectPodReferences(pods []corev1.Pod) podReferences {
+	refs := podReferences{
+		configMaps: map[string]bool{},
+		secrets:    map[string]bool{},
+		images:     map[string]bool{},
+	}
+	for i := range pods {
+		pod := &pods[i]
+		for _, volume := range pod.Spec.Volumes {
+			if volume.ConfigMap != nil {
+				refs.configMaps[pod.Namespace+"/"+volume.ConfigMap.Name] = true
+			}
+			if volume.Secret != nil {
+				refs.secrets[pod.Namespace+"/"+volume.Secret.SecretName] = true
+			}
+			if volume.Projected != nil {
+				for _, source := range volume.Projected.Sources {
+					if source.ConfigMap != nil {
+						refs.configMaps[pod.Namespace+"/"+source.ConfigMap.Name] = true
+					}
+					if source.Secret != nil {
+						refs.secrets[pod.Namespace+"/"+source.Secret.Name] = true
+					}
+				}
+			}
+		}
+		for _, pullSecret := range pod.Spec.ImagePullSecrets {
+			refs.secrets[pod.Namespace+"/"+pullSecret.Name] = true
+		}
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, container := range containers {
+			refs.images[container.Image] = true
+			for _, env := range container.Env {
+				if env.ValueFrom == nil {
+					continue
+				}
+				if env.ValueFrom.ConfigMapKeyRef != nil {
+					refs.configMaps[pod.Namespace+"/"+env.ValueFrom.ConfigMapKeyRef.Name] = true
+				}
+				if env.ValueFrom.SecretKeyRef != nil {
+					refs.secrets[pod.Namespace+"/"+env.ValueFrom.SecretKeyRef.Name] = true
+				}
+			}
+			for _, envFrom := range container.EnvFrom {
+				if envFrom.ConfigMapRef != nil {
+					refs.configMaps[pod.Namespace+"/"+envFrom.ConfigMapRef.Name] = true
+				}
+				if envFrom.SecretRef != nil {
+					refs.secrets[pod.Namespace+"/"+envFrom.SecretRef.Name] = true
+				}
+			}
+		}
+	}
+	return refs
+}
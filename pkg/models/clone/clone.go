@@ -0,0 +1,433 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clone copies a workload together with the config maps, secrets
+// and services it depends on into another namespace, optionally in another
+// cluster. Names can be prefixed and references inside the pod template are
+// rewritten accordingly, so a test copy of a production service comes up
+// without hand-editing manifests.
+package clone
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"kubesphere.io/kubesphere/pkg/client/informers/externalversions"
+	"kubesphere.io/kubesphere/pkg/utils/clusterclient"
+)
+
+const (
+	KindDeployment  = "deployments"
+	KindStatefulSet = "statefulsets"
+	KindDaemonSet   = "daemonsets"
+)
+
+// Request describes where the copy goes
+type Request struct {
+	// namespace the copy is created in, required
+	TargetNamespace string `json:"targetNamespace"`
+
+	// cluster the copy is created in, defaults to the cluster the
+	// source runs in
+	TargetCluster string `json:"targetCluster,omitempty"`
+
+	// prefix prepended to the names of the workload and its
+	// dependencies, required when cloning into the source namespace
+	NamePrefix string `json:"namePrefix,omitempty"`
+}
+
+// ClonedResource is one resource the clone created
+type ClonedResource struct {
+	Kind       string `json:"kind"`
+	SourceName string `json:"sourceName"`
+	Name       string `json:"name"`
+}
+
+// Result lists everything the clone created
+type Result struct {
+	TargetNamespace string           `json:"targetNamespace"`
+	TargetCluster   string           `json:"targetCluster,omitempty"`
+	Cloned          []ClonedResource `json:"cloned"`
+}
+
+type Operator interface {
+	// Clone copies the workload and its dependent config maps, secrets
+	// and services into the target namespace
+	Clone(namespace, kind, workload string, request Request) (*Result, error)
+}
+
+type operator struct {
+	client         kubernetes.Interface
+	clusterClients clusterclient.ClusterClients
+}
+
+// NewOperator builds a clone operator, ksFactory enables cloning into
+// member clusters and may be nil
+func NewOperator(client kubernetes.Interface, ksFactory externalversions.SharedInformerFactory) Operator {
+	o := &operator{client: client}
+	if ksFactory != nil {
+		o.clusterClients = clusterclient.NewClusterClient(ksFactory.Cluster().V1alpha1().Clusters())
+	}
+	return o
+}
+
+// targetClient resolves the clientset of the cluster the copy goes to
+func (o *operator) targetClient(cluster string) (kubernetes.Interface, error) {
+	if cluster == "" {
+		return o.client, nil
+	}
+	if o.clusterClients == nil {
+		return nil, fmt.Errorf("cloning into another cluster needs multi-cluster enabled")
+	}
+	target, err := o.clusterClients.Get(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if o.clusterClients.IsHostCluster(target) {
+		return o.client, nil
+	}
+	if !o.clusterClients.IsClusterReady(target) {
+		return nil, fmt.Errorf("cluster %s is not ready", cluster)
+	}
+	kubeconfig, err := o.clusterClients.GetClusterKubeconfig(cluster)
+	if err != nil {
+		return nil, err
+	}
+	clientConfig, err := clientcmd.NewClientConfigFromBytes([]byte(kubeconfig))
+	if err != nil {
+		return nil, err
+	}
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+func (o *operator) Clone(namespace, kind, workload string, request Request) (*Result, error) {
+	if request.TargetNamespace == "" {
+		return nil, fmt.Errorf("targetNamespace is required")
+	}
+	if request.TargetCluster == "" && request.TargetNamespace == namespace && request.NamePrefix == "" {
+		return nil, fmt.Errorf("cloning into the source namespace needs a namePrefix")
+	}
+
+	target, err := o.targetClient(request.TargetCluster)
+	if err != nil {
+		return nil, err
+	}
+
+	var template *corev1.PodTemplateSpec
+	var create func(podSpec corev1.PodSpec) error
+
+	ctx := context.TODO()
+	switch kind {
+	case KindDeployment:
+		source, err := o.client.AppsV1().Deployments(namespace).Get(ctx, workload, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		template = &source.Spec.Template
+		create = func(podSpec corev1.PodSpec) error {
+			copied := &appsv1.Deployment{
+				ObjectMeta: cloneMeta(source.ObjectMeta, request, request.NamePrefix+workload),
+				Spec:       source.Spec,
+			}
+			copied.Spec.Template.Spec = podSpec
+			_, err := target.AppsV1().Deployments(request.TargetNamespace).Create(ctx, copied, metav1.CreateOptions{})
+			return err
+		}
+	case KindStatefulSet:
+		source, err := o.client.AppsV1().StatefulSets(namespace).Get(ctx, workload, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		template = &source.Spec.Template
+		create = func(podSpec corev1.PodSpec) error {
+			copied := &appsv1.StatefulSet{
+				ObjectMeta: cloneMeta(source.ObjectMeta, request, request.NamePrefix+workload),
+				Spec:       source.Spec,
+			}
+			copied.Spec.Template.Spec = podSpec
+			for i := range copied.Spec.VolumeClaimTemplates {
+				copied.Spec.VolumeClaimTemplates[i].Namespace = ""
+			}
+			_, err := target.AppsV1().StatefulSets(request.TargetNamespace).Create(ctx, copied, metav1.CreateOptions{})
+			return err
+		}
+	case KindDaemonSet:
+		source, err := o.client.AppsV1().DaemonSets(namespace).Get(ctx, workload, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		template = &source.Spec.Template
+		create = func(podSpec corev1.PodSpec) error {
+			copied := &appsv1.DaemonSet{
+				ObjectMeta: cloneMeta(source.ObjectMeta, request, request.NamePrefix+workload),
+				Spec:       source.Spec,
+			}
+			copied.Spec.Template.Spec = podSpec
+			_, err := target.AppsV1().DaemonSets(request.TargetNamespace).Create(ctx, copied, metav1.CreateOptions{})
+			return err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind %s, one of %s, %s, %s", kind, KindDeployment, KindStatefulSet, KindDaemonSet)
+	}
+
+	result := &Result{TargetNamespace: request.TargetNamespace, TargetCluster: request.TargetCluster}
+
+	configMaps, secrets := referencedNames(&template.Spec)
+	if err := o.cloneConfigMaps(target, namespace, configMaps, request, result); err != nil {
+		return nil, err
+	}
+	if err := o.cloneSecrets(target, namespace, secrets, request, result); err != nil {
+		return nil, err
+	}
+	if err := o.cloneServices(target, namespace, template.Labels, request, result); err != nil {
+		return nil, err
+	}
+
+	podSpec := *template.Spec.DeepCopy()
+	rewritePodSpec(&podSpec, renameMap(configMaps, request.NamePrefix), renameMap(secrets, request.NamePrefix))
+	if err := create(podSpec); err != nil {
+		return nil, err
+	}
+	result.Cloned = append(result.Cloned, ClonedResource{Kind: kind, SourceName: workload, Name: request.NamePrefix + workload})
+
+	return result, nil
+}
+
+// cloneMeta keeps labels and annotations and drops everything the target
+// apiserver owns, ownership included
+func cloneMeta(source metav1.ObjectMeta, request Request, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   request.TargetNamespace,
+		Labels:      source.Labels,
+		Annotations: source.Annotations,
+	}
+}
+
+func renameMap(names []string, prefix string) map[string]string {
+	renamed := make(map[string]string, len(names))
+	for _, name := range names {
+		renamed[name] = prefix + name
+	}
+	return renamed
+}
+
+// referencedNames collects the config maps and secrets the pod spec
+// mounts, reads env from or pulls images with
+func referencedNames(spec *corev1.PodSpec) (configMaps, secrets []string) {
+	configMapSet := map[string]bool{}
+	secretSet := map[string]bool{}
+
+	for _, volume := range spec.Volumes {
+		if volume.ConfigMap != nil {
+			configMapSet[volume.ConfigMap.Name] = true
+		}
+		if volume.Secret != nil {
+			secretSet[volume.Secret.SecretName] = true
+		}
+		if volume.Projected != nil {
+			for _, projection := range volume.Projected.Sources {
+				if projection.ConfigMap != nil {
+					configMapSet[projection.ConfigMap.Name] = true
+				}
+				if projection.Secret != nil {
+					secretSet[projection.Secret.Name] = true
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				configMapSet[env.ValueFrom.ConfigMapKeyRef.Name] = true
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				secretSet[env.ValueFrom.SecretKeyRef.Name] = true
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				configMapSet[envFrom.ConfigMapRef.Name] = true
+			}
+			if envFrom.SecretRef != nil {
+				secretSet[envFrom.SecretRef.Name] = true
+			}
+		}
+	}
+
+	for _, pullSecret := range spec.ImagePullSecrets {
+		secretSet[pullSecret.Name] = true
+	}
+
+	for name := range configMapSet {
+		configMaps = append(configMaps, name)
+	}
+	for name := range secretSet {
+		secrets = append(secrets, name)
+	}
+	sort.Strings(configMaps)
+	sort.Strings(secrets)
+	return configMaps, secrets
+}
+
+// rewritePodSpec points every config map and secret reference at the
+// renamed copies
+func rewritePodSpec(spec *corev1.PodSpec, configMaps, secrets map[string]string) {
+	for i := range spec.Volumes {
+		volume := &spec.Volumes[i]
+		if volume.ConfigMap != nil {
+			volume.ConfigMap.Name = configMaps[volume.ConfigMap.Name]
+		}
+		if volume.Secret != nil {
+			volume.Secret.SecretName = secrets[volume.Secret.SecretName]
+		}
+		if volume.Projected != nil {
+			for j := range volume.Projected.Sources {
+				projection := &volume.Projected.Sources[j]
+				if projection.ConfigMap != nil {
+					projection.ConfigMap.Name = configMaps[projection.ConfigMap.Name]
+				}
+				if projection.Secret != nil {
+					projection.Secret.Name = secrets[projection.Secret.Name]
+				}
+			}
+		}
+	}
+
+	rewriteContainers := func(containers []corev1.Container) {
+		for i := range containers {
+			for j := range containers[i].Env {
+				valueFrom := containers[i].Env[j].ValueFrom
+				if valueFrom == nil {
+					continue
+				}
+				if valueFrom.ConfigMapKeyRef != nil {
+					valueFrom.ConfigMapKeyRef.Name = configMaps[valueFrom.ConfigMapKeyRef.Name]
+				}
+				if valueFrom.SecretKeyRef != nil {
+					valueFrom.SecretKeyRef.Name = secrets[valueFrom.SecretKeyRef.Name]
+				}
+			}
+			for j := range containers[i].EnvFrom {
+				envFrom := &containers[i].EnvFrom[j]
+				if envFrom.ConfigMapRef != nil {
+					envFrom.ConfigMapRef.Name = configMaps[envFrom.ConfigMapRef.Name]
+				}
+				if envFrom.SecretRef != nil {
+					envFrom.SecretRef.Name = secrets[envFrom.SecretRef.Name]
+				}
+			}
+		}
+	}
+	rewriteContainers(spec.InitContainers)
+	rewriteContainers(spec.Containers)
+
+	for i := range spec.ImagePullSecrets {
+		spec.ImagePullSecrets[i].Name = secrets[spec.ImagePullSecrets[i].Name]
+	}
+}
+
+func (o *operator) cloneConfigMaps(target kubernetes.Interface, namespace string, names []string, request Request, result *Result) error {
+	for _, name := range names {
+		source, err := o.client.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("get config map %s: %v", name, err)
+		}
+		copied := &corev1.ConfigMap{
+			ObjectMeta: cloneMeta(source.ObjectMeta, request, request.NamePrefix+name),
+			Data:       source.Data,
+			BinaryData: source.BinaryData,
+		}
+		if _, err := target.CoreV1().ConfigMaps(request.TargetNamespace).Create(context.TODO(), copied, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("create config map %s: %v", copied.Name, err)
+		}
+		result.Cloned = append(result.Cloned, ClonedResource{Kind: "configmaps", SourceName: name, Name: copied.Name})
+	}
+	return nil
+}
+
+func (o *operator) cloneSecrets(target kubernetes.Interface, namespace string, names []string, request Request, result *Result) error {
+	for _, name := range names {
+		source, err := o.client.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("get secret %s: %v", name, err)
+		}
+		// token secrets belong to a service account of the source
+		// namespace, copying them would leak its identity
+		if source.Type == corev1.SecretTypeServiceAccountToken {
+			continue
+		}
+		copied := &corev1.Secret{
+			ObjectMeta: cloneMeta(source.ObjectMeta, request, request.NamePrefix+name),
+			Type:       source.Type,
+			Data:       source.Data,
+		}
+		if _, err := target.CoreV1().Secrets(request.TargetNamespace).Create(context.TODO(), copied, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("create secret %s: %v", copied.Name, err)
+		}
+		result.Cloned = append(result.Cloned, ClonedResource{Kind: "secrets", SourceName: name, Name: copied.Name})
+	}
+	return nil
+}
+
+// cloneServices copies the services of the source namespace whose
+// selectors match the pod template, selectors are kept since the pod
+// labels travel with the copy
+func (o *operator) cloneServices(target kubernetes.Interface, namespace string, podLabels map[string]string, request Request, result *Result) error {
+	services, err := o.client.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, service := range services.Items {
+		if len(service.Spec.Selector) == 0 || !labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(podLabels)) {
+			continue
+		}
+		copied := &corev1.Service{
+			ObjectMeta: cloneMeta(service.ObjectMeta, request, request.NamePrefix+service.Name),
+			Spec:       service.Spec,
+		}
+		copied.Spec.ClusterIP = ""
+		for i := range copied.Spec.Ports {
+			copied.Spec.Ports[i].NodePort = 0
+		}
+		if _, err := target.CoreV1().Services(request.TargetNamespace).Create(context.TODO(), copied, metav1.CreateOptions{}); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return fmt.Errorf("service %s already exists in namespace %s, use a namePrefix", copied.Name, request.TargetNamespace)
+			}
+			return fmt.Errorf("create service %s: %v", copied.Name, err)
+		}
+		result.Cloned = append(result.Cloned, ClonedResource{Kind: "services", SourceName: service.Name, Name: copied.Name})
+	}
+	return nil
+}
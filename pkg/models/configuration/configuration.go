@@ -0,0 +1,264 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configuration
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// HashAnnotation holds the hash of the tracked object's data, a changed
+	// hash means the config changed
+	HashAnnotation = "configuration.kubesphere.io/hash"
+	// RevisionAnnotation counts the observed changes, starting at 1
+	RevisionAnnotation = "configuration.kubesphere.io/revision"
+	// LastChangeAnnotation records when the last change was observed
+	LastChangeAnnotation = "configuration.kubesphere.io/last-change"
+
+	// RestartOnChangeAnnotation opts a workload into automatic rolling
+	// restarts when a config map or secret it consumes changes
+	RestartOnChangeAnnotation = "configuration.kubesphere.io/restart-on-change"
+	// RestartedAtAnnotation is stamped into the pod template to trigger the
+	// rolling restart
+	RestartedAtAnnotation = "configuration.kubesphere.io/restarted-at"
+	// RestartedForAnnotation names the config object whose change triggered
+	// the restart
+	RestartedForAnnotation = "configuration.kubesphere.io/restarted-for"
+
+	KindConfigMap = "configmap"
+	KindSecret    = "secret"
+)
+
+// Consumer is one workload that mounts or reads the config object.
+type Consumer struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// RestartOnChange reports whether the workload opted into automatic
+	// restarts on config changes
+	RestartOnChange bool `json:"restartOnChange"`
+	// LastRestartedAt is set when the workload was last restarted for a
+	// config change
+	LastRestartedAt string `json:"lastRestartedAt,omitempty"`
+}
+
+// ConsumerReport pairs the tracked revision of a config object with the
+// workloads consuming it, so a stale workload is easy to spot.
+type ConsumerReport struct {
+	Kind       string     `json:"kind"`
+	Namespace  string     `json:"namespace"`
+	Name       string     `json:"name"`
+	Revision   string     `json:"revision,omitempty"`
+	Hash       string     `json:"hash,omitempty"`
+	LastChange string     `json:"lastChange,omitempty"`
+	Consumers  []Consumer `json:"consumers"`
+}
+
+type Operator interface {
+	// ConfigMapConsumers lists the workloads consuming the config map with
+	// its tracked revision
+	ConfigMapConsumers(namespace, name string) (*ConsumerReport, error)
+
+	// SecretConsumers lists the workloads consuming the secret with its
+	// tracked revision
+	SecretConsumers(namespace, name string) (*ConsumerReport, error)
+}
+
+type operator struct {
+	client kubernetes.Interface
+}
+
+func NewOperator(client kubernetes.Interface) Operator {
+	return &operator{client: client}
+}
+
+func (o *operator) ConfigMapConsumers(namespace, name string) (*ConsumerReport, error) {
+	cm, err := o.client.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	report := newConsumerReport(KindConfigMap, namespace, name, cm.Annotations)
+	return o.fillConsumers(report, func(spec *corev1.PodSpec) bool {
+		configMaps, _ := References(spec)
+		return configMaps[name]
+	})
+}
+
+func (o *operator) SecretConsumers(namespace, name string) (*ConsumerReport, error) {
+	secret, err := o.client.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	report := newConsumerReport(KindSecret, namespace, name, secret.Annotations)
+	return o.fillConsumers(report, func(spec *corev1.PodSpec) bool {
+		_, secrets := References(spec)
+		return secrets[name]
+	})
+}
+
+func newConsumerReport(kind, namespace, name string, annotations map[string]string) *ConsumerReport {
+	return &ConsumerReport{
+		Kind:       kind,
+		Namespace:  namespace,
+		Name:       name,
+		Revision:   annotations[RevisionAnnotation],
+		Hash:       annotations[HashAnnotation],
+		LastChange: annotations[LastChangeAnnotation],
+		Consumers:  []Consumer{},
+	}
+}
+
+func (o *operator) fillConsumers(report *ConsumerReport, consumes func(*corev1.PodSpec) bool) (*ConsumerReport, error) {
+	ctx := context.Background()
+
+	deployments, err := o.client.AppsV1().Deployments(report.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range deployments.Items {
+		appendConsumer(report, "deployment", &deployments.Items[i].ObjectMeta, &deployments.Items[i].Spec.Template, consumes)
+	}
+
+	statefulSets, err := o.client.AppsV1().StatefulSets(report.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range statefulSets.Items {
+		appendConsumer(report, "statefulset", &statefulSets.Items[i].ObjectMeta, &statefulSets.Items[i].Spec.Template, consumes)
+	}
+
+	daemonSets, err := o.client.AppsV1().DaemonSets(report.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range daemonSets.Items {
+		appendConsumer(report, "daemonset", &daemonSets.Items[i].ObjectMeta, &daemonSets.Items[i].Spec.Template, consumes)
+	}
+
+	return report, nil
+}
+
+func appendConsumer(report *ConsumerReport, kind string, meta *metav1.ObjectMeta, template *corev1.PodTemplateSpec, consumes func(*corev1.PodSpec) bool) {
+	if !consumes(&template.Spec) {
+		return
+	}
+	report.Consumers = append(report.Consumers, Consumer{
+		Kind:            kind,
+		Name:            meta.Name,
+		RestartOnChange: RestartOnChange(meta.Annotations),
+		LastRestartedAt: template.Annotations[RestartedAtAnnotation],
+	})
+}
+
+// RestartOnChange reports whether the workload opted into automatic restarts.
+func RestartOnChange(annotations map[string]string) bool {
+	return annotations[RestartOnChangeAnnotation] == "true"
+}
+
+// Hash returns a stable hash over the data of a config map or secret.
+func Hash(data map[string]string, binaryData map[string][]byte) string {
+	keys := make([]string, 0, len(data)+len(binaryData))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	for key := range binaryData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		if value, ok := data[key]; ok {
+			hash.Write([]byte(value))
+		} else {
+			hash.Write(binaryData[key])
+		}
+		hash.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))[:16]
+}
+
+// SecretHash converts the secret's data for Hash, secrets keep everything in
+// Data.
+func SecretHash(secret *corev1.Secret) string {
+	return Hash(secret.StringData, secret.Data)
+}
+
+// ConfigMapHash hashes the config map's data.
+func ConfigMapHash(cm *corev1.ConfigMap) string {
+	return Hash(cm.Data, cm.BinaryData)
+}
+
+// References collects the names of the config maps and secrets the pod spec
+// consumes through volumes, environment variables or image pull secrets.
+func References(spec *corev1.PodSpec) (configMaps, secrets map[string]bool) {
+	configMaps = map[string]bool{}
+	secrets = map[string]bool{}
+
+	for _, volume := range spec.Volumes {
+		if volume.ConfigMap != nil {
+			configMaps[volume.ConfigMap.Name] = true
+		}
+		if volume.Secret != nil {
+			secrets[volume.Secret.SecretName] = true
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					configMaps[source.ConfigMap.Name] = true
+				}
+				if source.Secret != nil {
+					secrets[source.Secret.Name] = true
+				}
+			}
+		}
+	}
+	for _, pullSecret := range spec.ImagePullSecrets {
+		secrets[pullSecret.Name] = true
+	}
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				configMaps[env.ValueFrom.ConfigMapKeyRef.Name] = true
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				secrets[env.ValueFrom.SecretKeyRef.Name] = true
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				configMaps[envFrom.ConfigMapRef.Name] = true
+			}
+			if envFrom.SecretRef != nil {
+				secrets[envFrom.SecretRef.Name] = true
+			}
+		}
+	}
+	return configMaps, secrets
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringdashboardv1alpha1 "kubesphere.io/monitoring-dashboard/api/v1alpha1"
+	"kubesphere.io/monitoring-dashboard/api/v1alpha1/panels"
+)
+
+// CopiedFromAnnotation records which cluster dashboard a copy was forked
+// from; PromotedFromAnnotation records which namespaced dashboard a cluster
+// dashboard was promoted from.
+const (
+	CopiedFromAnnotation   = "monitoring.kubesphere.io/copied-from"
+	PromotedFromAnnotation = "monitoring.kubesphere.io/promoted-from"
+)
+
+// CopyClusterDashboard forks a cluster dashboard into the namespace as a
+// Dashboard the user can tweak. The $namespace scoping variable is pinned
+// to the target namespace and dropped from the templating variables.
+func (o *operator) CopyClusterDashboard(source, targetNamespace, targetName string) error {
+	if targetNamespace == "" || targetName == "" {
+		return fmt.Errorf("a target namespace and name are required")
+	}
+
+	clusterDashboard := &monitoringdashboardv1alpha1.ClusterDashboard{}
+	if err := o.client.Get(context.Background(), runtimeclient.ObjectKey{Name: source}, clusterDashboard); err != nil {
+		return err
+	}
+
+	dashboard := &monitoringdashboardv1alpha1.Dashboard{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   targetNamespace,
+			Name:        targetName,
+			Annotations: map[string]string{CopiedFromAnnotation: source},
+		},
+	}
+	clusterDashboard.Spec.DeepCopyInto(&dashboard.Spec)
+	pinNamespace(&dashboard.Spec, targetNamespace)
+
+	if err := o.client.Create(context.Background(), dashboard); err != nil {
+		return fmt.Errorf("failed to create dashboard %s/%s: %v", targetNamespace, targetName, err)
+	}
+	return nil
+}
+
+// PromoteDashboard promotes a namespaced dashboard to a ClusterDashboard.
+// Promotion makes the dashboard visible to every tenant, so it must be
+// explicitly approved. The source namespace is generalized back into the
+// $namespace scoping variable.
+func (o *operator) PromoteDashboard(namespace, source, targetName string, approved bool) error {
+	if targetName == "" {
+		return fmt.Errorf("a target name is required")
+	}
+	if !approved {
+		return fmt.Errorf("promoting dashboard %s/%s to the cluster scope requires approval", namespace, source)
+	}
+
+	dashboard := &monitoringdashboardv1alpha1.Dashboard{}
+	if err := o.client.Get(context.Background(), runtimeclient.ObjectKey{Namespace: namespace, Name: source}, dashboard); err != nil {
+		return err
+	}
+
+	clusterDashboard := &monitoringdashboardv1alpha1.ClusterDashboard{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        targetName,
+			Annotations: map[string]string{PromotedFromAnnotation: namespace + "/" + source},
+		},
+	}
+	dashboard.Spec.DeepCopyInto(&clusterDashboard.Spec)
+	generalizeNamespace(&clusterDashboard.Spec, namespace)
+
+	if err := o.client.Create(context.Background(), clusterDashboard); err != nil {
+		return fmt.Errorf("failed to create cluster dashboard %s: %v", targetName, err)
+	}
+	return nil
+}
+
+// pinNamespace fixes the $namespace variable to the target namespace and
+// drops it from the templating variables, which no longer apply.
+func pinNamespace(spec *monitoringdashboardv1alpha1.DashboardSpec, namespace string) {
+	for i := range spec.Panels {
+		substituteSpecPanel(&spec.Panels[i], "namespace", namespace)
+	}
+	templatings := spec.Templatings[:0]
+	for _, templating := range spec.Templatings {
+		if templating.Name != "namespace" {
+			templatings = append(templatings, templating)
+		}
+	}
+	spec.Templatings = templatings
+}
+
+// generalizeNamespace replaces matchers pinned to the source namespace
+// with the $namespace variable and declares it as a templating variable.
+func generalizeNamespace(spec *monitoringdashboardv1alpha1.DashboardSpec, namespace string) {
+	pinned := fmt.Sprintf("namespace=%q", namespace)
+	general := `namespace="$namespace"`
+	for i := range spec.Panels {
+		panel := &spec.Panels[i]
+		switch {
+		case panel.Graph != nil:
+			replaceInTargets(panel.Graph.Targets, pinned, general)
+		case panel.SingleStat != nil:
+			replaceInTargets(panel.SingleStat.Targets, pinned, general)
+		}
+	}
+	for _, templating := range spec.Templatings {
+		if templating.Name == "namespace" {
+			return
+		}
+	}
+	spec.Templatings = append(spec.Templatings, monitoringdashboardv1alpha1.Templating{Name: "namespace"})
+}
+
+func substituteSpecPanel(panel *monitoringdashboardv1alpha1.Panel, variable, value string) {
+	*panel = substitutePanel(*panel, variable, value)
+}
+
+func replaceInTargets(targets []panels.Target, pinned, general string) {
+	for i := range targets {
+		targets[i].Expression = strings.ReplaceAll(targets[i].Expression, pinned, general)
+	}
+}
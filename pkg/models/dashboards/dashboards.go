@@ -0,0 +1,319 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringclient "kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+	monitoringdashboardv1alpha1 "kubesphere.io/monitoring-dashboard/api/v1alpha1"
+)
+
+const (
+	// FolderLabel groups dashboards into folders, one folder per dashboard
+	FolderLabel = "monitoring.kubesphere.io/folder"
+
+	// TagsAnnotation holds the dashboard's tags as a comma-separated list
+	TagsAnnotation = "monitoring.kubesphere.io/tags"
+)
+
+// Entry is one dashboard with its organization metadata, namespaced or
+// cluster scoped.
+type Entry struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// ClusterScoped is true for ClusterDashboards
+	ClusterScoped bool     `json:"clusterScoped"`
+	Title         string   `json:"title,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	Folder        string   `json:"folder,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Datasource    string   `json:"datasource,omitempty"`
+}
+
+// Query filters dashboards; empty fields do not filter. Title matches as a
+// case-insensitive substring.
+type Query struct {
+	Namespace  string
+	Folder     string
+	Tag        string
+	Title      string
+	Datasource string
+}
+
+// Metadata is the folder and tags to set on a dashboard.
+type Metadata struct {
+	Folder string   `json:"folder,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+type Folder struct {
+	Name string `json:"name"`
+	// Dashboards is the number of dashboards in the folder
+	Dashboards int `json:"dashboards"`
+}
+
+type Operator interface {
+	// Search lists the dashboards matching the query, cluster dashboards
+	// included unless a namespace is given
+	Search(query Query) ([]Entry, error)
+
+	// ListFolders lists the folders in use with their dashboard counts
+	ListFolders() ([]Folder, error)
+
+	// SetMetadata sets the folder and tags of a dashboard; an empty
+	// namespace addresses a cluster dashboard
+	SetMetadata(namespace, name string, metadata Metadata) error
+
+	// GetPanelTimeOverrides reads the dashboard's per-panel time overrides
+	GetPanelTimeOverrides(namespace, name string) ([]PanelTimeOverride, error)
+
+	// SetPanelTimeOverrides replaces the dashboard's per-panel time
+	// overrides, an empty list removes them
+	SetPanelTimeOverrides(namespace, name string, overrides []PanelTimeOverride) error
+
+	// GetGraphDisplays reads the dashboard's graph display settings
+	GetGraphDisplays(namespace, name string) ([]GraphDisplay, error)
+
+	// SetGraphDisplays replaces the dashboard's graph display settings,
+	// an empty list removes them
+	SetGraphDisplays(namespace, name string, displays []GraphDisplay) error
+
+	// GetSingleStatDisplays reads the dashboard's singlestat display settings
+	GetSingleStatDisplays(namespace, name string) ([]SingleStatDisplay, error)
+
+	// SetSingleStatDisplays replaces the dashboard's singlestat display
+	// settings, an empty list removes them
+	SetSingleStatDisplays(namespace, name string, displays []SingleStatDisplay) error
+
+	// GetPanelRepeats reads the dashboard's repeat-by-variable assignments
+	GetPanelRepeats(namespace, name string) ([]PanelRepeat, error)
+
+	// SetPanelRepeats replaces the dashboard's repeat-by-variable
+	// assignments, an empty list removes them
+	SetPanelRepeats(namespace, name string, repeats []PanelRepeat) error
+
+	// ExpandRepeats renders the dashboard's panels with repeated rows and
+	// graphs expanded over the given variable values
+	ExpandRepeats(namespace, name string, values map[string][]string) ([]monitoringdashboardv1alpha1.Panel, error)
+
+	// GetLocalizations reads the dashboard's translations keyed by locale
+	GetLocalizations(namespace, name string) (map[string]Localization, error)
+
+	// SetLocalizations replaces the dashboard's translations, an empty map
+	// removes them
+	SetLocalizations(namespace, name string, localizations map[string]Localization) error
+
+	// Localize resolves the dashboard's display strings for the best
+	// matching locale of the Accept-Language header
+	Localize(namespace, name, acceptLanguage string) (LocalizedDashboard, error)
+
+	// GetPanelThresholds reads the dashboard's panel thresholds
+	GetPanelThresholds(namespace, name string) ([]PanelThreshold, error)
+
+	// SetPanelThresholds replaces the dashboard's panel thresholds, an
+	// empty list removes them
+	SetPanelThresholds(namespace, name string, thresholds []PanelThreshold) error
+
+	// EvaluatePanelStates computes each threshold panel's current state
+	EvaluatePanelStates(namespace, name string) ([]PanelState, error)
+
+	// RecordView bumps the dashboard's view counter
+	RecordView(namespace, name string) error
+
+	// UsageReport lists every dashboard's view statistics, least viewed
+	// first
+	UsageReport() ([]UsageEntry, error)
+
+	// CopyClusterDashboard forks a cluster dashboard into a namespace
+	CopyClusterDashboard(source, targetNamespace, targetName string) error
+
+	// PromoteDashboard promotes a namespaced dashboard to the cluster
+	// scope; it must be explicitly approved
+	PromoteDashboard(namespace, source, targetName string, approved bool) error
+}
+
+// dashboardObject is what Dashboard and ClusterDashboard have in common for
+// metadata edits.
+type dashboardObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+type operator struct {
+	cache      runtimecache.Cache
+	client     runtimeclient.Client
+	monitoring monitoringclient.Interface
+}
+
+func NewOperator(cache runtimecache.Cache, client runtimeclient.Client, monitoring monitoringclient.Interface) Operator {
+	return &operator{cache: cache, client: client, monitoring: monitoring}
+}
+
+func (o *operator) entries(namespace string) ([]Entry, error) {
+	entries := make([]Entry, 0)
+
+	dashboards := &monitoringdashboardv1alpha1.DashboardList{}
+	options := make([]runtimeclient.ListOption, 0)
+	if namespace != "" {
+		options = append(options, runtimeclient.InNamespace(namespace))
+	}
+	if err := o.cache.List(context.Background(), dashboards, options...); err != nil {
+		return nil, err
+	}
+	for i := range dashboards.Items {
+		entries = append(entries, render(&dashboards.Items[i].ObjectMeta, dashboards.Items[i].Spec, false))
+	}
+
+	if namespace == "" {
+		clusterDashboards := &monitoringdashboardv1alpha1.ClusterDashboardList{}
+		if err := o.cache.List(context.Background(), clusterDashboards); err != nil {
+			return nil, err
+		}
+		for i := range clusterDashboards.Items {
+			entries = append(entries, render(&clusterDashboards.Items[i].ObjectMeta, clusterDashboards.Items[i].Spec, true))
+		}
+	}
+	return entries, nil
+}
+
+func render(meta *metav1.ObjectMeta, spec monitoringdashboardv1alpha1.DashboardSpec, clusterScoped bool) Entry {
+	return Entry{
+		Name:          meta.Name,
+		Namespace:     meta.Namespace,
+		ClusterScoped: clusterScoped,
+		Title:         spec.Title,
+		Description:   spec.Description,
+		Folder:        meta.Labels[FolderLabel],
+		Tags:          parseTags(meta.Annotations[TagsAnnotation]),
+		Datasource:    spec.DataSource,
+	}
+}
+
+func parseTags(value string) []string {
+	if value == "" {
+		return nil
+	}
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func (o *operator) Search(query Query) ([]Entry, error) {
+	entries, err := o.entries(query.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]Entry, 0)
+	for _, entry := range entries {
+		if query.Folder != "" && entry.Folder != query.Folder {
+			continue
+		}
+		if query.Datasource != "" && entry.Datasource != query.Datasource {
+			continue
+		}
+		if query.Title != "" && !strings.Contains(strings.ToLower(entry.Title), strings.ToLower(query.Title)) {
+			continue
+		}
+		if query.Tag != "" && !containsTag(entry.Tags, query.Tag) {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].Folder != matched[j].Folder {
+			return matched[i].Folder < matched[j].Folder
+		}
+		return matched[i].Title < matched[j].Title
+	})
+	return matched, nil
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *operator) ListFolders() ([]Folder, error) {
+	entries, err := o.entries("")
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		if entry.Folder != "" {
+			counts[entry.Folder]++
+		}
+	}
+	folders := make([]Folder, 0, len(counts))
+	for name, count := range counts {
+		folders = append(folders, Folder{Name: name, Dashboards: count})
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].Name < folders[j].Name })
+	return folders, nil
+}
+
+func (o *operator) SetMetadata(namespace, name string, metadata Metadata) error {
+	_, obj, err := o.dashboard(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	if metadata.Folder == "" {
+		delete(labels, FolderLabel)
+	} else {
+		labels[FolderLabel] = metadata.Folder
+	}
+	obj.SetLabels(labels)
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if len(metadata.Tags) == 0 {
+		delete(annotations, TagsAnnotation)
+	} else {
+		annotations[TagsAnnotation] = strings.Join(metadata.Tags, ",")
+	}
+	obj.SetAnnotations(annotations)
+
+	if err := o.client.Update(context.Background(), obj); err != nil {
+		return fmt.Errorf("failed to update dashboard %s: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// GraphDisplayAnnotation stores per-graph display settings as JSON: series
+// overrides, stacking and the right Y-axis. Like the panel time overrides
+// this lives next to the vendored dashboard spec instead of in it.
+const GraphDisplayAnnotation = "monitoring.kubesphere.io/graph-display"
+
+const (
+	StackingNone    = "none"
+	StackingNormal  = "normal"
+	StackingPercent = "percent"
+
+	DrawLine = "line"
+	DrawBars = "bars"
+)
+
+// SeriesOverride restyles the series whose legend matches.
+type SeriesOverride struct {
+	// Series is a regular expression matched against the series legend
+	Series string `json:"series"`
+
+	// Color is a hex color, e.g. #ff0000
+	Color string `json:"color,omitempty"`
+
+	// Draw switches the series between lines and bars
+	Draw string `json:"draw,omitempty"`
+
+	Dashed bool `json:"dashed,omitempty"`
+
+	// YAxis assigns the series to the left (1) or right (2) axis
+	YAxis int `json:"yAxis,omitempty"`
+}
+
+// YAxis configures one of the two vertical axes.
+type YAxis struct {
+	// Unit of the axis, e.g. bytes or percent
+	Unit string `json:"unit,omitempty"`
+
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+
+	Decimals *int `json:"decimals,omitempty"`
+}
+
+// GraphDisplay extends one Graph panel with stacking, series overrides and
+// a right Y-axis with its own unit.
+type GraphDisplay struct {
+	// Panel is the title of the Graph panel
+	Panel string `json:"panel"`
+
+	// Stacking is none, normal or percent
+	Stacking string `json:"stacking,omitempty"`
+
+	LeftYAxis  *YAxis `json:"leftYAxis,omitempty"`
+	RightYAxis *YAxis `json:"rightYAxis,omitempty"`
+
+	SeriesOverrides []SeriesOverride `json:"seriesOverrides,omitempty"`
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+func validateGraphDisplay(display GraphDisplay) error {
+	if display.Panel == "" {
+		return fmt.Errorf("panel title must not be empty")
+	}
+	switch display.Stacking {
+	case "", StackingNone, StackingNormal, StackingPercent:
+	default:
+		return fmt.Errorf("panel %s: stacking must be %s, %s or %s", display.Panel, StackingNone, StackingNormal, StackingPercent)
+	}
+	for _, override := range display.SeriesOverrides {
+		if override.Series == "" {
+			return fmt.Errorf("panel %s: series must not be empty", display.Panel)
+		}
+		if _, err := regexp.Compile(override.Series); err != nil {
+			return fmt.Errorf("panel %s: series %q is not a valid regular expression: %v", display.Panel, override.Series, err)
+		}
+		if override.Color != "" && !hexColor.MatchString(override.Color) {
+			return fmt.Errorf("panel %s: color %q is not a hex color like #ff0000", display.Panel, override.Color)
+		}
+		switch override.Draw {
+		case "", DrawLine, DrawBars:
+		default:
+			return fmt.Errorf("panel %s: draw must be %s or %s", display.Panel, DrawLine, DrawBars)
+		}
+		if override.YAxis != 0 && override.YAxis != 1 && override.YAxis != 2 {
+			return fmt.Errorf("panel %s: yAxis must be 1 (left) or 2 (right)", display.Panel)
+		}
+		if override.YAxis == 2 && display.RightYAxis == nil {
+			return fmt.Errorf("panel %s: a series is assigned to the right axis but rightYAxis is not configured", display.Panel)
+		}
+	}
+	return nil
+}
+
+func (o *operator) GetGraphDisplays(namespace, name string) ([]GraphDisplay, error) {
+	_, meta, err := o.dashboard(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	displays := make([]GraphDisplay, 0)
+	if value := meta.GetAnnotations()[GraphDisplayAnnotation]; value != "" {
+		if err := json.Unmarshal([]byte(value), &displays); err != nil {
+			return nil, fmt.Errorf("dashboard %s has malformed graph display settings: %v", name, err)
+		}
+	}
+	return displays, nil
+}
+
+func (o *operator) SetGraphDisplays(namespace, name string, displays []GraphDisplay) error {
+	spec, obj, err := o.dashboard(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	graphs := make(map[string]bool)
+	for _, panel := range spec.Panels {
+		if panel.Graph != nil {
+			graphs[panel.Graph.Title] = true
+		}
+	}
+	for _, display := range displays {
+		if err := validateGraphDisplay(display); err != nil {
+			return err
+		}
+		if !graphs[display.Panel] {
+			return fmt.Errorf("dashboard %s has no graph panel titled %q", name, display.Panel)
+		}
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if len(displays) == 0 {
+		delete(annotations, GraphDisplayAnnotation)
+	} else {
+		encoded, err := json.Marshal(displays)
+		if err != nil {
+			return err
+		}
+		annotations[GraphDisplayAnnotation] = string(encoded)
+	}
+	obj.SetAnnotations(annotations)
+
+	if err := o.client.Update(context.Background(), obj); err != nil {
+		return fmt.Errorf("failed to update dashboard %s: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// LocalizationsAnnotation stores per-locale titles and descriptions as a
+// JSON map keyed by locale tag, e.g. zh-CN.
+const LocalizationsAnnotation = "monitoring.kubesphere.io/i18n"
+
+// Localization translates a dashboard's display strings for one locale.
+type Localization struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Panels maps panel titles to their translations
+	Panels map[string]string `json:"panels,omitempty"`
+}
+
+// LocalizedDashboard is a dashboard's display strings resolved for the
+// locale negotiated from the Accept-Language header; the spec's own
+// strings fill the gaps.
+type LocalizedDashboard struct {
+	// Locale the strings were resolved to, empty when no translation matched
+	Locale      string `json:"locale,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Panels maps the spec's panel titles to their localized titles
+	Panels map[string]string `json:"panels,omitempty"`
+}
+
+func (o *operator) GetLocalizations(namespace, name string) (map[string]Localization, error) {
+	_, meta, err := o.dashboard(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return decodeLocalizations(name, meta.GetAnnotations()[LocalizationsAnnotation])
+}
+
+func decodeLocalizations(name, value string) (map[string]Localization, error) {
+	localizations := make(map[string]Localization)
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &localizations); err != nil {
+			return nil, fmt.Errorf("dashboard %s has malformed localizations: %v", name, err)
+		}
+	}
+	return localizations, nil
+}
+
+func (o *operator) SetLocalizations(namespace, name string, localizations map[string]Localization) error {
+	spec, obj, err := o.dashboard(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	titles := panelTitles(spec)
+	for locale, localization := range localizations {
+		if locale == "" {
+			return fmt.Errorf("a localization needs a locale tag, e.g. zh-CN")
+		}
+		for panel := range localization.Panels {
+			if !titles[panel] {
+				return fmt.Errorf("dashboard %s has no panel titled %q", name, panel)
+			}
+		}
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if len(localizations) == 0 {
+		delete(annotations, LocalizationsAnnotation)
+	} else {
+		encoded, err := json.Marshal(localizations)
+		if err != nil {
+			return err
+		}
+		annotations[LocalizationsAnnotation] = string(encoded)
+	}
+	obj.SetAnnotations(annotations)
+
+	if err := o.client.Update(context.Background(), obj); err != nil {
+		return fmt.Errorf("failed to update dashboard %s: %v", name, err)
+	}
+	return nil
+}
+
+// Localize resolves the dashboard's display strings for the best matching
+// locale of the Accept-Language header.
+func (o *operator) Localize(namespace, name, acceptLanguage string) (LocalizedDashboard, error) {
+	spec, meta, err := o.dashboard(namespace, name)
+	if err != nil {
+		return LocalizedDashboard{}, err
+	}
+	localizations, err := decodeLocalizations(name, meta.GetAnnotations()[LocalizationsAnnotation])
+	if err != nil {
+		return LocalizedDashboard{}, err
+	}
+
+	localized := LocalizedDashboard{
+		Title:       spec.Title,
+		Description: spec.Description,
+	}
+
+	locale := matchLocale(localizations, acceptLanguage)
+	if locale == "" {
+		return localized, nil
+	}
+	localization := localizations[locale]
+
+	localized.Locale = locale
+	if localization.Title != "" {
+		localized.Title = localization.Title
+	}
+	if localization.Description != "" {
+		localized.Description = localization.Description
+	}
+	if len(localization.Panels) > 0 {
+		localized.Panels = make(map[string]string)
+		for title := range panelTitles(spec) {
+			if translated, ok := localization.Panels[title]; ok {
+				localized.Panels[title] = translated
+			}
+		}
+	}
+	return localized, nil
+}
+
+// matchLocale negotiates a locale against the Accept-Language header,
+// preferring an exact tag match and falling back to the bare language,
+// e.g. zh-TW matches a zh translation.
+func matchLocale(localizations map[string]Localization, acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		for locale := range localizations {
+			if strings.EqualFold(locale, tag) {
+				return locale
+			}
+		}
+		language := strings.SplitN(tag, "-", 2)[0]
+		for locale := range localizations {
+			if strings.EqualFold(strings.SplitN(locale, "-", 2)[0], language) {
+				return locale
+			}
+		}
+	}
+	return ""
+}
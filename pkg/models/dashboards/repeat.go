@@ -0,0 +1,203 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	monitoringdashboardv1alpha1 "kubesphere.io/monitoring-dashboard/api/v1alpha1"
+	"kubesphere.io/monitoring-dashboard/api/v1alpha1/panels"
+)
+
+// PanelRepeatsAnnotation stores the repeat-by-variable assignments as JSON.
+// A repeated graph expands to one graph per variable value; a repeated row
+// expands together with the panels beneath it, up to the next row.
+const PanelRepeatsAnnotation = "monitoring.kubesphere.io/panel-repeats"
+
+// PanelRepeat expands one panel per value of a templating variable.
+type PanelRepeat struct {
+	// Panel is the title of the Row or Graph panel
+	Panel string `json:"panel"`
+
+	// Variable is the name of the templating variable to repeat over
+	Variable string `json:"variable"`
+}
+
+func (o *operator) GetPanelRepeats(namespace, name string) ([]PanelRepeat, error) {
+	_, meta, err := o.dashboard(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRepeats(name, meta.GetAnnotations()[PanelRepeatsAnnotation])
+}
+
+func decodeRepeats(name, value string) ([]PanelRepeat, error) {
+	repeats := make([]PanelRepeat, 0)
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &repeats); err != nil {
+			return nil, fmt.Errorf("dashboard %s has malformed panel repeats: %v", name, err)
+		}
+	}
+	return repeats, nil
+}
+
+func (o *operator) SetPanelRepeats(namespace, name string, repeats []PanelRepeat) error {
+	spec, obj, err := o.dashboard(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	repeatable := make(map[string]bool)
+	for _, panel := range spec.Panels {
+		if panel.Row != nil {
+			repeatable[panel.Row.Title] = true
+		}
+		if panel.Graph != nil {
+			repeatable[panel.Graph.Title] = true
+		}
+	}
+	variables := make(map[string]bool)
+	for _, templating := range spec.Templatings {
+		variables[templating.Name] = true
+	}
+	for _, repeat := range repeats {
+		if repeat.Panel == "" || repeat.Variable == "" {
+			return fmt.Errorf("a repeat needs a panel title and a variable name")
+		}
+		if !repeatable[repeat.Panel] {
+			return fmt.Errorf("dashboard %s has no row or graph panel titled %q", name, repeat.Panel)
+		}
+		if !variables[repeat.Variable] {
+			return fmt.Errorf("dashboard %s declares no templating variable named %q", name, repeat.Variable)
+		}
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if len(repeats) == 0 {
+		delete(annotations, PanelRepeatsAnnotation)
+	} else {
+		encoded, err := json.Marshal(repeats)
+		if err != nil {
+			return err
+		}
+		annotations[PanelRepeatsAnnotation] = string(encoded)
+	}
+	obj.SetAnnotations(annotations)
+
+	if err := o.client.Update(context.Background(), obj); err != nil {
+		return fmt.Errorf("failed to update dashboard %s: %v", name, err)
+	}
+	return nil
+}
+
+// ExpandRepeats renders the dashboard's panels with repeats expanded, one
+// copy per variable value, for exports and static snapshots. values maps
+// each repeated variable to the values to expand over; variables without
+// values leave their panels unexpanded.
+func (o *operator) ExpandRepeats(namespace, name string, values map[string][]string) ([]monitoringdashboardv1alpha1.Panel, error) {
+	spec, meta, err := o.dashboard(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	repeats, err := decodeRepeats(name, meta.GetAnnotations()[PanelRepeatsAnnotation])
+	if err != nil {
+		return nil, err
+	}
+
+	repeatedBy := make(map[string]string)
+	for _, repeat := range repeats {
+		repeatedBy[repeat.Panel] = repeat.Variable
+	}
+
+	expanded := make([]monitoringdashboardv1alpha1.Panel, 0, len(spec.Panels))
+	for i := 0; i < len(spec.Panels); i++ {
+		panel := spec.Panels[i]
+		switch {
+		case panel.Row != nil:
+			// a repeated row carries the panels beneath it, up to the
+			// next row
+			section := []monitoringdashboardv1alpha1.Panel{panel}
+			for i+1 < len(spec.Panels) && spec.Panels[i+1].Row == nil {
+				i++
+				section = append(section, spec.Panels[i])
+			}
+			variable := repeatedBy[panel.Row.Title]
+			if vals := values[variable]; variable != "" && len(vals) > 0 {
+				for _, value := range vals {
+					for _, p := range section {
+						expanded = append(expanded, substitutePanel(p, variable, value))
+					}
+				}
+			} else {
+				expanded = append(expanded, section...)
+			}
+		case panel.Graph != nil:
+			variable := repeatedBy[panel.Graph.Title]
+			if vals := values[variable]; variable != "" && len(vals) > 0 {
+				for _, value := range vals {
+					expanded = append(expanded, substitutePanel(panel, variable, value))
+				}
+			} else {
+				expanded = append(expanded, panel)
+			}
+		default:
+			expanded = append(expanded, panel)
+		}
+	}
+	return expanded, nil
+}
+
+// substitutePanel clones the panel with $variable and ${variable} replaced
+// in titles, expressions and legends.
+func substitutePanel(panel monitoringdashboardv1alpha1.Panel, variable, value string) monitoringdashboardv1alpha1.Panel {
+	out := monitoringdashboardv1alpha1.Panel{}
+	switch {
+	case panel.Row != nil:
+		row := panel.Row.DeepCopy()
+		row.Title = substituteVariable(row.Title, variable, value)
+		out.Row = row
+	case panel.Graph != nil:
+		graph := panel.Graph.DeepCopy()
+		graph.Title = substituteVariable(graph.Title, variable, value)
+		substituteTargets(graph.Targets, variable, value)
+		out.Graph = graph
+	case panel.SingleStat != nil:
+		stat := panel.SingleStat.DeepCopy()
+		stat.Title = substituteVariable(stat.Title, variable, value)
+		substituteTargets(stat.Targets, variable, value)
+		out.SingleStat = stat
+	}
+	return out
+}
+
+func substituteTargets(targets []panels.Target, variable, value string) {
+	for i := range targets {
+		targets[i].Expression = substituteVariable(targets[i].Expression, variable, value)
+		targets[i].LegendFormat = substituteVariable(targets[i].LegendFormat, variable, value)
+	}
+}
+
+func substituteVariable(s, variable, value string) string {
+	s = strings.ReplaceAll(s, "${"+variable+"}", value)
+	return strings.ReplaceAll(s, "$"+variable, value)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// SingleStatDisplayAnnotation stores per-singlestat display settings as
+// JSON: sparkline, value mappings and drill-down links.
+const SingleStatDisplayAnnotation = "monitoring.kubesphere.io/singlestat-display"
+
+// ValueMapping replaces a raw value with a text, e.g. 0 -> Down, 1 -> Up.
+type ValueMapping struct {
+	Value string `json:"value"`
+	Text  string `json:"text"`
+}
+
+// PanelLink is a drill-down from a singlestat to another dashboard.
+type PanelLink struct {
+	// Dashboard is the name of the target dashboard
+	Dashboard string `json:"dashboard"`
+
+	// Namespace of the target dashboard; empty for a cluster dashboard
+	Namespace string `json:"namespace,omitempty"`
+
+	// CarryVariables carries the current template variable values over to
+	// the target dashboard
+	CarryVariables bool `json:"carryVariables,omitempty"`
+}
+
+// SingleStatDisplay extends one SingleStat panel with a sparkline, value
+// mappings and drill-down links.
+type SingleStatDisplay struct {
+	// Panel is the title of the SingleStat panel
+	Panel string `json:"panel"`
+
+	// Sparkline draws the metric's recent history behind the value
+	Sparkline bool `json:"sparkline,omitempty"`
+
+	ValueMappings []ValueMapping `json:"valueMappings,omitempty"`
+
+	Links []PanelLink `json:"links,omitempty"`
+}
+
+func validateSingleStatDisplay(display SingleStatDisplay) error {
+	if display.Panel == "" {
+		return fmt.Errorf("panel title must not be empty")
+	}
+	seen := make(map[string]bool)
+	for _, mapping := range display.ValueMappings {
+		if mapping.Value == "" {
+			return fmt.Errorf("panel %s: a value mapping needs a value", display.Panel)
+		}
+		if mapping.Text == "" {
+			return fmt.Errorf("panel %s: a value mapping needs a text", display.Panel)
+		}
+		if seen[mapping.Value] {
+			return fmt.Errorf("panel %s: value %q is mapped twice", display.Panel, mapping.Value)
+		}
+		seen[mapping.Value] = true
+	}
+	for _, link := range display.Links {
+		if link.Dashboard == "" {
+			return fmt.Errorf("panel %s: a link needs a target dashboard", display.Panel)
+		}
+	}
+	return nil
+}
+
+func (o *operator) GetSingleStatDisplays(namespace, name string) ([]SingleStatDisplay, error) {
+	_, meta, err := o.dashboard(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	displays := make([]SingleStatDisplay, 0)
+	if value := meta.GetAnnotations()[SingleStatDisplayAnnotation]; value != "" {
+		if err := json.Unmarshal([]byte(value), &displays); err != nil {
+			return nil, fmt.Errorf("dashboard %s has malformed singlestat display settings: %v", name, err)
+		}
+	}
+	return displays, nil
+}
+
+func (o *operator) SetSingleStatDisplays(namespace, name string, displays []SingleStatDisplay) error {
+	spec, obj, err := o.dashboard(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	stats := make(map[string]bool)
+	for _, panel := range spec.Panels {
+		if panel.SingleStat != nil {
+			stats[panel.SingleStat.Title] = true
+		}
+	}
+	for _, display := range displays {
+		if err := validateSingleStatDisplay(display); err != nil {
+			return err
+		}
+		if !stats[display.Panel] {
+			return fmt.Errorf("dashboard %s has no singlestat panel titled %q", name, display.Panel)
+		}
+		for _, link := range display.Links {
+			if _, _, err := o.dashboard(link.Namespace, link.Dashboard); err != nil {
+				return fmt.Errorf("panel %s: link target dashboard %s not found: %v", display.Panel, link.Dashboard, err)
+			}
+		}
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if len(displays) == 0 {
+		delete(annotations, SingleStatDisplayAnnotation)
+	} else {
+		encoded, err := json.Marshal(displays)
+		if err != nil {
+			return err
+		}
+		annotations[SingleStatDisplayAnnotation] = string(encoded)
+	}
+	obj.SetAnnotations(annotations)
+
+	if err := o.client.Update(context.Background(), obj); err != nil {
+		return fmt.Errorf("failed to update dashboard %s: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"kubesphere.io/kubesphere/pkg/models/monitoring/expressions"
+	monitoringclient "kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+	"kubesphere.io/monitoring-dashboard/api/v1alpha1/panels"
+)
+
+// PanelThresholdsAnnotation stores per-panel thresholds as JSON. The state
+// they drive is computed server-side so the console and any other API
+// consumer share one coloring logic.
+const PanelThresholdsAnnotation = "monitoring.kubesphere.io/panel-thresholds"
+
+const (
+	StateOK       = "ok"
+	StateWarning  = "warning"
+	StateCritical = "critical"
+
+	// OperatorGreaterOrEqual fires a threshold when the value reaches it,
+	// OperatorLessOrEqual when the value drops to it
+	OperatorGreaterOrEqual = ">="
+	OperatorLessOrEqual    = "<="
+)
+
+// PanelThreshold colors a Graph or SingleStat panel by its current value.
+type PanelThreshold struct {
+	// Panel is the title of the Graph or SingleStat panel
+	Panel string `json:"panel"`
+
+	// Operator is >= (default) or <=
+	Operator string `json:"operator,omitempty"`
+
+	Warning  *float64 `json:"warning,omitempty"`
+	Critical *float64 `json:"critical,omitempty"`
+}
+
+// PanelState is a panel's threshold evaluation at the current value.
+type PanelState struct {
+	Panel string  `json:"panel"`
+	Value float64 `json:"value,omitempty"`
+
+	// State is ok, warning or critical
+	State string `json:"state"`
+
+	// NoData is true when the panel's query returned nothing; State is ok
+	NoData bool `json:"noData,omitempty"`
+}
+
+func validateThreshold(threshold PanelThreshold) error {
+	if threshold.Panel == "" {
+		return fmt.Errorf("panel title must not be empty")
+	}
+	switch threshold.Operator {
+	case "", OperatorGreaterOrEqual, OperatorLessOrEqual:
+	default:
+		return fmt.Errorf("panel %s: operator must be %s or %s", threshold.Panel, OperatorGreaterOrEqual, OperatorLessOrEqual)
+	}
+	if threshold.Warning == nil && threshold.Critical == nil {
+		return fmt.Errorf("panel %s: a threshold needs a warning or a critical bound", threshold.Panel)
+	}
+	return nil
+}
+
+func (o *operator) GetPanelThresholds(namespace, name string) ([]PanelThreshold, error) {
+	_, meta, err := o.dashboard(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return decodeThresholds(name, meta.GetAnnotations()[PanelThresholdsAnnotation])
+}
+
+func decodeThresholds(name, value string) ([]PanelThreshold, error) {
+	thresholds := make([]PanelThreshold, 0)
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &thresholds); err != nil {
+			return nil, fmt.Errorf("dashboard %s has malformed panel thresholds: %v", name, err)
+		}
+	}
+	return thresholds, nil
+}
+
+func (o *operator) SetPanelThresholds(namespace, name string, thresholds []PanelThreshold) error {
+	spec, obj, err := o.dashboard(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	titles := panelTitles(spec)
+	for _, threshold := range thresholds {
+		if err := validateThreshold(threshold); err != nil {
+			return err
+		}
+		if !titles[threshold.Panel] {
+			return fmt.Errorf("dashboard %s has no graph or singlestat panel titled %q", name, threshold.Panel)
+		}
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if len(thresholds) == 0 {
+		delete(annotations, PanelThresholdsAnnotation)
+	} else {
+		encoded, err := json.Marshal(thresholds)
+		if err != nil {
+			return err
+		}
+		annotations[PanelThresholdsAnnotation] = string(encoded)
+	}
+	obj.SetAnnotations(annotations)
+
+	if err := o.client.Update(context.Background(), obj); err != nil {
+		return fmt.Errorf("failed to update dashboard %s: %v", name, err)
+	}
+	return nil
+}
+
+// EvaluatePanelStates evaluates each threshold panel's first query at the
+// current time and returns the resulting states.
+func (o *operator) EvaluatePanelStates(namespace, name string) ([]PanelState, error) {
+	spec, meta, err := o.dashboard(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	thresholds, err := decodeThresholds(name, meta.GetAnnotations()[PanelThresholdsAnnotation])
+	if err != nil {
+		return nil, err
+	}
+
+	queries := make(map[string]string)
+	for _, panel := range spec.Panels {
+		if panel.Graph != nil {
+			queries[panel.Graph.Title] = firstExpression(panel.Graph.Targets)
+		}
+		if panel.SingleStat != nil {
+			queries[panel.SingleStat.Title] = firstExpression(panel.SingleStat.Targets)
+		}
+	}
+
+	states := make([]PanelState, 0, len(thresholds))
+	for _, threshold := range thresholds {
+		state := PanelState{Panel: threshold.Panel, State: StateOK}
+		expr := queries[threshold.Panel]
+		if expr == "" {
+			state.NoData = true
+			states = append(states, state)
+			continue
+		}
+		if namespace != "" {
+			if expr, err = expressions.ReplaceNamespaceFns["prometheus"](expr, namespace); err != nil {
+				return nil, fmt.Errorf("panel %s: %v", threshold.Panel, err)
+			}
+		}
+		value, ok := currentValue(o.monitoring.GetMetric(expr, time.Now()))
+		if !ok {
+			state.NoData = true
+			states = append(states, state)
+			continue
+		}
+		state.Value = value
+		state.State = evaluate(threshold, value)
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func firstExpression(targets []panels.Target) string {
+	if len(targets) == 0 {
+		return ""
+	}
+	return targets[0].Expression
+}
+
+// currentValue extracts the panel's current value: the first series' sample
+// for vectors, its last point for matrices.
+func currentValue(metric monitoringclient.Metric) (float64, bool) {
+	if metric.Error != "" || len(metric.MetricValues) == 0 {
+		return 0, false
+	}
+	value := metric.MetricValues[0]
+	if value.Sample != nil {
+		return value.Sample[1], true
+	}
+	if len(value.Series) > 0 {
+		return value.Series[len(value.Series)-1][1], true
+	}
+	return 0, false
+}
+
+func evaluate(threshold PanelThreshold, value float64) string {
+	fired := func(bound float64) bool {
+		if threshold.Operator == OperatorLessOrEqual {
+			return value <= bound
+		}
+		return value >= bound
+	}
+	if threshold.Critical != nil && fired(*threshold.Critical) {
+		return StateCritical
+	}
+	if threshold.Warning != nil && fired(*threshold.Warning) {
+		return StateWarning
+	}
+	return StateOK
+}
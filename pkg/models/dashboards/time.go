@@ -0,0 +1,186 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringdashboardv1alpha1 "kubesphere.io/monitoring-dashboard/api/v1alpha1"
+)
+
+// PanelTimeOverridesAnnotation stores per-panel time overrides as JSON, the
+// dashboard spec's Time model stays the single range for all other panels.
+const PanelTimeOverridesAnnotation = "monitoring.kubesphere.io/panel-time-overrides"
+
+// relativeTime is the format the dashboard Time model uses, e.g. now-7d.
+var relativeTime = regexp.MustCompile(`^now([+-][0-9]+[smhdwMy])?$`)
+
+// timeshift is a signed offset applied to the panel's queries, e.g. -7d for
+// a week-over-week comparison.
+var timeshift = regexp.MustCompile(`^[+-]?[0-9]+[smhdwMy]$`)
+
+// QuickRange is a named time range preset the console offers next to the
+// free-form picker.
+type QuickRange struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// quickRanges are the built-in presets, the same set most monitoring UIs
+// converge on.
+var quickRanges = []QuickRange{
+	{Name: "Last 5 minutes", From: "now-5m", To: "now"},
+	{Name: "Last 15 minutes", From: "now-15m", To: "now"},
+	{Name: "Last 30 minutes", From: "now-30m", To: "now"},
+	{Name: "Last 1 hour", From: "now-1h", To: "now"},
+	{Name: "Last 6 hours", From: "now-6h", To: "now"},
+	{Name: "Last 12 hours", From: "now-12h", To: "now"},
+	{Name: "Last 24 hours", From: "now-24h", To: "now"},
+	{Name: "Last 2 days", From: "now-2d", To: "now"},
+	{Name: "Last 7 days", From: "now-7d", To: "now"},
+	{Name: "Last 30 days", From: "now-30d", To: "now"},
+	{Name: "Last 90 days", From: "now-90d", To: "now"},
+	{Name: "Previous week", From: "now-14d", To: "now-7d"},
+	{Name: "Previous month", From: "now-2M", To: "now-1M"},
+}
+
+// PanelTimeOverride pins one panel to its own time range or shifts its
+// queries by a fixed offset relative to the dashboard range. Title selects
+// the panel, panel ids are not stable across edits.
+type PanelTimeOverride struct {
+	// Panel is the title of the Graph or SingleStat panel
+	Panel string `json:"panel"`
+
+	// From and To replace the dashboard range for this panel
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+
+	// Timeshift offsets the panel's queries, e.g. -7d to compare with the
+	// week before; combine with an unchanged range for side-by-side panels
+	Timeshift string `json:"timeshift,omitempty"`
+}
+
+// ListQuickRanges returns the named time range presets.
+func ListQuickRanges() []QuickRange {
+	return quickRanges
+}
+
+func validateOverride(override PanelTimeOverride) error {
+	if override.Panel == "" {
+		return fmt.Errorf("panel title must not be empty")
+	}
+	if (override.From == "") != (override.To == "") {
+		return fmt.Errorf("panel %s: from and to must be set together", override.Panel)
+	}
+	for _, value := range []string{override.From, override.To} {
+		if value != "" && !relativeTime.MatchString(value) {
+			return fmt.Errorf("panel %s: %q is not a valid relative time, expected e.g. now-7d", override.Panel, value)
+		}
+	}
+	if override.Timeshift != "" && !timeshift.MatchString(override.Timeshift) {
+		return fmt.Errorf("panel %s: %q is not a valid timeshift, expected e.g. -7d", override.Panel, override.Timeshift)
+	}
+	if override.From == "" && override.Timeshift == "" {
+		return fmt.Errorf("panel %s: an override needs a time range or a timeshift", override.Panel)
+	}
+	return nil
+}
+
+// panelTitles collects the titles of the dashboard's graph and singlestat
+// panels, the ones a time override can address.
+func panelTitles(spec monitoringdashboardv1alpha1.DashboardSpec) map[string]bool {
+	titles := make(map[string]bool)
+	for _, panel := range spec.Panels {
+		if panel.Graph != nil {
+			titles[panel.Graph.Title] = true
+		}
+		if panel.SingleStat != nil {
+			titles[panel.SingleStat.Title] = true
+		}
+	}
+	return titles
+}
+
+func (o *operator) GetPanelTimeOverrides(namespace, name string) ([]PanelTimeOverride, error) {
+	_, meta, err := o.dashboard(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	overrides := make([]PanelTimeOverride, 0)
+	if value := meta.GetAnnotations()[PanelTimeOverridesAnnotation]; value != "" {
+		if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+			return nil, fmt.Errorf("dashboard %s has malformed panel time overrides: %v", name, err)
+		}
+	}
+	return overrides, nil
+}
+
+func (o *operator) SetPanelTimeOverrides(namespace, name string, overrides []PanelTimeOverride) error {
+	spec, obj, err := o.dashboard(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	titles := panelTitles(spec)
+	for _, override := range overrides {
+		if err := validateOverride(override); err != nil {
+			return err
+		}
+		if !titles[override.Panel] {
+			return fmt.Errorf("dashboard %s has no graph or singlestat panel titled %q", name, override.Panel)
+		}
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if len(overrides) == 0 {
+		delete(annotations, PanelTimeOverridesAnnotation)
+	} else {
+		encoded, err := json.Marshal(overrides)
+		if err != nil {
+			return err
+		}
+		annotations[PanelTimeOverridesAnnotation] = string(encoded)
+	}
+	obj.SetAnnotations(annotations)
+
+	if err := o.client.Update(context.Background(), obj); err != nil {
+		return fmt.Errorf("failed to update dashboard %s: %v", name, err)
+	}
+	return nil
+}
+
+// dashboard fetches a namespaced or, with an empty namespace, a cluster
+// dashboard.
+func (o *operator) dashboard(namespace, name string) (monitoringdashboardv1alpha1.DashboardSpec, dashboardObject, error) {
+	if namespace == "" {
+		dashboard := &monitoringdashboardv1alpha1.ClusterDashboard{}
+		err := o.client.Get(context.Background(), runtimeclient.ObjectKey{Name: name}, dashboard)
+		return dashboard.Spec, dashboard, err
+	}
+	dashboard := &monitoringdashboardv1alpha1.Dashboard{}
+	err := o.client.Get(context.Background(), runtimeclient.ObjectKey{Namespace: namespace, Name: name}, dashboard)
+	return dashboard.Spec, dashboard, err
+}
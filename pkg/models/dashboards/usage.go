@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	monitoringdashboardv1alpha1 "kubesphere.io/monitoring-dashboard/api/v1alpha1"
+)
+
+const (
+	// ViewCountAnnotation counts how often the dashboard was opened
+	ViewCountAnnotation = "monitoring.kubesphere.io/view-count"
+
+	// LastViewedAnnotation is when the dashboard was last opened, RFC3339
+	LastViewedAnnotation = "monitoring.kubesphere.io/last-viewed"
+
+	// staleAfter marks dashboards nobody opened for this long as prune
+	// candidates
+	staleAfter = 90 * 24 * time.Hour
+)
+
+// UsageEntry is one dashboard's view statistics.
+type UsageEntry struct {
+	Name          string `json:"name"`
+	Namespace     string `json:"namespace,omitempty"`
+	ClusterScoped bool   `json:"clusterScoped"`
+	Title         string `json:"title,omitempty"`
+
+	Views int64 `json:"views"`
+
+	// LastViewed is empty for dashboards never opened
+	LastViewed string `json:"lastViewed,omitempty"`
+
+	// Stale is true for dashboards never opened or not opened for 90 days
+	Stale bool `json:"stale"`
+}
+
+// RecordView bumps the dashboard's view counter and last-viewed timestamp.
+func (o *operator) RecordView(namespace, name string) error {
+	_, obj, err := o.dashboard(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	views, _ := strconv.ParseInt(annotations[ViewCountAnnotation], 10, 64)
+	annotations[ViewCountAnnotation] = strconv.FormatInt(views+1, 10)
+	annotations[LastViewedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	obj.SetAnnotations(annotations)
+
+	if err := o.client.Update(context.Background(), obj); err != nil {
+		return fmt.Errorf("failed to update dashboard %s: %v", name, err)
+	}
+	return nil
+}
+
+// UsageReport lists every dashboard's view statistics, least viewed first,
+// so prune candidates lead the report.
+func (o *operator) UsageReport() ([]UsageEntry, error) {
+	entries := make([]UsageEntry, 0)
+
+	dashboards := &monitoringdashboardv1alpha1.DashboardList{}
+	if err := o.cache.List(context.Background(), dashboards); err != nil {
+		return nil, err
+	}
+	for i := range dashboards.Items {
+		entries = append(entries, usage(&dashboards.Items[i].ObjectMeta, dashboards.Items[i].Spec, false))
+	}
+
+	clusterDashboards := &monitoringdashboardv1alpha1.ClusterDashboardList{}
+	if err := o.cache.List(context.Background(), clusterDashboards); err != nil {
+		return nil, err
+	}
+	for i := range clusterDashboards.Items {
+		entries = append(entries, usage(&clusterDashboards.Items[i].ObjectMeta, clusterDashboards.Items[i].Spec, true))
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Views != entries[j].Views {
+			return entries[i].Views < entries[j].Views
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+func usage(meta *metav1.ObjectMeta, spec monitoringdashboardv1alpha1.DashboardSpec, clusterScoped bool) UsageEntry {
+	entry := UsageEntry{
+		Name:          meta.Name,
+		Namespace:     meta.Namespace,
+		ClusterScoped: clusterScoped,
+		Title:         spec.Title,
+		Stale:         true,
+	}
+	entry.Views, _ = strconv.ParseInt(meta.Annotations[ViewCountAnnotation], 10, 64)
+	entry.LastViewed = meta.Annotations[LastViewedAnnotation]
+	if entry.LastViewed != "" {
+		if lastViewed, err := time.Parse(time.RFC3339, entry.LastViewed); err == nil {
+			entry.Stale = time.Since(lastViewed) > staleAfter
+		}
+	}
+	return entry
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// AgentPoolConfigMapName names the ConfigMap holding the per-project
+	// Jenkins agent pool configurations; the casc sync in the devops
+	// system rolls them into the Jenkins kubernetes cloud configuration
+	AgentPoolConfigMapName = "devops-agent-pools"
+	agentPoolDataKey       = "pools"
+)
+
+// AgentResources sizes the build agent pods of a project.
+type AgentResources struct {
+	RequestCPU    string `json:"requestCPU,omitempty"`
+	RequestMemory string `json:"requestMemory,omitempty"`
+	LimitCPU      string `json:"limitCPU,omitempty"`
+	LimitMemory   string `json:"limitMemory,omitempty"`
+}
+
+// AgentPoolConfig caps and places the build agents of one devops project,
+// so heavyweight builds cannot consume the shared build capacity.
+type AgentPoolConfig struct {
+	// DevOpsProject is the devops project the pool belongs to
+	DevOpsProject string `json:"devops"`
+
+	// NodeSelector schedules the project's agent pods onto these nodes,
+	// e.g. a dedicated build node group
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Resources sizes the project's agent pods; empty keeps the shared
+	// defaults
+	Resources *AgentResources `json:"resources,omitempty"`
+
+	// MaxConcurrentAgents caps how many agent pods the project may run at
+	// once; 0 leaves it uncapped
+	MaxConcurrentAgents int `json:"maxConcurrentAgents,omitempty"`
+
+	// ExecutorQuota caps how many executors the project's builds may hold
+	// concurrently across all agents; 0 leaves it uncapped
+	ExecutorQuota int `json:"executorQuota,omitempty"`
+}
+
+type AgentPoolOperator interface {
+	ListAgentPoolConfigs() ([]AgentPoolConfig, error)
+	// GetAgentPoolConfig returns the project's pool configuration, an
+	// empty configuration when none is set
+	GetAgentPoolConfig(devops string) (*AgentPoolConfig, error)
+	// SaveAgentPoolConfig replaces the project's pool configuration, an
+	// empty configuration removes it
+	SaveAgentPoolConfig(devops string, config *AgentPoolConfig) (*AgentPoolConfig, error)
+}
+
+type agentPoolOperator struct {
+	client kubernetes.Interface
+}
+
+func NewAgentPoolOperator(client kubernetes.Interface) AgentPoolOperator {
+	return &agentPoolOperator{client: client}
+}
+
+func validQuantity(name, value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := resource.ParseQuantity(value); err != nil {
+		return fmt.Errorf("invalid %s quantity %s: %v", name, value, err)
+	}
+	return nil
+}
+
+func validateAgentPoolConfig(config *AgentPoolConfig) error {
+	if config.MaxConcurrentAgents < 0 {
+		return fmt.Errorf("maxConcurrentAgents must not be negative")
+	}
+	if config.ExecutorQuota < 0 {
+		return fmt.Errorf("executorQuota must not be negative")
+	}
+	if config.Resources != nil {
+		for _, quantity := range []struct{ name, value string }{
+			{"requestCPU", config.Resources.RequestCPU},
+			{"requestMemory", config.Resources.RequestMemory},
+			{"limitCPU", config.Resources.LimitCPU},
+			{"limitMemory", config.Resources.LimitMemory},
+		} {
+			if err := validQuantity(quantity.name, quantity.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// empty reports whether the configuration constrains anything, an empty one
+// is removed instead of stored
+func (c *AgentPoolConfig) empty() bool {
+	return len(c.NodeSelector) == 0 && c.Resources == nil &&
+		c.MaxConcurrentAgents == 0 && c.ExecutorQuota == 0
+}
+
+func (o *agentPoolOperator) ListAgentPoolConfigs() ([]AgentPoolConfig, error) {
+	cm, err := o.client.CoreV1().ConfigMaps(constants.KubesphereDevOpsNamespace).
+		Get(context.Background(), AgentPoolConfigMapName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []AgentPoolConfig{}, nil
+		}
+		return nil, err
+	}
+	return unmarshalAgentPoolConfigs(cm)
+}
+
+func unmarshalAgentPoolConfigs(cm *corev1.ConfigMap) ([]AgentPoolConfig, error) {
+	data, ok := cm.Data[agentPoolDataKey]
+	if !ok || data == "" {
+		return []AgentPoolConfig{}, nil
+	}
+	var configs []AgentPoolConfig
+	if err := json.Unmarshal([]byte(data), &configs); err != nil {
+		return nil, fmt.Errorf("configmap %s holds invalid agent pool configurations: %v", cm.Name, err)
+	}
+	return configs, nil
+}
+
+func (o *agentPoolOperator) GetAgentPoolConfig(devops string) (*AgentPoolConfig, error) {
+	configs, err := o.ListAgentPoolConfigs()
+	if err != nil {
+		return nil, err
+	}
+	for i := range configs {
+		if configs[i].DevOpsProject == devops {
+			return &configs[i], nil
+		}
+	}
+	return &AgentPoolConfig{DevOpsProject: devops}, nil
+}
+
+func (o *agentPoolOperator) SaveAgentPoolConfig(devops string, config *AgentPoolConfig) (*AgentPoolConfig, error) {
+	config.DevOpsProject = devops
+	if err := validateAgentPoolConfig(config); err != nil {
+		return nil, err
+	}
+
+	configs, err := o.ListAgentPoolConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make([]AgentPoolConfig, 0, len(configs)+1)
+	for _, existing := range configs {
+		if existing.DevOpsProject != devops {
+			kept = append(kept, existing)
+		}
+	}
+	if !config.empty() {
+		kept = append(kept, *config)
+	}
+
+	if err := o.saveAgentPoolConfigs(kept); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (o *agentPoolOperator) saveAgentPoolConfigs(configs []AgentPoolConfig) error {
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      AgentPoolConfigMapName,
+			Namespace: constants.KubesphereDevOpsNamespace,
+		},
+		Data: map[string]string{agentPoolDataKey: string(data)},
+	}
+
+	configMaps := o.client.CoreV1().ConfigMaps(constants.KubesphereDevOpsNamespace)
+	existing, err := configMaps.Get(context.Background(), AgentPoolConfigMapName, v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		_, err := configMaps.Create(context.Background(), cm, v1.CreateOptions{})
+		return err
+	}
+
+	existing.Data = cm.Data
+	_, err = configMaps.Update(context.Background(), existing, v1.UpdateOptions{})
+	return err
+}
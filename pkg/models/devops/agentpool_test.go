@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devops
+
+import (
+	"testing"
+
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateAgentPoolConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  AgentPoolConfig
+		wantErr bool
+	}{
+		{
+			name:   "empty config",
+			config: AgentPoolConfig{},
+		},
+		{
+			name: "valid config",
+			config: AgentPoolConfig{
+				NodeSelector:        map[string]string{"node-role.kubernetes.io/build": ""},
+				Resources:           &AgentResources{RequestCPU: "500m", RequestMemory: "1Gi", LimitCPU: "2", LimitMemory: "4Gi"},
+				MaxConcurrentAgents: 5,
+				ExecutorQuota:       10,
+			},
+		},
+		{
+			name:    "negative agent cap",
+			config:  AgentPoolConfig{MaxConcurrentAgents: -1},
+			wantErr: true,
+		},
+		{
+			name:    "negative executor quota",
+			config:  AgentPoolConfig{ExecutorQuota: -1},
+			wantErr: true,
+		},
+		{
+			name:    "malformed quantity",
+			config:  AgentPoolConfig{Resources: &AgentResources{LimitMemory: "lots"}},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateAgentPoolConfig(&test.config)
+			if (err != nil) != test.wantErr {
+				t.Errorf("validateAgentPoolConfig() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestSaveAgentPoolConfig(t *testing.T) {
+	operator := NewAgentPoolOperator(fakek8s.NewSimpleClientset())
+
+	saved, err := operator.SaveAgentPoolConfig("project1", &AgentPoolConfig{ExecutorQuota: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.DevOpsProject != "project1" {
+		t.Errorf("expected the project name to be filled in, got %q", saved.DevOpsProject)
+	}
+
+	config, err := operator.GetAgentPoolConfig("project1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ExecutorQuota != 3 {
+		t.Errorf("unexpected executor quota %d", config.ExecutorQuota)
+	}
+
+	// a project without a configuration gets an empty one back
+	config, err = operator.GetAgentPoolConfig("project2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.DevOpsProject != "project2" || config.ExecutorQuota != 0 {
+		t.Errorf("unexpected default configuration %+v", config)
+	}
+
+	// saving an empty configuration removes the stored one
+	if _, err = operator.SaveAgentPoolConfig("project1", &AgentPoolConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	configs, err := operator.ListAgentPoolConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 0 {
+		t.Errorf("expected no stored configurations, got %+v", configs)
+	}
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnosis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	CategoryScheduling = "Scheduling"
+	CategoryImage      = "Image"
+	CategoryCrash      = "Crash"
+	CategoryOOM        = "OutOfMemory"
+	CategoryProbe      = "Probe"
+	CategoryConfig     = "Configuration"
+	CategoryVolume     = "Volume"
+
+	SeverityCritical = "Critical"
+	SeverityWarning  = "Warning"
+)
+
+// Finding is one observed problem with a remediation suggestion.
+type Finding struct {
+	Category  string `json:"category"`
+	Severity  string `json:"severity"`
+	Container string `json:"container,omitempty"`
+	Message   string `json:"message"`
+	// Suggestion tells first-line support what to check before escalating
+	Suggestion string `json:"suggestion"`
+}
+
+// Diagnosis is the structured result of inspecting a failing pod.
+type Diagnosis struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Phase     string `json:"phase"`
+	// Hypothesis is the most likely root cause, taken from the most severe
+	// finding
+	Hypothesis string    `json:"hypothesis"`
+	Findings   []Finding `json:"findings"`
+}
+
+type Operator interface {
+	// Diagnose inspects the pod's status and events and returns the likely
+	// root cause with remediation suggestions
+	Diagnose(namespace, pod string) (*Diagnosis, error)
+
+	// CheckNetwork runs connectivity probes from ephemeral pods in the
+	// namespace and returns the structured report
+	CheckNetwork(namespace string, spec NetworkCheckSpec) (*NetworkCheckReport, error)
+}
+
+type operator struct {
+	client kubernetes.Interface
+}
+
+func NewOperator(client kubernetes.Interface) Operator {
+	return &operator{client: client}
+}
+
+func (o *operator) Diagnose(namespace, podName string) (*Diagnosis, error) {
+	pod, err := o.client.CoreV1().Pods(namespace).Get(context.Background(), podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	diagnosis := &Diagnosis{
+		Namespace: namespace,
+		Pod:       podName,
+		Phase:     string(pod.Status.Phase),
+		Findings:  make([]Finding, 0),
+	}
+
+	diagnosis.Findings = append(diagnosis.Findings, schedulingFindings(pod)...)
+	diagnosis.Findings = append(diagnosis.Findings, containerFindings(pod)...)
+
+	events, err := o.client.CoreV1().Events(namespace).List(context.Background(), metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.kind=Pod,involvedObject.name=%s", podName),
+	})
+	if err == nil {
+		diagnosis.Findings = append(diagnosis.Findings, eventFindings(diagnosis.Findings, events.Items)...)
+	}
+
+	for _, finding := range diagnosis.Findings {
+		if finding.Severity == SeverityCritical {
+			diagnosis.Hypothesis = finding.Message
+			break
+		}
+	}
+	if diagnosis.Hypothesis == "" && len(diagnosis.Findings) > 0 {
+		diagnosis.Hypothesis = diagnosis.Findings[0].Message
+	}
+	if diagnosis.Hypothesis == "" {
+		diagnosis.Hypothesis = "no problems detected, the pod looks healthy"
+	}
+	return diagnosis, nil
+}
+
+func schedulingFindings(pod *corev1.Pod) []Finding {
+	findings := make([]Finding, 0)
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			findings = append(findings, Finding{
+				Category: CategoryScheduling,
+				Severity: SeverityCritical,
+				Message:  fmt.Sprintf("pod cannot be scheduled: %s", condition.Message),
+				Suggestion: "check whether any node satisfies the pod's resource requests, node selector, " +
+					"affinity rules and tolerations, or free up capacity",
+			})
+		}
+	}
+	return findings
+}
+
+func containerFindings(pod *corev1.Pod) []Finding {
+	findings := make([]Finding, 0)
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if waiting := status.State.Waiting; waiting != nil {
+			switch waiting.Reason {
+			case "ImagePullBackOff", "ErrImagePull", "InvalidImageName":
+				findings = append(findings, Finding{
+					Category:  CategoryImage,
+					Severity:  SeverityCritical,
+					Container: status.Name,
+					Message:   fmt.Sprintf("container %s cannot pull image %s: %s", status.Name, status.Image, waiting.Message),
+					Suggestion: "verify the image name and tag exist in the registry and that the namespace " +
+						"has a pull secret for it",
+				})
+			case "CreateContainerConfigError":
+				findings = append(findings, Finding{
+					Category:   CategoryConfig,
+					Severity:   SeverityCritical,
+					Container:  status.Name,
+					Message:    fmt.Sprintf("container %s has a configuration error: %s", status.Name, waiting.Message),
+					Suggestion: "check that the configmaps and secrets the container references exist and contain the expected keys",
+				})
+			case "CrashLoopBackOff":
+				findings = append(findings, crashFinding(status))
+			}
+		}
+		if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason == "OOMKilled" {
+			findings = append(findings, Finding{
+				Category:  CategoryOOM,
+				Severity:  SeverityCritical,
+				Container: status.Name,
+				Message:   fmt.Sprintf("container %s was killed because it exceeded its memory limit", status.Name),
+				Suggestion: "raise the container's memory limit or reduce its memory usage, then check the " +
+					"workload's memory metrics to size the limit",
+			})
+		}
+	}
+	return findings
+}
+
+func crashFinding(status corev1.ContainerStatus) Finding {
+	finding := Finding{
+		Category:  CategoryCrash,
+		Severity:  SeverityCritical,
+		Container: status.Name,
+		Message:   fmt.Sprintf("container %s is crash looping with %d restarts", status.Name, status.RestartCount),
+		Suggestion: "inspect the container's previous logs for the error it exits with, the restart backoff " +
+			"grows until the crash is fixed",
+	}
+	if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason != "OOMKilled" {
+		finding.Message = fmt.Sprintf("container %s is crash looping, last run exited with code %d after %d restarts",
+			status.Name, terminated.ExitCode, status.RestartCount)
+	}
+	return finding
+}
+
+func eventFindings(existing []Finding, events []corev1.Event) []Finding {
+	findings := make([]Finding, 0)
+	covered := make(map[string]bool, len(existing))
+	for _, finding := range existing {
+		covered[finding.Category] = true
+	}
+	seen := make(map[string]bool)
+	for _, event := range events {
+		if event.Type != corev1.EventTypeWarning || seen[event.Reason] {
+			continue
+		}
+		seen[event.Reason] = true
+		switch event.Reason {
+		case "Unhealthy":
+			probe := CategoryProbe
+			finding := Finding{
+				Category: probe,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("probe is failing: %s", event.Message),
+				Suggestion: "check that the probe's path, port and timeouts match what the application " +
+					"serves, slow startups need a longer initial delay or a startup probe",
+			}
+			if strings.Contains(event.Message, "Liveness") {
+				finding.Severity = SeverityCritical
+				finding.Message = fmt.Sprintf("liveness probe is failing and restarts the container: %s", event.Message)
+			}
+			findings = append(findings, finding)
+		case "FailedMount", "FailedAttachVolume":
+			if covered[CategoryVolume] {
+				continue
+			}
+			covered[CategoryVolume] = true
+			findings = append(findings, Finding{
+				Category:   CategoryVolume,
+				Severity:   SeverityCritical,
+				Message:    fmt.Sprintf("volume cannot be mounted: %s", event.Message),
+				Suggestion: "check that the referenced claim, configmap or secret exists and the volume is not attached to another node",
+			})
+		case "FailedScheduling":
+			if covered[CategoryScheduling] {
+				continue
+			}
+			covered[CategoryScheduling] = true
+			findings = append(findings, Finding{
+				Category:   CategoryScheduling,
+				Severity:   SeverityCritical,
+				Message:    fmt.Sprintf("pod cannot be scheduled: %s", event.Message),
+				Suggestion: "check node capacity, selectors and taints against the pod's requirements",
+			})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnosis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// probeImage runs the probe script; busybox has nslookup, wget, nc
+	// and httpd, which is all the probes need
+	probeImage = "busybox:1.31.1"
+
+	// probeLabel marks the ephemeral probe pods for cleanup
+	probeLabel = "diagnosis.kubesphere.io/probe"
+
+	probeTimeout  = 2 * time.Minute
+	probeInterval = 2 * time.Second
+
+	// defaults when the check does not name its own targets
+	defaultServiceTarget  = "kubernetes.default.svc.cluster.local:443"
+	defaultExternalTarget = "kubesphere.io:443"
+
+	listenerPort = 8080
+)
+
+// NetworkCheckSpec selects the probes to run and their targets. Zero
+// values run every probe against the defaults.
+type NetworkCheckSpec struct {
+	// ServiceTarget is a host:port inside the cluster, defaults to the
+	// kubernetes API service
+	ServiceTarget string `json:"serviceTarget,omitempty"`
+
+	// ExternalTarget is a host:port outside the cluster
+	ExternalTarget string `json:"externalTarget,omitempty"`
+
+	// SkipCrossNode skips the cross-node probe, which needs a second pod
+	// on another node
+	SkipCrossNode bool `json:"skipCrossNode,omitempty"`
+}
+
+// ProbeResult is the outcome of one probe.
+type ProbeResult struct {
+	// Name is one of dns, service, external, crossnode
+	Name    string `json:"name"`
+	Target  string `json:"target"`
+	Success bool   `json:"success"`
+
+	// LatencyMillis is how long the probe took inside the probe pod
+	LatencyMillis int64 `json:"latencyMillis"`
+}
+
+// NetworkCheckReport is the structured result of a connectivity self-test
+// run from ephemeral probe pods in the namespace.
+type NetworkCheckReport struct {
+	Namespace string        `json:"namespace"`
+	Node      string        `json:"node,omitempty"`
+	Probes    []ProbeResult `json:"probes"`
+	Passed    int           `json:"passed"`
+	Failed    int           `json:"failed"`
+
+	// Verdict sums the report up: ok, or the names of the failed probes
+	Verdict string `json:"verdict"`
+}
+
+// CheckNetwork runs the connectivity probes from ephemeral pods in the
+// namespace and returns the structured report. The probe pods are deleted
+// again when the check finishes.
+func (o *operator) CheckNetwork(namespace string, spec NetworkCheckSpec) (*NetworkCheckReport, error) {
+	if spec.ServiceTarget == "" {
+		spec.ServiceTarget = defaultServiceTarget
+	}
+	if spec.ExternalTarget == "" {
+		spec.ExternalTarget = defaultExternalTarget
+	}
+
+	var listenerIP, listenerNode string
+	if !spec.SkipCrossNode {
+		listener, err := o.startListener(namespace)
+		if err != nil {
+			return nil, err
+		}
+		defer o.deletePod(namespace, listener)
+		listenerIP, listenerNode, err = o.waitForListener(namespace, listener)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	probe, err := o.startProbe(namespace, spec, listenerIP, listenerNode)
+	if err != nil {
+		return nil, err
+	}
+	defer o.deletePod(namespace, probe)
+
+	logs, node, err := o.waitForProbe(namespace, probe)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &NetworkCheckReport{
+		Namespace: namespace,
+		Node:      node,
+		Probes:    parseProbeResults(logs),
+	}
+	failed := make([]string, 0)
+	for _, result := range report.Probes {
+		if result.Success {
+			report.Passed++
+		} else {
+			report.Failed++
+			failed = append(failed, result.Name)
+		}
+	}
+	if report.Failed == 0 {
+		report.Verdict = "ok"
+	} else {
+		report.Verdict = fmt.Sprintf("failed: %s", strings.Join(failed, ", "))
+	}
+	return report, nil
+}
+
+// probeScript times each probe with /proc/uptime, which busybox can read
+// at centisecond precision, and prints one RESULT line per probe.
+func probeScript(spec NetworkCheckSpec, listenerIP string) string {
+	script := `now() { cut -d' ' -f1 /proc/uptime; }
+probe() {
+  name=$1; target=$2; shift 2
+  start=$(now)
+  if "$@" >/dev/null 2>&1; then ok=ok; else ok=fail; fi
+  echo "RESULT $name $target $ok $start $(now)"
+}
+`
+	dnsName := strings.Split(spec.ServiceTarget, ":")[0]
+	script += fmt.Sprintf("probe dns %s nslookup %s\n", dnsName, dnsName)
+	script += fmt.Sprintf("probe service %s nc -z -w 5 %s\n", spec.ServiceTarget, strings.Replace(spec.ServiceTarget, ":", " ", 1))
+	script += fmt.Sprintf("probe external %s nc -z -w 10 %s\n", spec.ExternalTarget, strings.Replace(spec.ExternalTarget, ":", " ", 1))
+	if listenerIP != "" {
+		target := fmt.Sprintf("%s:%d", listenerIP, listenerPort)
+		script += fmt.Sprintf("probe crossnode %s wget -q -O /dev/null -T 10 http://%s\n", target, target)
+	}
+	return script
+}
+
+func parseProbeResults(logs string) []ProbeResult {
+	results := make([]ProbeResult, 0)
+	for _, line := range strings.Split(logs, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 6 || fields[0] != "RESULT" {
+			continue
+		}
+		result := ProbeResult{
+			Name:    fields[1],
+			Target:  fields[2],
+			Success: fields[3] == "ok",
+		}
+		start, err1 := strconv.ParseFloat(fields[4], 64)
+		end, err2 := strconv.ParseFloat(fields[5], 64)
+		if err1 == nil && err2 == nil && end >= start {
+			result.LatencyMillis = int64((end - start) * 1000)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// startListener starts the pod the cross-node probe connects to.
+func (o *operator) startListener(namespace string) (string, error) {
+	pod := probePod("network-listener-", []string{"httpd", "-f", "-p", strconv.Itoa(listenerPort)})
+	created, err := o.client.CoreV1().Pods(namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create the listener pod: %v", err)
+	}
+	return created.Name, nil
+}
+
+func (o *operator) waitForListener(namespace, name string) (ip, node string, err error) {
+	err = wait.Poll(probeInterval, probeTimeout, func() (bool, error) {
+		pod, err := o.client.CoreV1().Pods(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" {
+			ip = pod.Status.PodIP
+			node = pod.Spec.NodeName
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("the listener pod did not become ready: %v", err)
+	}
+	return ip, node, nil
+}
+
+// startProbe starts the probe pod; when a listener node is given the pod
+// is kept off that node so the crossnode probe really crosses nodes.
+func (o *operator) startProbe(namespace string, spec NetworkCheckSpec, listenerIP, listenerNode string) (string, error) {
+	pod := probePod("network-probe-", []string{"sh", "-c", probeScript(spec, listenerIP)})
+	if listenerNode != "" {
+		pod.Spec.Affinity = &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchFields: []corev1.NodeSelectorRequirement{{
+							Key:      "metadata.name",
+							Operator: corev1.NodeSelectorOpNotIn,
+							Values:   []string{listenerNode},
+						}},
+					}},
+				},
+			},
+		}
+	}
+	created, err := o.client.CoreV1().Pods(namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create the probe pod: %v", err)
+	}
+	return created.Name, nil
+}
+
+func (o *operator) waitForProbe(namespace, name string) (logs, node string, err error) {
+	err = wait.Poll(probeInterval, probeTimeout, func() (bool, error) {
+		pod, err := o.client.CoreV1().Pods(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		node = pod.Spec.NodeName
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			return true, nil
+		case corev1.PodFailed:
+			return false, fmt.Errorf("the probe pod failed")
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("the probe pod did not finish: %v", err)
+	}
+
+	raw, err := o.client.CoreV1().Pods(namespace).GetLogs(name, &corev1.PodLogOptions{}).DoRaw(context.Background())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read the probe pod's logs: %v", err)
+	}
+	return string(raw), node, nil
+}
+
+func probePod(generateName string, command []string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: generateName,
+			Labels:       map[string]string{probeLabel: "true"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers: []corev1.Container{{
+				Name:    "probe",
+				Image:   probeImage,
+				Command: command,
+			}},
+		},
+	}
+}
+
+func (o *operator) deletePod(namespace, name string) {
+	_ = o.client.CoreV1().Pods(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package falco collects runtime security alerts from Falco into the
+// kubernetes events pipeline. Falco pushes alerts through its http_output
+// to the ingestion endpoint, each alert is stored as an event in the
+// namespace the offending container runs in so the usual namespace RBAC
+// applies to queries. Alerts at or above the notification severity of the
+// namespace become warning events which the notification pipeline routes
+// to subscribers.
+package falco
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// PriorityLabel marks events ingested from Falco and holds the
+	// normalized alert priority so events can be listed by severity
+	PriorityLabel = "security.kubesphere.io/falco-priority"
+
+	// RuleAnnotation holds the name of the Falco rule that fired
+	RuleAnnotation = "security.kubesphere.io/falco-rule"
+
+	// NotifyLevelAnnotation on a namespace sets the minimum priority at
+	// which alerts become warning events and reach the notification
+	// pipeline, defaults to warning
+	NotifyLevelAnnotation = "security.kubesphere.io/falco-notify-level"
+
+	defaultNotifyLevel = "warning"
+
+	alertEventReason = "FalcoAlert"
+)
+
+// falcoPriorities orders the Falco priorities from most to least severe
+var falcoPriorities = []string{
+	"emergency", "alert", "critical", "error", "warning", "notice", "informational", "debug",
+}
+
+// Alert is the payload Falco sends through its http_output
+type Alert struct {
+	Output       string                 `json:"output"`
+	Priority     string                 `json:"priority"`
+	Rule         string                 `json:"rule"`
+	Source       string                 `json:"source,omitempty"`
+	Time         time.Time              `json:"time,omitempty"`
+	OutputFields map[string]interface{} `json:"output_fields,omitempty"`
+	Tags         []string               `json:"tags,omitempty"`
+}
+
+// SecurityEvent is a stored runtime security alert
+type SecurityEvent struct {
+	Rule      string      `json:"rule"`
+	Priority  string      `json:"priority"`
+	Message   string      `json:"message"`
+	Namespace string      `json:"namespace"`
+	Pod       string      `json:"pod,omitempty"`
+	Time      metav1.Time `json:"time"`
+}
+
+type Operator interface {
+	// Ingest stores a Falco alert as an event in the namespace of the
+	// offending container
+	Ingest(alert *Alert) error
+
+	// ListSecurityEvents returns the alerts of a namespace, newest first,
+	// optionally restricted to a minimum priority
+	ListSecurityEvents(namespace, minPriority string) ([]SecurityEvent, error)
+}
+
+type operator struct {
+	client kubernetes.Interface
+}
+
+func NewOperator(client kubernetes.Interface) Operator {
+	return &operator{client: client}
+}
+
+// priorityRank returns the position of a priority in the Falco ordering,
+// unknown priorities rank least severe
+func priorityRank(priority string) int {
+	priority = strings.ToLower(priority)
+	for i, known := range falcoPriorities {
+		if known == priority {
+			return i
+		}
+	}
+	return len(falcoPriorities)
+}
+
+func (a *Alert) field(name string) string {
+	if value, ok := a.OutputFields[name].(string); ok {
+		return value
+	}
+	return ""
+}
+
+func (o *operator) Ingest(alert *Alert) error {
+	if alert.Rule == "" {
+		return fmt.Errorf("alert has no rule")
+	}
+
+	// cluster level alerts without a namespace context end up in the
+	// KubeSphere namespace
+	namespace := alert.field("k8s.ns.name")
+	if namespace == "" {
+		namespace = constants.KubeSphereNamespace
+	}
+	podName := alert.field("k8s.pod.name")
+
+	when := alert.Time
+	if when.IsZero() {
+		when = time.Now()
+	}
+
+	involved := corev1.ObjectReference{Kind: "Namespace", Name: namespace, APIVersion: "v1"}
+	if podName != "" {
+		involved = corev1.ObjectReference{Kind: "Pod", Namespace: namespace, Name: podName, APIVersion: "v1"}
+	}
+
+	eventType := corev1.EventTypeNormal
+	if priorityRank(alert.Priority) <= priorityRank(o.notifyLevel(namespace)) {
+		eventType = corev1.EventTypeWarning
+	}
+
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "falco-",
+			Namespace:    namespace,
+			Labels: map[string]string{
+				PriorityLabel: strings.ToLower(alert.Priority),
+			},
+			Annotations: map[string]string{
+				RuleAnnotation: alert.Rule,
+			},
+		},
+		InvolvedObject: involved,
+		Reason:         alertEventReason,
+		Message:        alert.Output,
+		Type:           eventType,
+		FirstTimestamp: metav1.Time{Time: when},
+		LastTimestamp:  metav1.Time{Time: when},
+		Count:          1,
+		Source:         corev1.EventSource{Component: "falco"},
+	}
+
+	_, err := o.client.CoreV1().Events(namespace).Create(context.Background(), event, metav1.CreateOptions{})
+	return err
+}
+
+// notifyLevel reads the notification severity of the namespace
+func (o *operator) notifyLevel(namespace string) string {
+	ns, err := o.client.CoreV1().Namespaces().Get(context.Background(), namespace, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Failed to get namespace %s: %v", namespace, err)
+		return defaultNotifyLevel
+	}
+	if level := ns.Annotations[NotifyLevelAnnotation]; level != "" {
+		return level
+	}
+	return defaultNotifyLevel
+}
+
+func (o *operator) ListSecurityEvents(namespace, minPriority string) ([]SecurityEvent, error) {
+	list, err := o.client.CoreV1().Events(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: PriorityLabel,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]SecurityEvent, 0, len(list.Items))
+	for _, event := range list.Items {
+		priority := event.Labels[PriorityLabel]
+		if minPriority != "" && priorityRank(priority) > priorityRank(minPriority) {
+			continue
+		}
+		securityEvent := SecurityEvent{
+			Rule:      event.Annotations[RuleAnnotation],
+			Priority:  priority,
+			Message:   event.Message,
+			Namespace: event.Namespace,
+			Time:      event.LastTimestamp,
+		}
+		if event.InvolvedObject.Kind == "Pod" {
+			securityEvent.Pod = event.InvolvedObject.Name
+		}
+		events = append(events, securityEvent)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[j].Time.Before(&events[i].Time)
+	})
+	return events, nil
+}
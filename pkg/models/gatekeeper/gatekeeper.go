@@ -0,0 +1,236 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gatekeeper surfaces the OPA Gatekeeper policy library through the
+// KubeSphere API: constraint templates installed in the cluster, the
+// constraints enabled from them and the violations Gatekeeper's audit found.
+// Notification delivery rides on Gatekeeper's own audit events.
+package gatekeeper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog"
+)
+
+var (
+	templatesGVR = schema.GroupVersionResource{Group: "templates.gatekeeper.sh", Version: "v1beta1", Resource: "constrainttemplates"}
+
+	constraintsGroupVersion = schema.GroupVersion{Group: "constraints.gatekeeper.sh", Version: "v1beta1"}
+)
+
+// Template summarizes an installed Gatekeeper constraint template.
+type Template struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Description string `json:"description,omitempty"`
+}
+
+// Constraint is a constraint enabled from a template, scoped to namespaces
+// through spec.match and parameterized through spec.parameters.
+type Constraint struct {
+	Kind              string                 `json:"kind"`
+	Name              string                 `json:"name"`
+	EnforcementAction string                 `json:"enforcementAction,omitempty"`
+	Namespaces        []string               `json:"namespaces,omitempty"`
+	Parameters        map[string]interface{} `json:"parameters,omitempty"`
+	TotalViolations   int64                  `json:"totalViolations"`
+}
+
+// Violation is one entry of a constraint's audit results.
+type Violation struct {
+	Constraint string `json:"constraint"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Message    string `json:"message"`
+}
+
+type Operator interface {
+	// ListTemplates lists the constraint templates installed in the cluster
+	ListTemplates() ([]Template, error)
+
+	// ListConstraints lists the constraints enabled from all templates
+	ListConstraints() ([]Constraint, error)
+
+	// EnableConstraint creates a constraint from a template
+	EnableConstraint(constraint *Constraint) error
+
+	// DisableConstraint deletes a constraint
+	DisableConstraint(kind, name string) error
+
+	// ListViolations aggregates audit violations, optionally filtered by
+	// namespace
+	ListViolations(namespace string) ([]Violation, error)
+}
+
+type operator struct {
+	client dynamic.Interface
+}
+
+func NewOperator(client dynamic.Interface) Operator {
+	return &operator{client: client}
+}
+
+func (o *operator) ListTemplates() ([]Template, error) {
+	list, err := o.client.Resource(templatesGVR).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("gatekeeper is not installed in this cluster")
+		}
+		return nil, err
+	}
+
+	templates := make([]Template, 0, len(list.Items))
+	for _, item := range list.Items {
+		kind, _, _ := unstructured.NestedString(item.Object, "spec", "crd", "spec", "names", "kind")
+		templates = append(templates, Template{
+			Name:        item.GetName(),
+			Kind:        kind,
+			Description: item.GetAnnotations()["description"],
+		})
+	}
+	return templates, nil
+}
+
+// constraintGVR returns the GVR of the constraint kind a template defines,
+// gatekeeper names the resource after the lowercased kind
+func constraintGVR(kind string) schema.GroupVersionResource {
+	return constraintsGroupVersion.WithResource(strings.ToLower(kind))
+}
+
+func (o *operator) ListConstraints() ([]Constraint, error) {
+	templates, err := o.ListTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	constraints := make([]Constraint, 0)
+	for _, template := range templates {
+		if template.Kind == "" {
+			continue
+		}
+		list, err := o.client.Resource(constraintGVR(template.Kind)).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			// the constraint CRD appears only after gatekeeper processed the template
+			klog.V(4).Infof("list %s constraints: %v", template.Kind, err)
+			continue
+		}
+		for _, item := range list.Items {
+			constraints = append(constraints, constraintFromObject(&item))
+		}
+	}
+	return constraints, nil
+}
+
+func constraintFromObject(obj *unstructured.Unstructured) Constraint {
+	enforcementAction, _, _ := unstructured.NestedString(obj.Object, "spec", "enforcementAction")
+	namespaces, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "match", "namespaces")
+	parameters, _, _ := unstructured.NestedMap(obj.Object, "spec", "parameters")
+	totalViolations, _, _ := unstructured.NestedInt64(obj.Object, "status", "totalViolations")
+	return Constraint{
+		Kind:              obj.GetKind(),
+		Name:              obj.GetName(),
+		EnforcementAction: enforcementAction,
+		Namespaces:        namespaces,
+		Parameters:        parameters,
+		TotalViolations:   totalViolations,
+	}
+}
+
+func (o *operator) EnableConstraint(constraint *Constraint) error {
+	if constraint.Kind == "" || constraint.Name == "" {
+		return fmt.Errorf("constraint kind and name must not be empty")
+	}
+
+	spec := map[string]interface{}{}
+	if len(constraint.Namespaces) > 0 {
+		namespaces := make([]interface{}, 0, len(constraint.Namespaces))
+		for _, namespace := range constraint.Namespaces {
+			namespaces = append(namespaces, namespace)
+		}
+		spec["match"] = map[string]interface{}{"namespaces": namespaces}
+	}
+	if len(constraint.Parameters) > 0 {
+		spec["parameters"] = constraint.Parameters
+	}
+	if constraint.EnforcementAction != "" {
+		spec["enforcementAction"] = constraint.EnforcementAction
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": constraintsGroupVersion.String(),
+		"kind":       constraint.Kind,
+		"metadata":   map[string]interface{}{"name": constraint.Name},
+		"spec":       spec,
+	}}
+
+	_, err := o.client.Resource(constraintGVR(constraint.Kind)).Create(context.Background(), obj, metav1.CreateOptions{})
+	return err
+}
+
+func (o *operator) DisableConstraint(kind, name string) error {
+	return o.client.Resource(constraintGVR(kind)).Delete(context.Background(), name, metav1.DeleteOptions{})
+}
+
+func (o *operator) ListViolations(namespace string) ([]Violation, error) {
+	templates, err := o.ListTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]Violation, 0)
+	for _, template := range templates {
+		if template.Kind == "" {
+			continue
+		}
+		list, err := o.client.Resource(constraintGVR(template.Kind)).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			continue
+		}
+		for _, item := range list.Items {
+			entries, _, _ := unstructured.NestedSlice(item.Object, "status", "violations")
+			for _, entry := range entries {
+				violation, ok := entry.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				violationNamespace, _ := violation["namespace"].(string)
+				if namespace != "" && violationNamespace != namespace {
+					continue
+				}
+				kind, _ := violation["kind"].(string)
+				name, _ := violation["name"].(string)
+				message, _ := violation["message"].(string)
+				violations = append(violations, Violation{
+					Constraint: fmt.Sprintf("%s/%s", item.GetKind(), item.GetName()),
+					Kind:       kind,
+					Namespace:  violationNamespace,
+					Name:       name,
+					Message:    message,
+				})
+			}
+		}
+	}
+	return violations, nil
+}
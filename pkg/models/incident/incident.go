@@ -0,0 +1,464 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package incident turns storms of critical alerts into Incident custom
+// resources. Alertmanager posts firing alerts to the report endpoint; once
+// enough critical alerts of one namespace arrive within the correlation
+// window an incident is opened, and later alerts keep attaching to it while
+// it stays active. Reading an incident merges the Kubernetes events and
+// workload rollouts of its window into the recorded timeline, giving the
+// postmortem one ordered view of what happened.
+package incident
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	incidentv1alpha1 "kubesphere.io/kubesphere/pkg/apis/incident/v1alpha1"
+)
+
+const (
+	// correlationWindow bounds how far apart critical alerts may arrive
+	// and still count as one storm
+	correlationWindow = 10 * time.Minute
+
+	// stormThreshold is how many critical alerts within the window open
+	// an incident
+	stormThreshold = 3
+
+	// enrichmentMargin widens the incident window when aggregating events
+	// and rollouts, a deploy shortly before the first alert is usually
+	// the interesting one
+	enrichmentMargin = 10 * time.Minute
+
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+var incidentGVR = schema.GroupVersionResource{
+	Group:    incidentv1alpha1.SchemeGroupVersion.Group,
+	Version:  incidentv1alpha1.SchemeGroupVersion.Version,
+	Resource: incidentv1alpha1.ResourcePluralIncident,
+}
+
+// Alert is one firing alert as posted by alertmanager's webhook.
+type Alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt,omitempty"`
+}
+
+// AlertWebhook is the body of an alertmanager webhook notification, only the
+// alerts are looked at.
+type AlertWebhook struct {
+	Alerts []Alert `json:"alerts"`
+}
+
+// ReportResult says what reporting a batch of alerts did.
+type ReportResult struct {
+	// alerts attached to already open incidents
+	Correlated int `json:"correlated"`
+	// incidents opened by this batch
+	Opened []string `json:"opened,omitempty"`
+}
+
+type Operator interface {
+	// ReportAlerts feeds firing alerts into the correlation, opening
+	// incidents from storms of critical alerts and attaching later
+	// alerts to open incidents
+	ReportAlerts(alerts []Alert) (*ReportResult, error)
+	// List returns the incidents newest first, optionally filtered by
+	// namespace and state
+	List(namespace string, state incidentv1alpha1.IncidentState) ([]incidentv1alpha1.Incident, error)
+	// Get returns the incident with events and rollouts of its window
+	// merged into the timeline
+	Get(name string) (*incidentv1alpha1.Incident, error)
+	// AddAction records an operator action on the incident's timeline
+	AddAction(name, user, message string) error
+	// Resolve closes the incident
+	Resolve(name, user string) error
+}
+
+type operator struct {
+	client        kubernetes.Interface
+	dynamicClient dynamic.Interface
+
+	// recent critical alerts per namespace which have not opened an
+	// incident yet
+	mutex   sync.Mutex
+	pending map[string][]pendingAlert
+}
+
+type pendingAlert struct {
+	at    time.Time
+	alert Alert
+}
+
+func NewOperator(client kubernetes.Interface, dynamicClient dynamic.Interface) Operator {
+	return &operator{
+		client:        client,
+		dynamicClient: dynamicClient,
+		pending:       make(map[string][]pendingAlert),
+	}
+}
+
+func convert(obj *unstructured.Unstructured) (*incidentv1alpha1.Incident, error) {
+	incident := &incidentv1alpha1.Incident{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, incident); err != nil {
+		return nil, err
+	}
+	return incident, nil
+}
+
+func alertEntry(alert Alert, at time.Time) incidentv1alpha1.TimelineEntry {
+	message := alert.Annotations["message"]
+	if message == "" {
+		message = alert.Annotations["summary"]
+	}
+	return incidentv1alpha1.TimelineEntry{
+		Time:    metav1.Time{Time: at},
+		Type:    incidentv1alpha1.EntryAlert,
+		Source:  alert.Labels["alertname"],
+		Message: message,
+	}
+}
+
+func (o *operator) ReportAlerts(alerts []Alert) (*ReportResult, error) {
+	result := &ReportResult{}
+
+	for _, alert := range alerts {
+		if alert.Labels["severity"] != "critical" {
+			continue
+		}
+		at := alert.StartsAt
+		if at.IsZero() {
+			at = time.Now()
+		}
+		namespace := alert.Labels["namespace"]
+
+		attached, err := o.attach(namespace, alert, at)
+		if err != nil {
+			return nil, err
+		}
+		if attached {
+			result.Correlated++
+			continue
+		}
+
+		opened, err := o.collect(namespace, alert, at)
+		if err != nil {
+			return nil, err
+		}
+		if opened != "" {
+			result.Opened = append(result.Opened, opened)
+		}
+	}
+	return result, nil
+}
+
+// attach appends the alert to an open incident of the namespace whose last
+// alert is still within the correlation window.
+func (o *operator) attach(namespace string, alert Alert, at time.Time) (bool, error) {
+	list, err := o.dynamicClient.Resource(incidentGVR).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return false, err
+	}
+	for i := range list.Items {
+		incident, err := convert(&list.Items[i])
+		if err != nil {
+			return false, err
+		}
+		if incident.Status.State != incidentv1alpha1.IncidentOpen || incident.Spec.Namespace != namespace {
+			continue
+		}
+		if incident.Status.LastAlertTime == nil || at.Sub(incident.Status.LastAlertTime.Time) > correlationWindow {
+			continue
+		}
+
+		incident.Status.AlertCount++
+		incident.Status.LastAlertTime = &metav1.Time{Time: at}
+		incident.Status.Timeline = append(incident.Status.Timeline, alertEntry(alert, at))
+		return true, o.update(incident)
+	}
+	return false, nil
+}
+
+// collect buffers the alert and opens an incident once the namespace
+// accumulated a storm within the correlation window.
+func (o *operator) collect(namespace string, alert Alert, at time.Time) (string, error) {
+	o.mutex.Lock()
+	kept := o.pending[namespace][:0]
+	for _, p := range o.pending[namespace] {
+		if at.Sub(p.at) <= correlationWindow {
+			kept = append(kept, p)
+		}
+	}
+	kept = append(kept, pendingAlert{at: at, alert: alert})
+	if len(kept) < stormThreshold {
+		o.pending[namespace] = kept
+		o.mutex.Unlock()
+		return "", nil
+	}
+	delete(o.pending, namespace)
+	o.mutex.Unlock()
+
+	return o.open(namespace, kept)
+}
+
+func (o *operator) open(namespace string, alerts []pendingAlert) (string, error) {
+	title := fmt.Sprintf("Critical alert storm in namespace %s", namespace)
+	if namespace == "" {
+		title = "Cluster wide critical alert storm"
+	}
+
+	first := alerts[0]
+	last := alerts[len(alerts)-1]
+	incident := &incidentv1alpha1.Incident{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: incidentv1alpha1.SchemeGroupVersion.String(),
+			Kind:       incidentv1alpha1.ResourceKindIncident,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "incident-",
+		},
+		Spec: incidentv1alpha1.IncidentSpec{
+			Title:     title,
+			Namespace: namespace,
+			Alertname: first.alert.Labels["alertname"],
+		},
+		Status: incidentv1alpha1.IncidentStatus{
+			State:         incidentv1alpha1.IncidentOpen,
+			StartTime:     &metav1.Time{Time: first.at},
+			LastAlertTime: &metav1.Time{Time: last.at},
+			AlertCount:    len(alerts),
+		},
+	}
+	for _, p := range alerts {
+		incident.Status.Timeline = append(incident.Status.Timeline, alertEntry(p.alert, p.at))
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(incident)
+	if err != nil {
+		return "", err
+	}
+	created, err := o.dynamicClient.Resource(incidentGVR).Create(context.Background(), &unstructured.Unstructured{Object: content}, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	return created.GetName(), nil
+}
+
+func (o *operator) update(incident *incidentv1alpha1.Incident) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(incident)
+	if err != nil {
+		return err
+	}
+	_, err = o.dynamicClient.Resource(incidentGVR).Update(context.Background(), &unstructured.Unstructured{Object: content}, metav1.UpdateOptions{})
+	return err
+}
+
+func (o *operator) List(namespace string, state incidentv1alpha1.IncidentState) ([]incidentv1alpha1.Incident, error) {
+	list, err := o.dynamicClient.Resource(incidentGVR).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	incidents := make([]incidentv1alpha1.Incident, 0, len(list.Items))
+	for i := range list.Items {
+		incident, err := convert(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		if namespace != "" && incident.Spec.Namespace != namespace {
+			continue
+		}
+		if state != "" && incident.Status.State != state {
+			continue
+		}
+		incidents = append(incidents, *incident)
+	}
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[j].CreationTimestamp.Before(&incidents[i].CreationTimestamp)
+	})
+	return incidents, nil
+}
+
+func (o *operator) Get(name string) (*incidentv1alpha1.Incident, error) {
+	obj, err := o.dynamicClient.Resource(incidentGVR).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	incident, err := convert(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := window(incident)
+	incident.Status.Timeline = append(incident.Status.Timeline, o.eventEntries(incident.Spec.Namespace, from, to)...)
+	incident.Status.Timeline = append(incident.Status.Timeline, o.deployEntries(incident.Spec.Namespace, from, to)...)
+	sort.SliceStable(incident.Status.Timeline, func(i, j int) bool {
+		return incident.Status.Timeline[i].Time.Before(&incident.Status.Timeline[j].Time)
+	})
+	return incident, nil
+}
+
+// window is the time span events and rollouts are aggregated over: a margin
+// before the first alert until the incident was resolved, or until a margin
+// after the last alert while it is still open.
+func window(incident *incidentv1alpha1.Incident) (time.Time, time.Time) {
+	from := incident.CreationTimestamp.Time
+	if incident.Status.StartTime != nil {
+		from = incident.Status.StartTime.Time
+	}
+	from = from.Add(-enrichmentMargin)
+
+	if incident.Status.ResolvedTime != nil {
+		return from, incident.Status.ResolvedTime.Time
+	}
+	to := time.Now()
+	if incident.Status.LastAlertTime != nil && incident.Status.LastAlertTime.Time.Add(enrichmentMargin).Before(to) {
+		to = incident.Status.LastAlertTime.Time.Add(enrichmentMargin)
+	}
+	return from, to
+}
+
+// eventEntries collects the Kubernetes events of the namespace within the
+// incident window. Aggregation failures only shorten the timeline, the
+// recorded entries still come back.
+func (o *operator) eventEntries(namespace string, from, to time.Time) []incidentv1alpha1.TimelineEntry {
+	if namespace == "" {
+		return nil
+	}
+	events, err := o.client.CoreV1().Events(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil
+	}
+
+	var entries []incidentv1alpha1.TimelineEntry
+	for _, event := range events.Items {
+		at := event.LastTimestamp.Time
+		if at.IsZero() {
+			at = event.CreationTimestamp.Time
+		}
+		if at.Before(from) || at.After(to) {
+			continue
+		}
+		if event.Type == corev1.EventTypeNormal {
+			continue
+		}
+		entries = append(entries, incidentv1alpha1.TimelineEntry{
+			Time:    metav1.Time{Time: at},
+			Type:    incidentv1alpha1.EntryEvent,
+			Source:  event.Reason,
+			Message: fmt.Sprintf("%s/%s: %s", event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Message),
+		})
+	}
+	return entries
+}
+
+// deployEntries collects the workload rollouts of the namespace within the
+// incident window, one entry per replicaset created in it.
+func (o *operator) deployEntries(namespace string, from, to time.Time) []incidentv1alpha1.TimelineEntry {
+	if namespace == "" {
+		return nil
+	}
+	replicaSets, err := o.client.AppsV1().ReplicaSets(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil
+	}
+
+	var entries []incidentv1alpha1.TimelineEntry
+	for _, rs := range replicaSets.Items {
+		at := rs.CreationTimestamp.Time
+		if at.Before(from) || at.After(to) {
+			continue
+		}
+		entries = append(entries, incidentv1alpha1.TimelineEntry{
+			Time:    metav1.Time{Time: at},
+			Type:    incidentv1alpha1.EntryDeploy,
+			Source:  deployName(&rs),
+			Message: fmt.Sprintf("revision %s rolled out", rs.Annotations[revisionAnnotation]),
+		})
+	}
+	return entries
+}
+
+func deployName(rs *appsv1.ReplicaSet) string {
+	for _, owner := range rs.OwnerReferences {
+		if owner.Kind == "Deployment" {
+			return owner.Name
+		}
+	}
+	return rs.Name
+}
+
+func (o *operator) AddAction(name, user, message string) error {
+	if message == "" {
+		return fmt.Errorf("an action needs a message")
+	}
+	obj, err := o.dynamicClient.Resource(incidentGVR).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	incident, err := convert(obj)
+	if err != nil {
+		return err
+	}
+
+	incident.Status.Timeline = append(incident.Status.Timeline, incidentv1alpha1.TimelineEntry{
+		Time:    metav1.Time{Time: time.Now()},
+		Type:    incidentv1alpha1.EntryAction,
+		Source:  user,
+		Message: message,
+	})
+	return o.update(incident)
+}
+
+func (o *operator) Resolve(name, user string) error {
+	obj, err := o.dynamicClient.Resource(incidentGVR).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	incident, err := convert(obj)
+	if err != nil {
+		return err
+	}
+	if incident.Status.State == incidentv1alpha1.IncidentResolved {
+		return fmt.Errorf("incident %s is already resolved", name)
+	}
+
+	now := metav1.Time{Time: time.Now()}
+	incident.Status.State = incidentv1alpha1.IncidentResolved
+	incident.Status.ResolvedTime = &now
+	incident.Status.Timeline = append(incident.Status.Timeline, incidentv1alpha1.TimelineEntry{
+		Time:    now,
+		Type:    incidentv1alpha1.EntryAction,
+		Source:  user,
+		Message: "incident resolved",
+	})
+	return o.update(incident)
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspection
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog"
+
+	inspectionv1alpha1 "kubesphere.io/kubesphere/pkg/apis/inspection/v1alpha1"
+)
+
+var reportGVR = schema.GroupVersionResource{
+	Group:    inspectionv1alpha1.SchemeGroupVersion.Group,
+	Version:  inspectionv1alpha1.SchemeGroupVersion.Version,
+	Resource: inspectionv1alpha1.ResourcePluralInspectionReport,
+}
+
+// ReportDiff compares two inspection reports of the same inspection, the
+// older one is the baseline.
+type ReportDiff struct {
+	// the newer report
+	Report string `json:"report"`
+	// the older report compared against
+	Against string `json:"against"`
+
+	// checks failing now that did not fail in the baseline
+	NewFailures []inspectionv1alpha1.CheckResult `json:"newFailures,omitempty"`
+	// checks that failed in the baseline and no longer do
+	Resolved []inspectionv1alpha1.CheckResult `json:"resolved,omitempty"`
+	// checks failing in both reports
+	StillFailing []inspectionv1alpha1.CheckResult `json:"stillFailing,omitempty"`
+}
+
+type Operator interface {
+	// ListReports returns the reports of an inspection, newest first
+	ListReports(inspection string) ([]inspectionv1alpha1.InspectionReport, error)
+	GetReport(name string) (*inspectionv1alpha1.InspectionReport, error)
+	// Diff compares a report against an older one, against defaults to the
+	// run right before the report
+	Diff(report, against string) (*ReportDiff, error)
+}
+
+type operator struct {
+	client dynamic.Interface
+}
+
+func NewOperator(client dynamic.Interface) Operator {
+	return &operator{client: client}
+}
+
+func (o *operator) ListReports(inspection string) ([]inspectionv1alpha1.InspectionReport, error) {
+	list, err := o.client.Resource(reportGVR).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", inspectionv1alpha1.ClusterInspectionLabel, inspection),
+	})
+	if err != nil {
+		klog.Errorf("list inspection reports of %s failed: %v", inspection, err)
+		return nil, err
+	}
+
+	reports := make([]inspectionv1alpha1.InspectionReport, 0, len(list.Items))
+	for i := range list.Items {
+		report, err := convertReport(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[j].StartTime.Before(&reports[i].StartTime)
+	})
+	return reports, nil
+}
+
+func (o *operator) GetReport(name string) (*inspectionv1alpha1.InspectionReport, error) {
+	obj, err := o.client.Resource(reportGVR).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return convertReport(obj)
+}
+
+func (o *operator) Diff(report, against string) (*ReportDiff, error) {
+	newer, err := o.GetReport(report)
+	if err != nil {
+		return nil, err
+	}
+
+	if against == "" {
+		against, err = o.previousReport(newer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	older, err := o.GetReport(against)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &ReportDiff{Report: report, Against: against}
+	baseline := map[string]bool{}
+	for _, result := range older.Results {
+		if result.Status == inspectionv1alpha1.CheckFail {
+			baseline[result.ID] = true
+		}
+	}
+	current := map[string]bool{}
+	for _, result := range newer.Results {
+		if result.Status != inspectionv1alpha1.CheckFail {
+			continue
+		}
+		current[result.ID] = true
+		if baseline[result.ID] {
+			diff.StillFailing = append(diff.StillFailing, result)
+		} else {
+			diff.NewFailures = append(diff.NewFailures, result)
+		}
+	}
+	for _, result := range older.Results {
+		if result.Status == inspectionv1alpha1.CheckFail && !current[result.ID] {
+			diff.Resolved = append(diff.Resolved, result)
+		}
+	}
+	return diff, nil
+}
+
+// previousReport finds the run of the same inspection right before the
+// given report.
+func (o *operator) previousReport(report *inspectionv1alpha1.InspectionReport) (string, error) {
+	inspection := report.Labels[inspectionv1alpha1.ClusterInspectionLabel]
+	reports, err := o.ListReports(inspection)
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range reports {
+		if candidate.Name != report.Name && candidate.StartTime.Before(&report.StartTime) {
+			return candidate.Name, nil
+		}
+	}
+	return "", fmt.Errorf("report %s has no previous run to compare against", report.Name)
+}
+
+func convertReport(obj *unstructured.Unstructured) (*inspectionv1alpha1.InspectionReport, error) {
+	report := &inspectionv1alpha1.InspectionReport{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/openpitrix/helmwrapper"
+)
+
+const (
+	// DefaultChartsDir is where the component charts are mounted. When a
+	// component's chart is present its enable and disable run through helm
+	// in seconds; otherwise the change falls back to a targeted installer
+	// reconcile.
+	DefaultChartsDir = "/var/lib/kubesphere/charts"
+
+	// previousStateAnnotationPrefix records the flag value before the last
+	// change, per component, so a failed change can be rolled back
+	previousStateAnnotationPrefix = "installer.kubesphere.io/previous-state-"
+
+	// ReconcileComponentAnnotation asks ks-installer to reconcile a single
+	// component instead of running every task
+	ReconcileComponentAnnotation = "installer.kubesphere.io/reconcile-component"
+
+	PhasePending    = "Pending"
+	PhaseRunning    = "Running"
+	PhaseSucceeded  = "Succeeded"
+	PhaseFailed     = "Failed"
+	PhaseRolledBack = "RolledBack"
+)
+
+// ComponentStatus is the declared and observed state of one pluggable
+// component.
+type ComponentStatus struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// Ready is true when the component's workload is deployed with all
+	// replicas ready
+	Ready bool `json:"ready"`
+	// HelmManaged is true when the component's chart is available, so
+	// enable and disable run through helm instead of the installer
+	HelmManaged bool `json:"helmManaged"`
+}
+
+// ChangeProgress tracks an in-flight or finished enable, disable or
+// rollback of a component.
+type ChangeProgress struct {
+	Component      string       `json:"component"`
+	Action         string       `json:"action"`
+	Phase          string       `json:"phase"`
+	Message        string       `json:"message,omitempty"`
+	StartTime      metav1.Time  `json:"startTime"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// ComponentOperator switches pluggable components on and off at runtime.
+type ComponentOperator interface {
+	// ListComponents lists the pluggable components with their declared
+	// and observed state
+	ListComponents() ([]ComponentStatus, error)
+
+	// SetComponentEnabled flips the component's flag in the
+	// ClusterConfiguration and reconciles it, asynchronously
+	SetComponentEnabled(name string, enabled bool) error
+
+	// ComponentProgress reports the state of the component's latest change
+	ComponentProgress(name string) (*ChangeProgress, error)
+
+	// RollbackComponent restores the component's flag to the value before
+	// the last change and reconciles it
+	RollbackComponent(name string) error
+}
+
+func componentByName(name string) *component {
+	for i := range components {
+		if components[i].name == name {
+			return &components[i]
+		}
+	}
+	return nil
+}
+
+func (o *operator) chartPath(name string) string {
+	return filepath.Join(o.chartsDir, name+".tgz")
+}
+
+func (o *operator) helmManaged(name string) bool {
+	_, err := os.Stat(o.chartPath(name))
+	return err == nil
+}
+
+func (o *operator) ListComponents() ([]ComponentStatus, error) {
+	cc, err := o.clusterConfiguration()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]ComponentStatus, 0, len(components))
+	for _, c := range components {
+		enabled, _, _ := unstructured.NestedBool(cc.Object, c.enabledKey...)
+		status := ComponentStatus{
+			Name:        c.name,
+			Enabled:     enabled,
+			HelmManaged: o.helmManaged(c.name),
+		}
+		deployment, err := o.client.AppsV1().Deployments(c.namespace).Get(context.Background(), c.deployment, metav1.GetOptions{})
+		if err == nil {
+			desired := int32(1)
+			if deployment.Spec.Replicas != nil {
+				desired = *deployment.Spec.Replicas
+			}
+			status.Ready = deployment.Status.ReadyReplicas == desired
+		} else if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (o *operator) SetComponentEnabled(name string, enabled bool) error {
+	c := componentByName(name)
+	if c == nil {
+		return fmt.Errorf("unknown component %s", name)
+	}
+
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	if err := o.beginChange(name, action); err != nil {
+		return err
+	}
+
+	cc, err := o.clusterConfiguration()
+	if err != nil {
+		o.finishChange(name, PhaseFailed, err.Error())
+		return err
+	}
+	previous, _, _ := unstructured.NestedBool(cc.Object, c.enabledKey...)
+	if err := unstructured.SetNestedField(cc.Object, enabled, c.enabledKey...); err != nil {
+		o.finishChange(name, PhaseFailed, err.Error())
+		return err
+	}
+	annotations := cc.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[previousStateAnnotationPrefix+name] = fmt.Sprintf("%t", previous)
+	if !o.helmManaged(name) {
+		annotations[ReconcileComponentAnnotation] = name
+	}
+	cc.SetAnnotations(annotations)
+	if _, err := o.dynamic.Resource(clusterConfigurationResource).Namespace(cc.GetNamespace()).
+		Update(context.Background(), cc, metav1.UpdateOptions{}); err != nil {
+		o.finishChange(name, PhaseFailed, err.Error())
+		return err
+	}
+
+	go o.reconcileComponent(c, enabled)
+	return nil
+}
+
+func (o *operator) RollbackComponent(name string) error {
+	c := componentByName(name)
+	if c == nil {
+		return fmt.Errorf("unknown component %s", name)
+	}
+	cc, err := o.clusterConfiguration()
+	if err != nil {
+		return err
+	}
+	previous, ok := cc.GetAnnotations()[previousStateAnnotationPrefix+name]
+	if !ok {
+		return fmt.Errorf("component %s has no recorded previous state to roll back to", name)
+	}
+	if err := o.SetComponentEnabled(name, previous == "true"); err != nil {
+		return err
+	}
+	o.setPhase(name, PhaseRolledBack)
+	return nil
+}
+
+func (o *operator) ComponentProgress(name string) (*ChangeProgress, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	progress, ok := o.changes[name]
+	if !ok {
+		return nil, fmt.Errorf("no change recorded for component %s", name)
+	}
+	copied := *progress
+	return &copied, nil
+}
+
+// reconcileComponent applies the declared state. With a chart present the
+// change runs through helm; otherwise ks-installer picks up the targeted
+// reconcile annotation and this only tracks it.
+func (o *operator) reconcileComponent(c *component, enabled bool) {
+	if !o.helmManaged(c.name) {
+		o.finishChange(c.name, PhaseSucceeded, "handed over to ks-installer for targeted reconciliation")
+		return
+	}
+
+	wrapper := helmwrapper.NewHelmWrapper("", c.namespace, c.name)
+	var err error
+	if enabled {
+		var chartData []byte
+		if chartData, err = ioutil.ReadFile(o.chartPath(c.name)); err == nil {
+			_, err = wrapper.Upgrade(c.name, string(chartData), "")
+		}
+	} else {
+		_, err = wrapper.Uninstall()
+	}
+	if err != nil {
+		klog.Errorf("failed to reconcile component %s: %v", c.name, err)
+		o.finishChange(c.name, PhaseFailed, err.Error())
+		return
+	}
+	o.finishChange(c.name, PhaseSucceeded, "")
+}
+
+func (o *operator) beginChange(name, action string) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if progress, ok := o.changes[name]; ok && (progress.Phase == PhasePending || progress.Phase == PhaseRunning) {
+		return fmt.Errorf("a change of component %s is already in progress", name)
+	}
+	o.changes[name] = &ChangeProgress{
+		Component: name,
+		Action:    action,
+		Phase:     PhaseRunning,
+		StartTime: metav1.Now(),
+	}
+	return nil
+}
+
+func (o *operator) finishChange(name, phase, message string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if progress, ok := o.changes[name]; ok {
+		progress.Phase = phase
+		progress.Message = message
+		progress.CompletionTime = &metav1.Time{Time: time.Now()}
+	}
+}
+
+func (o *operator) setPhase(name, phase string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if progress, ok := o.changes[name]; ok {
+		progress.Phase = phase
+	}
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	clusterConfigurationName = "ks-installer"
+
+	// ReconcileRequestedAnnotation kicks ks-installer into a reconcile run,
+	// any change to the ClusterConfiguration does
+	ReconcileRequestedAnnotation = "installer.kubesphere.io/reconcile-requested"
+
+	DriftMissing      = "Missing"
+	DriftUnexpected   = "Unexpected"
+	DriftReplicas     = "Replicas"
+	DriftImageVersion = "ImageVersion"
+)
+
+var clusterConfigurationResource = schema.GroupVersionResource{
+	Group:    "installer.kubesphere.io",
+	Version:  "v1alpha1",
+	Resource: "clusterconfigurations",
+}
+
+// component maps a ClusterConfiguration toggle to the deployment the
+// installer creates for it.
+type component struct {
+	name       string
+	enabledKey []string
+	namespace  string
+	deployment string
+}
+
+// components lists the optional components the drift check knows about.
+var components = []component{
+	{name: "devops", enabledKey: []string{"spec", "devops", "enabled"}, namespace: "kubesphere-devops-system", deployment: "ks-jenkins"},
+	{name: "events", enabledKey: []string{"spec", "events", "enabled"}, namespace: "kubesphere-logging-system", deployment: "ks-events-operator"},
+	{name: "auditing", enabledKey: []string{"spec", "auditing", "enabled"}, namespace: "kubesphere-logging-system", deployment: "kube-auditing-operator"},
+	{name: "logging", enabledKey: []string{"spec", "logging", "enabled"}, namespace: "kubesphere-logging-system", deployment: "fluentbit-operator"},
+	{name: "servicemesh", enabledKey: []string{"spec", "servicemesh", "enabled"}, namespace: "istio-system", deployment: "istiod"},
+	{name: "metrics_server", enabledKey: []string{"spec", "metrics_server", "enabled"}, namespace: "kube-system", deployment: "metrics-server"},
+}
+
+// coreDeployments are always installed, their image tags must match the
+// version the ClusterConfiguration declares.
+var coreDeployments = []string{"ks-apiserver", "ks-controller-manager", "ks-console"}
+
+// Drift is one observed difference between the declared ClusterConfiguration
+// and the deployed components.
+type Drift struct {
+	Component string `json:"component"`
+	Type      string `json:"type"`
+	Declared  string `json:"declared"`
+	Actual    string `json:"actual"`
+	Message   string `json:"message"`
+}
+
+type Operator interface {
+	// ListDrift compares the ClusterConfiguration with the deployed
+	// components and returns every difference found
+	ListDrift() ([]Drift, error)
+
+	// TriggerReconcile asks ks-installer to reconcile the drifted
+	// components back to the declared state
+	TriggerReconcile() error
+
+	// GetClusterConfiguration returns the declared ClusterConfiguration
+	GetClusterConfiguration() (*unstructured.Unstructured, error)
+
+	// UpdateClusterConfiguration validates and persists the given
+	// ClusterConfiguration, with dryRun it validates without persisting
+	UpdateClusterConfiguration(cc *unstructured.Unstructured, dryRun bool) error
+
+	ComponentOperator
+}
+
+type operator struct {
+	client    kubernetes.Interface
+	dynamic   dynamic.Interface
+	chartsDir string
+
+	mutex   sync.Mutex
+	changes map[string]*ChangeProgress
+}
+
+func NewOperator(client kubernetes.Interface, config *rest.Config) (Operator, error) {
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &operator{
+		client:    client,
+		dynamic:   dynamicClient,
+		chartsDir: DefaultChartsDir,
+		changes:   make(map[string]*ChangeProgress),
+	}, nil
+}
+
+func (o *operator) clusterConfiguration() (*unstructured.Unstructured, error) {
+	return o.dynamic.Resource(clusterConfigurationResource).Namespace(constants.KubeSphereNamespace).
+		Get(context.Background(), clusterConfigurationName, metav1.GetOptions{})
+}
+
+func (o *operator) ListDrift() ([]Drift, error) {
+	cc, err := o.clusterConfiguration()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clusterconfiguration %s: %v", clusterConfigurationName, err)
+	}
+
+	drifts := make([]Drift, 0)
+	for _, c := range components {
+		enabled, _, _ := unstructured.NestedBool(cc.Object, c.enabledKey...)
+		_, err := o.client.AppsV1().Deployments(c.namespace).Get(context.Background(), c.deployment, metav1.GetOptions{})
+		deployed := err == nil
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+
+		if enabled && !deployed {
+			drifts = append(drifts, Drift{
+				Component: c.name,
+				Type:      DriftMissing,
+				Declared:  "enabled",
+				Actual:    "not deployed",
+				Message:   fmt.Sprintf("%s is enabled but deployment %s/%s does not exist", c.name, c.namespace, c.deployment),
+			})
+		}
+		if !enabled && deployed {
+			drifts = append(drifts, Drift{
+				Component: c.name,
+				Type:      DriftUnexpected,
+				Declared:  "disabled",
+				Actual:    "deployed",
+				Message:   fmt.Sprintf("%s is disabled but deployment %s/%s still exists", c.name, c.namespace, c.deployment),
+			})
+		}
+	}
+
+	if consoleDrift := o.consoleReplicaDrift(cc); consoleDrift != nil {
+		drifts = append(drifts, *consoleDrift)
+	}
+	versionDrifts, err := o.imageVersionDrift(cc)
+	if err != nil {
+		return nil, err
+	}
+	return append(drifts, versionDrifts...), nil
+}
+
+func (o *operator) consoleReplicaDrift(cc *unstructured.Unstructured) *Drift {
+	declared, found, _ := unstructured.NestedInt64(cc.Object, "spec", "console", "replicas")
+	if !found || declared == 0 {
+		return nil
+	}
+	console, err := o.client.AppsV1().Deployments(constants.KubeSphereNamespace).Get(context.Background(), "ks-console", metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	actual := int64(1)
+	if console.Spec.Replicas != nil {
+		actual = int64(*console.Spec.Replicas)
+	}
+	if actual == declared {
+		return nil
+	}
+	return &Drift{
+		Component: "console",
+		Type:      DriftReplicas,
+		Declared:  fmt.Sprintf("%d", declared),
+		Actual:    fmt.Sprintf("%d", actual),
+		Message:   fmt.Sprintf("ks-console runs %d replicas but the cluster configuration declares %d", actual, declared),
+	}
+}
+
+// imageVersionDrift compares the image tags of the core deployments with the
+// version label of the ClusterConfiguration.
+func (o *operator) imageVersionDrift(cc *unstructured.Unstructured) ([]Drift, error) {
+	version := cc.GetLabels()["version"]
+	if version == "" {
+		return nil, nil
+	}
+
+	drifts := make([]Drift, 0)
+	for _, name := range coreDeployments {
+		deployment, err := o.client.AppsV1().Deployments(constants.KubeSphereNamespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			tag := imageTag(container.Image)
+			if tag == "" || tag == version {
+				continue
+			}
+			drifts = append(drifts, Drift{
+				Component: name,
+				Type:      DriftImageVersion,
+				Declared:  version,
+				Actual:    tag,
+				Message:   fmt.Sprintf("%s runs image %s but the cluster configuration declares version %s", name, container.Image, version),
+			})
+		}
+	}
+	return drifts, nil
+}
+
+func imageTag(image string) string {
+	i := strings.LastIndex(image, ":")
+	// no tag, or the colon belongs to a registry port
+	if i < 0 || strings.Contains(image[i+1:], "/") {
+		return ""
+	}
+	return image[i+1:]
+}
+
+func (o *operator) TriggerReconcile() error {
+	cc, err := o.clusterConfiguration()
+	if err != nil {
+		return fmt.Errorf("failed to get clusterconfiguration %s: %v", clusterConfigurationName, err)
+	}
+	annotations := cc.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[ReconcileRequestedAnnotation] = time.Now().Format(time.RFC3339)
+	cc.SetAnnotations(annotations)
+	_, err = o.dynamic.Resource(clusterConfigurationResource).Namespace(constants.KubeSphereNamespace).
+		Update(context.Background(), cc, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package installer
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
+)
+
+// validClusterRoles are the values spec.multicluster.clusterRole accepts.
+var validClusterRoles = []string{"", "none", "host", "member"}
+
+// ValidateClusterConfiguration checks field combinations of a
+// ClusterConfiguration that the installer would only trip over mid-run,
+// returning one actionable error per broken combination.
+func ValidateClusterConfiguration(cc *unstructured.Unstructured) []error {
+	var errs []error
+
+	esConfigured := elasticsearchConfigured(cc)
+	for _, component := range []string{"logging", "events", "auditing"} {
+		if enabled, _, _ := unstructured.NestedBool(cc.Object, "spec", component, "enabled"); enabled && !esConfigured {
+			errs = append(errs, fmt.Errorf("spec.%s.enabled is true but no elasticsearch is configured, "+
+				"set spec.common.es.externalElasticsearchUrl and externalElasticsearchPort "+
+				"or spec.common.es.elasticsearchMasterReplicas", component))
+		}
+	}
+
+	externalUrl, _, _ := unstructured.NestedString(cc.Object, "spec", "common", "es", "externalElasticsearchUrl")
+	_, portFound := externalElasticsearchPort(cc)
+	if len(externalUrl) != 0 && !portFound {
+		errs = append(errs, fmt.Errorf("spec.common.es.externalElasticsearchUrl is set but "+
+			"externalElasticsearchPort is missing"))
+	}
+	if portFound && len(externalUrl) == 0 {
+		errs = append(errs, fmt.Errorf("spec.common.es.externalElasticsearchPort is set but "+
+			"externalElasticsearchUrl is missing"))
+	}
+
+	if clusterRole, found, _ := unstructured.NestedString(cc.Object, "spec", "multicluster", "clusterRole"); found &&
+		!sliceutil.HasString(validClusterRoles, clusterRole) {
+		errs = append(errs, fmt.Errorf("spec.multicluster.clusterRole %q is invalid, "+
+			"must be one of none, host, member", clusterRole))
+	}
+
+	return errs
+}
+
+// elasticsearchConfigured reports whether the ClusterConfiguration declares
+// either an internal or an external elasticsearch.
+func elasticsearchConfigured(cc *unstructured.Unstructured) bool {
+	if url, _, _ := unstructured.NestedString(cc.Object, "spec", "common", "es", "externalElasticsearchUrl"); len(url) != 0 {
+		return true
+	}
+	replicas, _, _ := unstructured.NestedInt64(cc.Object, "spec", "common", "es", "elasticsearchMasterReplicas")
+	return replicas > 0
+}
+
+// externalElasticsearchPort tolerates the port being declared as a number or
+// a string, both appear in the wild.
+func externalElasticsearchPort(cc *unstructured.Unstructured) (string, bool) {
+	if port, found, _ := unstructured.NestedString(cc.Object, "spec", "common", "es", "externalElasticsearchPort"); found && len(port) != 0 {
+		return port, true
+	}
+	if port, found, _ := unstructured.NestedInt64(cc.Object, "spec", "common", "es", "externalElasticsearchPort"); found && port != 0 {
+		return fmt.Sprintf("%d", port), true
+	}
+	return "", false
+}
+
+// GetClusterConfiguration returns the declared ClusterConfiguration.
+func (o *operator) GetClusterConfiguration() (*unstructured.Unstructured, error) {
+	return o.clusterConfiguration()
+}
+
+// UpdateClusterConfiguration validates and persists the given
+// ClusterConfiguration. With dryRun the change is validated only and never
+// written, so a broken config is caught before the installer consumes it.
+func (o *operator) UpdateClusterConfiguration(cc *unstructured.Unstructured, dryRun bool) error {
+	if errs := ValidateClusterConfiguration(cc); len(errs) > 0 {
+		return aggregateValidationErrors(errs)
+	}
+	if dryRun {
+		return nil
+	}
+
+	current, err := o.clusterConfiguration()
+	if err != nil {
+		return fmt.Errorf("failed to get clusterconfiguration %s: %v", clusterConfigurationName, err)
+	}
+	current.Object["spec"] = cc.Object["spec"]
+
+	_, err = o.dynamic.Resource(clusterConfigurationResource).Namespace(constants.KubeSphereNamespace).
+		Update(context.Background(), current, metav1.UpdateOptions{})
+	return err
+}
+
+func aggregateValidationErrors(errs []error) error {
+	message := "invalid ClusterConfiguration:"
+	for _, err := range errs {
+		message = fmt.Sprintf("%s %s;", message, err.Error())
+	}
+	return fmt.Errorf("%s", message)
+}
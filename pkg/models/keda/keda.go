@@ -0,0 +1,331 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package keda manages KEDA ScaledObjects through a simplified surface, so
+// event-driven services can be scaled from the workload pages without
+// hand-written KEDA YAML. The KEDA CRDs are not compiled in, ScaledObjects
+// are managed as unstructured objects so the package works against whatever
+// KEDA version is installed.
+package keda
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+var scaledObjectGVR = schema.GroupVersionResource{
+	Group:    "keda.sh",
+	Version:  "v1alpha1",
+	Resource: "scaledobjects",
+}
+
+// TriggerType describes one supported scaler for the trigger picker,
+// RequiredMetadata is validated on create and update.
+type TriggerType struct {
+	Type             string   `json:"type"`
+	Description      string   `json:"description"`
+	RequiredMetadata []string `json:"requiredMetadata"`
+}
+
+// triggerTypes is the catalog of scalers the console offers. Other KEDA
+// scaler types are accepted but not validated beyond being non-empty.
+var triggerTypes = []TriggerType{
+	{Type: "kafka", Description: "scale on Kafka consumer group lag", RequiredMetadata: []string{"bootstrapServers", "consumerGroup", "topic", "lagThreshold"}},
+	{Type: "rabbitmq", Description: "scale on RabbitMQ queue depth", RequiredMetadata: []string{"host", "queueName"}},
+	{Type: "prometheus", Description: "scale on the result of a Prometheus query", RequiredMetadata: []string{"serverAddress", "query", "threshold"}},
+	{Type: "cron", Description: "scale on a schedule", RequiredMetadata: []string{"start", "end", "desiredReplicas"}},
+	{Type: "cpu", Description: "scale on CPU utilization", RequiredMetadata: []string{"value"}},
+	{Type: "memory", Description: "scale on memory utilization", RequiredMetadata: []string{"value"}},
+}
+
+// Trigger is one scaler of a ScaledObject.
+type Trigger struct {
+	Type     string            `json:"type"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// ScalerHealth is the per scaler health KEDA reports in the status.
+type ScalerHealth struct {
+	Scaler   string `json:"scaler"`
+	Failures int64  `json:"failures"`
+	Status   string `json:"status"`
+}
+
+// ScaledObject is the simplified view of a KEDA ScaledObject.
+type ScaledObject struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// scale target, Deployment or StatefulSet
+	TargetKind string `json:"targetKind"`
+	TargetName string `json:"targetName"`
+
+	MinReplicas     *int64 `json:"minReplicas,omitempty"`
+	MaxReplicas     *int64 `json:"maxReplicas,omitempty"`
+	PollingInterval *int64 `json:"pollingInterval,omitempty"`
+	CooldownPeriod  *int64 `json:"cooldownPeriod,omitempty"`
+
+	Triggers []Trigger `json:"triggers"`
+
+	// status
+	Ready  bool           `json:"ready"`
+	Active bool           `json:"active"`
+	Health []ScalerHealth `json:"health,omitempty"`
+}
+
+type Operator interface {
+	List(namespace string) ([]ScaledObject, error)
+	Get(namespace, name string) (*ScaledObject, error)
+	Create(namespace string, scaledObject *ScaledObject) (*ScaledObject, error)
+	Update(namespace string, scaledObject *ScaledObject) (*ScaledObject, error)
+	Delete(namespace, name string) error
+
+	// ListTriggerTypes returns the trigger catalog for the picker
+	ListTriggerTypes() []TriggerType
+}
+
+type operator struct {
+	client dynamic.Interface
+}
+
+func NewOperator(config *rest.Config) (Operator, error) {
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &operator{client: client}, nil
+}
+
+func (o *operator) List(namespace string) ([]ScaledObject, error) {
+	list, err := o.client.Resource(scaledObjectGVR).Namespace(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return []ScaledObject{}, nil
+		}
+		return nil, err
+	}
+	result := make([]ScaledObject, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, render(&list.Items[i]))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func (o *operator) Get(namespace, name string) (*ScaledObject, error) {
+	object, err := o.client.Resource(scaledObjectGVR).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	scaledObject := render(object)
+	return &scaledObject, nil
+}
+
+func (o *operator) Create(namespace string, scaledObject *ScaledObject) (*ScaledObject, error) {
+	if err := validate(scaledObject); err != nil {
+		return nil, err
+	}
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": scaledObjectGVR.Group + "/" + scaledObjectGVR.Version,
+		"kind":       "ScaledObject",
+		"metadata": map[string]interface{}{
+			"name":      scaledObject.Name,
+			"namespace": namespace,
+		},
+		"spec": renderSpec(scaledObject),
+	}}
+	created, err := o.client.Resource(scaledObjectGVR).Namespace(namespace).Create(context.Background(), object, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	result := render(created)
+	return &result, nil
+}
+
+func (o *operator) Update(namespace string, scaledObject *ScaledObject) (*ScaledObject, error) {
+	if err := validate(scaledObject); err != nil {
+		return nil, err
+	}
+	current, err := o.client.Resource(scaledObjectGVR).Namespace(namespace).Get(context.Background(), scaledObject.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedMap(current.Object, renderSpec(scaledObject), "spec"); err != nil {
+		return nil, err
+	}
+	updated, err := o.client.Resource(scaledObjectGVR).Namespace(namespace).Update(context.Background(), current, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	result := render(updated)
+	return &result, nil
+}
+
+func (o *operator) Delete(namespace, name string) error {
+	return o.client.Resource(scaledObjectGVR).Namespace(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+}
+
+func (o *operator) ListTriggerTypes() []TriggerType {
+	return triggerTypes
+}
+
+func validate(scaledObject *ScaledObject) error {
+	if scaledObject.Name == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	if scaledObject.TargetKind != "Deployment" && scaledObject.TargetKind != "StatefulSet" {
+		return fmt.Errorf("unsupported scale target kind %s, only Deployment and StatefulSet are supported", scaledObject.TargetKind)
+	}
+	if scaledObject.TargetName == "" {
+		return fmt.Errorf("scale target name must not be empty")
+	}
+	if len(scaledObject.Triggers) == 0 {
+		return fmt.Errorf("at least one trigger is required")
+	}
+	if scaledObject.MinReplicas != nil && scaledObject.MaxReplicas != nil && *scaledObject.MinReplicas > *scaledObject.MaxReplicas {
+		return fmt.Errorf("minReplicas must not be greater than maxReplicas")
+	}
+	for _, trigger := range scaledObject.Triggers {
+		if trigger.Type == "" {
+			return fmt.Errorf("trigger type must not be empty")
+		}
+		for _, triggerType := range triggerTypes {
+			if triggerType.Type != trigger.Type {
+				continue
+			}
+			for _, key := range triggerType.RequiredMetadata {
+				if trigger.Metadata[key] == "" {
+					return fmt.Errorf("trigger %s requires metadata %s", trigger.Type, key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func renderSpec(scaledObject *ScaledObject) map[string]interface{} {
+	spec := map[string]interface{}{
+		"scaleTargetRef": map[string]interface{}{
+			"kind": scaledObject.TargetKind,
+			"name": scaledObject.TargetName,
+		},
+	}
+	if scaledObject.MinReplicas != nil {
+		spec["minReplicaCount"] = *scaledObject.MinReplicas
+	}
+	if scaledObject.MaxReplicas != nil {
+		spec["maxReplicaCount"] = *scaledObject.MaxReplicas
+	}
+	if scaledObject.PollingInterval != nil {
+		spec["pollingInterval"] = *scaledObject.PollingInterval
+	}
+	if scaledObject.CooldownPeriod != nil {
+		spec["cooldownPeriod"] = *scaledObject.CooldownPeriod
+	}
+	triggers := make([]interface{}, 0, len(scaledObject.Triggers))
+	for _, trigger := range scaledObject.Triggers {
+		metadata := map[string]interface{}{}
+		for key, value := range trigger.Metadata {
+			metadata[key] = value
+		}
+		triggers = append(triggers, map[string]interface{}{
+			"type":     trigger.Type,
+			"metadata": metadata,
+		})
+	}
+	spec["triggers"] = triggers
+	return spec
+}
+
+func render(object *unstructured.Unstructured) ScaledObject {
+	scaledObject := ScaledObject{
+		Name:      object.GetName(),
+		Namespace: object.GetNamespace(),
+	}
+	scaledObject.TargetKind, _, _ = unstructured.NestedString(object.Object, "spec", "scaleTargetRef", "kind")
+	if scaledObject.TargetKind == "" {
+		// KEDA defaults the target kind to Deployment
+		scaledObject.TargetKind = "Deployment"
+	}
+	scaledObject.TargetName, _, _ = unstructured.NestedString(object.Object, "spec", "scaleTargetRef", "name")
+
+	if value, ok, _ := unstructured.NestedInt64(object.Object, "spec", "minReplicaCount"); ok {
+		scaledObject.MinReplicas = &value
+	}
+	if value, ok, _ := unstructured.NestedInt64(object.Object, "spec", "maxReplicaCount"); ok {
+		scaledObject.MaxReplicas = &value
+	}
+	if value, ok, _ := unstructured.NestedInt64(object.Object, "spec", "pollingInterval"); ok {
+		scaledObject.PollingInterval = &value
+	}
+	if value, ok, _ := unstructured.NestedInt64(object.Object, "spec", "cooldownPeriod"); ok {
+		scaledObject.CooldownPeriod = &value
+	}
+
+	triggers, _, _ := unstructured.NestedSlice(object.Object, "spec", "triggers")
+	for _, item := range triggers {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		trigger := Trigger{Metadata: map[string]string{}}
+		trigger.Type, _, _ = unstructured.NestedString(entry, "type")
+		metadata, _, _ := unstructured.NestedStringMap(entry, "metadata")
+		for key, value := range metadata {
+			trigger.Metadata[key] = value
+		}
+		scaledObject.Triggers = append(scaledObject.Triggers, trigger)
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditionType, _, _ := unstructured.NestedString(condition, "type")
+		status, _, _ := unstructured.NestedString(condition, "status")
+		switch conditionType {
+		case "Ready":
+			scaledObject.Ready = status == "True"
+		case "Active":
+			scaledObject.Active = status == "True"
+		}
+	}
+
+	health, _, _ := unstructured.NestedMap(object.Object, "status", "health")
+	for scaler, item := range health {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		scalerHealth := ScalerHealth{Scaler: scaler}
+		scalerHealth.Failures, _, _ = unstructured.NestedInt64(entry, "numberOfFailures")
+		scalerHealth.Status, _, _ = unstructured.NestedString(entry, "status")
+		scaledObject.Health = append(scaledObject.Health, scalerHealth)
+	}
+	sort.Slice(scaledObject.Health, func(i, j int) bool { return scaledObject.Health[i].Scaler < scaledObject.Health[j].Scaler })
+
+	return scaledObject
+}
@@ -60,6 +60,11 @@ type Interface interface {
 	GetKubeConfig(username string) (string, error)
 	CreateKubeConfig(user *iamv1alpha2.User) error
 	UpdateKubeconfig(username string, csr *certificatesv1beta1.CertificateSigningRequest) error
+
+	// RotateKubeConfig requests a new client certificate for the user, the
+	// csr-controller approves the request and swaps the fresh key and
+	// certificate into the kubeconfig once it has been signed
+	RotateKubeConfig(username string) error
 }
 
 type operator struct {
@@ -69,12 +74,8 @@ type operator struct {
 	masterURL         string
 }
 
-func NewOperator(k8sClient kubernetes.Interface, configMapInformer corev1informers.ConfigMapInformer, config *rest.Config) Interface {
-	return &operator{k8sClient: k8sClient, configMapInformer: configMapInformer, config: config}
-}
-
-func NewReadOnlyOperator(configMapInformer corev1informers.ConfigMapInformer, masterURL string) Interface {
-	return &operator{configMapInformer: configMapInformer, masterURL: masterURL}
+func NewOperator(k8sClient kubernetes.Interface, configMapInformer corev1informers.ConfigMapInformer, config *rest.Config, masterURL string) Interface {
+	return &operator{k8sClient: k8sClient, configMapInformer: configMapInformer, config: config, masterURL: masterURL}
 }
 
 func (o *operator) CreateKubeConfig(user *iamv1alpha2.User) error {
@@ -187,6 +188,17 @@ func (o *operator) GetKubeConfig(username string) (string, error) {
 	return string(data), nil
 }
 
+// RotateKubeConfig replaces the user's client certificate with a freshly
+// signed one, the previous certificate stays valid until it expires
+func (o *operator) RotateKubeConfig(username string) error {
+	configName := fmt.Sprintf(kubeconfigNameFormat, username)
+	if _, err := o.configMapInformer.Lister().ConfigMaps(constants.KubeSphereControlNamespace).Get(configName); err != nil {
+		klog.Errorln(err)
+		return err
+	}
+	return o.createCSR(username)
+}
+
 func (o *operator) createCSR(username string) error {
 	csrConfig := &certutil.Config{
 		CommonName:   username,
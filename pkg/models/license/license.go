@@ -0,0 +1,241 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package license manages the enterprise license of the cluster. The
+// license is uploaded through the kapis endpoint and kept in a secret, the
+// status endpoint exposes the entitlements together with soft warnings as
+// the node count or the expiry date approach the licensed limits. Limits
+// are never enforced hard, an exceeded license only produces warnings.
+package license
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// LicenseSecretName is the secret in the KubeSphere namespace holding
+	// the uploaded license
+	LicenseSecretName = "ks-license"
+
+	licenseSecretKey = "license.json"
+
+	// a license nearing its limits produces soft warnings: when the node
+	// count reaches the ratio of the licensed maximum or the expiry date
+	// is closer than the warning window
+	nodeWarningRatio  = 0.8
+	expiryWarningDays = 30
+)
+
+// License is the entitlement document uploaded by the customer
+type License struct {
+	// unique id of the license
+	LicenseID string `json:"licenseId"`
+
+	// who the license was issued to
+	Licensee string `json:"licensee"`
+
+	IssuedAt  time.Time `json:"issuedAt,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// maximum number of nodes the license covers, zero means unlimited
+	MaxNodes int `json:"maxNodes,omitempty"`
+
+	// entitled features, e.g. multicluster, devops
+	Features []string `json:"features,omitempty"`
+
+	// signature of the issuer over the license fields
+	Signature string `json:"signature,omitempty"`
+}
+
+// Status is the current license together with usage and soft warnings for
+// the console
+type Status struct {
+	License *License `json:"license,omitempty"`
+
+	// number of nodes currently in the cluster
+	NodeCount int `json:"nodeCount"`
+
+	Expired bool `json:"expired"`
+
+	// soft warnings shown in the console as limits approach, empty when
+	// the license is comfortably within its entitlements
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type Operator interface {
+	// UploadLicense validates and stores a license document
+	UploadLicense(raw []byte) (*License, error)
+
+	// GetStatus returns the license with usage and soft warnings, a
+	// cluster without a license gets a status with a warning
+	GetStatus() (*Status, error)
+
+	DeleteLicense() error
+
+	// HasFeature reports whether the license entitles a feature, clusters
+	// without a license are entitled to everything
+	HasFeature(feature string) (bool, error)
+}
+
+type operator struct {
+	client kubernetes.Interface
+}
+
+func NewOperator(client kubernetes.Interface) Operator {
+	return &operator{client: client}
+}
+
+// parseLicense validates the structure of an uploaded license
+func parseLicense(raw []byte) (*License, error) {
+	license := &License{}
+	if err := json.Unmarshal(raw, license); err != nil {
+		return nil, fmt.Errorf("license is not valid JSON: %v", err)
+	}
+	if license.Licensee == "" {
+		return nil, fmt.Errorf("license has no licensee")
+	}
+	if license.ExpiresAt.IsZero() {
+		return nil, fmt.Errorf("license has no expiry date")
+	}
+	if license.MaxNodes < 0 {
+		return nil, fmt.Errorf("maxNodes must not be negative")
+	}
+	return license, nil
+}
+
+func (o *operator) UploadLicense(raw []byte) (*License, error) {
+	license, err := parseLicense(raw)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(license.ExpiresAt) {
+		return nil, fmt.Errorf("license expired at %s", license.ExpiresAt.Format(time.RFC3339))
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LicenseSecretName,
+			Namespace: constants.KubeSphereNamespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			licenseSecretKey: raw,
+		},
+	}
+
+	ctx := context.Background()
+	secrets := o.client.CoreV1().Secrets(constants.KubeSphereNamespace)
+	current, err := secrets.Get(ctx, LicenseSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		return license, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	current.Data = secret.Data
+	_, err = secrets.Update(ctx, current, metav1.UpdateOptions{})
+	return license, err
+}
+
+// currentLicense returns the stored license, nil when none is uploaded
+func (o *operator) currentLicense() (*License, error) {
+	secret, err := o.client.CoreV1().Secrets(constants.KubeSphereNamespace).Get(context.Background(), LicenseSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseLicense(secret.Data[licenseSecretKey])
+}
+
+func (o *operator) GetStatus() (*Status, error) {
+	nodes, err := o.client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{NodeCount: len(nodes.Items)}
+
+	license, err := o.currentLicense()
+	if err != nil {
+		return nil, err
+	}
+	if license == nil {
+		status.Warnings = append(status.Warnings, "no license is uploaded, the cluster runs unlicensed")
+		return status, nil
+	}
+	status.License = license
+
+	now := time.Now()
+	if now.After(license.ExpiresAt) {
+		status.Expired = true
+		status.Warnings = append(status.Warnings,
+			fmt.Sprintf("the license expired at %s", license.ExpiresAt.Format(time.RFC3339)))
+	} else if remaining := license.ExpiresAt.Sub(now); remaining < expiryWarningDays*24*time.Hour {
+		status.Warnings = append(status.Warnings,
+			fmt.Sprintf("the license expires in %d days", int(remaining.Hours()/24)))
+	}
+
+	if license.MaxNodes > 0 {
+		if status.NodeCount > license.MaxNodes {
+			status.Warnings = append(status.Warnings,
+				fmt.Sprintf("the cluster has %d nodes, the license covers %d", status.NodeCount, license.MaxNodes))
+		} else if float64(status.NodeCount) >= nodeWarningRatio*float64(license.MaxNodes) {
+			status.Warnings = append(status.Warnings,
+				fmt.Sprintf("the cluster uses %d of %d licensed nodes", status.NodeCount, license.MaxNodes))
+		}
+	}
+
+	return status, nil
+}
+
+func (o *operator) DeleteLicense() error {
+	err := o.client.CoreV1().Secrets(constants.KubeSphereNamespace).Delete(context.Background(), LicenseSecretName, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (o *operator) HasFeature(feature string) (bool, error) {
+	license, err := o.currentLicense()
+	if err != nil {
+		return false, err
+	}
+	// unlicensed clusters and licenses without a feature list are not
+	// restricted
+	if license == nil || len(license.Features) == 0 {
+		return true, nil
+	}
+	for _, entitled := range license.Features {
+		if entitled == feature {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+)
+
+const (
+	// IndexPatternsExtraKey and NamespacesExtraKey carry the user's
+	// namespace-scoped index permissions inside the deep-link token; the
+	// SSO bridge in front of Kibana or OpenSearch Dashboards verifies the
+	// token with the shared jwt secret and applies them to the session
+	IndexPatternsExtraKey = "logging.kubesphere.io/index-patterns"
+	NamespacesExtraKey    = "logging.kubesphere.io/namespaces"
+
+	// dashboardsSSOPath is the token-exchange endpoint of the SSO bridge
+	dashboardsSSOPath = "/auth/kubesphere"
+
+	// deepLinkExpiration bounds how long a deep link stays usable, the
+	// bridge exchanges it for its own session right away
+	deepLinkExpiration = 10 * time.Minute
+)
+
+// DashboardsDeepLink is a single-use jump from the console log view into
+// Kibana or OpenSearch Dashboards under the user's own identity.
+type DashboardsDeepLink struct {
+	// URL is the link to open, it carries the token to the SSO bridge
+	URL string `json:"url"`
+
+	// ExpiresIn is how many seconds the link stays usable
+	ExpiresIn int `json:"expiresIn"`
+
+	// IndexPatterns are the index permissions the session is scoped to
+	IndexPatterns []string `json:"indexPatterns"`
+}
+
+type DashboardsOperator interface {
+	// CreateDeepLink issues a deep link scoped to the namespace, or to
+	// every namespace the user may view logs in when namespace is empty
+	CreateDeepLink(user user.Info, namespace string) (*DashboardsDeepLink, error)
+}
+
+type dashboardsOperator struct {
+	client     kubernetes.Interface
+	issuer     token.Issuer
+	authorizer authorizer.Authorizer
+	strategies IndexStrategyOperator
+	options    *logging.Options
+}
+
+func NewDashboardsOperator(client kubernetes.Interface, issuer token.Issuer, authorizer authorizer.Authorizer,
+	options *logging.Options) DashboardsOperator {
+	return &dashboardsOperator{
+		client:     client,
+		issuer:     issuer,
+		authorizer: authorizer,
+		strategies: NewIndexStrategyOperator(client),
+		options:    options,
+	}
+}
+
+func (o *dashboardsOperator) CreateDeepLink(user user.Info, namespace string) (*DashboardsDeepLink, error) {
+	if o.options == nil || o.options.DashboardsHost == "" {
+		return nil, fmt.Errorf("no log dashboards host configured")
+	}
+
+	namespaces, err := o.allowedNamespaces(user, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(namespaces) == 0 {
+		return nil, errors.NewForbidden(schema.GroupResource{Group: "logging.kubesphere.io", Resource: "dashboards"},
+			namespace, fmt.Errorf("user %s may not view logs of any requested namespace", user.GetName()))
+	}
+
+	patterns, err := o.indexPatterns(namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.issueLink(user, namespaces, patterns)
+}
+
+// allowedNamespaces resolves which of the requested namespaces the user may
+// view logs in, using the same pods/log attributes as the log query path
+func (o *dashboardsOperator) allowedNamespaces(user user.Info, namespace string) ([]string, error) {
+	if namespace != "" {
+		allowed, err := o.allowed(user, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, nil
+		}
+		return []string{namespace}, nil
+	}
+
+	list, err := o.client.CoreV1().Namespaces().List(context.Background(), v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var namespaces []string
+	for _, ns := range list.Items {
+		allowed, err := o.allowed(user, ns.Name)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	return namespaces, nil
+}
+
+func (o *dashboardsOperator) allowed(user user.Info, namespace string) (bool, error) {
+	podLogs := authorizer.AttributesRecord{
+		User:            user,
+		Verb:            "get",
+		APIGroup:        "",
+		APIVersion:      "v1",
+		Namespace:       namespace,
+		Resource:        "pods",
+		Subresource:     "log",
+		ResourceRequest: true,
+		ResourceScope:   request.NamespaceScope,
+	}
+	decision, _, err := o.authorizer.Authorize(podLogs)
+	if err != nil {
+		return false, err
+	}
+	return decision == authorizer.DecisionAllow, nil
+}
+
+// indexPatterns maps the namespaces to the indices holding their logs: the
+// shared logstash-style indices by default, a workspace's dedicated indices
+// or data stream where an index strategy routes them
+func (o *dashboardsOperator) indexPatterns(namespaces []string) ([]string, error) {
+	strategies, err := o.strategies.ListIndexStrategies()
+	if err != nil {
+		return nil, err
+	}
+	byWorkspace := map[string]WorkspaceIndexStrategy{}
+	for _, strategy := range strategies {
+		byWorkspace[strategy.Workspace] = strategy
+	}
+
+	patterns := map[string]bool{}
+	for _, namespace := range namespaces {
+		ns, err := o.client.CoreV1().Namespaces().Get(context.Background(), namespace, v1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if strategy, ok := byWorkspace[ns.Labels[constants.WorkspaceLabelKey]]; ok {
+			if strategy.Datastream {
+				patterns[strategy.IndexPrefix] = true
+			} else {
+				patterns[strategy.IndexPrefix+"-*"] = true
+			}
+			continue
+		}
+		patterns[o.options.IndexPrefix+"-*"] = true
+	}
+
+	sorted := make([]string, 0, len(patterns))
+	for pattern := range patterns {
+		sorted = append(sorted, pattern)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+func (o *dashboardsOperator) issueLink(requestor user.Info, namespaces, patterns []string) (*DashboardsDeepLink, error) {
+	scoped := &user.DefaultInfo{
+		Name:   requestor.GetName(),
+		Groups: requestor.GetGroups(),
+		Extra: map[string][]string{
+			IndexPatternsExtraKey: patterns,
+			NamespacesExtraKey:    namespaces,
+		},
+	}
+	accessToken, err := o.issuer.IssueTo(scoped, token.AccessToken, deepLinkExpiration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DashboardsDeepLink{
+		URL:           fmt.Sprintf("%s%s?token=%s", o.options.DashboardsHost, dashboardsSSOPath, url.QueryEscape(accessToken)),
+		ExpiresIn:     int(deepLinkExpiration.Seconds()),
+		IndexPatterns: patterns,
+	}, nil
+}
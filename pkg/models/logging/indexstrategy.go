@@ -0,0 +1,240 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// IndexStrategyConfigMapName names the ConfigMap holding the
+	// per-workspace log index strategies
+	IndexStrategyConfigMapName = "logging-index-strategies"
+	indexStrategyDataKey       = "strategies"
+
+	// AgentOutputConfigMapName holds the fluent-bit output sections
+	// rendered from the strategies; the log agent includes output.conf to
+	// route workspace logs into their dedicated indices
+	AgentOutputConfigMapName = "fluent-bit-workspace-output"
+	agentOutputDataKey       = "output.conf"
+)
+
+// indexPrefixPattern is what Elasticsearch accepts as an index or data
+// stream name prefix
+var indexPrefixPattern = regexp.MustCompile(`^[a-z][a-z0-9\-]*$`)
+
+// WorkspaceIndexStrategy routes a workspace's logs into dedicated indices
+// with their own quotas, so its usage is isolated and chargeable.
+type WorkspaceIndexStrategy struct {
+	Workspace string `json:"workspace"`
+
+	// IndexPrefix is the dedicated index prefix (or data stream name) the
+	// workspace's logs are written to
+	IndexPrefix string `json:"indexPrefix"`
+
+	// Datastream writes into a data stream named IndexPrefix instead of
+	// daily logstash-style indices
+	Datastream bool `json:"datastream,omitempty"`
+
+	// MaxDocuments rolls the write index over once it holds this many
+	// documents; 0 leaves the document count uncapped
+	MaxDocuments int64 `json:"maxDocuments,omitempty"`
+
+	// MaxSizeGB rolls the write index over once it reaches this size; 0
+	// leaves the size uncapped
+	MaxSizeGB int `json:"maxSizeGB,omitempty"`
+}
+
+type IndexStrategyOperator interface {
+	ListIndexStrategies() ([]WorkspaceIndexStrategy, error)
+	// SaveIndexStrategies replaces the strategies; the controller rolls
+	// them into the agent configuration and drives the rollovers
+	SaveIndexStrategies(strategies []WorkspaceIndexStrategy) ([]WorkspaceIndexStrategy, error)
+}
+
+type indexStrategyOperator struct {
+	client kubernetes.Interface
+}
+
+func NewIndexStrategyOperator(client kubernetes.Interface) IndexStrategyOperator {
+	return &indexStrategyOperator{client: client}
+}
+
+func validateIndexStrategies(strategies []WorkspaceIndexStrategy) error {
+	workspaces := map[string]bool{}
+	prefixes := map[string]bool{}
+	for i, strategy := range strategies {
+		if strategy.Workspace == "" {
+			return fmt.Errorf("index strategy %d has no workspace", i)
+		}
+		if workspaces[strategy.Workspace] {
+			return fmt.Errorf("duplicate index strategy for workspace %s", strategy.Workspace)
+		}
+		workspaces[strategy.Workspace] = true
+		if strategy.IndexPrefix == "" {
+			return fmt.Errorf("index strategy for workspace %s has no index prefix", strategy.Workspace)
+		}
+		if !indexPrefixPattern.MatchString(strategy.IndexPrefix) {
+			return fmt.Errorf("index strategy for workspace %s: index prefix %s must match %s",
+				strategy.Workspace, strategy.IndexPrefix, indexPrefixPattern.String())
+		}
+		if prefixes[strategy.IndexPrefix] {
+			return fmt.Errorf("index prefix %s is shared by several workspaces", strategy.IndexPrefix)
+		}
+		prefixes[strategy.IndexPrefix] = true
+		if strategy.MaxDocuments < 0 {
+			return fmt.Errorf("index strategy for workspace %s: maxDocuments must not be negative", strategy.Workspace)
+		}
+		if strategy.MaxSizeGB < 0 {
+			return fmt.Errorf("index strategy for workspace %s: maxSizeGB must not be negative", strategy.Workspace)
+		}
+	}
+	return nil
+}
+
+func (o *indexStrategyOperator) ListIndexStrategies() ([]WorkspaceIndexStrategy, error) {
+	cm, err := o.client.CoreV1().ConfigMaps(constants.KubeSphereLoggingNamespace).
+		Get(context.Background(), IndexStrategyConfigMapName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []WorkspaceIndexStrategy{}, nil
+		}
+		return nil, err
+	}
+	return UnmarshalIndexStrategies(cm)
+}
+
+// UnmarshalIndexStrategies reads the strategies out of their ConfigMap, for
+// the API and the controller reconciling them.
+func UnmarshalIndexStrategies(cm *corev1.ConfigMap) ([]WorkspaceIndexStrategy, error) {
+	data, ok := cm.Data[indexStrategyDataKey]
+	if !ok || data == "" {
+		return []WorkspaceIndexStrategy{}, nil
+	}
+	var strategies []WorkspaceIndexStrategy
+	if err := json.Unmarshal([]byte(data), &strategies); err != nil {
+		return nil, fmt.Errorf("configmap %s holds invalid index strategies: %v", cm.Name, err)
+	}
+	return strategies, nil
+}
+
+func (o *indexStrategyOperator) SaveIndexStrategies(strategies []WorkspaceIndexStrategy) ([]WorkspaceIndexStrategy, error) {
+	if err := validateIndexStrategies(strategies); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(strategies)
+	if err != nil {
+		return nil, err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      IndexStrategyConfigMapName,
+			Namespace: constants.KubeSphereLoggingNamespace,
+		},
+		Data: map[string]string{indexStrategyDataKey: string(data)},
+	}
+
+	configMaps := o.client.CoreV1().ConfigMaps(constants.KubeSphereLoggingNamespace)
+	existing, err := configMaps.Get(context.Background(), IndexStrategyConfigMapName, v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if _, err := configMaps.Create(context.Background(), cm, v1.CreateOptions{}); err != nil {
+			return nil, err
+		}
+		return strategies, nil
+	}
+
+	existing.Data = cm.Data
+	if _, err := configMaps.Update(context.Background(), existing, v1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return strategies, nil
+}
+
+// RenderAgentOutputConfig renders the strategies into the data of the agent
+// ConfigMap: one fluent-bit output section per namespace of each workspace,
+// writing into the workspace's dedicated indices. namespaces maps a
+// workspace to its namespaces.
+func RenderAgentOutputConfig(strategies []WorkspaceIndexStrategy, namespaces map[string][]string) map[string]string {
+	sorted := make([]WorkspaceIndexStrategy, len(strategies))
+	copy(sorted, strategies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Workspace < sorted[j].Workspace })
+
+	var conf strings.Builder
+	conf.WriteString("# generated from the logging index strategies, do not edit\n")
+	for _, strategy := range sorted {
+		members := make([]string, len(namespaces[strategy.Workspace]))
+		copy(members, namespaces[strategy.Workspace])
+		sort.Strings(members)
+		for _, namespace := range members {
+			if strategy.Datastream {
+				fmt.Fprintf(&conf, `
+[OUTPUT]
+    Name                es
+    Match               %s
+    Index               %s
+    Suppress_Type_Name  On
+`, namespaceMatch(namespace), strategy.IndexPrefix)
+			} else {
+				fmt.Fprintf(&conf, `
+[OUTPUT]
+    Name             es
+    Match            %s
+    Logstash_Format  On
+    Logstash_Prefix  %s
+`, namespaceMatch(namespace), strategy.IndexPrefix)
+			}
+		}
+	}
+	return map[string]string{agentOutputDataKey: conf.String()}
+}
+
+// RolloverConditions builds the conditions body of an Elasticsearch
+// _rollover request for the strategy's quotas; ok is false when the
+// strategy sets no quota and no rollover is due.
+func RolloverConditions(strategy WorkspaceIndexStrategy) (body string, ok bool) {
+	conditions := map[string]interface{}{}
+	if strategy.MaxDocuments > 0 {
+		conditions["max_docs"] = strategy.MaxDocuments
+	}
+	if strategy.MaxSizeGB > 0 {
+		conditions["max_size"] = fmt.Sprintf("%dgb", strategy.MaxSizeGB)
+	}
+	if len(conditions) == 0 {
+		return "", false
+	}
+	data, err := json.Marshal(map[string]interface{}{"conditions": conditions})
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
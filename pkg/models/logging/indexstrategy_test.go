@@ -0,0 +1,120 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderAgentOutputConfig(t *testing.T) {
+	strategies := []WorkspaceIndexStrategy{
+		{Workspace: "acme", IndexPrefix: "logs-acme", MaxSizeGB: 30},
+		{Workspace: "wile", IndexPrefix: "logs-wile", Datastream: true},
+	}
+	namespaces := map[string][]string{
+		"acme": {"acme-prod", "acme-dev"},
+		"wile": {"wile-prod"},
+	}
+
+	conf := RenderAgentOutputConfig(strategies, namespaces)[agentOutputDataKey]
+
+	if !strings.Contains(conf, "Match            kube.var.log.containers.*_acme-prod_*") ||
+		!strings.Contains(conf, "Match            kube.var.log.containers.*_acme-dev_*") {
+		t.Errorf("config misses a namespace of the workspace:\n%s", conf)
+	}
+	if !strings.Contains(conf, "Logstash_Prefix  logs-acme") {
+		t.Errorf("config misses the dedicated index prefix:\n%s", conf)
+	}
+	if !strings.Contains(conf, "Index               logs-wile") {
+		t.Errorf("config misses the data stream output:\n%s", conf)
+	}
+	if strings.Contains(conf, "Logstash_Prefix  logs-wile") {
+		t.Errorf("data stream workspace got a logstash output:\n%s", conf)
+	}
+}
+
+func TestRolloverConditions(t *testing.T) {
+	body, ok := RolloverConditions(WorkspaceIndexStrategy{Workspace: "acme", IndexPrefix: "logs-acme", MaxDocuments: 1000000, MaxSizeGB: 30})
+	if !ok {
+		t.Fatal("expected rollover conditions")
+	}
+	if !strings.Contains(body, `"max_docs":1000000`) || !strings.Contains(body, `"max_size":"30gb"`) {
+		t.Errorf("unexpected conditions body: %s", body)
+	}
+
+	if _, ok := RolloverConditions(WorkspaceIndexStrategy{Workspace: "acme", IndexPrefix: "logs-acme"}); ok {
+		t.Error("unexpected rollover conditions without quotas")
+	}
+}
+
+func TestValidateIndexStrategies(t *testing.T) {
+	var tests = []struct {
+		name       string
+		strategies []WorkspaceIndexStrategy
+		expectErr  bool
+	}{
+		{
+			name: "valid",
+			strategies: []WorkspaceIndexStrategy{
+				{Workspace: "acme", IndexPrefix: "logs-acme", MaxDocuments: 1000},
+				{Workspace: "wile", IndexPrefix: "logs-wile", Datastream: true, MaxSizeGB: 30},
+			},
+		},
+		{
+			name:       "missing workspace",
+			strategies: []WorkspaceIndexStrategy{{IndexPrefix: "logs"}},
+			expectErr:  true,
+		},
+		{
+			name:       "duplicate workspace",
+			strategies: []WorkspaceIndexStrategy{{Workspace: "a", IndexPrefix: "x"}, {Workspace: "a", IndexPrefix: "y"}},
+			expectErr:  true,
+		},
+		{
+			name:       "missing index prefix",
+			strategies: []WorkspaceIndexStrategy{{Workspace: "a"}},
+			expectErr:  true,
+		},
+		{
+			name:       "invalid index prefix",
+			strategies: []WorkspaceIndexStrategy{{Workspace: "a", IndexPrefix: "Logs_A"}},
+			expectErr:  true,
+		},
+		{
+			name:       "shared index prefix",
+			strategies: []WorkspaceIndexStrategy{{Workspace: "a", IndexPrefix: "logs"}, {Workspace: "b", IndexPrefix: "logs"}},
+			expectErr:  true,
+		},
+		{
+			name:       "negative quota",
+			strategies: []WorkspaceIndexStrategy{{Workspace: "a", IndexPrefix: "logs", MaxDocuments: -1}},
+			expectErr:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateIndexStrategies(test.strategies)
+			if test.expectErr && err == nil {
+				t.Error("expected a validation error")
+			}
+			if !test.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
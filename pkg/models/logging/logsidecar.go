@@ -0,0 +1,167 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// LogSidecarConfigAnnotation carries a namespace's logsidecar-injector
+// configuration; the injector webhook reads it when mutating pods
+const LogSidecarConfigAnnotation = "logging.kubesphere.io/logsidecar-config"
+
+// MultilineParser folds continuation lines into the preceding log entry, so
+// a Java stack trace arrives as one entry instead of hundreds.
+type MultilineParser struct {
+	Name string `json:"name"`
+
+	// FirstLine is the regular expression recognizing the first line of
+	// an entry, e.g. ^\d{4}-\d{2}-\d{2} for timestamped logs; lines not
+	// matching it are appended to the previous entry
+	FirstLine string `json:"firstLine"`
+}
+
+// PathGroup is one group of log files collected from a container, parsed
+// with an optional multiline parser.
+type PathGroup struct {
+	// Paths are the file paths or globs to tail, e.g. /var/log/app/*.log
+	Paths []string `json:"paths"`
+
+	// Parser names the multiline parser applied to these files; empty
+	// collects them line by line
+	Parser string `json:"parser,omitempty"`
+}
+
+// LogSidecarConfig is a namespace's logsidecar-injector configuration.
+type LogSidecarConfig struct {
+	// ContainerFiles maps a container name to the file path groups its
+	// sidecar collects
+	ContainerFiles map[string][]PathGroup `json:"containerFiles,omitempty"`
+
+	// Parsers are the multiline parsers the path groups may reference
+	Parsers []MultilineParser `json:"parsers,omitempty"`
+}
+
+type LogSidecarOperator interface {
+	GetLogSidecarConfig(namespace string) (*LogSidecarConfig, error)
+	// SaveLogSidecarConfig replaces the namespace's configuration, an
+	// empty configuration removes it
+	SaveLogSidecarConfig(namespace string, config *LogSidecarConfig) (*LogSidecarConfig, error)
+}
+
+type logSidecarOperator struct {
+	client kubernetes.Interface
+}
+
+func NewLogSidecarOperator(client kubernetes.Interface) LogSidecarOperator {
+	return &logSidecarOperator{client: client}
+}
+
+func validateLogSidecarConfig(config *LogSidecarConfig) error {
+	parsers := map[string]bool{}
+	for i, parser := range config.Parsers {
+		if parser.Name == "" {
+			return fmt.Errorf("parser %d has no name", i)
+		}
+		if parsers[parser.Name] {
+			return fmt.Errorf("duplicate parser name %s", parser.Name)
+		}
+		parsers[parser.Name] = true
+		if parser.FirstLine == "" {
+			return fmt.Errorf("parser %s has no firstLine pattern", parser.Name)
+		}
+		if _, err := regexp.Compile(parser.FirstLine); err != nil {
+			return fmt.Errorf("parser %s: invalid firstLine pattern: %v", parser.Name, err)
+		}
+	}
+
+	for container, groups := range config.ContainerFiles {
+		if container == "" {
+			return fmt.Errorf("a path group is bound to an empty container name")
+		}
+		if len(groups) == 0 {
+			return fmt.Errorf("container %s has no path groups", container)
+		}
+		for i, group := range groups {
+			if len(group.Paths) == 0 {
+				return fmt.Errorf("container %s: path group %d has no paths", container, i)
+			}
+			for _, path := range group.Paths {
+				if !strings.HasPrefix(path, "/") {
+					return fmt.Errorf("container %s: path %s must be absolute", container, path)
+				}
+			}
+			if group.Parser != "" && !parsers[group.Parser] {
+				return fmt.Errorf("container %s: path group %d references unknown parser %s", container, i, group.Parser)
+			}
+		}
+	}
+	return nil
+}
+
+func (o *logSidecarOperator) GetLogSidecarConfig(namespace string) (*LogSidecarConfig, error) {
+	ns, err := o.client.CoreV1().Namespaces().Get(context.Background(), namespace, v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	config := &LogSidecarConfig{}
+	data, ok := ns.Annotations[LogSidecarConfigAnnotation]
+	if !ok || data == "" {
+		return config, nil
+	}
+	if err := json.Unmarshal([]byte(data), config); err != nil {
+		return nil, fmt.Errorf("namespace %s holds an invalid logsidecar config: %v", namespace, err)
+	}
+	return config, nil
+}
+
+func (o *logSidecarOperator) SaveLogSidecarConfig(namespace string, config *LogSidecarConfig) (*LogSidecarConfig, error) {
+	if err := validateLogSidecarConfig(config); err != nil {
+		return nil, err
+	}
+
+	ns, err := o.client.CoreV1().Namespaces().Get(context.Background(), namespace, v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.ContainerFiles) == 0 {
+		delete(ns.Annotations, LogSidecarConfigAnnotation)
+	} else {
+		data, err := json.Marshal(config)
+		if err != nil {
+			return nil, err
+		}
+		if ns.Annotations == nil {
+			ns.Annotations = map[string]string{}
+		}
+		ns.Annotations[LogSidecarConfigAnnotation] = string(data)
+	}
+
+	if _, err := o.client.CoreV1().Namespaces().Update(context.Background(), ns, v1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"testing"
+)
+
+func TestValidateLogSidecarConfig(t *testing.T) {
+	var tests = []struct {
+		name      string
+		config    LogSidecarConfig
+		expectErr bool
+	}{
+		{
+			name: "valid",
+			config: LogSidecarConfig{
+				ContainerFiles: map[string][]PathGroup{
+					"app": {
+						{Paths: []string{"/var/log/app/*.log"}, Parser: "java"},
+						{Paths: []string{"/var/log/access.log"}},
+					},
+				},
+				Parsers: []MultilineParser{
+					{Name: "java", FirstLine: `^\d{4}-\d{2}-\d{2}`},
+				},
+			},
+		},
+		{
+			name: "unknown parser reference",
+			config: LogSidecarConfig{
+				ContainerFiles: map[string][]PathGroup{
+					"app": {{Paths: []string{"/var/log/app.log"}, Parser: "java"}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid firstLine pattern",
+			config: LogSidecarConfig{
+				Parsers: []MultilineParser{{Name: "bad", FirstLine: `^(`}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "duplicate parser name",
+			config: LogSidecarConfig{
+				Parsers: []MultilineParser{
+					{Name: "java", FirstLine: `^\d`},
+					{Name: "java", FirstLine: `^\[`},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "relative path",
+			config: LogSidecarConfig{
+				ContainerFiles: map[string][]PathGroup{
+					"app": {{Paths: []string{"var/log/app.log"}}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "empty path group",
+			config: LogSidecarConfig{
+				ContainerFiles: map[string][]PathGroup{
+					"app": {{}},
+				},
+			},
+			expectErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateLogSidecarConfig(&test.config)
+			if test.expectErr && err == nil {
+				t.Error("expected a validation error")
+			}
+			if !test.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
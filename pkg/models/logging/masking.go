@@ -0,0 +1,385 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+)
+
+const (
+	// MaskingPolicyConfigMapName names the ConfigMap holding the
+	// per-namespace log masking policies
+	MaskingPolicyConfigMapName = "logging-masking-policies"
+	maskingPolicyDataKey       = "policies"
+
+	// AgentMaskingConfigMapName holds the agent-stage rules rendered from
+	// the policies; the log agent's masking plugin watches masking.json
+	AgentMaskingConfigMapName = "fluent-bit-masking"
+	agentMaskingDataKey       = "masking.json"
+
+	// MaskingStageAgent rewrites lines at the collection agent, before
+	// they reach storage; MaskingStageQuery rewrites them in query and
+	// export results while storage keeps the original
+	MaskingStageAgent = "agent"
+	MaskingStageQuery = "query"
+
+	// builtinPatternPrefix marks a rule pattern naming one of the shipped
+	// patterns instead of spelling out a regular expression
+	builtinPatternPrefix = "builtin:"
+
+	defaultReplacement = "****"
+
+	maskingAuditReason = "LogMaskingPoliciesChanged"
+)
+
+// builtinPatterns are the shipped patterns a rule may reference as
+// builtin:<name> instead of maintaining the regular expression itself
+var builtinPatterns = map[string]string{
+	"email":        `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	"credit-card":  `\b\d(?:[ \-]?\d){12,15}\b`,
+	"bearer-token": `(?i)bearer\s+[A-Za-z0-9\-._~+/]+=*`,
+}
+
+// MaskingRule redacts one kind of sensitive data from log lines.
+type MaskingRule struct {
+	Name string `json:"name"`
+
+	// Pattern is the regular expression to redact, or a shipped pattern
+	// referenced as builtin:<name>, e.g. builtin:email
+	Pattern string `json:"pattern"`
+
+	// Replacement substitutes the matches; empty masks them with ****
+	Replacement string `json:"replacement,omitempty"`
+
+	// Stage is where the rule applies: agent rewrites lines before they
+	// reach storage, query (the default) rewrites query and export results
+	Stage string `json:"stage,omitempty"`
+}
+
+// MaskingPolicy is one namespace's set of masking rules.
+type MaskingPolicy struct {
+	Namespace string        `json:"namespace"`
+	Rules     []MaskingRule `json:"rules"`
+}
+
+type MaskingPolicyOperator interface {
+	ListMaskingPolicies() ([]MaskingPolicy, error)
+	// SaveMaskingPolicies replaces the policies and records who changed
+	// them as an audit event
+	SaveMaskingPolicies(policies []MaskingPolicy, operator string) ([]MaskingPolicy, error)
+}
+
+type maskingPolicyOperator struct {
+	client kubernetes.Interface
+}
+
+func NewMaskingPolicyOperator(client kubernetes.Interface) MaskingPolicyOperator {
+	return &maskingPolicyOperator{client: client}
+}
+
+// resolvePattern expands a builtin:<name> reference into its regular
+// expression and compiles the result
+func resolvePattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, builtinPatternPrefix) {
+		name := strings.TrimPrefix(pattern, builtinPatternPrefix)
+		builtin, ok := builtinPatterns[name]
+		if !ok {
+			known := make([]string, 0, len(builtinPatterns))
+			for name := range builtinPatterns {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown builtin pattern %s, known patterns are %s", name, strings.Join(known, ", "))
+		}
+		pattern = builtin
+	}
+	return regexp.Compile(pattern)
+}
+
+func validateMaskingPolicies(policies []MaskingPolicy) error {
+	namespaces := map[string]bool{}
+	for i, policy := range policies {
+		if policy.Namespace == "" {
+			return fmt.Errorf("masking policy %d has no namespace", i)
+		}
+		if namespaces[policy.Namespace] {
+			return fmt.Errorf("duplicate masking policy for namespace %s", policy.Namespace)
+		}
+		namespaces[policy.Namespace] = true
+		names := map[string]bool{}
+		for j, rule := range policy.Rules {
+			if rule.Name == "" {
+				return fmt.Errorf("masking policy for namespace %s: rule %d has no name", policy.Namespace, j)
+			}
+			if names[rule.Name] {
+				return fmt.Errorf("masking policy for namespace %s: duplicate rule name %s", policy.Namespace, rule.Name)
+			}
+			names[rule.Name] = true
+			if rule.Pattern == "" {
+				return fmt.Errorf("masking policy for namespace %s: rule %s has no pattern", policy.Namespace, rule.Name)
+			}
+			if _, err := resolvePattern(rule.Pattern); err != nil {
+				return fmt.Errorf("masking policy for namespace %s: rule %s: %v", policy.Namespace, rule.Name, err)
+			}
+			if rule.Stage != "" && rule.Stage != MaskingStageAgent && rule.Stage != MaskingStageQuery {
+				return fmt.Errorf("masking policy for namespace %s: rule %s: unknown stage %s, use %s or %s",
+					policy.Namespace, rule.Name, rule.Stage, MaskingStageAgent, MaskingStageQuery)
+			}
+		}
+	}
+	return nil
+}
+
+func (o *maskingPolicyOperator) ListMaskingPolicies() ([]MaskingPolicy, error) {
+	cm, err := o.client.CoreV1().ConfigMaps(constants.KubeSphereLoggingNamespace).
+		Get(context.Background(), MaskingPolicyConfigMapName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []MaskingPolicy{}, nil
+		}
+		return nil, err
+	}
+	return UnmarshalMaskingPolicies(cm)
+}
+
+// UnmarshalMaskingPolicies reads the policies out of their ConfigMap, for the
+// API, the query path and the controller reconciling the agent config.
+func UnmarshalMaskingPolicies(cm *corev1.ConfigMap) ([]MaskingPolicy, error) {
+	data, ok := cm.Data[maskingPolicyDataKey]
+	if !ok || data == "" {
+		return []MaskingPolicy{}, nil
+	}
+	var policies []MaskingPolicy
+	if err := json.Unmarshal([]byte(data), &policies); err != nil {
+		return nil, fmt.Errorf("configmap %s holds invalid masking policies: %v", cm.Name, err)
+	}
+	return policies, nil
+}
+
+func (o *maskingPolicyOperator) SaveMaskingPolicies(policies []MaskingPolicy, operator string) ([]MaskingPolicy, error) {
+	if err := validateMaskingPolicies(policies); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(policies)
+	if err != nil {
+		return nil, err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      MaskingPolicyConfigMapName,
+			Namespace: constants.KubeSphereLoggingNamespace,
+		},
+		Data: map[string]string{maskingPolicyDataKey: string(data)},
+	}
+
+	configMaps := o.client.CoreV1().ConfigMaps(constants.KubeSphereLoggingNamespace)
+	existing, err := configMaps.Get(context.Background(), MaskingPolicyConfigMapName, v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if _, err := configMaps.Create(context.Background(), cm, v1.CreateOptions{}); err != nil {
+			return nil, err
+		}
+		return policies, o.audit(operator, policies)
+	}
+
+	existing.Data = cm.Data
+	if _, err := configMaps.Update(context.Background(), existing, v1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return policies, o.audit(operator, policies)
+}
+
+// audit records who replaced the masking policies as an event on their
+// ConfigMap, so rule changes leave a trail
+func (o *maskingPolicyOperator) audit(operator string, policies []MaskingPolicy) error {
+	now := v1.Time{Time: time.Now()}
+	event := &corev1.Event{
+		ObjectMeta: v1.ObjectMeta{
+			GenerateName: MaskingPolicyConfigMapName + "-",
+			Namespace:    constants.KubeSphereLoggingNamespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:       "ConfigMap",
+			Namespace:  constants.KubeSphereLoggingNamespace,
+			Name:       MaskingPolicyConfigMapName,
+			APIVersion: "v1",
+		},
+		Reason:         maskingAuditReason,
+		Message:        fmt.Sprintf("user %s replaced the log masking policies, %d namespaces are covered", operator, len(policies)),
+		Type:           corev1.EventTypeNormal,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Source:         corev1.EventSource{Component: "ks-apiserver"},
+	}
+	_, err := o.client.CoreV1().Events(constants.KubeSphereLoggingNamespace).Create(context.Background(), event, v1.CreateOptions{})
+	return err
+}
+
+// agentMaskingRule is one resolved rule as the agent's masking plugin
+// consumes it, grouped by namespace in masking.json
+type agentMaskingRule struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// RenderAgentMaskingConfig renders the agent-stage rules into the data of
+// the agent ConfigMap, with builtin patterns expanded.
+func RenderAgentMaskingConfig(policies []MaskingPolicy) (map[string]string, error) {
+	rules := map[string][]agentMaskingRule{}
+	for _, policy := range policies {
+		for _, rule := range policy.Rules {
+			if rule.Stage != MaskingStageAgent {
+				continue
+			}
+			re, err := resolvePattern(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("masking policy for namespace %s: rule %s: %v", policy.Namespace, rule.Name, err)
+			}
+			replacement := rule.Replacement
+			if replacement == "" {
+				replacement = defaultReplacement
+			}
+			rules[policy.Namespace] = append(rules[policy.Namespace], agentMaskingRule{Pattern: re.String(), Replacement: replacement})
+		}
+	}
+
+	data, err := json.MarshalIndent(rules, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{agentMaskingDataKey: string(data)}, nil
+}
+
+// maskingRewrite is one compiled query-stage rule
+type maskingRewrite struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// Masker applies the query-stage masking rules to log lines leaving the
+// API, covering data that reached storage before a rule existed.
+type Masker struct {
+	rewrites map[string][]maskingRewrite
+}
+
+// NewMasker compiles the query-stage rules of the policies; invalid rules
+// are skipped, validation rejects them on the way in.
+func NewMasker(policies []MaskingPolicy) *Masker {
+	m := &Masker{rewrites: map[string][]maskingRewrite{}}
+	for _, policy := range policies {
+		for _, rule := range policy.Rules {
+			if rule.Stage == MaskingStageAgent {
+				continue
+			}
+			re, err := resolvePattern(rule.Pattern)
+			if err != nil {
+				continue
+			}
+			replacement := rule.Replacement
+			if replacement == "" {
+				replacement = defaultReplacement
+			}
+			m.rewrites[policy.Namespace] = append(m.rewrites[policy.Namespace], maskingRewrite{pattern: re, replacement: replacement})
+		}
+	}
+	return m
+}
+
+// Mask applies the namespace's query-stage rules to one log line.
+func (m *Masker) Mask(namespace, line string) string {
+	for _, rewrite := range m.rewrites[namespace] {
+		line = rewrite.pattern.ReplaceAllString(line, rewrite.replacement)
+	}
+	return line
+}
+
+// MaskLogs rewrites the records of a query result in place, each under its
+// own namespace's rules.
+func (m *Masker) MaskLogs(logs *logging.Logs) {
+	if logs == nil || len(m.rewrites) == 0 {
+		return
+	}
+	for i, record := range logs.Records {
+		logs.Records[i].Log = m.Mask(record.Namespace, record.Log)
+	}
+}
+
+// MaskingWriter masks an export stream line by line. Exported lines carry
+// no namespace, so every namespace's query-stage rules apply; call Flush
+// once the export is written out.
+type MaskingWriter struct {
+	w   io.Writer
+	m   *Masker
+	buf bytes.Buffer
+}
+
+func (m *Masker) Writer(w io.Writer) *MaskingWriter {
+	return &MaskingWriter{w: w, m: m}
+}
+
+func (mw *MaskingWriter) maskAll(line string) string {
+	for namespace := range mw.m.rewrites {
+		line = mw.m.Mask(namespace, line)
+	}
+	return line
+}
+
+func (mw *MaskingWriter) Write(p []byte) (int, error) {
+	mw.buf.Write(p)
+	for {
+		line, err := mw.buf.ReadString('\n')
+		if err != nil {
+			// an incomplete line stays buffered until more arrives
+			mw.buf.WriteString(line)
+			return len(p), nil
+		}
+		if _, err := io.WriteString(mw.w, mw.maskAll(line)); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush masks and writes out a trailing line without a line break.
+func (mw *MaskingWriter) Flush() error {
+	if mw.buf.Len() == 0 {
+		return nil
+	}
+	line := mw.buf.String()
+	mw.buf.Reset()
+	_, err := io.WriteString(mw.w, mw.maskAll(line))
+	return err
+}
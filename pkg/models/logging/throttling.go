@@ -0,0 +1,256 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// ThrottlePolicyConfigMapName names the ConfigMap holding the
+	// per-namespace log throttling and sampling policies
+	ThrottlePolicyConfigMapName = "logging-throttle-policies"
+	throttlePolicyDataKey       = "policies"
+
+	// AgentThrottleConfigMapName holds the fluent-bit sections rendered
+	// from the policies; the log agent includes throttle.conf and mounts
+	// sampling.lua next to it
+	AgentThrottleConfigMapName = "fluent-bit-throttle"
+	agentConfigDataKey         = "throttle.conf"
+	agentScriptDataKey         = "sampling.lua"
+
+	// agentScriptPath is where the agent mounts the sampling script
+	agentScriptPath = "/fluent-bit/config/sampling.lua"
+)
+
+// knownLogLevels are the levels a policy may drop
+var knownLogLevels = []string{"trace", "debug", "info", "warn", "warning", "error"}
+
+// ThrottlePolicy caps what one namespace may push into the logging
+// pipeline, so a chatty tenant cannot saturate it for everyone.
+type ThrottlePolicy struct {
+	Namespace string `json:"namespace"`
+
+	// LinesPerSecond caps the namespace's log throughput; 0 leaves it
+	// uncapped
+	LinesPerSecond int `json:"linesPerSecond,omitempty"`
+
+	// SampleRate keeps one in every N lines of the namespace; 0 and 1
+	// keep everything
+	SampleRate int `json:"sampleRate,omitempty"`
+
+	// DropLevels discards lines of these levels entirely, e.g. debug
+	DropLevels []string `json:"dropLevels,omitempty"`
+}
+
+type ThrottlePolicyOperator interface {
+	ListThrottlePolicies() ([]ThrottlePolicy, error)
+	// SaveThrottlePolicies replaces the policies; the controller rolls
+	// them into the agent configuration
+	SaveThrottlePolicies(policies []ThrottlePolicy) ([]ThrottlePolicy, error)
+}
+
+type throttlePolicyOperator struct {
+	client kubernetes.Interface
+}
+
+func NewThrottlePolicyOperator(client kubernetes.Interface) ThrottlePolicyOperator {
+	return &throttlePolicyOperator{client: client}
+}
+
+func validLogLevel(level string) bool {
+	for _, known := range knownLogLevels {
+		if level == known {
+			return true
+		}
+	}
+	return false
+}
+
+func validateThrottlePolicies(policies []ThrottlePolicy) error {
+	namespaces := map[string]bool{}
+	for i, policy := range policies {
+		if policy.Namespace == "" {
+			return fmt.Errorf("throttle policy %d has no namespace", i)
+		}
+		if namespaces[policy.Namespace] {
+			return fmt.Errorf("duplicate throttle policy for namespace %s", policy.Namespace)
+		}
+		namespaces[policy.Namespace] = true
+		if policy.LinesPerSecond < 0 {
+			return fmt.Errorf("throttle policy for namespace %s: linesPerSecond must not be negative", policy.Namespace)
+		}
+		if policy.SampleRate < 0 {
+			return fmt.Errorf("throttle policy for namespace %s: sampleRate must not be negative", policy.Namespace)
+		}
+		for _, level := range policy.DropLevels {
+			if !validLogLevel(level) {
+				return fmt.Errorf("throttle policy for namespace %s: unknown log level %s, known levels are %s",
+					policy.Namespace, level, strings.Join(knownLogLevels, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func (o *throttlePolicyOperator) ListThrottlePolicies() ([]ThrottlePolicy, error) {
+	cm, err := o.client.CoreV1().ConfigMaps(constants.KubeSphereLoggingNamespace).
+		Get(context.Background(), ThrottlePolicyConfigMapName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []ThrottlePolicy{}, nil
+		}
+		return nil, err
+	}
+	return UnmarshalThrottlePolicies(cm)
+}
+
+// UnmarshalThrottlePolicies reads the policies out of their ConfigMap, for
+// the API and the controller reconciling them into the agent config.
+func UnmarshalThrottlePolicies(cm *corev1.ConfigMap) ([]ThrottlePolicy, error) {
+	data, ok := cm.Data[throttlePolicyDataKey]
+	if !ok || data == "" {
+		return []ThrottlePolicy{}, nil
+	}
+	var policies []ThrottlePolicy
+	if err := json.Unmarshal([]byte(data), &policies); err != nil {
+		return nil, fmt.Errorf("configmap %s holds invalid throttle policies: %v", cm.Name, err)
+	}
+	return policies, nil
+}
+
+func (o *throttlePolicyOperator) SaveThrottlePolicies(policies []ThrottlePolicy) ([]ThrottlePolicy, error) {
+	if err := validateThrottlePolicies(policies); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(policies)
+	if err != nil {
+		return nil, err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      ThrottlePolicyConfigMapName,
+			Namespace: constants.KubeSphereLoggingNamespace,
+		},
+		Data: map[string]string{throttlePolicyDataKey: string(data)},
+	}
+
+	configMaps := o.client.CoreV1().ConfigMaps(constants.KubeSphereLoggingNamespace)
+	existing, err := configMaps.Get(context.Background(), ThrottlePolicyConfigMapName, v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if _, err := configMaps.Create(context.Background(), cm, v1.CreateOptions{}); err != nil {
+			return nil, err
+		}
+		return policies, nil
+	}
+
+	existing.Data = cm.Data
+	if _, err := configMaps.Update(context.Background(), existing, v1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// namespaceMatch is the fluent-bit tag pattern selecting the container
+// logs of one namespace under the kubernetes tail input
+func namespaceMatch(namespace string) string {
+	return fmt.Sprintf("kube.var.log.containers.*_%s_*", namespace)
+}
+
+// RenderAgentThrottleConfig renders the policies into the data of the agent
+// ConfigMap: fluent-bit filter sections in throttle.conf plus the lua
+// sampling script they call.
+func RenderAgentThrottleConfig(policies []ThrottlePolicy) map[string]string {
+	sorted := make([]ThrottlePolicy, len(policies))
+	copy(sorted, policies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Namespace < sorted[j].Namespace })
+
+	var conf strings.Builder
+	var script strings.Builder
+	conf.WriteString("# generated from the logging throttle policies, do not edit\n")
+	for _, policy := range sorted {
+		match := namespaceMatch(policy.Namespace)
+		if policy.LinesPerSecond > 0 {
+			fmt.Fprintf(&conf, `
+[FILTER]
+    Name      throttle
+    Match     %s
+    Rate      %d
+    Window    5
+    Interval  1s
+`, match, policy.LinesPerSecond)
+		}
+		if len(policy.DropLevels) > 0 {
+			fmt.Fprintf(&conf, `
+[FILTER]
+    Name      grep
+    Match     %s
+    Exclude   level (?i)^(%s)$
+`, match, strings.Join(policy.DropLevels, "|"))
+		}
+		if policy.SampleRate > 1 {
+			function := samplingFunction(policy.Namespace)
+			fmt.Fprintf(&conf, `
+[FILTER]
+    Name      lua
+    Match     %s
+    script    %s
+    call      %s
+`, match, agentScriptPath, function)
+			fmt.Fprintf(&script, `
+local count_%[1]s = 0
+function %[2]s(tag, timestamp, record)
+    count_%[1]s = count_%[1]s + 1
+    if count_%[1]s %% %[3]d == 1 then
+        return 0, timestamp, record
+    end
+    return -1, 0, 0
+end
+`, sanitize(policy.Namespace), function, policy.SampleRate)
+		}
+	}
+
+	data := map[string]string{agentConfigDataKey: conf.String()}
+	if script.Len() > 0 {
+		data[agentScriptDataKey] = "-- generated from the logging throttle policies, do not edit\n" + script.String()
+	}
+	return data
+}
+
+func samplingFunction(namespace string) string {
+	return "sample_" + sanitize(namespace)
+}
+
+// sanitize turns a namespace into a valid lua identifier suffix
+func sanitize(namespace string) string {
+	return strings.NewReplacer("-", "_", ".", "_").Replace(namespace)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderAgentThrottleConfig(t *testing.T) {
+	policies := []ThrottlePolicy{
+		{Namespace: "chatty-ns", LinesPerSecond: 100, DropLevels: []string{"debug", "trace"}},
+		{Namespace: "sampled-ns", SampleRate: 10},
+	}
+
+	data := RenderAgentThrottleConfig(policies)
+	conf := data["throttle.conf"]
+
+	if !strings.Contains(conf, "Match     kube.var.log.containers.*_chatty-ns_*") {
+		t.Errorf("config misses the namespace match:\n%s", conf)
+	}
+	if !strings.Contains(conf, "Rate      100") {
+		t.Errorf("config misses the throttle rate:\n%s", conf)
+	}
+	if !strings.Contains(conf, "Exclude   level (?i)^(debug|trace)$") {
+		t.Errorf("config misses the level filter:\n%s", conf)
+	}
+	if !strings.Contains(conf, "call      sample_sampled_ns") {
+		t.Errorf("config misses the sampling call:\n%s", conf)
+	}
+
+	script, ok := data["sampling.lua"]
+	if !ok {
+		t.Fatal("sampling script missing")
+	}
+	if !strings.Contains(script, "function sample_sampled_ns(tag, timestamp, record)") {
+		t.Errorf("script misses the sampling function:\n%s", script)
+	}
+	if !strings.Contains(script, "% 10 == 1") {
+		t.Errorf("script misses the sampling rate:\n%s", script)
+	}
+
+	// without sampling policies no script is rendered
+	data = RenderAgentThrottleConfig(policies[:1])
+	if _, ok := data["sampling.lua"]; ok {
+		t.Error("unexpected sampling script without sampling policies")
+	}
+}
+
+func TestValidateThrottlePolicies(t *testing.T) {
+	var tests = []struct {
+		name      string
+		policies  []ThrottlePolicy
+		expectErr bool
+	}{
+		{
+			name:     "valid",
+			policies: []ThrottlePolicy{{Namespace: "a", LinesPerSecond: 10}, {Namespace: "b", SampleRate: 5, DropLevels: []string{"debug"}}},
+		},
+		{
+			name:      "missing namespace",
+			policies:  []ThrottlePolicy{{LinesPerSecond: 10}},
+			expectErr: true,
+		},
+		{
+			name:      "duplicate namespace",
+			policies:  []ThrottlePolicy{{Namespace: "a"}, {Namespace: "a"}},
+			expectErr: true,
+		},
+		{
+			name:      "negative rate",
+			policies:  []ThrottlePolicy{{Namespace: "a", LinesPerSecond: -1}},
+			expectErr: true,
+		},
+		{
+			name:      "unknown level",
+			policies:  []ThrottlePolicy{{Namespace: "a", DropLevels: []string{"verbose"}}},
+			expectErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateThrottlePolicies(test.policies)
+			if test.expectErr && err == nil {
+				t.Error("expected a validation error")
+			}
+			if !test.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
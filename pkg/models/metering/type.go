@@ -7,6 +7,8 @@ type PriceInfo struct {
 	IngressNetworkTrafficPerGiagabytesPerHour float64 `json:"ingress_network_traffic_per_giagabytes_per_hour,omitempty" description:"ingress price"`
 	EgressNetworkTrafficPerGiagabytesPerHour  float64 `json:"egress_network_traffic_per_gigabytes_per_hour,omitempty" description:"egress price"`
 	PvcPerGigabytesPerHour                    float64 `json:"pvc_per_gigabytes_per_hour,omitempty" description:"pvc price"`
+
+	PvcPerGigabytesPerHourByStorageClass map[string]float64 `json:"pvc_per_gigabytes_per_hour_by_storage_class,omitempty" description:"per storage class pvc price"`
 }
 
 // currently init method fill illegal value to hint that metering config file was not mounted yet
@@ -0,0 +1,187 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const (
+	// configmap holding the workload labels finance aggregates costs by
+	costDimensionConfigMap = "ks-metering-dimensions"
+	costDimensionConfigKey = "dimensions"
+
+	// group costs of pods missing the dimension label end up in
+	unassignedGroup = "unassigned"
+)
+
+// pod meters aggregated in chargeback groups
+var podMeters = []string{
+	"meter_pod_cpu_usage",
+	"meter_pod_memory_usage_wo_cache",
+	"meter_pod_net_bytes_transmitted",
+	"meter_pod_net_bytes_received",
+	"meter_pod_pvc_bytes_total",
+}
+
+// CostGroup is the aggregated usage and cost of all pods sharing one value
+// of a cost dimension label.
+type CostGroup struct {
+	Dimension string `json:"dimension" description:"label key used as cost dimension"`
+	Value     string `json:"value" description:"label value, or unassigned for pods without the label"`
+
+	Usage map[string]float64 `json:"usage" description:"summed usage per meter"`
+
+	Fee          float64 `json:"fee" description:"total monetary cost of the group"`
+	CurrencyUnit string  `json:"currency_unit" description:"currency of the fee"`
+}
+
+type ChargebackOperator interface {
+	GetCostDimensions() ([]string, error)
+	SetCostDimensions(dimensions []string) error
+	Chargeback(dimension string, start, end time.Time) ([]CostGroup, error)
+}
+
+type chargebackOperator struct {
+	k8s       kubernetes.Interface
+	informers coreinformers.SharedInformerFactory
+	c         monitoring.Interface
+}
+
+func NewChargebackOperator(k8s kubernetes.Interface, informers coreinformers.SharedInformerFactory, c monitoring.Interface) ChargebackOperator {
+	return &chargebackOperator{k8s: k8s, informers: informers, c: c}
+}
+
+func (o *chargebackOperator) GetCostDimensions() ([]string, error) {
+	cm, err := o.k8s.CoreV1().ConfigMaps(constants.KubeSphereNamespace).Get(context.Background(), costDimensionConfigMap, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var dimensions []string
+	for _, dimension := range strings.Split(cm.Data[costDimensionConfigKey], ",") {
+		if dimension = strings.TrimSpace(dimension); dimension != "" {
+			dimensions = append(dimensions, dimension)
+		}
+	}
+	return dimensions, nil
+}
+
+func (o *chargebackOperator) SetCostDimensions(dimensions []string) error {
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      costDimensionConfigMap,
+			Namespace: constants.KubeSphereNamespace,
+		},
+		Data: map[string]string{
+			costDimensionConfigKey: strings.Join(dimensions, ","),
+		},
+	}
+
+	_, err := o.k8s.CoreV1().ConfigMaps(constants.KubeSphereNamespace).Update(ctx, cm, metav1.UpdateOptions{})
+	if errors.IsNotFound(err) {
+		_, err = o.k8s.CoreV1().ConfigMaps(constants.KubeSphereNamespace).Create(ctx, cm, metav1.CreateOptions{})
+	}
+	return err
+}
+
+// Chargeback groups pod costs over the period by the values of the dimension
+// label on the pods.
+func (o *chargebackOperator) Chargeback(dimension string, start, end time.Time) ([]CostGroup, error) {
+	dimensions, err := o.GetCostDimensions()
+	if err != nil {
+		return nil, err
+	}
+	if !contains(dimensions, dimension) {
+		return nil, fmt.Errorf("label %s is not configured as a cost dimension", dimension)
+	}
+
+	metrics := o.c.GetNamedMetersOverTime(podMeters, start, end, time.Hour,
+		[]monitoring.QueryOption{monitoring.PodOption{}})
+
+	podLister := o.informers.Core().V1().Pods().Lister()
+	currencyUnit := getCurrencyUnit()
+
+	groups := make(map[string]*CostGroup)
+	for _, metric := range metrics {
+		if metric.Error != "" {
+			return nil, fmt.Errorf("query %s failed: %s", metric.MetricName, metric.Error)
+		}
+		for _, value := range metric.MetricValues {
+			pod, err := podLister.Pods(value.Metadata["namespace"]).Get(value.Metadata["pod"])
+			if err != nil {
+				// pods may be gone by the time the meters are grouped
+				continue
+			}
+
+			groupValue := pod.Labels[dimension]
+			if groupValue == "" {
+				groupValue = unassignedGroup
+			}
+
+			group, ok := groups[groupValue]
+			if !ok {
+				group = &CostGroup{
+					Dimension:    dimension,
+					Value:        groupValue,
+					Usage:        make(map[string]float64),
+					CurrencyUnit: currencyUnit,
+				}
+				groups[groupValue] = group
+			}
+
+			sum := getSumPointValue(value.Series)
+			group.Usage[metric.MetricName] += sum
+			group.Fee += getFeeWithMeterName(metric.MetricName, sum, value.Metadata)
+		}
+	}
+
+	result := make([]CostGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Value < result[j].Value
+	})
+	return result, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+// SeriesDelta compares one time series between the current and the offset
+// window, series are matched by their label set
+type SeriesDelta struct {
+	Metadata map[string]string `json:"metric,omitempty" description:"time series labels"`
+
+	CurrentAvg float64 `json:"current_avg" description:"average value over the current window"`
+	OffsetAvg  float64 `json:"offset_avg" description:"average value over the offset window"`
+
+	// Delta is current minus offset, DeltaRatio the relative change
+	// against the offset window, 0 when the offset average is 0
+	Delta      float64 `json:"delta"`
+	DeltaRatio float64 `json:"delta_ratio"`
+}
+
+// ComparedMetric carries the current and the offset series of one metric
+// in a single response. The offset series keeps its original timestamps,
+// panels overlay the two windows by shifting it client-side.
+type ComparedMetric struct {
+	MetricName string                `json:"metric_name,omitempty"`
+	Current    monitoring.MetricData `json:"current,omitempty" description:"series of the queried range"`
+	Offset     monitoring.MetricData `json:"offset,omitempty" description:"series of the same range shifted back by the offset"`
+	Deltas     []SeriesDelta         `json:"deltas,omitempty" description:"per-series delta statistics"`
+}
+
+type ComparedMetrics struct {
+	// the offset both windows are apart, e.g. 24h0m0s
+	Offset string `json:"offset"`
+
+	Results []ComparedMetric `json:"results" description:"actual array of results"`
+}
+
+// GetNamedMetricsOverTimeComparison runs the range query twice, once as
+// given and once shifted back by offset, and pairs the results with delta
+// statistics so "compared to last week" panels need a single request.
+func (mo monitoringOperator) GetNamedMetricsOverTimeComparison(metrics []string, start, end time.Time, step, offset time.Duration, opt monitoring.QueryOption) ComparedMetrics {
+	current := mo.GetNamedMetricsOverTime(metrics, start, end, step, opt)
+	shifted := mo.GetNamedMetricsOverTime(metrics, start.Add(-offset), end.Add(-offset), step, opt)
+
+	offsetByName := make(map[string]monitoring.Metric, len(shifted.Results))
+	for _, metric := range shifted.Results {
+		offsetByName[metric.MetricName] = metric
+	}
+
+	res := ComparedMetrics{Offset: offset.String()}
+	for _, metric := range current.Results {
+		compared := ComparedMetric{
+			MetricName: metric.MetricName,
+			Current:    metric.MetricData,
+			Offset:     offsetByName[metric.MetricName].MetricData,
+		}
+		compared.Deltas = seriesDeltas(compared.Current, compared.Offset)
+		res.Results = append(res.Results, compared)
+	}
+	return res
+}
+
+// seriesDeltas matches the series of both windows by label set and
+// compares their averages, series missing on either side compare against 0
+func seriesDeltas(current, offset monitoring.MetricData) []SeriesDelta {
+	offsetAvgs := make(map[string]float64, len(offset.MetricValues))
+	offsetMetadata := make(map[string]map[string]string, len(offset.MetricValues))
+	for _, value := range offset.MetricValues {
+		key := labelKey(value.Metadata)
+		offsetAvgs[key] = seriesAvg(value)
+		offsetMetadata[key] = value.Metadata
+	}
+
+	var deltas []SeriesDelta
+	seen := map[string]bool{}
+	for _, value := range current.MetricValues {
+		key := labelKey(value.Metadata)
+		seen[key] = true
+		deltas = append(deltas, newSeriesDelta(value.Metadata, seriesAvg(value), offsetAvgs[key]))
+	}
+	for key, avg := range offsetAvgs {
+		if !seen[key] {
+			deltas = append(deltas, newSeriesDelta(offsetMetadata[key], 0, avg))
+		}
+	}
+	return deltas
+}
+
+func newSeriesDelta(metadata map[string]string, currentAvg, offsetAvg float64) SeriesDelta {
+	delta := SeriesDelta{
+		Metadata:   metadata,
+		CurrentAvg: currentAvg,
+		OffsetAvg:  offsetAvg,
+		Delta:      currentAvg - offsetAvg,
+	}
+	if offsetAvg != 0 {
+		delta.DeltaRatio = delta.Delta / offsetAvg
+	}
+	return delta
+}
+
+func seriesAvg(value monitoring.MetricValue) float64 {
+	if len(value.Series) == 0 {
+		if value.Sample != nil {
+			return value.Sample.Value()
+		}
+		return 0
+	}
+	var sum float64
+	for _, point := range value.Series {
+		sum += point.Value()
+	}
+	return sum / float64(len(value.Series))
+}
+
+func labelKey(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+metadata[key])
+	}
+	return strings.Join(pairs, ",")
+}
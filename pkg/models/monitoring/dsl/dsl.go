@@ -0,0 +1,136 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dsl compiles structured metric queries into backend query
+// languages. Unlike raw expression strings, a compiled query is built from
+// validated parts, so tenant scoping selectors can always be injected.
+package dsl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter matches a label against a value. Operator defaults to "=".
+type Filter struct {
+	Label    string `json:"label"`
+	Operator string `json:"operator,omitempty"`
+	Value    string `json:"value"`
+}
+
+// Query is one structured metric query. Metric is required; everything
+// else is optional. When Rate is set the metric is turned into a per-second
+// rate over that window before Function aggregates it.
+type Query struct {
+	Metric  string   `json:"metric"`
+	Filters []Filter `json:"filters,omitempty"`
+
+	// Rate is a window like 5m; it wraps the metric in rate()
+	Rate string `json:"rate,omitempty"`
+
+	// Function aggregates the series, one of sum, avg, min, max, count
+	Function string `json:"function,omitempty"`
+
+	// GroupBy keeps the given labels when aggregating
+	GroupBy []string `json:"groupBy,omitempty"`
+}
+
+// compileFn turns a structured query into a backend expression.
+type compileFn func(query Query) (string, error)
+
+// CompileFns maps a monitoring backend to its query compiler.
+var CompileFns = make(map[string]compileFn)
+
+func register(name string, fn compileFn) {
+	CompileFns[name] = fn
+}
+
+func init() {
+	register("prometheus", promqlCompile)
+}
+
+var (
+	metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegexp  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	rateWindowRegexp = regexp.MustCompile(`^[0-9]+[smhdwy]$`)
+)
+
+var filterOperators = map[string]bool{
+	"=": true, "!=": true, "=~": true, "!~": true,
+}
+
+var aggregations = map[string]bool{
+	"sum": true, "avg": true, "min": true, "max": true, "count": true,
+}
+
+// labelValueReplacer escapes a label value for a quoted matcher.
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// promqlCompile compiles the query to PromQL. Every part is validated, so
+// a filter value can never break out of its matcher.
+func promqlCompile(query Query) (string, error) {
+	if !metricNameRegexp.MatchString(query.Metric) {
+		return "", fmt.Errorf("invalid metric name %q", query.Metric)
+	}
+
+	matchers := make([]string, 0, len(query.Filters))
+	for _, filter := range query.Filters {
+		if !labelNameRegexp.MatchString(filter.Label) {
+			return "", fmt.Errorf("invalid filter label %q", filter.Label)
+		}
+		operator := filter.Operator
+		if operator == "" {
+			operator = "="
+		}
+		if !filterOperators[operator] {
+			return "", fmt.Errorf("invalid filter operator %q", operator)
+		}
+		matchers = append(matchers, fmt.Sprintf("%s%s\"%s\"", filter.Label, operator, labelValueReplacer.Replace(filter.Value)))
+	}
+
+	expr := query.Metric
+	if len(matchers) > 0 {
+		expr = fmt.Sprintf("%s{%s}", expr, strings.Join(matchers, ","))
+	}
+
+	if query.Rate != "" {
+		if !rateWindowRegexp.MatchString(query.Rate) {
+			return "", fmt.Errorf("invalid rate window %q", query.Rate)
+		}
+		expr = fmt.Sprintf("rate(%s[%s])", expr, query.Rate)
+	}
+
+	if query.Function != "" {
+		if !aggregations[query.Function] {
+			return "", fmt.Errorf("invalid function %q", query.Function)
+		}
+		for _, label := range query.GroupBy {
+			if !labelNameRegexp.MatchString(label) {
+				return "", fmt.Errorf("invalid group-by label %q", label)
+			}
+		}
+		if len(query.GroupBy) > 0 {
+			expr = fmt.Sprintf("%s by(%s) (%s)", query.Function, strings.Join(query.GroupBy, ","), expr)
+		} else {
+			expr = fmt.Sprintf("%s(%s)", query.Function, expr)
+		}
+	} else if len(query.GroupBy) > 0 {
+		return "", fmt.Errorf("group-by requires an aggregation function")
+	}
+
+	return expr, nil
+}
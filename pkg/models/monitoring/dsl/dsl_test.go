@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dsl
+
+import "testing"
+
+func TestPromqlCompile(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       Query
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name:     "bare metric",
+			query:    Query{Metric: "up"},
+			expected: `up`,
+		},
+		{
+			name: "filters with default and explicit operators",
+			query: Query{
+				Metric: "container_memory_usage_bytes",
+				Filters: []Filter{
+					{Label: "namespace", Value: "default"},
+					{Label: "pod", Operator: "=~", Value: "web-.*"},
+				},
+			},
+			expected: `container_memory_usage_bytes{namespace="default",pod=~"web-.*"}`,
+		},
+		{
+			name: "rate and aggregation with group-by",
+			query: Query{
+				Metric:   "container_cpu_usage_seconds_total",
+				Filters:  []Filter{{Label: "namespace", Value: "default"}},
+				Rate:     "5m",
+				Function: "sum",
+				GroupBy:  []string{"pod"},
+			},
+			expected: `sum by(pod) (rate(container_cpu_usage_seconds_total{namespace="default"}[5m]))`,
+		},
+		{
+			name:     "aggregation without group-by",
+			query:    Query{Metric: "up", Function: "count"},
+			expected: `count(up)`,
+		},
+		{
+			name: "filter value is escaped, not interpreted",
+			query: Query{
+				Metric:  "up",
+				Filters: []Filter{{Label: "job", Value: `a"},evil{b="`}},
+			},
+			expected: `up{job="a\"},evil{b=\""}`,
+		},
+		{
+			name:        "invalid metric name",
+			query:       Query{Metric: "up or on() vector(1)"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid filter operator",
+			query:       Query{Metric: "up", Filters: []Filter{{Label: "job", Operator: ">", Value: "x"}}},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid rate window",
+			query:       Query{Metric: "up", Rate: "5minutes"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid function",
+			query:       Query{Metric: "up", Function: "drop"},
+			expectedErr: true,
+		},
+		{
+			name:        "group-by without function",
+			query:       Query{Metric: "up", GroupBy: []string{"job"}},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CompileFns["prometheus"](tt.query)
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expressions
+
+import (
+	"strings"
+
+	"github.com/prometheus-community/prom-label-proxy/injectproxy"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// Scope confines an expression to a tenant's resources. Empty fields do not
+// confine. The tokens $cluster, $namespace, $workload and $pod in the
+// expression are substituted with the scope values before evaluation, and
+// the corresponding label matchers are enforced on every selector so the
+// expression cannot query outside the scope, whatever it says.
+type Scope struct {
+	Cluster   string
+	Namespace string
+	Workload  string
+	Pod       string
+}
+
+type scopeReplaceFn func(expr string, scope Scope) (string, error)
+
+var ReplaceScopeFns = make(map[string]scopeReplaceFn)
+
+func registerScope(name string, fn scopeReplaceFn) {
+	ReplaceScopeFns[name] = fn
+}
+
+func init() {
+	registerScope("prometheus", scopeReplace)
+}
+
+func scopeReplace(input string, scope Scope) (string, error) {
+	tokens := []struct {
+		name  string
+		value string
+	}{
+		{"cluster", scope.Cluster},
+		{"namespace", scope.Namespace},
+		{"workload", scope.Workload},
+		{"pod", scope.Pod},
+	}
+
+	for _, token := range tokens {
+		if token.value == "" {
+			continue
+		}
+		input = strings.ReplaceAll(input, "${"+token.name+"}", token.value)
+		input = strings.ReplaceAll(input, "$"+token.name, token.value)
+	}
+
+	root, err := parser.ParseExpr(input)
+	if err != nil {
+		return "", err
+	}
+
+	matchers := make([]*labels.Matcher, 0, len(tokens))
+	for _, token := range tokens {
+		if token.value == "" {
+			continue
+		}
+		matchers = append(matchers, &labels.Matcher{
+			Type:  labels.MatchEqual,
+			Name:  token.name,
+			Value: token.value,
+		})
+	}
+	if err = injectproxy.NewEnforcer(matchers...).EnforceNode(root); err != nil {
+		return "", err
+	}
+
+	return root.String(), nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expressions
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScopeReplace(t *testing.T) {
+	tests := []struct {
+		expr        string
+		scope       Scope
+		expected    string
+		expectedErr bool
+	}{
+		{
+			expr:     "up",
+			scope:    Scope{Namespace: "default"},
+			expected: `up{namespace="default"}`,
+		},
+		{
+			expr:     `rate(container_cpu_usage_seconds_total{pod="$pod"}[5m])`,
+			scope:    Scope{Namespace: "default", Pod: "mypod"},
+			expected: `rate(container_cpu_usage_seconds_total{namespace="default",pod="mypod"}[5m])`,
+		},
+		{
+			// tenants cannot break out of their scope by naming other
+			// namespaces or pods
+			expr:     `up{namespace="random",pod="other"}`,
+			scope:    Scope{Namespace: "default", Pod: "mypod"},
+			expected: `up{namespace="default",pod="mypod"}`,
+		},
+		{
+			expr:     `sum(kube_pod_owner{workload="$workload"}) by (pod)`,
+			scope:    Scope{Namespace: "default", Workload: "mydeploy"},
+			expected: `sum by(pod) (kube_pod_owner{namespace="default",workload="mydeploy"})`,
+		},
+		{
+			expr:        `@@@@`,
+			scope:       Scope{Namespace: "default"},
+			expectedErr: true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			result, err := scopeReplace(tt.expr, tt.scope)
+			if err != nil {
+				if !tt.expectedErr {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if diff := cmp.Diff(result, tt.expected); diff != "" {
+				t.Fatalf("%T differ (-got, +want): %s", tt.expected, diff)
+			}
+		})
+	}
+}
@@ -46,8 +46,12 @@ import (
 type MonitoringOperator interface {
 	GetMetric(expr, namespace string, time time.Time) (monitoring.Metric, error)
 	GetMetricOverTime(expr, namespace string, start, end time.Time, step time.Duration) (monitoring.Metric, error)
+	GetScopedMetric(expr string, scope expressions.Scope, time time.Time) (monitoring.Metric, error)
+	GetScopedMetricOverTime(expr string, scope expressions.Scope, start, end time.Time, step time.Duration) (monitoring.Metric, error)
 	GetNamedMetrics(metrics []string, time time.Time, opt monitoring.QueryOption) Metrics
 	GetNamedMetricsOverTime(metrics []string, start, end time.Time, step time.Duration, opt monitoring.QueryOption) Metrics
+	GetNamedMetricsOverTimeComparison(metrics []string, start, end time.Time, step, offset time.Duration, opt monitoring.QueryOption) ComparedMetrics
+	GetTopConsumers(metric, workspace, namespace string, t time.Time, page, limit int) (TopConsumers, error)
 	GetMetadata(namespace string) Metadata
 	GetMetricLabelSet(metric, namespace string, start, end time.Time) MetricLabelSet
 
@@ -111,6 +115,26 @@ func (mo monitoringOperator) GetMetricOverTime(expr, namespace string, start, en
 	return mo.prometheus.GetMetricOverTime(expr, start, end, step), nil
 }
 
+func (mo monitoringOperator) GetScopedMetric(expr string, scope expressions.Scope, time time.Time) (monitoring.Metric, error) {
+	// Like namespace isolation above, scope enforcement is backend specific.
+	// Each implementation should register itself to `ReplaceScopeFns` during init().
+	expr, err := expressions.ReplaceScopeFns["prometheus"](expr, scope)
+	if err != nil {
+		return monitoring.Metric{}, err
+	}
+	return mo.prometheus.GetMetric(expr, time), nil
+}
+
+func (mo monitoringOperator) GetScopedMetricOverTime(expr string, scope expressions.Scope, start, end time.Time, step time.Duration) (monitoring.Metric, error) {
+	// Like namespace isolation above, scope enforcement is backend specific.
+	// Each implementation should register itself to `ReplaceScopeFns` during init().
+	expr, err := expressions.ReplaceScopeFns["prometheus"](expr, scope)
+	if err != nil {
+		return monitoring.Metric{}, err
+	}
+	return mo.prometheus.GetMetricOverTime(expr, start, end, step), nil
+}
+
 func (mo monitoringOperator) GetNamedMetrics(metrics []string, time time.Time, opt monitoring.QueryOption) Metrics {
 	ress := mo.prometheus.GetNamedMetrics(metrics, time, opt)
 
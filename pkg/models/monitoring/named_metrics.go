@@ -248,6 +248,7 @@ var PodMetrics = []string{
 	"pod_memory_usage_wo_cache",
 	"pod_net_bytes_transmitted",
 	"pod_net_bytes_received",
+	"pod_cpu_throttled_ratio",
 
 	// meter
 	"meter_pod_cpu_usage",
@@ -272,6 +273,12 @@ var PVCMetrics = []string{
 	"pvc_bytes_used",
 	"pvc_bytes_total",
 	"pvc_bytes_utilisation",
+	"pvc_read_iops",
+	"pvc_write_iops",
+	"pvc_read_throughput",
+	"pvc_write_throughput",
+	"pvc_read_latency",
+	"pvc_write_latency",
 }
 
 var EtcdMetrics = []string{
@@ -0,0 +1,220 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	coreinformers "k8s.io/client-go/informers"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const (
+	// pods throttled for more than this share of their cpu periods count
+	// as constrained even when they stay below their request
+	throttledRatioThreshold = 0.25
+)
+
+// instant metrics the noisy neighbor analysis is built from
+var (
+	noisyPodMetrics = []string{
+		"pod_cpu_usage",
+		"pod_memory_usage_wo_cache",
+		"pod_cpu_throttled_ratio",
+	}
+	nodePressureMetrics = []string{
+		"node_cpu_utilisation",
+		"node_memory_utilisation",
+	}
+)
+
+// NoisyPod is a pod running above its requests, or heavily throttled, on a
+// node under pressure.
+type NoisyPod struct {
+	Namespace string `json:"namespace" description:"namespace of the pod"`
+	Pod       string `json:"pod" description:"pod name"`
+
+	CPUUsage          float64 `json:"cpuUsage" description:"current cpu cores used"`
+	CPURequest        float64 `json:"cpuRequest" description:"cpu cores requested, zero when no request is set"`
+	CPUThrottledRatio float64 `json:"cpuThrottledRatio" description:"share of cpu periods the pod was throttled"`
+	MemoryUsage       float64 `json:"memoryUsage" description:"current working set bytes"`
+	MemoryRequest     float64 `json:"memoryRequest" description:"memory bytes requested, zero when no request is set"`
+}
+
+// NodePressure is the utilisation and pressure state of one node together
+// with the noisy pods running on it, nodes under the most pressure first.
+type NodePressure struct {
+	Node string `json:"node" description:"node name"`
+
+	CPUUtilisation    float64 `json:"cpuUtilisation" description:"node cpu utilisation ratio"`
+	MemoryUtilisation float64 `json:"memoryUtilisation" description:"node memory utilisation ratio"`
+	MemoryPressure    bool    `json:"memoryPressure" description:"the MemoryPressure node condition, evictions are imminent when true"`
+	DiskPressure      bool    `json:"diskPressure" description:"the DiskPressure node condition"`
+
+	NoisyPods []NoisyPod `json:"noisyPods" description:"pods above their requests or throttled, largest cpu consumers first"`
+}
+
+type NoisyNeighborOperator interface {
+	GetNoisyNeighbors() ([]NodePressure, error)
+}
+
+type noisyNeighborOperator struct {
+	informers coreinformers.SharedInformerFactory
+	c         monitoring.Interface
+}
+
+func NewNoisyNeighborOperator(informers coreinformers.SharedInformerFactory, c monitoring.Interface) NoisyNeighborOperator {
+	return &noisyNeighborOperator{informers: informers, c: c}
+}
+
+// GetNoisyNeighbors correlates current pod usage with the requests on the pod
+// spec and with node utilisation, so pods causing node pressure stand out.
+func (o *noisyNeighborOperator) GetNoisyNeighbors() ([]NodePressure, error) {
+	nodes, err := o.informers.Core().V1().Nodes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	pressureByNode := make(map[string]*NodePressure, len(nodes))
+	for _, node := range nodes {
+		pressureByNode[node.Name] = &NodePressure{
+			Node:           node.Name,
+			MemoryPressure: nodeConditionTrue(node, corev1.NodeMemoryPressure),
+			DiskPressure:   nodeConditionTrue(node, corev1.NodeDiskPressure),
+			NoisyPods:      make([]NoisyPod, 0),
+		}
+	}
+
+	for _, metric := range o.c.GetNamedMetrics(nodePressureMetrics, now, monitoring.NodeOption{}) {
+		for _, value := range metric.MetricValues {
+			pressure, ok := pressureByNode[value.Metadata["node"]]
+			if !ok || value.Sample == nil {
+				continue
+			}
+			switch metric.MetricName {
+			case "node_cpu_utilisation":
+				pressure.CPUUtilisation = value.Sample.Value()
+			case "node_memory_utilisation":
+				pressure.MemoryUtilisation = value.Sample.Value()
+			}
+		}
+	}
+
+	for _, pod := range o.noisyPods(now) {
+		node, ok := pressureByNode[pod.node]
+		if !ok {
+			continue
+		}
+		node.NoisyPods = append(node.NoisyPods, pod.NoisyPod)
+	}
+
+	result := make([]NodePressure, 0, len(pressureByNode))
+	for _, pressure := range pressureByNode {
+		sort.Slice(pressure.NoisyPods, func(i, j int) bool {
+			return pressure.NoisyPods[i].CPUUsage > pressure.NoisyPods[j].CPUUsage
+		})
+		result = append(result, *pressure)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CPUUtilisation+result[i].MemoryUtilisation > result[j].CPUUtilisation+result[j].MemoryUtilisation
+	})
+	return result, nil
+}
+
+type noisyPod struct {
+	NoisyPod
+	node string
+}
+
+// noisyPods returns pods exceeding their requests or throttled beyond the
+// threshold, keyed back to their node via the pod spec.
+func (o *noisyNeighborOperator) noisyPods(now time.Time) []noisyPod {
+	usage := make(map[string]*noisyPod)
+	podLister := o.informers.Core().V1().Pods().Lister()
+
+	for _, metric := range o.c.GetNamedMetrics(noisyPodMetrics, now, monitoring.PodOption{}) {
+		for _, value := range metric.MetricValues {
+			if value.Sample == nil {
+				continue
+			}
+			namespace, name := value.Metadata["namespace"], value.Metadata["pod"]
+			key := namespace + "/" + name
+
+			entry, ok := usage[key]
+			if !ok {
+				pod, err := podLister.Pods(namespace).Get(name)
+				if err != nil {
+					// the pod may be gone by the time metrics are correlated
+					continue
+				}
+				cpuRequest, memoryRequest := podRequests(pod)
+				entry = &noisyPod{
+					NoisyPod: NoisyPod{
+						Namespace:     namespace,
+						Pod:           name,
+						CPURequest:    cpuRequest,
+						MemoryRequest: memoryRequest,
+					},
+					node: pod.Spec.NodeName,
+				}
+				usage[key] = entry
+			}
+
+			switch metric.MetricName {
+			case "pod_cpu_usage":
+				entry.CPUUsage = value.Sample.Value()
+			case "pod_memory_usage_wo_cache":
+				entry.MemoryUsage = value.Sample.Value()
+			case "pod_cpu_throttled_ratio":
+				entry.CPUThrottledRatio = value.Sample.Value()
+			}
+		}
+	}
+
+	result := make([]noisyPod, 0)
+	for _, entry := range usage {
+		overCPU := entry.CPURequest > 0 && entry.CPUUsage > entry.CPURequest
+		overMemory := entry.MemoryRequest > 0 && entry.MemoryUsage > entry.MemoryRequest
+		throttled := entry.CPUThrottledRatio > throttledRatioThreshold
+		if overCPU || overMemory || throttled {
+			result = append(result, *entry)
+		}
+	}
+	return result
+}
+
+func podRequests(pod *corev1.Pod) (cpuCores, memoryBytes float64) {
+	for _, container := range pod.Spec.Containers {
+		cpuCores += float64(container.Resources.Requests.Cpu().MilliValue()) / 1000
+		memoryBytes += float64(container.Resources.Requests.Memory().Value())
+	}
+	return
+}
+
+func nodeConditionTrue(node *corev1.Node, conditionType corev1.NodeConditionType) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
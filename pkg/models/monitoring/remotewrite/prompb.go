@@ -0,0 +1,292 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotewrite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// The remote-write WriteRequest message is four small protobuf messages;
+// they are coded by hand here instead of pulling the prompb package and its
+// generated code into the vendor tree. Unknown fields are skipped on decode
+// so newer senders keep working.
+
+// Label is one label of a series.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is one value at a millisecond timestamp.
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// TimeSeries is a labeled series with its samples.
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// WriteRequest is the payload of a remote-write request.
+type WriteRequest struct {
+	Timeseries []TimeSeries
+}
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func decodeKey(data []byte, pos int) (field int, wire int, next int, err error) {
+	key, next, err := decodeVarint(data, pos)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return int(key >> 3), int(key & 0x7), next, nil
+}
+
+func decodeVarint(data []byte, pos int) (uint64, int, error) {
+	var value uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if pos >= len(data) {
+			return 0, 0, fmt.Errorf("truncated varint")
+		}
+		b := data[pos]
+		pos++
+		value |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return value, pos, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("varint overflow")
+}
+
+func decodeBytes(data []byte, pos int) ([]byte, int, error) {
+	length, pos, err := decodeVarint(data, pos)
+	if err != nil {
+		return nil, 0, err
+	}
+	if uint64(len(data)-pos) < length {
+		return nil, 0, fmt.Errorf("truncated field")
+	}
+	return data[pos : pos+int(length)], pos + int(length), nil
+}
+
+func skipField(data []byte, pos, wire int) (int, error) {
+	switch wire {
+	case wireVarint:
+		_, pos, err := decodeVarint(data, pos)
+		return pos, err
+	case wireFixed64:
+		if len(data)-pos < 8 {
+			return 0, fmt.Errorf("truncated field")
+		}
+		return pos + 8, nil
+	case wireBytes:
+		_, pos, err := decodeBytes(data, pos)
+		return pos, err
+	case wireFixed32:
+		if len(data)-pos < 4 {
+			return 0, fmt.Errorf("truncated field")
+		}
+		return pos + 4, nil
+	}
+	return 0, fmt.Errorf("unsupported wire type %d", wire)
+}
+
+// DecodeWriteRequest decodes an uncompressed WriteRequest.
+func DecodeWriteRequest(data []byte) (WriteRequest, error) {
+	var request WriteRequest
+	for pos := 0; pos < len(data); {
+		field, wire, next, err := decodeKey(data, pos)
+		if err != nil {
+			return request, err
+		}
+		pos = next
+		if field == 1 && wire == wireBytes {
+			raw, next, err := decodeBytes(data, pos)
+			if err != nil {
+				return request, err
+			}
+			pos = next
+			series, err := decodeTimeSeries(raw)
+			if err != nil {
+				return request, err
+			}
+			request.Timeseries = append(request.Timeseries, series)
+			continue
+		}
+		if pos, err = skipField(data, pos, wire); err != nil {
+			return request, err
+		}
+	}
+	return request, nil
+}
+
+func decodeTimeSeries(data []byte) (TimeSeries, error) {
+	var series TimeSeries
+	for pos := 0; pos < len(data); {
+		field, wire, next, err := decodeKey(data, pos)
+		if err != nil {
+			return series, err
+		}
+		pos = next
+		if wire == wireBytes && (field == 1 || field == 2) {
+			raw, next, err := decodeBytes(data, pos)
+			if err != nil {
+				return series, err
+			}
+			pos = next
+			if field == 1 {
+				label, err := decodeLabel(raw)
+				if err != nil {
+					return series, err
+				}
+				series.Labels = append(series.Labels, label)
+			} else {
+				sample, err := decodeSample(raw)
+				if err != nil {
+					return series, err
+				}
+				series.Samples = append(series.Samples, sample)
+			}
+			continue
+		}
+		if pos, err = skipField(data, pos, wire); err != nil {
+			return series, err
+		}
+	}
+	return series, nil
+}
+
+func decodeLabel(data []byte) (Label, error) {
+	var label Label
+	for pos := 0; pos < len(data); {
+		field, wire, next, err := decodeKey(data, pos)
+		if err != nil {
+			return label, err
+		}
+		pos = next
+		if wire == wireBytes && (field == 1 || field == 2) {
+			raw, next, err := decodeBytes(data, pos)
+			if err != nil {
+				return label, err
+			}
+			pos = next
+			if field == 1 {
+				label.Name = string(raw)
+			} else {
+				label.Value = string(raw)
+			}
+			continue
+		}
+		if pos, err = skipField(data, pos, wire); err != nil {
+			return label, err
+		}
+	}
+	return label, nil
+}
+
+func decodeSample(data []byte) (Sample, error) {
+	var sample Sample
+	for pos := 0; pos < len(data); {
+		field, wire, next, err := decodeKey(data, pos)
+		if err != nil {
+			return sample, err
+		}
+		pos = next
+		switch {
+		case field == 1 && wire == wireFixed64:
+			if len(data)-pos < 8 {
+				return sample, fmt.Errorf("truncated field")
+			}
+			sample.Value = math.Float64frombits(binary.LittleEndian.Uint64(data[pos:]))
+			pos += 8
+		case field == 2 && wire == wireVarint:
+			value, next, err := decodeVarint(data, pos)
+			if err != nil {
+				return sample, err
+			}
+			sample.Timestamp = int64(value)
+			pos = next
+		default:
+			var err error
+			if pos, err = skipField(data, pos, wire); err != nil {
+				return sample, err
+			}
+		}
+	}
+	return sample, nil
+}
+
+// EncodeWriteRequest encodes the request back to an uncompressed
+// WriteRequest.
+func EncodeWriteRequest(request WriteRequest) []byte {
+	var out []byte
+	for _, series := range request.Timeseries {
+		out = appendBytesField(out, 1, encodeTimeSeries(series))
+	}
+	return out
+}
+
+func encodeTimeSeries(series TimeSeries) []byte {
+	var out []byte
+	for _, label := range series.Labels {
+		out = appendBytesField(out, 1, encodeLabel(label))
+	}
+	for _, sample := range series.Samples {
+		out = appendBytesField(out, 2, encodeSample(sample))
+	}
+	return out
+}
+
+func encodeLabel(label Label) []byte {
+	var out []byte
+	out = appendBytesField(out, 1, []byte(label.Name))
+	out = appendBytesField(out, 2, []byte(label.Value))
+	return out
+}
+
+func encodeSample(sample Sample) []byte {
+	var out []byte
+	out = append(out, 1<<3|wireFixed64)
+	out = append(out, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint64(out[len(out)-8:], math.Float64bits(sample.Value))
+	out = append(out, 2<<3|wireVarint)
+	out = appendVarint(out, uint64(sample.Timestamp))
+	return out
+}
+
+func appendBytesField(out []byte, field int, value []byte) []byte {
+	out = append(out, byte(field<<3|wireBytes))
+	out = appendVarint(out, uint64(len(value)))
+	return append(out, value...)
+}
+
+func appendVarint(out []byte, value uint64) []byte {
+	for value >= 0x80 {
+		out = append(out, byte(value)|0x80)
+		value >>= 7
+	}
+	return append(out, byte(value))
+}
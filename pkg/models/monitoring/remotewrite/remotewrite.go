@@ -0,0 +1,258 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package remotewrite receives Prometheus remote-write requests from
+// sources outside the cluster, such as VMs and external services, and
+// forwards them into the monitoring stack. Senders authenticate with
+// per-tenant tokens and every forwarded series is pinned to the tenant's
+// namespace, so external metrics show up in dashboards next to cluster
+// metrics without crossing tenant boundaries.
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// TenantSecret maps tenant namespaces to their remote-write tokens;
+	// each data entry is one namespace with its token as the value
+	TenantSecret = "remote-write-tenants"
+
+	// RelabelConfigMap holds the relabeling rules applied to every
+	// received series, as a YAML list under the rules key
+	RelabelConfigMap = "remote-write-relabeling"
+
+	relabelKey     = "rules"
+	forwardTimeout = 30 * time.Second
+)
+
+var (
+	// ErrNotConfigured is returned when no forward endpoint is set
+	ErrNotConfigured = fmt.Errorf("remote write is not configured")
+
+	// ErrUnauthorized is returned for an unknown or missing token
+	ErrUnauthorized = fmt.Errorf("unknown remote-write token")
+)
+
+// RelabelRule rewrites series labels before forwarding. Action is one of
+// replace, drop and labeldrop.
+type RelabelRule struct {
+	Action string `json:"action"`
+
+	// SourceLabel is the label matched by replace and drop rules
+	SourceLabel string `json:"sourceLabel,omitempty"`
+
+	// Regex matches the source label's value, or the label name for
+	// labeldrop rules
+	Regex string `json:"regex"`
+
+	// TargetLabel receives the replacement for replace rules
+	TargetLabel string `json:"targetLabel,omitempty"`
+
+	// Replacement may reference capture groups, defaults to $1
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// Operator receives remote-write requests and forwards them downstream.
+type Operator interface {
+	// Receive authenticates the token, relabels the snappy-compressed
+	// WriteRequest and forwards it to the write endpoint
+	Receive(token string, compressed []byte) error
+}
+
+type operator struct {
+	k        kubernetes.Interface
+	endpoint string
+	client   *http.Client
+}
+
+func NewOperator(k kubernetes.Interface, endpoint string) Operator {
+	return &operator{
+		k:        k,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: forwardTimeout},
+	}
+}
+
+func (o *operator) Receive(token string, compressed []byte) error {
+	if o.endpoint == "" {
+		return ErrNotConfigured
+	}
+
+	namespace, err := o.tenant(token)
+	if err != nil {
+		return err
+	}
+
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return fmt.Errorf("failed to decompress the request: %v", err)
+	}
+	request, err := DecodeWriteRequest(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode the request: %v", err)
+	}
+
+	rules, err := o.relabelRules()
+	if err != nil {
+		return err
+	}
+	request, err = relabel(request, rules, namespace)
+	if err != nil {
+		return err
+	}
+	if len(request.Timeseries) == 0 {
+		return nil
+	}
+
+	return o.forward(request)
+}
+
+// tenant resolves the token to the tenant's namespace.
+func (o *operator) tenant(token string) (string, error) {
+	if token == "" {
+		return "", ErrUnauthorized
+	}
+	secret, err := o.k.CoreV1().Secrets(constants.KubeSphereMonitoringNamespace).Get(context.Background(), TenantSecret, metav1.GetOptions{})
+	if err != nil {
+		return "", ErrUnauthorized
+	}
+	for namespace, value := range secret.Data {
+		if string(value) == token {
+			return namespace, nil
+		}
+	}
+	return "", ErrUnauthorized
+}
+
+func (o *operator) relabelRules() ([]RelabelRule, error) {
+	configMap, err := o.k.CoreV1().ConfigMaps(constants.KubeSphereMonitoringNamespace).Get(context.Background(), RelabelConfigMap, metav1.GetOptions{})
+	if err != nil {
+		// relabeling is optional
+		return nil, nil
+	}
+	rules := make([]RelabelRule, 0)
+	if err := yaml.Unmarshal([]byte(configMap.Data[relabelKey]), &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse the relabeling rules: %v", err)
+	}
+	return rules, nil
+}
+
+// relabel applies the rules to every series and pins the namespace label
+// to the tenant's namespace.
+func relabel(request WriteRequest, rules []RelabelRule, namespace string) (WriteRequest, error) {
+	kept := make([]TimeSeries, 0, len(request.Timeseries))
+	for _, series := range request.Timeseries {
+		series, drop, err := relabelSeries(series, rules)
+		if err != nil {
+			return request, err
+		}
+		if drop {
+			continue
+		}
+		kept = append(kept, setLabel(series, "namespace", namespace))
+	}
+	request.Timeseries = kept
+	return request, nil
+}
+
+func relabelSeries(series TimeSeries, rules []RelabelRule) (TimeSeries, bool, error) {
+	for _, rule := range rules {
+		pattern, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return series, false, fmt.Errorf("invalid relabeling regex %q: %v", rule.Regex, err)
+		}
+		switch rule.Action {
+		case "drop":
+			if value, ok := labelValue(series, rule.SourceLabel); ok && pattern.MatchString(value) {
+				return series, true, nil
+			}
+		case "replace":
+			if value, ok := labelValue(series, rule.SourceLabel); ok && pattern.MatchString(value) {
+				replacement := rule.Replacement
+				if replacement == "" {
+					replacement = "$1"
+				}
+				series = setLabel(series, rule.TargetLabel, pattern.ReplaceAllString(value, replacement))
+			}
+		case "labeldrop":
+			labels := series.Labels[:0]
+			for _, label := range series.Labels {
+				if !pattern.MatchString(label.Name) {
+					labels = append(labels, label)
+				}
+			}
+			series.Labels = labels
+		default:
+			return series, false, fmt.Errorf("invalid relabeling action %q", rule.Action)
+		}
+	}
+	return series, false, nil
+}
+
+func labelValue(series TimeSeries, name string) (string, bool) {
+	for _, label := range series.Labels {
+		if label.Name == name {
+			return label.Value, true
+		}
+	}
+	return "", false
+}
+
+func setLabel(series TimeSeries, name, value string) TimeSeries {
+	for i := range series.Labels {
+		if series.Labels[i].Name == name {
+			series.Labels[i].Value = value
+			return series
+		}
+	}
+	series.Labels = append(series.Labels, Label{Name: name, Value: value})
+	return series
+}
+
+func (o *operator) forward(request WriteRequest) error {
+	compressed := snappy.Encode(nil, EncodeWriteRequest(request))
+	req, err := http.NewRequest(http.MethodPost, o.endpoint, bytes.NewReader(compressed))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to forward the request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("the write endpoint rejected the request: %s", strings.TrimSpace(resp.Status))
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotewrite
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWriteRequestRoundTrip(t *testing.T) {
+	request := WriteRequest{
+		Timeseries: []TimeSeries{
+			{
+				Labels: []Label{
+					{Name: "__name__", Value: "node_cpu_seconds_total"},
+					{Name: "instance", Value: "vm-1:9100"},
+				},
+				Samples: []Sample{
+					{Value: 0.75, Timestamp: 1620000000000},
+					{Value: 0.5, Timestamp: 1620000060000},
+				},
+			},
+			{
+				Labels:  []Label{{Name: "__name__", Value: "up"}},
+				Samples: []Sample{{Value: 1, Timestamp: 1620000000000}},
+			},
+		},
+	}
+
+	decoded, err := DecodeWriteRequest(EncodeWriteRequest(request))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(request, decoded) {
+		t.Fatalf("expected %+v, got %+v", request, decoded)
+	}
+}
+
+func TestDecodeWriteRequestTruncated(t *testing.T) {
+	data := EncodeWriteRequest(WriteRequest{
+		Timeseries: []TimeSeries{{Labels: []Label{{Name: "__name__", Value: "up"}}}},
+	})
+	if _, err := DecodeWriteRequest(data[:len(data)-2]); err == nil {
+		t.Fatal("expected an error for a truncated request")
+	}
+}
+
+func TestRelabel(t *testing.T) {
+	request := WriteRequest{
+		Timeseries: []TimeSeries{
+			{
+				Labels: []Label{
+					{Name: "__name__", Value: "node_cpu_seconds_total"},
+					{Name: "instance", Value: "vm-1:9100"},
+					{Name: "namespace", Value: "kube-system"},
+					{Name: "__meta_internal", Value: "x"},
+				},
+			},
+			{
+				Labels: []Label{
+					{Name: "__name__", Value: "go_goroutines"},
+					{Name: "instance", Value: "vm-2:9100"},
+				},
+			},
+		},
+	}
+	rules := []RelabelRule{
+		{Action: "drop", SourceLabel: "__name__", Regex: "go_.*"},
+		{Action: "replace", SourceLabel: "instance", Regex: `(.+):\d+`, TargetLabel: "host"},
+		{Action: "labeldrop", Regex: "__meta_.*"},
+	}
+
+	result, err := relabel(request, rules, "tenant-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Timeseries) != 1 {
+		t.Fatalf("expected the go_goroutines series to be dropped, got %d series", len(result.Timeseries))
+	}
+
+	expected := []Label{
+		{Name: "__name__", Value: "node_cpu_seconds_total"},
+		{Name: "instance", Value: "vm-1:9100"},
+		// the tenant namespace overrides whatever the sender set
+		{Name: "namespace", Value: "tenant-a"},
+		{Name: "host", Value: "vm-1"},
+	}
+	if !reflect.DeepEqual(result.Timeseries[0].Labels, expected) {
+		t.Fatalf("expected labels %+v, got %+v", expected, result.Timeseries[0].Labels)
+	}
+
+	if _, err := relabel(request, []RelabelRule{{Action: "keep", Regex: ".*"}}, "tenant-a"); err == nil {
+		t.Fatal("expected an error for an invalid action")
+	}
+}
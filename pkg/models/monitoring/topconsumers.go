@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// base expressions of the metrics top consumers can be ranked by, keyed by
+// the named metric they correspond to. Each takes one extra selector that
+// scopes it to a namespace, empty for cluster scope.
+var topConsumerExprs = map[string]string{
+	"pod_cpu_usage":             `round(sum by (namespace, pod) (irate(container_cpu_usage_seconds_total{job="kubelet", pod!="", image!=""%s}[5m])), 0.001)`,
+	"pod_memory_usage":          `sum by (namespace, pod) (container_memory_usage_bytes{job="kubelet", pod!="", image!=""%s})`,
+	"pod_memory_usage_wo_cache": `sum by (namespace, pod) (container_memory_working_set_bytes{job="kubelet", pod!="", image!=""%s})`,
+	"pod_net_bytes_transmitted": `sum by (namespace, pod) (irate(container_network_transmit_bytes_total{pod!="", interface!~"^(cali.+|tunl.+|dummy.+|kube.+|flannel.+|cni.+|docker.+|veth.+|lo.*)", job="kubelet"%s}[5m]))`,
+	"pod_net_bytes_received":    `sum by (namespace, pod) (irate(container_network_receive_bytes_total{pod!="", interface!~"^(cali.+|tunl.+|dummy.+|kube.+|flannel.+|cni.+|docker.+|veth.+|lo.*)", job="kubelet"%s}[5m]))`,
+}
+
+// Consumer is one pod in the ranking
+type Consumer struct {
+	Rank      int     `json:"rank"`
+	Namespace string  `json:"namespace"`
+	Pod       string  `json:"pod"`
+	Value     float64 `json:"value"`
+}
+
+// TopConsumers is one page of the ranking, TotalItems counts every pod
+// reporting the metric within the scope
+type TopConsumers struct {
+	Metric      string     `json:"metric"`
+	Results     []Consumer `json:"results"`
+	CurrentPage int        `json:"page"`
+	TotalPages  int        `json:"total_page"`
+	TotalItems  int        `json:"total_item"`
+}
+
+// topConsumersExpr scopes the base expression of the metric, a workspace
+// scope joins against the namespace labels so only its namespaces count
+func topConsumersExpr(metric, workspace, namespace string) (string, error) {
+	base, ok := topConsumerExprs[metric]
+	if !ok {
+		supported := make([]string, 0, len(topConsumerExprs))
+		for name := range topConsumerExprs {
+			supported = append(supported, name)
+		}
+		sort.Strings(supported)
+		return "", fmt.Errorf("unsupported metric %s, one of: %v", metric, supported)
+	}
+
+	selector := ""
+	if namespace != "" {
+		selector = fmt.Sprintf(`, namespace="%s"`, namespace)
+	}
+	expr := fmt.Sprintf(base, selector)
+
+	if workspace != "" {
+		expr = fmt.Sprintf(`%s * on (namespace) group_left(workspace) kube_namespace_labels{workspace="%s"}`, expr, workspace)
+	}
+	return expr, nil
+}
+
+// GetTopConsumers ranks the pods of the scope by the metric through PromQL
+// topk, so only the requested page travels from Prometheus instead of every
+// pod series. The total comes from a count over the same expression.
+func (mo monitoringOperator) GetTopConsumers(metric, workspace, namespace string, t time.Time, page, limit int) (TopConsumers, error) {
+	res := TopConsumers{Metric: metric, CurrentPage: page}
+
+	expr, err := topConsumersExpr(metric, workspace, namespace)
+	if err != nil {
+		return res, err
+	}
+
+	count := mo.prometheus.GetMetric(fmt.Sprintf("count(%s)", expr), t)
+	if count.Error != "" {
+		return res, fmt.Errorf(count.Error)
+	}
+	for _, value := range count.MetricValues {
+		if value.Sample != nil {
+			res.TotalItems = int(value.Sample.Value())
+		}
+	}
+	res.TotalPages = (res.TotalItems + limit - 1) / limit
+
+	// topk returns everything down to the end of the requested page, the
+	// earlier pages are cheap to skip server-side
+	top := mo.prometheus.GetMetric(fmt.Sprintf("topk(%d, %s)", page*limit, expr), t)
+	if top.Error != "" {
+		return res, fmt.Errorf(top.Error)
+	}
+
+	consumers := make([]Consumer, 0, len(top.MetricValues))
+	for _, value := range top.MetricValues {
+		if value.Sample == nil {
+			continue
+		}
+		consumers = append(consumers, Consumer{
+			Namespace: value.Metadata["namespace"],
+			Pod:       value.Metadata["pod"],
+			Value:     value.Sample.Value(),
+		})
+	}
+	// topk gives no ordering guarantee across series
+	sort.Slice(consumers, func(i, j int) bool { return consumers[i].Value > consumers[j].Value })
+
+	start := (page - 1) * limit
+	if start > len(consumers) {
+		start = len(consumers)
+	}
+	end := start + limit
+	if end > len(consumers) {
+		end = len(consumers)
+	}
+	for i, consumer := range consumers[start:end] {
+		consumer.Rank = start + i + 1
+		res.Results = append(res.Results, consumer)
+	}
+	return res, nil
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const (
+	TransformRename        = "rename"
+	TransformMerge         = "merge"
+	TransformRatio         = "ratio"
+	TransformTopK          = "topk"
+	TransformMovingAverage = "movingAverage"
+
+	// LegendLabel carries a series' display name produced by a rename
+	// transformation
+	LegendLabel = "legend"
+)
+
+// Transformation is one step of a server-side pipeline applied to query
+// results, so derived views do not require impossible single PromQL
+// expressions. Steps run in order, each consuming the previous output.
+type Transformation struct {
+	// Type is one of rename, merge, ratio, topk, movingAverage
+	Type string `json:"type"`
+
+	// Legend renames series using {{label}} placeholders, e.g. "{{pod}} cpu"
+	Legend string `json:"legend,omitempty"`
+
+	// By merges series sharing the values of these labels, summing their
+	// points
+	By []string `json:"by,omitempty"`
+
+	// Numerator and Denominator are query indices for ratio, defaulting to
+	// the first and second query
+	Numerator   int `json:"numerator,omitempty"`
+	Denominator int `json:"denominator,omitempty"`
+
+	// K keeps the k series with the highest average for topk
+	K int `json:"k,omitempty"`
+
+	// Window is the trailing window size in points for movingAverage
+	Window int `json:"window,omitempty"`
+}
+
+// ApplyTransformations runs the pipeline over the results of one or more
+// queries. A ratio step replaces the result list with the single derived
+// metric; the other steps transform each result in place.
+func ApplyTransformations(metrics []monitoring.Metric, transformations []Transformation) ([]monitoring.Metric, error) {
+	var err error
+	for _, transformation := range transformations {
+		switch transformation.Type {
+		case TransformRename:
+			for i := range metrics {
+				renameSeries(&metrics[i], transformation.Legend)
+			}
+		case TransformMerge:
+			for i := range metrics {
+				mergeSeries(&metrics[i], transformation.By)
+			}
+		case TransformRatio:
+			if metrics, err = ratio(metrics, transformation); err != nil {
+				return nil, err
+			}
+		case TransformTopK:
+			if transformation.K <= 0 {
+				return nil, fmt.Errorf("topk needs a positive k")
+			}
+			for i := range metrics {
+				topK(&metrics[i], transformation.K)
+			}
+		case TransformMovingAverage:
+			if transformation.Window <= 1 {
+				return nil, fmt.Errorf("movingAverage needs a window of at least 2 points")
+			}
+			for i := range metrics {
+				movingAverage(&metrics[i], transformation.Window)
+			}
+		default:
+			return nil, fmt.Errorf("unknown transformation %q", transformation.Type)
+		}
+	}
+	return metrics, nil
+}
+
+func renameSeries(metric *monitoring.Metric, legend string) {
+	for i := range metric.MetricValues {
+		value := &metric.MetricValues[i]
+		rendered := legend
+		for label, labelValue := range value.Metadata {
+			rendered = strings.ReplaceAll(rendered, "{{"+label+"}}", labelValue)
+		}
+		if value.Metadata == nil {
+			value.Metadata = make(map[string]string)
+		}
+		value.Metadata[LegendLabel] = rendered
+	}
+}
+
+func mergeSeries(metric *monitoring.Metric, by []string) {
+	groups := make(map[string]*monitoring.MetricValue)
+	var order []string
+	for _, value := range metric.MetricValues {
+		var parts []string
+		metadata := make(map[string]string)
+		for _, label := range by {
+			parts = append(parts, value.Metadata[label])
+			if v, ok := value.Metadata[label]; ok {
+				metadata[label] = v
+			}
+		}
+		key := strings.Join(parts, "|")
+		group, ok := groups[key]
+		if !ok {
+			group = &monitoring.MetricValue{Metadata: metadata}
+			groups[key] = group
+			order = append(order, key)
+		}
+		addPoints(group, value)
+	}
+
+	merged := make([]monitoring.MetricValue, 0, len(order))
+	for _, key := range order {
+		merged = append(merged, *groups[key])
+	}
+	metric.MetricValues = merged
+}
+
+// addPoints sums the series' points into the group, aligning matrix points
+// on their timestamps.
+func addPoints(group *monitoring.MetricValue, value monitoring.MetricValue) {
+	if value.Sample != nil {
+		if group.Sample == nil {
+			sample := *value.Sample
+			group.Sample = &sample
+		} else {
+			group.Sample[1] += value.Sample[1]
+		}
+		return
+	}
+
+	sums := make(map[float64]float64)
+	for _, point := range group.Series {
+		sums[point[0]] += point[1]
+	}
+	for _, point := range value.Series {
+		sums[point[0]] += point[1]
+	}
+	group.Series = sortedPoints(sums)
+}
+
+func sortedPoints(byTimestamp map[float64]float64) []monitoring.Point {
+	timestamps := make([]float64, 0, len(byTimestamp))
+	for timestamp := range byTimestamp {
+		timestamps = append(timestamps, timestamp)
+	}
+	sort.Float64s(timestamps)
+	points := make([]monitoring.Point, 0, len(timestamps))
+	for _, timestamp := range timestamps {
+		points = append(points, monitoring.Point{timestamp, byTimestamp[timestamp]})
+	}
+	return points
+}
+
+func ratio(metrics []monitoring.Metric, transformation Transformation) ([]monitoring.Metric, error) {
+	numerator, denominator := transformation.Numerator, transformation.Denominator
+	if numerator == 0 && denominator == 0 {
+		denominator = 1
+	}
+	if numerator < 0 || numerator >= len(metrics) || denominator < 0 || denominator >= len(metrics) {
+		return nil, fmt.Errorf("ratio references query %d and %d but only %d queries were evaluated",
+			numerator, denominator, len(metrics))
+	}
+
+	result := monitoring.Metric{MetricName: "ratio"}
+	result.MetricType = metrics[numerator].MetricType
+	for _, value := range metrics[numerator].MetricValues {
+		match, ok := matchSeries(value, metrics[denominator].MetricValues)
+		if !ok {
+			continue
+		}
+		result.MetricValues = append(result.MetricValues, divideSeries(value, match))
+	}
+	return []monitoring.Metric{result}, nil
+}
+
+// matchSeries finds the candidate agreeing with the series on every label
+// they share.
+func matchSeries(value monitoring.MetricValue, candidates []monitoring.MetricValue) (monitoring.MetricValue, bool) {
+	for _, candidate := range candidates {
+		matches := true
+		for label, labelValue := range value.Metadata {
+			if other, ok := candidate.Metadata[label]; ok && other != labelValue {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return candidate, true
+		}
+	}
+	return monitoring.MetricValue{}, false
+}
+
+func divideSeries(numerator, denominator monitoring.MetricValue) monitoring.MetricValue {
+	result := monitoring.MetricValue{Metadata: numerator.Metadata}
+	if numerator.Sample != nil && denominator.Sample != nil && denominator.Sample[1] != 0 {
+		result.Sample = &monitoring.Point{numerator.Sample[0], numerator.Sample[1] / denominator.Sample[1]}
+		return result
+	}
+
+	divisors := make(map[float64]float64)
+	for _, point := range denominator.Series {
+		divisors[point[0]] = point[1]
+	}
+	for _, point := range numerator.Series {
+		if divisor, ok := divisors[point[0]]; ok && divisor != 0 {
+			result.Series = append(result.Series, monitoring.Point{point[0], point[1] / divisor})
+		}
+	}
+	return result
+}
+
+func topK(metric *monitoring.Metric, k int) {
+	if len(metric.MetricValues) <= k {
+		return
+	}
+	sort.SliceStable(metric.MetricValues, func(i, j int) bool {
+		return seriesMean(metric.MetricValues[i]) > seriesMean(metric.MetricValues[j])
+	})
+	metric.MetricValues = metric.MetricValues[:k]
+}
+
+func seriesMean(value monitoring.MetricValue) float64 {
+	if value.Sample != nil {
+		return value.Sample[1]
+	}
+	if len(value.Series) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, point := range value.Series {
+		sum += point[1]
+	}
+	return sum / float64(len(value.Series))
+}
+
+func movingAverage(metric *monitoring.Metric, window int) {
+	for i := range metric.MetricValues {
+		series := metric.MetricValues[i].Series
+		smoothed := make([]monitoring.Point, len(series))
+		var sum float64
+		for j, point := range series {
+			sum += point[1]
+			if j >= window {
+				sum -= series[j-window][1]
+			}
+			width := j + 1
+			if width > window {
+				width = window
+			}
+			smoothed[j] = monitoring.Point{point[0], sum / float64(width)}
+		}
+		metric.MetricValues[i].Series = smoothed
+	}
+}
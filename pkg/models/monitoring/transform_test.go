@@ -0,0 +1,147 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+func matrix(values ...monitoring.MetricValue) monitoring.Metric {
+	m := monitoring.Metric{}
+	m.MetricType = monitoring.MetricTypeMatrix
+	m.MetricValues = values
+	return m
+}
+
+func TestApplyTransformations(t *testing.T) {
+	tests := []struct {
+		name            string
+		metrics         []monitoring.Metric
+		transformations []Transformation
+		expected        []monitoring.Metric
+		expectedErr     bool
+	}{
+		{
+			name: "rename",
+			metrics: []monitoring.Metric{matrix(monitoring.MetricValue{
+				Metadata: map[string]string{"pod": "mypod"},
+			})},
+			transformations: []Transformation{{Type: TransformRename, Legend: "{{pod}} cpu"}},
+			expected: []monitoring.Metric{matrix(monitoring.MetricValue{
+				Metadata: map[string]string{"pod": "mypod", "legend": "mypod cpu"},
+			})},
+		},
+		{
+			name: "merge by label",
+			metrics: []monitoring.Metric{matrix(
+				monitoring.MetricValue{
+					Metadata: map[string]string{"node": "node1", "pod": "a"},
+					Series:   []monitoring.Point{{1, 1}, {2, 2}},
+				},
+				monitoring.MetricValue{
+					Metadata: map[string]string{"node": "node1", "pod": "b"},
+					Series:   []monitoring.Point{{1, 3}, {2, 4}},
+				},
+			)},
+			transformations: []Transformation{{Type: TransformMerge, By: []string{"node"}}},
+			expected: []monitoring.Metric{matrix(monitoring.MetricValue{
+				Metadata: map[string]string{"node": "node1"},
+				Series:   []monitoring.Point{{1, 4}, {2, 6}},
+			})},
+		},
+		{
+			name: "ratio of two queries",
+			metrics: []monitoring.Metric{
+				matrix(monitoring.MetricValue{
+					Metadata: map[string]string{"pod": "a"},
+					Series:   []monitoring.Point{{1, 2}, {2, 6}},
+				}),
+				matrix(monitoring.MetricValue{
+					Metadata: map[string]string{"pod": "a"},
+					Series:   []monitoring.Point{{1, 4}, {2, 12}},
+				}),
+			},
+			transformations: []Transformation{{Type: TransformRatio}},
+			expected: []monitoring.Metric{{
+				MetricName: "ratio",
+				MetricData: monitoring.MetricData{
+					MetricType: monitoring.MetricTypeMatrix,
+					MetricValues: []monitoring.MetricValue{{
+						Metadata: map[string]string{"pod": "a"},
+						Series:   []monitoring.Point{{1, 0.5}, {2, 0.5}},
+					}},
+				},
+			}},
+		},
+		{
+			name: "topk",
+			metrics: []monitoring.Metric{matrix(
+				monitoring.MetricValue{
+					Metadata: map[string]string{"pod": "low"},
+					Series:   []monitoring.Point{{1, 1}},
+				},
+				monitoring.MetricValue{
+					Metadata: map[string]string{"pod": "high"},
+					Series:   []monitoring.Point{{1, 10}},
+				},
+			)},
+			transformations: []Transformation{{Type: TransformTopK, K: 1}},
+			expected: []monitoring.Metric{matrix(monitoring.MetricValue{
+				Metadata: map[string]string{"pod": "high"},
+				Series:   []monitoring.Point{{1, 10}},
+			})},
+		},
+		{
+			name: "moving average",
+			metrics: []monitoring.Metric{matrix(monitoring.MetricValue{
+				Series: []monitoring.Point{{1, 2}, {2, 4}, {3, 6}},
+			})},
+			transformations: []Transformation{{Type: TransformMovingAverage, Window: 2}},
+			expected: []monitoring.Metric{matrix(monitoring.MetricValue{
+				Series: []monitoring.Point{{1, 2}, {2, 3}, {3, 5}},
+			})},
+		},
+		{
+			name:            "unknown transformation",
+			metrics:         []monitoring.Metric{matrix()},
+			transformations: []Transformation{{Type: "bogus"}},
+			expectedErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ApplyTransformations(tt.metrics, tt.transformations)
+			if err != nil {
+				if !tt.expectedErr {
+					t.Fatal(err)
+				}
+				return
+			}
+			if tt.expectedErr {
+				t.Fatal("expected an error")
+			}
+			if diff := cmp.Diff(result, tt.expected); diff != "" {
+				t.Fatalf("%T differ (-got, +want): %s", tt.expected, diff)
+			}
+		})
+	}
+}
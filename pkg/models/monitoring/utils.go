@@ -75,7 +75,9 @@ type PriceInfo struct {
 	IngressNetworkTrafficPerGiagabytesPerHour float64 `json:"ingressNetworkTrafficPerGiagabytesPerHour" yaml:"ingressNetworkTrafficPerGiagabytesPerHour"`
 	EgressNetworkTrafficPerGigabytesPerHour   float64 `json:"egressNetworkTrafficPerGigabytesPerHour" yaml:"egressNetworkTrafficPerGigabytesPerHour"`
 	PvcPerGigabytesPerHour                    float64 `json:"pvcPerGigabytesPerHour" yaml:"pvcPerGigabytesPerHour"`
-	CurrencyUnit                              string  `json:"currencyUnit" yaml:"currencyUnit"`
+	// per storage class price overriding PvcPerGigabytesPerHour, keyed by storage class name
+	PvcPerGigabytesPerHourByStorageClass map[string]float64 `json:"pvcPerGigabytesPerHourByStorageClass,omitempty" yaml:"pvcPerGigabytesPerHourByStorageClass"`
+	CurrencyUnit                         string             `json:"currencyUnit" yaml:"currencyUnit"`
 }
 
 type Billing struct {
@@ -84,12 +86,23 @@ type Billing struct {
 
 type MeterConfig struct {
 	Billing Billing `json:"billing" yaml:"billing"`
+	// per cluster price sheets overriding the default billing section, keyed by cluster name
+	Clusters map[string]Billing `json:"clusters,omitempty" yaml:"clusters"`
 }
 
 func (mc MeterConfig) GetPriceInfo() PriceInfo {
 	return mc.Billing.PriceInfo
 }
 
+// GetClusterPriceInfo returns the price sheet of the cluster, falling back to
+// the default one when the cluster has no dedicated price sheet.
+func (mc MeterConfig) GetClusterPriceInfo(cluster string) PriceInfo {
+	if billing, ok := mc.Clusters[cluster]; ok {
+		return billing.PriceInfo
+	}
+	return mc.Billing.PriceInfo
+}
+
 func LoadYaml() (*MeterConfig, error) {
 
 	var meterConfig MeterConfig
@@ -171,14 +184,14 @@ func getResourceUnit(meterName string) string {
 	}
 }
 
-func getFeeWithMeterName(meterName string, sum float64) float64 {
+func getFeeWithMeterName(meterName string, sum float64, metadata map[string]string) float64 {
 
 	meterConfig, err := LoadYaml()
 	if err != nil {
 		klog.Error(err)
 		return -1
 	}
-	priceInfo := meterConfig.GetPriceInfo()
+	priceInfo := meterConfig.GetClusterPriceInfo(metadata["cluster"])
 
 	if resourceType, ok := MeterResourceMap[meterName]; !ok {
 		klog.Errorf("invlaid meter %v", meterName)
@@ -204,6 +217,9 @@ func getFeeWithMeterName(meterName string, sum float64) float64 {
 		case METER_RESOURCE_TYPE_PVC:
 			// unit: Gigabyte, precision: 0.1
 			sum = math.Round(sum/1073741824*10) / 10
+			if price, ok := priceInfo.PvcPerGigabytesPerHourByStorageClass[metadata["storageclass"]]; ok {
+				return price * sum
+			}
 			return priceInfo.PvcPerGigabytesPerHour * sum
 		}
 
@@ -235,14 +251,14 @@ func updateMetricStatData(metric monitoring.Metric, scalingMap map[string]float6
 			metricData.MetricValues[index].MaxValue = sample[1]
 			metricData.MetricValues[index].AvgValue = sample[1]
 			metricData.MetricValues[index].SumValue = sum
-			metricData.MetricValues[index].Fee = getFeeWithMeterName(metricName, sum)
+			metricData.MetricValues[index].Fee = getFeeWithMeterName(metricName, sum, metricValue.Metadata)
 		} else {
 			sum := getSumPointValue(points) * factor
 			metricData.MetricValues[index].MinValue = getMinPointValue(points)
 			metricData.MetricValues[index].MaxValue = getMaxPointValue(points)
 			metricData.MetricValues[index].AvgValue = getAvgPointValue(points)
 			metricData.MetricValues[index].SumValue = sum
-			metricData.MetricValues[index].Fee = getFeeWithMeterName(metricName, sum)
+			metricData.MetricValues[index].Fee = getFeeWithMeterName(metricName, sum, metricValue.Metadata)
 		}
 		metricData.MetricValues[index].CurrencyUnit = getCurrencyUnit()
 		metricData.MetricValues[index].ResourceUnit = getResourceUnit(metricName)
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodegroup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	nodev1alpha1 "kubesphere.io/kubesphere/pkg/apis/node/v1alpha1"
+)
+
+const (
+	// ComplianceStateCompliant means the node agent applied the current
+	// revision of the group's profile
+	ComplianceStateCompliant = "compliant"
+	// ComplianceStateDrifted means the node reports an older revision,
+	// a different profile or a failed apply
+	ComplianceStateDrifted = "drifted"
+	// ComplianceStateUnapplied means the node agent has not reported yet
+	ComplianceStateUnapplied = "unapplied"
+)
+
+// NodeCompliance is the profile state of a single node of a node group.
+type NodeCompliance struct {
+	Node     string `json:"node"`
+	Group    string `json:"group"`
+	Profile  string `json:"profile,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Applied  string `json:"applied,omitempty"`
+	State    string `json:"state"`
+	Message  string `json:"message,omitempty"`
+}
+
+// ListNodeCompliance compares the profile revision the node agent reported
+// on each grouped node against the current generation of the group's
+// NodeProfile.
+func (o *operator) ListNodeCompliance() ([]NodeCompliance, error) {
+	nodes, err := o.informers.Core().V1().Nodes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := &nodev1alpha1.NodeProfileList{}
+	if err := o.cache.List(context.Background(), profiles); err != nil {
+		return nil, err
+	}
+	expected := map[string]*nodev1alpha1.NodeProfile{}
+	for i := range profiles.Items {
+		expected[profiles.Items[i].Spec.NodeGroup] = &profiles.Items[i]
+	}
+
+	result := make([]NodeCompliance, 0)
+	for _, node := range nodes {
+		group := node.Labels[GroupLabel]
+		if group == "" {
+			continue
+		}
+		profile, ok := expected[group]
+		if !ok {
+			continue
+		}
+
+		compliance := NodeCompliance{
+			Node:     node.Name,
+			Group:    group,
+			Profile:  profile.Name,
+			Expected: fmt.Sprintf("%s/%d", profile.Name, profile.Generation),
+			Applied:  node.Annotations[nodev1alpha1.AppliedProfileAnnotation],
+		}
+		status := node.Annotations[nodev1alpha1.AppliedStatusAnnotation]
+		switch {
+		case compliance.Applied == "":
+			compliance.State = ComplianceStateUnapplied
+		case compliance.Applied == compliance.Expected && status == nodev1alpha1.StatusApplied:
+			compliance.State = ComplianceStateCompliant
+		default:
+			compliance.State = ComplianceStateDrifted
+			if status != nodev1alpha1.StatusApplied {
+				compliance.Message = status
+			}
+		}
+		result = append(result, compliance)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Node < result[j].Node })
+	return result, nil
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nodegroup manages dedicated node groups: a pool of nodes labeled
+// and tainted for exclusive use by the workspaces bound to it.
+package nodegroup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+
+	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+)
+
+const (
+	// GroupLabel assigns a node to a node group and is used as the
+	// nodeSelector injected into bound workloads
+	GroupLabel = "node-group.kubesphere.io/name"
+
+	// TaintKey keeps workloads of unbound workspaces off the dedicated
+	// nodes, the taint value is the group name
+	TaintKey = "node-group.kubesphere.io/dedicated"
+)
+
+// Capacity aggregates the allocatable resources of a node group.
+type Capacity struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+	Pods   string `json:"pods"`
+}
+
+// NodeGroup is a dedicated node pool with the workspaces bound to it.
+type NodeGroup struct {
+	Name       string   `json:"name"`
+	Nodes      []string `json:"nodes"`
+	Workspaces []string `json:"workspaces"`
+	Capacity   Capacity `json:"capacity"`
+}
+
+type Operator interface {
+	// ListNodeGroups lists the node groups with member nodes, bound
+	// workspaces and aggregated allocatable capacity
+	ListNodeGroups() ([]NodeGroup, error)
+
+	// AddNode labels and taints the node for the group
+	AddNode(group, node string) error
+
+	// RemoveNode removes the group label and taint from the node
+	RemoveNode(group, node string) error
+
+	// BindWorkspace dedicates the group to the workspace, the admission
+	// layer schedules the workspace's workloads onto the group's nodes
+	BindWorkspace(group, workspace string) error
+
+	// UnbindWorkspace releases the workspace from the group
+	UnbindWorkspace(group, workspace string) error
+
+	// ListNodeCompliance reports per node whether the current revision of
+	// the group's NodeProfile has been applied by the node agent
+	ListNodeCompliance() ([]NodeCompliance, error)
+}
+
+type operator struct {
+	client    kubernetes.Interface
+	ksClient  kubesphere.Interface
+	informers informers.SharedInformerFactory
+	cache     runtimecache.Cache
+}
+
+func NewOperator(client kubernetes.Interface, ksClient kubesphere.Interface, informers informers.SharedInformerFactory, cache runtimecache.Cache) Operator {
+	return &operator{client: client, ksClient: ksClient, informers: informers, cache: cache}
+}
+
+func (o *operator) ListNodeGroups() ([]NodeGroup, error) {
+	nodes, err := o.informers.Core().V1().Nodes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string]*NodeGroup{}
+	capacities := map[string]corev1.ResourceList{}
+	for _, node := range nodes {
+		name := node.Labels[GroupLabel]
+		if name == "" {
+			continue
+		}
+		group, ok := groups[name]
+		if !ok {
+			group = &NodeGroup{Name: name}
+			groups[name] = group
+			capacities[name] = corev1.ResourceList{}
+		}
+		group.Nodes = append(group.Nodes, node.Name)
+		addResources(capacities[name], node.Status.Allocatable)
+	}
+
+	workspaces, err := o.ksClient.TenantV1alpha1().Workspaces().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, workspace := range workspaces.Items {
+		if name := workspace.Labels[GroupLabel]; name != "" {
+			if group, ok := groups[name]; ok {
+				group.Workspaces = append(group.Workspaces, workspace.Name)
+			}
+		}
+	}
+
+	result := make([]NodeGroup, 0, len(groups))
+	for name, group := range groups {
+		sort.Strings(group.Nodes)
+		sort.Strings(group.Workspaces)
+		group.Capacity = renderCapacity(capacities[name])
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func (o *operator) AddNode(group, node string) error {
+	current, err := o.client.CoreV1().Nodes().Get(context.Background(), node, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if current.Labels[GroupLabel] != "" && current.Labels[GroupLabel] != group {
+		return fmt.Errorf("node %s already belongs to node group %s", node, current.Labels[GroupLabel])
+	}
+	if current.Labels == nil {
+		current.Labels = map[string]string{}
+	}
+	current.Labels[GroupLabel] = group
+	if !hasTaint(current.Spec.Taints, group) {
+		current.Spec.Taints = append(current.Spec.Taints, corev1.Taint{
+			Key:    TaintKey,
+			Value:  group,
+			Effect: corev1.TaintEffectNoSchedule,
+		})
+	}
+	_, err = o.client.CoreV1().Nodes().Update(context.Background(), current, metav1.UpdateOptions{})
+	return err
+}
+
+func (o *operator) RemoveNode(group, node string) error {
+	current, err := o.client.CoreV1().Nodes().Get(context.Background(), node, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if current.Labels[GroupLabel] != group {
+		return nil
+	}
+	delete(current.Labels, GroupLabel)
+	taints := current.Spec.Taints[:0]
+	for _, taint := range current.Spec.Taints {
+		if taint.Key != TaintKey {
+			taints = append(taints, taint)
+		}
+	}
+	current.Spec.Taints = taints
+	_, err = o.client.CoreV1().Nodes().Update(context.Background(), current, metav1.UpdateOptions{})
+	return err
+}
+
+func (o *operator) BindWorkspace(group, workspace string) error {
+	current, err := o.ksClient.TenantV1alpha1().Workspaces().Get(context.Background(), workspace, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if current.Labels[GroupLabel] == group {
+		return nil
+	}
+	if current.Labels == nil {
+		current.Labels = map[string]string{}
+	}
+	current.Labels[GroupLabel] = group
+	_, err = o.ksClient.TenantV1alpha1().Workspaces().Update(context.Background(), current, metav1.UpdateOptions{})
+	return err
+}
+
+func (o *operator) UnbindWorkspace(group, workspace string) error {
+	current, err := o.ksClient.TenantV1alpha1().Workspaces().Get(context.Background(), workspace, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if current.Labels[GroupLabel] != group {
+		return nil
+	}
+	delete(current.Labels, GroupLabel)
+	_, err = o.ksClient.TenantV1alpha1().Workspaces().Update(context.Background(), current, metav1.UpdateOptions{})
+	return err
+}
+
+func addResources(total, allocatable corev1.ResourceList) {
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourcePods} {
+		quantity := total[name]
+		quantity.Add(allocatable[name])
+		total[name] = quantity
+	}
+}
+
+func renderCapacity(total corev1.ResourceList) Capacity {
+	cpu := total[corev1.ResourceCPU]
+	memory := total[corev1.ResourceMemory]
+	pods := total[corev1.ResourcePods]
+	return Capacity{
+		CPU:    cpu.String(),
+		Memory: memory.String(),
+		Pods:   pods.String(),
+	}
+}
+
+func hasTaint(taints []corev1.Taint, group string) bool {
+	for _, taint := range taints {
+		if taint.Key == TaintKey && taint.Value == group {
+			return true
+		}
+	}
+	return false
+}
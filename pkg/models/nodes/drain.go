@@ -0,0 +1,376 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nodes provides node maintenance operations: cordon, PDB-aware
+// drain with progress reporting and automatic uncordon, and scheduled drain
+// windows, so routine maintenance does not require SSH or kubectl.
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+)
+
+const (
+	// DefaultDrainTimeoutSeconds bounds a drain, pods still pending when it
+	// expires leave the drain in the failed phase with the node cordoned
+	DefaultDrainTimeoutSeconds = 300
+
+	evictionRetryInterval = 5 * time.Second
+
+	mirrorPodAnnotation = "kubernetes.io/config.mirror"
+)
+
+// Drain phases.
+const (
+	DrainPhaseDraining = "draining"
+	DrainPhaseDrained  = "drained"
+	DrainPhaseFailed   = "failed"
+)
+
+// DrainOptions controls a drain operation.
+type DrainOptions struct {
+	// pod termination grace period, nil keeps the pods' own values
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"`
+
+	// seconds before a pending drain is marked failed, defaults to 300
+	TimeoutSeconds int64 `json:"timeoutSeconds,omitempty"`
+
+	// evict pods that are not managed by a controller, they are not
+	// rescheduled anywhere and refuse to drain without this
+	Force bool `json:"force,omitempty"`
+
+	// uncordon the node automatically once the drain completes, for
+	// maintenance that ends with the node staying in the cluster
+	Uncordon bool `json:"uncordon,omitempty"`
+}
+
+// PodEviction is the progress of a single pod during a drain.
+type PodEviction struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// pending, evicted or failed
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// DrainStatus reports the progress of a drain, polled while it runs.
+type DrainStatus struct {
+	Node           string        `json:"node"`
+	Phase          string        `json:"phase"`
+	StartTime      metav1.Time   `json:"startTime"`
+	CompletionTime *metav1.Time  `json:"completionTime,omitempty"`
+	Evicted        int           `json:"evicted"`
+	Pending        int           `json:"pending"`
+	Pods           []PodEviction `json:"pods,omitempty"`
+	Message        string        `json:"message,omitempty"`
+}
+
+// ScheduledDrain is a drain registered to start at a later time, typically
+// the start of a maintenance window.
+type ScheduledDrain struct {
+	Node      string       `json:"node"`
+	StartTime metav1.Time  `json:"startTime"`
+	Options   DrainOptions `json:"options,omitempty"`
+}
+
+type Operator interface {
+	// Cordon marks the node unschedulable
+	Cordon(node string) error
+
+	// Uncordon marks the node schedulable again
+	Uncordon(node string) error
+
+	// Drain cordons the node and evicts its pods asynchronously through
+	// the eviction API, respecting pod disruption budgets, progress is
+	// available through DrainStatus
+	Drain(node string, options DrainOptions) error
+
+	// DrainStatus returns the progress of the latest drain of the node
+	DrainStatus(node string) (*DrainStatus, error)
+
+	// ScheduleDrain registers a drain to start at the given time
+	ScheduleDrain(node string, startTime time.Time, options DrainOptions) error
+
+	// CancelScheduledDrain removes a pending scheduled drain
+	CancelScheduledDrain(node string) error
+
+	// ListScheduledDrains lists drains waiting for their window
+	ListScheduledDrains() []ScheduledDrain
+}
+
+type operator struct {
+	client kubernetes.Interface
+
+	mutex     sync.Mutex
+	drains    map[string]*DrainStatus
+	scheduled map[string]*scheduledDrain
+}
+
+type scheduledDrain struct {
+	ScheduledDrain
+	timer *time.Timer
+}
+
+func NewOperator(client kubernetes.Interface) Operator {
+	return &operator{
+		client:    client,
+		drains:    map[string]*DrainStatus{},
+		scheduled: map[string]*scheduledDrain{},
+	}
+}
+
+func (o *operator) Cordon(node string) error {
+	return o.setUnschedulable(node, true)
+}
+
+func (o *operator) Uncordon(node string) error {
+	return o.setUnschedulable(node, false)
+}
+
+func (o *operator) setUnschedulable(node string, unschedulable bool) error {
+	current, err := o.client.CoreV1().Nodes().Get(context.Background(), node, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if current.Spec.Unschedulable == unschedulable {
+		return nil
+	}
+	current.Spec.Unschedulable = unschedulable
+	_, err = o.client.CoreV1().Nodes().Update(context.Background(), current, metav1.UpdateOptions{})
+	return err
+}
+
+func (o *operator) Drain(node string, options DrainOptions) error {
+	if _, err := o.client.CoreV1().Nodes().Get(context.Background(), node, metav1.GetOptions{}); err != nil {
+		return err
+	}
+
+	o.mutex.Lock()
+	if status, ok := o.drains[node]; ok && status.Phase == DrainPhaseDraining {
+		o.mutex.Unlock()
+		return fmt.Errorf("node %s is already being drained", node)
+	}
+	status := &DrainStatus{
+		Node:      node,
+		Phase:     DrainPhaseDraining,
+		StartTime: metav1.Now(),
+	}
+	o.drains[node] = status
+	o.mutex.Unlock()
+
+	if err := o.Cordon(node); err != nil {
+		o.finishDrain(status, DrainPhaseFailed, err.Error())
+		return err
+	}
+
+	go o.drain(node, options, status)
+	return nil
+}
+
+func (o *operator) DrainStatus(node string) (*DrainStatus, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	status, ok := o.drains[node]
+	if !ok {
+		return nil, fmt.Errorf("no drain recorded for node %s", node)
+	}
+	copied := *status
+	copied.Pods = append([]PodEviction(nil), status.Pods...)
+	return &copied, nil
+}
+
+func (o *operator) ScheduleDrain(node string, startTime time.Time, options DrainOptions) error {
+	if _, err := o.client.CoreV1().Nodes().Get(context.Background(), node, metav1.GetOptions{}); err != nil {
+		return err
+	}
+	delay := time.Until(startTime)
+	if delay <= 0 {
+		return fmt.Errorf("drain window start %s is in the past", startTime.Format(time.RFC3339))
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if existing, ok := o.scheduled[node]; ok {
+		existing.timer.Stop()
+	}
+	scheduled := &scheduledDrain{
+		ScheduledDrain: ScheduledDrain{
+			Node:      node,
+			StartTime: metav1.NewTime(startTime),
+			Options:   options,
+		},
+	}
+	scheduled.timer = time.AfterFunc(delay, func() {
+		o.mutex.Lock()
+		delete(o.scheduled, node)
+		o.mutex.Unlock()
+		if err := o.Drain(node, options); err != nil {
+			klog.Errorf("scheduled drain of node %s failed to start: %v", node, err)
+		}
+	})
+	o.scheduled[node] = scheduled
+	return nil
+}
+
+func (o *operator) CancelScheduledDrain(node string) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	scheduled, ok := o.scheduled[node]
+	if !ok {
+		return fmt.Errorf("no scheduled drain for node %s", node)
+	}
+	scheduled.timer.Stop()
+	delete(o.scheduled, node)
+	return nil
+}
+
+func (o *operator) ListScheduledDrains() []ScheduledDrain {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	result := make([]ScheduledDrain, 0, len(o.scheduled))
+	for _, scheduled := range o.scheduled {
+		result = append(result, scheduled.ScheduledDrain)
+	}
+	return result
+}
+
+// drain evicts the evictable pods of the node, retrying evictions rejected
+// by a pod disruption budget until the timeout expires
+func (o *operator) drain(node string, options DrainOptions, status *DrainStatus) {
+	timeout := time.Duration(options.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeoutSeconds * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+
+	pods, err := o.evictablePods(node, options.Force)
+	if err != nil {
+		o.finishDrain(status, DrainPhaseFailed, err.Error())
+		return
+	}
+
+	o.mutex.Lock()
+	for _, pod := range pods {
+		status.Pods = append(status.Pods, PodEviction{Namespace: pod.Namespace, Name: pod.Name, Status: "pending"})
+	}
+	status.Pending = len(pods)
+	o.mutex.Unlock()
+
+	for i, pod := range pods {
+		if err := o.evictWithRetry(pod, options.GracePeriodSeconds, deadline); err != nil {
+			o.updatePod(status, i, "failed", err.Error())
+			o.finishDrain(status, DrainPhaseFailed,
+				fmt.Sprintf("failed to evict pod %s/%s: %v", pod.Namespace, pod.Name, err))
+			return
+		}
+		o.updatePod(status, i, "evicted", "")
+	}
+
+	o.finishDrain(status, DrainPhaseDrained, "")
+	klog.Infof("node %s drained, %d pods evicted", node, len(pods))
+
+	if options.Uncordon {
+		if err := o.Uncordon(node); err != nil {
+			klog.Errorf("failed to uncordon node %s after drain: %v", node, err)
+		}
+	}
+}
+
+// evictablePods lists the pods of the node that a drain must evict, mirror
+// pods and daemonset pods are left alone, unmanaged pods require force
+func (o *operator) evictablePods(node string, force bool) ([]corev1.Pod, error) {
+	podList, err := o.client.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("spec.nodeName=%s", node),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+			continue
+		}
+		controller := metav1.GetControllerOf(&pod)
+		if controller != nil && controller.Kind == "DaemonSet" {
+			continue
+		}
+		if controller == nil && !force {
+			return nil, fmt.Errorf("pod %s/%s is not managed by a controller and would not be rescheduled, retry with force to evict it", pod.Namespace, pod.Name)
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+func (o *operator) evictWithRetry(pod corev1.Pod, gracePeriod *int64, deadline time.Time) error {
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+		},
+	}
+	if gracePeriod != nil {
+		eviction.DeleteOptions = &metav1.DeleteOptions{GracePeriodSeconds: gracePeriod}
+	}
+	for {
+		err := o.client.PolicyV1beta1().Evictions(pod.Namespace).Evict(context.Background(), eviction)
+		if err == nil || apierrors.IsNotFound(err) {
+			return nil
+		}
+		// 429 means a pod disruption budget currently forbids the
+		// eviction, retry until the deadline
+		if !apierrors.IsTooManyRequests(err) {
+			return err
+		}
+		if time.Now().Add(evictionRetryInterval).After(deadline) {
+			return fmt.Errorf("pod disruption budget blocked the eviction until the drain timeout: %v", err)
+		}
+		time.Sleep(evictionRetryInterval)
+	}
+}
+
+func (o *operator) updatePod(status *DrainStatus, index int, state, message string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	status.Pods[index].Status = state
+	status.Pods[index].Message = message
+	if state == "evicted" {
+		status.Evicted++
+		status.Pending--
+	}
+}
+
+func (o *operator) finishDrain(status *DrainStatus, phase, message string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	now := metav1.Now()
+	status.Phase = phase
+	status.Message = message
+	status.CompletionTime = &now
+}
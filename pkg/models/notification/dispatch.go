@@ -0,0 +1,364 @@
+/*
+
+  Copyright 2021 The KubeSphere Authors.
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/utils/idutils"
+)
+
+const (
+	// DeliveryQueueConfigMapName persists the in-flight notification
+	// deliveries, so a restart or an unreachable endpoint does not lose
+	// them; the dispatch controller drives the retries
+	DeliveryQueueConfigMapName = "notification-delivery-queue"
+	deliveryQueueDataKey       = "deliveries"
+
+	// DeadLetterConfigMapName keeps the deliveries that exhausted their
+	// attempts, available for inspection and manual retry
+	DeadLetterConfigMapName = "notification-dead-letters"
+
+	// delivery states
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+
+	// maxDeliveryAttempts is how often a delivery is tried before it
+	// becomes a dead letter
+	maxDeliveryAttempts = 5
+
+	// retryBaseDelay is the delay before the second attempt, it doubles
+	// with every further attempt
+	retryBaseDelay = 30 * time.Second
+
+	// deadLetterLimit caps the dead letters kept, oldest go first
+	deadLetterLimit = 100
+
+	// deliveredRetention is how long delivered entries stay queryable
+	// before they are pruned from the queue
+	deliveredRetention = time.Hour
+)
+
+// Delivery is one notification payload on its way to an endpoint, tracked
+// until it is delivered or becomes a dead letter.
+type Delivery struct {
+	ID       string `json:"id"`
+	Endpoint string `json:"endpoint"`
+
+	// Payload is the JSON body posted to the endpoint
+	Payload json.RawMessage `json:"payload"`
+
+	// State is pending, delivered or dead
+	State string `json:"state"`
+
+	// Attempts counts the posts tried so far
+	Attempts int `json:"attempts"`
+
+	// LastError is what the most recent failed attempt returned
+	LastError string `json:"lastError,omitempty"`
+
+	CreatedAt   time.Time `json:"createdAt"`
+	LastAttempt time.Time `json:"lastAttempt,omitempty"`
+
+	// NextAttempt is when the next retry is due, empty once the delivery
+	// left the pending state
+	NextAttempt time.Time `json:"nextAttempt,omitempty"`
+}
+
+type DispatchOperator interface {
+	// Enqueue persists the payload as a pending delivery and tries it
+	// once right away, later retries are driven by the controller
+	Enqueue(endpoint string, payload interface{}) (*Delivery, error)
+	// ListDeliveries lists the queued deliveries and dead letters, state
+	// narrows the list to one state
+	ListDeliveries(state string) ([]Delivery, error)
+	GetDelivery(id string) (*Delivery, error)
+	// RetryDelivery puts a dead letter back into the queue with a fresh
+	// attempt budget
+	RetryDelivery(id string) (*Delivery, error)
+}
+
+type dispatchOperator struct {
+	client     kubernetes.Interface
+	httpClient *http.Client
+}
+
+func NewDispatchOperator(client kubernetes.Interface) DispatchOperator {
+	return &dispatchOperator{
+		client:     client,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// UnmarshalDeliveries reads deliveries out of a queue or dead-letter
+// ConfigMap, for the API and the dispatch controller.
+func UnmarshalDeliveries(cm *corev1.ConfigMap) ([]Delivery, error) {
+	data, ok := cm.Data[deliveryQueueDataKey]
+	if !ok || data == "" {
+		return []Delivery{}, nil
+	}
+	var deliveries []Delivery
+	if err := json.Unmarshal([]byte(data), &deliveries); err != nil {
+		return nil, fmt.Errorf("configmap %s holds invalid deliveries: %v", cm.Name, err)
+	}
+	return deliveries, nil
+}
+
+// MarshalDeliveries renders deliveries into the data of their ConfigMap.
+func MarshalDeliveries(deliveries []Delivery) (map[string]string, error) {
+	data, err := json.Marshal(deliveries)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{deliveryQueueDataKey: string(data)}, nil
+}
+
+// AttemptDelivery posts the delivery once and moves its state forward:
+// delivered on success, pending with a backed-off next attempt on failure,
+// dead once the attempts are exhausted.
+func AttemptDelivery(httpClient *http.Client, delivery *Delivery) {
+	delivery.Attempts++
+	delivery.LastAttempt = time.Now()
+
+	err := post(httpClient, delivery.Endpoint, delivery.Payload)
+	if err == nil {
+		delivery.State = DeliveryDelivered
+		delivery.LastError = ""
+		delivery.NextAttempt = time.Time{}
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= maxDeliveryAttempts {
+		delivery.State = DeliveryDead
+		delivery.NextAttempt = time.Time{}
+		return
+	}
+	delivery.State = DeliveryPending
+	delivery.NextAttempt = time.Now().Add(retryBackoff(delivery.Attempts))
+}
+
+// retryBackoff doubles the delay with every attempt already made.
+func retryBackoff(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+	}
+	return delay
+}
+
+func post(httpClient *http.Client, endpoint string, payload []byte) error {
+	response, err := httpClient.Post(endpoint, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("notification endpoint returned %s", response.Status)
+	}
+	return nil
+}
+
+// PruneDeliveries drops delivered entries past their retention, keeping the
+// queue ConfigMap from growing without bound.
+func PruneDeliveries(deliveries []Delivery) []Delivery {
+	kept := make([]Delivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		if delivery.State == DeliveryDelivered && time.Since(delivery.LastAttempt) > deliveredRetention {
+			continue
+		}
+		kept = append(kept, delivery)
+	}
+	return kept
+}
+
+func (o *dispatchOperator) Enqueue(endpoint string, payload interface{}) (*Delivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	delivery := Delivery{
+		ID:        idutils.GetUuid36("delivery-"),
+		Endpoint:  endpoint,
+		Payload:   body,
+		State:     DeliveryPending,
+		CreatedAt: time.Now(),
+	}
+
+	// the first attempt happens inline, the common case reaches the
+	// endpoint without waiting for the dispatch controller
+	AttemptDelivery(o.httpClient, &delivery)
+
+	queue, err := o.loadQueue(DeliveryQueueConfigMapName)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.State == DeliveryDead {
+		// a single inline attempt cannot exhaust the budget, but keep
+		// the invariant should the limits ever change
+		if err := o.appendDeadLetter(delivery); err != nil {
+			return nil, err
+		}
+		return &delivery, nil
+	}
+	queue = append(PruneDeliveries(queue), delivery)
+	if err := o.saveQueue(DeliveryQueueConfigMapName, queue); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (o *dispatchOperator) ListDeliveries(state string) ([]Delivery, error) {
+	queue, err := o.loadQueue(DeliveryQueueConfigMapName)
+	if err != nil {
+		return nil, err
+	}
+	dead, err := o.loadQueue(DeadLetterConfigMapName)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]Delivery, 0, len(queue)+len(dead))
+	for _, delivery := range append(queue, dead...) {
+		if state != "" && delivery.State != state {
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt) })
+	return deliveries, nil
+}
+
+func (o *dispatchOperator) GetDelivery(id string) (*Delivery, error) {
+	deliveries, err := o.ListDeliveries("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range deliveries {
+		if deliveries[i].ID == id {
+			return &deliveries[i], nil
+		}
+	}
+	return nil, errors.NewNotFound(corev1.Resource("configmaps"), id)
+}
+
+func (o *dispatchOperator) RetryDelivery(id string) (*Delivery, error) {
+	dead, err := o.loadQueue(DeadLetterConfigMapName)
+	if err != nil {
+		return nil, err
+	}
+
+	index := -1
+	for i := range dead {
+		if dead[i].ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, errors.NewNotFound(corev1.Resource("configmaps"), id)
+	}
+
+	delivery := dead[index]
+	delivery.State = DeliveryPending
+	delivery.Attempts = 0
+	delivery.LastError = ""
+	delivery.NextAttempt = time.Now()
+
+	queue, err := o.loadQueue(DeliveryQueueConfigMapName)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.saveQueue(DeliveryQueueConfigMapName, append(PruneDeliveries(queue), delivery)); err != nil {
+		return nil, err
+	}
+	if err := o.saveQueue(DeadLetterConfigMapName, append(dead[:index], dead[index+1:]...)); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// CapDeadLetters keeps only the most recent dead letters, oldest go first.
+func CapDeadLetters(dead []Delivery) []Delivery {
+	if len(dead) > deadLetterLimit {
+		return dead[len(dead)-deadLetterLimit:]
+	}
+	return dead
+}
+
+func (o *dispatchOperator) appendDeadLetter(delivery Delivery) error {
+	dead, err := o.loadQueue(DeadLetterConfigMapName)
+	if err != nil {
+		return err
+	}
+	return o.saveQueue(DeadLetterConfigMapName, CapDeadLetters(append(dead, delivery)))
+}
+
+func (o *dispatchOperator) loadQueue(name string) ([]Delivery, error) {
+	cm, err := o.client.CoreV1().ConfigMaps(constants.KubeSphereNamespace).
+		Get(context.Background(), name, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []Delivery{}, nil
+		}
+		return nil, err
+	}
+	return UnmarshalDeliveries(cm)
+}
+
+func (o *dispatchOperator) saveQueue(name string, deliveries []Delivery) error {
+	data, err := MarshalDeliveries(deliveries)
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: constants.KubeSphereNamespace,
+		},
+		Data: data,
+	}
+
+	configMaps := o.client.CoreV1().ConfigMaps(constants.KubeSphereNamespace)
+	existing, err := configMaps.Get(context.Background(), name, v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		_, err := configMaps.Create(context.Background(), cm, v1.CreateOptions{})
+		return err
+	}
+
+	existing.Data = cm.Data
+	_, err = configMaps.Update(context.Background(), existing, v1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,89 @@
+/*
+
+  Copyright 2021 The KubeSphere Authors.
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package notification
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAttemptDelivery(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	delivery := &Delivery{ID: "d1", Endpoint: up.URL, Payload: []byte(`[]`), State: DeliveryPending}
+	AttemptDelivery(up.Client(), delivery)
+	if delivery.State != DeliveryDelivered || delivery.Attempts != 1 || delivery.LastError != "" {
+		t.Errorf("unexpected delivery after success: %+v", delivery)
+	}
+
+	delivery = &Delivery{ID: "d2", Endpoint: down.URL, Payload: []byte(`[]`), State: DeliveryPending}
+	AttemptDelivery(down.Client(), delivery)
+	if delivery.State != DeliveryPending || delivery.LastError == "" || delivery.NextAttempt.IsZero() {
+		t.Errorf("unexpected delivery after failure: %+v", delivery)
+	}
+
+	// exhausting the attempts turns the delivery into a dead letter
+	delivery.Attempts = maxDeliveryAttempts - 1
+	AttemptDelivery(down.Client(), delivery)
+	if delivery.State != DeliveryDead || !delivery.NextAttempt.IsZero() {
+		t.Errorf("unexpected delivery after exhausted attempts: %+v", delivery)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	if d := retryBackoff(1); d != retryBaseDelay {
+		t.Errorf("unexpected first backoff %v", d)
+	}
+	if d := retryBackoff(3); d != 4*retryBaseDelay {
+		t.Errorf("unexpected third backoff %v", d)
+	}
+}
+
+func TestPruneDeliveries(t *testing.T) {
+	deliveries := []Delivery{
+		{ID: "old", State: DeliveryDelivered, LastAttempt: time.Now().Add(-2 * deliveredRetention)},
+		{ID: "fresh", State: DeliveryDelivered, LastAttempt: time.Now()},
+		{ID: "pending", State: DeliveryPending, LastAttempt: time.Now().Add(-2 * deliveredRetention)},
+	}
+	kept := PruneDeliveries(deliveries)
+	if len(kept) != 2 || kept[0].ID != "fresh" || kept[1].ID != "pending" {
+		t.Errorf("unexpected kept deliveries: %+v", kept)
+	}
+}
+
+func TestCapDeadLetters(t *testing.T) {
+	dead := make([]Delivery, deadLetterLimit+5)
+	for i := range dead {
+		dead[i].ID = string(rune('a' + i%26))
+	}
+	capped := CapDeadLetters(dead)
+	if len(capped) != deadLetterLimit {
+		t.Errorf("expected %d dead letters, got %d", deadLetterLimit, len(capped))
+	}
+	if capped[len(capped)-1].ID != dead[len(dead)-1].ID {
+		t.Error("capping dropped the most recent dead letter")
+	}
+}
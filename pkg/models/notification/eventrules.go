@@ -0,0 +1,222 @@
+/*
+
+  Copyright 2021 The KubeSphere Authors.
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// eventRuleConfigMapFormat names the ConfigMap holding the event
+	// notification rules of a workspace
+	eventRuleConfigMapFormat = "notification-event-rules-%s"
+	eventRuleDataKey         = "rules"
+
+	// DefaultDedupWindow suppresses repeated notifications of the same
+	// event within this window when a rule does not set its own
+	DefaultDedupWindow = 10 * time.Minute
+)
+
+// EventRule converts matching Kubernetes events in the workspace's
+// namespaces into notifications on the workspace's channels.
+type EventRule struct {
+	Name string `json:"name"`
+
+	// Namespaces restricts the rule to these namespaces of the workspace;
+	// empty matches every namespace of the workspace
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Reasons are the event reasons to match, e.g. FailedScheduling,
+	// Unhealthy, BackOff
+	Reasons []string `json:"reasons"`
+
+	// Type matches the event type, Normal or Warning; empty matches both
+	Type string `json:"type,omitempty"`
+
+	// DedupWindow suppresses repeated notifications of the same event
+	// source within this window, as a Go duration. Defaults to 10m.
+	DedupWindow string `json:"dedupWindow,omitempty"`
+
+	// Receiver is the notification receiver the rule targets; empty falls
+	// through to the workspace's default routing
+	Receiver string `json:"receiver,omitempty"`
+
+	// OnCallSchedule routes the notification to whoever the workspace's
+	// on-call schedule with this name resolves to at send time
+	OnCallSchedule string `json:"onCallSchedule,omitempty"`
+}
+
+// Matches reports whether the event is selected by the rule. The caller
+// has already scoped the event to the rule's workspace.
+func (r EventRule) Matches(event *corev1.Event) bool {
+	if r.Type != "" && r.Type != event.Type {
+		return false
+	}
+	if len(r.Namespaces) > 0 {
+		matched := false
+		for _, namespace := range r.Namespaces {
+			if namespace == event.InvolvedObject.Namespace {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, reason := range r.Reasons {
+		if reason == event.Reason {
+			return true
+		}
+	}
+	return false
+}
+
+// Window returns the rule's dedup window, falling back to the default.
+func (r EventRule) Window() time.Duration {
+	if r.DedupWindow == "" {
+		return DefaultDedupWindow
+	}
+	window, err := time.ParseDuration(r.DedupWindow)
+	if err != nil || window <= 0 {
+		return DefaultDedupWindow
+	}
+	return window
+}
+
+// EventRuleOperator manages the per-workspace event notification rules.
+type EventRuleOperator interface {
+	ListEventRules(workspace string) ([]EventRule, error)
+	// SaveEventRules replaces the workspace's rules
+	SaveEventRules(workspace string, rules []EventRule) ([]EventRule, error)
+}
+
+type eventRuleOperator struct {
+	k8sClient kubernetes.Interface
+}
+
+func NewEventRuleOperator(k8sClient kubernetes.Interface) EventRuleOperator {
+	return &eventRuleOperator{k8sClient: k8sClient}
+}
+
+func (o *eventRuleOperator) ListEventRules(workspace string) ([]EventRule, error) {
+	cm, err := o.k8sClient.CoreV1().ConfigMaps(constants.KubeSphereNamespace).
+		Get(context.Background(), fmt.Sprintf(eventRuleConfigMapFormat, workspace), v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []EventRule{}, nil
+		}
+		return nil, err
+	}
+	return unmarshalEventRules(cm)
+}
+
+func (o *eventRuleOperator) SaveEventRules(workspace string, rules []EventRule) ([]EventRule, error) {
+	if err := validateEventRules(rules); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      fmt.Sprintf(eventRuleConfigMapFormat, workspace),
+			Namespace: constants.KubeSphereNamespace,
+			Labels:    map[string]string{constants.WorkspaceLabelKey: workspace},
+		},
+		Data: map[string]string{eventRuleDataKey: string(data)},
+	}
+	client := o.k8sClient.CoreV1().ConfigMaps(constants.KubeSphereNamespace)
+	old, err := client.Get(context.Background(), cm.Name, v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if _, err := client.Create(context.Background(), cm, v1.CreateOptions{}); err != nil {
+			return nil, err
+		}
+		return rules, nil
+	}
+	old.Labels = cm.Labels
+	old.Data = cm.Data
+	if _, err := client.Update(context.Background(), old, v1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func validateEventRules(rules []EventRule) error {
+	names := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule name must not be empty")
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate rule name %s", rule.Name)
+		}
+		names[rule.Name] = true
+		if len(rule.Reasons) == 0 {
+			return fmt.Errorf("rule %s must match at least one event reason", rule.Name)
+		}
+		if rule.Type != "" && rule.Type != corev1.EventTypeNormal && rule.Type != corev1.EventTypeWarning {
+			return fmt.Errorf("rule %s has invalid event type %s", rule.Name, rule.Type)
+		}
+		if rule.DedupWindow != "" {
+			if window, err := time.ParseDuration(rule.DedupWindow); err != nil || window <= 0 {
+				return fmt.Errorf("rule %s has invalid dedup window %s", rule.Name, rule.DedupWindow)
+			}
+		}
+	}
+	return nil
+}
+
+// UnmarshalEventRules reads the rules out of a workspace's rule ConfigMap.
+func UnmarshalEventRules(cm *corev1.ConfigMap) ([]EventRule, error) {
+	return unmarshalEventRules(cm)
+}
+
+func unmarshalEventRules(cm *corev1.ConfigMap) ([]EventRule, error) {
+	data, ok := cm.Data[eventRuleDataKey]
+	if !ok || data == "" {
+		return []EventRule{}, nil
+	}
+	var rules []EventRule
+	if err := json.Unmarshal([]byte(data), &rules); err != nil {
+		return nil, fmt.Errorf("invalid event rules in %s/%s: %v", cm.Namespace, cm.Name, err)
+	}
+	return rules, nil
+}
+
+// EventRuleConfigMapName returns the name of the workspace's rule
+// ConfigMap, used by the controller watching it.
+func EventRuleConfigMapName(workspace string) string {
+	return fmt.Sprintf(eventRuleConfigMapFormat, workspace)
+}
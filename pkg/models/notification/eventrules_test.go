@@ -0,0 +1,96 @@
+/*
+
+  Copyright 2021 The KubeSphere Authors.
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package notification
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestEventRuleMatches(t *testing.T) {
+	rule := EventRule{
+		Name:       "scheduling-failures",
+		Namespaces: []string{"demo"},
+		Reasons:    []string{"FailedScheduling", "BackOff"},
+		Type:       corev1.EventTypeWarning,
+	}
+
+	event := func(namespace, reason, eventType string) *corev1.Event {
+		return &corev1.Event{
+			Reason: reason,
+			Type:   eventType,
+			InvolvedObject: corev1.ObjectReference{
+				Namespace: namespace,
+				Kind:      "Pod",
+				Name:      "demo-pod",
+			},
+		}
+	}
+
+	var tests = []struct {
+		event    *corev1.Event
+		expected bool
+	}{
+		{event("demo", "FailedScheduling", corev1.EventTypeWarning), true},
+		{event("demo", "BackOff", corev1.EventTypeWarning), true},
+		{event("demo", "Unhealthy", corev1.EventTypeWarning), false},
+		{event("demo", "FailedScheduling", corev1.EventTypeNormal), false},
+		{event("other", "FailedScheduling", corev1.EventTypeWarning), false},
+	}
+	for _, test := range tests {
+		if got := rule.Matches(test.event); got != test.expected {
+			t.Errorf("rule.Matches(%s/%s/%s) = %v, expected %v",
+				test.event.InvolvedObject.Namespace, test.event.Reason, test.event.Type, got, test.expected)
+		}
+	}
+}
+
+func TestEventRuleWindow(t *testing.T) {
+	if window := (EventRule{}).Window(); window != DefaultDedupWindow {
+		t.Errorf("empty dedup window = %v, expected default %v", window, DefaultDedupWindow)
+	}
+	if window := (EventRule{DedupWindow: "30m"}).Window(); window != 30*time.Minute {
+		t.Errorf("dedup window = %v, expected 30m", window)
+	}
+	if window := (EventRule{DedupWindow: "nonsense"}).Window(); window != DefaultDedupWindow {
+		t.Errorf("invalid dedup window = %v, expected default %v", window, DefaultDedupWindow)
+	}
+}
+
+func TestValidateEventRules(t *testing.T) {
+	var tests = []struct {
+		description string
+		rules       []EventRule
+		expectErr   bool
+	}{
+		{"valid", []EventRule{{Name: "a", Reasons: []string{"BackOff"}}}, false},
+		{"missing name", []EventRule{{Reasons: []string{"BackOff"}}}, true},
+		{"duplicate name", []EventRule{{Name: "a", Reasons: []string{"BackOff"}}, {Name: "a", Reasons: []string{"Unhealthy"}}}, true},
+		{"no reasons", []EventRule{{Name: "a"}}, true},
+		{"bad type", []EventRule{{Name: "a", Reasons: []string{"BackOff"}, Type: "Fatal"}}, true},
+		{"bad window", []EventRule{{Name: "a", Reasons: []string{"BackOff"}, DedupWindow: "-5m"}}, true},
+	}
+	for _, test := range tests {
+		if err := validateEventRules(test.rules); (err != nil) != test.expectErr {
+			t.Errorf("%s: validateEventRules returned %v, expectErr %v", test.description, err, test.expectErr)
+		}
+	}
+}
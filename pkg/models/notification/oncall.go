@@ -0,0 +1,328 @@
+/*
+
+  Copyright 2021 The KubeSphere Authors.
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// onCallConfigMapFormat names the ConfigMap holding the on-call
+	// schedules of a workspace
+	onCallConfigMapFormat = "oncall-schedules-%s"
+	onCallDataKey         = "schedules"
+
+	// handoffTimeLayout is how rotation and override times are written,
+	// interpreted in the schedule's timezone
+	handoffTimeLayout = "2006-01-02T15:04"
+)
+
+// OnCallSchedule says who gets paged when, through rotations handing off at
+// fixed intervals and overrides taking precedence over them.
+type OnCallSchedule struct {
+	Name string `json:"name"`
+
+	// Timezone is the IANA name the handoff times are interpreted in,
+	// e.g. Asia/Shanghai; empty means UTC
+	Timezone string `json:"timezone,omitempty"`
+
+	Rotations []Rotation `json:"rotations"`
+
+	// Overrides replace all rotations while active, e.g. for vacations
+	// or holiday cover
+	Overrides []Override `json:"overrides,omitempty"`
+}
+
+// Rotation cycles through its participants, handing off every shift length
+// counted from the start time.
+type Rotation struct {
+	Name string `json:"name"`
+
+	// Participants in handoff order; the first one is on call for the
+	// first shift
+	Participants []string `json:"participants"`
+
+	// Start is the first handoff in the schedule's timezone, in the form
+	// 2006-01-02T15:04. Before this time the rotation pages nobody.
+	Start string `json:"start"`
+
+	// ShiftLength is how long one participant stays on call, as a Go
+	// duration, e.g. 24h or 168h. Shifts advance by absolute duration
+	// from the start, so a 24h shift hands off at the same wall-clock
+	// time except across daylight saving transitions.
+	ShiftLength string `json:"shiftLength"`
+}
+
+// Override puts the user on call between start and end, in the schedule's
+// timezone, suppressing the rotations.
+type Override struct {
+	User  string `json:"user"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// OnCallStatus resolves a schedule at one point in time.
+type OnCallStatus struct {
+	Schedule string    `json:"schedule"`
+	At       time.Time `json:"at"`
+	// Users currently on call, one per rotation, or the active overrides
+	Users []string `json:"users"`
+}
+
+// location returns the timezone of the schedule, UTC when unset.
+func (s OnCallSchedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// OnCallAt resolves who is on call at the given time. Active overrides win
+// over the rotations, otherwise every rotation contributes its current
+// participant.
+func (s OnCallSchedule) OnCallAt(at time.Time) ([]string, error) {
+	loc, err := s.location()
+	if err != nil {
+		return nil, fmt.Errorf("schedule %s has invalid timezone %s: %v", s.Name, s.Timezone, err)
+	}
+
+	var users []string
+	for _, override := range s.Overrides {
+		start, err := time.ParseInLocation(handoffTimeLayout, override.Start, loc)
+		if err != nil {
+			continue
+		}
+		end, err := time.ParseInLocation(handoffTimeLayout, override.End, loc)
+		if err != nil {
+			continue
+		}
+		if !at.Before(start) && at.Before(end) {
+			users = append(users, override.User)
+		}
+	}
+	if len(users) > 0 {
+		return users, nil
+	}
+
+	for _, rotation := range s.Rotations {
+		if user := rotation.onCallAt(at, loc); user != "" {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r Rotation) onCallAt(at time.Time, loc *time.Location) string {
+	if len(r.Participants) == 0 {
+		return ""
+	}
+	start, err := time.ParseInLocation(handoffTimeLayout, r.Start, loc)
+	if err != nil || at.Before(start) {
+		return ""
+	}
+	shift, err := time.ParseDuration(r.ShiftLength)
+	if err != nil || shift <= 0 {
+		return ""
+	}
+	index := int(at.Sub(start)/shift) % len(r.Participants)
+	return r.Participants[index]
+}
+
+// OnCallOperator manages the per-workspace on-call schedules.
+type OnCallOperator interface {
+	ListSchedules(workspace string) ([]OnCallSchedule, error)
+	// SaveSchedules replaces the workspace's schedules
+	SaveSchedules(workspace string, schedules []OnCallSchedule) ([]OnCallSchedule, error)
+	// OnCall resolves who is on call for the schedule at the given time
+	OnCall(workspace, schedule string, at time.Time) (*OnCallStatus, error)
+}
+
+type onCallOperator struct {
+	k8sClient kubernetes.Interface
+}
+
+func NewOnCallOperator(k8sClient kubernetes.Interface) OnCallOperator {
+	return &onCallOperator{k8sClient: k8sClient}
+}
+
+func (o *onCallOperator) ListSchedules(workspace string) ([]OnCallSchedule, error) {
+	cm, err := o.k8sClient.CoreV1().ConfigMaps(constants.KubeSphereNamespace).
+		Get(context.Background(), fmt.Sprintf(onCallConfigMapFormat, workspace), v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []OnCallSchedule{}, nil
+		}
+		return nil, err
+	}
+	return unmarshalOnCallSchedules(cm)
+}
+
+func (o *onCallOperator) SaveSchedules(workspace string, schedules []OnCallSchedule) ([]OnCallSchedule, error) {
+	if err := validateOnCallSchedules(schedules); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(schedules)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      fmt.Sprintf(onCallConfigMapFormat, workspace),
+			Namespace: constants.KubeSphereNamespace,
+			Labels:    map[string]string{constants.WorkspaceLabelKey: workspace},
+		},
+		Data: map[string]string{onCallDataKey: string(data)},
+	}
+	client := o.k8sClient.CoreV1().ConfigMaps(constants.KubeSphereNamespace)
+	old, err := client.Get(context.Background(), cm.Name, v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if _, err := client.Create(context.Background(), cm, v1.CreateOptions{}); err != nil {
+			return nil, err
+		}
+		return schedules, nil
+	}
+	old.Labels = cm.Labels
+	old.Data = cm.Data
+	if _, err := client.Update(context.Background(), old, v1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (o *onCallOperator) OnCall(workspace, schedule string, at time.Time) (*OnCallStatus, error) {
+	schedules, err := o.ListSchedules(workspace)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range schedules {
+		if s.Name != schedule {
+			continue
+		}
+		users, err := s.OnCallAt(at)
+		if err != nil {
+			return nil, err
+		}
+		if users == nil {
+			users = []string{}
+		}
+		return &OnCallStatus{Schedule: schedule, At: at, Users: users}, nil
+	}
+	return nil, errors.NewNotFound(corev1.Resource("configmaps"), schedule)
+}
+
+func validateOnCallSchedules(schedules []OnCallSchedule) error {
+	names := make(map[string]bool, len(schedules))
+	for _, schedule := range schedules {
+		if schedule.Name == "" {
+			return fmt.Errorf("schedule name must not be empty")
+		}
+		if names[schedule.Name] {
+			return fmt.Errorf("duplicate schedule name %s", schedule.Name)
+		}
+		names[schedule.Name] = true
+
+		loc, err := schedule.location()
+		if err != nil {
+			return fmt.Errorf("schedule %s has invalid timezone %s", schedule.Name, schedule.Timezone)
+		}
+		if len(schedule.Rotations) == 0 && len(schedule.Overrides) == 0 {
+			return fmt.Errorf("schedule %s must have at least one rotation or override", schedule.Name)
+		}
+
+		rotations := make(map[string]bool, len(schedule.Rotations))
+		for _, rotation := range schedule.Rotations {
+			if rotation.Name == "" {
+				return fmt.Errorf("schedule %s has a rotation without a name", schedule.Name)
+			}
+			if rotations[rotation.Name] {
+				return fmt.Errorf("schedule %s has duplicate rotation name %s", schedule.Name, rotation.Name)
+			}
+			rotations[rotation.Name] = true
+			if len(rotation.Participants) == 0 {
+				return fmt.Errorf("rotation %s of schedule %s has no participants", rotation.Name, schedule.Name)
+			}
+			if _, err := time.ParseInLocation(handoffTimeLayout, rotation.Start, loc); err != nil {
+				return fmt.Errorf("rotation %s of schedule %s has invalid start %s, expected the form %s",
+					rotation.Name, schedule.Name, rotation.Start, handoffTimeLayout)
+			}
+			if shift, err := time.ParseDuration(rotation.ShiftLength); err != nil || shift <= 0 {
+				return fmt.Errorf("rotation %s of schedule %s has invalid shift length %s",
+					rotation.Name, schedule.Name, rotation.ShiftLength)
+			}
+		}
+
+		for _, override := range schedule.Overrides {
+			if override.User == "" {
+				return fmt.Errorf("schedule %s has an override without a user", schedule.Name)
+			}
+			start, err := time.ParseInLocation(handoffTimeLayout, override.Start, loc)
+			if err != nil {
+				return fmt.Errorf("override of schedule %s has invalid start %s, expected the form %s",
+					schedule.Name, override.Start, handoffTimeLayout)
+			}
+			end, err := time.ParseInLocation(handoffTimeLayout, override.End, loc)
+			if err != nil {
+				return fmt.Errorf("override of schedule %s has invalid end %s, expected the form %s",
+					schedule.Name, override.End, handoffTimeLayout)
+			}
+			if !end.After(start) {
+				return fmt.Errorf("override of schedule %s must end after it starts", schedule.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// UnmarshalOnCallSchedules reads the schedules out of a workspace's on-call
+// ConfigMap.
+func UnmarshalOnCallSchedules(cm *corev1.ConfigMap) ([]OnCallSchedule, error) {
+	return unmarshalOnCallSchedules(cm)
+}
+
+func unmarshalOnCallSchedules(cm *corev1.ConfigMap) ([]OnCallSchedule, error) {
+	data, ok := cm.Data[onCallDataKey]
+	if !ok || data == "" {
+		return []OnCallSchedule{}, nil
+	}
+	var schedules []OnCallSchedule
+	if err := json.Unmarshal([]byte(data), &schedules); err != nil {
+		return nil, fmt.Errorf("invalid on-call schedules in %s/%s: %v", cm.Namespace, cm.Name, err)
+	}
+	return schedules, nil
+}
+
+// OnCallConfigMapName returns the name of the workspace's on-call ConfigMap,
+// used by the controller resolving on-call users during routing.
+func OnCallConfigMapName(workspace string) string {
+	return fmt.Sprintf(onCallConfigMapFormat, workspace)
+}
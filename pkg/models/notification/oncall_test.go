@@ -0,0 +1,162 @@
+/*
+
+  Copyright 2021 The KubeSphere Authors.
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package notification
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOnCallAtRotation(t *testing.T) {
+	schedule := OnCallSchedule{
+		Name: "primary",
+		Rotations: []Rotation{{
+			Name:         "weekly",
+			Participants: []string{"alice", "bob", "carol"},
+			Start:        "2021-01-04T09:00",
+			ShiftLength:  "168h",
+		}},
+	}
+
+	var tests = []struct {
+		at       string
+		expected []string
+	}{
+		// before the rotation starts nobody is paged
+		{"2021-01-03T12:00:00Z", nil},
+		{"2021-01-04T09:00:00Z", []string{"alice"}},
+		{"2021-01-11T08:59:00Z", []string{"alice"}},
+		{"2021-01-11T09:00:00Z", []string{"bob"}},
+		{"2021-01-18T12:00:00Z", []string{"carol"}},
+		// the rotation wraps around to the first participant
+		{"2021-01-25T12:00:00Z", []string{"alice"}},
+	}
+	for _, test := range tests {
+		at, err := time.Parse(time.RFC3339, test.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		users, err := schedule.OnCallAt(at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != len(test.expected) {
+			t.Errorf("on call at %s = %v, expected %v", test.at, users, test.expected)
+			continue
+		}
+		for i := range users {
+			if users[i] != test.expected[i] {
+				t.Errorf("on call at %s = %v, expected %v", test.at, users, test.expected)
+			}
+		}
+	}
+}
+
+func TestOnCallAtOverride(t *testing.T) {
+	schedule := OnCallSchedule{
+		Name: "primary",
+		Rotations: []Rotation{{
+			Name:         "daily",
+			Participants: []string{"alice", "bob"},
+			Start:        "2021-01-04T09:00",
+			ShiftLength:  "24h",
+		}},
+		Overrides: []Override{{
+			User:  "carol",
+			Start: "2021-01-05T00:00",
+			End:   "2021-01-06T00:00",
+		}},
+	}
+
+	at, _ := time.Parse(time.RFC3339, "2021-01-05T12:00:00Z")
+	users, err := schedule.OnCallAt(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0] != "carol" {
+		t.Errorf("on call during override = %v, expected [carol]", users)
+	}
+
+	at, _ = time.Parse(time.RFC3339, "2021-01-06T12:00:00Z")
+	users, err = schedule.OnCallAt(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0] != "alice" {
+		t.Errorf("on call after override = %v, expected the rotation to resume with [alice]", users)
+	}
+}
+
+func TestOnCallAtTimezone(t *testing.T) {
+	schedule := OnCallSchedule{
+		Name:     "primary",
+		Timezone: "Asia/Shanghai",
+		Rotations: []Rotation{{
+			Name:         "daily",
+			Participants: []string{"alice", "bob"},
+			Start:        "2021-01-04T09:00",
+			ShiftLength:  "24h",
+		}},
+	}
+
+	// 2021-01-04T09:00 in Shanghai is 01:00 UTC, so at 00:30 UTC the
+	// rotation has not started yet
+	at, _ := time.Parse(time.RFC3339, "2021-01-04T00:30:00Z")
+	users, err := schedule.OnCallAt(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 0 {
+		t.Errorf("on call before the local start = %v, expected nobody", users)
+	}
+
+	at, _ = time.Parse(time.RFC3339, "2021-01-04T01:30:00Z")
+	users, err = schedule.OnCallAt(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0] != "alice" {
+		t.Errorf("on call after the local start = %v, expected [alice]", users)
+	}
+}
+
+func TestValidateOnCallSchedules(t *testing.T) {
+	rotation := Rotation{Name: "daily", Participants: []string{"alice"}, Start: "2021-01-04T09:00", ShiftLength: "24h"}
+
+	var tests = []struct {
+		description string
+		schedules   []OnCallSchedule
+		expectErr   bool
+	}{
+		{"valid", []OnCallSchedule{{Name: "a", Rotations: []Rotation{rotation}}}, false},
+		{"missing name", []OnCallSchedule{{Rotations: []Rotation{rotation}}}, true},
+		{"duplicate name", []OnCallSchedule{{Name: "a", Rotations: []Rotation{rotation}}, {Name: "a", Rotations: []Rotation{rotation}}}, true},
+		{"bad timezone", []OnCallSchedule{{Name: "a", Timezone: "Mars/Olympus", Rotations: []Rotation{rotation}}}, true},
+		{"empty schedule", []OnCallSchedule{{Name: "a"}}, true},
+		{"no participants", []OnCallSchedule{{Name: "a", Rotations: []Rotation{{Name: "r", Start: "2021-01-04T09:00", ShiftLength: "24h"}}}}, true},
+		{"bad start", []OnCallSchedule{{Name: "a", Rotations: []Rotation{{Name: "r", Participants: []string{"alice"}, Start: "soon", ShiftLength: "24h"}}}}, true},
+		{"bad shift", []OnCallSchedule{{Name: "a", Rotations: []Rotation{{Name: "r", Participants: []string{"alice"}, Start: "2021-01-04T09:00", ShiftLength: "-24h"}}}}, true},
+		{"override ends before start", []OnCallSchedule{{Name: "a", Overrides: []Override{{User: "carol", Start: "2021-01-06T00:00", End: "2021-01-05T00:00"}}}}, true},
+	}
+	for _, test := range tests {
+		if err := validateOnCallSchedules(test.schedules); (err != nil) != test.expectErr {
+			t.Errorf("%s: validateOnCallSchedules returned %v, expectErr %v", test.description, err, test.expectErr)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+/*
+
+  Copyright 2021 The KubeSphere Authors.
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// templateConfigMapFormat names the ConfigMap holding the message
+	// templates of a receiver
+	templateConfigMapFormat = "notification-templates-%s"
+	templateDataKey         = "templates"
+
+	// DefaultLanguage is used when no template exists for the requested
+	// language
+	DefaultLanguage = "en"
+)
+
+// defaultTemplate is the former fixed message format, kept as the fallback
+// when a receiver has no template for the requested language.
+var defaultTemplate = MessageTemplate{
+	Language: DefaultLanguage,
+	Subject:  `{{ severityIcon .Severity }} [{{ .Severity }}] {{ .Alertname }}`,
+	Body: `{{ .Message }}
+{{ range $name, $value := .Labels }}{{ $name }}: {{ $value }}
+{{ end }}`,
+}
+
+// MessageTemplate renders the notification messages of a receiver in one
+// language. Subject and Body are Go templates evaluated against TemplateData,
+// with severityIcon, runbookLink and dashboardURL available as functions.
+type MessageTemplate struct {
+	Language string `json:"language"`
+	Subject  string `json:"subject,omitempty"`
+	Body     string `json:"body"`
+}
+
+// TemplateData is what a template is evaluated against, one alert per
+// message.
+type TemplateData struct {
+	Alertname   string            `json:"alertname,omitempty"`
+	Severity    string            `json:"severity,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Cluster     string            `json:"cluster,omitempty"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// RenderedMessage is the outcome of rendering a template, as it would be
+// delivered to the receiver.
+type RenderedMessage struct {
+	Language string `json:"language"`
+	Subject  string `json:"subject,omitempty"`
+	Body     string `json:"body"`
+}
+
+// templateFuncs are the extra functions available inside message templates.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"severityIcon": severityIcon,
+		"runbookLink":  runbookLink,
+		"dashboardURL": dashboardURL,
+	}
+}
+
+// severityIcon maps an alert severity to an icon prefixed to messages.
+func severityIcon(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return "🔴"
+	case "error", "warning":
+		return "🟡"
+	case "info":
+		return "🔵"
+	default:
+		return "⚪"
+	}
+}
+
+// runbookLink renders a markdown link to the runbook, or nothing when the
+// alert carries no runbook url.
+func runbookLink(url string) string {
+	if url == "" {
+		return ""
+	}
+	return fmt.Sprintf("[Runbook](%s)", url)
+}
+
+// dashboardURL builds the console path to the resources the alert is about,
+// so messages can link back to the dashboard.
+func dashboardURL(cluster, namespace string) string {
+	if cluster == "" {
+		cluster = "default"
+	}
+	if namespace == "" {
+		return fmt.Sprintf("/clusters/%s/monitor-cluster/overview", cluster)
+	}
+	return fmt.Sprintf("/clusters/%s/projects/%s/overview", cluster, namespace)
+}
+
+// TemplateOperator manages the per-receiver message templates and renders
+// them for preview.
+type TemplateOperator interface {
+	ListTemplates(receiver string) ([]MessageTemplate, error)
+	// SaveTemplates replaces the receiver's templates
+	SaveTemplates(receiver string, templates []MessageTemplate) ([]MessageTemplate, error)
+	// RenderTemplate renders the receiver's template of the language against
+	// the data, falling back to the default language and then to the builtin
+	// format
+	RenderTemplate(receiver, language string, data *TemplateData) (*RenderedMessage, error)
+}
+
+type templateOperator struct {
+	k8sClient kubernetes.Interface
+}
+
+func NewTemplateOperator(k8sClient kubernetes.Interface) TemplateOperator {
+	return &templateOperator{k8sClient: k8sClient}
+}
+
+func (o *templateOperator) ListTemplates(receiver string) ([]MessageTemplate, error) {
+	cm, err := o.k8sClient.CoreV1().ConfigMaps(constants.KubeSphereNamespace).
+		Get(context.Background(), fmt.Sprintf(templateConfigMapFormat, receiver), v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []MessageTemplate{}, nil
+		}
+		return nil, err
+	}
+	return unmarshalTemplates(cm)
+}
+
+func (o *templateOperator) SaveTemplates(receiver string, templates []MessageTemplate) ([]MessageTemplate, error) {
+	if err := validateTemplates(templates); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(templates)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      fmt.Sprintf(templateConfigMapFormat, receiver),
+			Namespace: constants.KubeSphereNamespace,
+		},
+		Data: map[string]string{templateDataKey: string(data)},
+	}
+	client := o.k8sClient.CoreV1().ConfigMaps(constants.KubeSphereNamespace)
+	old, err := client.Get(context.Background(), cm.Name, v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if _, err := client.Create(context.Background(), cm, v1.CreateOptions{}); err != nil {
+			return nil, err
+		}
+		return templates, nil
+	}
+	old.Data = cm.Data
+	if _, err := client.Update(context.Background(), old, v1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (o *templateOperator) RenderTemplate(receiver, language string, data *TemplateData) (*RenderedMessage, error) {
+	templates, err := o.ListTemplates(receiver)
+	if err != nil {
+		return nil, err
+	}
+	return renderTemplate(selectTemplate(templates, language), data)
+}
+
+// selectTemplate picks the template of the language, falling back to the
+// default language and then to the builtin format.
+func selectTemplate(templates []MessageTemplate, language string) MessageTemplate {
+	if language == "" {
+		language = DefaultLanguage
+	}
+	var fallback *MessageTemplate
+	for i := range templates {
+		if templates[i].Language == language {
+			return templates[i]
+		}
+		if templates[i].Language == DefaultLanguage {
+			fallback = &templates[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback
+	}
+	return defaultTemplate
+}
+
+func renderTemplate(tmpl MessageTemplate, data *TemplateData) (*RenderedMessage, error) {
+	if data == nil {
+		data = &TemplateData{}
+	}
+	subject, err := execute(tmpl.Subject, data)
+	if err != nil {
+		return nil, fmt.Errorf("subject of template %s is invalid: %v", tmpl.Language, err)
+	}
+	body, err := execute(tmpl.Body, data)
+	if err != nil {
+		return nil, fmt.Errorf("body of template %s is invalid: %v", tmpl.Language, err)
+	}
+	return &RenderedMessage{Language: tmpl.Language, Subject: subject, Body: body}, nil
+}
+
+func execute(text string, data *TemplateData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := template.New("message").Funcs(templateFuncs()).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func validateTemplates(templates []MessageTemplate) error {
+	languages := make(map[string]bool, len(templates))
+	for _, tmpl := range templates {
+		if tmpl.Language == "" {
+			return fmt.Errorf("template language must not be empty")
+		}
+		if languages[tmpl.Language] {
+			return fmt.Errorf("duplicate template language %s", tmpl.Language)
+		}
+		languages[tmpl.Language] = true
+		if tmpl.Body == "" {
+			return fmt.Errorf("template %s must have a body", tmpl.Language)
+		}
+		if _, err := renderTemplate(tmpl, &TemplateData{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unmarshalTemplates(cm *corev1.ConfigMap) ([]MessageTemplate, error) {
+	data, ok := cm.Data[templateDataKey]
+	if !ok || data == "" {
+		return []MessageTemplate{}, nil
+	}
+	var templates []MessageTemplate
+	if err := json.Unmarshal([]byte(data), &templates); err != nil {
+		return nil, fmt.Errorf("invalid templates in %s/%s: %v", cm.Namespace, cm.Name, err)
+	}
+	return templates, nil
+}
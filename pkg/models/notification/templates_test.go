@@ -0,0 +1,95 @@
+/*
+
+  Copyright 2021 The KubeSphere Authors.
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package notification
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	tmpl := MessageTemplate{
+		Language: "en",
+		Subject:  `{{ severityIcon .Severity }} {{ .Alertname }}`,
+		Body: `{{ .Message }}
+{{ runbookLink (index .Annotations "runbook_url") }}
+{{ dashboardURL .Cluster .Namespace }}`,
+	}
+	data := &TemplateData{
+		Alertname:   "KubePodCrashLooping",
+		Severity:    "critical",
+		Message:     "Pod demo/demo-pod is crash looping",
+		Cluster:     "host",
+		Namespace:   "demo",
+		Annotations: map[string]string{"runbook_url": "https://example.com/runbook"},
+	}
+
+	rendered, err := renderTemplate(tmpl, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered.Subject != "🔴 KubePodCrashLooping" {
+		t.Errorf("unexpected subject: %s", rendered.Subject)
+	}
+	if !strings.Contains(rendered.Body, "[Runbook](https://example.com/runbook)") {
+		t.Errorf("body misses the runbook link: %s", rendered.Body)
+	}
+	if !strings.Contains(rendered.Body, "/clusters/host/projects/demo/overview") {
+		t.Errorf("body misses the dashboard url: %s", rendered.Body)
+	}
+}
+
+func TestSelectTemplate(t *testing.T) {
+	templates := []MessageTemplate{
+		{Language: "en", Body: "english"},
+		{Language: "zh", Body: "chinese"},
+	}
+
+	if tmpl := selectTemplate(templates, "zh"); tmpl.Body != "chinese" {
+		t.Errorf("selected %s, expected the zh template", tmpl.Body)
+	}
+	// an unknown language falls back to the default language
+	if tmpl := selectTemplate(templates, "fr"); tmpl.Body != "english" {
+		t.Errorf("selected %s, expected the en template", tmpl.Body)
+	}
+	// without any template the builtin format applies
+	if tmpl := selectTemplate(nil, "en"); tmpl.Body != defaultTemplate.Body {
+		t.Errorf("selected %s, expected the builtin template", tmpl.Body)
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	var tests = []struct {
+		description string
+		templates   []MessageTemplate
+		expectErr   bool
+	}{
+		{"valid", []MessageTemplate{{Language: "en", Body: "{{ .Message }}"}}, false},
+		{"missing language", []MessageTemplate{{Body: "{{ .Message }}"}}, true},
+		{"duplicate language", []MessageTemplate{{Language: "en", Body: "a"}, {Language: "en", Body: "b"}}, true},
+		{"missing body", []MessageTemplate{{Language: "en"}}, true},
+		{"broken template", []MessageTemplate{{Language: "en", Body: "{{ .Message"}}, true},
+		{"unknown function", []MessageTemplate{{Language: "en", Body: "{{ nonsense }}"}}, true},
+	}
+	for _, test := range tests {
+		if err := validateTemplates(test.templates); (err != nil) != test.expectErr {
+			t.Errorf("%s: validateTemplates returned %v, expectErr %v", test.description, err, test.expectErr)
+		}
+	}
+}
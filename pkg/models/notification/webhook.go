@@ -0,0 +1,486 @@
+/*
+
+  Copyright 2021 The KubeSphere Authors.
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+const (
+	// webhookMappingConfigMapName names the ConfigMap holding the mapping
+	// rules applied to alerts received from external monitoring systems
+	webhookMappingConfigMapName = "notification-webhook-mappings"
+	webhookMappingDataKey       = "mappings"
+
+	// webhookAlertsEndpoint is where normalized alerts are posted, the
+	// same notification-manager endpoint the event rules use
+	webhookAlertsEndpoint = "http://notification-manager-svc.kubesphere-monitoring-system.svc:19093/api/v2/alerts"
+)
+
+// webhook sources with a dedicated normalizer; anything else is rejected
+const (
+	SourceGeneric    = "generic"
+	SourceZabbix     = "zabbix"
+	SourceNagios     = "nagios"
+	SourceCloudWatch = "cloudwatch"
+)
+
+// WebhookAlert is an alert normalized from an external monitoring system,
+// in the form notification-manager accepts.
+type WebhookAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"startsAt,omitempty"`
+}
+
+// MappingRule rewrites normalized alerts before they enter the notification
+// pipeline, so one notification config covers hybrid environments.
+type MappingRule struct {
+	Name string `json:"name"`
+
+	// Source restricts the rule to alerts of this webhook source; empty
+	// applies to every source
+	Source string `json:"source,omitempty"`
+
+	// Match selects alerts whose labels carry all of these values
+	Match map[string]string `json:"match,omitempty"`
+
+	// SetLabels are merged into the alert's labels, e.g. receiver or
+	// namespace for routing
+	SetLabels map[string]string `json:"setLabels,omitempty"`
+
+	// Severity overrides the normalized severity, one of critical, error,
+	// warning, info
+	Severity string `json:"severity,omitempty"`
+
+	// Drop discards matching alerts instead of forwarding them
+	Drop bool `json:"drop,omitempty"`
+}
+
+// IngestResult says what happened to a received webhook payload.
+type IngestResult struct {
+	// alerts forwarded into the notification pipeline
+	Forwarded int `json:"forwarded"`
+	// alerts discarded by a mapping rule
+	Dropped int `json:"dropped,omitempty"`
+	// payload entries not describing a firing problem, e.g. recoveries
+	Ignored int `json:"ignored,omitempty"`
+}
+
+type WebhookOperator interface {
+	ListMappingRules() ([]MappingRule, error)
+	// SaveMappingRules replaces the mapping rules
+	SaveMappingRules(rules []MappingRule) ([]MappingRule, error)
+	// Ingest normalizes the payload of the source, applies the mapping
+	// rules and forwards the surviving alerts
+	Ingest(source string, payload []byte) (*IngestResult, error)
+}
+
+type webhookOperator struct {
+	client   kubernetes.Interface
+	endpoint string
+	dispatch DispatchOperator
+}
+
+func NewWebhookOperator(client kubernetes.Interface) WebhookOperator {
+	return &webhookOperator{
+		client:   client,
+		endpoint: webhookAlertsEndpoint,
+		dispatch: NewDispatchOperator(client),
+	}
+}
+
+func validSeverity(severity string) bool {
+	switch severity {
+	case "critical", "error", "warning", "info":
+		return true
+	}
+	return false
+}
+
+func validateMappingRules(rules []MappingRule) error {
+	names := map[string]bool{}
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("mapping rule %d has no name", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate mapping rule name %s", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.Severity != "" && !validSeverity(rule.Severity) {
+			return fmt.Errorf("mapping rule %s: severity must be critical, error, warning or info", rule.Name)
+		}
+		if rule.Drop && (len(rule.SetLabels) > 0 || rule.Severity != "") {
+			return fmt.Errorf("mapping rule %s drops the alert, setLabels and severity would have no effect", rule.Name)
+		}
+	}
+	return nil
+}
+
+// matches reports whether the rule selects an alert of the source.
+func (r MappingRule) matches(source string, alert *WebhookAlert) bool {
+	if r.Source != "" && r.Source != source {
+		return false
+	}
+	for key, value := range r.Match {
+		if alert.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// apply rewrites the alert with every matching rule in order, reporting
+// false when a rule drops it.
+func apply(rules []MappingRule, source string, alert *WebhookAlert) bool {
+	for _, rule := range rules {
+		if !rule.matches(source, alert) {
+			continue
+		}
+		if rule.Drop {
+			return false
+		}
+		for key, value := range rule.SetLabels {
+			alert.Labels[key] = value
+		}
+		if rule.Severity != "" {
+			alert.Labels["severity"] = rule.Severity
+		}
+	}
+	return true
+}
+
+func (o *webhookOperator) ListMappingRules() ([]MappingRule, error) {
+	cm, err := o.client.CoreV1().ConfigMaps(constants.KubeSphereNamespace).
+		Get(context.Background(), webhookMappingConfigMapName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return []MappingRule{}, nil
+		}
+		return nil, err
+	}
+	return unmarshalMappingRules(cm)
+}
+
+func unmarshalMappingRules(cm *corev1.ConfigMap) ([]MappingRule, error) {
+	data, ok := cm.Data[webhookMappingDataKey]
+	if !ok || data == "" {
+		return []MappingRule{}, nil
+	}
+	var rules []MappingRule
+	if err := json.Unmarshal([]byte(data), &rules); err != nil {
+		return nil, fmt.Errorf("configmap %s holds invalid mapping rules: %v", cm.Name, err)
+	}
+	return rules, nil
+}
+
+func (o *webhookOperator) SaveMappingRules(rules []MappingRule) ([]MappingRule, error) {
+	if err := validateMappingRules(rules); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      webhookMappingConfigMapName,
+			Namespace: constants.KubeSphereNamespace,
+		},
+		Data: map[string]string{webhookMappingDataKey: string(data)},
+	}
+
+	configMaps := o.client.CoreV1().ConfigMaps(constants.KubeSphereNamespace)
+	existing, err := configMaps.Get(context.Background(), webhookMappingConfigMapName, v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if _, err := configMaps.Create(context.Background(), cm, v1.CreateOptions{}); err != nil {
+			return nil, err
+		}
+		return rules, nil
+	}
+
+	existing.Data = cm.Data
+	if _, err := configMaps.Update(context.Background(), existing, v1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (o *webhookOperator) Ingest(source string, payload []byte) (*IngestResult, error) {
+	alerts, ignored, err := normalize(source, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := o.ListMappingRules()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &IngestResult{Ignored: ignored}
+	forward := make([]WebhookAlert, 0, len(alerts))
+	for i := range alerts {
+		if !apply(rules, source, &alerts[i]) {
+			result.Dropped++
+			continue
+		}
+		forward = append(forward, alerts[i])
+	}
+	result.Forwarded = len(forward)
+
+	if len(forward) == 0 {
+		return result, nil
+	}
+	// queue the alerts instead of posting them directly; an unreachable
+	// notification-manager no longer loses them, retries are driven by
+	// the dispatch controller
+	if _, err := o.dispatch.Enqueue(o.endpoint, forward); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// normalize turns the source's payload into alerts, also reporting how many
+// entries were skipped as not describing a firing problem.
+func normalize(source string, payload []byte) ([]WebhookAlert, int, error) {
+	switch source {
+	case SourceGeneric:
+		return normalizeGeneric(payload)
+	case SourceZabbix:
+		return normalizeZabbix(payload)
+	case SourceNagios:
+		return normalizeNagios(payload)
+	case SourceCloudWatch:
+		return normalizeCloudWatch(payload)
+	}
+	return nil, 0, fmt.Errorf("unknown webhook source %s, known sources are %s, %s, %s and %s",
+		source, SourceGeneric, SourceZabbix, SourceNagios, SourceCloudWatch)
+}
+
+func baseLabels(source, alertname, severity string) map[string]string {
+	return map[string]string{
+		"alertname": alertname,
+		"alerttype": "external",
+		"source":    source,
+		"severity":  severity,
+	}
+}
+
+// normalizeGeneric accepts alerts already in the normalized form, as a list
+// or a single alert, only filling in the source labels.
+func normalizeGeneric(payload []byte) ([]WebhookAlert, int, error) {
+	var alerts []WebhookAlert
+	if err := json.Unmarshal(payload, &alerts); err != nil {
+		var single WebhookAlert
+		if err := json.Unmarshal(payload, &single); err != nil {
+			return nil, 0, fmt.Errorf("generic payload must be an alert or a list of alerts: %v", err)
+		}
+		alerts = []WebhookAlert{single}
+	}
+	for i := range alerts {
+		if alerts[i].Labels == nil {
+			alerts[i].Labels = map[string]string{}
+		}
+		if alerts[i].Labels["alertname"] == "" {
+			return nil, 0, fmt.Errorf("generic alert %d has no alertname label", i)
+		}
+		alerts[i].Labels["alerttype"] = "external"
+		alerts[i].Labels["source"] = SourceGeneric
+		if alerts[i].StartsAt.IsZero() {
+			alerts[i].StartsAt = time.Now()
+		}
+	}
+	return alerts, 0, nil
+}
+
+// zabbixPayload is the body a Zabbix webhook media type is expected to send.
+type zabbixPayload struct {
+	TriggerName string `json:"trigger_name"`
+	Severity    string `json:"severity"`
+	Host        string `json:"host"`
+	HostGroup   string `json:"host_group"`
+	Message     string `json:"message"`
+	// PROBLEM or RESOLVED
+	Status string `json:"status,omitempty"`
+}
+
+func zabbixSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "disaster", "high":
+		return "critical"
+	case "average":
+		return "error"
+	case "warning":
+		return "warning"
+	}
+	return "info"
+}
+
+func normalizeZabbix(payload []byte) ([]WebhookAlert, int, error) {
+	var p zabbixPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, 0, fmt.Errorf("invalid zabbix payload: %v", err)
+	}
+	if p.TriggerName == "" {
+		return nil, 0, fmt.Errorf("zabbix payload has no trigger_name")
+	}
+	if p.Status != "" && !strings.EqualFold(p.Status, "PROBLEM") {
+		return nil, 1, nil
+	}
+
+	labels := baseLabels(SourceZabbix, p.TriggerName, zabbixSeverity(p.Severity))
+	if p.Host != "" {
+		labels["host"] = p.Host
+	}
+	if p.HostGroup != "" {
+		labels["host_group"] = p.HostGroup
+	}
+	return []WebhookAlert{{
+		Labels:      labels,
+		Annotations: map[string]string{"message": p.Message},
+		StartsAt:    time.Now(),
+	}}, 0, nil
+}
+
+// nagiosPayload is the body a Nagios notification command is expected to
+// send, service checks carry a service_description, host checks do not.
+type nagiosPayload struct {
+	HostName           string `json:"host_name"`
+	ServiceDescription string `json:"service_description,omitempty"`
+	// CRITICAL, WARNING, UNKNOWN or OK for services; DOWN, UNREACHABLE
+	// or UP for hosts
+	State  string `json:"state"`
+	Output string `json:"output,omitempty"`
+}
+
+func nagiosSeverity(state string) (string, bool) {
+	switch strings.ToUpper(state) {
+	case "CRITICAL", "DOWN":
+		return "critical", true
+	case "UNREACHABLE":
+		return "error", true
+	case "WARNING", "UNKNOWN":
+		return "warning", true
+	}
+	return "", false
+}
+
+func normalizeNagios(payload []byte) ([]WebhookAlert, int, error) {
+	var p nagiosPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, 0, fmt.Errorf("invalid nagios payload: %v", err)
+	}
+	if p.HostName == "" {
+		return nil, 0, fmt.Errorf("nagios payload has no host_name")
+	}
+	severity, firing := nagiosSeverity(p.State)
+	if !firing {
+		return nil, 1, nil
+	}
+
+	alertname := p.HostName
+	if p.ServiceDescription != "" {
+		alertname = fmt.Sprintf("%s/%s", p.HostName, p.ServiceDescription)
+	}
+	labels := baseLabels(SourceNagios, alertname, severity)
+	labels["host"] = p.HostName
+	if p.ServiceDescription != "" {
+		labels["service"] = p.ServiceDescription
+	}
+	return []WebhookAlert{{
+		Labels:      labels,
+		Annotations: map[string]string{"message": p.Output},
+		StartsAt:    time.Now(),
+	}}, 0, nil
+}
+
+// snsEnvelope is the wrapper SNS puts around a CloudWatch alarm, the alarm
+// itself is a JSON document in Message.
+type snsEnvelope struct {
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+}
+
+type cloudWatchAlarm struct {
+	AlarmName        string `json:"AlarmName"`
+	AlarmDescription string `json:"AlarmDescription,omitempty"`
+	NewStateValue    string `json:"NewStateValue"`
+	NewStateReason   string `json:"NewStateReason,omitempty"`
+	Region           string `json:"Region,omitempty"`
+}
+
+func normalizeCloudWatch(payload []byte) ([]WebhookAlert, int, error) {
+	var envelope snsEnvelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return nil, 0, fmt.Errorf("invalid SNS payload: %v", err)
+	}
+	// subscription handshakes and unsubscribe notices carry no alarm
+	if envelope.Type != "" && envelope.Type != "Notification" {
+		return nil, 1, nil
+	}
+
+	var alarm cloudWatchAlarm
+	if err := json.Unmarshal([]byte(envelope.Message), &alarm); err != nil {
+		return nil, 0, fmt.Errorf("invalid CloudWatch alarm in SNS message: %v", err)
+	}
+	if alarm.AlarmName == "" {
+		return nil, 0, fmt.Errorf("CloudWatch alarm has no AlarmName")
+	}
+
+	var severity string
+	switch alarm.NewStateValue {
+	case "ALARM":
+		severity = "critical"
+	case "INSUFFICIENT_DATA":
+		severity = "warning"
+	default:
+		// OK transitions are recoveries
+		return nil, 1, nil
+	}
+
+	labels := baseLabels(SourceCloudWatch, alarm.AlarmName, severity)
+	if alarm.Region != "" {
+		labels["region"] = alarm.Region
+	}
+	message := alarm.NewStateReason
+	if message == "" {
+		message = alarm.AlarmDescription
+	}
+	return []WebhookAlert{{
+		Labels:      labels,
+		Annotations: map[string]string{"message": message},
+		StartsAt:    time.Now(),
+	}}, 0, nil
+}
@@ -0,0 +1,180 @@
+/*
+
+  Copyright 2021 The KubeSphere Authors.
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+  See the License for the specific language governing permissions and
+  limitations under the License.
+
+*/
+
+package notification
+
+import (
+	"testing"
+)
+
+func TestNormalizeZabbix(t *testing.T) {
+	payload := []byte(`{"trigger_name": "High CPU", "severity": "High", "host": "db-01", "host_group": "databases", "message": "CPU above 90% for 5m", "status": "PROBLEM"}`)
+
+	alerts, ignored, err := normalize(SourceZabbix, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ignored != 0 || len(alerts) != 1 {
+		t.Fatalf("expected one alert, got %d alerts and %d ignored", len(alerts), ignored)
+	}
+	alert := alerts[0]
+	if alert.Labels["alertname"] != "High CPU" || alert.Labels["severity"] != "critical" {
+		t.Errorf("unexpected labels: %v", alert.Labels)
+	}
+	if alert.Labels["source"] != SourceZabbix || alert.Labels["host"] != "db-01" {
+		t.Errorf("unexpected labels: %v", alert.Labels)
+	}
+	if alert.Annotations["message"] != "CPU above 90% for 5m" {
+		t.Errorf("unexpected message: %s", alert.Annotations["message"])
+	}
+
+	// recoveries are ignored, not forwarded
+	alerts, ignored, err = normalize(SourceZabbix, []byte(`{"trigger_name": "High CPU", "status": "RESOLVED"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 0 || ignored != 1 {
+		t.Errorf("expected the recovery to be ignored, got %d alerts and %d ignored", len(alerts), ignored)
+	}
+}
+
+func TestNormalizeNagios(t *testing.T) {
+	payload := []byte(`{"host_name": "web-01", "service_description": "HTTP", "state": "CRITICAL", "output": "CRITICAL - socket timeout"}`)
+
+	alerts, _, err := normalize(SourceNagios, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("expected one alert, got %d", len(alerts))
+	}
+	alert := alerts[0]
+	if alert.Labels["alertname"] != "web-01/HTTP" || alert.Labels["severity"] != "critical" {
+		t.Errorf("unexpected labels: %v", alert.Labels)
+	}
+	if alert.Labels["service"] != "HTTP" {
+		t.Errorf("unexpected labels: %v", alert.Labels)
+	}
+
+	alerts, ignored, err := normalize(SourceNagios, []byte(`{"host_name": "web-01", "state": "UP"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 0 || ignored != 1 {
+		t.Errorf("expected the UP state to be ignored, got %d alerts and %d ignored", len(alerts), ignored)
+	}
+}
+
+func TestNormalizeCloudWatch(t *testing.T) {
+	payload := []byte(`{"Type": "Notification", "Message": "{\"AlarmName\": \"HighLatency\", \"NewStateValue\": \"ALARM\", \"NewStateReason\": \"Threshold crossed\", \"Region\": \"us-east-1\"}"}`)
+
+	alerts, _, err := normalize(SourceCloudWatch, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("expected one alert, got %d", len(alerts))
+	}
+	alert := alerts[0]
+	if alert.Labels["alertname"] != "HighLatency" || alert.Labels["severity"] != "critical" {
+		t.Errorf("unexpected labels: %v", alert.Labels)
+	}
+	if alert.Labels["region"] != "us-east-1" {
+		t.Errorf("unexpected labels: %v", alert.Labels)
+	}
+	if alert.Annotations["message"] != "Threshold crossed" {
+		t.Errorf("unexpected message: %s", alert.Annotations["message"])
+	}
+
+	// the subscription handshake carries no alarm
+	alerts, ignored, err := normalize(SourceCloudWatch, []byte(`{"Type": "SubscriptionConfirmation", "Message": "confirm"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 0 || ignored != 1 {
+		t.Errorf("expected the handshake to be ignored, got %d alerts and %d ignored", len(alerts), ignored)
+	}
+}
+
+func TestApplyMappingRules(t *testing.T) {
+	rules := []MappingRule{
+		{Name: "drop-noise", Source: SourceZabbix, Match: map[string]string{"host_group": "staging"}, Drop: true},
+		{Name: "route-databases", Match: map[string]string{"host_group": "databases"}, SetLabels: map[string]string{"receiver": "dba-team"}, Severity: "error"},
+	}
+
+	alert := WebhookAlert{Labels: map[string]string{"alertname": "High CPU", "host_group": "databases", "severity": "critical"}}
+	if !apply(rules, SourceZabbix, &alert) {
+		t.Fatal("alert should not have been dropped")
+	}
+	if alert.Labels["receiver"] != "dba-team" || alert.Labels["severity"] != "error" {
+		t.Errorf("unexpected labels after mapping: %v", alert.Labels)
+	}
+
+	noise := WebhookAlert{Labels: map[string]string{"alertname": "High CPU", "host_group": "staging"}}
+	if apply(rules, SourceZabbix, &noise) {
+		t.Error("staging alert should have been dropped")
+	}
+	// the drop rule is scoped to zabbix
+	if !apply(rules, SourceNagios, &noise) {
+		t.Error("the drop rule should not apply to other sources")
+	}
+}
+
+func TestValidateMappingRules(t *testing.T) {
+	var tests = []struct {
+		name      string
+		rules     []MappingRule
+		expectErr bool
+	}{
+		{
+			name:  "valid",
+			rules: []MappingRule{{Name: "a", Severity: "warning"}, {Name: "b", Drop: true}},
+		},
+		{
+			name:      "missing name",
+			rules:     []MappingRule{{Severity: "warning"}},
+			expectErr: true,
+		},
+		{
+			name:      "duplicate name",
+			rules:     []MappingRule{{Name: "a"}, {Name: "a"}},
+			expectErr: true,
+		},
+		{
+			name:      "unknown severity",
+			rules:     []MappingRule{{Name: "a", Severity: "disaster"}},
+			expectErr: true,
+		},
+		{
+			name:      "drop with rewrites",
+			rules:     []MappingRule{{Name: "a", Drop: true, Severity: "warning"}},
+			expectErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateMappingRules(test.rules)
+			if test.expectErr && err == nil {
+				t.Error("expected a validation error")
+			}
+			if !test.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
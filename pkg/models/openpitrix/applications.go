@@ -53,6 +53,7 @@ type ApplicationInterface interface {
 	CreateAppVersion(request *CreateAppVersionRequest) (*CreateAppVersionResponse, error)
 	ValidatePackage(request *ValidatePackageRequest) (*ValidatePackageResponse, error)
 	GetAppVersionPackage(appId, versionId string) (*GetAppVersionPackageResponse, error)
+	GetAppVersionValuesSchema(appId, versionId string) (*GetAppVersionValuesSchemaResponse, error)
 	DoAppAction(appId string, request *ActionRequest) error
 	DoAppVersionAction(versionId string, request *ActionRequest) error
 	ListAppVersionAudits(conditions *params.Conditions, orderBy string, reverse bool, limit, offset int) (*models.PageableResponse, error)
@@ -27,6 +27,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/go-openapi/strfmt"
+	"helm.sh/helm/v3/pkg/chart/loader"
 	"io"
 	"io/ioutil"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -62,6 +63,27 @@ func (c *applicationOperator) GetAppVersionPackage(appId, versionId string) (*Ge
 	}, nil
 }
 
+// GetAppVersionValuesSchema extracts the values.schema.json bundled in the chart
+// package, so the console can render typed forms for the chart values.
+func (c *applicationOperator) GetAppVersionValuesSchema(appId, versionId string) (*GetAppVersionValuesSchemaResponse, error) {
+	version, err := c.getAppVersionByVersionIdWithData(versionId)
+	if err != nil {
+		return nil, err
+	}
+
+	chrt, err := loader.LoadArchive(bytes.NewReader(version.Spec.Data))
+	if err != nil {
+		klog.Errorf("load chart of app version %s failed, error: %s", versionId, err)
+		return nil, err
+	}
+
+	return &GetAppVersionValuesSchemaResponse{
+		AppId:     appId,
+		VersionId: versionId,
+		Schema:    chrt.Schema,
+	}, nil
+}
+
 // check helm package and create helm app version if not exist
 func (c *applicationOperator) CreateAppVersion(request *CreateAppVersionRequest) (*CreateAppVersionResponse, error) {
 	if c.backingStoreClient == nil {
@@ -303,7 +325,8 @@ func (c *applicationOperator) DoAppVersionAction(versionId string, request *Acti
 		state = v1alpha1.StatePassed
 		audit.State = v1alpha1.StatePassed
 	case ActionRecover:
-		if version.Status.State != v1alpha1.StateSuspended {
+		if version.Status.State != v1alpha1.StateSuspended &&
+			version.Status.State != v1alpha1.StateDeprecated {
 
 		}
 		state = v1alpha1.StateActive
@@ -333,6 +356,13 @@ func (c *applicationOperator) DoAppVersionAction(versionId string, request *Acti
 		}
 		state = v1alpha1.StateActive
 		audit.State = v1alpha1.StateActive
+	case ActionDeprecate:
+		// a deprecated version is hidden from the store, existing releases keep running
+		if version.Status.State != v1alpha1.StateActive {
+			// todo check status
+		}
+		state = v1alpha1.StateDeprecated
+		audit.State = v1alpha1.StateDeprecated
 	default:
 		err = errors.New("action not support")
 	}
@@ -28,8 +28,9 @@ var (
 	actionNotSupport          = errors.New("action not support")
 	actionNotPermitted        = errors.New("action not permitted")
 
-	loadRepoInfoFailed = errors.New("load repo info failed")
-	downloadFileFailed = errors.New("download file failed")
-	readFileFailed     = errors.New("read file failed")
-	releaseExists      = errors.New("release exists")
+	loadRepoInfoFailed   = errors.New("load repo info failed")
+	downloadFileFailed   = errors.New("download file failed")
+	readFileFailed       = errors.New("read file failed")
+	releaseExists        = errors.New("release exists")
+	appVersionDeprecated = errors.New("application version deprecated")
 )
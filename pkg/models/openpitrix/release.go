@@ -22,6 +22,9 @@ import (
 	"errors"
 	"fmt"
 	"github.com/go-openapi/strfmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"helm.sh/helm/v3/pkg/chart/loader"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -107,6 +110,11 @@ func (c *releaseOperator) UpgradeApplication(request UpgradeClusterRequest) erro
 		return err
 	}
 
+	if err := c.validateValues(request.VersionId, request.Conf); err != nil {
+		klog.Errorf("validate values of release %s failed, error: %v", request.ClusterId, err)
+		return err
+	}
+
 	newRls := oldRls.DeepCopy()
 	newRls.Spec.ApplicationId = request.AppId
 	newRls.Spec.ApplicationVersionId = request.VersionId
@@ -143,6 +151,16 @@ func (c *releaseOperator) CreateApplication(workspace, clusterName, namespace st
 		return err
 	}
 
+	if version.State() == v1alpha1.StateDeprecated {
+		klog.Warningf("helm application version %s is deprecated, deploy not permitted", request.VersionId)
+		return appVersionDeprecated
+	}
+
+	if err := c.validateValues(request.VersionId, request.Conf); err != nil {
+		klog.Errorf("validate values of release %s failed, error: %v", request.Name, err)
+		return err
+	}
+
 	exists, err := c.releaseExists(workspace, clusterName, namespace, request.Name)
 
 	if err != nil && !apierrors.IsNotFound(err) {
@@ -396,6 +414,40 @@ func (c *releaseOperator) DeleteApplication(workspace, clusterName, namespace, i
 	return nil
 }
 
+// validateValues rejects values that do not conform to the values.schema.json
+// bundled in the chart, before the helm release is created or upgraded.
+func (c *releaseOperator) validateValues(versionId, conf string) error {
+	if conf == "" {
+		return nil
+	}
+
+	version, err := c.getAppVersionWithData("", versionId)
+	if err != nil {
+		return err
+	}
+
+	if len(version.Spec.Data) == 0 {
+		// chart data is not cached locally, nothing to validate against
+		return nil
+	}
+
+	chrt, err := loader.LoadArchive(bytes.NewReader(version.Spec.Data))
+	if err != nil {
+		klog.Errorf("load chart of app version %s failed, error: %s", versionId, err)
+		return err
+	}
+
+	if len(chrt.Schema) == 0 {
+		return nil
+	}
+
+	if err := validateAgainstValuesSchema(chrt.Schema, []byte(conf)); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return nil
+}
+
 // get app version from repo and helm application
 func (c *releaseOperator) getAppVersion(repoId, id string) (ret *v1alpha1.HelmApplicationVersion, err error) {
 	if ver, exists, _ := c.cachedRepos.GetAppVersion(id); exists {
@@ -14,6 +14,8 @@ limitations under the License.
 package openpitrix
 
 import (
+	"encoding/json"
+
 	"github.com/go-openapi/strfmt"
 )
 
@@ -241,6 +243,18 @@ type GetAppVersionPackageResponse struct {
 	VersionId string `json:"version_id,omitempty"`
 }
 
+type GetAppVersionValuesSchemaResponse struct {
+
+	// app id of the schema
+	AppId string `json:"app_id,omitempty"`
+
+	// values.schema.json bundled in the chart package, empty if the chart does not have one
+	Schema json.RawMessage `json:"schema,omitempty"`
+
+	// version id of the schema
+	VersionId string `json:"version_id,omitempty"`
+}
+
 type GetAppVersionPackageFilesResponse struct {
 
 	// filename map to content
@@ -909,5 +923,6 @@ const (
 	ActionReject    = "reject"
 	ActionSubmit    = "submit"
 	ActionRelease   = "release"
+	ActionDeprecate = "deprecate"
 	ActionIndex     = "index"
 )
@@ -17,9 +17,12 @@ limitations under the License.
 package openpitrix
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/Masterminds/semver/v3"
+	"github.com/go-openapi/spec"
 	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/validate"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/resource"
@@ -32,6 +35,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/utils/stringutils"
 	"path"
 	"regexp"
+	"sigs.k8s.io/yaml"
 	"strings"
 	"time"
 )
@@ -601,6 +605,12 @@ func filterAppReviews(versions []*v1alpha1.HelmApplicationVersion, conditions *p
 			}
 		}
 
+		if conditions.Match[WorkspaceLabel] != "" {
+			if versions[i].GetWorkspace() != conditions.Match[WorkspaceLabel] {
+				continue
+			}
+		}
+
 		if conditions.Match[Status] != "" {
 			states := strings.Split(conditions.Match[Status], "|")
 			state := versions[i].State()
@@ -785,3 +795,21 @@ func parseChartVersionName(name string) (version, appVersion string) {
 	appVersion = strings.TrimSpace(appVersion)
 	return
 }
+
+// validateAgainstValuesSchema validates the submitted chart values against the
+// values.schema.json shipped with the chart. The values are a yaml document.
+func validateAgainstValuesSchema(schemaData, values []byte) error {
+	schema := &spec.Schema{}
+	err := json.Unmarshal(schemaData, schema)
+	if err != nil {
+		return fmt.Errorf("load values schema failed: %v", err)
+	}
+
+	var obj interface{}
+	err = yaml.Unmarshal(values, &obj)
+	if err != nil {
+		return fmt.Errorf("parse values failed: %v", err)
+	}
+
+	return validate.AgainstSchema(schema, obj, strfmt.Default)
+}
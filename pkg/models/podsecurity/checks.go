@@ -0,0 +1,154 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podsecurity
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// the capabilities the baseline level allows containers to add
+var baselineAllowedCapabilities = map[corev1.Capability]bool{
+	"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true,
+	"FSETID": true, "KILL": true, "MKNOD": true, "NET_BIND_SERVICE": true,
+	"SETFCAP": true, "SETGID": true, "SETPCAP": true, "SETUID": true,
+	"SYS_CHROOT": true,
+}
+
+// the volume types the restricted level allows
+var restrictedAllowedVolumes = map[string]bool{
+	"configMap": true, "csi": true, "downwardAPI": true, "emptyDir": true,
+	"persistentVolumeClaim": true, "projected": true, "secret": true,
+}
+
+// checkPodTemplate evaluates a pod template against a Pod Security Standards
+// level and returns the violations in human readable form. The checks cover
+// the common rejection causes, not every last rule of the standard.
+func checkPodTemplate(template *corev1.PodTemplateSpec, level string) []string {
+	violations := checkBaseline(template)
+	if level == LevelRestricted {
+		violations = append(violations, checkRestricted(template)...)
+	}
+	return violations
+}
+
+func checkBaseline(template *corev1.PodTemplateSpec) []string {
+	var violations []string
+	spec := &template.Spec
+
+	if spec.HostNetwork {
+		violations = append(violations, "host network is not allowed")
+	}
+	if spec.HostPID {
+		violations = append(violations, "host PID namespace is not allowed")
+	}
+	if spec.HostIPC {
+		violations = append(violations, "host IPC namespace is not allowed")
+	}
+
+	for _, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			violations = append(violations, fmt.Sprintf("hostPath volume %s is not allowed", volume.Name))
+		}
+	}
+
+	for _, container := range allContainers(spec) {
+		sc := container.SecurityContext
+		if sc != nil && sc.Privileged != nil && *sc.Privileged {
+			violations = append(violations, fmt.Sprintf("container %s is privileged", container.Name))
+		}
+		if sc != nil && sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if !baselineAllowedCapabilities[capability] {
+					violations = append(violations, fmt.Sprintf("container %s adds capability %s", container.Name, capability))
+				}
+			}
+		}
+		for _, port := range container.Ports {
+			if port.HostPort != 0 {
+				violations = append(violations, fmt.Sprintf("container %s uses host port %d", container.Name, port.HostPort))
+			}
+		}
+	}
+
+	return violations
+}
+
+func checkRestricted(template *corev1.PodTemplateSpec) []string {
+	var violations []string
+	spec := &template.Spec
+
+	for _, volume := range spec.Volumes {
+		if !restrictedAllowedVolumes[volumeType(&volume)] {
+			violations = append(violations, fmt.Sprintf("volume %s has a type the restricted level does not allow", volume.Name))
+		}
+	}
+
+	podRunAsNonRoot := spec.SecurityContext != nil && spec.SecurityContext.RunAsNonRoot != nil && *spec.SecurityContext.RunAsNonRoot
+
+	for _, container := range allContainers(spec) {
+		sc := container.SecurityContext
+		if sc == nil || sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			violations = append(violations, fmt.Sprintf("container %s must set allowPrivilegeEscalation to false", container.Name))
+		}
+		if !podRunAsNonRoot && (sc == nil || sc.RunAsNonRoot == nil || !*sc.RunAsNonRoot) {
+			violations = append(violations, fmt.Sprintf("container %s must set runAsNonRoot to true", container.Name))
+		}
+		if sc == nil || sc.Capabilities == nil || !dropsAll(sc.Capabilities.Drop) {
+			violations = append(violations, fmt.Sprintf("container %s must drop all capabilities", container.Name))
+		}
+	}
+
+	return violations
+}
+
+func allContainers(spec *corev1.PodSpec) []corev1.Container {
+	containers := make([]corev1.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	containers = append(containers, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+	return containers
+}
+
+func dropsAll(dropped []corev1.Capability) bool {
+	for _, capability := range dropped {
+		if capability == "ALL" {
+			return true
+		}
+	}
+	return false
+}
+
+func volumeType(volume *corev1.Volume) string {
+	switch {
+	case volume.ConfigMap != nil:
+		return "configMap"
+	case volume.CSI != nil:
+		return "csi"
+	case volume.DownwardAPI != nil:
+		return "downwardAPI"
+	case volume.EmptyDir != nil:
+		return "emptyDir"
+	case volume.PersistentVolumeClaim != nil:
+		return "persistentVolumeClaim"
+	case volume.Projected != nil:
+		return "projected"
+	case volume.Secret != nil:
+		return "secret"
+	}
+	return "other"
+}
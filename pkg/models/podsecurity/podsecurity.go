@@ -0,0 +1,236 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podsecurity
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+// the well known Pod Security Standards labels,
+// see https://kubernetes.io/docs/concepts/security/pod-security-standards/
+const (
+	EnforceLabel = "pod-security.kubernetes.io/enforce"
+	AuditLabel   = "pod-security.kubernetes.io/audit"
+	WarnLabel    = "pod-security.kubernetes.io/warn"
+
+	LevelPrivileged = "privileged"
+	LevelBaseline   = "baseline"
+	LevelRestricted = "restricted"
+)
+
+// Levels holds the Pod Security Standards levels of one namespace, an empty
+// level means the corresponding mode is not configured.
+type Levels struct {
+	Enforce string `json:"enforce,omitempty"`
+	Audit   string `json:"audit,omitempty"`
+	Warn    string `json:"warn,omitempty"`
+}
+
+// NamespaceLevels pairs a namespace with its configured levels.
+type NamespaceLevels struct {
+	Namespace string `json:"namespace"`
+	Levels    `json:",inline"`
+}
+
+// WorkloadViolations lists why one workload would be rejected at a level.
+type WorkloadViolations struct {
+	Kind       string   `json:"kind"`
+	Name       string   `json:"name"`
+	Violations []string `json:"violations"`
+}
+
+// Report is the result of a dry run, listing workloads in the namespace
+// that would be rejected if the level were enforced.
+type Report struct {
+	Namespace string               `json:"namespace"`
+	Level     string               `json:"level"`
+	Workloads []WorkloadViolations `json:"workloads"`
+}
+
+type Operator interface {
+	// GetLevels returns the levels configured on the namespace
+	GetLevels(namespace string) (*Levels, error)
+
+	// SetLevels updates the namespace labels, an empty level removes the mode
+	SetLevels(namespace string, levels *Levels) error
+
+	// ListWorkspaceLevels returns the levels of every namespace in the workspace
+	ListWorkspaceLevels(workspace string) ([]NamespaceLevels, error)
+
+	// SetWorkspaceLevels applies the levels to every namespace in the workspace
+	SetWorkspaceLevels(workspace string, levels *Levels) error
+
+	// DryRun reports workloads that would be rejected at the given level
+	DryRun(namespace, level string) (*Report, error)
+}
+
+type operator struct {
+	client    kubernetes.Interface
+	informers informers.SharedInformerFactory
+}
+
+func NewOperator(client kubernetes.Interface, informers informers.SharedInformerFactory) Operator {
+	return &operator{client: client, informers: informers}
+}
+
+func validLevel(level string) bool {
+	return level == "" || level == LevelPrivileged || level == LevelBaseline || level == LevelRestricted
+}
+
+func validateLevels(levels *Levels) error {
+	for _, level := range []string{levels.Enforce, levels.Audit, levels.Warn} {
+		if !validLevel(level) {
+			return fmt.Errorf("invalid pod security level %s, one of privileged, baseline, restricted expected", level)
+		}
+	}
+	return nil
+}
+
+func (o *operator) GetLevels(namespace string) (*Levels, error) {
+	ns, err := o.informers.Core().V1().Namespaces().Lister().Get(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &Levels{
+		Enforce: ns.Labels[EnforceLabel],
+		Audit:   ns.Labels[AuditLabel],
+		Warn:    ns.Labels[WarnLabel],
+	}, nil
+}
+
+func (o *operator) SetLevels(namespace string, levels *Levels) error {
+	if err := validateLevels(levels); err != nil {
+		return err
+	}
+
+	ns, err := o.client.CoreV1().Namespaces().Get(context.Background(), namespace, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if ns.Labels == nil {
+		ns.Labels = map[string]string{}
+	}
+	for label, level := range map[string]string{
+		EnforceLabel: levels.Enforce,
+		AuditLabel:   levels.Audit,
+		WarnLabel:    levels.Warn,
+	} {
+		if level == "" {
+			delete(ns.Labels, label)
+		} else {
+			ns.Labels[label] = level
+		}
+	}
+
+	_, err = o.client.CoreV1().Namespaces().Update(context.Background(), ns, metav1.UpdateOptions{})
+	return err
+}
+
+func (o *operator) ListWorkspaceLevels(workspace string) ([]NamespaceLevels, error) {
+	selector := labels.SelectorFromSet(labels.Set{constants.WorkspaceLabelKey: workspace})
+	namespaces, err := o.informers.Core().V1().Namespaces().Lister().List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]NamespaceLevels, 0, len(namespaces))
+	for _, ns := range namespaces {
+		result = append(result, NamespaceLevels{
+			Namespace: ns.Name,
+			Levels: Levels{
+				Enforce: ns.Labels[EnforceLabel],
+				Audit:   ns.Labels[AuditLabel],
+				Warn:    ns.Labels[WarnLabel],
+			},
+		})
+	}
+	return result, nil
+}
+
+func (o *operator) SetWorkspaceLevels(workspace string, levels *Levels) error {
+	if err := validateLevels(levels); err != nil {
+		return err
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{constants.WorkspaceLabelKey: workspace})
+	namespaces, err := o.informers.Core().V1().Namespaces().Lister().List(selector)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		if err := o.SetLevels(ns.Name, levels); err != nil {
+			klog.Errorf("Failed to set pod security levels of namespace %s: %v", ns.Name, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *operator) DryRun(namespace, level string) (*Report, error) {
+	if level != LevelBaseline && level != LevelRestricted {
+		return nil, fmt.Errorf("invalid pod security level %s, baseline or restricted expected", level)
+	}
+
+	report := &Report{
+		Namespace: namespace,
+		Level:     level,
+		Workloads: make([]WorkloadViolations, 0),
+	}
+
+	deployments, err := o.informers.Apps().V1().Deployments().Lister().Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range deployments {
+		if violations := checkPodTemplate(&deployment.Spec.Template, level); len(violations) > 0 {
+			report.Workloads = append(report.Workloads, WorkloadViolations{Kind: "Deployment", Name: deployment.Name, Violations: violations})
+		}
+	}
+
+	statefulSets, err := o.informers.Apps().V1().StatefulSets().Lister().StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, statefulSet := range statefulSets {
+		if violations := checkPodTemplate(&statefulSet.Spec.Template, level); len(violations) > 0 {
+			report.Workloads = append(report.Workloads, WorkloadViolations{Kind: "StatefulSet", Name: statefulSet.Name, Violations: violations})
+		}
+	}
+
+	daemonSets, err := o.informers.Apps().V1().DaemonSets().Lister().DaemonSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, daemonSet := range daemonSets {
+		if violations := checkPodTemplate(&daemonSet.Spec.Template, level); len(violations) > 0 {
+			report.Workloads = append(report.Workloads, WorkloadViolations{Kind: "DaemonSet", Name: daemonSet.Name, Violations: violations})
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package preferences stores per-user console settings in UserPreference
+// custom resources named after the user, replacing browser localStorage so
+// the settings follow the user across devices.
+package preferences
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	consolev1alpha1 "kubesphere.io/kubesphere/pkg/apis/console/v1alpha1"
+)
+
+var preferenceGVR = schema.GroupVersionResource{
+	Group:    consolev1alpha1.SchemeGroupVersion.Group,
+	Version:  consolev1alpha1.SchemeGroupVersion.Version,
+	Resource: consolev1alpha1.ResourcePluralUserPreference,
+}
+
+type Operator interface {
+	// Get returns the preferences of the user, empty defaults when the
+	// user has none saved yet
+	Get(username string) (*consolev1alpha1.UserPreferenceSpec, error)
+
+	// Update replaces the preferences of the user
+	Update(username string, spec *consolev1alpha1.UserPreferenceSpec) error
+
+	// Delete resets the preferences of the user to the defaults
+	Delete(username string) error
+}
+
+type operator struct {
+	client dynamic.Interface
+}
+
+func NewOperator(client dynamic.Interface) Operator {
+	return &operator{client: client}
+}
+
+func (o *operator) Get(username string) (*consolev1alpha1.UserPreferenceSpec, error) {
+	if username == "" {
+		return nil, fmt.Errorf("preferences need an authenticated user")
+	}
+
+	obj, err := o.client.Resource(preferenceGVR).Get(context.Background(), username, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return &consolev1alpha1.UserPreferenceSpec{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	preference := &consolev1alpha1.UserPreference{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, preference); err != nil {
+		return nil, err
+	}
+	return &preference.Spec, nil
+}
+
+func (o *operator) Update(username string, spec *consolev1alpha1.UserPreferenceSpec) error {
+	if username == "" {
+		return fmt.Errorf("preferences need an authenticated user")
+	}
+
+	preference := &consolev1alpha1.UserPreference{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: consolev1alpha1.SchemeGroupVersion.String(),
+			Kind:       consolev1alpha1.ResourceKindUserPreference,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: username,
+		},
+		Spec: *spec,
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(preference)
+	if err != nil {
+		return err
+	}
+	obj := &unstructured.Unstructured{Object: content}
+
+	ctx := context.Background()
+	current, err := o.client.Resource(preferenceGVR).Get(ctx, username, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = o.client.Resource(preferenceGVR).Create(ctx, obj, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	obj.SetResourceVersion(current.GetResourceVersion())
+	_, err = o.client.Resource(preferenceGVR).Update(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
+
+func (o *operator) Delete(username string) error {
+	if username == "" {
+		return fmt.Errorf("preferences need an authenticated user")
+	}
+
+	err := o.client.Resource(preferenceGVR).Delete(context.Background(), username, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
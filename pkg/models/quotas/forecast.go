@@ -0,0 +1,206 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quotas
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	quotav1alpha2 "kubesphere.io/kubesphere/pkg/apis/quota/v1alpha2"
+	tenantv1alpha1 "kubesphere.io/kubesphere/pkg/apis/tenant/v1alpha1"
+	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const (
+	// DefaultForecastHorizon flags workspaces projected to hit their quota
+	// within this period
+	DefaultForecastHorizon = 7 * 24 * time.Hour
+
+	// the trend is fitted over this much usage history
+	forecastWindow = 24 * time.Hour
+	forecastStep   = time.Hour
+)
+
+// forecastMetrics maps the quota resources whose usage trend can be read
+// from workspace metrics to their metric names.
+var forecastMetrics = map[corev1.ResourceName]string{
+	corev1.ResourceCPU:                "workspace_cpu_usage",
+	corev1.ResourceRequestsCPU:        "workspace_cpu_usage",
+	corev1.ResourceLimitsCPU:          "workspace_cpu_usage",
+	corev1.ResourceMemory:             "workspace_memory_usage",
+	corev1.ResourceRequestsMemory:     "workspace_memory_usage",
+	corev1.ResourceLimitsMemory:       "workspace_memory_usage",
+	corev1.ResourcePods:               "workspace_pod_count",
+	corev1.ResourceName("count/pods"): "workspace_pod_count",
+}
+
+// QuotaForecast projects one quota resource of a workspace forward along
+// its recent usage trend.
+type QuotaForecast struct {
+	Workspace string `json:"workspace"`
+	Quota     string `json:"quota"`
+	Resource  string `json:"resource"`
+	Hard      string `json:"hard"`
+	Used      string `json:"used"`
+
+	// DailyGrowth is the fitted usage growth per day, in the resource's
+	// base unit
+	DailyGrowth float64 `json:"dailyGrowth"`
+
+	// DaysLeft is the projected number of days until the hard limit is
+	// reached
+	DaysLeft float64 `json:"daysLeft"`
+
+	// ExhaustionTime is when the hard limit is projected to be reached
+	ExhaustionTime string `json:"exhaustionTime"`
+}
+
+// QuotaForecaster projects workspace quota usage forward, so a workspace
+// about to hit its quota can be flagged before deploys start failing.
+type QuotaForecaster interface {
+	// ForecastQuota returns the quota resources projected to reach their
+	// hard limit within the horizon
+	ForecastQuota(quota *quotav1alpha2.ResourceQuota, horizon time.Duration) []QuotaForecast
+
+	// ListHotspots returns the projections of every workspace quota,
+	// soonest exhaustion first
+	ListHotspots(horizon time.Duration) ([]QuotaForecast, error)
+}
+
+type quotaForecaster struct {
+	ksclient         kubesphere.Interface
+	monitoringClient monitoring.Interface
+}
+
+func NewQuotaForecaster(ksclient kubesphere.Interface, monitoringClient monitoring.Interface) QuotaForecaster {
+	return &quotaForecaster{ksclient: ksclient, monitoringClient: monitoringClient}
+}
+
+func (f *quotaForecaster) ListHotspots(horizon time.Duration) ([]QuotaForecast, error) {
+	quotas, err := f.ksclient.QuotaV1alpha2().ResourceQuotas().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	hotspots := make([]QuotaForecast, 0)
+	for i := range quotas.Items {
+		hotspots = append(hotspots, f.ForecastQuota(&quotas.Items[i], horizon)...)
+	}
+	sort.Slice(hotspots, func(i, j int) bool {
+		if hotspots[i].DaysLeft != hotspots[j].DaysLeft {
+			return hotspots[i].DaysLeft < hotspots[j].DaysLeft
+		}
+		return hotspots[i].Workspace < hotspots[j].Workspace
+	})
+	return hotspots, nil
+}
+
+func (f *quotaForecaster) ForecastQuota(quota *quotav1alpha2.ResourceQuota, horizon time.Duration) []QuotaForecast {
+	workspace := quota.Labels[tenantv1alpha1.WorkspaceLabel]
+	if workspace == "" {
+		return nil
+	}
+
+	forecasts := make([]QuotaForecast, 0)
+	now := time.Now()
+	for name, hard := range quota.Status.Total.Hard {
+		metric, ok := forecastMetrics[name]
+		if !ok {
+			continue
+		}
+
+		used := quota.Status.Total.Used[name]
+		remaining := quantityValue(name, hard) - quantityValue(name, used)
+
+		slope := f.usageSlope(metric, workspace, now)
+		dailyGrowth := slope * (24 * time.Hour).Seconds()
+		if dailyGrowth <= 0 {
+			// flat or shrinking usage never hits the limit
+			continue
+		}
+
+		daysLeft := remaining / dailyGrowth
+		if daysLeft < 0 {
+			daysLeft = 0
+		}
+		if daysLeft > horizon.Hours()/24 {
+			continue
+		}
+
+		forecasts = append(forecasts, QuotaForecast{
+			Workspace:      workspace,
+			Quota:          quota.Name,
+			Resource:       string(name),
+			Hard:           hard.String(),
+			Used:           used.String(),
+			DailyGrowth:    dailyGrowth,
+			DaysLeft:       daysLeft,
+			ExhaustionTime: now.Add(time.Duration(daysLeft * 24 * float64(time.Hour))).UTC().Format(time.RFC3339),
+		})
+	}
+	return forecasts
+}
+
+// usageSlope fits the workspace's recent usage of the metric with least
+// squares and returns the growth per second.
+func (f *quotaForecaster) usageSlope(metric, workspace string, now time.Time) float64 {
+	metrics := f.monitoringClient.GetNamedMetricsOverTime([]string{metric}, now.Add(-forecastWindow), now, forecastStep,
+		monitoring.WorkspaceOption{WorkspaceName: workspace, ResourceFilter: workspace})
+
+	points := make([]monitoring.Point, 0)
+	for _, m := range metrics {
+		for _, value := range m.MetricValues {
+			points = append(points, value.Series...)
+		}
+	}
+	return leastSquaresSlope(points)
+}
+
+func leastSquaresSlope(points []monitoring.Point) float64 {
+	if len(points) < 2 {
+		return 0
+	}
+	var sumT, sumV, sumTT, sumTV float64
+	for _, p := range points {
+		sumT += p.Timestamp()
+		sumV += p.Value()
+		sumTT += p.Timestamp() * p.Timestamp()
+		sumTV += p.Timestamp() * p.Value()
+	}
+	n := float64(len(points))
+	denominator := n*sumTT - sumT*sumT
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumTV - sumT*sumV) / denominator
+}
+
+// quantityValue reads the quantity in the resource's base unit: cores for
+// cpu, bytes and counts for everything else.
+func quantityValue(name corev1.ResourceName, q resource.Quantity) float64 {
+	switch name {
+	case corev1.ResourceCPU, corev1.ResourceRequestsCPU, corev1.ResourceLimitsCPU:
+		return float64(q.MilliValue()) / 1000
+	}
+	return float64(q.Value())
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	monitoringmodel "kubesphere.io/kubesphere/pkg/models/monitoring"
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const (
+	// PreviousReplicasAnnotation keeps the replica count of a workload
+	// scaled to zero, so it can be restored on wake
+	PreviousReplicasAnnotation = "recommendation.kubesphere.io/previous-replicas"
+
+	// ScaleDownScheduleAnnotation holds a cron expression, idle deployments
+	// carrying it are scaled to zero when the schedule fires
+	ScaleDownScheduleAnnotation = "recommendation.kubesphere.io/scale-down-schedule"
+
+	// workloads below these averages over the window count as idle
+	idleCPUThresholdCores   = 0.01
+	idleNetworkThresholdBps = 1024
+	hoursPerMonth           = 730
+	idleMetricStep          = time.Hour
+	defaultIdlenessWindow   = 24 * time.Hour
+)
+
+// IdleWorkload describes a deployment with near-zero usage over the
+// analyzed window and what scaling it to zero would save.
+type IdleWorkload struct {
+	Namespace string `json:"namespace" description:"namespace of the deployment"`
+	Name      string `json:"name" description:"deployment name"`
+
+	Replicas int32 `json:"replicas" description:"current replica count"`
+
+	AverageCPUUsage     float64 `json:"averageCpuUsage" description:"average cpu cores used over the window"`
+	AverageNetworkUsage float64 `json:"averageNetworkUsage" description:"average network bytes per second over the window"`
+
+	EstimatedMonthlySavings float64 `json:"estimatedMonthlySavings" description:"monthly cost of the requested resources, zero when no price sheet is configured"`
+	CurrencyUnit            string  `json:"currencyUnit,omitempty" description:"currency of the savings"`
+}
+
+// ListIdleDeployments flags deployments whose cpu and network usage stayed
+// near zero over the window.
+func (o *operator) ListIdleDeployments(namespace string, window time.Duration) ([]IdleWorkload, error) {
+	if window <= 0 {
+		window = defaultIdlenessWindow
+	}
+
+	deployments, err := o.informers.Apps().V1().Deployments().Lister().Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	start := end.Add(-window)
+
+	result := make([]IdleWorkload, 0)
+	for _, deployment := range deployments {
+		if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas == 0 {
+			continue
+		}
+
+		cpu, network := o.deploymentUsage(namespace, deployment.Name, start, end)
+		if cpu >= idleCPUThresholdCores || network >= idleNetworkThresholdBps {
+			continue
+		}
+
+		idle := IdleWorkload{
+			Namespace:           namespace,
+			Name:                deployment.Name,
+			Replicas:            *deployment.Spec.Replicas,
+			AverageCPUUsage:     cpu,
+			AverageNetworkUsage: network,
+		}
+		idle.EstimatedMonthlySavings, idle.CurrencyUnit = estimateMonthlySavings(deployment)
+		result = append(result, idle)
+	}
+	return result, nil
+}
+
+// ScaleToZero scales the deployment down to zero replicas, keeping the
+// current replica count so Wake can restore it.
+func (o *operator) ScaleToZero(namespace, name string) error {
+	ctx := context.Background()
+	deployment, err := o.k8s.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas == 0 {
+		return nil
+	}
+
+	if deployment.Annotations == nil {
+		deployment.Annotations = make(map[string]string)
+	}
+	deployment.Annotations[PreviousReplicasAnnotation] = strconv.Itoa(int(*deployment.Spec.Replicas))
+	zero := int32(0)
+	deployment.Spec.Replicas = &zero
+
+	_, err = o.k8s.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{})
+	return err
+}
+
+// Wake restores the replica count recorded when the deployment was scaled
+// to zero.
+func (o *operator) Wake(namespace, name string) error {
+	ctx := context.Background()
+	deployment, err := o.k8s.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	previous, ok := deployment.Annotations[PreviousReplicasAnnotation]
+	if !ok {
+		return fmt.Errorf("deployment %s/%s was not scaled to zero", namespace, name)
+	}
+	replicas, err := strconv.Atoi(previous)
+	if err != nil {
+		return fmt.Errorf("invalid value %q of annotation %s", previous, PreviousReplicasAnnotation)
+	}
+
+	restored := int32(replicas)
+	deployment.Spec.Replicas = &restored
+	delete(deployment.Annotations, PreviousReplicasAnnotation)
+
+	_, err = o.k8s.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{})
+	return err
+}
+
+// deploymentUsage returns the average cpu cores and network bytes per second
+// of the deployment over the window.
+func (o *operator) deploymentUsage(namespace, name string, start, end time.Time) (cpu, network float64) {
+	metrics := o.monitoringClient.GetNamedMetricsOverTime(
+		[]string{"workload_cpu_usage", "workload_net_bytes_transmitted", "workload_net_bytes_received"},
+		start, end, idleMetricStep, monitoring.WorkloadOption{
+			NamespaceName:  namespace,
+			WorkloadKind:   "deployment",
+			ResourceFilter: fmt.Sprintf("^%s$", name),
+		})
+
+	for _, metric := range metrics {
+		var sum float64
+		var count int
+		for _, value := range metric.MetricValues {
+			for _, point := range value.Series {
+				sum += point[1]
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		avg := sum / float64(count)
+		switch metric.MetricName {
+		case "workload_cpu_usage":
+			cpu = avg
+		case "workload_net_bytes_transmitted", "workload_net_bytes_received":
+			network += avg
+		}
+	}
+	return
+}
+
+// estimateMonthlySavings prices the requested resources of all replicas with
+// the metering price sheet, zero when none is configured.
+func estimateMonthlySavings(deployment *appsv1.Deployment) (float64, string) {
+	meterConfig, err := monitoringmodel.LoadYaml()
+	if err != nil {
+		return 0, ""
+	}
+	priceInfo := meterConfig.GetPriceInfo()
+
+	var cpuCores, memoryBytes float64
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		cpuCores += float64(container.Resources.Requests.Cpu().MilliValue()) / 1000
+		memoryBytes += float64(container.Resources.Requests.Memory().Value())
+	}
+
+	replicas := float64(*deployment.Spec.Replicas)
+	perHour := cpuCores*priceInfo.CpuPerCorePerHour + memoryBytes/(1<<30)*priceInfo.MemPerGigabytesPerHour
+	return perHour * replicas * hoursPerMonth, priceInfo.CurrencyUnit
+}
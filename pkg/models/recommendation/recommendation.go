@@ -0,0 +1,256 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+const (
+	KindDeployment  = "deployments"
+	KindStatefulSet = "statefulsets"
+
+	// usage history window the recommendations are computed from
+	lookback = 7 * 24 * time.Hour
+	step     = time.Hour
+
+	// requests cover the 90th usage percentile, limits cover the observed
+	// peak with some headroom
+	requestPercentile = 0.9
+	limitHeadroom     = 1.15
+)
+
+// ContainerRecommendation holds suggested resource requests and limits for
+// one container of a workload.
+type ContainerRecommendation struct {
+	ContainerName string `json:"containerName" description:"container name"`
+
+	CPURequest    string `json:"cpuRequest" description:"suggested cpu request"`
+	CPULimit      string `json:"cpuLimit" description:"suggested cpu limit"`
+	MemoryRequest string `json:"memoryRequest" description:"suggested memory request"`
+	MemoryLimit   string `json:"memoryLimit" description:"suggested memory limit"`
+}
+
+// WorkloadRecommendation is the right-sizing suggestion for a workload,
+// computed from its containers' historical usage.
+type WorkloadRecommendation struct {
+	Namespace string `json:"namespace" description:"namespace of the workload"`
+	Kind      string `json:"kind" description:"workload kind, deployments or statefulsets"`
+	Name      string `json:"name" description:"workload name"`
+
+	Containers []ContainerRecommendation `json:"containers" description:"per container suggestions"`
+}
+
+type Operator interface {
+	GetWorkloadRecommendation(namespace, kind, name string) (*WorkloadRecommendation, error)
+	ApplyWorkloadRecommendation(namespace, kind, name string) (*WorkloadRecommendation, error)
+	ListIdleDeployments(namespace string, window time.Duration) ([]IdleWorkload, error)
+	ScaleToZero(namespace, name string) error
+	Wake(namespace, name string) error
+}
+
+type operator struct {
+	k8s              kubernetes.Interface
+	informers        informers.SharedInformerFactory
+	monitoringClient monitoring.Interface
+}
+
+func NewOperator(k8s kubernetes.Interface, informers informers.SharedInformerFactory, monitoringClient monitoring.Interface) Operator {
+	return &operator{k8s: k8s, informers: informers, monitoringClient: monitoringClient}
+}
+
+func (o *operator) GetWorkloadRecommendation(namespace, kind, name string) (*WorkloadRecommendation, error) {
+	containers, selector, err := o.workloadContainers(namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := o.informers.Core().V1().Pods().Lister().Pods(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendation := &WorkloadRecommendation{Namespace: namespace, Kind: kind, Name: name}
+	for _, container := range containers {
+		cpu, memory := o.containerUsageHistory(namespace, pods, container.Name)
+		recommendation.Containers = append(recommendation.Containers, ContainerRecommendation{
+			ContainerName: container.Name,
+			CPURequest:    cpuQuantity(percentile(cpu, requestPercentile)).String(),
+			CPULimit:      cpuQuantity(peak(cpu) * limitHeadroom).String(),
+			MemoryRequest: memoryQuantity(percentile(memory, requestPercentile)).String(),
+			MemoryLimit:   memoryQuantity(peak(memory) * limitHeadroom).String(),
+		})
+	}
+	return recommendation, nil
+}
+
+func (o *operator) ApplyWorkloadRecommendation(namespace, kind, name string) (*WorkloadRecommendation, error) {
+	recommendation, err := o.GetWorkloadRecommendation(namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	resourcesByContainer := make(map[string]corev1.ResourceRequirements, len(recommendation.Containers))
+	for _, container := range recommendation.Containers {
+		resourcesByContainer[container.ContainerName] = corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(container.CPURequest),
+				corev1.ResourceMemory: resource.MustParse(container.MemoryRequest),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(container.CPULimit),
+				corev1.ResourceMemory: resource.MustParse(container.MemoryLimit),
+			},
+		}
+	}
+
+	ctx := context.Background()
+	switch kind {
+	case KindDeployment:
+		deploy, err := o.k8s.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		applyResources(deploy.Spec.Template.Spec.Containers, resourcesByContainer)
+		if _, err = o.k8s.AppsV1().Deployments(namespace).Update(ctx, deploy, metav1.UpdateOptions{}); err != nil {
+			return nil, err
+		}
+	case KindStatefulSet:
+		sts, err := o.k8s.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		applyResources(sts.Spec.Template.Spec.Containers, resourcesByContainer)
+		if _, err = o.k8s.AppsV1().StatefulSets(namespace).Update(ctx, sts, metav1.UpdateOptions{}); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported workload kind %s", kind)
+	}
+	return recommendation, nil
+}
+
+func (o *operator) workloadContainers(namespace, kind, name string) ([]corev1.Container, labels.Selector, error) {
+	switch kind {
+	case KindDeployment:
+		deploy, err := o.informers.Apps().V1().Deployments().Lister().Deployments(namespace).Get(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		selector, err := metav1.LabelSelectorAsSelector(deploy.Spec.Selector)
+		if err != nil {
+			return nil, nil, err
+		}
+		return deploy.Spec.Template.Spec.Containers, selector, nil
+	case KindStatefulSet:
+		sts, err := o.informers.Apps().V1().StatefulSets().Lister().StatefulSets(namespace).Get(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		selector, err := metav1.LabelSelectorAsSelector(sts.Spec.Selector)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sts.Spec.Template.Spec.Containers, selector, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported workload kind %s", kind)
+	}
+}
+
+// containerUsageHistory collects cpu and memory samples of the container
+// across all pods of the workload over the lookback window.
+func (o *operator) containerUsageHistory(namespace string, pods []*corev1.Pod, containerName string) (cpu, memory []float64) {
+	end := time.Now()
+	start := end.Add(-lookback)
+
+	for _, pod := range pods {
+		metrics := o.monitoringClient.GetNamedMetricsOverTime([]string{"container_cpu_usage", "container_memory_usage_wo_cache"},
+			start, end, step, monitoring.ContainerOption{
+				NamespaceName: namespace,
+				PodName:       pod.Name,
+				ContainerName: containerName,
+			})
+
+		for _, metric := range metrics {
+			for _, value := range metric.MetricValues {
+				for _, point := range value.Series {
+					switch metric.MetricName {
+					case "container_cpu_usage":
+						cpu = append(cpu, point[1])
+					case "container_memory_usage_wo_cache":
+						memory = append(memory, point[1])
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+func applyResources(containers []corev1.Container, resourcesByContainer map[string]corev1.ResourceRequirements) {
+	for i := range containers {
+		if resources, ok := resourcesByContainer[containers[i].Name]; ok {
+			containers[i].Resources = resources
+		}
+	}
+}
+
+func percentile(samples []float64, p float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]float64{}, samples...)
+	sort.Float64s(sorted)
+	index := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
+
+func peak(samples []float64) float64 {
+	var max float64
+	for _, sample := range samples {
+		if sample > max {
+			max = sample
+		}
+	}
+	return max
+}
+
+// cpuQuantity rounds cores up to the next millicore.
+func cpuQuantity(cores float64) *resource.Quantity {
+	return resource.NewMilliQuantity(int64(math.Ceil(cores*1000)), resource.DecimalSI)
+}
+
+// memoryQuantity rounds bytes up to the next Mi.
+func memoryQuantity(bytes float64) *resource.Quantity {
+	return resource.NewQuantity(int64(math.Ceil(bytes/(1<<20)))*(1<<20), resource.BinarySI)
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package release implements blue/green releases for applications without a
+// service mesh. The stable (blue) Service keeps serving while a preview
+// Service exposes the green deployment; promoting switches the stable
+// Service's selector to the green pods in one step, aborting removes the
+// preview without ever touching live traffic.
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	stateAnnotation          = "release.kubesphere.io/state"
+	blueSelectorAnnotation   = "release.kubesphere.io/blue-selector"
+	greenSelectorAnnotation  = "release.kubesphere.io/green-selector"
+	previewServiceAnnotation = "release.kubesphere.io/preview-service"
+	lastPromotedAnnotation   = "release.kubesphere.io/last-promoted"
+
+	// managedByAnnotation marks the preview services this operator creates,
+	// only those are ever deleted
+	managedByAnnotation = "release.kubesphere.io/managed-by"
+	managedByValue      = "bluegreen"
+
+	// StatePreview means a preview service points at the green deployment
+	// and the release waits for promote or abort
+	StatePreview = "Preview"
+
+	previewSuffix = "-preview"
+)
+
+// BlueGreenRequest starts a blue/green release for a service.
+type BlueGreenRequest struct {
+	// GreenDeployment is the deployment carrying the new version, its
+	// selector must differ from the service's current one
+	GreenDeployment string `json:"greenDeployment"`
+}
+
+// BlueGreenStatus describes an ongoing blue/green release.
+type BlueGreenStatus struct {
+	State string `json:"state,omitempty"`
+	// BlueSelector is the selector live traffic is pinned to
+	BlueSelector map[string]string `json:"blueSelector,omitempty"`
+	// GreenSelector is the selector promote will switch to
+	GreenSelector  map[string]string `json:"greenSelector,omitempty"`
+	PreviewService string            `json:"previewService,omitempty"`
+	// PreviewURL is the in-cluster address of the preview service
+	PreviewURL string `json:"previewURL,omitempty"`
+	// LastPromoted records when the service was last promoted
+	LastPromoted string `json:"lastPromoted,omitempty"`
+}
+
+type Operator interface {
+	// StartBlueGreen creates the preview service for the green deployment
+	// and records the release on the stable service
+	StartBlueGreen(namespace, service string, request BlueGreenRequest) (*BlueGreenStatus, error)
+
+	// Promote switches the stable service's selector to the green pods and
+	// removes the preview
+	Promote(namespace, service string) (*BlueGreenStatus, error)
+
+	// Abort removes the preview without touching the stable service
+	Abort(namespace, service string) error
+
+	// Status returns the state of the release on the service
+	Status(namespace, service string) (*BlueGreenStatus, error)
+}
+
+type operator struct {
+	client kubernetes.Interface
+}
+
+func NewOperator(client kubernetes.Interface) Operator {
+	return &operator{client: client}
+}
+
+func (o *operator) StartBlueGreen(namespace, service string, request BlueGreenRequest) (*BlueGreenStatus, error) {
+	ctx := context.Background()
+
+	svc, err := o.client.CoreV1().Services(namespace).Get(ctx, service, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if svc.Annotations[stateAnnotation] == StatePreview {
+		return nil, fmt.Errorf("service %s already has a release in preview, promote or abort it first", service)
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return nil, fmt.Errorf("service %s has no selector, blue/green needs selector switching", service)
+	}
+
+	deployment, err := o.client.AppsV1().Deployments(namespace).Get(ctx, request.GreenDeployment, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	greenSelector := deployment.Spec.Selector.MatchLabels
+	if len(greenSelector) == 0 {
+		return nil, fmt.Errorf("deployment %s has no label selector", request.GreenDeployment)
+	}
+	if reflect.DeepEqual(greenSelector, svc.Spec.Selector) {
+		return nil, fmt.Errorf("deployment %s selects the same pods the service already serves, "+
+			"the green deployment needs distinct labels, e.g. a version label", request.GreenDeployment)
+	}
+
+	preview := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      service + previewSuffix,
+			Namespace: namespace,
+			Labels:    svc.Labels,
+			Annotations: map[string]string{
+				managedByAnnotation: managedByValue,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: greenSelector,
+			Ports:    svc.Spec.Ports,
+		},
+	}
+	if _, err = o.client.CoreV1().Services(namespace).Create(ctx, preview, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return nil, err
+	}
+
+	blue, _ := json.Marshal(svc.Spec.Selector)
+	green, _ := json.Marshal(greenSelector)
+	if svc.Annotations == nil {
+		svc.Annotations = make(map[string]string)
+	}
+	svc.Annotations[stateAnnotation] = StatePreview
+	svc.Annotations[blueSelectorAnnotation] = string(blue)
+	svc.Annotations[greenSelectorAnnotation] = string(green)
+	svc.Annotations[previewServiceAnnotation] = preview.Name
+	if svc, err = o.client.CoreV1().Services(namespace).Update(ctx, svc, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+
+	return status(svc), nil
+}
+
+func (o *operator) Promote(namespace, service string) (*BlueGreenStatus, error) {
+	ctx := context.Background()
+
+	svc, err := o.client.CoreV1().Services(namespace).Get(ctx, service, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if svc.Annotations[stateAnnotation] != StatePreview {
+		return nil, fmt.Errorf("service %s has no release in preview", service)
+	}
+
+	greenSelector := map[string]string{}
+	if err = json.Unmarshal([]byte(svc.Annotations[greenSelectorAnnotation]), &greenSelector); err != nil {
+		return nil, fmt.Errorf("release annotations of service %s are corrupt: %v", service, err)
+	}
+
+	if err = o.deletePreview(ctx, namespace, svc.Annotations[previewServiceAnnotation]); err != nil {
+		return nil, err
+	}
+
+	svc.Spec.Selector = greenSelector
+	svc.Annotations[lastPromotedAnnotation] = time.Now().Format(time.RFC3339)
+	delete(svc.Annotations, stateAnnotation)
+	delete(svc.Annotations, blueSelectorAnnotation)
+	delete(svc.Annotations, greenSelectorAnnotation)
+	delete(svc.Annotations, previewServiceAnnotation)
+	if svc, err = o.client.CoreV1().Services(namespace).Update(ctx, svc, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+
+	return status(svc), nil
+}
+
+func (o *operator) Abort(namespace, service string) error {
+	ctx := context.Background()
+
+	svc, err := o.client.CoreV1().Services(namespace).Get(ctx, service, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if svc.Annotations[stateAnnotation] != StatePreview {
+		return fmt.Errorf("service %s has no release in preview", service)
+	}
+
+	if err = o.deletePreview(ctx, namespace, svc.Annotations[previewServiceAnnotation]); err != nil {
+		return err
+	}
+
+	delete(svc.Annotations, stateAnnotation)
+	delete(svc.Annotations, blueSelectorAnnotation)
+	delete(svc.Annotations, greenSelectorAnnotation)
+	delete(svc.Annotations, previewServiceAnnotation)
+	_, err = o.client.CoreV1().Services(namespace).Update(ctx, svc, metav1.UpdateOptions{})
+	return err
+}
+
+func (o *operator) Status(namespace, service string) (*BlueGreenStatus, error) {
+	svc, err := o.client.CoreV1().Services(namespace).Get(context.Background(), service, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return status(svc), nil
+}
+
+// deletePreview removes the preview service, refusing services the operator
+// did not create.
+func (o *operator) deletePreview(ctx context.Context, namespace, name string) error {
+	if name == "" {
+		return nil
+	}
+	preview, err := o.client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if preview.Annotations[managedByAnnotation] != managedByValue {
+		return fmt.Errorf("service %s is not a managed preview service", name)
+	}
+	return o.client.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+func status(svc *corev1.Service) *BlueGreenStatus {
+	result := &BlueGreenStatus{
+		State:          svc.Annotations[stateAnnotation],
+		PreviewService: svc.Annotations[previewServiceAnnotation],
+		LastPromoted:   svc.Annotations[lastPromotedAnnotation],
+	}
+	if blue := svc.Annotations[blueSelectorAnnotation]; blue != "" {
+		json.Unmarshal([]byte(blue), &result.BlueSelector)
+	}
+	if green := svc.Annotations[greenSelectorAnnotation]; green != "" {
+		json.Unmarshal([]byte(green), &result.GreenSelector)
+	}
+	if result.PreviewService != "" && len(svc.Spec.Ports) > 0 {
+		result.PreviewURL = fmt.Sprintf("http://%s.%s.svc:%d", result.PreviewService, svc.Namespace, svc.Spec.Ports[0].Port)
+	}
+	return result
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package v1alpha3
 
 import (
+	"fmt"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
@@ -60,12 +62,17 @@ func DefaultList(objects []runtime.Object, q *query.Query, compareFunc CompareFu
 		}
 	}
 
-	// sort by sortBy field
+	// sort by sortBy field, dot paths like status.phase are resolved
+	// generically so any column can be sorted on
+	greater := compareFunc
+	if strings.Contains(string(q.SortBy), ".") {
+		greater = FieldPathCompare
+	}
 	sort.Slice(filtered, func(i, j int) bool {
 		if !q.Ascending {
-			return compareFunc(filtered[i], filtered[j], q.SortBy)
+			return greater(filtered[i], filtered[j], q.SortBy)
 		}
-		return !compareFunc(filtered[i], filtered[j], q.SortBy)
+		return !greater(filtered[i], filtered[j], q.SortBy)
 	})
 
 	total := len(filtered)
@@ -76,10 +83,78 @@ func DefaultList(objects []runtime.Object, q *query.Query, compareFunc CompareFu
 
 	start, end := q.Pagination.GetValidPagination(total)
 
-	return &api.ListResult{
+	result := &api.ListResult{
 		TotalItems: len(filtered),
 		Items:      objectsToInterfaces(filtered[start:end]),
 	}
+	if end < total {
+		result.NextCursor = query.EncodeCursor(end)
+	}
+	return result
+}
+
+// FieldPathCompare returns true if the value at the dot path of left is
+// greater than the one of right, comparing numbers numerically and anything
+// else by its string form.
+func FieldPathCompare(left, right runtime.Object, sortBy query.Field) bool {
+	leftValue := fieldPathValue(left, string(sortBy))
+	rightValue := fieldPathValue(right, string(sortBy))
+
+	leftNumber, leftOK := toFloat(leftValue)
+	rightNumber, rightOK := toFloat(rightValue)
+	if leftOK && rightOK {
+		return leftNumber > rightNumber
+	}
+	return strings.Compare(fmt.Sprintf("%v", leftValue), fmt.Sprintf("%v", rightValue)) > 0
+}
+
+func fieldPathValue(object runtime.Object, path string) interface{} {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+	if err != nil {
+		return nil
+	}
+	value, found, err := unstructured.NestedFieldNoCopy(content, strings.Split(path, ".")...)
+	if !found || err != nil {
+		return nil
+	}
+	return value
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// ProjectFields strips each item of the list down to the requested dot
+// paths, e.g. fields=metadata.name,status.phase.
+func ProjectFields(result *api.ListResult, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	for i, item := range result.Items {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			continue
+		}
+		projected := make(map[string]interface{})
+		for _, field := range fields {
+			path := strings.Split(field, ".")
+			value, found, err := unstructured.NestedFieldNoCopy(content, path...)
+			if !found || err != nil {
+				continue
+			}
+			if err = unstructured.SetNestedField(projected, value, path...); err != nil {
+				continue
+			}
+		}
+		result.Items[i] = projected
+	}
 }
 
 // DefaultObjectMetaCompare return true is left great than right
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// HistoryAnnotation stores the recent rollout records of a deployment as
+	// JSON, newest last
+	HistoryAnnotation = "rollout.kubesphere.io/history"
+	// AutoRollbackAnnotation opts the deployment into automatic rollback
+	// when the post-deploy error rate regresses beyond the threshold
+	AutoRollbackAnnotation = "rollout.kubesphere.io/auto-rollback"
+	// ThresholdAnnotation overrides the error rate delta that triggers the
+	// automatic rollback
+	ThresholdAnnotation = "rollout.kubesphere.io/error-rate-threshold"
+
+	ResultProgressing = "Progressing"
+	ResultSucceeded   = "Succeeded"
+	ResultRolledBack  = "RolledBack"
+
+	// historyLimit caps the records kept per deployment, older ones are
+	// dropped
+	historyLimit = 10
+)
+
+// Record is the analytics of one rollout of a deployment.
+type Record struct {
+	// Revision is the deployment revision the rollout moved to
+	Revision  string       `json:"revision"`
+	StartTime metav1.Time  `json:"startTime"`
+	EndTime   *metav1.Time `json:"endTime,omitempty"`
+	// DurationSeconds is how long the rollout took to complete
+	DurationSeconds int64 `json:"durationSeconds,omitempty"`
+	// Restarts counts container restarts across the deployment's pods at
+	// completion, early crash loops show up here
+	Restarts int32 `json:"restarts,omitempty"`
+	// ErrorRateBefore and ErrorRateAfter are the monitored error rates
+	// sampled at rollout start and after the soak period
+	ErrorRateBefore *float64 `json:"errorRateBefore,omitempty"`
+	ErrorRateAfter  *float64 `json:"errorRateAfter,omitempty"`
+	// Result is Progressing, Succeeded or RolledBack
+	Result string `json:"result"`
+	// Message explains a rollback
+	Message string `json:"message,omitempty"`
+}
+
+// History decodes the rollout records from the deployment's annotations,
+// oldest first.
+func History(annotations map[string]string) []Record {
+	encoded := annotations[HistoryAnnotation]
+	if encoded == "" {
+		return nil
+	}
+	var records []Record
+	if err := json.Unmarshal([]byte(encoded), &records); err != nil {
+		// a corrupt annotation starts a fresh history
+		return nil
+	}
+	return records
+}
+
+// EncodeHistory serializes the records for the history annotation, trimming
+// to the newest historyLimit entries.
+func EncodeHistory(records []Record) string {
+	if len(records) > historyLimit {
+		records = records[len(records)-historyLimit:]
+	}
+	encoded, err := json.Marshal(records)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+type Operator interface {
+	// History returns the recorded rollouts of the deployment, oldest first
+	History(namespace, deployment string) ([]Record, error)
+}
+
+type operator struct {
+	client kubernetes.Interface
+}
+
+func NewOperator(client kubernetes.Interface) Operator {
+	return &operator{client: client}
+}
+
+func (o *operator) History(namespace, deployment string) ([]Record, error) {
+	d, err := o.client.AppsV1().Deployments(namespace).Get(context.Background(), deployment, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	records := History(d.Annotations)
+	if records == nil {
+		records = []Record{}
+	}
+	return records, nil
+}
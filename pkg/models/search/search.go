@@ -0,0 +1,338 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package search answers "where is service payments-v2" style queries with
+// an in-memory inverted index fed by the shared informers. Names, labels
+// and annotations of the common resource kinds are tokenized as they are
+// added or updated, a query intersects the posting lists of its tokens.
+// Results are filtered through the RBAC authorizer so users only see
+// resources in namespaces they can list.
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+)
+
+// longer annotation values are not worth indexing, they are mostly
+// machine-written JSON
+const maxIndexedValueLength = 64
+
+// Result is one resource matching a search query
+type Result struct {
+	Kind      string            `json:"kind"`
+	Namespace string            `json:"namespace,omitempty"`
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt metav1.Time       `json:"createdAt"`
+}
+
+// Results is the response of a search query
+type Results struct {
+	Total int      `json:"total"`
+	Items []Result `json:"items"`
+}
+
+type Interface interface {
+	// Search returns the resources matching the query the user is allowed
+	// to see, best matches first
+	Search(user user.Info, query string, limit int) (*Results, error)
+}
+
+// document is one indexed resource
+type document struct {
+	result   Result
+	resource string // plural used for authorization
+	apiGroup string
+	tokens   map[string]bool
+}
+
+type index struct {
+	mutex sync.RWMutex
+	// inverted index, token to the keys of the documents containing it
+	postings map[string]map[string]bool
+	docs     map[string]*document
+
+	authorizer authorizer.Authorizer
+}
+
+func NewSearch(factory informers.SharedInformerFactory, authorizer authorizer.Authorizer) Interface {
+	s := &index{
+		postings:   map[string]map[string]bool{},
+		docs:       map[string]*document{},
+		authorizer: authorizer,
+	}
+
+	s.watch(factory.Apps().V1().Deployments().Informer(), "Deployment", "deployments", "apps")
+	s.watch(factory.Apps().V1().StatefulSets().Informer(), "StatefulSet", "statefulsets", "apps")
+	s.watch(factory.Apps().V1().DaemonSets().Informer(), "DaemonSet", "daemonsets", "apps")
+	s.watch(factory.Core().V1().Services().Informer(), "Service", "services", "")
+	s.watch(factory.Core().V1().ConfigMaps().Informer(), "ConfigMap", "configmaps", "")
+	s.watch(factory.Core().V1().Secrets().Informer(), "Secret", "secrets", "")
+	s.watch(factory.Core().V1().Pods().Informer(), "Pod", "pods", "")
+	s.watch(factory.Core().V1().PersistentVolumeClaims().Informer(), "PersistentVolumeClaim", "persistentvolumeclaims", "")
+
+	return s
+}
+
+func (s *index) watch(informer cache.SharedIndexInformer, kind, resource, apiGroup string) {
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			s.upsert(obj, kind, resource, apiGroup)
+		},
+		UpdateFunc: func(old, new interface{}) {
+			s.upsert(new, kind, resource, apiGroup)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			s.delete(obj, kind)
+		},
+	})
+}
+
+// object extracts the metadata common to every indexed kind
+func object(obj interface{}) (metav1.Object, bool) {
+	switch resource := obj.(type) {
+	case *appsv1.Deployment:
+		return resource, true
+	case *appsv1.StatefulSet:
+		return resource, true
+	case *appsv1.DaemonSet:
+		return resource, true
+	case *corev1.Service:
+		return resource, true
+	case *corev1.ConfigMap:
+		return resource, true
+	case *corev1.Secret:
+		return resource, true
+	case *corev1.Pod:
+		return resource, true
+	case *corev1.PersistentVolumeClaim:
+		return resource, true
+	}
+	return nil, false
+}
+
+func docKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// tokenize splits a string into lowercase index tokens, the full string is
+// a token too so exact matches work
+func tokenize(value string) []string {
+	value = strings.ToLower(value)
+	tokens := strings.FieldsFunc(value, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+	})
+	return append(tokens, value)
+}
+
+func (d *document) addTokens(values ...string) {
+	for _, value := range values {
+		if len(value) > maxIndexedValueLength {
+			continue
+		}
+		for _, token := range tokenize(value) {
+			d.tokens[token] = true
+		}
+	}
+}
+
+func (s *index) upsert(obj interface{}, kind, resource, apiGroup string) {
+	meta, ok := object(obj)
+	if !ok {
+		klog.Warningf("search index got an unexpected %T", obj)
+		return
+	}
+
+	doc := &document{
+		result: Result{
+			Kind:      kind,
+			Namespace: meta.GetNamespace(),
+			Name:      meta.GetName(),
+			Labels:    meta.GetLabels(),
+			CreatedAt: metav1.Time{Time: meta.GetCreationTimestamp().Time},
+		},
+		resource: resource,
+		apiGroup: apiGroup,
+		tokens:   map[string]bool{},
+	}
+	doc.addTokens(kind, meta.GetName())
+	for key, value := range meta.GetLabels() {
+		doc.addTokens(key, value)
+	}
+	for key, value := range meta.GetAnnotations() {
+		doc.addTokens(key, value)
+	}
+
+	key := docKey(kind, meta.GetNamespace(), meta.GetName())
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.remove(key)
+	s.docs[key] = doc
+	for token := range doc.tokens {
+		if s.postings[token] == nil {
+			s.postings[token] = map[string]bool{}
+		}
+		s.postings[token][key] = true
+	}
+}
+
+func (s *index) delete(obj interface{}, kind string) {
+	meta, ok := object(obj)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.remove(docKey(kind, meta.GetNamespace(), meta.GetName()))
+}
+
+// remove unindexes a document, callers hold the lock
+func (s *index) remove(key string) {
+	doc, ok := s.docs[key]
+	if !ok {
+		return
+	}
+	for token := range doc.tokens {
+		delete(s.postings[token], key)
+		if len(s.postings[token]) == 0 {
+			delete(s.postings, token)
+		}
+	}
+	delete(s.docs, key)
+}
+
+// matches returns the keys of the documents containing the token, prefix
+// matches included so partial names find their resources. Callers hold the
+// lock.
+func (s *index) matches(token string) map[string]bool {
+	keys := map[string]bool{}
+	for indexed, posting := range s.postings {
+		if !strings.HasPrefix(indexed, token) {
+			continue
+		}
+		for key := range posting {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+func (s *index) Search(user user.Info, query string, limit int) (*Results, error) {
+	tokens := tokenize(strings.TrimSpace(query))
+	if len(tokens) == 0 {
+		return &Results{Items: []Result{}}, nil
+	}
+
+	s.mutex.RLock()
+	keys := s.matches(tokens[0])
+	for _, token := range tokens[1:] {
+		next := s.matches(token)
+		for key := range keys {
+			if !next[key] {
+				delete(keys, key)
+			}
+		}
+	}
+
+	candidates := make([]*document, 0, len(keys))
+	for key := range keys {
+		candidates = append(candidates, s.docs[key])
+	}
+	s.mutex.RUnlock()
+
+	// exact name matches first, then name prefixes, then the rest, ties
+	// broken alphabetically
+	lowered := strings.ToLower(strings.TrimSpace(query))
+	rank := func(doc *document) int {
+		name := strings.ToLower(doc.result.Name)
+		switch {
+		case name == lowered:
+			return 0
+		case strings.HasPrefix(name, lowered):
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		ri, rj := rank(candidates[i]), rank(candidates[j])
+		if ri != rj {
+			return ri < rj
+		}
+		if candidates[i].result.Name != candidates[j].result.Name {
+			return candidates[i].result.Name < candidates[j].result.Name
+		}
+		return candidates[i].result.Namespace < candidates[j].result.Namespace
+	})
+
+	results := &Results{Items: []Result{}}
+	allowed := map[string]bool{}
+	for _, doc := range candidates {
+		if !s.authorized(user, doc, allowed) {
+			continue
+		}
+		results.Total++
+		if limit <= 0 || len(results.Items) < limit {
+			results.Items = append(results.Items, doc.result)
+		}
+	}
+	return results, nil
+}
+
+// authorized checks whether the user may list the resource in its
+// namespace, decisions are memoized per namespace and resource
+func (s *index) authorized(user user.Info, doc *document, cached map[string]bool) bool {
+	cacheKey := doc.result.Namespace + "/" + doc.resource
+	if decision, ok := cached[cacheKey]; ok {
+		return decision
+	}
+
+	attributes := authorizer.AttributesRecord{
+		User:            user,
+		Verb:            "list",
+		APIGroup:        doc.apiGroup,
+		Resource:        doc.resource,
+		Namespace:       doc.result.Namespace,
+		ResourceRequest: true,
+		ResourceScope:   request.NamespaceScope,
+	}
+	decision, _, err := s.authorizer.Authorize(attributes)
+	if err != nil {
+		klog.Errorf("authorize search result failed: %v", err)
+		cached[cacheKey] = false
+		return false
+	}
+
+	cached[cacheKey] = decision == authorizer.DecisionAllow
+	return cached[cacheKey]
+}
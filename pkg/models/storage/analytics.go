@@ -0,0 +1,229 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+)
+
+// StorageClassCapacity aggregates the provisioned and actually used capacity
+// of all volumes of one storage class.
+type StorageClassCapacity struct {
+	Name string `json:"name" description:"storage class name"`
+
+	PersistentVolumeClaimCount int `json:"persistentVolumeClaimCount" description:"number of PVCs using the storage class"`
+
+	ProvisionedCapacity string `json:"provisionedCapacity" description:"total capacity of provisioned volumes"`
+
+	UsedBytes float64 `json:"usedBytes" description:"total bytes used on the volumes, reported by kubelet volume stats"`
+}
+
+// OrphanedPersistentVolume describes a PV no longer bound to an existing claim.
+type OrphanedPersistentVolume struct {
+	Name string `json:"name" description:"persistent volume name"`
+
+	StorageClass string `json:"storageClass,omitempty" description:"storage class of the volume"`
+
+	Phase string `json:"phase" description:"volume phase, Available or Released"`
+
+	Capacity string `json:"capacity" description:"volume capacity"`
+}
+
+// PersistentVolumeClaimPerformance holds the latest IOPS, throughput and
+// latency samples of one volume, collected from csi driver metrics.
+type PersistentVolumeClaimPerformance struct {
+	Namespace string `json:"namespace" description:"namespace of the PVC"`
+
+	Name string `json:"name" description:"PVC name"`
+
+	ReadIops        float64 `json:"readIops" description:"read operations per second"`
+	WriteIops       float64 `json:"writeIops" description:"write operations per second"`
+	ReadThroughput  float64 `json:"readThroughput" description:"bytes read per second"`
+	WriteThroughput float64 `json:"writeThroughput" description:"bytes written per second"`
+	ReadLatency     float64 `json:"readLatency" description:"average read latency in seconds"`
+	WriteLatency    float64 `json:"writeLatency" description:"average write latency in seconds"`
+}
+
+var pvcPerformanceMetrics = []string{
+	"pvc_read_iops",
+	"pvc_write_iops",
+	"pvc_read_throughput",
+	"pvc_write_throughput",
+	"pvc_read_latency",
+	"pvc_write_latency",
+}
+
+type AnalyticsInterface interface {
+	ListStorageClassCapacities() ([]StorageClassCapacity, error)
+	ListOrphanedPersistentVolumes() ([]OrphanedPersistentVolume, error)
+	GetPersistentVolumeClaimPerformance(namespace, name string) (*PersistentVolumeClaimPerformance, error)
+}
+
+type analyticsOperator struct {
+	informers        informers.SharedInformerFactory
+	monitoringClient monitoring.Interface
+}
+
+func NewAnalyticsOperator(informers informers.SharedInformerFactory, monitoringClient monitoring.Interface) AnalyticsInterface {
+	return &analyticsOperator{informers: informers, monitoringClient: monitoringClient}
+}
+
+func (o *analyticsOperator) ListStorageClassCapacities() ([]StorageClassCapacity, error) {
+	classes, err := o.informers.Storage().V1().StorageClasses().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, err := o.informers.Core().V1().PersistentVolumes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := o.informers.Core().V1().PersistentVolumeClaims().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]StorageClassCapacity, 0)
+	for _, class := range classes {
+		capacity := StorageClassCapacity{Name: class.Name}
+
+		var provisioned resource.Quantity
+		for _, volume := range volumes {
+			if volume.Spec.StorageClassName == class.Name {
+				provisioned.Add(volume.Spec.Capacity[v1.ResourceStorage])
+			}
+		}
+		capacity.ProvisionedCapacity = provisioned.String()
+
+		for _, claim := range claims {
+			if storageClassOf(claim) == class.Name {
+				capacity.PersistentVolumeClaimCount++
+			}
+		}
+
+		capacity.UsedBytes = o.sumUsedBytes(class.Name)
+		result = append(result, capacity)
+	}
+	return result, nil
+}
+
+func (o *analyticsOperator) ListOrphanedPersistentVolumes() ([]OrphanedPersistentVolume, error) {
+	volumes, err := o.informers.Core().V1().PersistentVolumes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	claimLister := o.informers.Core().V1().PersistentVolumeClaims().Lister()
+
+	result := make([]OrphanedPersistentVolume, 0)
+	for _, volume := range volumes {
+		orphaned := volume.Status.Phase == v1.VolumeAvailable || volume.Status.Phase == v1.VolumeReleased
+		if !orphaned && volume.Spec.ClaimRef != nil {
+			// bound volumes whose claim is gone are orphaned as well
+			_, err := claimLister.PersistentVolumeClaims(volume.Spec.ClaimRef.Namespace).Get(volume.Spec.ClaimRef.Name)
+			if errors.IsNotFound(err) {
+				orphaned = true
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		if !orphaned {
+			continue
+		}
+
+		capacity := volume.Spec.Capacity[v1.ResourceStorage]
+		result = append(result, OrphanedPersistentVolume{
+			Name:         volume.Name,
+			StorageClass: volume.Spec.StorageClassName,
+			Phase:        string(volume.Status.Phase),
+			Capacity:     capacity.String(),
+		})
+	}
+	return result, nil
+}
+
+func (o *analyticsOperator) GetPersistentVolumeClaimPerformance(namespace, name string) (*PersistentVolumeClaimPerformance, error) {
+	if _, err := o.informers.Core().V1().PersistentVolumeClaims().Lister().PersistentVolumeClaims(namespace).Get(name); err != nil {
+		return nil, err
+	}
+
+	metrics := o.monitoringClient.GetNamedMetrics(pvcPerformanceMetrics, time.Now(), monitoring.PVCOption{
+		NamespaceName:             namespace,
+		PersistentVolumeClaimName: name,
+	})
+
+	performance := &PersistentVolumeClaimPerformance{Namespace: namespace, Name: name}
+	for _, metric := range metrics {
+		value := latestSample(metric)
+		switch metric.MetricName {
+		case "pvc_read_iops":
+			performance.ReadIops = value
+		case "pvc_write_iops":
+			performance.WriteIops = value
+		case "pvc_read_throughput":
+			performance.ReadThroughput = value
+		case "pvc_write_throughput":
+			performance.WriteThroughput = value
+		case "pvc_read_latency":
+			performance.ReadLatency = value
+		case "pvc_write_latency":
+			performance.WriteLatency = value
+		}
+	}
+	return performance, nil
+}
+
+// sumUsedBytes adds up the used bytes of all volumes of the storage class,
+// zero when the monitoring system has no samples for it.
+func (o *analyticsOperator) sumUsedBytes(storageClassName string) float64 {
+	metrics := o.monitoringClient.GetNamedMetrics([]string{"pvc_bytes_used"}, time.Now(), monitoring.PVCOption{
+		StorageClassName: storageClassName,
+	})
+
+	var used float64
+	for _, metric := range metrics {
+		for _, value := range metric.MetricValues {
+			if value.Sample != nil {
+				used += value.Sample[1]
+			}
+		}
+	}
+	return used
+}
+
+func latestSample(metric monitoring.Metric) float64 {
+	if len(metric.MetricValues) == 0 || metric.MetricValues[0].Sample == nil {
+		return 0
+	}
+	return metric.MetricValues[0].Sample[1]
+}
+
+func storageClassOf(claim *v1.PersistentVolumeClaim) string {
+	if claim.Spec.StorageClassName != nil {
+		return *claim.Spec.StorageClassName
+	}
+	return claim.GetAnnotations()[v1.BetaStorageClassAnnotation]
+}
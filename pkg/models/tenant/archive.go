@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenant
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	"kubesphere.io/kubesphere/pkg/constants"
+)
+
+// ArchiveNamespace marks the namespace for archival: the namespace
+// controller scales its workloads to zero, snapshots its volumes, exports
+// its manifests to object storage and deletes it once the grace period has
+// passed. This is the safe alternative to DeleteNamespace's immediate
+// cascading delete.
+func (t *tenantOperator) ArchiveNamespace(workspace, namespace string, gracePeriod time.Duration) error {
+	ns, err := t.DescribeNamespace(workspace, namespace)
+	if err != nil {
+		return err
+	}
+	if _, archived := ns.Annotations[constants.ArchiveAnnotationKey]; archived {
+		return errors.NewConflict(corev1.Resource("namespace"), namespace, fmt.Errorf("namespace is already archived"))
+	}
+	if gracePeriod <= 0 {
+		return errors.NewBadRequest(fmt.Sprintf("invalid grace period %s", gracePeriod))
+	}
+	ns = ns.DeepCopy()
+	if ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	ns.Annotations[constants.ArchiveAnnotationKey] = gracePeriod.String()
+	_, err = t.UpdateNamespace(workspace, ns)
+	return err
+}
+
+// CancelNamespaceArchival unmarks an archived namespace; the namespace
+// controller restores its workloads and unschedules the deletion.
+func (t *tenantOperator) CancelNamespaceArchival(workspace, namespace string) error {
+	ns, err := t.DescribeNamespace(workspace, namespace)
+	if err != nil {
+		return err
+	}
+	if _, archived := ns.Annotations[constants.ArchiveAnnotationKey]; !archived {
+		return errors.NewBadRequest(fmt.Sprintf("namespace %s is not archived", namespace))
+	}
+	ns = ns.DeepCopy()
+	delete(ns.Annotations, constants.ArchiveAnnotationKey)
+	_, err = t.UpdateNamespace(workspace, ns)
+	return err
+}
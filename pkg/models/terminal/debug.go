@@ -0,0 +1,241 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/klog"
+)
+
+const (
+	// defaultDebugImage is used when the request does not pick an image
+	defaultDebugImage = "alpine:3.12"
+
+	// debugStartTimeout bounds the wait for the ephemeral container to start
+	debugStartTimeout = 30 * time.Second
+
+	debugLaunchedEventReason = "DebugContainerLaunched"
+)
+
+// allowedDebugCapabilities is the policy for extra capabilities a debug
+// container may request, anything else is rejected. Privileged debug
+// containers are never allowed.
+var allowedDebugCapabilities = []string{"NET_ADMIN", "NET_RAW", "SYS_PTRACE"}
+
+// DebugContainerRequest launches an ephemeral debug container in a running
+// pod.
+type DebugContainerRequest struct {
+	// Image of the debug container, a small shell image by default
+	Image string `json:"image,omitempty"`
+	// Capabilities to add, restricted to the allowed set
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// DebugContainer identifies a launched debug container.
+type DebugContainer struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+func capabilityAllowed(capability string) bool {
+	for _, allowed := range allowedDebugCapabilities {
+		if capability == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// LaunchDebugContainer attaches an ephemeral debug container to the running
+// pod and waits for it to start. The launch is audited through an event on
+// the pod naming the user, the image and the granted capabilities.
+func (t *terminaler) LaunchDebugContainer(username, namespace, podName string, request DebugContainerRequest) (*DebugContainer, error) {
+	ctx := context.Background()
+
+	image := request.Image
+	if image == "" {
+		image = defaultDebugImage
+	}
+	var capabilities []v1.Capability
+	for _, capability := range request.Capabilities {
+		if !capabilityAllowed(capability) {
+			return nil, fmt.Errorf("capability %s is not allowed for debug containers, allowed: %v",
+				capability, allowedDebugCapabilities)
+		}
+		capabilities = append(capabilities, v1.Capability(capability))
+	}
+
+	pod, err := t.client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if pod.Status.Phase != v1.PodRunning {
+		return nil, fmt.Errorf("pod %s is %s, debug containers need a running pod", podName, pod.Status.Phase)
+	}
+
+	privileged := false
+	debug := DebugContainer{Name: fmt.Sprintf("debug-%s", rand.String(5)), Image: image}
+	ephemeral := v1.EphemeralContainer{
+		EphemeralContainerCommon: v1.EphemeralContainerCommon{
+			Name:  debug.Name,
+			Image: image,
+			Stdin: true,
+			TTY:   true,
+			SecurityContext: &v1.SecurityContext{
+				Privileged: &privileged,
+				Capabilities: &v1.Capabilities{
+					Add: capabilities,
+				},
+			},
+		},
+	}
+
+	containers, err := t.client.CoreV1().Pods(namespace).GetEphemeralContainers(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("ephemeral containers are not available in this cluster: %v", err)
+	}
+	containers.EphemeralContainers = append(containers.EphemeralContainers, ephemeral)
+	if _, err = t.client.CoreV1().Pods(namespace).UpdateEphemeralContainers(ctx, podName, containers, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+
+	t.auditDebugLaunch(ctx, pod, username, &debug, request.Capabilities)
+
+	if err = t.waitForDebugContainer(namespace, podName, debug.Name); err != nil {
+		return nil, err
+	}
+	return &debug, nil
+}
+
+// waitForDebugContainer blocks until the ephemeral container is running.
+func (t *terminaler) waitForDebugContainer(namespace, podName, containerName string) error {
+	return wait.PollImmediate(time.Second, debugStartTimeout, func() (bool, error) {
+		pod, err := t.client.CoreV1().Pods(namespace).Get(context.Background(), podName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, status := range pod.Status.EphemeralContainerStatuses {
+			if status.Name != containerName {
+				continue
+			}
+			if status.State.Running != nil {
+				return true, nil
+			}
+			if status.State.Terminated != nil {
+				return false, fmt.Errorf("debug container %s terminated: %s",
+					containerName, status.State.Terminated.Reason)
+			}
+		}
+		return false, nil
+	})
+}
+
+// auditDebugLaunch leaves an event on the pod recording who attached which
+// debug container, failures only log since the container is already running.
+func (t *terminaler) auditDebugLaunch(ctx context.Context, pod *v1.Pod, username string, debug *DebugContainer, capabilities []string) {
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "debug-container-",
+			Namespace:    pod.Namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:       "Pod",
+			Namespace:  pod.Namespace,
+			Name:       pod.Name,
+			UID:        pod.UID,
+			APIVersion: "v1",
+		},
+		Reason:         debugLaunchedEventReason,
+		Message:        fmt.Sprintf("user %s attached debug container %s (image %s, capabilities %v)", username, debug.Name, debug.Image, capabilities),
+		Type:           v1.EventTypeNormal,
+		FirstTimestamp: metav1.Now(),
+		LastTimestamp:  metav1.Now(),
+		Count:          1,
+		Source:         v1.EventSource{Component: "ks-terminal"},
+	}
+	if _, err := t.client.CoreV1().Events(pod.Namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		klog.Errorf("Failed to record debug container launch on pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+}
+
+// startAttach connects the pty to the debug container through the attach
+// subresource, ephemeral containers cannot be exec'd into.
+func (t *terminaler) startAttach(namespace, podName, containerName string, ptyHandler PtyHandler) error {
+	req := t.client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("attach")
+	req.VersionedParams(&v1.PodAttachOptions{
+		Container: containerName,
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       true,
+	}, scheme.ParameterCodec)
+
+	attach, err := remotecommand.NewSPDYExecutor(t.config, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+
+	return attach.Stream(remotecommand.StreamOptions{
+		Stdin:             ptyHandler,
+		Stdout:            ptyHandler,
+		Stderr:            ptyHandler,
+		TerminalSizeQueue: ptyHandler,
+		Tty:               true,
+	})
+}
+
+// HandleDebugSession opens a terminal attached to the debug container, the
+// session is recorded like a regular terminal session.
+func (t *terminaler) HandleDebugSession(username, namespace, podName, containerName string, conn *websocket.Conn) {
+	session := &TerminalSession{conn: conn, sizeChan: make(chan remotecommand.TerminalSize)}
+
+	var pty PtyHandler = session
+	var recorder *SessionRecorder
+	if t.shouldRecord(namespace) {
+		recorder = NewSessionRecorder(username, namespace, podName, containerName, "debug")
+		pty = recordedSession{TerminalSession: session, recorder: recorder}
+	}
+
+	err := t.startAttach(namespace, podName, containerName, pty)
+
+	if recorder != nil {
+		if err := t.SaveRecording(recorder); err != nil {
+			klog.Errorf("Failed to save recording of debug session: %v", err)
+		}
+	}
+
+	if err != nil {
+		session.Close(2, err.Error())
+		return
+	}
+
+	session.Close(1, "Process exited")
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/klog"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/s3"
+)
+
+const (
+	// RecordingPolicyAnnotation set to "disabled" on a namespace turns
+	// session recording off for terminals opened in it
+	RecordingPolicyAnnotation = "terminal.kubesphere.io/record"
+
+	RecordingDisabled = "disabled"
+
+	recordingKeyPrefix = "terminal-recordings"
+
+	defaultTerminalWidth  = 80
+	defaultTerminalHeight = 24
+)
+
+// SessionMetadata describes one recorded terminal session, it is what the
+// recording list API returns and doubles as the audit record of the session.
+type SessionMetadata struct {
+	ID        string     `json:"id"`
+	Username  string     `json:"username"`
+	Namespace string     `json:"namespace"`
+	Pod       string     `json:"pod"`
+	Container string     `json:"container"`
+	Shell     string     `json:"shell,omitempty"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+}
+
+// castHeader is the first line of an asciicast v2 file,
+// see https://github.com/asciinema/asciinema/blob/master/doc/asciicast-v2.md
+type castHeader struct {
+	Version   int    `json:"version"`
+	Width     uint16 `json:"width"`
+	Height    uint16 `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+// SessionRecorder accumulates an asciicast v2 recording of one terminal
+// session in memory, the recording store uploads it when the session ends.
+type SessionRecorder struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+
+	meta       SessionMetadata
+	start      time.Time
+	headerDone bool
+	width      uint16
+	height     uint16
+}
+
+func NewSessionRecorder(username, namespace, pod, container, shell string) *SessionRecorder {
+	return &SessionRecorder{
+		meta: SessionMetadata{
+			ID:        fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102150405"), rand.String(5)),
+			Username:  username,
+			Namespace: namespace,
+			Pod:       pod,
+			Container: container,
+			Shell:     shell,
+			StartTime: time.Now(),
+		},
+		start:  time.Now(),
+		width:  defaultTerminalWidth,
+		height: defaultTerminalHeight,
+	}
+}
+
+func (r *SessionRecorder) Metadata() SessionMetadata {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.meta
+}
+
+// Resize records the terminal size, the first resize before any output
+// determines the cast header dimensions
+func (r *SessionRecorder) Resize(cols, rows uint16) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.headerDone {
+		r.width = cols
+		r.height = rows
+	}
+}
+
+// WriteOutput appends process output to the recording
+func (r *SessionRecorder) WriteOutput(p []byte) {
+	r.writeEvent("o", string(p))
+}
+
+// WriteInput appends user keystrokes to the recording
+func (r *SessionRecorder) WriteInput(data string) {
+	r.writeEvent("i", data)
+}
+
+func (r *SessionRecorder) writeEvent(kind, data string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.headerDone {
+		header, err := json.Marshal(castHeader{
+			Version:   2,
+			Width:     r.width,
+			Height:    r.height,
+			Timestamp: r.start.Unix(),
+			Title:     fmt.Sprintf("%s@%s/%s/%s", r.meta.Username, r.meta.Namespace, r.meta.Pod, r.meta.Container),
+		})
+		if err != nil {
+			klog.Error(err)
+			return
+		}
+		r.buf.Write(header)
+		r.buf.WriteByte('\n')
+		r.headerDone = true
+	}
+
+	event, err := json.Marshal([]interface{}{time.Since(r.start).Seconds(), kind, data})
+	if err != nil {
+		klog.Error(err)
+		return
+	}
+	r.buf.Write(event)
+	r.buf.WriteByte('\n')
+}
+
+// RecordingOperator stores and serves terminal session recordings.
+type RecordingOperator interface {
+	// RecordingEnabled reports whether recordings can be stored, session
+	// recording requires object storage to be configured
+	RecordingEnabled() bool
+
+	// SaveRecording uploads the finished recording and indexes its metadata
+	SaveRecording(recorder *SessionRecorder) error
+
+	// ListRecordings returns metadata of all recorded sessions in the namespace
+	ListRecordings(namespace string) ([]SessionMetadata, error)
+
+	// GetRecording returns the asciicast content of a recorded session
+	GetRecording(namespace, id string) ([]byte, error)
+}
+
+type recordingOperator struct {
+	mutex sync.Mutex
+	store s3.Interface
+}
+
+func NewRecordingOperator(store s3.Interface) RecordingOperator {
+	return &recordingOperator{store: store}
+}
+
+func castKey(namespace, id string) string {
+	return fmt.Sprintf("%s/%s/%s.cast", recordingKeyPrefix, namespace, id)
+}
+
+func indexKey(namespace string) string {
+	return fmt.Sprintf("%s/%s/index.json", recordingKeyPrefix, namespace)
+}
+
+func (o *recordingOperator) RecordingEnabled() bool {
+	return o.store != nil
+}
+
+func (o *recordingOperator) SaveRecording(recorder *SessionRecorder) error {
+	if o.store == nil {
+		return fmt.Errorf("terminal session recording requires object storage, but none is configured")
+	}
+
+	recorder.mutex.Lock()
+	meta := recorder.meta
+	now := time.Now()
+	meta.EndTime = &now
+	content := make([]byte, recorder.buf.Len())
+	copy(content, recorder.buf.Bytes())
+	recorder.mutex.Unlock()
+
+	key := castKey(meta.Namespace, meta.ID)
+	if err := o.store.Upload(key, fmt.Sprintf("%s.cast", meta.ID), bytes.NewReader(content)); err != nil {
+		return err
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	recordings, err := o.listRecordings(meta.Namespace)
+	if err != nil {
+		return err
+	}
+	recordings = append(recordings, meta)
+	index, err := json.Marshal(recordings)
+	if err != nil {
+		return err
+	}
+	if err := o.store.Upload(indexKey(meta.Namespace), "index.json", bytes.NewReader(index)); err != nil {
+		return err
+	}
+
+	// audit trail for privileged access monitoring
+	klog.Infof("terminal session %s recorded: user %s exec into %s/%s/%s from %s to %s",
+		meta.ID, meta.Username, meta.Namespace, meta.Pod, meta.Container,
+		meta.StartTime.Format(time.RFC3339), now.Format(time.RFC3339))
+	return nil
+}
+
+func (o *recordingOperator) listRecordings(namespace string) ([]SessionMetadata, error) {
+	recordings := make([]SessionMetadata, 0)
+	content, err := o.store.Read(indexKey(namespace))
+	if err != nil {
+		// no session has been recorded in this namespace yet
+		return recordings, nil
+	}
+	if err := json.Unmarshal(content, &recordings); err != nil {
+		return nil, err
+	}
+	return recordings, nil
+}
+
+func (o *recordingOperator) ListRecordings(namespace string) ([]SessionMetadata, error) {
+	if o.store == nil {
+		return nil, fmt.Errorf("terminal session recording requires object storage, but none is configured")
+	}
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.listRecordings(namespace)
+}
+
+func (o *recordingOperator) GetRecording(namespace, id string) ([]byte, error) {
+	if o.store == nil {
+		return nil, fmt.Errorf("terminal session recording requires object storage, but none is configured")
+	}
+	return o.store.Read(castKey(namespace, id))
+}
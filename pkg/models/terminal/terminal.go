@@ -21,16 +21,19 @@ limitations under the License.
 package terminal
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/websocket"
 	"io"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
 	"k8s.io/klog"
+	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 	"time"
 )
 
@@ -138,16 +141,56 @@ func (t TerminalSession) Close(status uint32, reason string) {
 }
 
 type Interface interface {
-	HandleSession(shell, namespace, podName, containerName string, conn *websocket.Conn)
+	HandleSession(username, shell, namespace, podName, containerName string, conn *websocket.Conn)
+
+	// LaunchDebugContainer attaches an ephemeral debug container to a
+	// running pod, HandleDebugSession opens a terminal to it
+	LaunchDebugContainer(username, namespace, podName string, request DebugContainerRequest) (*DebugContainer, error)
+	HandleDebugSession(username, namespace, podName, containerName string, conn *websocket.Conn)
+
+	// recordings of past sessions, see SessionRecorder
+	RecordingOperator
+
+	// file transfer in and out of containers, see FileTransferOperator
+	FileTransferOperator
 }
 
 type terminaler struct {
 	client kubernetes.Interface
 	config *rest.Config
+	RecordingOperator
+}
+
+func NewTerminaler(client kubernetes.Interface, config *rest.Config, store s3.Interface) Interface {
+	return &terminaler{client: client, config: config, RecordingOperator: NewRecordingOperator(store)}
+}
+
+// recordedSession tees everything flowing through a terminal session into a
+// SessionRecorder
+type recordedSession struct {
+	*TerminalSession
+	recorder *SessionRecorder
+}
+
+func (s recordedSession) Next() *remotecommand.TerminalSize {
+	size := s.TerminalSession.Next()
+	if size != nil {
+		s.recorder.Resize(size.Width, size.Height)
+	}
+	return size
 }
 
-func NewTerminaler(client kubernetes.Interface, config *rest.Config) Interface {
-	return &terminaler{client: client, config: config}
+func (s recordedSession) Read(p []byte) (int, error) {
+	n, err := s.TerminalSession.Read(p)
+	if n > 0 {
+		s.recorder.WriteInput(string(p[:n]))
+	}
+	return n, err
+}
+
+func (s recordedSession) Write(p []byte) (int, error) {
+	s.recorder.WriteOutput(p)
+	return s.TerminalSession.Write(p)
 }
 
 // startProcess is called by handleAttach
@@ -196,26 +239,54 @@ func isValidShell(validShells []string, shell string) bool {
 	return false
 }
 
-func (t *terminaler) HandleSession(shell, namespace, podName, containerName string, conn *websocket.Conn) {
+// shouldRecord decides whether a session gets recorded, sessions are
+// recorded unless recording storage is unavailable or the namespace opted
+// out through the policy annotation
+func (t *terminaler) shouldRecord(namespace string) bool {
+	if !t.RecordingEnabled() {
+		return false
+	}
+	ns, err := t.client.CoreV1().Namespaces().Get(context.Background(), namespace, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Failed to get namespace %s: %v", namespace, err)
+		return true
+	}
+	return ns.Annotations[RecordingPolicyAnnotation] != RecordingDisabled
+}
+
+func (t *terminaler) HandleSession(username, shell, namespace, podName, containerName string, conn *websocket.Conn) {
 	var err error
 	validShells := []string{"sh", "bash"}
 
 	session := &TerminalSession{conn: conn, sizeChan: make(chan remotecommand.TerminalSize)}
 
+	var pty PtyHandler = session
+	var recorder *SessionRecorder
+	if t.shouldRecord(namespace) {
+		recorder = NewSessionRecorder(username, namespace, podName, containerName, shell)
+		pty = recordedSession{TerminalSession: session, recorder: recorder}
+	}
+
 	if isValidShell(validShells, shell) {
 		cmd := []string{shell}
-		err = t.startProcess(namespace, podName, containerName, cmd, session)
+		err = t.startProcess(namespace, podName, containerName, cmd, pty)
 	} else {
 		// No shell given or it was not valid: try some shells until one succeeds or all fail
 		// FIXME: if the first shell fails then the first keyboard event is lost
 		for _, testShell := range validShells {
 			cmd := []string{testShell}
-			if err = t.startProcess(namespace, podName, containerName, cmd, session); err == nil {
+			if err = t.startProcess(namespace, podName, containerName, cmd, pty); err == nil {
 				break
 			}
 		}
 	}
 
+	if recorder != nil {
+		if err := t.SaveRecording(recorder); err != nil {
+			klog.Errorf("Failed to save recording of terminal session: %v", err)
+		}
+	}
+
 	if err != nil {
 		session.Close(2, err.Error())
 		return
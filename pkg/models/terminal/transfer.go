@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terminal
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/klog"
+)
+
+// MaxTransferSize limits uploads and downloads, large artifacts belong in
+// object storage, not in an exec stream
+const MaxTransferSize = 100 << 20
+
+// FileInfo describes one entry of a directory listed inside a container.
+type FileInfo struct {
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	Mode  string `json:"mode"`
+	IsDir bool   `json:"isDir"`
+}
+
+// FileTransferOperator moves files in and out of running containers through
+// exec streams, the way kubectl cp does.
+type FileTransferOperator interface {
+	// DownloadFile writes the file or directory at the given path as a tar
+	// archive, at most MaxTransferSize bytes
+	DownloadFile(username, namespace, pod, container, filePath string, w io.Writer) error
+
+	// UploadFile places the content at destDir/fileName inside the container
+	UploadFile(username, namespace, pod, container, destDir, fileName string, size int64, r io.Reader) error
+
+	// ListFiles lists the directory at the given path inside the container
+	ListFiles(namespace, pod, container, dirPath string) ([]FileInfo, error)
+}
+
+// execStream runs cmd in the container without a tty, wiring up the given
+// streams
+func (t *terminaler) execStream(namespace, pod, container string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	req := t.client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod).
+		Namespace(namespace).
+		SubResource("exec")
+	req.VersionedParams(&v1.PodExecOptions{
+		Container: container,
+		Command:   cmd,
+		Stdin:     stdin != nil,
+		Stdout:    stdout != nil,
+		Stderr:    stderr != nil,
+	}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(t.config, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+
+	return exec.Stream(remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
+
+// limitedWriter fails the transfer instead of silently truncating it once
+// the size limit is reached
+type limitedWriter struct {
+	w io.Writer
+	n int64
+}
+
+var errTransferTooLarge = fmt.Errorf("transfer exceeds the %d byte limit", MaxTransferSize)
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.n {
+		return 0, errTransferTooLarge
+	}
+	n, err := l.w.Write(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+func (t *terminaler) DownloadFile(username, namespace, pod, container, filePath string, w io.Writer) error {
+	if !path.IsAbs(filePath) {
+		return fmt.Errorf("file path must be absolute")
+	}
+
+	var stderr bytes.Buffer
+	cmd := []string{"tar", "cf", "-", "-C", path.Dir(filePath), path.Base(filePath)}
+	err := t.execStream(namespace, pod, container, cmd, nil, &limitedWriter{w: w, n: MaxTransferSize}, &stderr)
+	if err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return err
+	}
+
+	// audit trail for privileged access monitoring
+	klog.Infof("terminal file transfer: user %s downloaded %s from %s/%s/%s", username, filePath, namespace, pod, container)
+	return nil
+}
+
+func (t *terminaler) UploadFile(username, namespace, pod, container, destDir, fileName string, size int64, r io.Reader) error {
+	if !path.IsAbs(destDir) {
+		return fmt.Errorf("destination path must be absolute")
+	}
+	if size > MaxTransferSize {
+		return errTransferTooLarge
+	}
+
+	// wrap the content in a tar stream so the receiving side needs nothing
+	// but tar, the same trick kubectl cp uses
+	reader, writer := io.Pipe()
+	go func() {
+		tarWriter := tar.NewWriter(writer)
+		err := tarWriter.WriteHeader(&tar.Header{
+			Name: fileName,
+			Mode: 0644,
+			Size: size,
+		})
+		if err == nil {
+			_, err = io.CopyN(tarWriter, r, size)
+		}
+		if err == nil {
+			err = tarWriter.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	var stderr bytes.Buffer
+	cmd := []string{"tar", "xf", "-", "-C", destDir}
+	err := t.execStream(namespace, pod, container, cmd, reader, nil, &stderr)
+	if err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return err
+	}
+
+	// audit trail for privileged access monitoring
+	klog.Infof("terminal file transfer: user %s uploaded %s (%d bytes) to %s in %s/%s/%s",
+		username, fileName, size, destDir, namespace, pod, container)
+	return nil
+}
+
+func (t *terminaler) ListFiles(namespace, pod, container, dirPath string) ([]FileInfo, error) {
+	if !path.IsAbs(dirPath) {
+		return nil, fmt.Errorf("directory path must be absolute")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"ls", "-lA", dirPath}
+	err := t.execStream(namespace, pod, container, cmd, nil, &stdout, &stderr)
+	if err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+
+	return parseFileListing(stdout.String()), nil
+}
+
+// parseFileListing turns `ls -l` style output into FileInfo entries, lines
+// it cannot make sense of are skipped
+func parseFileListing(listing string) []FileInfo {
+	files := make([]FileInfo, 0)
+	for _, line := range strings.Split(listing, "\n") {
+		fields := strings.Fields(line)
+		// mode links owner group size month day time name
+		if len(fields) < 9 || len(fields[0]) < 10 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			continue
+		}
+		name := strings.Join(fields[8:], " ")
+		// symlinks are listed as `name -> target`
+		if i := strings.Index(name, " -> "); i > 0 {
+			name = name[:i]
+		}
+		files = append(files, FileInfo{
+			Name:  name,
+			Size:  size,
+			Mode:  fields[0],
+			IsDir: fields[0][0] == 'd',
+		})
+	}
+	return files
+}
@@ -0,0 +1,337 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+)
+
+const (
+	KindService               = "Service"
+	KindDeployment            = "Deployment"
+	KindStatefulSet           = "StatefulSet"
+	KindDaemonSet             = "DaemonSet"
+	KindConfigMap             = "ConfigMap"
+	KindSecret                = "Secret"
+	KindPersistentVolumeClaim = "PersistentVolumeClaim"
+
+	// EdgeSelects connects a service to the workloads its selector matches
+	EdgeSelects = "selects"
+	// EdgeReferences connects a workload to the configmaps, secrets and
+	// volume claims its pod template uses
+	EdgeReferences = "references"
+	// EdgeTraffic connects two workloads observed exchanging requests in
+	// the service mesh, only present when kiali is configured
+	EdgeTraffic = "traffic"
+
+	kialiTimeout = 5 * time.Second
+)
+
+// Node is one resource in the dependency graph, identified by "Kind/name"
+// within the namespace.
+type Node struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+type Edge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Impact lists the resources that directly depend on one resource, what the
+// console shows before a delete.
+type Impact struct {
+	Resource   Node   `json:"resource"`
+	Dependents []Node `json:"dependents"`
+}
+
+type Operator interface {
+	// Graph computes the dependency graph of the namespace
+	Graph(namespace string) (*Graph, error)
+
+	// Impact lists the resources directly depending on the given one
+	Impact(namespace, kind, name string) (*Impact, error)
+}
+
+type operator struct {
+	client kubernetes.Interface
+	// kialiQueryHost enables mesh traffic edges when set
+	kialiQueryHost string
+}
+
+func NewOperator(client kubernetes.Interface, kialiQueryHost string) Operator {
+	return &operator{client: client, kialiQueryHost: kialiQueryHost}
+}
+
+func nodeID(kind, name string) string {
+	return fmt.Sprintf("%s/%s", kind, name)
+}
+
+func (o *operator) Graph(namespace string) (*Graph, error) {
+	graph := &Graph{Nodes: make([]Node, 0), Edges: make([]Edge, 0)}
+	seen := make(map[string]bool)
+	addNode := func(kind, name string) string {
+		id := nodeID(kind, name)
+		if !seen[id] {
+			seen[id] = true
+			graph.Nodes = append(graph.Nodes, Node{ID: id, Kind: kind, Name: name})
+		}
+		return id
+	}
+
+	type workload struct {
+		id       string
+		podLabel labels.Set
+	}
+	workloads := make([]workload, 0)
+	addWorkload := func(kind, name string, template corev1.PodTemplateSpec) {
+		id := addNode(kind, name)
+		workloads = append(workloads, workload{id: id, podLabel: template.Labels})
+		for _, ref := range podSpecReferences(&template.Spec) {
+			graph.Edges = append(graph.Edges, Edge{Source: id, Target: addNode(ref.Kind, ref.Name), Type: EdgeReferences})
+		}
+	}
+
+	deployments, err := o.client.AppsV1().Deployments(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range deployments.Items {
+		addWorkload(KindDeployment, deployments.Items[i].Name, deployments.Items[i].Spec.Template)
+	}
+
+	statefulSets, err := o.client.AppsV1().StatefulSets(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range statefulSets.Items {
+		addWorkload(KindStatefulSet, statefulSets.Items[i].Name, statefulSets.Items[i].Spec.Template)
+		for _, template := range statefulSets.Items[i].Spec.VolumeClaimTemplates {
+			id := nodeID(KindStatefulSet, statefulSets.Items[i].Name)
+			graph.Edges = append(graph.Edges, Edge{Source: id, Target: addNode(KindPersistentVolumeClaim, template.Name), Type: EdgeReferences})
+		}
+	}
+
+	daemonSets, err := o.client.AppsV1().DaemonSets(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range daemonSets.Items {
+		addWorkload(KindDaemonSet, daemonSets.Items[i].Name, daemonSets.Items[i].Spec.Template)
+	}
+
+	services, err := o.client.CoreV1().Services(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range services.Items {
+		service := &services.Items[i]
+		if len(service.Spec.Selector) == 0 {
+			continue
+		}
+		serviceID := addNode(KindService, service.Name)
+		selector := labels.SelectorFromSet(service.Spec.Selector)
+		for _, w := range workloads {
+			if selector.Matches(w.podLabel) {
+				graph.Edges = append(graph.Edges, Edge{Source: serviceID, Target: w.id, Type: EdgeSelects})
+			}
+		}
+	}
+
+	if o.kialiQueryHost != "" {
+		// mesh edges are best effort, the static graph is still useful
+		// when kiali is unreachable
+		if err := o.addTrafficEdges(graph, seen, namespace); err != nil {
+			klog.V(4).Infof("failed to get mesh traffic edges for namespace %s: %v", namespace, err)
+		}
+	}
+	return graph, nil
+}
+
+func (o *operator) Impact(namespace, kind, name string) (*Impact, error) {
+	graph, err := o.Graph(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	id := nodeID(kind, name)
+	nodes := make(map[string]Node, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+	resource, ok := nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("%s %s not found in namespace %s", kind, name, namespace)
+	}
+
+	impact := &Impact{Resource: resource, Dependents: make([]Node, 0)}
+	seen := make(map[string]bool)
+	for _, edge := range graph.Edges {
+		if edge.Target != id || edge.Type == EdgeTraffic || seen[edge.Source] {
+			continue
+		}
+		seen[edge.Source] = true
+		impact.Dependents = append(impact.Dependents, nodes[edge.Source])
+	}
+	return impact, nil
+}
+
+// podSpecReferences collects the configmaps, secrets and volume claims a pod
+// spec uses through volumes, env and envFrom.
+func podSpecReferences(spec *corev1.PodSpec) []Node {
+	refs := make([]Node, 0)
+	seen := make(map[string]bool)
+	add := func(kind, name string) {
+		if name == "" || seen[nodeID(kind, name)] {
+			return
+		}
+		seen[nodeID(kind, name)] = true
+		refs = append(refs, Node{Kind: kind, Name: name})
+	}
+
+	for _, volume := range spec.Volumes {
+		switch {
+		case volume.ConfigMap != nil:
+			add(KindConfigMap, volume.ConfigMap.Name)
+		case volume.Secret != nil:
+			add(KindSecret, volume.Secret.SecretName)
+		case volume.PersistentVolumeClaim != nil:
+			add(KindPersistentVolumeClaim, volume.PersistentVolumeClaim.ClaimName)
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					add(KindConfigMap, source.ConfigMap.Name)
+				}
+				if source.Secret != nil {
+					add(KindSecret, source.Secret.Name)
+				}
+			}
+		}
+	}
+
+	containers := make([]corev1.Container, 0, len(spec.Containers)+len(spec.InitContainers))
+	containers = append(containers, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				add(KindConfigMap, envFrom.ConfigMapRef.Name)
+			}
+			if envFrom.SecretRef != nil {
+				add(KindSecret, envFrom.SecretRef.Name)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				add(KindConfigMap, env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				add(KindSecret, env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	for _, pullSecret := range spec.ImagePullSecrets {
+		add(KindSecret, pullSecret.Name)
+	}
+	return refs
+}
+
+// kialiGraph is the subset of the kiali graph response the traffic edges
+// need.
+type kialiGraph struct {
+	Elements struct {
+		Nodes []struct {
+			Data struct {
+				ID        string `json:"id"`
+				NodeType  string `json:"nodeType"`
+				Namespace string `json:"namespace"`
+				Workload  string `json:"workload"`
+			} `json:"data"`
+		} `json:"nodes"`
+		Edges []struct {
+			Data struct {
+				Source string `json:"source"`
+				Target string `json:"target"`
+			} `json:"data"`
+		} `json:"edges"`
+	} `json:"elements"`
+}
+
+func (o *operator) addTrafficEdges(graph *Graph, seen map[string]bool, namespace string) error {
+	url := fmt.Sprintf("%s/kiali/api/namespaces/graph?namespaces=%s&graphType=workload", o.kialiQueryHost, namespace)
+	client := &http.Client{Timeout: kialiTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kiali returned status %s", resp.Status)
+	}
+
+	var kiali kialiGraph
+	if err := json.NewDecoder(resp.Body).Decode(&kiali); err != nil {
+		return err
+	}
+
+	// map kiali node ids to the workload nodes of this graph
+	workloadByKialiID := make(map[string]string)
+	for _, node := range kiali.Elements.Nodes {
+		if node.Data.NodeType != "workload" || node.Data.Namespace != namespace {
+			continue
+		}
+		for _, kind := range []string{KindDeployment, KindStatefulSet, KindDaemonSet} {
+			if id := nodeID(kind, node.Data.Workload); seen[id] {
+				workloadByKialiID[node.Data.ID] = id
+				break
+			}
+		}
+	}
+	for _, edge := range kiali.Elements.Edges {
+		source, ok := workloadByKialiID[edge.Data.Source]
+		if !ok {
+			continue
+		}
+		target, ok := workloadByKialiID[edge.Data.Target]
+		if !ok || source == target {
+			continue
+		}
+		graph.Edges = append(graph.Edges, Edge{Source: source, Target: target, Type: EdgeTraffic})
+	}
+	return nil
+}
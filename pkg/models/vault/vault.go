@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vault surfaces which workloads consume external secrets, either
+// through the secrets store CSI driver or through vault agent injection.
+package vault
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+
+	vaultv1alpha1 "kubesphere.io/kubesphere/pkg/apis/vault/v1alpha1"
+)
+
+const (
+	csiDriverName               = "secrets-store.csi.k8s.io"
+	providerClassAttribute      = "secretProviderClass"
+	agentInjectAnnotation       = "vault.hashicorp.com/agent-inject"
+	agentSecretAnnotationPrefix = "vault.hashicorp.com/agent-inject-secret-"
+)
+
+// Consumer is a workload that mounts or injects external secrets.
+type Consumer struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+
+	// injection mode, csi or agent
+	Mode string `json:"mode"`
+
+	// name of the SecretProviderClass in csi mode, which matches the
+	// SecretBinding the class was generated from
+	SecretProviderClass string `json:"secretProviderClass,omitempty"`
+
+	// names of the secrets injected in agent mode
+	Secrets []string `json:"secrets,omitempty"`
+}
+
+type Operator interface {
+	// ListConsumers lists workloads consuming external secrets, an empty
+	// namespace lists consumers across all namespaces
+	ListConsumers(namespace string) ([]Consumer, error)
+}
+
+type operator struct {
+	informers informers.SharedInformerFactory
+}
+
+func NewOperator(informers informers.SharedInformerFactory) Operator {
+	return &operator{informers: informers}
+}
+
+func (o *operator) ListConsumers(namespace string) ([]Consumer, error) {
+	var consumers []Consumer
+
+	deployments, err := o.informers.Apps().V1().Deployments().Lister().Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range deployments {
+		consumers = append(consumers, podTemplateConsumers(deployment.Namespace, "Deployment", deployment.Name, &deployment.Spec.Template)...)
+	}
+
+	statefulSets, err := o.informers.Apps().V1().StatefulSets().Lister().StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, statefulSet := range statefulSets {
+		consumers = append(consumers, podTemplateConsumers(statefulSet.Namespace, "StatefulSet", statefulSet.Name, &statefulSet.Spec.Template)...)
+	}
+
+	daemonSets, err := o.informers.Apps().V1().DaemonSets().Lister().DaemonSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, daemonSet := range daemonSets {
+		consumers = append(consumers, podTemplateConsumers(daemonSet.Namespace, "DaemonSet", daemonSet.Name, &daemonSet.Spec.Template)...)
+	}
+
+	sort.Slice(consumers, func(i, j int) bool {
+		if consumers[i].Namespace != consumers[j].Namespace {
+			return consumers[i].Namespace < consumers[j].Namespace
+		}
+		return consumers[i].Name < consumers[j].Name
+	})
+	return consumers, nil
+}
+
+func podTemplateConsumers(namespace, kind, name string, podTemplate *corev1.PodTemplateSpec) []Consumer {
+	var consumers []Consumer
+
+	for _, volume := range podTemplate.Spec.Volumes {
+		if volume.CSI == nil || volume.CSI.Driver != csiDriverName {
+			continue
+		}
+		providerClass := ""
+		if volume.CSI.VolumeAttributes != nil {
+			providerClass = volume.CSI.VolumeAttributes[providerClassAttribute]
+		}
+		consumers = append(consumers, Consumer{
+			Namespace:           namespace,
+			Kind:                kind,
+			Name:                name,
+			Mode:                vaultv1alpha1.InjectionModeCSI,
+			SecretProviderClass: providerClass,
+		})
+		break
+	}
+
+	if secrets := injectedSecrets(podTemplate.ObjectMeta); len(secrets) > 0 {
+		consumers = append(consumers, Consumer{
+			Namespace: namespace,
+			Kind:      kind,
+			Name:      name,
+			Mode:      vaultv1alpha1.InjectionModeAgent,
+			Secrets:   secrets,
+		})
+	}
+
+	return consumers
+}
+
+func injectedSecrets(meta metav1.ObjectMeta) []string {
+	if meta.Annotations[agentInjectAnnotation] != "true" {
+		return nil
+	}
+	var secrets []string
+	for key := range meta.Annotations {
+		if strings.HasPrefix(key, agentSecretAnnotationPrefix) {
+			secrets = append(secrets, strings.TrimPrefix(key, agentSecretAnnotationPrefix))
+		}
+	}
+	sort.Strings(secrets)
+	return secrets
+}
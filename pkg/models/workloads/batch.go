@@ -0,0 +1,206 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	ActionRestart = "restart"
+	ActionScale   = "scale"
+	ActionDelete  = "delete"
+	ActionLabel   = "label"
+
+	KindDeployment  = "deployments"
+	KindStatefulSet = "statefulsets"
+	KindDaemonSet   = "daemonsets"
+
+	// bumped on restart to trigger a rolling update of the pod template
+	restartedAtAnnotation = "kubesphere.io/restartedAt"
+)
+
+// WorkloadRef names one workload a batch operation applies to.
+type WorkloadRef struct {
+	Kind string `json:"kind" description:"workload kind, one of deployments, statefulsets, daemonsets"`
+	Name string `json:"name" description:"workload name"`
+}
+
+// BatchRequest applies one action to many workloads of a namespace.
+type BatchRequest struct {
+	Action string `json:"action" description:"one of restart, scale, delete, label"`
+
+	// replica count the workloads are scaled to, for action scale
+	Replicas *int32 `json:"replicas,omitempty" description:"replica count for action scale"`
+
+	// labels set on the workloads, an empty value removes the key, for action label
+	Labels map[string]string `json:"labels,omitempty" description:"labels for action label, an empty value removes the key"`
+
+	// when true the action is validated by the apiserver but not persisted
+	DryRun bool `json:"dryRun,omitempty" description:"validate the action without persisting it"`
+
+	Workloads []WorkloadRef `json:"workloads" description:"workloads the action applies to"`
+}
+
+// BatchItemResult is the outcome of the action on one workload.
+type BatchItemResult struct {
+	Kind    string `json:"kind" description:"workload kind"`
+	Name    string `json:"name" description:"workload name"`
+	Success bool   `json:"success" description:"whether the action succeeded"`
+	Error   string `json:"error,omitempty" description:"failure reason, empty on success"`
+}
+
+type BatchResult struct {
+	Items     []BatchItemResult `json:"items" description:"per workload outcomes"`
+	Succeeded int               `json:"succeeded" description:"number of workloads the action succeeded on"`
+	Failed    int               `json:"failed" description:"number of workloads the action failed on"`
+}
+
+type BatchOperator interface {
+	// Batch applies the requested action to every workload of the request,
+	// failures on one workload do not stop the others.
+	Batch(namespace string, request BatchRequest) (BatchResult, error)
+}
+
+type batchOperator struct {
+	client kubernetes.Interface
+}
+
+func NewBatchOperator(client kubernetes.Interface) BatchOperator {
+	return &batchOperator{client: client}
+}
+
+func (o *batchOperator) Batch(namespace string, request BatchRequest) (BatchResult, error) {
+	switch request.Action {
+	case ActionRestart, ActionDelete, ActionLabel:
+	case ActionScale:
+		if request.Replicas == nil {
+			return BatchResult{}, fmt.Errorf("action scale requires replicas")
+		}
+	default:
+		return BatchResult{}, fmt.Errorf("invalid action %s", request.Action)
+	}
+
+	result := BatchResult{Items: make([]BatchItemResult, 0, len(request.Workloads))}
+	for _, workload := range request.Workloads {
+		item := BatchItemResult{Kind: workload.Kind, Name: workload.Name, Success: true}
+		if err := o.apply(namespace, workload, request); err != nil {
+			item.Success = false
+			item.Error = err.Error()
+			result.Failed++
+		} else {
+			result.Succeeded++
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+func (o *batchOperator) apply(namespace string, workload WorkloadRef, request BatchRequest) error {
+	var dryRun []string
+	if request.DryRun {
+		dryRun = []string{metav1.DryRunAll}
+	}
+
+	if request.Action == ActionDelete {
+		return o.delete(namespace, workload, metav1.DeleteOptions{DryRun: dryRun})
+	}
+
+	patch, err := buildPatch(workload, request)
+	if err != nil {
+		return err
+	}
+	return o.patch(namespace, workload, patch, metav1.PatchOptions{DryRun: dryRun})
+}
+
+func buildPatch(workload WorkloadRef, request BatchRequest) ([]byte, error) {
+	switch request.Action {
+	case ActionRestart:
+		return json.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"annotations": map[string]string{
+							restartedAtAnnotation: time.Now().Format(time.RFC3339),
+						},
+					},
+				},
+			},
+		})
+	case ActionScale:
+		if workload.Kind == KindDaemonSet {
+			return nil, fmt.Errorf("daemonsets cannot be scaled")
+		}
+		return json.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{
+				"replicas": *request.Replicas,
+			},
+		})
+	case ActionLabel:
+		labels := make(map[string]interface{}, len(request.Labels))
+		for key, value := range request.Labels {
+			if value == "" {
+				labels[key] = nil
+			} else {
+				labels[key] = value
+			}
+		}
+		return json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": labels,
+			},
+		})
+	}
+	return nil, fmt.Errorf("invalid action %s", request.Action)
+}
+
+func (o *batchOperator) patch(namespace string, workload WorkloadRef, patch []byte, options metav1.PatchOptions) error {
+	ctx := context.Background()
+	var err error
+	switch workload.Kind {
+	case KindDeployment:
+		_, err = o.client.AppsV1().Deployments(namespace).Patch(ctx, workload.Name, types.StrategicMergePatchType, patch, options)
+	case KindStatefulSet:
+		_, err = o.client.AppsV1().StatefulSets(namespace).Patch(ctx, workload.Name, types.StrategicMergePatchType, patch, options)
+	case KindDaemonSet:
+		_, err = o.client.AppsV1().DaemonSets(namespace).Patch(ctx, workload.Name, types.StrategicMergePatchType, patch, options)
+	default:
+		err = fmt.Errorf("invalid workload kind %s", workload.Kind)
+	}
+	return err
+}
+
+func (o *batchOperator) delete(namespace string, workload WorkloadRef, options metav1.DeleteOptions) error {
+	ctx := context.Background()
+	switch workload.Kind {
+	case KindDeployment:
+		return o.client.AppsV1().Deployments(namespace).Delete(ctx, workload.Name, options)
+	case KindStatefulSet:
+		return o.client.AppsV1().StatefulSets(namespace).Delete(ctx, workload.Name, options)
+	case KindDaemonSet:
+		return o.client.AppsV1().DaemonSets(namespace).Delete(ctx, workload.Name, options)
+	default:
+		return fmt.Errorf("invalid workload kind %s", workload.Kind)
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloads
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// instantiateAnnotation marks jobs created by the trigger-now API, the
+	// same annotation kubectl create job --from=cronjob uses
+	instantiateAnnotation = "cronjob.kubernetes.io/instantiate"
+
+	RunStatusRunning   = "Running"
+	RunStatusSucceeded = "Succeeded"
+	RunStatusFailed    = "Failed"
+)
+
+// Run is one execution of a CronJob, past or in flight.
+type Run struct {
+	Name            string       `json:"name"`
+	StartTime       *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime  *metav1.Time `json:"completionTime,omitempty"`
+	DurationSeconds *int64       `json:"durationSeconds,omitempty"`
+	Status          string       `json:"status"`
+	// Manual is true for runs started by the trigger-now API
+	Manual  bool   `json:"manual"`
+	Message string `json:"message,omitempty"`
+}
+
+type CronJobOperator interface {
+	// TriggerNow starts a run of the cron job immediately, outside its
+	// schedule
+	TriggerNow(namespace, cronJobName string) (*batchv1.Job, error)
+
+	// Pause suspends the schedule, running jobs are not interrupted
+	Pause(namespace, cronJobName string) error
+
+	// Resume lifts the suspension
+	Resume(namespace, cronJobName string) error
+
+	// History lists the runs of the cron job, newest first
+	History(namespace, cronJobName string) ([]Run, error)
+
+	// RunLogs streams the pod logs of one run
+	RunLogs(namespace, jobName string) (string, error)
+}
+
+type cronJobOperator struct {
+	client kubernetes.Interface
+}
+
+func NewCronJobOperator(client kubernetes.Interface) CronJobOperator {
+	return &cronJobOperator{client: client}
+}
+
+func (o *cronJobOperator) TriggerNow(namespace, cronJobName string) (*batchv1.Job, error) {
+	cronJob, err := o.client.BatchV1beta1().CronJobs(namespace).Get(context.Background(), cronJobName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := map[string]string{instantiateAnnotation: "manual"}
+	for key, value := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[key] = value
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-manual-%d", cronJobName, time.Now().Unix()),
+			Namespace:   namespace,
+			Labels:      cronJob.Spec.JobTemplate.Labels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(cronJob, batchv1beta1GroupVersionKind()),
+			},
+		},
+		Spec: cronJob.Spec.JobTemplate.Spec,
+	}
+	return o.client.BatchV1().Jobs(namespace).Create(context.Background(), job, metav1.CreateOptions{})
+}
+
+func (o *cronJobOperator) Pause(namespace, cronJobName string) error {
+	return o.setSuspend(namespace, cronJobName, true)
+}
+
+func (o *cronJobOperator) Resume(namespace, cronJobName string) error {
+	return o.setSuspend(namespace, cronJobName, false)
+}
+
+func (o *cronJobOperator) setSuspend(namespace, cronJobName string, suspend bool) error {
+	cronJob, err := o.client.BatchV1beta1().CronJobs(namespace).Get(context.Background(), cronJobName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend == suspend {
+		return nil
+	}
+	cronJob.Spec.Suspend = &suspend
+	_, err = o.client.BatchV1beta1().CronJobs(namespace).Update(context.Background(), cronJob, metav1.UpdateOptions{})
+	return err
+}
+
+func (o *cronJobOperator) History(namespace, cronJobName string) ([]Run, error) {
+	cronJob, err := o.client.BatchV1beta1().CronJobs(namespace).Get(context.Background(), cronJobName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := o.client.BatchV1().Jobs(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]Run, 0)
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if !ownedBy(job, cronJob.UID) {
+			continue
+		}
+		runs = append(runs, renderRun(job))
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		it, jt := runs[i].StartTime, runs[j].StartTime
+		if it == nil || jt == nil {
+			return it != nil
+		}
+		return jt.Before(it)
+	})
+	return runs, nil
+}
+
+func (o *cronJobOperator) RunLogs(namespace, jobName string) (string, error) {
+	pods, err := o.client.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(pods.Items) == 0 {
+		return "", fmt.Errorf("no pods found for job %s", jobName)
+	}
+
+	var buffer bytes.Buffer
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if len(pods.Items) > 1 {
+			fmt.Fprintf(&buffer, "==> %s <==\n", pod.Name)
+		}
+		stream, err := o.client.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).Stream(context.Background())
+		if err != nil {
+			fmt.Fprintf(&buffer, "failed to read logs: %v\n", err)
+			continue
+		}
+		_, err = io.Copy(&buffer, stream)
+		stream.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return buffer.String(), nil
+}
+
+func ownedBy(job *batchv1.Job, uid types.UID) bool {
+	for _, owner := range job.OwnerReferences {
+		if owner.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func renderRun(job *batchv1.Job) Run {
+	run := Run{
+		Name:           job.Name,
+		StartTime:      job.Status.StartTime,
+		CompletionTime: job.Status.CompletionTime,
+		Status:         RunStatusRunning,
+		Manual:         job.Annotations[instantiateAnnotation] == "manual",
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			run.Status = RunStatusSucceeded
+		case batchv1.JobFailed:
+			run.Status = RunStatusFailed
+			run.Message = condition.Message
+		}
+	}
+	if run.StartTime != nil {
+		end := time.Now()
+		if run.CompletionTime != nil {
+			end = run.CompletionTime.Time
+		}
+		duration := int64(end.Sub(run.StartTime.Time).Seconds())
+		run.DurationSeconds = &duration
+	}
+	return run
+}
+
+func batchv1beta1GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: "batch", Version: "v1beta1", Kind: "CronJob"}
+}
@@ -0,0 +1,393 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloads
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// container roles in a workload form
+	RoleMain = "main"
+	RoleInit = "init"
+	// RoleSidecar containers run alongside the main containers for the
+	// whole pod lifetime; they are ordered before the main containers and
+	// recorded in the sidecars annotation
+	RoleSidecar = "sidecar"
+
+	FormKindDeployment  = "deployment"
+	FormKindStatefulSet = "statefulset"
+	FormKindDaemonSet   = "daemonset"
+
+	// SidecarsAnnotation lists the sidecar container names on the pod
+	// template, so the console can render them separately from the main
+	// containers
+	SidecarsAnnotation = "workloads.kubesphere.io/sidecars"
+)
+
+// ContainerForm models one container of a workload form, including the
+// pieces the simple form used to drop: lifecycle hooks, startup probes and
+// the init/sidecar roles.
+type ContainerForm struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	// Role is main, init or sidecar; empty means main
+	Role            string                      `json:"role,omitempty"`
+	Command         []string                    `json:"command,omitempty"`
+	Args            []string                    `json:"args,omitempty"`
+	WorkingDir      string                      `json:"workingDir,omitempty"`
+	Env             []corev1.EnvVar             `json:"env,omitempty"`
+	EnvFrom         []corev1.EnvFromSource      `json:"envFrom,omitempty"`
+	Ports           []corev1.ContainerPort      `json:"ports,omitempty"`
+	Resources       corev1.ResourceRequirements `json:"resources,omitempty"`
+	VolumeMounts    []corev1.VolumeMount        `json:"volumeMounts,omitempty"`
+	StartupProbe    *corev1.Probe               `json:"startupProbe,omitempty"`
+	LivenessProbe   *corev1.Probe               `json:"livenessProbe,omitempty"`
+	ReadinessProbe  *corev1.Probe               `json:"readinessProbe,omitempty"`
+	Lifecycle       *corev1.Lifecycle           `json:"lifecycle,omitempty"`
+	ImagePullPolicy corev1.PullPolicy           `json:"imagePullPolicy,omitempty"`
+	SecurityContext *corev1.SecurityContext     `json:"securityContext,omitempty"`
+}
+
+// WorkloadForm is the full workload creation/edit form.
+type WorkloadForm struct {
+	// Kind is deployment, statefulset or daemonset
+	Kind        string            `json:"kind"`
+	Name        string            `json:"name"`
+	Replicas    *int32            `json:"replicas,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Containers  []ContainerForm   `json:"containers"`
+	Volumes     []corev1.Volume   `json:"volumes,omitempty"`
+	// ServiceName is required for stateful sets
+	ServiceName        string                     `json:"serviceName,omitempty"`
+	ServiceAccountName string                     `json:"serviceAccountName,omitempty"`
+	ImagePullSecrets   []string                   `json:"imagePullSecrets,omitempty"`
+	NodeSelector       map[string]string          `json:"nodeSelector,omitempty"`
+	Tolerations        []corev1.Toleration        `json:"tolerations,omitempty"`
+	SecurityContext    *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+}
+
+// Validate returns one actionable error per problem of the form.
+func (f *WorkloadForm) Validate() []error {
+	var errs []error
+
+	switch f.Kind {
+	case FormKindDeployment, FormKindDaemonSet:
+	case FormKindStatefulSet:
+		if f.ServiceName == "" {
+			errs = append(errs, fmt.Errorf("statefulsets need serviceName, the headless service governing the pods"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("kind %q is invalid, must be one of deployment, statefulset, daemonset", f.Kind))
+	}
+	if f.Name == "" {
+		errs = append(errs, fmt.Errorf("name is required"))
+	}
+
+	mains := 0
+	names := map[string]bool{}
+	for i := range f.Containers {
+		container := &f.Containers[i]
+		prefix := fmt.Sprintf("containers[%d]", i)
+		if container.Name == "" {
+			errs = append(errs, fmt.Errorf("%s: name is required", prefix))
+		} else if names[container.Name] {
+			errs = append(errs, fmt.Errorf("%s: name %q is used twice", prefix, container.Name))
+		} else {
+			names[container.Name] = true
+			prefix = fmt.Sprintf("container %s", container.Name)
+		}
+		if container.Image == "" {
+			errs = append(errs, fmt.Errorf("%s: image is required", prefix))
+		}
+
+		switch container.Role {
+		case "", RoleMain:
+			mains++
+		case RoleSidecar:
+		case RoleInit:
+			// the API server rejects probes and lifecycle hooks on init
+			// containers, catch it in the form
+			if container.StartupProbe != nil || container.LivenessProbe != nil || container.ReadinessProbe != nil {
+				errs = append(errs, fmt.Errorf("%s: init containers cannot have probes, use a sidecar if the process keeps running", prefix))
+			}
+			if container.Lifecycle != nil {
+				errs = append(errs, fmt.Errorf("%s: init containers cannot have lifecycle hooks", prefix))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%s: role %q is invalid, must be one of main, init, sidecar", prefix, container.Role))
+		}
+
+		for _, probe := range []struct {
+			name  string
+			probe *corev1.Probe
+		}{
+			{"startupProbe", container.StartupProbe},
+			{"livenessProbe", container.LivenessProbe},
+			{"readinessProbe", container.ReadinessProbe},
+		} {
+			if probe.probe == nil {
+				continue
+			}
+			if err := validateHandler(&probe.probe.Handler); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %v", prefix, probe.name, err))
+			}
+		}
+		if container.Lifecycle != nil {
+			if container.Lifecycle.PostStart == nil && container.Lifecycle.PreStop == nil {
+				errs = append(errs, fmt.Errorf("%s: lifecycle needs at least one of postStart, preStop", prefix))
+			}
+			for _, hook := range []struct {
+				name    string
+				handler *corev1.Handler
+			}{
+				{"postStart", container.Lifecycle.PostStart},
+				{"preStop", container.Lifecycle.PreStop},
+			} {
+				if hook.handler == nil {
+					continue
+				}
+				if err := validateHandler(hook.handler); err != nil {
+					errs = append(errs, fmt.Errorf("%s: lifecycle.%s: %v", prefix, hook.name, err))
+				}
+			}
+		}
+	}
+	if mains == 0 {
+		errs = append(errs, fmt.Errorf("at least one container with role main is required"))
+	}
+
+	return errs
+}
+
+// validateHandler enforces exactly one action per probe or lifecycle hook.
+func validateHandler(handler *corev1.Handler) error {
+	actions := 0
+	if handler.Exec != nil {
+		actions++
+	}
+	if handler.HTTPGet != nil {
+		actions++
+	}
+	if handler.TCPSocket != nil {
+		actions++
+	}
+	if actions != 1 {
+		return fmt.Errorf("exactly one of exec, httpGet, tcpSocket must be set")
+	}
+	return nil
+}
+
+// podTemplate renders the form into a pod template. Sidecars are ordered
+// before the main containers so they start first, and their names are
+// recorded in the sidecars annotation.
+func (f *WorkloadForm) podTemplate() corev1.PodTemplateSpec {
+	var initContainers, sidecars, mains []corev1.Container
+	var sidecarNames []string
+	for i := range f.Containers {
+		form := &f.Containers[i]
+		container := corev1.Container{
+			Name:            form.Name,
+			Image:           form.Image,
+			Command:         form.Command,
+			Args:            form.Args,
+			WorkingDir:      form.WorkingDir,
+			Env:             form.Env,
+			EnvFrom:         form.EnvFrom,
+			Ports:           form.Ports,
+			Resources:       form.Resources,
+			VolumeMounts:    form.VolumeMounts,
+			StartupProbe:    form.StartupProbe,
+			LivenessProbe:   form.LivenessProbe,
+			ReadinessProbe:  form.ReadinessProbe,
+			Lifecycle:       form.Lifecycle,
+			ImagePullPolicy: form.ImagePullPolicy,
+			SecurityContext: form.SecurityContext,
+		}
+		switch form.Role {
+		case RoleInit:
+			initContainers = append(initContainers, container)
+		case RoleSidecar:
+			sidecars = append(sidecars, container)
+			sidecarNames = append(sidecarNames, form.Name)
+		default:
+			mains = append(mains, container)
+		}
+	}
+
+	annotations := map[string]string{}
+	for key, value := range f.Annotations {
+		annotations[key] = value
+	}
+	if len(sidecarNames) > 0 {
+		annotations[SidecarsAnnotation] = strings.Join(sidecarNames, ",")
+	}
+
+	var pullSecrets []corev1.LocalObjectReference
+	for _, name := range f.ImagePullSecrets {
+		pullSecrets = append(pullSecrets, corev1.LocalObjectReference{Name: name})
+	}
+
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      f.selector(),
+			Annotations: annotations,
+		},
+		Spec: corev1.PodSpec{
+			InitContainers:     initContainers,
+			Containers:         append(sidecars, mains...),
+			Volumes:            f.Volumes,
+			ServiceAccountName: f.ServiceAccountName,
+			ImagePullSecrets:   pullSecrets,
+			NodeSelector:       f.NodeSelector,
+			Tolerations:        f.Tolerations,
+			SecurityContext:    f.SecurityContext,
+		},
+	}
+}
+
+func (f *WorkloadForm) selector() map[string]string {
+	labels := map[string]string{"app": f.Name}
+	for key, value := range f.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// Render produces the workload object the form describes.
+func (f *WorkloadForm) Render(namespace string) runtime.Object {
+	meta := metav1.ObjectMeta{
+		Name:        f.Name,
+		Namespace:   namespace,
+		Labels:      f.selector(),
+		Annotations: f.Annotations,
+	}
+	selector := &metav1.LabelSelector{MatchLabels: f.selector()}
+	template := f.podTemplate()
+
+	switch f.Kind {
+	case FormKindStatefulSet:
+		return &appsv1.StatefulSet{
+			ObjectMeta: meta,
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:    f.Replicas,
+				Selector:    selector,
+				ServiceName: f.ServiceName,
+				Template:    template,
+			},
+		}
+	case FormKindDaemonSet:
+		return &appsv1.DaemonSet{
+			ObjectMeta: meta,
+			Spec: appsv1.DaemonSetSpec{
+				Selector: selector,
+				Template: template,
+			},
+		}
+	default:
+		return &appsv1.Deployment{
+			ObjectMeta: meta,
+			Spec: appsv1.DeploymentSpec{
+				Replicas: f.Replicas,
+				Selector: selector,
+				Template: template,
+			},
+		}
+	}
+}
+
+// FormOperator applies workload forms.
+type FormOperator interface {
+	// Apply validates the form and creates or updates the workload it
+	// describes. With dryRun the rendered object is returned without
+	// touching the cluster.
+	Apply(namespace string, form WorkloadForm, dryRun bool) (runtime.Object, error)
+}
+
+type formOperator struct {
+	client kubernetes.Interface
+}
+
+func NewFormOperator(client kubernetes.Interface) FormOperator {
+	return &formOperator{client: client}
+}
+
+func (o *formOperator) Apply(namespace string, form WorkloadForm, dryRun bool) (runtime.Object, error) {
+	if errs := form.Validate(); len(errs) > 0 {
+		messages := make([]string, 0, len(errs))
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		return nil, fmt.Errorf("invalid workload form: %s", strings.Join(messages, "; "))
+	}
+
+	rendered := form.Render(namespace)
+	if dryRun {
+		return rendered, nil
+	}
+
+	ctx := context.Background()
+	switch workload := rendered.(type) {
+	case *appsv1.Deployment:
+		existing, err := o.client.AppsV1().Deployments(namespace).Get(ctx, form.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return o.client.AppsV1().Deployments(namespace).Create(ctx, workload, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return nil, err
+		}
+		existing.Labels = workload.Labels
+		existing.Annotations = workload.Annotations
+		existing.Spec = workload.Spec
+		return o.client.AppsV1().Deployments(namespace).Update(ctx, existing, metav1.UpdateOptions{})
+	case *appsv1.StatefulSet:
+		existing, err := o.client.AppsV1().StatefulSets(namespace).Get(ctx, form.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return o.client.AppsV1().StatefulSets(namespace).Create(ctx, workload, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return nil, err
+		}
+		existing.Labels = workload.Labels
+		existing.Annotations = workload.Annotations
+		existing.Spec = workload.Spec
+		return o.client.AppsV1().StatefulSets(namespace).Update(ctx, existing, metav1.UpdateOptions{})
+	case *appsv1.DaemonSet:
+		existing, err := o.client.AppsV1().DaemonSets(namespace).Get(ctx, form.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return o.client.AppsV1().DaemonSets(namespace).Create(ctx, workload, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return nil, err
+		}
+		existing.Labels = workload.Labels
+		existing.Annotations = workload.Annotations
+		existing.Spec = workload.Spec
+		return o.client.AppsV1().DaemonSets(namespace).Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	return nil, fmt.Errorf("unsupported workload kind %s", form.Kind)
+}
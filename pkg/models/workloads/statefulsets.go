@@ -0,0 +1,226 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloads
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ScaleRequest scales a stateful set and optionally cleans up the volume
+// claims of the removed ordinals, which Kubernetes itself never deletes.
+type ScaleRequest struct {
+	Replicas int32 `json:"replicas"`
+	// DeletePersistentVolumeClaims removes the claims of ordinals dropped by
+	// a scale-down. Ignored when scaling up.
+	DeletePersistentVolumeClaims bool `json:"deletePersistentVolumeClaims,omitempty"`
+}
+
+// PartitionRequest moves the rolling update partition, ordinals >= partition
+// run the updated revision while the rest stay on the current one.
+type PartitionRequest struct {
+	Partition int32 `json:"partition"`
+}
+
+// PodProgress is the rollout state of one ordinal.
+type PodProgress struct {
+	Name    string `json:"name"`
+	Ordinal int32  `json:"ordinal"`
+	Ready   bool   `json:"ready"`
+	// Updated is true once the pod runs the update revision
+	Updated bool   `json:"updated"`
+	Phase   string `json:"phase"`
+}
+
+// UpdateProgress is a point-in-time view of a stateful set rollout, ordered
+// by ordinal so the console can render the canary boundary.
+type UpdateProgress struct {
+	Replicas        int32         `json:"replicas"`
+	ReadyReplicas   int32         `json:"readyReplicas"`
+	UpdatedReplicas int32         `json:"updatedReplicas"`
+	CurrentRevision string        `json:"currentRevision"`
+	UpdateRevision  string        `json:"updateRevision"`
+	Partition       *int32        `json:"partition,omitempty"`
+	Pods            []PodProgress `json:"pods"`
+}
+
+type StatefulSetOperator interface {
+	// SetPartition moves the rolling update partition for canary updates
+	SetPartition(namespace, name string, partition int32) error
+
+	// RestartOrdinal deletes the pod of the given ordinal so the stateful
+	// set controller recreates it in place
+	RestartOrdinal(namespace, name string, ordinal int32) error
+
+	// Scale changes the replica count and, on scale-down, optionally
+	// deletes the volume claims of the removed ordinals
+	Scale(namespace, name string, request ScaleRequest) error
+
+	// Progress reports the rollout state of every ordinal, poll it while
+	// an update or restart is in flight
+	Progress(namespace, name string) (*UpdateProgress, error)
+}
+
+type statefulSetOperator struct {
+	client kubernetes.Interface
+}
+
+func NewStatefulSetOperator(client kubernetes.Interface) StatefulSetOperator {
+	return &statefulSetOperator{client: client}
+}
+
+func (o *statefulSetOperator) SetPartition(namespace, name string, partition int32) error {
+	statefulSet, err := o.client.AppsV1().StatefulSets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if partition < 0 {
+		return fmt.Errorf("partition must not be negative")
+	}
+	if statefulSet.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return fmt.Errorf("statefulset %s does not use the RollingUpdate strategy", name)
+	}
+	if statefulSet.Spec.UpdateStrategy.RollingUpdate == nil {
+		statefulSet.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{}
+	}
+	statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition = &partition
+	_, err = o.client.AppsV1().StatefulSets(namespace).Update(context.Background(), statefulSet, metav1.UpdateOptions{})
+	return err
+}
+
+func (o *statefulSetOperator) RestartOrdinal(namespace, name string, ordinal int32) error {
+	statefulSet, err := o.client.AppsV1().StatefulSets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if ordinal < 0 || (statefulSet.Spec.Replicas != nil && ordinal >= *statefulSet.Spec.Replicas) {
+		return fmt.Errorf("ordinal %d is out of range", ordinal)
+	}
+	podName := fmt.Sprintf("%s-%d", name, ordinal)
+	return o.client.CoreV1().Pods(namespace).Delete(context.Background(), podName, metav1.DeleteOptions{})
+}
+
+func (o *statefulSetOperator) Scale(namespace, name string, request ScaleRequest) error {
+	if request.Replicas < 0 {
+		return fmt.Errorf("replicas must not be negative")
+	}
+	statefulSet, err := o.client.AppsV1().StatefulSets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	oldReplicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		oldReplicas = *statefulSet.Spec.Replicas
+	}
+	statefulSet.Spec.Replicas = &request.Replicas
+	if _, err = o.client.AppsV1().StatefulSets(namespace).Update(context.Background(), statefulSet, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	if !request.DeletePersistentVolumeClaims || request.Replicas >= oldReplicas {
+		return nil
+	}
+	for ordinal := request.Replicas; ordinal < oldReplicas; ordinal++ {
+		for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+			claimName := fmt.Sprintf("%s-%s-%d", template.Name, name, ordinal)
+			err := o.client.CoreV1().PersistentVolumeClaims(namespace).Delete(context.Background(), claimName, metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("statefulset scaled down but failed to delete claim %s: %v", claimName, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (o *statefulSetOperator) Progress(namespace, name string) (*UpdateProgress, error) {
+	statefulSet, err := o.client.AppsV1().StatefulSets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &UpdateProgress{
+		ReadyReplicas:   statefulSet.Status.ReadyReplicas,
+		UpdatedReplicas: statefulSet.Status.UpdatedReplicas,
+		CurrentRevision: statefulSet.Status.CurrentRevision,
+		UpdateRevision:  statefulSet.Status.UpdateRevision,
+		Pods:            make([]PodProgress, 0),
+	}
+	if statefulSet.Spec.Replicas != nil {
+		progress.Replicas = *statefulSet.Spec.Replicas
+	}
+	if statefulSet.Spec.UpdateStrategy.RollingUpdate != nil {
+		progress.Partition = statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(statefulSet.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := o.client.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		ordinal, ok := podOrdinal(name, pod.Name)
+		if !ok {
+			continue
+		}
+		progress.Pods = append(progress.Pods, PodProgress{
+			Name:    pod.Name,
+			Ordinal: ordinal,
+			Ready:   podReady(pod),
+			Updated: pod.Labels[appsv1.StatefulSetRevisionLabel] == statefulSet.Status.UpdateRevision,
+			Phase:   string(pod.Status.Phase),
+		})
+	}
+	sort.Slice(progress.Pods, func(i, j int) bool {
+		return progress.Pods[i].Ordinal < progress.Pods[j].Ordinal
+	})
+	return progress, nil
+}
+
+func podOrdinal(statefulSetName, podName string) (int32, bool) {
+	if !strings.HasPrefix(podName, statefulSetName+"-") {
+		return 0, false
+	}
+	ordinal, err := strconv.ParseInt(strings.TrimPrefix(podName, statefulSetName+"-"), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int32(ordinal), true
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
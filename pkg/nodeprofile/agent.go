@@ -0,0 +1,205 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nodeprofile implements the node agent that applies NodeProfiles.
+// It runs as a DaemonSet with the host's /proc/sys and the kubelet drop-in
+// directory mounted, applies the profile matching the node's node group and
+// reports the applied revision through node annotations, which the
+// compliance API compares against the current profile generation.
+package nodeprofile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	nodev1alpha1 "kubesphere.io/kubesphere/pkg/apis/node/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/models/nodegroup"
+)
+
+const (
+	// DefaultSyncPeriod is how often the agent re-applies the profile,
+	// which also serves as drift correction for changed sysctls
+	DefaultSyncPeriod = time.Minute
+
+	// DefaultKubeletDropInFile is where the kubelet settings of the
+	// profile are rendered, picked up on the next kubelet restart
+	DefaultKubeletDropInFile = "/etc/kubernetes/kubelet.d/kubesphere-nodeprofile.json"
+
+	defaultProcSysPath = "/proc/sys"
+)
+
+// Agent applies the NodeProfile of the node's node group.
+type Agent struct {
+	Client            client.Client
+	NodeName          string
+	KubeletDropInFile string
+	SyncPeriod        time.Duration
+
+	// root of the sysctl tree, overridable in tests
+	ProcSysPath string
+}
+
+func (a *Agent) Run(stopCh <-chan struct{}) error {
+	if a.NodeName == "" {
+		return fmt.Errorf("node name must not be empty, set the NODE_NAME environment variable")
+	}
+	if a.SyncPeriod <= 0 {
+		a.SyncPeriod = DefaultSyncPeriod
+	}
+	if a.KubeletDropInFile == "" {
+		a.KubeletDropInFile = DefaultKubeletDropInFile
+	}
+	if a.ProcSysPath == "" {
+		a.ProcSysPath = defaultProcSysPath
+	}
+
+	ticker := time.NewTicker(a.SyncPeriod)
+	defer ticker.Stop()
+	for {
+		if err := a.sync(); err != nil {
+			klog.Errorf("failed to sync node profile: %v", err)
+		}
+		select {
+		case <-stopCh:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *Agent) sync() error {
+	ctx := context.Background()
+	node := &corev1.Node{}
+	if err := a.Client.Get(ctx, client.ObjectKey{Name: a.NodeName}, node); err != nil {
+		return err
+	}
+
+	profile, err := a.profileFor(ctx, node)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		return a.annotate(ctx, node, "", "")
+	}
+
+	revision := fmt.Sprintf("%s/%d", profile.Name, profile.Generation)
+	if err := a.apply(profile); err != nil {
+		if annotateErr := a.annotate(ctx, node, revision, err.Error()); annotateErr != nil {
+			klog.Error(annotateErr)
+		}
+		return err
+	}
+	return a.annotate(ctx, node, revision, nodev1alpha1.StatusApplied)
+}
+
+func (a *Agent) profileFor(ctx context.Context, node *corev1.Node) (*nodev1alpha1.NodeProfile, error) {
+	group := node.Labels[nodegroup.GroupLabel]
+	if group == "" {
+		return nil, nil
+	}
+	profiles := &nodev1alpha1.NodeProfileList{}
+	if err := a.Client.List(ctx, profiles); err != nil {
+		return nil, err
+	}
+	for i := range profiles.Items {
+		if profiles.Items[i].Spec.NodeGroup == group {
+			return &profiles.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (a *Agent) apply(profile *nodev1alpha1.NodeProfile) error {
+	for name, value := range profile.Spec.Sysctls {
+		if err := a.writeSysctl(name, value); err != nil {
+			return fmt.Errorf("sysctl %s: %v", name, err)
+		}
+	}
+	return a.writeKubeletDropIn(profile)
+}
+
+func (a *Agent) writeSysctl(name, value string) error {
+	path := filepath.Join(a.ProcSysPath, strings.Replace(name, ".", "/", -1))
+	current, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(current)) == value {
+		return nil
+	}
+	return ioutil.WriteFile(path, []byte(value), 0644)
+}
+
+// writeKubeletDropIn renders the kubelet settings of the profile into the
+// drop-in file, kubelet reads it on its next restart
+func (a *Agent) writeKubeletDropIn(profile *nodev1alpha1.NodeProfile) error {
+	settings := map[string]interface{}{
+		"kind":       "KubeletConfiguration",
+		"apiVersion": "kubelet.config.k8s.io/v1beta1",
+	}
+	if len(profile.Spec.KubeReserved) > 0 {
+		settings["kubeReserved"] = profile.Spec.KubeReserved
+	}
+	if len(profile.Spec.SystemReserved) > 0 {
+		settings["systemReserved"] = profile.Spec.SystemReserved
+	}
+	if profile.Spec.CPUManagerPolicy != "" {
+		settings["cpuManagerPolicy"] = profile.Spec.CPUManagerPolicy
+	}
+	if len(profile.Spec.HugePages) > 0 {
+		settings["allocatableHugepages"] = profile.Spec.HugePages
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if current, err := ioutil.ReadFile(a.KubeletDropInFile); err == nil && string(current) == string(data) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(a.KubeletDropInFile), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(a.KubeletDropInFile, data, 0644)
+}
+
+func (a *Agent) annotate(ctx context.Context, node *corev1.Node, revision, status string) error {
+	if node.Annotations[nodev1alpha1.AppliedProfileAnnotation] == revision &&
+		node.Annotations[nodev1alpha1.AppliedStatusAnnotation] == status {
+		return nil
+	}
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	if revision == "" {
+		delete(node.Annotations, nodev1alpha1.AppliedProfileAnnotation)
+		delete(node.Annotations, nodev1alpha1.AppliedStatusAnnotation)
+	} else {
+		node.Annotations[nodev1alpha1.AppliedProfileAnnotation] = revision
+		node.Annotations[nodev1alpha1.AppliedStatusAnnotation] = status
+	}
+	return a.Client.Update(ctx, node)
+}
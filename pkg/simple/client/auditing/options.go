@@ -34,6 +34,31 @@ type Options struct {
 	Host               string        `json:"host" yaml:"host"`
 	IndexPrefix        string        `json:"indexPrefix,omitempty" yaml:"indexPrefix"`
 	Version            string        `json:"version" yaml:"version"`
+	// Sinks stream audit events to external systems in near-real-time, in
+	// addition to the builtin webhook. Configured through kubesphere.yaml.
+	Sinks []SinkOptions `json:"sinks,omitempty" yaml:"sinks,omitempty"`
+}
+
+// SinkOptions configures one external audit event sink. Only events
+// matching the sink's rule (empty lists match everything) are sent.
+type SinkOptions struct {
+	Name string `json:"name" yaml:"name"`
+	// Type of the sink, one of webhook, splunk, kafka
+	Type string `json:"type" yaml:"type"`
+	// Url of the sink: the webhook endpoint, the Splunk HEC collector
+	// endpoint, or the Kafka REST proxy topic endpoint
+	Url string `json:"url" yaml:"url"`
+	// Client certificate, key and CA for sinks that require mTLS
+	CertFile           string `json:"certFile,omitempty" yaml:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty" yaml:"keyFile,omitempty"`
+	CAFile             string `json:"caFile,omitempty" yaml:"caFile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+	// Token authenticates against Splunk HEC
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+	// Rule selecting the events the sink receives
+	Verbs      []string `json:"verbs,omitempty" yaml:"verbs,omitempty"`
+	Workspaces []string `json:"workspaces,omitempty" yaml:"workspaces,omitempty"`
+	Resources  []string `json:"resources,omitempty" yaml:"resources,omitempty"`
 }
 
 func NewAuditingOptions() *Options {
@@ -0,0 +1,169 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/pkg/transport"
+)
+
+const (
+	registryPrefix = "/registry/"
+
+	dialTimeout    = 5 * time.Second
+	requestTimeout = 30 * time.Second
+	defragTimeout  = time.Minute
+
+	// scanPageSize limits how many keys one ObjectCounts page reads
+	scanPageSize = 1000
+)
+
+type etcdClient struct {
+	client    *clientv3.Client
+	endpoints []string
+}
+
+func NewEtcdClient(options *Options) (Interface, error) {
+	config := clientv3.Config{
+		Endpoints:   options.Endpoints,
+		DialTimeout: dialTimeout,
+	}
+	if options.CertFile != "" || options.CAFile != "" {
+		tlsInfo := transport.TLSInfo{
+			CertFile:      options.CertFile,
+			KeyFile:       options.KeyFile,
+			TrustedCAFile: options.CAFile,
+		}
+		tlsConfig, err := tlsInfo.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		config.TLS = tlsConfig
+	}
+
+	client, err := clientv3.New(config)
+	if err != nil {
+		return nil, err
+	}
+	return &etcdClient{client: client, endpoints: options.Endpoints}, nil
+}
+
+func (e *etcdClient) Status() ([]MemberStatus, error) {
+	result := make([]MemberStatus, 0, len(e.endpoints))
+	for _, endpoint := range e.endpoints {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		status, err := e.client.Status(ctx, endpoint)
+		cancel()
+		if err != nil {
+			result = append(result, MemberStatus{Endpoint: endpoint, Errors: []string{err.Error()}})
+			continue
+		}
+		fragmented := status.DbSize - status.DbSizeInUse
+		member := MemberStatus{
+			Endpoint:        endpoint,
+			Version:         status.Version,
+			IsLeader:        status.Header.MemberId == status.Leader,
+			DBSize:          status.DbSize,
+			DBSizeInUse:     status.DbSizeInUse,
+			FragmentedBytes: fragmented,
+			Errors:          status.Errors,
+		}
+		if status.DbSize > 0 {
+			member.FragmentationRatio = float64(fragmented) / float64(status.DbSize)
+		}
+		result = append(result, member)
+	}
+	return result, nil
+}
+
+func (e *etcdClient) ObjectCounts() ([]ResourceUsage, error) {
+	usage := map[string]*ResourceUsage{}
+	key := registryPrefix
+	end := clientv3.GetPrefixRangeEnd(registryPrefix)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		resp, err := e.client.Get(ctx, key,
+			clientv3.WithRange(end),
+			clientv3.WithLimit(scanPageSize),
+			clientv3.WithSerializable())
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range resp.Kvs {
+			resource := resourceType(string(kv.Key))
+			if resource == "" {
+				continue
+			}
+			u, ok := usage[resource]
+			if !ok {
+				u = &ResourceUsage{Resource: resource}
+				usage[resource] = u
+			}
+			u.Count++
+			u.Bytes += int64(len(kv.Key) + len(kv.Value))
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			break
+		}
+		// resume right after the last key of this page
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+
+	result := make([]ResourceUsage, 0, len(usage))
+	for _, u := range usage {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bytes == result[j].Bytes {
+			return result[i].Resource < result[j].Resource
+		}
+		return result[i].Bytes > result[j].Bytes
+	})
+	return result, nil
+}
+
+func (e *etcdClient) Defragment() error {
+	for _, endpoint := range e.endpoints {
+		ctx, cancel := context.WithTimeout(context.Background(), defragTimeout)
+		_, err := e.client.Defragment(ctx, endpoint)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resourceType derives the resource type from a registry key. Core resources
+// are stored as /registry/<resource>/..., resources of other API groups as
+// /registry/<group>/<resource>/....
+func resourceType(key string) string {
+	parts := strings.Split(strings.TrimPrefix(key, registryPrefix), "/")
+	if len(parts) == 0 || parts[0] == "" {
+		return ""
+	}
+	if strings.Contains(parts[0], ".") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+// MemberStatus is the health and storage state of one etcd member.
+type MemberStatus struct {
+	Endpoint    string `json:"endpoint"`
+	Version     string `json:"version"`
+	IsLeader    bool   `json:"isLeader"`
+	DBSize      int64  `json:"dbSize"`
+	DBSizeInUse int64  `json:"dbSizeInUse"`
+	// FragmentedBytes is space held by the DB file but no longer used,
+	// reclaimable by defragmentation
+	FragmentedBytes int64 `json:"fragmentedBytes"`
+	// FragmentationRatio is FragmentedBytes over DBSize
+	FragmentationRatio float64  `json:"fragmentationRatio"`
+	Errors             []string `json:"errors,omitempty"`
+}
+
+// ResourceUsage is the storage footprint of one resource type.
+type ResourceUsage struct {
+	Resource string `json:"resource"`
+	Count    int64  `json:"count"`
+	Bytes    int64  `json:"bytes"`
+}
+
+type Interface interface {
+	// Status reports per member DB size, fragmentation and leadership
+	Status() ([]MemberStatus, error)
+
+	// ObjectCounts scans the registry prefix and aggregates object count
+	// and key/value bytes per resource type, largest first
+	ObjectCounts() ([]ResourceUsage, error)
+
+	// Defragment defragments every member, one at a time
+	Defragment() error
+}
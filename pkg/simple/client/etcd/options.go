@@ -0,0 +1,63 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd
+
+import (
+	"github.com/spf13/pflag"
+)
+
+type Options struct {
+	Endpoints []string `json:",omitempty" yaml:"endpoints" description:"etcd endpoints"`
+	CertFile  string   `json:",omitempty" yaml:"certFile" description:"etcd client certificate file"`
+	KeyFile   string   `json:",omitempty" yaml:"keyFile" description:"etcd client key file"`
+	CAFile    string   `json:",omitempty" yaml:"caFile" description:"etcd trusted CA file"`
+}
+
+func NewEtcdOptions() *Options {
+	return &Options{
+		Endpoints: []string{},
+	}
+}
+
+func (s *Options) Validate() []error {
+	var errors []error
+
+	return errors
+}
+
+func (s *Options) ApplyTo(options *Options) {
+	if len(s.Endpoints) > 0 {
+		options.Endpoints = s.Endpoints
+		options.CertFile = s.CertFile
+		options.KeyFile = s.KeyFile
+		options.CAFile = s.CAFile
+	}
+}
+
+func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
+	fs.StringSliceVar(&s.Endpoints, "etcd-endpoints", c.Endpoints, ""+
+		"etcd endpoints, e.g. https://127.0.0.1:2379. If left empty, the etcd analytics APIs are disabled.")
+
+	fs.StringVar(&s.CertFile, "etcd-cert-file", c.CertFile, ""+
+		"Client certificate used to authenticate against etcd.")
+
+	fs.StringVar(&s.KeyFile, "etcd-key-file", c.KeyFile, ""+
+		"Client key used to authenticate against etcd.")
+
+	fs.StringVar(&s.CAFile, "etcd-ca-file", c.CAFile, ""+
+		"CA certificate used to verify the etcd server certificate.")
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harbor
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+type harborClient struct {
+	host     string
+	username string
+	password string
+	client   *http.Client
+}
+
+func NewHarborClient(options *Options) (Interface, error) {
+	if options.Host == "" {
+		return nil, fmt.Errorf("harbor host must not be empty")
+	}
+	transport := http.DefaultTransport
+	if options.InsecureSkipTLSVerify {
+		transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	return &harborClient{
+		host:     strings.TrimSuffix(options.Host, "/"),
+		username: options.Username,
+		password: options.Password,
+		client:   &http.Client{Transport: transport, Timeout: requestTimeout},
+	}, nil
+}
+
+func (h *harborClient) EnsureProject(name string) error {
+	resp, err := h.do(http.MethodHead, fmt.Sprintf("/api/v2.0/projects?project_name=%s", url.QueryEscape(name)), nil, nil)
+	if err == nil {
+		return nil
+	}
+	if resp != http.StatusNotFound {
+		return err
+	}
+	_, err = h.do(http.MethodPost, "/api/v2.0/projects", map[string]interface{}{"project_name": name}, nil)
+	return err
+}
+
+func (h *harborClient) DeleteProject(name string) error {
+	resp, err := h.do(http.MethodDelete, fmt.Sprintf("/api/v2.0/projects/%s", url.PathEscape(name)), nil, nil)
+	if resp == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func (h *harborClient) SetProjectMember(project, username string, roleID int) error {
+	memberID, currentRole, err := h.findMember(project, username)
+	if err != nil {
+		return err
+	}
+	if memberID == 0 {
+		_, err = h.do(http.MethodPost, fmt.Sprintf("/api/v2.0/projects/%s/members", url.PathEscape(project)),
+			map[string]interface{}{
+				"role_id":     roleID,
+				"member_user": map[string]interface{}{"username": username},
+			}, nil)
+		return err
+	}
+	if currentRole == roleID {
+		return nil
+	}
+	_, err = h.do(http.MethodPut, fmt.Sprintf("/api/v2.0/projects/%s/members/%d", url.PathEscape(project), memberID),
+		map[string]interface{}{"role_id": roleID}, nil)
+	return err
+}
+
+func (h *harborClient) RemoveProjectMember(project, username string) error {
+	memberID, _, err := h.findMember(project, username)
+	if err != nil || memberID == 0 {
+		return err
+	}
+	resp, err := h.do(http.MethodDelete, fmt.Sprintf("/api/v2.0/projects/%s/members/%d", url.PathEscape(project), memberID), nil, nil)
+	if resp == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func (h *harborClient) CreateRobotAccount(project, name string) (*RobotAccount, error) {
+	robot := &RobotAccount{}
+	_, err := h.do(http.MethodPost, fmt.Sprintf("/api/v2.0/projects/%s/robots", url.PathEscape(project)),
+		map[string]interface{}{
+			"name": name,
+			"access": []map[string]interface{}{
+				{"resource": fmt.Sprintf("/project/%s/repository", project), "action": "pull"},
+			},
+		}, robot)
+	if err != nil {
+		return nil, err
+	}
+	return robot, nil
+}
+
+func (h *harborClient) ListRepositories(project string) ([]Repository, error) {
+	var repositories []Repository
+	_, err := h.do(http.MethodGet, fmt.Sprintf("/api/v2.0/projects/%s/repositories?page_size=100", url.PathEscape(project)), nil, &repositories)
+	if err != nil {
+		return nil, err
+	}
+	// Harbor returns repository names prefixed with the project name
+	for i := range repositories {
+		repositories[i].Name = strings.TrimPrefix(repositories[i].Name, project+"/")
+	}
+	return repositories, nil
+}
+
+func (h *harborClient) ListArtifacts(project, repository string) ([]Artifact, error) {
+	var raw []struct {
+		Digest       string                     `json:"digest"`
+		Size         int64                      `json:"size"`
+		PushTime     string                     `json:"push_time"`
+		Tags         []Tag                      `json:"tags"`
+		ScanOverview map[string]json.RawMessage `json:"scan_overview"`
+	}
+	// repository names containing slashes must be double escaped
+	path := fmt.Sprintf("/api/v2.0/projects/%s/repositories/%s/artifacts?page_size=100&with_tag=true&with_scan_overview=true",
+		url.PathEscape(project), url.PathEscape(url.PathEscape(repository)))
+	if _, err := h.do(http.MethodGet, path, nil, &raw); err != nil {
+		return nil, err
+	}
+	artifacts := make([]Artifact, 0, len(raw))
+	for _, item := range raw {
+		artifacts = append(artifacts, Artifact{
+			Digest:   item.Digest,
+			Size:     item.Size,
+			PushTime: item.PushTime,
+			Tags:     item.Tags,
+			Scan:     parseScanOverview(item.ScanOverview),
+		})
+	}
+	return artifacts, nil
+}
+
+// parseScanOverview flattens Harbor's mime-type keyed scan overview into a
+// single summary, there is at most one report in practice
+func parseScanOverview(overview map[string]json.RawMessage) *ScanSummary {
+	for _, raw := range overview {
+		var report struct {
+			Status   string `json:"scan_status"`
+			Severity string `json:"severity"`
+			Summary  struct {
+				Total   int            `json:"total"`
+				Fixable int            `json:"fixable"`
+				Summary map[string]int `json:"summary"`
+			} `json:"summary"`
+		}
+		if err := json.Unmarshal(raw, &report); err != nil {
+			continue
+		}
+		return &ScanSummary{
+			Status:   report.Status,
+			Severity: report.Severity,
+			Total:    report.Summary.Total,
+			Fixable:  report.Summary.Fixable,
+			Summary:  report.Summary.Summary,
+		}
+	}
+	return nil
+}
+
+func (h *harborClient) findMember(project, username string) (memberID, roleID int, err error) {
+	var members []struct {
+		ID         int    `json:"id"`
+		RoleID     int    `json:"role_id"`
+		EntityName string `json:"entity_name"`
+	}
+	_, err = h.do(http.MethodGet, fmt.Sprintf("/api/v2.0/projects/%s/members?entityname=%s",
+		url.PathEscape(project), url.QueryEscape(username)), nil, &members)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, member := range members {
+		if member.EntityName == username {
+			return member.ID, member.RoleID, nil
+		}
+	}
+	return 0, 0, nil
+}
+
+// do performs an authenticated request against the Harbor API, decoding the
+// response into result when it is non-nil, and returns the status code
+func (h *harborClient) do(method, path string, body interface{}, result interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, h.host+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.SetBasicAuth(h.username, h.password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.StatusCode, fmt.Errorf("harbor: %s %s returned %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, nil
+}
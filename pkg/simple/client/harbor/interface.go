@@ -0,0 +1,93 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harbor
+
+// Harbor built-in project member roles, see
+// https://goharbor.io/docs/latest/administration/managing-users/user-permissions-by-role/
+const (
+	RoleProjectAdmin = 1
+	RoleDeveloper    = 2
+	RoleGuest        = 3
+	RoleMaintainer   = 4
+)
+
+// Repository is an image repository inside a Harbor project.
+type Repository struct {
+	Name          string `json:"name"`
+	ArtifactCount int64  `json:"artifact_count"`
+	PullCount     int64  `json:"pull_count"`
+	UpdateTime    string `json:"update_time,omitempty"`
+}
+
+// Tag is a tag attached to an artifact.
+type Tag struct {
+	Name     string `json:"name"`
+	PushTime string `json:"push_time,omitempty"`
+}
+
+// ScanSummary condenses the vulnerability scan result of an artifact.
+type ScanSummary struct {
+	Status   string         `json:"scan_status,omitempty"`
+	Severity string         `json:"severity,omitempty"`
+	Total    int            `json:"total"`
+	Fixable  int            `json:"fixable"`
+	Summary  map[string]int `json:"summary,omitempty"`
+}
+
+// Artifact is an image pushed to a repository together with its tags and
+// scan result.
+type Artifact struct {
+	Digest   string       `json:"digest"`
+	Size     int64        `json:"size,omitempty"`
+	PushTime string       `json:"push_time,omitempty"`
+	Tags     []Tag        `json:"tags,omitempty"`
+	Scan     *ScanSummary `json:"scanSummary,omitempty"`
+}
+
+// RobotAccount holds the credentials of a newly created robot account, the
+// secret is only returned by Harbor at creation time.
+type RobotAccount struct {
+	Name   string `json:"name"`
+	Secret string `json:"secret"`
+}
+
+type Interface interface {
+	// EnsureProject creates the project if it does not exist yet
+	EnsureProject(name string) error
+
+	// DeleteProject removes the project, missing projects are not an error
+	DeleteProject(name string) error
+
+	// SetProjectMember adds the user to the project with the given role, or
+	// updates the role of an existing member
+	SetProjectMember(project, username string, roleID int) error
+
+	// RemoveProjectMember removes the user from the project, missing
+	// members are not an error
+	RemoveProjectMember(project, username string) error
+
+	// CreateRobotAccount creates a pull-only robot account scoped to the
+	// project and returns its credentials
+	CreateRobotAccount(project, name string) (*RobotAccount, error)
+
+	// ListRepositories lists the image repositories of the project
+	ListRepositories(project string) ([]Repository, error)
+
+	// ListArtifacts lists the artifacts of a repository together with
+	// their tags and vulnerability scan summaries
+	ListArtifacts(project, repository string) ([]Artifact, error)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2021 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harbor
+
+import (
+	"github.com/spf13/pflag"
+)
+
+type Options struct {
+	Host                  string `json:",omitempty" yaml:"host" description:"Harbor service host address"`
+	Username              string `json:",omitempty" yaml:"username" description:"Harbor admin username"`
+	Password              string `json:",omitempty" yaml:"password" description:"Harbor admin password"`
+	InsecureSkipTLSVerify bool   `json:",omitempty" yaml:"insecureSkipTLSVerify" description:"skip verification of the Harbor TLS certificate"`
+}
+
+func NewHarborOptions() *Options {
+	return &Options{
+		Host:     "",
+		Username: "",
+		Password: "",
+	}
+}
+
+func (s *Options) Validate() []error {
+	var errors []error
+
+	return errors
+}
+
+func (s *Options) ApplyTo(options *Options) {
+	if s.Host != "" {
+		options.Host = s.Host
+		options.Username = s.Username
+		options.Password = s.Password
+		options.InsecureSkipTLSVerify = s.InsecureSkipTLSVerify
+	}
+}
+
+func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
+	fs.StringVar(&s.Host, "harbor-host", c.Host, ""+
+		"Harbor service address, if left empty, following harbor options will be ignored.")
+
+	fs.StringVar(&s.Username, "harbor-username", c.Username, ""+
+		"Harbor admin username used to provision projects and robot accounts.")
+
+	fs.StringVar(&s.Password, "harbor-password", c.Password, ""+
+		"Harbor admin password.")
+
+	fs.BoolVar(&s.InsecureSkipTLSVerify, "harbor-insecure-skip-tls-verify", c.InsecureSkipTLSVerify, ""+
+		"Skip verification of the Harbor TLS certificate.")
+}
@@ -25,13 +25,18 @@ type Options struct {
 	Host        string `json:"host" yaml:"host"`
 	IndexPrefix string `json:"indexPrefix,omitempty" yaml:"indexPrefix"`
 	Version     string `json:"version" yaml:"version"`
+
+	// DashboardsHost is the Kibana or OpenSearch Dashboards endpoint the
+	// console deep-links into, empty disables the deep links
+	DashboardsHost string `json:"dashboardsHost,omitempty" yaml:"dashboardsHost"`
 }
 
 func NewLoggingOptions() *Options {
 	return &Options{
-		Host:        "",
-		IndexPrefix: "fluentbit",
-		Version:     "",
+		Host:           "",
+		IndexPrefix:    "fluentbit",
+		Version:        "",
+		DashboardsHost: "",
 	}
 }
 
@@ -58,4 +63,8 @@ func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 	fs.StringVar(&s.Version, "logging-elasticsearch-version", c.Version, ""+
 		"Elasticsearch major version, e.g. 5/6/7, if left blank, will detect automatically."+
 		"Currently, minimum supported version is 5.x")
+
+	fs.StringVar(&s.DashboardsHost, "logging-dashboards-host", c.DashboardsHost, ""+
+		"Kibana or OpenSearch Dashboards endpoint the console deep-links into. "+
+		"If left blank, log dashboard deep links are disabled.")
 }
@@ -28,6 +28,7 @@ import (
 	"github.com/prometheus/common/model"
 	"k8s.io/klog"
 	"kubesphere.io/kubesphere/pkg/simple/client/monitoring"
+	"kubesphere.io/kubesphere/pkg/utils/tracing"
 )
 
 const MeteringDefaultTimeout = 20 * time.Second
@@ -39,7 +40,8 @@ type prometheus struct {
 
 func NewPrometheus(options *Options) (monitoring.Interface, error) {
 	cfg := api.Config{
-		Address: options.Endpoint,
+		Address:      options.Endpoint,
+		RoundTripper: tracing.InstrumentRoundTripper("prometheus", api.DefaultRoundTripper),
 	}
 
 	client, err := api.NewClient(cfg)
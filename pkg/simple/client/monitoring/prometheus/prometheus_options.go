@@ -22,11 +22,14 @@ import (
 
 type Options struct {
 	Endpoint string `json:"endpoint,omitempty" yaml:"endpoint"`
+
+	RemoteWriteEndpoint string `json:"remoteWriteEndpoint,omitempty" yaml:"remoteWriteEndpoint"`
 }
 
 func NewPrometheusOptions() *Options {
 	return &Options{
-		Endpoint: "",
+		Endpoint:            "",
+		RemoteWriteEndpoint: "",
 	}
 }
 
@@ -39,10 +42,18 @@ func (s *Options) ApplyTo(options *Options) {
 	if s.Endpoint != "" {
 		options.Endpoint = s.Endpoint
 	}
+	if s.RemoteWriteEndpoint != "" {
+		options.RemoteWriteEndpoint = s.RemoteWriteEndpoint
+	}
 }
 
 func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 	fs.StringVar(&s.Endpoint, "prometheus-endpoint", c.Endpoint, ""+
 		"Prometheus service endpoint which stores KubeSphere monitoring data, if left "+
 		"blank, will use builtin metrics-server as data source.")
+
+	fs.StringVar(&s.RemoteWriteEndpoint, "prometheus-remote-write-endpoint", c.RemoteWriteEndpoint, ""+
+		"Prometheus remote-write endpoint which received external metrics are forwarded to, "+
+		"e.g. http://prometheus-k8s.kubesphere-monitoring-system.svc:9090/api/v1/write. If left "+
+		"blank, the remote-write receiver is disabled.")
 }
@@ -188,6 +188,7 @@ var promQLTemplates = map[string]string{
 	"pod_memory_usage_wo_cache": `sum by (namespace, pod) (container_memory_working_set_bytes{job="kubelet", pod!="", image!=""}) * on (namespace, pod) group_left(owner_kind, owner_name) kube_pod_owner{$1} * on (namespace, pod) group_left(node) kube_pod_info{$2}`,
 	"pod_net_bytes_transmitted": `sum by (namespace, pod) (irate(container_network_transmit_bytes_total{pod!="", interface!~"^(cali.+|tunl.+|dummy.+|kube.+|flannel.+|cni.+|docker.+|veth.+|lo.*)", job="kubelet"}[5m])) * on (namespace, pod) group_left(owner_kind, owner_name) kube_pod_owner{$1} * on (namespace, pod) group_left(node) kube_pod_info{$2}`,
 	"pod_net_bytes_received":    `sum by (namespace, pod) (irate(container_network_receive_bytes_total{pod!="", interface!~"^(cali.+|tunl.+|dummy.+|kube.+|flannel.+|cni.+|docker.+|veth.+|lo.*)", job="kubelet"}[5m])) * on (namespace, pod) group_left(owner_kind, owner_name) kube_pod_owner{$1} * on (namespace, pod) group_left(node) kube_pod_info{$2}`,
+	"pod_cpu_throttled_ratio":   `(sum by (namespace, pod) (increase(container_cpu_cfs_throttled_periods_total{job="kubelet", pod!="", image!=""}[5m])) / sum by (namespace, pod) (increase(container_cpu_cfs_periods_total{job="kubelet", pod!="", image!=""}[5m])) * on (namespace, pod) group_left(owner_kind, owner_name) kube_pod_owner{$1}) * on (namespace, pod) group_left(node) kube_pod_info{$2}`,
 
 	// container
 	"container_cpu_usage":             `round(sum by (namespace, pod, container) (irate(container_cpu_usage_seconds_total{job="kubelet", container!="POD", container!="", image!="", $1}[5m])), 0.001)`,
@@ -204,6 +205,14 @@ var promQLTemplates = map[string]string{
 	"pvc_bytes_total":        `max by (namespace, persistentvolumeclaim) (kubelet_volume_stats_capacity_bytes) * on (namespace, persistentvolumeclaim) group_left (storageclass) kube_persistentvolumeclaim_info{$1}`,
 	"pvc_bytes_utilisation":  `max by (namespace, persistentvolumeclaim) (kubelet_volume_stats_used_bytes / kubelet_volume_stats_capacity_bytes) * on (namespace, persistentvolumeclaim) group_left (storageclass) kube_persistentvolumeclaim_info{$1}`,
 
+	// pvc performance, exposed by csi drivers
+	"pvc_read_iops":        `max by (namespace, persistentvolumeclaim) (irate(csi_volume_read_ops_total[5m])) * on (namespace, persistentvolumeclaim) group_left (storageclass) kube_persistentvolumeclaim_info{$1}`,
+	"pvc_write_iops":       `max by (namespace, persistentvolumeclaim) (irate(csi_volume_write_ops_total[5m])) * on (namespace, persistentvolumeclaim) group_left (storageclass) kube_persistentvolumeclaim_info{$1}`,
+	"pvc_read_throughput":  `max by (namespace, persistentvolumeclaim) (irate(csi_volume_read_bytes_total[5m])) * on (namespace, persistentvolumeclaim) group_left (storageclass) kube_persistentvolumeclaim_info{$1}`,
+	"pvc_write_throughput": `max by (namespace, persistentvolumeclaim) (irate(csi_volume_write_bytes_total[5m])) * on (namespace, persistentvolumeclaim) group_left (storageclass) kube_persistentvolumeclaim_info{$1}`,
+	"pvc_read_latency":     `max by (namespace, persistentvolumeclaim) (irate(csi_volume_read_seconds_total[5m]) / irate(csi_volume_read_ops_total[5m])) * on (namespace, persistentvolumeclaim) group_left (storageclass) kube_persistentvolumeclaim_info{$1}`,
+	"pvc_write_latency":    `max by (namespace, persistentvolumeclaim) (irate(csi_volume_write_seconds_total[5m]) / irate(csi_volume_write_ops_total[5m])) * on (namespace, persistentvolumeclaim) group_left (storageclass) kube_persistentvolumeclaim_info{$1}`,
+
 	// component
 	"etcd_server_list":                           `label_replace(up{job="etcd"}, "node_ip", "$1", "instance", "(.*):.*")`,
 	"etcd_server_total":                          `count(up{job="etcd"})`,
@@ -239,13 +248,40 @@ var promQLTemplates = map[string]string{
 	"scheduler_e2e_scheduling_latency_quantile": `scheduler:scheduler_e2e_scheduling_duration:histogram_quantile`,
 }
 
+// windowsPromQLTemplates are the windows_exporter equivalents of the node
+// metrics above. Windows nodes run windows_exporter instead of
+// node-exporter, so without these the node endpoints return empty rows for
+// them in mixed-OS clusters. The scrape config is expected to relabel the
+// instance label to the node name, as the kube-prometheus Windows addon
+// does.
+var windowsPromQLTemplates = map[string]string{
+	"node_cpu_utilisation":       `1 - avg by (instance) (irate(windows_cpu_time_total{job="windows-exporter",mode="idle"}[5m]))`,
+	"node_cpu_usage":             `round((1 - avg by (instance) (irate(windows_cpu_time_total{job="windows-exporter",mode="idle"}[5m]))) * count by (instance) (sum by (instance, core) (windows_cpu_time_total{job="windows-exporter"})), 0.001)`,
+	"node_cpu_total":             `count by (instance) (sum by (instance, core) (windows_cpu_time_total{job="windows-exporter"}))`,
+	"node_memory_utilisation":    `1 - windows_os_physical_memory_free_bytes{job="windows-exporter"} / windows_cs_physical_memory_bytes{job="windows-exporter"}`,
+	"node_memory_available":      `windows_os_physical_memory_free_bytes{job="windows-exporter"}`,
+	"node_memory_total":          `windows_cs_physical_memory_bytes{job="windows-exporter"}`,
+	"node_memory_usage_wo_cache": `windows_cs_physical_memory_bytes{job="windows-exporter"} - windows_os_physical_memory_free_bytes{job="windows-exporter"}`,
+	"node_net_utilisation":       `sum by (instance) (irate(windows_net_bytes_total{job="windows-exporter"}[5m]))`,
+	"node_net_bytes_transmitted": `sum by (instance) (irate(windows_net_bytes_sent_total{job="windows-exporter"}[5m]))`,
+	"node_net_bytes_received":    `sum by (instance) (irate(windows_net_bytes_received_total{job="windows-exporter"}[5m]))`,
+	"node_disk_read_iops":        `sum by (instance) (irate(windows_logical_disk_reads_total{job="windows-exporter"}[5m]))`,
+	"node_disk_write_iops":       `sum by (instance) (irate(windows_logical_disk_writes_total{job="windows-exporter"}[5m]))`,
+	"node_disk_read_throughput":  `sum by (instance) (irate(windows_logical_disk_read_bytes_total{job="windows-exporter"}[5m]))`,
+	"node_disk_write_throughput": `sum by (instance) (irate(windows_logical_disk_write_bytes_total{job="windows-exporter"}[5m]))`,
+	"node_disk_size_capacity":    `sum by (instance) (windows_logical_disk_size_bytes{job="windows-exporter"})`,
+	"node_disk_size_available":   `sum by (instance) (windows_logical_disk_free_bytes{job="windows-exporter"})`,
+	"node_disk_size_usage":       `sum by (instance) (windows_logical_disk_size_bytes{job="windows-exporter"} - windows_logical_disk_free_bytes{job="windows-exporter"})`,
+	"node_disk_size_utilisation": `1 - sum by (instance) (windows_logical_disk_free_bytes{job="windows-exporter"}) / sum by (instance) (windows_logical_disk_size_bytes{job="windows-exporter"})`,
+}
+
 func makeExpr(metric string, opts monitoring.QueryOptions) string {
 	tmpl := promQLTemplates[metric]
 	switch opts.Level {
 	case monitoring.LevelCluster:
 		return tmpl
 	case monitoring.LevelNode:
-		return makeNodeMetricExpr(tmpl, opts)
+		return makeNodeMetricExpr(metric, tmpl, opts)
 	case monitoring.LevelWorkspace:
 		return makeWorkspaceMetricExpr(tmpl, opts)
 	case monitoring.LevelNamespace:
@@ -265,14 +301,22 @@ func makeExpr(metric string, opts monitoring.QueryOptions) string {
 	}
 }
 
-func makeNodeMetricExpr(tmpl string, o monitoring.QueryOptions) string {
+func makeNodeMetricExpr(metric, tmpl string, o monitoring.QueryOptions) string {
 	var nodeSelector string
 	if o.NodeName != "" {
 		nodeSelector = fmt.Sprintf(`node="%s"`, o.NodeName)
 	} else {
 		nodeSelector = fmt.Sprintf(`node=~"%s"`, o.ResourceFilter)
 	}
-	return strings.Replace(tmpl, "$1", nodeSelector, -1)
+	expr := strings.Replace(tmpl, "$1", nodeSelector, -1)
+
+	// windows_exporter series carry the node name in the instance label;
+	// lift it into the node label and filter on the node selector through
+	// kube_node_info, then merge them with the node-exporter series
+	if windowsTmpl, ok := windowsPromQLTemplates[metric]; ok {
+		expr = fmt.Sprintf(`%s or (label_replace(%s, "node", "${1}", "instance", "(.*)") and on(node) kube_node_info{%s})`, expr, windowsTmpl, nodeSelector)
+	}
+	return expr
 }
 
 func makeWorkspaceMetricExpr(tmpl string, o monitoring.QueryOptions) string {
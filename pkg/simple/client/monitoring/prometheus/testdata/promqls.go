@@ -18,8 +18,8 @@ package testdata
 
 var PromQLs = map[string]string{
 	"cluster_cpu_utilisation":               `:node_cpu_utilisation:avg1m`,
-	"node_cpu_utilisation":                  `node:node_cpu_utilisation:avg1m{node="i-2dazc1d6"}`,
-	"node_cpu_total":                        `node:node_num_cpu:sum{node=~"i-2dazc1d6|i-ezjb7gsk"}`,
+	"node_cpu_utilisation":                  `node:node_cpu_utilisation:avg1m{node="i-2dazc1d6"} or (label_replace(1 - avg by (instance) (irate(windows_cpu_time_total{job="windows-exporter",mode="idle"}[5m])), "node", "${1}", "instance", "(.*)") and on(node) kube_node_info{node="i-2dazc1d6"})`,
+	"node_cpu_total":                        `node:node_num_cpu:sum{node=~"i-2dazc1d6|i-ezjb7gsk"} or (label_replace(count by (instance) (sum by (instance, core) (windows_cpu_time_total{job="windows-exporter"})), "node", "${1}", "instance", "(.*)") and on(node) kube_node_info{node=~"i-2dazc1d6|i-ezjb7gsk"})`,
 	"node_pod_quota":                        `max(kube_node_status_capacity{resource="pods",node=~"i-2dazc1d6|i-ezjb7gsk"}) by (node) unless on (node) (kube_node_status_condition{condition="Ready",status=~"unknown|false"} > 0)`,
 	"workspace_cpu_usage":                   `round(sum by (workspace) (namespace:container_cpu_usage_seconds_total:sum_rate{namespace!="", workspace="system-workspace"}), 0.001)`,
 	"workspace_memory_usage":                `sum by (workspace) (namespace:container_memory_usage_bytes:sum{namespace!="", workspace=~"system-workspace|demo", workspace!=""})`,
@@ -45,6 +45,15 @@ type Options struct {
 
 	// ClusterControllerResyncSecond is the resync period used by cluster controller.
 	ClusterControllerResyncSecond time.Duration `json:"clusterControllerResyncSecond,omitempty" yaml:"clusterControllerResyncSecond"`
+
+	// APIServerShards are the addresses of all ks-apiserver replicas member
+	// clusters are sharded across on a consistent hash ring. Sharding is
+	// disabled when fewer than two shards are configured.
+	APIServerShards []string `json:"apiserverShards,omitempty" yaml:"apiserverShards"`
+
+	// APIServerShard is the address of this replica, must be one of
+	// APIServerShards when sharding is enabled.
+	APIServerShard string `json:"apiserverShard,omitempty" yaml:"apiserverShard"`
 }
 
 // NewOptions() returns a default nil options
@@ -80,4 +89,12 @@ func (o *Options) AddFlags(fs *pflag.FlagSet, s *Options) {
 
 	fs.DurationVar(&o.ClusterControllerResyncSecond, "cluster-controller-resync-second", s.ClusterControllerResyncSecond,
 		"Cluster controller resync second to sync cluster resource. e.g. 2m 5m 10m ... default set to 2m")
+
+	fs.StringSliceVar(&o.APIServerShards, "apiserver-shards", s.APIServerShards, ""+
+		"Addresses of all ks-apiserver replicas member clusters are sharded across on a consistent hash ring, "+
+		"e.g. http://ks-apiserver-0.kubesphere-system.svc:9090,http://ks-apiserver-1.kubesphere-system.svc:9090. "+
+		"Sharding is disabled when fewer than two shards are configured.")
+
+	fs.StringVar(&o.APIServerShard, "apiserver-shard", s.APIServerShard, ""+
+		"Address of this ks-apiserver replica, must be one of apiserver-shards when sharding is enabled.")
 }
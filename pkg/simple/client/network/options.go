@@ -31,6 +31,7 @@ type Options struct {
 	NSNPOptions         NSNPOptions `json:"nsnpOptions,omitempty" yaml:"nsnpOptions,omitempty"`
 	WeaveScopeHost      string      `json:"weaveScopeHost,omitempty" yaml:"weaveScopeHost,omitempty"`
 	IPPoolType          string      `json:"ippoolType,omitempty" yaml:"ippoolType,omitempty"`
+	FlowSourceHost      string      `json:"flowSourceHost,omitempty" yaml:"flowSourceHost,omitempty"`
 }
 
 // NewNetworkOptions returns a `zero` instance
@@ -42,12 +43,14 @@ func NewNetworkOptions() *Options {
 			AllowedIngressNamespaces: []string{},
 		},
 		WeaveScopeHost: "",
+		FlowSourceHost: "",
 	}
 }
 
 func (s *Options) IsEmpty() bool {
 	return s.EnableNetworkPolicy == false &&
 		s.WeaveScopeHost == "" &&
+		s.FlowSourceHost == "" &&
 		s.IPPoolType == networkv1alpha1.IPPoolTypeNone
 }
 
@@ -61,6 +64,7 @@ func (s *Options) ApplyTo(options *Options) {
 	options.IPPoolType = s.IPPoolType
 	options.NSNPOptions = s.NSNPOptions
 	options.WeaveScopeHost = s.WeaveScopeHost
+	options.FlowSourceHost = s.FlowSourceHost
 }
 
 func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
@@ -70,4 +74,6 @@ func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 		"This field instructs KubeSphere to enable ippool or not.")
 	fs.StringVar(&s.WeaveScopeHost, "weave-scope-host", c.WeaveScopeHost,
 		"Weave Scope service endpoint which build a topology API of the applications and the containers running on the hosts")
+	fs.StringVar(&s.FlowSourceHost, "flow-source-host", c.FlowSourceHost,
+		"Network flow source endpoint, e.g. a Cilium Hubble JSON gateway, which serves the observed network flows the flows API aggregates")
 }
@@ -85,6 +85,20 @@ func (c *clusterClients) GetInnerCluster(name string) *innerCluster {
 var c *clusterClients
 var lock sync.Mutex
 
+// ownershipFilter restricts which clusters this replica builds transports
+// for, nil means all of them
+var ownershipFilter func(clusterName string) bool
+
+// SetClusterOwnershipFilter limits the clusters this process keeps inner
+// cluster state (parsed endpoints and transports) for. Used by sharded
+// ks-apiserver replicas so each one only warms its share of the member
+// clusters. Must be called before NewClusterClient.
+func SetClusterOwnershipFilter(owns func(clusterName string) bool) {
+	lock.Lock()
+	defer lock.Unlock()
+	ownershipFilter = owns
+}
+
 func NewClusterClient(clusterInformer clusterinformer.ClusterInformer) ClusterClients {
 
 	if c == nil {
@@ -178,11 +192,18 @@ func (c *clusterClients) addCluster(obj interface{}) {
 		return
 	}
 
-	innerCluster := newInnerCluster(cluster)
+	// clusters owned by another shard are tracked but no transport is built
+	// for them, requests get routed to the owning replica instead
+	var inner *innerCluster
+	if ownershipFilter == nil || ownershipFilter(cluster.Name) {
+		inner = newInnerCluster(cluster)
+	}
 	c.Lock()
 	c.clusterMap[cluster.Name] = cluster
 	c.clusterKubeconfig[cluster.Name] = string(cluster.Spec.Connection.KubeConfig)
-	c.innerClusters[cluster.Name] = innerCluster
+	if inner != nil {
+		c.innerClusters[cluster.Name] = inner
+	}
 	c.Unlock()
 }
 
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate reports whether spec is a well-formed five-field cron expression.
+func Validate(spec string) error {
+	_, err := parseSpec(spec)
+	return err
+}
+
+// Matches reports whether the cron spec fires at the given time, with minute
+// granularity. A field supports "*", steps "*/n", numbers, lists "a,b,c",
+// ranges "a-b" and stepped ranges "a-b/n". Following cron convention, day of
+// month and day of week are OR-ed when both are restricted.
+func Matches(spec string, t time.Time) (bool, error) {
+	fields, err := parseSpec(spec)
+	if err != nil {
+		return false, err
+	}
+
+	minute, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	if !minute.matches(t.Minute()) || !hour.matches(t.Hour()) || !month.matches(int(t.Month())) {
+		return false, nil
+	}
+
+	domMatch := dom.matches(t.Day())
+	dowMatch := dow.matches(int(t.Weekday())) || (t.Weekday() == time.Sunday && dow.matches(7))
+	if dom.restricted && dow.restricted {
+		return domMatch || dowMatch, nil
+	}
+	return domMatch && dowMatch, nil
+}
+
+type field struct {
+	values     map[int]bool
+	restricted bool
+}
+
+func (f field) matches(v int) bool {
+	return f.values[v]
+}
+
+var fieldBounds = [5]struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+func parseSpec(spec string) ([5]field, error) {
+	var fields [5]field
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return fields, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", spec, len(parts))
+	}
+
+	for i, part := range parts {
+		bounds := fieldBounds[i]
+		f, err := parseField(part, bounds.min, bounds.max)
+		if err != nil {
+			return fields, fmt.Errorf("invalid %s field in cron expression %q: %v", bounds.name, spec, err)
+		}
+		fields[i] = f
+	}
+	return fields, nil
+}
+
+func parseField(expr string, min, max int) (field, error) {
+	f := field{values: map[int]bool{}, restricted: expr != "*"}
+
+	for _, part := range strings.Split(expr, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			s, err := strconv.Atoi(part[idx+1:])
+			if err != nil || s <= 0 {
+				return f, fmt.Errorf("invalid step %q", part)
+			}
+			step = s
+			part = part[:idx]
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			var err error
+			if idx := strings.Index(part, "-"); idx >= 0 {
+				lo, err = strconv.Atoi(part[:idx])
+				if err == nil {
+					hi, err = strconv.Atoi(part[idx+1:])
+				}
+			} else {
+				lo, err = strconv.Atoi(part)
+				if err == nil && step == 1 {
+					hi = lo
+				}
+				// "n/step" means n to max with the given step
+			}
+			if err != nil {
+				return f, fmt.Errorf("invalid value %q", part)
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return f, fmt.Errorf("value %q out of range [%d, %d]", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			f.values[v] = true
+		}
+	}
+	return f, nil
+}
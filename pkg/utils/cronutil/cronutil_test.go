@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cronutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatches(t *testing.T) {
+	// 2021-03-02 02:30, a Tuesday
+	at := time.Date(2021, 3, 2, 2, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec    string
+		want    bool
+		wantErr bool
+	}{
+		{spec: "* * * * *", want: true},
+		{spec: "30 2 * * *", want: true},
+		{spec: "30 2 2 3 *", want: true},
+		{spec: "30 2 * * 2", want: true},
+		{spec: "*/10 * * * *", want: true},
+		{spec: "0-45/15 2 * * *", want: true},
+		{spec: "0,15,30 * * * *", want: true},
+		{spec: "31 2 * * *", want: false},
+		{spec: "30 3 * * *", want: false},
+		{spec: "30 2 * * 0", want: false},
+		// dom and dow are OR-ed when both are restricted
+		{spec: "30 2 15 * 2", want: true},
+		{spec: "30 2 15 * 0", want: false},
+		{spec: "bad spec", wantErr: true},
+		{spec: "61 * * * *", wantErr: true},
+		{spec: "* * * * * *", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := Matches(test.spec, at)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("Matches(%q) expected error, got none", test.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Matches(%q) unexpected error: %v", test.spec, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Matches(%q) = %v, want %v", test.spec, got, test.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("0 2 * * *"); err != nil {
+		t.Errorf("Validate returned unexpected error: %v", err)
+	}
+	if err := Validate("0 25 * * *"); err == nil {
+		t.Error("Validate expected error for out-of-range hour, got none")
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hashring provides a consistent hash ring, used to shard member
+// clusters across ks-apiserver replicas: adding or removing a replica only
+// moves the clusters adjacent to it on the ring.
+package hashring
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"sync"
+)
+
+const defaultVirtualNodes = 100
+
+// Ring maps keys to nodes through consistent hashing with virtual nodes.
+type Ring struct {
+	mu           sync.RWMutex
+	virtualNodes int
+	hashes       []uint32
+	nodeByHash   map[uint32]string
+	nodes        map[string]bool
+}
+
+func New(virtualNodes int) *Ring {
+	if virtualNodes <= 0 {
+		virtualNodes = defaultVirtualNodes
+	}
+	return &Ring{
+		virtualNodes: virtualNodes,
+		nodeByHash:   make(map[uint32]string),
+		nodes:        make(map[string]bool),
+	}
+}
+
+func (r *Ring) Add(nodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, node := range nodes {
+		if r.nodes[node] {
+			continue
+		}
+		r.nodes[node] = true
+		for i := 0; i < r.virtualNodes; i++ {
+			hash := crc32.ChecksumIEEE([]byte(fmt.Sprintf("%s#%d", node, i)))
+			r.nodeByHash[hash] = node
+			r.hashes = append(r.hashes, hash)
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+}
+
+func (r *Ring) Remove(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.nodes[node] {
+		return
+	}
+	delete(r.nodes, node)
+
+	hashes := r.hashes[:0]
+	for _, hash := range r.hashes {
+		if r.nodeByHash[hash] == node {
+			delete(r.nodeByHash, hash)
+			continue
+		}
+		hashes = append(hashes, hash)
+	}
+	r.hashes = hashes
+}
+
+// Get returns the node owning the key, empty when the ring has no nodes.
+func (r *Ring) Get(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	hash := crc32.ChecksumIEEE([]byte(key))
+	index := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= hash })
+	if index == len(r.hashes) {
+		index = 0
+	}
+	return r.nodeByHash[r.hashes[index]]
+}
+
+func (r *Ring) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	nodes := make([]string, 0, len(r.nodes))
+	for node := range r.nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
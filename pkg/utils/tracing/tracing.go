@@ -0,0 +1,197 @@
+/*
+Copyright 2021 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing keeps track of in-flight API requests and the backend
+// calls (kubernetes API, Prometheus, Elasticsearch) made while serving them,
+// so slow console requests can be traced to the backend call that caused
+// them. Backend calls are correlated to a request through its context;
+// clients that do not propagate the request context still get their slow
+// calls logged, just without the owning request.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog"
+)
+
+// backend calls slower than this are logged even when they cannot be
+// correlated to a request
+const slowBackendThreshold = 2 * time.Second
+
+// Span is one backend call made while serving a request.
+type Span struct {
+	Backend  string        `json:"backend" description:"backend the call went to, e.g. kubernetes, prometheus"`
+	Detail   string        `json:"detail" description:"method and url of the call"`
+	Duration time.Duration `json:"duration" description:"how long the call took"`
+}
+
+// Trace collects the backend calls of one in-flight request.
+type Trace struct {
+	ID        uint64    `json:"id" description:"identifier of the request, unique per apiserver process"`
+	Verb      string    `json:"verb" description:"HTTP verb of the request"`
+	Path      string    `json:"path" description:"request path"`
+	User      string    `json:"user" description:"authenticated user the request was made by"`
+	StartTime time.Time `json:"startTime" description:"when the request arrived"`
+
+	mu    sync.Mutex
+	spans []Span
+}
+
+// Record appends one backend call to the trace, safe on a nil trace so
+// callers do not need to check whether tracing is attached.
+func (t *Trace) Record(backend, detail string, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = append(t.spans, Span{Backend: backend, Detail: detail, Duration: time.Since(start)})
+}
+
+func (t *Trace) Spans() []Span {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Span{}, t.spans...)
+}
+
+// SpanSummary renders the recorded backend calls for slow request logs.
+func (t *Trace) SpanSummary() string {
+	spans := t.Spans()
+	if len(spans) == 0 {
+		return "no backend calls recorded"
+	}
+	parts := make([]string, 0, len(spans))
+	for _, span := range spans {
+		parts = append(parts, fmt.Sprintf("%s %s took %v", span.Backend, span.Detail, span.Duration))
+	}
+	return strings.Join(parts, "; ")
+}
+
+type contextKey struct{}
+
+var (
+	nextID   uint64
+	mu       sync.Mutex
+	inFlight = map[uint64]*Trace{}
+)
+
+// StartRequest registers an in-flight request and returns a context the
+// trace can be recovered from; FinishRequest must be called when the
+// request completes.
+func StartRequest(ctx context.Context, verb, path, user string) (*Trace, context.Context) {
+	trace := &Trace{
+		ID:        atomic.AddUint64(&nextID, 1),
+		Verb:      verb,
+		Path:      path,
+		User:      user,
+		StartTime: time.Now(),
+	}
+	mu.Lock()
+	inFlight[trace.ID] = trace
+	mu.Unlock()
+	return trace, context.WithValue(ctx, contextKey{}, trace)
+}
+
+func FinishRequest(trace *Trace) {
+	mu.Lock()
+	delete(inFlight, trace.ID)
+	mu.Unlock()
+}
+
+// FromContext returns the trace of the request the context belongs to, nil
+// when the call is not serving a traced request.
+func FromContext(ctx context.Context) *Trace {
+	trace, _ := ctx.Value(contextKey{}).(*Trace)
+	return trace
+}
+
+// InFlightRequest is the externally visible state of one request being
+// served right now.
+type InFlightRequest struct {
+	ID        uint64    `json:"id" description:"identifier of the request"`
+	Verb      string    `json:"verb" description:"HTTP verb of the request"`
+	Path      string    `json:"path" description:"request path"`
+	User      string    `json:"user" description:"authenticated user the request was made by"`
+	StartTime time.Time `json:"startTime" description:"when the request arrived"`
+	Elapsed   string    `json:"elapsed" description:"how long the request has been running"`
+	Spans     []Span    `json:"spans" description:"backend calls made so far"`
+}
+
+// InFlightRequests returns the requests currently being served, longest
+// running first.
+func InFlightRequests() []InFlightRequest {
+	mu.Lock()
+	traces := make([]*Trace, 0, len(inFlight))
+	for _, trace := range inFlight {
+		traces = append(traces, trace)
+	}
+	mu.Unlock()
+
+	requests := make([]InFlightRequest, 0, len(traces))
+	for _, trace := range traces {
+		requests = append(requests, InFlightRequest{
+			ID:        trace.ID,
+			Verb:      trace.Verb,
+			Path:      trace.Path,
+			User:      trace.User,
+			StartTime: trace.StartTime,
+			Elapsed:   time.Since(trace.StartTime).String(),
+			Spans:     trace.Spans(),
+		})
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].StartTime.Before(requests[j].StartTime)
+	})
+	return requests
+}
+
+type roundTripper struct {
+	backend string
+	next    http.RoundTripper
+}
+
+// InstrumentRoundTripper records calls through the transport as spans of
+// the request found in the outgoing request's context, and logs calls
+// slower than the backend threshold either way.
+func InstrumentRoundTripper(backend string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &roundTripper{backend: backend, next: next}
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+
+	detail := fmt.Sprintf("%s %s", req.Method, req.URL.Path)
+	FromContext(req.Context()).Record(rt.backend, detail, start)
+	if elapsed := time.Since(start); elapsed > slowBackendThreshold {
+		klog.Warningf("slow %s call: %s took %v", rt.backend, detail, elapsed)
+	}
+	return resp, err
+}
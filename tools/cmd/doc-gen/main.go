@@ -35,6 +35,7 @@ import (
 	"github.com/pkg/errors"
 	promfake "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned/fake"
 	urlruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/rest"
 	"k8s.io/klog"
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	"kubesphere.io/kubesphere/pkg/constants"
@@ -126,16 +127,16 @@ func generateSwaggerJson() []byte {
 	urlruntime.Must(monitoringv1alpha3.AddToContainer(container, clientsets.Kubernetes(), nil, nil, informerFactory))
 	urlruntime.Must(openpitrixv1.AddToContainer(container, informerFactory, fake.NewSimpleClientset(), nil))
 	urlruntime.Must(openpitrixv2.AddToContainer(container, informerFactory, fake.NewSimpleClientset(), nil))
-	urlruntime.Must(operationsv1alpha2.AddToContainer(container, clientsets.Kubernetes()))
+	urlruntime.Must(operationsv1alpha2.AddToContainer(container, clientsets.Kubernetes(), &rest.Config{}, nil, nil))
 	urlruntime.Must(resourcesv1alpha2.AddToContainer(container, clientsets.Kubernetes(), informerFactory, ""))
 	urlruntime.Must(resourcesv1alpha3.AddToContainer(container, informerFactory, nil))
-	urlruntime.Must(tenantv1alpha2.AddToContainer(container, informerFactory, nil, nil, nil, nil, nil, nil, nil, nil, nil))
-	urlruntime.Must(terminalv1alpha2.AddToContainer(container, clientsets.Kubernetes(), nil))
+	urlruntime.Must(tenantv1alpha2.AddToContainer(container, informerFactory, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil))
+	urlruntime.Must(terminalv1alpha2.AddToContainer(container, clientsets.Kubernetes(), nil, nil))
 	urlruntime.Must(metricsv1alpha2.AddToContainer(container))
-	urlruntime.Must(networkv1alpha2.AddToContainer(container, ""))
+	urlruntime.Must(networkv1alpha2.AddToContainer(container, "", ""))
 	alertingOptions := &alerting.Options{}
 	alertingClient, _ := alerting.NewRuleClient(alertingOptions)
-	urlruntime.Must(alertingv2alpha1.AddToContainer(container, informerFactory, promfake.NewSimpleClientset(), alertingClient, alertingOptions))
+	urlruntime.Must(alertingv2alpha1.AddToContainer(container, informerFactory, clientsets.Kubernetes(), promfake.NewSimpleClientset(), alertingClient, alertingOptions, nil))
 
 	config := restfulspec.Config{
 		WebServices:                   container.RegisteredWebServices(),